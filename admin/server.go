@@ -0,0 +1,197 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// Source provides the view of the last completed scaling cycle and the health of the scaling loop
+type Source interface {
+	Snapshot() (core.Snapshot, bool)
+	Liveness(now time.Time) api.HealthResponse
+	Readiness() api.HealthResponse
+}
+
+// Server serves the local admin HTTP endpoints
+type Server struct {
+	source  Source
+	metrics http.Handler
+	trigger func() bool // Requests an immediate cycle, see SetTrigger
+	server  *http.Server
+}
+
+// The status endpoints encode exactly the types of the public api package
+var _ []api.ASGStatus = core.Snapshot{}.ASGs
+
+// NewServer creates an admin server listening on the given address; GET /metrics is served when metrics is not nil
+func NewServer(listen string, source Source, metrics http.Handler) *Server {
+	s := &Server{source: source, metrics: metrics}
+	s.server = &http.Server{
+		Addr:              listen,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	return s
+}
+
+// Handler returns the HTTP handler with all admin routes
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /state", s.handleState)
+	mux.HandleFunc("GET /asgs", s.handleASGs)
+	mux.HandleFunc("GET /asgs/{name}", s.handleASG)
+	mux.HandleFunc("GET /healthz", s.handleHealthz)
+	mux.HandleFunc("GET /readyz", s.handleReadyz)
+	mux.HandleFunc("POST /trigger", s.handleTrigger)
+	if s.metrics != nil {
+		mux.Handle("GET /metrics", s.metrics)
+	}
+	return mux
+}
+
+// SetTrigger makes POST /trigger call trigger, which requests an immediate cycle and reports whether it was coalesced
+// with a pending one; call it before Start
+func (s *Server) SetTrigger(trigger func() bool) {
+	s.trigger = trigger
+}
+
+// Start binds the listen address and serves requests in the background
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", s.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.server.Addr, err)
+	}
+
+	slog.Info("Admin endpoints listening", "address", listener.Addr().String())
+	go func() {
+		if err := s.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("Admin server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// StartWithRetry starts the server like Start, but a failure to bind is not fatal: it is reported to
+// degraded and binding is retried every retryInterval in the background until it succeeds or ctx is done.
+// degraded is called with nil once the server is listening.
+func (s *Server) StartWithRetry(ctx context.Context, retryInterval time.Duration, degraded func(error)) {
+	err := s.Start()
+	degraded(err)
+	if err == nil {
+		return
+	}
+	slog.Error("Admin endpoints unavailable, retrying", "retry_interval", retryInterval, "error", err)
+
+	go func() {
+		ticker := time.NewTicker(retryInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if err := s.Start(); err != nil {
+					continue
+				}
+				slog.Info("Admin endpoints recovered")
+				degraded(nil)
+				return
+			}
+		}
+	}()
+}
+
+// Shutdown gracefully stops the admin server
+func (s *Server) Shutdown(ctx context.Context) error {
+	return s.server.Shutdown(ctx)
+}
+
+// handleState serves the last cluster state with its timestamp
+func (s *Server) handleState(w http.ResponseWriter, r *http.Request) {
+	snapshot, ok := s.source.Snapshot()
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: api.ErrNoCycle.Error()})
+		return
+	}
+	state, err := json.Marshal(snapshot.State)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, api.ErrorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, api.StateResponse{Timestamp: snapshot.Timestamp, State: state})
+}
+
+// handleASGs serves the last observed capacity and scaling decision of every ASG
+func (s *Server) handleASGs(w http.ResponseWriter, r *http.Request) {
+	snapshot, ok := s.source.Snapshot()
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: api.ErrNoCycle.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, snapshot.ASGs)
+}
+
+// handleASG serves the last observed capacity and scaling decision of a single ASG
+func (s *Server) handleASG(w http.ResponseWriter, r *http.Request) {
+	snapshot, ok := s.source.Snapshot()
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: api.ErrNoCycle.Error()})
+		return
+	}
+	name := r.PathValue("name")
+	for _, status := range snapshot.ASGs {
+		if status.Name == name {
+			writeJSON(w, http.StatusOK, status)
+			return
+		}
+	}
+	writeJSON(w, http.StatusNotFound, api.ErrorResponse{Error: fmt.Sprintf("%s: %s", api.ErrUnknownASG, name)})
+}
+
+// handleHealthz answers whether the scaling loop is alive, 503 when no cycle completed within 3 check-intervals
+func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.source.Liveness(time.Now()))
+}
+
+// handleReadyz answers whether the configuration is loaded, the providers are initialized and the last GitLab
+// fetch succeeded, 503 otherwise
+func (s *Server) handleReadyz(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, s.source.Readiness())
+}
+
+// handleTrigger requests an immediate scaling cycle, 503 when the scaling loop does not take triggers
+func (s *Server) handleTrigger(w http.ResponseWriter, r *http.Request) {
+	if s.trigger == nil {
+		writeJSON(w, http.StatusServiceUnavailable, api.ErrorResponse{Error: "immediate cycles are not available"})
+		return
+	}
+	writeJSON(w, http.StatusAccepted, api.TriggerResponse{Coalesced: s.trigger()})
+}
+
+// writeHealth writes the answer of a health endpoint, 503 with the failed preconditions when it is not ok
+func writeHealth(w http.ResponseWriter, health api.HealthResponse) {
+	status := http.StatusOK
+	if health.Status != api.HealthOK {
+		status = http.StatusServiceUnavailable
+	}
+	writeJSON(w, status, health)
+}
+
+// writeJSON writes body as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		slog.Error("Error encoding admin response", "error", err)
+	}
+}
@@ -0,0 +1,241 @@
+package admin
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// fakeSource returns a fixed snapshot and health
+type fakeSource struct {
+	snapshot  core.Snapshot
+	ok        bool
+	liveness  api.HealthResponse
+	readiness api.HealthResponse
+}
+
+func (f *fakeSource) Snapshot() (core.Snapshot, bool) {
+	return f.snapshot, f.ok
+}
+
+func (f *fakeSource) Liveness(time.Time) api.HealthResponse {
+	return f.liveness
+}
+
+func (f *fakeSource) Readiness() api.HealthResponse {
+	return f.readiness
+}
+
+// TestServer_BeforeFirstCycle verifies all endpoints answer 503 until a cycle completed
+func TestServer_BeforeFirstCycle(t *testing.T) {
+	handler := NewServer("127.0.0.1:0", &fakeSource{}, nil).Handler()
+
+	for _, path := range []string{"/state", "/asgs", "/asgs/test-asg"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusServiceUnavailable, rec.Code, path)
+	}
+}
+
+// TestServer_StateAndASGs verifies the last snapshot is served as JSON
+// Expected behavior:
+//   - GET /state returns the timestamp and per-tag counts
+//   - GET /asgs returns the decision and reason per ASG
+func TestServer_StateAndASGs(t *testing.T) {
+	timestamp := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	source := &fakeSource{ok: true, snapshot: core.Snapshot{
+		Timestamp: timestamp,
+		State: gitlab.ClusterState{
+			TotalPendingJobs:    3,
+			PendingJobsWithTags: map[string]int{"amd64": 3},
+		},
+		ASGs: []core.ASGStatus{{Name: "test-asg", Desired: 1, Allocated: 1, Proposed: 4,
+			Decision: core.DecisionScaleUp, Reason: "3 matching pending jobs, 0 free slots"}},
+	}}
+	handler := NewServer("127.0.0.1:0", source, nil).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/state", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var state struct {
+		Timestamp time.Time `json:"timestamp"`
+		State     struct {
+			TotalPendingJobs    int64          `json:"total_pending_jobs"`
+			PendingJobsWithTags map[string]int `json:"pending_jobs_with_tags"`
+		} `json:"state"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &state))
+	assert.Equal(t, timestamp, state.Timestamp)
+	assert.Equal(t, int64(3), state.State.TotalPendingJobs)
+	assert.Equal(t, 3, state.State.PendingJobsWithTags["amd64"])
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/asgs", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	var asgs []core.ASGStatus
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &asgs))
+	require.Len(t, asgs, 1)
+	assert.Equal(t, core.DecisionScaleUp, asgs[0].Decision)
+	assert.Equal(t, int64(4), asgs[0].Proposed)
+}
+
+// TestServer_APIClient verifies the public api client decodes what the server encodes
+// Expected behavior:
+//   - Status and Explain return the ASG statuses of the snapshot unchanged
+//   - Explain of an unmanaged ASG returns api.ErrUnknownASG
+//   - State returns the timestamp and the raw cluster state
+func TestServer_APIClient(t *testing.T) {
+	timestamp := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	statuses := []core.ASGStatus{
+		{Name: "runner-amd64", Provider: "aws", Desired: 1, Allocated: 1, Proposed: 3, Decision: core.DecisionScaleUp,
+			Reason: "3 matching pending jobs, 1 free slots", EvaluatedAt: timestamp, CadenceSeconds: 10},
+		{Name: "runner-arm64", Provider: "aws", Decision: core.DecisionBackedOff, CadenceSeconds: 40, ErrorStreak: 3},
+	}
+	source := &fakeSource{ok: true, snapshot: core.Snapshot{
+		Timestamp: timestamp,
+		State:     gitlab.ClusterState{TotalPendingJobs: 3},
+		ASGs:      statuses,
+	}}
+	server := httptest.NewServer(NewServer("127.0.0.1:0", source, nil).Handler())
+	defer server.Close()
+
+	client, err := api.NewClient(server.URL)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	all, err := client.Status(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, statuses, all)
+
+	one, err := client.Explain(ctx, "runner-arm64")
+	require.NoError(t, err)
+	assert.Equal(t, statuses[1], one)
+
+	_, err = client.Explain(ctx, "runner-gpu")
+	assert.ErrorIs(t, err, api.ErrUnknownASG)
+
+	state, err := client.State(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, timestamp, state.Timestamp)
+	var clusterState gitlab.ClusterState
+	require.NoError(t, json.Unmarshal(state.State, &clusterState))
+	assert.Equal(t, int64(3), clusterState.TotalPendingJobs)
+}
+
+// TestServer_Health verifies the probes answer the health of the source
+// Expected behavior:
+//   - GET /healthz and /readyz return 200 when the source is healthy, even before the first cycle
+//   - They return 503 with the failed preconditions and the last error otherwise
+func TestServer_Health(t *testing.T) {
+	errorAt := time.Date(2024, 5, 6, 9, 2, 0, 0, time.UTC)
+	source := &fakeSource{
+		liveness: api.HealthResponse{Status: api.HealthOK},
+		readiness: api.HealthResponse{
+			Status:      api.HealthUnavailable,
+			Failed:      []string{"last GitLab fetch failed"},
+			LastErrorAt: errorAt,
+			LastError:   "failed to fetch projects: connection refused",
+		},
+	}
+	handler := NewServer("127.0.0.1:0", source, nil).Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+	var readiness api.HealthResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &readiness))
+	assert.Equal(t, source.readiness, readiness)
+}
+
+// TestServer_Trigger verifies POST /trigger requests an immediate cycle
+// Expected behavior:
+//   - Without a trigger it answers 503
+//   - Each request calls the trigger and answers 202 with whether it was coalesced
+//   - GET is not allowed
+func TestServer_Trigger(t *testing.T) {
+	server := NewServer("127.0.0.1:0", &fakeSource{}, nil)
+	handler := server.Handler()
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trigger", nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	calls := 0
+	server.SetTrigger(func() bool {
+		calls++
+		return calls > 1
+	})
+	for _, coalesced := range []bool{false, true} {
+		rec = httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/trigger", nil))
+		require.Equal(t, http.StatusAccepted, rec.Code)
+		var response api.TriggerResponse
+		require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+		assert.Equal(t, coalesced, response.Coalesced)
+	}
+	assert.Equal(t, 2, calls)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/trigger", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// TestServer_StartWithRetry verifies an occupied port degrades the server until the port is released
+// Expected behavior:
+//   - The first bind fails and is reported as degraded
+//   - Once the port is free the retry loop binds it, clears the degraded state and serves requests
+func TestServer_StartWithRetry(t *testing.T) {
+	occupant, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := occupant.Addr().String()
+
+	var mu sync.Mutex
+	var reports []error
+	server := NewServer(addr, &fakeSource{}, nil)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	defer server.Shutdown(context.Background())
+
+	server.StartWithRetry(ctx, 10*time.Millisecond, func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		reports = append(reports, err)
+	})
+
+	mu.Lock()
+	require.Len(t, reports, 1)
+	assert.Error(t, reports[0])
+	mu.Unlock()
+
+	require.NoError(t, occupant.Close())
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return len(reports) == 2 && reports[1] == nil
+	}, 2*time.Second, 10*time.Millisecond)
+
+	resp, err := http.Get("http://" + addr + "/asgs")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/aws"
+)
+
+// asgDiscoverer is implemented by provider clients that find ASGs by a tag set on them
+type asgDiscoverer interface {
+	DiscoverASGs(ctx context.Context, tagKey, tagValue string) ([]aws.DiscoveredASG, error)
+}
+
+// discoverASGs adds the ASGs found by the asg-discovery of a provider to its asg-names. It runs at startup and
+// on every reload, with the client of the provider defaults, so a failure keeps the previous configuration.
+func discoverASGs(ctx context.Context, cfg *config.Config) error {
+	for providerName, providerCfg := range cfg.Providers {
+		if !providerCfg.AsgDiscovery.IsSet() {
+			continue
+		}
+		client := clientFor(providerCfg, config.Asg{}, providerRegion(providerCfg))
+		provider, err := newProviderClient(providerName, client, providerCfg)
+		if err != nil {
+			return err
+		}
+		discoverer, ok := provider.(asgDiscoverer)
+		if !ok {
+			return fmt.Errorf("provider %s does not support asg-discovery", providerName)
+		}
+		groups, err := discoverer.DiscoverASGs(ctx, providerCfg.AsgDiscovery.TagKey, providerCfg.AsgDiscovery.TagValue)
+		if err != nil {
+			return fmt.Errorf("provider %s: %w", providerName, err)
+		}
+		providerCfg.AsgNames = mergeDiscovered(providerName, providerCfg.AsgNames, groups)
+		cfg.Providers[providerName] = providerCfg
+	}
+	return nil
+}
+
+// mergeDiscovered appends the discovered groups to the configured ASGs. A group named in asg-names keeps the
+// configuration there; a group whose well-known tags are missing or invalid is skipped with a warning.
+func mergeDiscovered(providerName string, configured []config.Asg, groups []aws.DiscoveredASG) []config.Asg {
+	known := make(map[string]bool, len(configured))
+	for _, asg := range configured {
+		known[asg.Name] = true
+	}
+	merged := append([]config.Asg(nil), configured...)
+	for _, group := range groups {
+		if known[group.Name] {
+			continue
+		}
+		asg, err := config.DiscoveredAsg(group.Name, group.Tags)
+		if err != nil {
+			slog.Warn("Discovered ASG skipped", "asg", group.Name, "provider", providerName, "error", err)
+			continue
+		}
+		known[asg.Name] = true
+		merged = append(merged, asg)
+		slog.Info("Discovered ASG", "asg", asg.Name, "provider", providerName, "tags", asg.Tags,
+			"max_asg_capacity", asg.MaxAsgCapacity, "scale_to_zero", asg.ScaleToZero)
+	}
+	return merged
+}
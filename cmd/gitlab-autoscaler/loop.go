@@ -0,0 +1,133 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"reflect"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// loopState is what the scaling cycles of the main loop run with; a reload replaces it as a whole
+type loopState struct {
+	cfg           *config.Config
+	gitlabClient  *gitlab.Client
+	providers     map[string]core.Provider
+	asgToProvider map[string]string
+}
+
+// checkInterval returns the interval between the scheduled cycles of a configuration
+func (s loopState) checkInterval() time.Duration {
+	return time.Duration(s.cfg.Autoscaler.CheckInterval) * time.Second
+}
+
+// runLoop runs cycle right away, then on every tick of the check-interval and every trigger until ctx is done, and
+// returns the state in use by then. Reloaded states are taken only between cycles, so that a cycle never sees half
+// a configuration: apply swaps in what lives outside the loop, e.g. the providers of the orchestrator, and the
+// ticker is reset when the check-interval changed.
+func runLoop(ctx context.Context, state loopState, reloaded <-chan loopState, triggers <-chan struct{},
+	cycle func(context.Context, loopState), apply func(previous, next loopState)) loopState {
+	interval := state.checkInterval()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	cycle(ctx, state)
+	for {
+		select {
+		case <-ctx.Done():
+			return state
+		case <-ticker.C:
+			cycle(ctx, state)
+		case <-triggers:
+			cycle(ctx, state)
+		case next := <-reloaded:
+			apply(state, next)
+			if next.checkInterval() != interval {
+				slog.Info("check-interval changed", "previous", interval, "check_interval", next.checkInterval())
+				interval = next.checkInterval()
+				ticker.Reset(interval)
+			}
+			state = next
+		}
+	}
+}
+
+// applyReload swaps the providers and settings of a reloaded state into the orchestrator, logging what changed and
+// warning about the settings that only a restart applies
+func applyReload(orchestrator *core.Orchestrator, previous, next loopState) {
+	logConfigDiff(config.Compare(previous.cfg, next.cfg))
+	warnOverlappingTags(next.cfg)
+	if next.cfg.Admin.Listen != previous.cfg.Admin.Listen {
+		slog.Warn("admin.listen changed; restart to apply", "listen", next.cfg.Admin.Listen)
+	}
+	if !reflect.DeepEqual(next.cfg.Metrics, previous.cfg.Metrics) {
+		slog.Warn("metrics settings changed; restart to apply")
+	}
+	if next.cfg.Fleeting != previous.cfg.Fleeting {
+		slog.Warn("fleeting settings changed; restart to apply")
+	}
+	if next.cfg.Autoscaler.DryRun && !previous.cfg.Autoscaler.DryRun {
+		slog.Info("dry-run enabled: capacity changes are only logged")
+	} else if !next.cfg.Autoscaler.DryRun && previous.cfg.Autoscaler.DryRun {
+		slog.Info("dry-run disabled: capacity changes are applied again")
+	}
+
+	orchestrator.SetProviders(next.providers, next.asgToProvider)
+	// Reloads build all providers anew, so none of the previous ones is used any longer
+	closeProviders(previous.providers)
+	orchestrator.MigrateRenamedASGs(*next.cfg)
+	orchestrator.SetAuditLog(next.cfg.Autoscaler)
+}
+
+// closeProviders closes the providers holding connections, e.g. to a plugin
+func closeProviders(providers map[string]core.Provider) {
+	for name, provider := range providers {
+		if closer, ok := provider.(io.Closer); ok {
+			if err := closer.Close(); err != nil {
+				slog.Warn("Error closing provider", "provider", name, "error", err)
+			}
+		}
+	}
+}
+
+// warnOverlappingTags logs every tag served by several ASGs, unless overlapping-tags is set otherwise
+func warnOverlappingTags(cfg *config.Config) {
+	if cfg.Autoscaler.EffectiveOverlappingTags() != config.OverlappingTagsWarn {
+		return
+	}
+	message := "Tag served by several ASGs, which split its pending jobs by tag-sharing"
+	if cfg.Autoscaler.EffectiveTagSharing() == config.TagSharingDuplicate {
+		message = "Tag served by several ASGs, which all scale for its jobs"
+	}
+	for _, overlap := range cfg.TagOverlaps() {
+		slog.Warn(message, "tag", overlap.Tag, "asgs", overlap.ASGs, "tag_sharing", cfg.Autoscaler.EffectiveTagSharing())
+	}
+}
+
+// logConfigDiff logs the changes of a reload: one record per setting and per added, removed or modified ASG
+func logConfigDiff(diff config.Diff) {
+	if diff.IsEmpty() {
+		slog.Info("Config reloaded without changes")
+		return
+	}
+	for _, change := range diff.Changes {
+		slog.Info("Config setting changed", "setting", change.Path, "old", change.Old, "new", change.New)
+	}
+	for _, name := range diff.AddedASGs {
+		slog.Info("ASG added", "asg", name)
+	}
+	for _, name := range diff.RemovedASGs {
+		slog.Info("ASG removed", "asg", name)
+	}
+	for _, asg := range diff.ModifiedASGs {
+		changes := make([]string, len(asg.Changes))
+		for i, change := range asg.Changes {
+			changes[i] = change.String()
+		}
+		slog.Info("ASG changed", "asg", asg.Name, "changes", changes)
+	}
+}
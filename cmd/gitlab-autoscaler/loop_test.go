@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// closingProvider is a mock provider counting the calls of Close
+type closingProvider struct {
+	*mocks.MockProvider
+	closed int
+}
+
+func (p *closingProvider) Close() error {
+	p.closed++
+	return nil
+}
+
+// loopStateWithInterval returns a state whose configuration has the check-interval in seconds
+func loopStateWithInterval(seconds int) loopState {
+	return loopState{cfg: &config.Config{Autoscaler: config.AutoscalerConfig{CheckInterval: seconds}}}
+}
+
+// TestRunLoop_ReloadChangesInterval verifies a reload takes effect between cycles with its check-interval
+// Expected behavior:
+//   - The first cycle runs right away with the initial configuration
+//   - A reload from 1h to 1s is applied with the previous and next state, without running a cycle
+//   - The ticker is reset, so the next cycle runs within seconds with the reloaded configuration
+//   - The loop returns the reloaded state once ctx is done
+func TestRunLoop_ReloadChangesInterval(t *testing.T) {
+	initial, next := loopStateWithInterval(3600), loopStateWithInterval(1)
+	cycles := make(chan loopState, 10)
+	applied := make(chan [2]loopState, 1)
+	reloaded := make(chan loopState)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan loopState)
+
+	go func() {
+		done <- runLoop(ctx, initial, reloaded, nil,
+			func(_ context.Context, state loopState) { cycles <- state },
+			func(previous, next loopState) { applied <- [2]loopState{previous, next} })
+	}()
+
+	require.Same(t, initial.cfg, (<-cycles).cfg)
+	reloaded <- next
+	states := <-applied
+	assert.Same(t, initial.cfg, states[0].cfg)
+	assert.Same(t, next.cfg, states[1].cfg)
+
+	select {
+	case state := <-cycles:
+		assert.Same(t, next.cfg, state.cfg)
+	case <-time.After(3 * time.Second):
+		t.Fatal("no cycle after check-interval was reloaded to 1s")
+	}
+
+	cancel()
+	assert.Same(t, next.cfg, (<-done).cfg)
+}
+
+// TestRunLoop_Trigger verifies a trigger runs a cycle before the next tick
+// Expected behavior:
+//   - With a 1h check-interval, a trigger runs a second cycle right away
+func TestRunLoop_Trigger(t *testing.T) {
+	cycles := make(chan loopState, 10)
+	triggers := make(chan struct{}, 1)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	go runLoop(ctx, loopStateWithInterval(3600), nil, triggers,
+		func(_ context.Context, state loopState) { cycles <- state },
+		func(previous, next loopState) {})
+
+	<-cycles
+	triggers <- struct{}{}
+	select {
+	case <-cycles:
+	case <-time.After(time.Second):
+		t.Fatal("trigger did not run a cycle")
+	}
+}
+
+// TestApplyReload_ClosesPreviousProviders verifies a reload closes the connections of the providers it replaces
+// Expected behavior:
+//   - The previous provider holding a connection is closed once
+//   - The provider of the reloaded configuration stays open
+func TestApplyReload_ClosesPreviousProviders(t *testing.T) {
+	previous, next := loopStateWithInterval(10), loopStateWithInterval(10)
+	oldPlugin, newPlugin := &closingProvider{MockProvider: &mocks.MockProvider{}}, &closingProvider{MockProvider: &mocks.MockProvider{}}
+	previous.providers = map[string]core.Provider{"on-prem": oldPlugin, "aws": &mocks.MockProvider{}}
+	next.providers = map[string]core.Provider{"on-prem": newPlugin}
+	orchestrator := core.NewOrchestrator(previous.providers, nil, nil, nil)
+
+	applyReload(orchestrator, previous, next)
+
+	assert.Equal(t, 1, oldPlugin.closed)
+	assert.Equal(t, 0, newPlugin.closed)
+}
@@ -1,21 +1,38 @@
 package main
 
 import (
+	"cmp"
 	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"io/ioutil"
-	"log"
+	"log/slog"
+	"maps"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"syscall"
 	"time"
+	_ "time/tzdata" // schedule time zones must load in minimal images without zoneinfo
 
+	"github.com/shuliakovsky/gitlab-autoscaler/admin"
 	"github.com/shuliakovsky/gitlab-autoscaler/config"
 	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/faults"
+	"github.com/shuliakovsky/gitlab-autoscaler/fleeting"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/metrics"
 	"github.com/shuliakovsky/gitlab-autoscaler/providers/aws"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/azure"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/digitalocean"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/exec"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/gcp"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/k8s"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin"
+	"github.com/shuliakovsky/gitlab-autoscaler/utils"
 )
 
 // Version and CommitHash will be set during the build process
@@ -27,9 +44,22 @@ const (
 	systemPidPath    = "/var/run/gitlab-autoscaler.pid"
 	localConfigPath  = "./config.yml"
 	localPidPath     = "./gitlab-autoscaler.pid"
+
+	// adminRetryInterval is how often binding admin.listen is retried unless admin.required is set
+	adminRetryInterval = 30 * time.Second
+	// exitListenerUnavailable is the exit code when admin.required is set and admin.listen cannot be bound
+	exitListenerUnavailable = 3
 )
 
 func main() {
+	// Set when a --once cycle failed; deferred first, so that the process exits after every other deferred cleanup
+	exitCode := 0
+	defer func() {
+		if exitCode != 0 {
+			os.Exit(exitCode)
+		}
+	}()
+
 	// Flags: allow explicit override; resolution happens after parsing
 	configFlag := flag.String("config", "", "Path to the configuration file (explicit overrides discovery)")
 	flag.StringVar(configFlag, "c", "", "Alias for -config")
@@ -41,8 +71,20 @@ func main() {
 	flag.BoolVar(reloadFlag, "r", false, "Alias for -reload")
 	versionFlag := flag.Bool("version", false, "Display application version")
 	flag.BoolVar(versionFlag, "v", false, "Alias for -version")
+	allowFaultInjectionFlag := flag.Bool("allow-fault-injection", false, "Permit testing.fault-injection from the configuration (resilience testing only)")
+	onceFlag := flag.Bool("once", false, "Run a single cycle and exit, non-zero when it failed (no pidfile, admin server or reloads)")
+	dryRunFlag := flag.Bool("dry-run", false, "Log every scaling decision but never change capacity, whatever autoscaler.dry-run says")
+	logLevelFlag := flag.String("log-level", "info", "Log records of this level and above: debug, info, warn or error")
+	logFormatFlag := flag.String("log-format", utils.LogFormatText, "Log format: text or json")
+	noColorFlag := flag.Bool("no-color", false, "Never color text logs; they are only colored when stderr is a terminal anyway")
+	validateFlag := flag.Bool("validate", false, "Check the configuration, print the problems found and exit, non-zero when there are any")
+	strictFlag := flag.Bool("strict", false, "With --validate, also confirm the GitLab and cloud credentials with read-only calls")
 
 	flag.Parse()
+	if err := setupLogging(*logLevelFlag, *logFormatFlag, *noColorFlag); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
 	if *versionFlag {
 		fmt.Printf("gitlab-autoscaler version: %s\n", Version)
 		if CommitHash != "" {
@@ -63,104 +105,220 @@ func main() {
 	configPath := resolveConfigPath(*configFlag)
 	pidFile := resolvePidFilePath(*pidFileFlag)
 
+	if *strictFlag && !*validateFlag {
+		fmt.Fprintln(os.Stderr, "--strict requires --validate")
+		os.Exit(2)
+	}
+	// --validate writes no pidfile and, unless --strict, contacts neither GitLab nor the cloud
+	if *validateFlag {
+		if !validateConfig(os.Stdout, configPath, *strictFlag, *allowFaultInjectionFlag) {
+			exitCode = 1
+		}
+		return
+	}
+
 	// If -r: validate config first, then send SIGHUP to pidfile (or self)
 	if *reloadFlag {
 		cfg, err := config.Load(configPath)
 		if err != nil {
-			log.Fatalf("Failed to load config (%s): %v", configPath, err)
+			fatal("Failed to load config", "path", configPath, "error", err)
 		}
 		if err := cfg.Validate(); err != nil {
-			log.Fatalf("Config validation failed: %v", err)
+			fatal("Config validation failed", "error", err)
 		}
 
 		pid, err := readPidFile(pidFile)
 		if err != nil {
 			// pidfile not found — send SIGHUP to self
-			log.Printf("pidfile not found (%s), sending SIGHUP to self", pidFile)
+			slog.Info("pidfile not found, sending SIGHUP to self", "pidfile", pidFile)
 			pid = os.Getpid()
 		} else {
-			log.Printf("Sending SIGHUP to pid %d (pidfile: %s)", pid, pidFile)
+			slog.Info("Sending SIGHUP", "pid", pid, "pidfile", pidFile)
 		}
 
 		if err := sendHUPToPID(pid); err != nil {
-			log.Fatalf("Failed to send SIGHUP to pid %d: %v", pid, err)
+			fatal("Failed to send SIGHUP", "pid", pid, "error", err)
 		}
-		log.Printf("Reload signal sent successfully")
+		slog.Info("Reload signal sent successfully")
 		return
 	}
 
-	// Normal start: write pidfile
-	if err := writePidFile(pidFile); err != nil {
-		log.Fatalf("Failed to write pidfile: %v", err)
+	// Normal start: write pidfile; a single cycle cannot be signaled to reload, so it has none
+	if !*onceFlag {
+		if err := writePidFile(pidFile); err != nil {
+			fatal("Failed to write pidfile", "pidfile", pidFile, "error", err)
+		}
+		defer func() {
+			_ = os.Remove(pidFile)
+		}()
 	}
-	defer func() {
-		_ = os.Remove(pidFile)
-	}()
 
 	// Load and validate config
+	loadedHash := configHash(configPath)
 	cfg, err := config.Load(configPath)
 	if err != nil {
-		log.Fatalf("Failed to load config (%s): %v", configPath, err)
+		fatal("Failed to load config", "path", configPath, "error", err)
 	}
 	if err := cfg.Validate(); err != nil {
-		log.Fatalf("Invalid configuration: %v", err)
+		fatal("Invalid configuration", "error", err)
+	}
+	if err := faults.Guard(cfg.Testing.FaultInjection, *allowFaultInjectionFlag); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	if err := discoverASGs(context.Background(), cfg); err != nil {
+		fatal("Failed to discover ASGs", "error", err)
+	}
+	// Discovered ASGs may serve the tags of configured ones
+	if err := cfg.CheckOverlappingTags(); err != nil {
+		fatal("Invalid configuration", "error", err)
+	}
+	warnOverlappingTags(cfg)
+	cfg.Autoscaler.DryRun = cfg.Autoscaler.DryRun || *dryRunFlag
+
+	config.PrintConfiguration(cfg, Version, CommitHash)
+
+	// The orchestrator and the GitLab clients, also those of reloads, publish into the registry
+	registry := metrics.NewRegistry(cfg.Metrics)
+	gitlabClient, err := gitlab.NewClient(cfg.GitLab, registry)
+	if err != nil {
+		fatal("Failed to configure GitLab client", "error", err)
+	}
+	if cfg.GitLab.CleanupOfflineRunners {
+		if err := gitlabClient.CheckRunnerCleanupAccess(cfg.GitLab.Group); err != nil {
+			fatal("Offline runner cleanup requires an admin or group owner token", "error", err)
+		}
 	}
 
 	// Build initial providers and asg mapping (keeps original behavior)
 	providers, asgToProvider, err := buildProvidersFromConfig(cfg)
 	if err != nil {
-		log.Fatalf("Failed to build providers: %v", err)
+		fatal("Failed to build providers", "error", err)
+	}
+	providers = applyFaultInjection(cfg, gitlabClient, providers)
+
+	orchestrator := core.NewOrchestrator(providers, asgToProvider, nil, registry)
+	orchestrator.Subscribe(registry.ObserveSnapshot)
+	orchestrator.SetRunnerController(gitlab.NewRunnerControl(gitlabClient, cfg.GitLab.Group))
+	orchestrator.ConfigLoaded(time.Now(), loadedHash)
+	if err := orchestrator.LoadDemandHistory(cfg.Autoscaler); err != nil {
+		slog.Warn("Demand history not loaded, learning starts over", "error", err)
+	}
+	orchestrator.MigrateRenamedASGs(*cfg)
+	orchestrator.SetAuditLog(cfg.Autoscaler)
+	defer orchestrator.CloseAuditLog()
+
+	if cfg.Fleeting.IsSet() {
+		publisher := fleeting.NewPublisher(cfg.Fleeting)
+		if err := publisher.Start(); err != nil {
+			fatal("Failed to start fleeting targets", "error", err)
+		}
+		defer publisher.Shutdown()
+		orchestrator.SetPublisher(config.ExportFleeting, publisher)
 	}
 
-	orchestrator := core.NewOrchestrator(providers, asgToProvider)
+	if *onceFlag {
+		if err := runOnce(cfg, gitlabClient, orchestrator); err != nil {
+			slog.Error("Cycle failed", "error", err)
+			exitCode = 1
+		}
+		return
+	}
 
 	// Context and signal handling
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
+	if cfg.Metrics.Listen != "" {
+		metricsServer, err := registry.Start(cfg.Metrics.Listen)
+		if err != nil {
+			fatal("Failed to start metrics server", "error", err)
+		}
+		defer metricsServer.Shutdown(context.Background())
+	}
+
+	trigger := newCycleTrigger()
+
+	if cfg.Admin.Listen != "" {
+		adminServer := admin.NewServer(cfg.Admin.Listen, orchestrator, registry.Handler())
+		adminServer.SetTrigger(func() bool { return trigger.request("admin") })
+		if cfg.Admin.Required {
+			if err := adminServer.Start(); err != nil {
+				slog.Error("Failed to start admin server", "error", err)
+				os.Exit(exitListenerUnavailable)
+			}
+		} else {
+			adminServer.StartWithRetry(ctx, adminRetryInterval, func(err error) {
+				orchestrator.SetDegraded("admin-listener", err)
+			})
+		}
+		defer adminServer.Shutdown(context.Background())
+	}
+
 	sigCh := make(chan os.Signal, 1)
-	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+	signal.Notify(sigCh, syscall.SIGHUP, syscall.SIGUSR2, syscall.SIGINT, syscall.SIGTERM)
+
+	// Reloads run one at a time on their own worker; a SIGHUP burst coalesces into at most one pending reload.
+	// A failed reload keeps the previous configuration and reports config-reload degraded until a reload succeeds.
+	reloaded := make(chan loopState)
+	reloads := newReloader(tracked(configPath, orchestrator, func() (func(), error) {
+		newCfg, err := config.Load(configPath)
+		if err != nil {
+			return nil, fmt.Errorf("config load failed: %w", err)
+		}
+		if err := newCfg.Validate(); err != nil {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		if err := faults.Guard(newCfg.Testing.FaultInjection, *allowFaultInjectionFlag); err != nil {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		if err := discoverASGs(ctx, newCfg); err != nil {
+			return nil, fmt.Errorf("ASG discovery failed: %w", err)
+		}
+		if err := newCfg.CheckOverlappingTags(); err != nil {
+			return nil, fmt.Errorf("config validation failed: %w", err)
+		}
+		newCfg.Autoscaler.DryRun = newCfg.Autoscaler.DryRun || *dryRunFlag
+
+		newGitlabClient, err := gitlab.NewClient(newCfg.GitLab, registry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure GitLab client for new config: %w", err)
+		}
+		if newCfg.GitLab.CleanupOfflineRunners {
+			if err := newGitlabClient.CheckRunnerCleanupAccess(newCfg.GitLab.Group); err != nil {
+				return nil, fmt.Errorf("offline runner cleanup requires an admin or group owner token: %w", err)
+			}
+		}
+
+		// Build new providers (initialization happens here)
+		newProviders, newAsgToProvider, err := buildProvidersFromConfig(newCfg)
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize providers for new config: %w", err)
+		}
+		newProviders = applyFaultInjection(newCfg, newGitlabClient, newProviders)
+
+		next := loopState{cfg: newCfg, gitlabClient: newGitlabClient, providers: newProviders, asgToProvider: newAsgToProvider}
+		return func() {
+			// The main loop swaps the configuration in between two cycles
+			select {
+			case reloaded <- next:
+			case <-ctx.Done():
+			}
+		}, nil
+	}))
+	go reloads.run(ctx)
 
 	go func() {
-		// debounce: not more often than once per second
-		var lastReload time.Time
-		minInterval := time.Second
 		for {
 			select {
 			case s := <-sigCh:
 				switch s {
 				case syscall.SIGHUP:
-					if time.Since(lastReload) < minInterval {
-						log.Printf("Reload suppressed (debounce)")
-						continue
-					}
-					lastReload = time.Now()
-					log.Printf("Received SIGHUP: reloading config")
-					newCfg, err := config.Load(configPath)
-					if err != nil {
-						log.Printf("Config load failed: %v", err)
-						continue
-					}
-					if err := newCfg.Validate(); err != nil {
-						log.Printf("Config validation failed: %v", err)
-						continue
-					}
-
-					// Build new providers (initialization happens here)
-					newProviders, newAsgToProvider, err := buildProvidersFromConfig(newCfg)
-					if err != nil {
-						log.Printf("Failed to initialize providers for new config: %v", err)
-						continue
-					}
-
-					// Atomically swap providers in orchestrator
-					orchestrator.SetProviders(newProviders, newAsgToProvider)
-					// Update cfg used by ticker loop below
-					cfg = newCfg
-
-					log.Printf("Config reloaded successfully")
+					generation := reloads.request()
+					slog.Info("Received SIGHUP: reload requested", "generation", generation)
+				case syscall.SIGUSR2:
+					trigger.request("SIGUSR2")
 				case syscall.SIGINT, syscall.SIGTERM:
-					log.Printf("Shutdown signal received")
+					slog.Info("Shutdown signal received")
 					cancel()
 					return
 				}
@@ -170,21 +328,20 @@ func main() {
 		}
 	}()
 
-	// Main loop
-	ticker := time.NewTicker(time.Duration(cfg.Autoscaler.CheckInterval) * time.Second)
-	defer ticker.Stop()
-
-	core.Run(cfg, orchestrator)
-
-	for {
-		select {
-		case <-ctx.Done():
-			log.Printf("Exiting")
-			return
-		case <-ticker.C:
-			core.Run(cfg, orchestrator)
-		}
+	// Main loop; canceling ctx on SIGINT or SIGTERM aborts the provider calls of a cycle in flight
+	initial := loopState{cfg: cfg, gitlabClient: gitlabClient, providers: providers, asgToProvider: asgToProvider}
+	final := runLoop(ctx, initial, reloaded, trigger.pending,
+		func(ctx context.Context, state loopState) {
+			core.Run(ctx, state.cfg, state.gitlabClient, orchestrator)
+		},
+		func(previous, next loopState) {
+			applyReload(orchestrator, previous, next)
+		})
+	if err := orchestrator.SaveDemandHistory(final.cfg.Autoscaler); err != nil {
+		slog.Error("Error saving demand history", "error", err)
 	}
+	closeProviders(final.providers)
+	slog.Info("Exiting")
 }
 
 func printHelp() {
@@ -192,10 +349,54 @@ func printHelp() {
 	fmt.Println("  -c, --config <path>       Specify the path to the configuration file")
 	fmt.Println("  -p, --pid-file <path>     Path to pidfile")
 	fmt.Println("  -r, --reload              Validate config and signal the running process to reload and apply updated configuration")
+	fmt.Println("  --allow-fault-injection   Permit testing.fault-injection from the configuration (resilience testing only)")
+	fmt.Println("  --dry-run                 Log every scaling decision but never change capacity")
+	fmt.Println("  --validate                Check the configuration and exit, non-zero when it has problems; no cloud API is called")
+	fmt.Println("  --strict                  With --validate, also confirm the GitLab and cloud credentials with read-only calls")
+	fmt.Println("  --once                    Run a single cycle and exit, non-zero when fetching from GitLab or scaling an ASG failed")
+	fmt.Println("  --log-level <level>       Log records of this level and above: debug, info (default), warn or error")
+	fmt.Println("  --log-format <format>     Log format: text (default) or json")
+	fmt.Println("  --no-color                Never color text logs; they are only colored when stderr is a terminal anyway")
 	fmt.Println("  -v, --version             Display application version")
 	fmt.Println("  -h, --help                Show help message")
 }
 
+// runOnce runs the single cycle of --once; SIGINT and SIGTERM abort it cleanly. The learned demand is saved as on
+// shutdown, so that cron-driven runs keep learning.
+func runOnce(cfg *config.Config, gitlabClient *gitlab.Client, orchestrator *core.Orchestrator) error {
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	err := core.Run(ctx, cfg, gitlabClient, orchestrator)
+	if saveErr := orchestrator.SaveDemandHistory(cfg.Autoscaler); saveErr != nil {
+		slog.Error("Error saving demand history", "error", saveErr)
+	}
+	if ctx.Err() != nil {
+		return errors.Join(errors.New("cycle interrupted"), err)
+	}
+	return err
+}
+
+// setupLogging installs the logger of the --log-level, --log-format and --no-color flags. Text logs are colored by
+// level only when stderr is a terminal, so that journald and log files get plain lines.
+func setupLogging(level, format string, noColor bool) error {
+	logLevel, err := utils.ParseLogLevel(level)
+	if err != nil {
+		return err
+	}
+	return utils.SetupLogging(os.Stderr, utils.LogOptions{
+		Level:  logLevel,
+		Format: format,
+		Color:  !noColor && utils.IsTerminal(os.Stderr),
+	})
+}
+
+// fatal logs an error that keeps the autoscaler from starting and exits with code 1
+func fatal(msg string, args ...any) {
+	slog.Error(msg, args...)
+	os.Exit(1)
+}
+
 // resolveConfigPath chooses config path by priority: explicit -> system if exists -> local
 func resolveConfigPath(explicit string) string {
 	if explicit != "" {
@@ -247,6 +448,158 @@ func sendHUPToPID(pid int) error {
 	return syscall.Kill(pid, syscall.SIGHUP)
 }
 
+// applyFaultInjection wraps the GitLab client and providers with fault injection when the configuration enables it
+func applyFaultInjection(cfg *config.Config, client *gitlab.Client, providers map[string]core.Provider) map[string]core.Provider {
+	fi := cfg.Testing.FaultInjection
+	if !fi.Enabled {
+		return providers
+	}
+	slog.Warn("Fault injection is enabled. Never run this in production!", "seed", fi.Seed,
+		"gitlab_fetch_error", fi.GitLabFetchError, "provider_describe_error", fi.ProviderDescribeError,
+		"provider_update_error", fi.ProviderUpdateError, "latency", fi.Latency, "latency_probability", fi.LatencyProbability)
+	injector := faults.NewInjector(fi)
+	client.WrapTransport(injector.Transport)
+	return injector.Providers(providers)
+}
+
+// providerClient is what a client of a provider is bound to; ASGs with the same region and roles share a client
+type providerClient struct {
+	region string
+	roles  aws.Roles
+}
+
+// clientFor returns the region and roles of the client serving the ASG: the ones of the provider unless the ASG
+// sets its own region, role-arn, external-id or role-session-name
+func clientFor(providerCfg config.ProviderConfig, asg config.Asg, defaultRegion string) providerClient {
+	roles := aws.Roles{
+		Read:        cmp.Or(providerCfg.ReadRoleARN, providerCfg.RoleARN),
+		Write:       cmp.Or(providerCfg.WriteRoleARN, providerCfg.RoleARN),
+		ExternalID:  cmp.Or(asg.ExternalID, providerCfg.ExternalID),
+		SessionName: cmp.Or(asg.RoleSessionName, providerCfg.RoleSessionName),
+	}
+	if asg.RoleARN != "" {
+		roles.Read, roles.Write = asg.RoleARN, asg.RoleARN
+	}
+	return providerClient{region: cmp.Or(asg.Region, defaultRegion), roles: roles}
+}
+
+// name is the name of the client as a provider: the configured name for the client of the provider defaults,
+// suffixed with the region and the read role where they differ from the defaults
+func (c providerClient) name(providerName string, defaults providerClient) string {
+	name := providerName
+	if c.region != defaults.region {
+		name += "/" + c.region
+	}
+	if c.roles.Read != defaults.roles.Read {
+		name += "/" + c.roles.Read
+	}
+	return name
+}
+
+// newProviderClient creates the client of the provider for a region and roles, or of its plugin
+func newProviderClient(providerName string, client providerClient, providerCfg config.ProviderConfig) (core.Provider, error) {
+	if providerCfg.Plugin.IsSet() {
+		return plugin.NewPluginClient(providerName, plugin.Options{
+			Address:        providerCfg.Plugin.Address,
+			RequestTimeout: providerCfg.EffectiveRequestTimeout(),
+		})
+	}
+	switch providerCfg.EffectiveType(providerName) {
+	case config.ProviderAWS:
+		provider, err := aws.NewAWSClient(client.region, client.roles,
+			aws.Options{
+				ManageMinMax:              providerCfg.ManageMinMax,
+				CountUnhealthyAsAllocated: providerCfg.CountUnhealthyAsAllocated,
+				TagScalingEvents:          providerCfg.TagScalingEvents,
+				MaxRetries:                providerCfg.EffectiveMaxRetries(),
+				RequestTimeout:            providerCfg.EffectiveRequestTimeout(),
+				Profile:                   providerCfg.Profile,
+				EndpointURL:               providerCfg.EndpointURL,
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client for region %s: %w", providerName, client.region, err)
+		}
+		return provider, nil
+	case config.ProviderGCP:
+		provider, err := gcp.NewGCPClient(client.region,
+			gcp.Options{
+				Project:         providerCfg.Project,
+				Zone:            providerCfg.DefaultZone,
+				CredentialsFile: providerCfg.CredentialsFile,
+				EndpointURL:     providerCfg.EndpointURL,
+				RequestTimeout:  providerCfg.EffectiveRequestTimeout(),
+			})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client for %s: %w", providerName, cmp.Or(providerCfg.DefaultZone, client.region), err)
+		}
+		return provider, nil
+	case config.ProviderAzure:
+		provider, err := azure.NewAzureClient(azure.Options{
+			SubscriptionID: providerCfg.SubscriptionID,
+			ResourceGroup:  providerCfg.ResourceGroup,
+			TenantID:       providerCfg.TenantID,
+			ClientID:       providerCfg.ClientID,
+			ClientSecret:   providerCfg.ClientSecret,
+			RequestTimeout: providerCfg.EffectiveRequestTimeout(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client for resource group %s: %w", providerName, providerCfg.ResourceGroup, err)
+		}
+		return provider, nil
+	case config.ProviderK8s:
+		provider, err := k8s.NewK8sClient(k8s.Options{
+			Kubeconfig:     providerCfg.Kubeconfig,
+			RequestTimeout: providerCfg.EffectiveRequestTimeout(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client: %w", providerName, err)
+		}
+		return provider, nil
+	case config.ProviderDigitalOcean:
+		pools := make(map[string]digitalocean.Pool, len(providerCfg.AsgNames))
+		for _, asg := range providerCfg.AsgNames {
+			pools[asg.Name] = digitalocean.Pool{
+				Size:     asg.Droplet.Size,
+				Image:    asg.Droplet.Image,
+				UserData: asg.Droplet.UserData,
+				SSHKeys:  asg.Droplet.SSHKeys,
+				VPCUUID:  asg.Droplet.VPCUUID,
+			}
+		}
+		provider, err := digitalocean.NewDigitalOceanClient(client.region, digitalocean.Options{
+			Token:          providerCfg.Token,
+			EndpointURL:    providerCfg.EndpointURL,
+			RequestTimeout: providerCfg.EffectiveRequestTimeout(),
+			Pools:          pools,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client for region %s: %w", providerName, client.region, err)
+		}
+		return provider, nil
+	case config.ProviderExec:
+		provider, err := exec.NewExecClient(exec.Options{
+			GetCapacityCmd: providerCfg.GetCapacityCmd,
+			SetCapacityCmd: providerCfg.SetCapacityCmd,
+			GetCapacityURL: providerCfg.GetCapacityURL,
+			SetCapacityURL: providerCfg.SetCapacityURL,
+			Token:          providerCfg.Token,
+			RequestTimeout: providerCfg.EffectiveRequestTimeout(),
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize %s client: %w", providerName, err)
+		}
+		return provider, nil
+	default:
+		return nil, fmt.Errorf("unsupported provider '%s'", providerName)
+	}
+}
+
+// providerRegion returns the region of the ASGs of a provider that do not set their own: the one of the provider,
+// then AWS_REGION, then us-east-1
+func providerRegion(providerCfg config.ProviderConfig) string {
+	return cmp.Or(providerCfg.Region, os.Getenv("AWS_REGION"), "us-east-1")
+}
+
 func buildProvidersFromConfig(cfg *config.Config) (map[string]core.Provider, map[string]string, error) {
 	providers := make(map[string]core.Provider)
 	asgToProvider := make(map[string]string)
@@ -256,27 +609,31 @@ func buildProvidersFromConfig(cfg *config.Config) (map[string]core.Provider, map
 			continue
 		}
 
-		defaultRegion := providerCfg.Region
-		if defaultRegion == "" {
-			defaultRegion = os.Getenv("AWS_REGION")
-			if defaultRegion == "" {
-				defaultRegion = "us-east-1"
+		defaultRegion := providerRegion(providerCfg)
+
+		// One client per region and roles: ASGs with a region or role of their own are routed to a client of
+		// their own, shared with the ASGs of the same region and roles
+		defaults := clientFor(providerCfg, config.Asg{}, defaultRegion)
+		clients := make(map[string]providerClient)
+		for _, asg := range providerCfg.AsgNames {
+			client := clientFor(providerCfg, asg, defaultRegion)
+			name := client.name(providerName, defaults)
+			if existing, ok := clients[name]; ok && existing != client {
+				return nil, nil, fmt.Errorf("ASG %s: the ASGs of %s must use the same external-id and role-session-name", asg.Name, name)
 			}
+			clients[name] = client
+			if owner, ok := asgToProvider[asg.Name]; ok {
+				// Validate rejects names listed twice; discovered ASGs can still collide with another entry
+				return nil, nil, fmt.Errorf("ASG %s is served by both %s and %s", asg.Name, owner, name)
+			}
+			asgToProvider[asg.Name] = name
 		}
-
-		switch strings.ToLower(providerName) {
-		case "aws":
-			client, err := aws.NewAWSClient(defaultRegion)
+		for _, name := range slices.Sorted(maps.Keys(clients)) {
+			provider, err := newProviderClient(providerName, clients[name], providerCfg)
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to initialize %s client: %w", providerName, err)
+				return nil, nil, err
 			}
-			providers[providerName] = client
-		default:
-			return nil, nil, fmt.Errorf("unsupported provider '%s'", providerName)
-		}
-
-		for _, asg := range providerCfg.AsgNames {
-			asgToProvider[asg.Name] = providerName
+			providers[name] = provider
 		}
 	}
 
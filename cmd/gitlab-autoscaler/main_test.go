@@ -0,0 +1,211 @@
+package main
+
+import (
+	"context"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/aws"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin"
+)
+
+// TestBuildProvidersFromConfig_Regions verifies ASGs are routed to a client of their own region
+// Expected behavior:
+//   - ASGs without a region and with the provider region share the "aws" client of eu-west-1
+//   - The ASG in us-east-1 is routed to a separate "aws/us-east-1" client bound to us-east-1
+func TestBuildProvidersFromConfig_Regions(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	cfg := &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"aws": {
+				Region: "eu-west-1",
+				AsgNames: []config.Asg{
+					{Name: "runners-default"},
+					{Name: "runners-eu", Region: "eu-west-1"},
+					{Name: "runners-us", Region: "us-east-1"},
+				},
+			},
+		},
+	}
+
+	providers, asgToProvider, err := buildProvidersFromConfig(cfg)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"runners-default": "aws",
+		"runners-eu":      "aws",
+		"runners-us":      "aws/us-east-1",
+	}, asgToProvider)
+	require.Len(t, providers, 2)
+
+	defaultClient, ok := providers["aws"].(*aws.AWSClient)
+	require.True(t, ok)
+	regionalClient, ok := providers["aws/us-east-1"].(*aws.AWSClient)
+	require.True(t, ok)
+	assert.NotSame(t, defaultClient, regionalClient)
+	assert.Equal(t, "eu-west-1", defaultClient.Region())
+	assert.Equal(t, "us-east-1", regionalClient.Region())
+}
+
+// TestBuildProvidersFromConfig_NamedAWS verifies entries of type aws get AWS clients of their own
+// Expected behavior:
+//   - aws-prod and aws-dev are AWS clients bound to their own regions and serve their own ASGs
+//   - An ASG served by two entries, e.g. after discovery, is rejected
+func TestBuildProvidersFromConfig_NamedAWS(t *testing.T) {
+	t.Setenv("AWS_CONFIG_FILE", "/dev/null")
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", "/dev/null")
+	cfg := &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"aws-prod": {Type: "aws", Region: "eu-west-1", AsgNames: []config.Asg{{Name: "prod-runners"}}},
+			"aws-dev":  {Type: "AWS", Region: "eu-central-1", AsgNames: []config.Asg{{Name: "dev-runners"}}},
+		},
+	}
+
+	providers, asgToProvider, err := buildProvidersFromConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"prod-runners": "aws-prod", "dev-runners": "aws-dev"}, asgToProvider)
+	prod, ok := providers["aws-prod"].(*aws.AWSClient)
+	require.True(t, ok)
+	dev, ok := providers["aws-dev"].(*aws.AWSClient)
+	require.True(t, ok)
+	assert.Equal(t, "eu-west-1", prod.Region())
+	assert.Equal(t, "eu-central-1", dev.Region())
+
+	devCfg := cfg.Providers["aws-dev"]
+	devCfg.AsgNames = append(devCfg.AsgNames, config.Asg{Name: "prod-runners"})
+	cfg.Providers["aws-dev"] = devCfg
+	_, _, err = buildProvidersFromConfig(cfg)
+	assert.ErrorContains(t, err, "ASG prod-runners is served by both")
+}
+
+// TestClientFor_Roles verifies the roles of the client serving an ASG and the provider name of the client
+// Expected behavior:
+//   - role-arn of the provider is used for reads and writes unless read-role-arn or write-role-arn are set
+//   - role-arn of an ASG replaces both roles, and its client is named after the role
+//   - external-id and role-session-name of an ASG override the ones of the provider
+func TestClientFor_Roles(t *testing.T) {
+	providerCfg := config.ProviderConfig{
+		RoleARN:      "arn:aws:iam::111111111111:role/autoscaler",
+		WriteRoleARN: "arn:aws:iam::111111111111:role/autoscaler-write",
+		ExternalID:   "provider-id",
+	}
+	defaults := clientFor(providerCfg, config.Asg{}, "eu-west-1")
+	assert.Equal(t, aws.Roles{
+		Read:       "arn:aws:iam::111111111111:role/autoscaler",
+		Write:      "arn:aws:iam::111111111111:role/autoscaler-write",
+		ExternalID: "provider-id",
+	}, defaults.roles)
+	assert.Equal(t, "aws", defaults.name("aws", defaults))
+
+	client := clientFor(providerCfg, config.Asg{
+		Name:            "runners-other-account",
+		Region:          "us-east-1",
+		RoleARN:         "arn:aws:iam::222222222222:role/autoscaler",
+		ExternalID:      "asg-id",
+		RoleSessionName: "runners",
+	}, "eu-west-1")
+	assert.Equal(t, providerClient{region: "us-east-1", roles: aws.Roles{
+		Read:        "arn:aws:iam::222222222222:role/autoscaler",
+		Write:       "arn:aws:iam::222222222222:role/autoscaler",
+		ExternalID:  "asg-id",
+		SessionName: "runners",
+	}}, client)
+	assert.Equal(t, "aws/us-east-1/arn:aws:iam::222222222222:role/autoscaler", client.name("aws", defaults))
+}
+
+// TestBuildProvidersFromConfig_ConflictingRoles verifies ASGs of one client may not differ in external-id,
+// which would otherwise silently use the external-id of one of them, before any role is assumed
+func TestBuildProvidersFromConfig_ConflictingRoles(t *testing.T) {
+	cfg := &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"aws": {
+				Region:  "eu-west-1",
+				RoleARN: "arn:aws:iam::111111111111:role/autoscaler",
+				AsgNames: []config.Asg{
+					{Name: "runners-a", ExternalID: "id-a"},
+					{Name: "runners-b", ExternalID: "id-b"},
+				},
+			},
+		},
+	}
+
+	_, _, err := buildProvidersFromConfig(cfg)
+	assert.ErrorContains(t, err, "ASG runners-b: the ASGs of aws must use the same external-id and role-session-name")
+}
+
+// TestMergeDiscovered verifies discovered ASGs are added to the configured ones
+// Expected behavior:
+//   - A discovered group named in asg-names keeps its configured settings
+//   - A group with valid well-known tags is added, one with missing tags is skipped
+func TestMergeDiscovered(t *testing.T) {
+	configured := []config.Asg{{Name: "team-a-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 2}}
+	groups := []aws.DiscoveredASG{
+		{Name: "team-a-runners", Tags: map[string]string{config.DiscoveryTagTags: "arm64", config.DiscoveryTagMaxCapacity: "9"}},
+		{Name: "team-b-runners", Tags: map[string]string{config.DiscoveryTagTags: "team-b", config.DiscoveryTagMaxCapacity: "4"}},
+		{Name: "team-c-runners", Tags: map[string]string{config.DiscoveryTagTags: "team-c"}},
+	}
+
+	merged := mergeDiscovered("aws", configured, groups)
+
+	assert.Equal(t, []config.Asg{
+		{Name: "team-a-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 2},
+		{Name: "team-b-runners", Tags: []string{"team-b"}, MaxAsgCapacity: 4},
+	}, merged)
+	assert.Len(t, configured, 1)
+}
+
+// staticProvider reports a fixed capacity for every ASG and records the capacity set last
+type staticProvider struct {
+	capacity int64
+}
+
+func (p *staticProvider) GetCurrentCapacity(context.Context, string) (int64, int64, error) {
+	return p.capacity, p.capacity, nil
+}
+
+func (p *staticProvider) UpdateASGCapacity(_ context.Context, _ string, capacity int64) error {
+	p.capacity = capacity
+	return nil
+}
+
+// TestBuildProvidersFromConfig_Plugin verifies a provider entry with a plugin is served by the plugin
+// Expected behavior:
+//   - The entry, named freely, becomes a provider calling the plugin served in process on a unix socket
+//   - Capacity changes reach the plugin
+func TestBuildProvidersFromConfig_Plugin(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	backend := &staticProvider{capacity: 2}
+	server := plugin.NewServer("static", "v1", backend)
+	go func() { _ = server.Serve(listener) }()
+	defer server.Stop()
+
+	cfg := &config.Config{
+		Providers: map[string]config.ProviderConfig{
+			"proxmox": {
+				Plugin:         config.PluginConfig{Address: "unix://" + socket},
+				RequestTimeout: 5 * time.Second,
+				AsgNames:       []config.Asg{{Name: "runners"}},
+			},
+		},
+	}
+
+	providers, asgToProvider, err := buildProvidersFromConfig(cfg)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"runners": "proxmox"}, asgToProvider)
+
+	allocated, desired, err := providers["proxmox"].GetCurrentCapacity(context.Background(), "runners")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(2), desired)
+	require.NoError(t, providers["proxmox"].UpdateASGCapacity(context.Background(), "runners", 3))
+	assert.Equal(t, int64(3), backend.capacity)
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"os"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// reloader serializes configuration reloads through a single worker. A burst of requests
+// coalesces into at most one pending reload, and every request gets a generation number so a
+// reload that finishes after a newer one was applied is discarded instead of rolling back.
+type reloader struct {
+	pending   chan struct{}
+	requested atomic.Uint64 // Generation of the newest reload request
+	mu        sync.Mutex
+	applied   uint64 // Generation of the configuration in use
+	// prepare loads the configuration and builds what it needs without touching running state;
+	// the returned function swaps it in
+	prepare func() (func(), error)
+}
+
+// newReloader creates a reloader; call run to start its worker
+func newReloader(prepare func() (func(), error)) *reloader {
+	return &reloader{pending: make(chan struct{}, 1), prepare: prepare}
+}
+
+// request asks for a reload and returns its generation; it never blocks
+func (r *reloader) request() uint64 {
+	generation := r.requested.Add(1)
+	select {
+	case r.pending <- struct{}{}:
+	default:
+		slog.Info("Reload coalesced with the pending reload", "generation", generation)
+	}
+	return generation
+}
+
+// run processes reload requests one at a time until ctx is done
+func (r *reloader) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-r.pending:
+			generation := r.requested.Load()
+			slog.Info("Reloading config", "generation", generation)
+			apply, err := r.prepare()
+			if err != nil {
+				slog.Error("Reload failed", "generation", generation, "error", err)
+				continue
+			}
+			if r.commit(generation, apply) {
+				slog.Info("Config reloaded successfully", "generation", generation)
+			}
+		}
+	}
+}
+
+// commit swaps in a prepared reload unless a newer generation was applied meanwhile
+func (r *reloader) commit(generation uint64, apply func()) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation <= r.applied {
+		slog.Warn("Discarding stale reload, a newer generation is already applied", "generation", generation, "applied", r.applied)
+		return false
+	}
+	apply()
+	r.applied = generation
+	return true
+}
+
+// generation returns the generation of the configuration in use
+func (r *reloader) generation() uint64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.applied
+}
+
+// reloadTracker records the outcome of configuration reloads, e.g. core.Orchestrator
+type reloadTracker interface {
+	ConfigLoaded(at time.Time, hash string)
+	ReloadFailed(at time.Time, hash string, err error)
+}
+
+// tracked wraps prepare so that a failure to reload the configuration file at path is reported to tracker,
+// and applying a reload reports the file as loaded
+func tracked(path string, tracker reloadTracker, prepare func() (func(), error)) func() (func(), error) {
+	return func() (func(), error) {
+		hash := configHash(path)
+		apply, err := prepare()
+		if err != nil {
+			tracker.ReloadFailed(time.Now(), hash, err)
+			return nil, err
+		}
+		return func() {
+			apply()
+			tracker.ConfigLoaded(time.Now(), hash)
+		}, nil
+	}
+}
+
+// configHash returns the SHA-256 of the configuration file at path; empty when it cannot be read
+func configHash(path string) string {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
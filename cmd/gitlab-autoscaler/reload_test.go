@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestReloader_OverlappingReloads verifies a burst of reloads during a slow provider build ends on the newest config
+// Expected behavior:
+//   - Requests arriving while a reload is being prepared coalesce into one pending reload
+//   - The final applied generation is the newest requested one, with the config of that time
+//   - Reloads are prepared one at a time
+func TestReloader_OverlappingReloads(t *testing.T) {
+	var fileVersion, applied, inFlight, maxInFlight, builds atomic.Int64
+	started := make(chan struct{}, 10)
+	release := make(chan struct{})
+
+	r := newReloader(func() (func(), error) {
+		if n := inFlight.Add(1); n > maxInFlight.Load() {
+			maxInFlight.Store(n)
+		}
+		defer inFlight.Add(-1)
+		builds.Add(1)
+
+		version := fileVersion.Load()
+		started <- struct{}{}
+		<-release // slow provider factory
+		return func() { applied.Store(version) }, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+
+	fileVersion.Store(1)
+	r.request()
+	<-started
+
+	var newest uint64
+	for version := int64(2); version <= 5; version++ {
+		fileVersion.Store(version)
+		newest = r.request()
+	}
+	close(release)
+
+	require.Eventually(t, func() bool { return r.generation() == newest }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(5), newest)
+	assert.Equal(t, int64(5), applied.Load())
+	assert.Equal(t, int64(2), builds.Load())
+	assert.Equal(t, int64(1), maxInFlight.Load())
+}
+
+// TestReloader_RapidRequestsApplyLatestFile verifies two reloads requested right after each other, as by a deploy
+// writing the configuration twice within a second, end on the second file
+// Expected behavior:
+//   - No request is dropped: the second one runs after or coalesces with the first, whenever the worker takes them
+//   - The generation of the second request is applied with the content of the second file
+func TestReloader_RapidRequestsApplyLatestFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	var applied atomic.Value
+	r := newReloader(func() (func(), error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return func() { applied.Store(string(data)) }, nil
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go r.run(ctx)
+
+	require.NoError(t, os.WriteFile(path, []byte("check-interval: 10"), 0644))
+	r.request()
+	require.NoError(t, os.WriteFile(path, []byte("check-interval: 30"), 0644))
+	newest := r.request()
+
+	require.Eventually(t, func() bool { return r.generation() == newest }, time.Second, time.Millisecond)
+	assert.Equal(t, "check-interval: 30", applied.Load())
+}
+
+// TestReloader_DiscardsStaleReload verifies a reload is not applied over a newer generation
+func TestReloader_DiscardsStaleReload(t *testing.T) {
+	r := newReloader(nil)
+	var applied []uint64
+
+	assert.True(t, r.commit(3, func() { applied = append(applied, 3) }))
+	assert.False(t, r.commit(2, func() { applied = append(applied, 2) }))
+	assert.False(t, r.commit(3, func() { applied = append(applied, 3) }))
+
+	assert.Equal(t, []uint64{3}, applied)
+	assert.Equal(t, uint64(3), r.generation())
+}
+
+// recordingTracker records the reload outcomes reported to it
+type recordingTracker struct {
+	loaded []string
+	failed []string
+}
+
+func (r *recordingTracker) ConfigLoaded(_ time.Time, hash string) { r.loaded = append(r.loaded, hash) }
+
+func (r *recordingTracker) ReloadFailed(_ time.Time, hash string, err error) {
+	r.failed = append(r.failed, hash+": "+err.Error())
+}
+
+// TestTracked_FailedThenSuccessful verifies reload outcomes are reported with the hash of the file they read
+// Expected behavior:
+//   - A failed prepare reports the failure with the hash of the broken file and applies nothing
+//   - A successful prepare reports the fixed file as loaded only once it is applied
+func TestTracked_FailedThenSuccessful(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yml")
+	tracker := &recordingTracker{}
+	var applied int
+	valid := false
+	prepare := tracked(path, tracker, func() (func(), error) {
+		if !valid {
+			return nil, errors.New("config validation failed")
+		}
+		return func() { applied++ }, nil
+	})
+
+	require.NoError(t, os.WriteFile(path, []byte("broken"), 0644))
+	_, err := prepare()
+	assert.Error(t, err)
+	assert.Equal(t, []string{configHash(path) + ": config validation failed"}, tracker.failed)
+	assert.Empty(t, tracker.loaded)
+
+	require.NoError(t, os.WriteFile(path, []byte("fixed"), 0644))
+	valid = true
+	apply, err := prepare()
+	require.NoError(t, err)
+	assert.Empty(t, tracker.loaded)
+	apply()
+	assert.Equal(t, 1, applied)
+	assert.Equal(t, []string{configHash(path)}, tracker.loaded)
+	assert.NotEqual(t, tracker.failed[0][:64], tracker.loaded[0])
+}
@@ -0,0 +1,27 @@
+package main
+
+import "log/slog"
+
+// cycleTrigger asks the main loop to run a cycle before the next tick, on SIGUSR2 or POST /trigger. Triggers
+// arriving while one is pending, e.g. during a cycle in progress, coalesce into a single extra cycle; the ticker
+// is left alone, so that the following ticks keep their schedule.
+type cycleTrigger struct {
+	pending chan struct{}
+}
+
+// newCycleTrigger creates a trigger; the main loop receives from pending
+func newCycleTrigger() *cycleTrigger {
+	return &cycleTrigger{pending: make(chan struct{}, 1)}
+}
+
+// request asks for an immediate cycle and reports whether it was coalesced with a pending one; it never blocks
+func (t *cycleTrigger) request(source string) bool {
+	select {
+	case t.pending <- struct{}{}:
+		slog.Info("Immediate cycle requested", "source", source)
+		return false
+	default:
+		slog.Info("Immediate cycle coalesced with the pending one", "source", source)
+		return true
+	}
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCycleTrigger_Coalesces verifies triggers arriving while a cycle is pending run it only once
+// Expected behavior:
+//   - The first trigger is pending, later ones are coalesced with it without blocking
+//   - Once the main loop took it, the next trigger is pending again
+func TestCycleTrigger_Coalesces(t *testing.T) {
+	trigger := newCycleTrigger()
+
+	assert.False(t, trigger.request("SIGUSR2"))
+	assert.True(t, trigger.request("admin"))
+	assert.True(t, trigger.request("SIGUSR2"))
+
+	<-trigger.pending
+	select {
+	case <-trigger.pending:
+		t.Fatal("coalesced triggers ran another cycle")
+	default:
+	}
+
+	assert.False(t, trigger.request("admin"))
+	assert.Len(t, trigger.pending, 1)
+}
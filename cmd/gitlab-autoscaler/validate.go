@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/faults"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// strictValidateTimeout bounds the read-only calls of --validate --strict
+const strictValidateTimeout = 2 * time.Minute
+
+// validateConfig checks the configuration file at path for --validate, prints every problem found to w and reports
+// whether there was none. Tags served by several ASGs are printed as warnings, unless overlapping-tags says otherwise.
+// Only with strict does it contact GitLab and the cloud, with read-only calls confirming the credentials, since the
+// CI running it may have none.
+func validateConfig(w io.Writer, path string, strict, allowFaultInjection bool) bool {
+	cfg, problems := config.Check(path)
+	if cfg != nil {
+		if err := faults.Guard(cfg.Testing.FaultInjection, allowFaultInjection); err != nil {
+			problems = append(problems, err)
+		}
+	}
+	if strict && len(problems) == 0 {
+		ctx, cancel := context.WithTimeout(context.Background(), strictValidateTimeout)
+		defer cancel()
+		problems = checkCredentials(ctx, cfg)
+	}
+
+	for _, problem := range problems {
+		fmt.Fprintf(w, "%s: %v\n", path, problem)
+	}
+	if cfg != nil && cfg.Autoscaler.EffectiveOverlappingTags() == config.OverlappingTagsWarn {
+		for _, overlap := range cfg.TagOverlaps() {
+			fmt.Fprintf(w, "%s: warning: %s\n", path, overlap)
+		}
+	}
+	if len(problems) > 0 {
+		fmt.Fprintf(w, "%s: %d problems found\n", path, len(problems))
+		return false
+	}
+	fmt.Fprintf(w, "%s: configuration is valid\n", path)
+	return true
+}
+
+// checkCredentials confirms with read-only calls that the GitLab token reads the configured group or projects and
+// that the providers read the capacity of every ASG, discovered ones included
+func checkCredentials(ctx context.Context, cfg *config.Config) []error {
+	var problems []error
+	client, err := gitlab.NewClient(cfg.GitLab, nil)
+	if err == nil {
+		err = client.CheckAccess(cfg.GitLab)
+	}
+	if err != nil {
+		problems = append(problems, fmt.Errorf("gitlab: %w", err))
+	}
+
+	if err := discoverASGs(ctx, cfg); err != nil {
+		return append(problems, fmt.Errorf("ASG discovery failed: %w", err))
+	}
+	providers, asgToProvider, err := buildProvidersFromConfig(cfg)
+	if err != nil {
+		return append(problems, fmt.Errorf("failed to build providers: %w", err))
+	}
+	// Export-only ASGs are published rather than read from their provider
+	for _, providerCfg := range cfg.Providers {
+		for _, asg := range providerCfg.AsgNames {
+			if asg.ExportOnly != "" {
+				delete(asgToProvider, asg.Name)
+			}
+		}
+	}
+	for _, asgName := range slices.Sorted(maps.Keys(asgToProvider)) {
+		if _, _, err := providers[asgToProvider[asgName]].GetCurrentCapacity(ctx, asgName); err != nil {
+			problems = append(problems, fmt.Errorf("asg %s: %w", asgName, err))
+		}
+	}
+	return problems
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestValidateConfig verifies --validate prints the problems of a configuration and fails on any
+// Expected behavior:
+//   - A valid configuration passes with a single line saying so
+//   - Every problem is printed with the path, followed by their count
+//   - Fault injection without --allow-fault-injection is a problem
+func TestValidateConfig(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.yml")
+	require.NoError(t, os.WriteFile(valid, []byte(`gitlab:
+  token: 'glpat-token'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+aws:
+  asg-names:
+    - name: test-asg
+      tags: ["amd64"]
+      max-asg-capacity: 3
+`), 0644))
+	invalid := filepath.Join(dir, "invalid.yml")
+	require.NoError(t, os.WriteFile(invalid, []byte(`gitlab:
+  token: '{{ .Values.token }}'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+  chek-interval: 10
+testing:
+  fault-injection:
+    enabled: true
+aws:
+  asg-names:
+    - name: test-asg
+      max-asg-capacity: 3
+`), 0644))
+
+	var out strings.Builder
+	assert.True(t, validateConfig(&out, valid, false, false))
+	assert.Equal(t, valid+": configuration is valid\n", out.String())
+
+	out.Reset()
+	assert.False(t, validateConfig(&out, invalid, false, false))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	require.Len(t, lines, 4, out.String())
+	assert.Contains(t, lines[0], invalid+": line 6: field chek-interval not found")
+	assert.Equal(t, invalid+": line 2: unrendered template placeholder", lines[1])
+	assert.Contains(t, lines[2], "fault-injection")
+	assert.Equal(t, invalid+": 3 problems found", lines[3])
+}
+
+// TestValidateConfig_OverlappingTags verifies --validate prints tags served by several ASGs as warnings
+// Expected behavior:
+//   - With overlapping-tags unset, each shared tag is printed with its ASGs and the configuration stays valid
+//   - With overlapping-tags: allow nothing is printed
+func TestValidateConfig_OverlappingTags(t *testing.T) {
+	content := `gitlab:
+  token: 'glpat-token'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+aws:
+  asg-names:
+    - name: runners-a
+      tags: ["amd64", "build"]
+      max-asg-capacity: 3
+    - name: runners-b
+      tags: ["arm64", "build"]
+      max-asg-capacity: 3
+`
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+
+	var out strings.Builder
+	assert.True(t, validateConfig(&out, path, false, false))
+	assert.Equal(t, path+`: warning: tag "build" is served by ASGs runners-a, runners-b`+"\n"+path+": configuration is valid\n", out.String())
+
+	content = strings.Replace(content, "check-interval: 10", "check-interval: 10\n  overlapping-tags: allow", 1)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	out.Reset()
+	assert.True(t, validateConfig(&out, path, false, false))
+	assert.Equal(t, path+": configuration is valid\n", out.String())
+}
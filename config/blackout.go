@@ -0,0 +1,75 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// blackoutLayout is the format of the start and end of a blackout window
+const blackoutLayout = "2006-01-02 15:04"
+
+// Validate checks that the window has a parseable start before its end in a known time zone
+func (w BlackoutWindow) Validate() error {
+	start, end, err := w.bounds()
+	if err != nil {
+		return err
+	}
+	if !end.After(start) {
+		return fmt.Errorf("end %s is not after start %s", w.End, w.Start)
+	}
+	return nil
+}
+
+// Active reports whether now falls into the window. Windows are validated on load, so parse errors make it inactive.
+func (w BlackoutWindow) Active(now time.Time) bool {
+	start, end, err := w.bounds()
+	if err != nil {
+		return false
+	}
+	return !now.Before(start) && now.Before(end)
+}
+
+// String describes the window for logs and the status API, e.g. "2024-12-20 18:00-2025-01-06 08:00 Europe/Berlin (release freeze)"
+func (w BlackoutWindow) String() string {
+	timezone := w.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	description := fmt.Sprintf("%s-%s %s", w.Start, w.End, timezone)
+	if w.Reason != "" {
+		description += " (" + w.Reason + ")"
+	}
+	return description
+}
+
+// bounds parses the start and end of the window in its time zone
+func (w BlackoutWindow) bounds() (time.Time, time.Time, error) {
+	loc, err := loadLocation(w.Timezone)
+	if err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start, err := time.ParseInLocation(blackoutLayout, w.Start, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("start %q is not a %q time", w.Start, "YYYY-MM-DD HH:MM")
+	}
+	end, err := time.ParseInLocation(blackoutLayout, w.End, loc)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("end %q is not a %q time", w.End, "YYYY-MM-DD HH:MM")
+	}
+	return start, end, nil
+}
+
+// ActiveBlackout returns the blackout window the ASG is in at now, if any. The blackout-windows of the ASG
+// replace the global ones when set, so an ASG with an empty list is never blacked out.
+func (a Asg) ActiveBlackout(global []BlackoutWindow, now time.Time) (BlackoutWindow, bool) {
+	windows := global
+	if a.BlackoutWindows != nil {
+		windows = a.BlackoutWindows
+	}
+	for _, window := range windows {
+		if window.Active(now) {
+			return window, true
+		}
+	}
+	return BlackoutWindow{}, false
+}
@@ -0,0 +1,106 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// templatePlaceholder matches the markers of Go, Helm and Jinja templates
+var templatePlaceholder = regexp.MustCompile(`\{\{|\{%`)
+
+// Check loads the configuration file at path for --validate and returns it with every problem found, none when it
+// is valid: environment variables referenced without a default that are unset, keys no setting is decoded from (e.g.
+// a misspelled one, of which Load reports only the first ones), markers a template left unrendered, and the error of
+// Validate, which also rejects ASGs listed twice or claimed by several providers. The configuration is nil when the
+// file cannot be read or is no YAML.
+func Check(path string) (*Config, []error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, []error{fmt.Errorf("failed to open config file: %w", err)}
+	}
+
+	data, problems := expandEnv(data)
+	var cfg Config
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
+		// The decoder keeps going after fields it cannot decode and lists them all
+		var typeErr *yaml.TypeError
+		if !errors.As(err, &typeErr) {
+			return nil, []error{fmt.Errorf("failed to decode config: %w", err)}
+		}
+		for _, message := range typeErr.Errors {
+			problems = append(problems, errors.New(message))
+		}
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err == nil {
+		problems = append(problems, unknownSections(&root)...)
+		problems = append(problems, unrenderedPlaceholders(&root)...)
+	}
+	if err := cfg.Validate(); err != nil {
+		problems = append(problems, err)
+	}
+	return &cfg, problems
+}
+
+// unknownSections returns a problem per top-level key that is neither a section of Config nor a provider entry.
+// Provider entries are inlined, so a misspelled section, e.g. "autoscalr", would otherwise be decoded as a provider
+// named after it. An entry is a provider when it is named after a built-in one or sets type or plugin.
+func unknownSections(root *yaml.Node) []error {
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	sections := make(map[string]bool)
+	for _, field := range reflect.VisibleFields(reflect.TypeOf(Config{})) {
+		if name, inline := yamlName(field); !inline {
+			sections[name] = true
+		}
+	}
+
+	var problems []error
+	mapping := root.Content[0]
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		key, value := mapping.Content[i], mapping.Content[i+1]
+		if sections[key.Value] || slices.Contains(builtinProviders, strings.ToLower(key.Value)) || hasKey(value, "type") || hasKey(value, "plugin") {
+			continue
+		}
+		problems = append(problems, fmt.Errorf("line %d: unknown key %q: neither a section nor a provider entry, which is named after a built-in provider or sets type or plugin",
+			key.Line, key.Value))
+	}
+	return problems
+}
+
+// hasKey reports whether node is a mapping with the key
+func hasKey(node *yaml.Node, key string) bool {
+	if node.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 0; i < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return true
+		}
+	}
+	return false
+}
+
+// unrenderedPlaceholders returns a problem per value of the document with a template marker, comments aside; the
+// value itself is not quoted, since it may be a token
+func unrenderedPlaceholders(node *yaml.Node) []error {
+	var problems []error
+	if node.Kind == yaml.ScalarNode && templatePlaceholder.MatchString(node.Value) {
+		problems = append(problems, fmt.Errorf("line %d: unrendered template placeholder", node.Line))
+	}
+	for _, child := range node.Content {
+		problems = append(problems, unrenderedPlaceholders(child)...)
+	}
+	return problems
+}
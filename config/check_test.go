@@ -0,0 +1,133 @@
+package config
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// writeConfig writes a configuration file into a temporary directory and returns its path
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yml")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0644))
+	return path
+}
+
+// TestCheck_Valid verifies a valid configuration has no problems
+func TestCheck_Valid(t *testing.T) {
+	cfg, problems := Check(writeConfig(t, `gitlab:
+  token: 'glpat-token'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+aws:
+  asg-names:
+    - name: test-asg  # tags: ${none}
+      tags: ["amd64"]
+      max-asg-capacity: 3
+`))
+
+	assert.Empty(t, problems)
+	require.NotNil(t, cfg)
+	assert.Equal(t, "mygroup", cfg.GitLab.Group)
+}
+
+// TestCheck_Problems verifies every problem of a file is reported, not only the first
+// Expected behavior:
+//   - Misspelled keys are reported with their line
+//   - Template markers outside comments are reported with their line, without the token
+//   - The error of Validate is reported, here an ASG claimed by two providers
+func TestCheck_Problems(t *testing.T) {
+	path := writeConfig(t, `# rendered from {{ template }}
+gitlab:
+  token: '{{ .Values.token }}'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+  scale-up-stabilisation: 2
+aws:
+  asg-names:
+    - name: shared-asg
+      max-asg-capacity: 3
+      scale-to-zeroo: true
+aws-eu:
+  type: aws
+  asg-names:
+    - name: shared-asg
+      max-asg-capacity: 3
+`)
+
+	cfg, problems := Check(path)
+
+	require.NotNil(t, cfg)
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+	}
+	require.Len(t, messages, 4, messages)
+	assert.Contains(t, messages[0], "line 7: field scale-up-stabilisation not found")
+	assert.Contains(t, messages[1], "line 12: field scale-to-zeroo not found")
+	assert.Equal(t, "line 3: unrendered template placeholder", messages[2])
+	assert.Equal(t, "asg shared-asg is claimed by providers aws and aws-eu, as aws.asg-names[0] and aws-eu.asg-names[0]", messages[3])
+}
+
+// TestCheck_Unreadable verifies a missing file or broken YAML is the only problem reported
+func TestCheck_Unreadable(t *testing.T) {
+	cfg, problems := Check(filepath.Join(t.TempDir(), "missing.yml"))
+	assert.Nil(t, cfg)
+	require.Len(t, problems, 1)
+	assert.ErrorContains(t, problems[0], "failed to open config file")
+
+	cfg, problems = Check(writeConfig(t, "gitlab: [unclosed"))
+	assert.Nil(t, cfg)
+	require.Len(t, problems, 1)
+	assert.ErrorContains(t, problems[0], "failed to decode config")
+}
+
+// TestLoad_UnknownKeys verifies Load rejects keys no setting is read from, naming them and their line
+// Expected behavior:
+//   - A misspelled section is rejected instead of being taken for a provider entry
+//   - A misspelled setting of an ASG is rejected
+//   - Entries of several providers, named after a built-in one or setting type or plugin, are accepted
+func TestLoad_UnknownKeys(t *testing.T) {
+	_, err := Load(writeConfig(t, `gitlab:
+  token: 'glpat-token'
+  group: mygroup
+autoscalr:
+  check-interval: 10
+`))
+	assert.ErrorContains(t, err, `line 4: unknown key "autoscalr"`)
+
+	_, err = Load(writeConfig(t, `gitlab:
+  token: 'glpat-token'
+  group: mygroup
+aws:
+  asg-names:
+    - name: test-asg
+      scale-to-zeroo: true
+`))
+	assert.ErrorContains(t, err, "line 7: field scale-to-zeroo not found")
+
+	cfg, err := Load(writeConfig(t, `gitlab:
+  token: 'glpat-token'
+  group: mygroup
+autoscaler:
+  check-interval: 10
+AWS:
+  region: eu-west-1
+aws-prod:
+  type: aws
+  role-arn: 'arn:aws:iam::123456789012:role/autoscaler'
+on-prem:
+  plugin:
+    address: 'unix:///run/autoscaler-plugin.sock'
+`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"AWS", "aws-prod", "on-prem"}, slices.Sorted(maps.Keys(cfg.Providers)))
+}
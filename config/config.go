@@ -1,22 +1,51 @@
 package config
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
-	"gopkg.in/yaml.v3"
+	"maps"
+	"math"
+	"net"
+	"net/url"
 	"os"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
-// Load loads the configuration from a YAML file
+// dropletTagPattern matches the names DigitalOcean accepts for a tag, the name of a droplet pool
+var dropletTagPattern = regexp.MustCompile(`^[A-Za-z0-9_:-]{1,255}$`)
+
+// Load loads the configuration from a YAML file. Decoding is strict: a key no setting is read from, e.g. a
+// misspelled one, is an error naming it and its line rather than being ignored.
 func Load(configPath string) (*Config, error) {
-	file, err := os.Open(configPath)
+	data, err := os.ReadFile(configPath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open config file: %w", err)
 	}
-	defer file.Close()
+	data, problems := expandEnv(data)
+	if len(problems) > 0 {
+		return nil, fmt.Errorf("failed to expand environment variables: %w", errors.Join(problems...))
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
+		return nil, fmt.Errorf("failed to decode config: %w", err)
+	}
+	if problems := unknownSections(&root); len(problems) > 0 {
+		return nil, fmt.Errorf("failed to decode config: %w", errors.Join(problems...))
+	}
 
 	var cfg Config
-	err = yaml.NewDecoder(file).Decode(&cfg)
-	if err != nil {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	if err := decoder.Decode(&cfg); err != nil {
 		return nil, fmt.Errorf("failed to decode config: %w", err)
 	}
 
@@ -29,11 +58,228 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("check-interval must be positive")
 	}
 
+	switch c.Autoscaler.RunnerReconciliation {
+	case "", RunnerReconciliationWarn, RunnerReconciliationBlock:
+	default:
+		return fmt.Errorf("runner-reconciliation must be one of %q, %q or empty", RunnerReconciliationWarn, RunnerReconciliationBlock)
+	}
+
+	switch c.Autoscaler.TagSharing {
+	case "", TagSharingEven, TagSharingHeadroom, TagSharingPriority, TagSharingDuplicate:
+	default:
+		return fmt.Errorf("tag-sharing must be one of %q, %q, %q, %q or empty", TagSharingEven, TagSharingHeadroom, TagSharingPriority, TagSharingDuplicate)
+	}
+
+	switch c.Autoscaler.SharedTagScaleDown {
+	case "", SharedTagScaleDownHold, SharedTagScaleDownAllow:
+	default:
+		return fmt.Errorf("shared-tag-scale-down must be one of %q, %q or empty", SharedTagScaleDownHold, SharedTagScaleDownAllow)
+	}
+
+	switch c.Autoscaler.OverlappingTags {
+	case "", OverlappingTagsWarn, OverlappingTagsError, OverlappingTagsAllow:
+	default:
+		return fmt.Errorf("overlapping-tags must be one of %q, %q, %q or empty", OverlappingTagsWarn, OverlappingTagsError, OverlappingTagsAllow)
+	}
+
+	if err := validateTagAliases(c.Autoscaler.TagAliases); err != nil {
+		return err
+	}
+
+	if err := validateTagLimits(c.Autoscaler.TagLimits, c.Autoscaler.TagAliasLookup()); err != nil {
+		return err
+	}
+
+	if c.Autoscaler.ScaleUpStabilization < 0 {
+		return fmt.Errorf("scale-up-stabilization must be non-negative")
+	}
+
+	if c.Autoscaler.ScaleDownIdleCycles < 0 {
+		return fmt.Errorf("scale-down-idle-cycles must be non-negative")
+	}
+
+	if c.Autoscaler.ScaleDownCooldown < 0 {
+		return fmt.Errorf("scale-down-cooldown must be non-negative")
+	}
+
+	if c.Autoscaler.ScaleUpCooldown < 0 {
+		return fmt.Errorf("scale-up-cooldown must be non-negative")
+	}
+
+	if c.Autoscaler.DescribeStaleAfter < 0 {
+		return fmt.Errorf("describe-stale-after must be non-negative")
+	}
+
+	if c.Autoscaler.ErrorBackoffAfter < 0 {
+		return fmt.Errorf("error-backoff-after must be non-negative")
+	}
+
+	if c.Autoscaler.ErrorBackoffMax < 0 {
+		return fmt.Errorf("error-backoff-max must be non-negative")
+	}
+
+	if c.Autoscaler.PendingTimeout < 0 {
+		return fmt.Errorf("pending-timeout must be non-negative")
+	}
+
+	if c.Autoscaler.DrainTimeout < 0 {
+		return fmt.Errorf("drain-timeout must be non-negative")
+	}
+
+	if c.Autoscaler.MaxTotalCapacity < 0 {
+		return fmt.Errorf("max-total-capacity must be non-negative")
+	}
+
+	if c.Autoscaler.StuckQueueCycles < 0 {
+		return fmt.Errorf("stuck-queue-cycles must be non-negative")
+	}
+
+	if c.Autoscaler.DemandHistoryHalfLife < 0 {
+		return fmt.Errorf("demand-history-half-life must be non-negative")
+	}
+
+	if c.Autoscaler.AuditLogMaxSize < 0 {
+		return fmt.Errorf("audit-log-max-size must be non-negative")
+	}
+
+	if c.Autoscaler.PipelineHold < 0 {
+		return fmt.Errorf("pipeline-hold must be non-negative")
+	}
+
+	for i, window := range c.Autoscaler.BlackoutWindows {
+		if err := window.Validate(); err != nil {
+			return fmt.Errorf("blackout-windows[%d]: %w", i, err)
+		}
+	}
+
+	if c.Autoscaler.CreatedJobsFactor < 0 || c.Autoscaler.CreatedJobsFactor > 1 {
+		return fmt.Errorf("created-jobs-factor must be between 0 and 1, got %v", c.Autoscaler.CreatedJobsFactor)
+	}
+
+	if err := c.validateAsgOwners(); err != nil {
+		return err
+	}
+	if err := c.CheckOverlappingTags(); err != nil {
+		return err
+	}
+
 	for providerName, config := range c.Providers {
+		providerType := config.EffectiveType(providerName)
+		switch {
+		case config.Plugin.IsSet() && config.Type != "":
+			return fmt.Errorf("provider %s: type and plugin cannot be used together", providerName)
+		case !config.Plugin.IsSet() && !slices.Contains(builtinProviders, providerType):
+			return fmt.Errorf("provider %s: unknown type %q, must be one of %s or a plugin", providerName, providerType, strings.Join(builtinProviders, ", "))
+		}
+		if config.MaxRetries < -1 {
+			return fmt.Errorf("provider %s: max-retries must be -1 (disabled) or more, got %d", providerName, config.MaxRetries)
+		}
+		if config.RequestTimeout < 0 {
+			return fmt.Errorf("provider %s: request-timeout must not be negative, got %s", providerName, config.RequestTimeout)
+		}
+		if config.EndpointURL != "" {
+			if err := validateEndpointURL(config.EndpointURL); err != nil {
+				return fmt.Errorf("provider %s: endpoint-url %w", providerName, err)
+			}
+		}
+		if err := config.validateRoles(); err != nil {
+			return fmt.Errorf("provider %s: %w", providerName, err)
+		}
+		if providerType == ProviderGCP {
+			if config.Project == "" {
+				return fmt.Errorf("provider %s: project is required", providerName)
+			}
+			if config.Region == "" && config.DefaultZone == "" {
+				return fmt.Errorf("provider %s: region or default-zone is required", providerName)
+			}
+			for i, asg := range config.AsgNames {
+				if asg.Region != "" && config.DefaultZone != "" {
+					return fmt.Errorf("provider %s: asg[%d]: region selects a regional group and cannot be used with default-zone", providerName, i)
+				}
+			}
+		}
+		if providerType == ProviderAzure {
+			if config.SubscriptionID == "" || config.ResourceGroup == "" {
+				return fmt.Errorf("provider %s: subscription-id and resource-group are required", providerName)
+			}
+			if config.ClientSecret != "" && (config.TenantID == "" || config.ClientID == "") {
+				return fmt.Errorf("provider %s: client-secret requires tenant-id and client-id", providerName)
+			}
+		}
+		if providerType == ProviderK8s {
+			for i, asg := range config.AsgNames {
+				if namespace, deployment, ok := strings.Cut(asg.Name, "/"); !ok || namespace == "" || deployment == "" || strings.Contains(deployment, "/") {
+					return fmt.Errorf("provider %s: asg[%d]: name %q must be namespace/deployment", providerName, i, asg.Name)
+				}
+			}
+		}
+		if config.Plugin.IsSet() {
+			if slices.Contains(builtinProviders, strings.ToLower(providerName)) {
+				return fmt.Errorf("provider %s: plugin needs a provider name of its own, %s is a built-in provider", providerName, providerName)
+			}
+			if err := validatePluginAddress(config.Plugin.Address); err != nil {
+				return fmt.Errorf("provider %s: plugin.address %w", providerName, err)
+			}
+		}
+		if providerType == ProviderExec {
+			if (config.GetCapacityCmd == "") == (config.GetCapacityURL == "") {
+				return fmt.Errorf("provider %s: exactly one of get-capacity-cmd and get-capacity-url is required", providerName)
+			}
+			if (config.SetCapacityCmd == "") == (config.SetCapacityURL == "") {
+				return fmt.Errorf("provider %s: exactly one of set-capacity-cmd and set-capacity-url is required", providerName)
+			}
+			if config.GetCapacityURL != "" {
+				if err := validateEndpointURL(config.GetCapacityURL); err != nil {
+					return fmt.Errorf("provider %s: get-capacity-url %w", providerName, err)
+				}
+			}
+			if config.SetCapacityURL != "" {
+				if err := validateEndpointURL(config.SetCapacityURL); err != nil {
+					return fmt.Errorf("provider %s: set-capacity-url %w", providerName, err)
+				}
+			}
+		}
+		for i, asg := range config.AsgNames {
+			if providerType != ProviderDigitalOcean && asg.Droplet.IsSet() {
+				return fmt.Errorf("provider %s: asg[%d]: droplet is only supported by the %s provider", providerName, i, ProviderDigitalOcean)
+			}
+			if providerType != ProviderDigitalOcean {
+				continue
+			}
+			if !dropletTagPattern.MatchString(asg.Name) {
+				return fmt.Errorf("provider %s: asg[%d]: name %q must be a droplet tag of letters, digits, '_', '-' and ':'", providerName, i, asg.Name)
+			}
+			if asg.Droplet.Size == "" || asg.Droplet.Image == "" {
+				return fmt.Errorf("provider %s: asg[%d]: droplet.size and droplet.image are required", providerName, i)
+			}
+			if asg.Region == "" && config.Region == "" {
+				return fmt.Errorf("provider %s: asg[%d]: region is required, set it on the provider or the ASG", providerName, i)
+			}
+		}
+		if config.AsgDiscovery.TagValue != "" && !config.AsgDiscovery.IsSet() {
+			return fmt.Errorf("provider %s: asg-discovery.tag-value requires tag-key", providerName)
+		}
 		for i, asg := range config.AsgNames {
 			if err := asg.Validate(); err != nil {
 				return fmt.Errorf("provider %s: asg[%d]: %w", providerName, i, err)
 			}
+			if asg.ExternalID != "" && asg.RoleARN == "" && !config.assumesRole() {
+				return fmt.Errorf("provider %s: asg[%d]: external-id requires role-arn on the ASG or the provider", providerName, i)
+			}
+		}
+	}
+
+	if err := c.validatePreviousNames(); err != nil {
+		return err
+	}
+
+	if !c.Fleeting.IsSet() {
+		for providerName, config := range c.Providers {
+			for i, asg := range config.AsgNames {
+				if asg.ExportOnly == ExportFleeting {
+					return fmt.Errorf("provider %s: asg[%d]: export-only %q requires fleeting.listen or fleeting.file", providerName, i, ExportFleeting)
+				}
+			}
 		}
 	}
 
@@ -41,8 +287,71 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("gitlab.token is required")
 	}
 
-	if len(c.GitLab.Group) == 0 {
-		return fmt.Errorf("gitlab.group is required")
+	if (c.GitLab.Group == "") == (len(c.GitLab.Projects) == 0) {
+		return fmt.Errorf("exactly one of gitlab.group or gitlab.projects is required")
+	}
+	if c.GitLab.Group == "" {
+		if c.Autoscaler.RunnerReconciliation != "" {
+			return fmt.Errorf("runner-reconciliation requires gitlab.group")
+		}
+		if c.GitLab.CleanupOfflineRunners {
+			return fmt.Errorf("gitlab.cleanup-offline-runners requires gitlab.group")
+		}
+	}
+
+	if c.GitLab.Proxy != "" {
+		if _, err := url.Parse(c.GitLab.Proxy); err != nil {
+			return fmt.Errorf("gitlab.proxy is not a valid URL: %w", err)
+		}
+	}
+
+	for providerName, config := range c.Providers {
+		for i, asg := range config.AsgNames {
+			if asg.GitLabScope.IsSet() && c.GitLab.Group == "" {
+				return fmt.Errorf("provider %s: asg[%d]: gitlab-scope requires gitlab.group", providerName, i)
+			}
+			if err := asg.GitLabScope.Validate(c.GitLab.Group); err != nil {
+				return fmt.Errorf("provider %s: asg[%d]: %w", providerName, i, err)
+			}
+		}
+	}
+
+	if c.GitLab.RequestTimeout < 0 {
+		return fmt.Errorf("gitlab.request-timeout must be non-negative")
+	}
+	if c.GitLab.MaxIdleConns < 0 || c.GitLab.MaxIdleConnsPerHost < 0 {
+		return fmt.Errorf("gitlab.max-idle-conns and gitlab.max-idle-conns-per-host must be non-negative")
+	}
+
+	if err := c.Testing.FaultInjection.Validate(); err != nil {
+		return err
+	}
+
+	for i, boundary := range c.Metrics.AgeBuckets {
+		if boundary <= 0 || (i > 0 && boundary <= c.Metrics.AgeBuckets[i-1]) {
+			return fmt.Errorf("metrics.age-buckets must be positive and strictly increasing")
+		}
+	}
+	if c.Metrics.MaxTags < 0 {
+		return fmt.Errorf("metrics.max-tags must be non-negative")
+	}
+	if c.Metrics.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Metrics.Listen); err != nil {
+			return fmt.Errorf("metrics.listen is not a valid host:port address: %w", err)
+		}
+		if c.Metrics.Listen == c.Admin.Listen {
+			return fmt.Errorf("metrics.listen must differ from admin.listen, which serves GET /metrics already")
+		}
+	}
+
+	if c.Admin.Listen != "" {
+		if _, _, err := net.SplitHostPort(c.Admin.Listen); err != nil {
+			return fmt.Errorf("admin.listen is not a valid host:port address: %w", err)
+		}
+	}
+
+	if c.GitLab.CleanupOfflineRunners && c.GitLab.OfflineRunnerMaxAge <= 0 {
+		return fmt.Errorf("gitlab.offline-runner-max-age must be positive when gitlab.cleanup-offline-runners is enabled")
 	}
 
 	return nil
@@ -56,44 +365,518 @@ func (a *Asg) Validate() error {
 	if a.MaxAsgCapacity < 0 {
 		return fmt.Errorf("max-asg-capacity must be non-negative")
 	}
+	if a.MaxAsgCapacity > MaxAsgCapacityCeiling {
+		return fmt.Errorf("max-asg-capacity %d exceeds the ceiling of %d", a.MaxAsgCapacity, MaxAsgCapacityCeiling)
+	}
+	if a.MinAsgCapacity < 0 {
+		return fmt.Errorf("min-asg-capacity must be non-negative")
+	}
+	if a.MinAsgCapacity > a.MaxAsgCapacity {
+		return fmt.Errorf("min-asg-capacity %d exceeds max-asg-capacity %d", a.MinAsgCapacity, a.MaxAsgCapacity)
+	}
+	if a.MinAsgCapacity > 0 && a.ScaleToZero {
+		return fmt.Errorf("min-asg-capacity %d conflicts with scale-to-zero", a.MinAsgCapacity)
+	}
+	if a.JobsPerInstance < 0 {
+		return fmt.Errorf("jobs-per-instance must be non-negative")
+	}
+	if a.WarmSlots < 0 {
+		return fmt.Errorf("warm-slots must be non-negative")
+	}
+	if a.WarmSlotsOnlyWhenActive && (a.WarmSlots == 0 || !a.ScaleToZero) {
+		return fmt.Errorf("warm-slots-only-when-active requires warm-slots and scale-to-zero")
+	}
+	if a.PredictiveMax < 0 {
+		return fmt.Errorf("predictive-max must be non-negative")
+	}
+	if a.PredictiveMax > 0 && !a.PredictivePrescale {
+		return fmt.Errorf("predictive-max requires predictive-prescale")
+	}
+	if a.TargetMaxWait < 0 {
+		return fmt.Errorf("target-max-wait must be non-negative")
+	}
+	if a.ScaleDownIdleCycles < 0 {
+		return fmt.Errorf("scale-down-idle-cycles must be non-negative")
+	}
+	if a.ScaleDownCooldown < 0 {
+		return fmt.Errorf("scale-down-cooldown must be non-negative")
+	}
+	if a.ScaleUpCooldown < 0 {
+		return fmt.Errorf("scale-up-cooldown must be non-negative")
+	}
+	for i, schedule := range a.Schedules {
+		if err := schedule.Validate(*a); err != nil {
+			return fmt.Errorf("schedules[%d]: %w", i, err)
+		}
+	}
+	for _, tag := range a.Tags {
+		if err := validateTagEntry(tag); err != nil {
+			return err
+		}
+	}
+	switch a.ExportOnly {
+	case "", ExportFleeting:
+	default:
+		return fmt.Errorf("export-only must be %q or empty", ExportFleeting)
+	}
+	if a.ProtectBusyInstances && a.ExportOnly != "" {
+		return fmt.Errorf("protect-busy-instances cannot be used with export-only, the external scaler owns the instances")
+	}
+	if a.LifecycleHook != "" && a.ExportOnly != "" {
+		return fmt.Errorf("lifecycle-hook cannot be used with export-only, the external scaler owns the instances")
+	}
+	if err := validateLifecycleHook(a.LifecycleHook); err != nil {
+		return fmt.Errorf("lifecycle-hook %w", err)
+	}
+	if a.MaxInstanceAge < 0 {
+		return fmt.Errorf("max-instance-age must be non-negative")
+	}
+	if a.MaxInstanceAge > 0 && a.ExportOnly != "" {
+		return fmt.Errorf("max-instance-age cannot be used with export-only, the external scaler owns the instances")
+	}
+	for i, window := range a.BlackoutWindows {
+		if err := window.Validate(); err != nil {
+			return fmt.Errorf("blackout-windows[%d]: %w", i, err)
+		}
+	}
+	weighted := make([]string, 0, len(a.TagWeights))
+	for tag := range a.TagWeights {
+		weighted = append(weighted, tag)
+	}
+	sort.Strings(weighted)
+	for _, tag := range weighted {
+		if weight := a.TagWeights[tag]; weight <= 0 || math.IsNaN(weight) || math.IsInf(weight, 0) {
+			return fmt.Errorf("tag-weights: weight %v of tag %q must be a positive number", weight, tag)
+		}
+		if !a.servesTag(tag) {
+			return fmt.Errorf("tag-weights: tag %q is not served by this ASG", tag)
+		}
+	}
+	for _, excluded := range a.ExcludeTags {
+		if IsTagPattern(excluded) {
+			return fmt.Errorf("exclude-tags entry %q must be a literal tag", excluded)
+		}
+		for _, tag := range a.Tags {
+			if tag == excluded {
+				return fmt.Errorf("tag %q is both served and excluded", tag)
+			}
+		}
+	}
 
 	return nil
 }
 
-// PrintConfiguration prints the configuration to standard output for debugging
-func PrintConfiguration(cfg *Config, version string, commitHash string) {
+// validateAsgOwners rejects an ASG name listed twice, by one provider entry or by several, since every ASG is served
+// by one and the last entry would otherwise win. The error names both entries and, when their settings differ, the
+// settings that do.
+func (c *Config) validateAsgOwners() error {
+	names := make([]string, 0, len(c.Providers))
+	for providerName := range c.Providers {
+		names = append(names, providerName)
+	}
+	sort.Strings(names)
+
+	type asgEntry struct {
+		provider string
+		index    int
+	}
+	entries := make(map[string]asgEntry)
+	for _, providerName := range names {
+		for i, asg := range c.Providers[providerName].AsgNames {
+			first, ok := entries[asg.Name]
+			if !ok {
+				entries[asg.Name] = asgEntry{provider: providerName, index: i}
+				continue
+			}
+			var changes []Change
+			diffValues(&changes, "", reflect.ValueOf(c.Providers[first.provider].AsgNames[first.index]), reflect.ValueOf(asg))
+			conflict := ""
+			if len(changes) > 0 {
+				paths := make([]string, len(changes))
+				for j, change := range changes {
+					paths[j] = change.Path
+				}
+				conflict = ", with different " + strings.Join(paths, ", ")
+			}
+			if first.provider == providerName {
+				return fmt.Errorf("provider %s: asg %s is listed twice, as asg-names[%d] and asg-names[%d]%s",
+					providerName, asg.Name, first.index, i, conflict)
+			}
+			return fmt.Errorf("asg %s is claimed by providers %s and %s, as %s.asg-names[%d] and %s.asg-names[%d]%s",
+				asg.Name, first.provider, providerName, first.provider, first.index, providerName, i, conflict)
+		}
+	}
+	return nil
+}
+
+// validatePreviousNames rejects previous names that are still active ASGs or claimed by several ASGs
+func (c *Config) validatePreviousNames() error {
+	active := make(map[string]bool)
+	for _, providerConfig := range c.Providers {
+		for _, asg := range providerConfig.AsgNames {
+			active[asg.Name] = true
+		}
+	}
+
+	claimed := make(map[string]string)
+	for _, providerConfig := range c.Providers {
+		for _, asg := range providerConfig.AsgNames {
+			for _, previous := range asg.PreviousNames {
+				if active[previous] {
+					return fmt.Errorf("asg %s: previous name %q is still an active ASG", asg.Name, previous)
+				}
+				if other, ok := claimed[previous]; ok && other != asg.Name {
+					return fmt.Errorf("asg %s: previous name %q is also claimed by asg %s", asg.Name, previous, other)
+				}
+				claimed[previous] = asg.Name
+			}
+		}
+	}
+	return nil
+}
+
+// EffectiveMinCapacity returns the fewest instances the ASG is kept at; without min-asg-capacity
+// it is 0 with scale-to-zero and 1 otherwise
+func (a Asg) EffectiveMinCapacity() int64 {
+	if a.MinAsgCapacity > 0 {
+		return a.MinAsgCapacity
+	}
+	if a.ScaleToZero {
+		return 0
+	}
+	return 1
+}
+
+// EffectiveJobsPerInstance returns the jobs one instance of the ASG runs at once
+func (a Asg) EffectiveJobsPerInstance() int64 {
+	if a.JobsPerInstance <= 0 {
+		return 1
+	}
+	return a.JobsPerInstance
+}
+
+// EffectivePredictiveMax returns the most instances predictive-prescale raises the minimum to
+func (a Asg) EffectivePredictiveMax() int64 {
+	if a.PredictiveMax == 0 || a.PredictiveMax > a.MaxAsgCapacity {
+		return a.MaxAsgCapacity
+	}
+	return a.PredictiveMax
+}
+
+// EffectiveCreatedJobsFactor returns the share of created jobs counted as pending demand, or 0 when created jobs are not counted
+func (a AutoscalerConfig) EffectiveCreatedJobsFactor() float64 {
+	if !a.IncludeCreatedJobs {
+		return 0
+	}
+	if a.CreatedJobsFactor == 0 {
+		return DefaultCreatedJobsFactor
+	}
+	return a.CreatedJobsFactor
+}
+
+// EffectivePendingTimeout returns how long an instance may stay pending before it is treated as stuck
+func (a AutoscalerConfig) EffectivePendingTimeout() time.Duration {
+	if a.PendingTimeout == 0 {
+		return DefaultPendingTimeout
+	}
+	return a.PendingTimeout
+}
+
+// EffectiveTagSharing returns how the pending jobs of a tag served by several ASGs are split; "even" when not set
+func (a AutoscalerConfig) EffectiveTagSharing() string {
+	if a.TagSharing == "" {
+		return TagSharingEven
+	}
+	return a.TagSharing
+}
+
+// EffectiveDemandHistoryHalfLife returns the age at which demand learned for predictive-prescale counts half
+func (a AutoscalerConfig) EffectiveDemandHistoryHalfLife() time.Duration {
+	if a.DemandHistoryHalfLife == 0 {
+		return DefaultDemandHistoryHalfLife
+	}
+	return a.DemandHistoryHalfLife
+}
+
+// EffectiveErrorBackoffMax returns the cap of the widened evaluation interval of a failing ASG
+func (a AutoscalerConfig) EffectiveErrorBackoffMax() time.Duration {
+	if a.ErrorBackoffMax == 0 {
+		return DefaultErrorBackoffMax
+	}
+	return a.ErrorBackoffMax
+}
+
+// EffectiveOverlappingTags returns how tags served by several ASGs are treated; "warn" when not set
+func (a AutoscalerConfig) EffectiveOverlappingTags() string {
+	if a.OverlappingTags == "" {
+		return OverlappingTagsWarn
+	}
+	return a.OverlappingTags
+}
+
+// EffectiveAuditLogMaxSize returns the size in bytes at which the audit log is rotated
+func (a AutoscalerConfig) EffectiveAuditLogMaxSize() int64 {
+	if a.AuditLogMaxSize == 0 {
+		return DefaultAuditLogMaxSize << 20
+	}
+	return a.AuditLogMaxSize << 20
+}
+
+// assumesRole tells whether any call of the provider assumes an IAM role
+func (p ProviderConfig) assumesRole() bool {
+	return p.RoleARN != "" || p.ReadRoleARN != "" || p.WriteRoleARN != ""
+}
+
+// validateRoles rejects role ARNs that are not IAM role ARNs and an external-id without a role to assume
+func (p ProviderConfig) validateRoles() error {
+	for _, role := range []struct{ field, arn string }{
+		{"role-arn", p.RoleARN}, {"read-role-arn", p.ReadRoleARN}, {"write-role-arn", p.WriteRoleARN},
+	} {
+		if err := validateRoleARN(role.field, role.arn); err != nil {
+			return err
+		}
+	}
+	for _, asg := range p.AsgNames {
+		if err := validateRoleARN("role-arn of ASG "+asg.Name, asg.RoleARN); err != nil {
+			return err
+		}
+	}
+	if p.ExternalID != "" && !p.assumesRole() {
+		return fmt.Errorf("external-id requires role-arn, read-role-arn or write-role-arn")
+	}
+	return nil
+}
 
-	if len(cfg.GitLab.Token) > 0 {
-		fmt.Printf("gitlab-autoscaler. version: %s commit hash: %s\n", version, commitHash)
-	} else {
-		fmt.Printf("gitlab-autoscaler. version: %s (unconfigured)\n", version)
+// validateEndpointURL requires an absolute http or https URL, e.g. http://localhost:4566
+func validateEndpointURL(endpoint string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return fmt.Errorf("is not a valid URL: %w", err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("must be an http or https URL with a host, got %q", endpoint)
+	}
+	return nil
+}
+
+// validatePluginAddress rejects a plugin address that is not unix:///path, tcp://host:port or host:port
+func validatePluginAddress(address string) error {
+	if path, ok := strings.CutPrefix(address, "unix://"); ok {
+		if !strings.HasPrefix(path, "/") {
+			return fmt.Errorf("must name an absolute socket path, e.g. unix:///run/plugin.sock, got %q", address)
+		}
+		return nil
+	}
+	hostPort := strings.TrimPrefix(address, "tcp://")
+	if _, port, err := net.SplitHostPort(hostPort); err != nil || port == "" || strings.Contains(hostPort, "://") {
+		return fmt.Errorf("must be unix:///path, tcp://host:port or host:port, got %q", address)
+	}
+	return nil
+}
+
+// validateLifecycleHook rejects a non-empty value that is not a valid lifecycle hook name: up to 255 letters,
+// digits, hyphens, underscores and slashes
+func validateLifecycleHook(name string) error {
+	if len(name) > 255 {
+		return fmt.Errorf("must be at most 255 characters, got %d", len(name))
+	}
+	for _, r := range name {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || strings.ContainsRune("-_/", r)) {
+			return fmt.Errorf("%q may only contain letters, digits, '-', '_' and '/'", name)
+		}
+	}
+	return nil
+}
+
+// validateRoleARN rejects a non-empty value that is not an IAM role ARN, e.g. arn:aws:iam::123456789012:role/name
+func validateRoleARN(field, arn string) error {
+	if arn == "" {
+		return nil
+	}
+	if !strings.HasPrefix(arn, "arn:") || !strings.Contains(arn, ":role/") {
+		return fmt.Errorf("%s must be an IAM role ARN like arn:aws:iam::123456789012:role/name, got %q", field, arn)
+	}
+	return nil
+}
+
+// EffectiveType returns the built-in provider serving the entry name: type when set, the name otherwise
+func (p ProviderConfig) EffectiveType(name string) string {
+	if p.Type != "" {
+		return strings.ToLower(p.Type)
+	}
+	return strings.ToLower(name)
+}
+
+// EffectiveMaxRetries returns how often a throttled or transiently failing API call is retried
+func (p ProviderConfig) EffectiveMaxRetries() int {
+	switch {
+	case p.MaxRetries == 0:
+		return DefaultMaxRetries
+	case p.MaxRetries < 0:
+		return 0
+	}
+	return p.MaxRetries
+}
+
+// EffectiveRequestTimeout returns the bound of a single provider API call
+func (p ProviderConfig) EffectiveRequestTimeout() time.Duration {
+	if p.RequestTimeout == 0 {
+		return DefaultRequestTimeout
+	}
+	return p.RequestTimeout
+}
+
+// TagAliasLookup returns the synonym -> canonical tag mapping of tag-aliases
+func (a AutoscalerConfig) TagAliasLookup() map[string]string {
+	if len(a.TagAliases) == 0 {
+		return nil
+	}
+	lookup := make(map[string]string)
+	for canonical, synonyms := range a.TagAliases {
+		for _, synonym := range synonyms {
+			lookup[synonym] = canonical
+		}
+	}
+	return lookup
+}
+
+// TagOverlap is a tag served by several ASGs, which split its pending jobs as tag-sharing says
+type TagOverlap struct {
+	Tag  string   // Canonical tag, see tag-aliases
+	ASGs []string // Names of the ASGs serving it, sorted
+}
+
+// String formats the overlap as `tag "amd64" is served by ASGs a, b`
+func (o TagOverlap) String() string {
+	return fmt.Sprintf("tag %q is served by ASGs %s", o.Tag, strings.Join(o.ASGs, ", "))
+}
+
+// TagOverlaps returns the tags served by several ASGs, sorted by tag. Synonyms of tag-aliases count as their canonical
+// tag; patterns are compared as written, so a pattern and a literal tag it matches are not reported.
+func (c *Config) TagOverlaps() []TagOverlap {
+	aliases := c.Autoscaler.TagAliasLookup()
+	served := make(map[string][]string)
+	for _, providerCfg := range c.Providers {
+		for _, asg := range providerCfg.AsgNames {
+			seen := make(map[string]bool)
+			for _, tag := range asg.Tags {
+				if canonical, ok := aliases[tag]; ok {
+					tag = canonical
+				}
+				if !seen[tag] {
+					seen[tag] = true
+					served[tag] = append(served[tag], asg.Name)
+				}
+			}
+		}
 	}
 
-	fmt.Printf("configuration:\n")
-	fmt.Printf("  gitlab private token: %s\n", "present")
-	fmt.Printf("  gitlab group name: %s\n", cfg.GitLab.Group)
-	fmt.Printf("  check interval: %d seconds\n", cfg.Autoscaler.CheckInterval)
+	var overlaps []TagOverlap
+	for _, tag := range slices.Sorted(maps.Keys(served)) {
+		if asgs := served[tag]; len(asgs) > 1 {
+			sort.Strings(asgs)
+			overlaps = append(overlaps, TagOverlap{Tag: tag, ASGs: asgs})
+		}
+	}
+	return overlaps
+}
 
-	// Print ASGs from the AWS provider (if it exists in Providers)
-	if awsConfig, ok := cfg.Providers["aws"]; ok {
-		fmt.Println("\naws asg names:")
-		for _, asg := range awsConfig.AsgNames {
-			fmt.Printf("  - name: %-40s region: %-15s max capacity: %-3d scale to zero: %t  tags: %v\n",
-				asg.Name, asg.Region, asg.MaxAsgCapacity, asg.ScaleToZero, asg.Tags)
+// CheckOverlappingTags rejects tags served by several ASGs when overlapping-tags is "error", listing each with the
+// ASGs serving it; otherwise they are accepted
+func (c *Config) CheckOverlappingTags() error {
+	if c.Autoscaler.EffectiveOverlappingTags() != OverlappingTagsError {
+		return nil
+	}
+	overlaps := c.TagOverlaps()
+	if len(overlaps) == 0 {
+		return nil
+	}
+	reports := make([]string, len(overlaps))
+	for i, overlap := range overlaps {
+		reports[i] = overlap.String()
+	}
+	return fmt.Errorf("overlapping-tags is %q: %s", OverlappingTagsError, strings.Join(reports, "; "))
+}
+
+// validateTagAliases rejects synonyms that are canonical tags themselves or belong to several canonical tags
+func validateTagAliases(aliases map[string][]string) error {
+	canonicals := make([]string, 0, len(aliases))
+	for canonical := range aliases {
+		canonicals = append(canonicals, canonical)
+	}
+	sort.Strings(canonicals)
+
+	owner := make(map[string]string)
+	for _, canonical := range canonicals {
+		for _, synonym := range aliases[canonical] {
+			if _, ok := aliases[synonym]; ok && synonym != canonical {
+				return fmt.Errorf("tag-aliases: %q is a synonym of %q and also a canonical tag", synonym, canonical)
+			}
+			if other, ok := owner[synonym]; ok && other != canonical {
+				return fmt.Errorf("tag-aliases: %q is a synonym of both %q and %q", synonym, other, canonical)
+			}
+			owner[synonym] = canonical
 		}
-	} else {
-		fmt.Println("\nNo AWS ASGs configured")
 	}
+	return nil
+}
 
-	// Print other providers if needed
-	for providerName, config := range cfg.Providers {
-		if providerName == "aws" {
-			continue // Already printed above
+// validateTagLimits rejects negative limits and tags that cannot match a counted job tag
+func validateTagLimits(limits map[string]int64, aliases map[string]string) error {
+	for tag, limit := range limits {
+		if IsTagPattern(tag) {
+			return fmt.Errorf("tag-limits: %q must be a literal tag", tag)
+		}
+		if canonical, ok := aliases[tag]; ok {
+			return fmt.Errorf("tag-limits: %q is a synonym of %q; limit the canonical tag", tag, canonical)
+		}
+		if limit < 0 {
+			return fmt.Errorf("tag-limits: limit of %q must be non-negative", tag)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the fault injection probabilities are within 0..1 and the latency is non-negative
+func (f *FaultInjectionConfig) Validate() error {
+	probabilities := []struct {
+		name  string
+		value float64
+	}{
+		{"gitlab-fetch-error", f.GitLabFetchError},
+		{"provider-describe-error", f.ProviderDescribeError},
+		{"provider-update-error", f.ProviderUpdateError},
+		{"latency-probability", f.LatencyProbability},
+	}
+	for _, p := range probabilities {
+		if p.value < 0 || p.value > 1 {
+			return fmt.Errorf("testing.fault-injection.%s must be between 0 and 1, got %v", p.name, p.value)
 		}
-		fmt.Printf("\n%s asg names:\n", providerName)
-		for _, asg := range config.AsgNames {
-			fmt.Printf("  - name: %-40s region: %-15s max capacity: %-3d scale to zero: %t  tags: %v\n",
-				asg.Name, asg.Region, asg.MaxAsgCapacity, asg.ScaleToZero, asg.Tags)
+	}
+	if f.Latency < 0 {
+		return fmt.Errorf("testing.fault-injection.latency must be non-negative")
+	}
+	return nil
+}
+
+// PrintConfiguration prints the configuration to standard output for debugging
+func PrintConfiguration(cfg *Config, version string, commitHash string) {
+	fmt.Printf("gitlab-autoscaler. version: %s commit hash: %s\n", version, commitHash)
+	names := make([]string, 0, len(cfg.Providers))
+	for providerName := range cfg.Providers {
+		names = append(names, providerName)
+	}
+	sort.Strings(names)
+	for _, providerName := range names {
+		providerCfg := cfg.Providers[providerName]
+		kind := providerCfg.EffectiveType(providerName)
+		if providerCfg.Plugin.IsSet() {
+			kind = "plugin at " + providerCfg.Plugin.Address
 		}
+		fmt.Printf("provider %s: %s, %d ASGs\n", providerName, kind, len(providerCfg.AsgNames))
+	}
+	if cfg.Autoscaler.DryRun {
+		fmt.Println("dry-run: scaling decisions are logged, capacity is never changed")
 	}
+	fmt.Printf("configuration:\n%s", Render(cfg))
 }
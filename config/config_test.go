@@ -0,0 +1,813 @@
+package config
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+)
+
+// validConfig returns a minimal configuration that passes validation
+func validConfig() Config {
+	return Config{
+		GitLab:     GitLabConfig{Token: "token", Group: "mygroup"},
+		Autoscaler: AutoscalerConfig{CheckInterval: 10},
+		Providers: map[string]ProviderConfig{
+			"aws": {AsgNames: []Asg{{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 3}}},
+		},
+	}
+}
+
+// TestAsgValidate_MaxCapacityCeiling verifies max-asg-capacity is checked against the documented ceiling
+// Expected behavior:
+//   - MaxAsgCapacityCeiling is accepted
+//   - MaxAsgCapacityCeiling + 1 and int32-overflowing values are rejected with the offending value
+func TestAsgValidate_MaxCapacityCeiling(t *testing.T) {
+	asg := Asg{Name: "test-asg", MaxAsgCapacity: MaxAsgCapacityCeiling}
+	assert.NoError(t, asg.Validate())
+
+	asg.MaxAsgCapacity = MaxAsgCapacityCeiling + 1
+	err := asg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "10001")
+
+	asg.MaxAsgCapacity = 9999999999
+	err = asg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "9999999999")
+}
+
+// TestConfigValidate_Valid verifies the baseline configuration used by the tests is valid
+func TestConfigValidate_Valid(t *testing.T) {
+	cfg := validConfig()
+	assert.NoError(t, cfg.Validate())
+}
+
+// TestGitLabScope verifies project membership and subset validation of per-ASG scopes
+// Expected behavior:
+//   - Group scopes include nested subgroups but not sibling groups sharing a prefix
+//   - Scopes outside the configured group are rejected
+func TestGitLabScope(t *testing.T) {
+	scope := GitLabScope{Group: "mygroup/team-a", Projects: []string{"mygroup/tools/app"}}
+
+	assert.True(t, scope.Contains("mygroup/team-a/app"))
+	assert.True(t, scope.Contains("mygroup/team-a/nested/app"))
+	assert.True(t, scope.Contains("mygroup/tools/app"))
+	assert.False(t, scope.Contains("mygroup/team-ab/app"))
+	assert.False(t, scope.Contains("mygroup/tools/other"))
+
+	assert.NoError(t, scope.Validate("mygroup"))
+	assert.Error(t, scope.Validate("othergroup"))
+	assert.Error(t, GitLabScope{Projects: []string{"othergroup/app"}}.Validate("mygroup"))
+
+	assert.Equal(t, GitLabScope{Projects: []string{"b", "a"}}.Key(), GitLabScope{Projects: []string{"a", "b"}}.Key())
+}
+
+// TestConfigValidate_FaultInjection verifies fault injection probabilities are bounded to 0..1
+func TestConfigValidate_FaultInjection(t *testing.T) {
+	cfg := validConfig()
+	cfg.Testing.FaultInjection = FaultInjectionConfig{Enabled: true, GitLabFetchError: 1, ProviderUpdateError: 0.5}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Testing.FaultInjection.ProviderDescribeError = 1.5
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "provider-describe-error")
+}
+
+// TestConfigValidate_GroupOrProjects verifies exactly one of gitlab.group and gitlab.projects is required
+// Expected behavior:
+//   - projects without a group are valid
+//   - both or neither are rejected
+//   - group-only features are rejected in projects mode
+func TestConfigValidate_GroupOrProjects(t *testing.T) {
+	cfg := validConfig()
+	cfg.GitLab.Group = ""
+	cfg.GitLab.Projects = []string{"org/repo", "42"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Autoscaler.RunnerReconciliation = RunnerReconciliationWarn
+	assert.Error(t, cfg.Validate())
+	cfg.Autoscaler.RunnerReconciliation = ""
+
+	cfg.GitLab.Group = "mygroup"
+	assert.Error(t, cfg.Validate())
+
+	cfg.GitLab.Group = ""
+	cfg.GitLab.Projects = nil
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_TagAliases verifies a synonym may not also be a canonical tag or belong to two canonical tags
+func TestConfigValidate_TagAliases(t *testing.T) {
+	cfg := validConfig()
+	cfg.Autoscaler.TagAliases = map[string][]string{
+		"amd64": {"linux", "x86_64"},
+		"arm64": {"aarch64"},
+	}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, map[string]string{"linux": "amd64", "x86_64": "amd64", "aarch64": "arm64"}, cfg.Autoscaler.TagAliasLookup())
+
+	cfg.Autoscaler.TagAliases["arm64"] = []string{"aarch64", "amd64"}
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `"amd64" is a synonym of "arm64"`)
+
+	cfg.Autoscaler.TagAliases["arm64"] = []string{"linux"}
+	assert.Error(t, cfg.Validate())
+}
+
+// TestAsgValidate_MinAsgCapacity verifies min-asg-capacity fits within max-asg-capacity and does not contradict scale-to-zero
+func TestAsgValidate_MinAsgCapacity(t *testing.T) {
+	asg := Asg{Name: "test-asg", MinAsgCapacity: 2, MaxAsgCapacity: 5}
+	assert.NoError(t, asg.Validate())
+	assert.Equal(t, int64(2), asg.EffectiveMinCapacity())
+
+	asg.MinAsgCapacity = 6
+	err := asg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "min-asg-capacity 6 exceeds max-asg-capacity 5")
+
+	asg.MinAsgCapacity, asg.ScaleToZero = 2, true
+	assert.Error(t, asg.Validate())
+
+	asg.MinAsgCapacity = 0
+	assert.Equal(t, int64(0), asg.EffectiveMinCapacity())
+	asg.ScaleToZero = false
+	assert.Equal(t, int64(1), asg.EffectiveMinCapacity())
+}
+
+// TestConfigValidate_TagLimits verifies tag-limits only accept non-negative limits of literal canonical tags
+func TestConfigValidate_TagLimits(t *testing.T) {
+	cfg := validConfig()
+	cfg.Autoscaler.TagAliases = map[string][]string{"amd64": {"linux"}}
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 4, "amd64": 0}
+	assert.NoError(t, cfg.Validate())
+
+	for tag, limit := range map[string]int64{"gpu": -1, "glob:gpu-*": 4, "linux": 4} {
+		cfg.Autoscaler.TagLimits = map[string]int64{tag: limit}
+		assert.Error(t, cfg.Validate(), tag)
+	}
+}
+
+// TestExpandTags verifies glob and regex entries are resolved against live tags
+// Expected behavior:
+//   - Literal entries are kept even when no job carries them
+//   - A tag matched by several entries appears once
+//   - Invalid patterns fail validation
+func TestExpandTags(t *testing.T) {
+	live := []string{"gpu-a100", "team-payments-runner", "team-search-runner", "amd64"}
+
+	tags := ExpandTags([]string{"amd64", "glob:team-*-runner", "re:^team-pay", "re:^gpu-.*$", "arm64"}, live)
+	assert.Equal(t, []string{"amd64", "team-payments-runner", "team-search-runner", "gpu-a100", "arm64"}, tags)
+
+	asg := Asg{Name: "test-asg", Tags: []string{"re:^gpu-(.*$"}}
+	assert.Error(t, asg.Validate())
+	asg.Tags = []string{"glob:team-[a-"}
+	assert.Error(t, asg.Validate())
+}
+
+// TestConfigValidate_PreviousNames verifies a previous name may not still be an active ASG
+func TestConfigValidate_PreviousNames(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "runners-green", Tags: []string{"amd64"}, MaxAsgCapacity: 3, PreviousNames: []string{"runners-blue"}},
+	}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "runners-green", Tags: []string{"amd64"}, MaxAsgCapacity: 3, PreviousNames: []string{"runners-blue"}},
+		{Name: "runners-blue", Tags: []string{"amd64"}, MaxAsgCapacity: 3},
+	}}
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `previous name "runners-blue" is still an active ASG`)
+}
+
+// TestAsgValidate_ExcludeTags verifies excluded tags must be literal and not served at the same time
+func TestAsgValidate_ExcludeTags(t *testing.T) {
+	asg := Asg{Name: "test-asg", Tags: []string{"amd64"}, ExcludeTags: []string{"privileged"}}
+	assert.NoError(t, asg.Validate())
+
+	asg.ExcludeTags = []string{"amd64"}
+	assert.Error(t, asg.Validate())
+
+	asg.ExcludeTags = []string{"glob:priv*"}
+	assert.Error(t, asg.Validate())
+}
+
+// TestAsgValidate_TagWeights verifies tag-weights only accept positive weights of served tags
+// Expected behavior:
+//   - Weights of literal tags and of tags matched by a pattern entry are accepted
+//   - Zero, negative and NaN weights are rejected
+//   - A weight of a tag the ASG does not serve is rejected
+func TestAsgValidate_TagWeights(t *testing.T) {
+	asg := Asg{Name: "test-asg", Tags: []string{"amd64", "glob:team-*"}, TagWeights: map[string]float64{"amd64": 0.5, "team-a": 2}}
+	assert.NoError(t, asg.Validate())
+	assert.Equal(t, 2.0, asg.TagWeight("team-a"))
+	assert.Equal(t, 1.0, asg.TagWeight("docker"))
+
+	for _, weight := range []float64{0, -1, math.NaN()} {
+		asg.TagWeights = map[string]float64{"amd64": weight}
+		assert.Error(t, asg.Validate(), "weight %v", weight)
+	}
+
+	asg.TagWeights = map[string]float64{"arm64": 2}
+	err := asg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), `tag "arm64" is not served`)
+}
+
+// TestAsgValidate_WarmSlots verifies warm-slots is non-negative and warm-slots-only-when-active needs it with scale-to-zero
+func TestAsgValidate_WarmSlots(t *testing.T) {
+	asg := Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 3, WarmSlots: 2}
+	assert.NoError(t, asg.Validate())
+
+	asg.WarmSlots = -1
+	assert.Error(t, asg.Validate())
+
+	asg.WarmSlots, asg.WarmSlotsOnlyWhenActive = 2, true
+	assert.Error(t, asg.Validate())
+
+	asg.ScaleToZero = true
+	assert.NoError(t, asg.Validate())
+
+	asg.WarmSlots = 0
+	assert.Error(t, asg.Validate())
+}
+
+// TestAsgValidate_PredictivePrescale verifies predictive-max and its default
+func TestAsgValidate_PredictivePrescale(t *testing.T) {
+	asg := Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, PredictivePrescale: true}
+	assert.NoError(t, asg.Validate())
+	assert.Equal(t, int64(5), asg.EffectivePredictiveMax())
+
+	asg.PredictiveMax = 3
+	assert.NoError(t, asg.Validate())
+	assert.Equal(t, int64(3), asg.EffectivePredictiveMax())
+
+	asg.PredictiveMax = -1
+	assert.Error(t, asg.Validate())
+
+	asg.PredictiveMax, asg.PredictivePrescale = 3, false
+	assert.Error(t, asg.Validate())
+
+	cfg := validConfig()
+	assert.Equal(t, DefaultDemandHistoryHalfLife, cfg.Autoscaler.EffectiveDemandHistoryHalfLife())
+	cfg.Autoscaler.DemandHistoryHalfLife = -time.Hour
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_TagSharing verifies the tag-sharing strategies and its default
+func TestConfigValidate_TagSharing(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, TagSharingEven, cfg.Autoscaler.EffectiveTagSharing())
+
+	for _, strategy := range []string{TagSharingEven, TagSharingHeadroom, TagSharingPriority, TagSharingDuplicate} {
+		cfg.Autoscaler.TagSharing = strategy
+		assert.NoError(t, cfg.Validate(), strategy)
+	}
+
+	cfg.Autoscaler.TagSharing = "random"
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_SharedTagScaleDown verifies shared-tag-scale-down accepts hold, allow or empty
+func TestConfigValidate_SharedTagScaleDown(t *testing.T) {
+	cfg := validConfig()
+	for _, mode := range []string{"", SharedTagScaleDownHold, SharedTagScaleDownAllow} {
+		cfg.Autoscaler.SharedTagScaleDown = mode
+		assert.NoError(t, cfg.Validate(), mode)
+	}
+
+	cfg.Autoscaler.SharedTagScaleDown = "never"
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_MaxRetries verifies max-retries defaults to DefaultMaxRetries, -1 disables retries and
+// anything below is rejected
+func TestConfigValidate_MaxRetries(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, DefaultMaxRetries, cfg.Providers["aws"].EffectiveMaxRetries())
+
+	for retries, effective := range map[int]int{-1: 0, 5: 5} {
+		provider := cfg.Providers["aws"]
+		provider.MaxRetries = retries
+		cfg.Providers["aws"] = provider
+		assert.NoError(t, cfg.Validate(), retries)
+		assert.Equal(t, effective, provider.EffectiveMaxRetries(), retries)
+	}
+
+	provider := cfg.Providers["aws"]
+	provider.MaxRetries = -2
+	cfg.Providers["aws"] = provider
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_RequestTimeout verifies request-timeout defaults to DefaultRequestTimeout and may not be negative
+func TestConfigValidate_RequestTimeout(t *testing.T) {
+	cfg := validConfig()
+	assert.Equal(t, DefaultRequestTimeout, cfg.Providers["aws"].EffectiveRequestTimeout())
+
+	provider := cfg.Providers["aws"]
+	provider.RequestTimeout = -time.Second
+	cfg.Providers["aws"] = provider
+	assert.Error(t, cfg.Validate())
+}
+
+// TestConfigValidate_Roles verifies role ARNs must be IAM role ARNs and an external-id needs a role to assume
+// Expected behavior:
+//   - role-arn, external-id and role-session-name of the provider and an ASG are accepted
+//   - A role ARN of another resource type is rejected naming the field
+//   - external-id without any role on the provider or the ASG is rejected
+func TestConfigValidate_Roles(t *testing.T) {
+	cfg := validConfig()
+	provider := cfg.Providers["aws"]
+	provider.RoleARN = "arn:aws:iam::111111111111:role/autoscaler"
+	provider.ExternalID = "external"
+	provider.RoleSessionName = "autoscaler"
+	provider.AsgNames[0].RoleARN = "arn:aws:iam::222222222222:role/autoscaler"
+	provider.AsgNames[0].ExternalID = "other"
+	cfg.Providers["aws"] = provider
+	assert.NoError(t, cfg.Validate())
+
+	provider.AsgNames[0].RoleARN = "arn:aws:iam::222222222222:user/autoscaler"
+	err := cfg.Validate()
+	assert.ErrorContains(t, err, "role-arn of ASG test-asg must be an IAM role ARN")
+
+	provider.AsgNames[0].RoleARN = ""
+	provider.RoleARN = ""
+	cfg.Providers["aws"] = provider
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, "external-id requires role-arn")
+
+	provider.ExternalID = ""
+	cfg.Providers["aws"] = provider
+	err = cfg.Validate()
+	assert.ErrorContains(t, err, "asg[0]: external-id requires role-arn on the ASG or the provider")
+}
+
+// TestConfigValidate_EndpointURL verifies endpoint-url must be an http or https URL with a host
+func TestConfigValidate_EndpointURL(t *testing.T) {
+	cfg := validConfig()
+	provider := cfg.Providers["aws"]
+	for endpoint, valid := range map[string]bool{
+		"http://localhost:4566":                       true,
+		"https://autoscaling.eu-west-1.amazonaws.com": true,
+		"localhost:4566":                              false,
+		"http://":                                     false,
+		"http://local host:4566":                      false,
+	} {
+		provider.EndpointURL = endpoint
+		cfg.Providers["aws"] = provider
+		if valid {
+			assert.NoError(t, cfg.Validate(), endpoint)
+		} else {
+			assert.ErrorContains(t, cfg.Validate(), "endpoint-url", endpoint)
+		}
+	}
+}
+
+// TestConfigValidate_ExportOnly verifies export-only values, that fleeting ASGs have somewhere to publish
+// and that their instances, owned by the external scaler, are not protected
+func TestConfigValidate_ExportOnly(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers["aws"].AsgNames[0].ExportOnly = "nomad"
+	assert.Error(t, cfg.Validate())
+
+	cfg.Providers["aws"].AsgNames[0].ExportOnly = ExportFleeting
+	err := cfg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "requires fleeting.listen or fleeting.file")
+
+	cfg.Fleeting.File = "/var/lib/gitlab-autoscaler/fleeting.json"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Providers["aws"].AsgNames[0].ProtectBusyInstances = true
+	assert.ErrorContains(t, cfg.Validate(), "protect-busy-instances cannot be used with export-only")
+
+	cfg.Providers["aws"].AsgNames[0].ProtectBusyInstances = false
+	cfg.Providers["aws"].AsgNames[0].MaxInstanceAge = 168 * time.Hour
+	assert.ErrorContains(t, cfg.Validate(), "max-instance-age cannot be used with export-only")
+}
+
+// TestConfigValidate_LifecycleHook verifies lifecycle hook names are checked like AWS does
+func TestConfigValidate_LifecycleHook(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers["aws"].AsgNames[0].LifecycleHook = "runners/drain_runner-1"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Providers["aws"].AsgNames[0].LifecycleHook = "drain runner"
+	assert.ErrorContains(t, cfg.Validate(), "lifecycle-hook \"drain runner\" may only contain")
+
+	cfg.Providers["aws"].AsgNames[0].LifecycleHook = strings.Repeat("a", 256)
+	assert.ErrorContains(t, cfg.Validate(), "lifecycle-hook must be at most 255 characters")
+}
+
+// TestConfigValidate_AsgDiscovery verifies an asg-discovery tag value needs a tag key
+func TestConfigValidate_AsgDiscovery(t *testing.T) {
+	cfg := validConfig()
+	provider := cfg.Providers["aws"]
+	provider.AsgDiscovery = AsgDiscovery{TagValue: "true"}
+	cfg.Providers["aws"] = provider
+	assert.ErrorContains(t, cfg.Validate(), "asg-discovery.tag-value requires tag-key")
+
+	provider.AsgDiscovery.TagKey = "gitlab-autoscaler:enabled"
+	cfg.Providers["aws"] = provider
+	assert.NoError(t, cfg.Validate())
+}
+
+// TestConfigValidate_GCP verifies the gcp provider needs a project and the location of its groups
+func TestConfigValidate_GCP(t *testing.T) {
+	cfg := validConfig()
+	gcp := ProviderConfig{AsgNames: []Asg{{Name: "runners", Tags: []string{"gcp"}, MaxAsgCapacity: 2}}}
+	cfg.Providers[ProviderGCP] = gcp
+	assert.ErrorContains(t, cfg.Validate(), "provider gcp: project is required")
+
+	gcp.Project = "ci-runners"
+	cfg.Providers[ProviderGCP] = gcp
+	assert.ErrorContains(t, cfg.Validate(), "provider gcp: region or default-zone is required")
+
+	gcp.DefaultZone = "europe-west1-b"
+	cfg.Providers[ProviderGCP] = gcp
+	assert.NoError(t, cfg.Validate())
+
+	gcp.AsgNames[0].Region = "europe-west4"
+	assert.ErrorContains(t, cfg.Validate(), "cannot be used with default-zone")
+}
+
+// TestConfigValidate_Azure verifies the azure provider needs its scale sets located and a complete service principal
+func TestConfigValidate_Azure(t *testing.T) {
+	cfg := validConfig()
+	azure := ProviderConfig{AsgNames: []Asg{{Name: "runners", Tags: []string{"azure"}, MaxAsgCapacity: 2}}}
+	cfg.Providers[ProviderAzure] = azure
+	assert.ErrorContains(t, cfg.Validate(), "provider azure: subscription-id and resource-group are required")
+
+	azure.SubscriptionID, azure.ResourceGroup = "00000000-0000-0000-0000-000000000000", "ci-runners"
+	cfg.Providers[ProviderAzure] = azure
+	assert.NoError(t, cfg.Validate())
+
+	azure.ClientSecret = "secret"
+	cfg.Providers[ProviderAzure] = azure
+	assert.ErrorContains(t, cfg.Validate(), "provider azure: client-secret requires tenant-id and client-id")
+
+	azure.TenantID, azure.ClientID = "11111111-1111-1111-1111-111111111111", "22222222-2222-2222-2222-222222222222"
+	cfg.Providers[ProviderAzure] = azure
+	assert.NoError(t, cfg.Validate())
+}
+
+// TestConfigValidate_K8s verifies runner Deployments of the k8s provider are named namespace/deployment
+func TestConfigValidate_K8s(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers[ProviderK8s] = ProviderConfig{AsgNames: []Asg{{Name: "ci/runners", Tags: []string{"k8s"}, MaxAsgCapacity: 2}}}
+	assert.NoError(t, cfg.Validate())
+
+	for _, name := range []string{"runners", "/runners", "ci/", "ci/runners/extra"} {
+		cfg.Providers[ProviderK8s] = ProviderConfig{AsgNames: []Asg{{Name: name, Tags: []string{"k8s"}, MaxAsgCapacity: 2}}}
+		assert.ErrorContains(t, cfg.Validate(), "must be namespace/deployment", name)
+	}
+}
+
+// TestConfigValidate_DigitalOcean verifies the pools of the digitalocean provider
+// Expected behavior:
+//   - A pool needs a valid tag as its name, droplet.size and droplet.image, and a region
+//   - droplet settings are rejected on other providers
+func TestConfigValidate_DigitalOcean(t *testing.T) {
+	cfg := validConfig()
+	pool := Asg{Name: "ci:docker", Tags: []string{"docker"}, MaxAsgCapacity: 2, Droplet: DropletSettings{Size: "s-2vcpu-4gb", Image: "ubuntu-24-04-x64"}}
+	cfg.Providers[ProviderDigitalOcean] = ProviderConfig{Region: "fra1", AsgNames: []Asg{pool}}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Providers[ProviderDigitalOcean] = ProviderConfig{AsgNames: []Asg{pool}}
+	assert.ErrorContains(t, cfg.Validate(), "region is required")
+
+	invalid := pool
+	invalid.Name = "ci docker"
+	cfg.Providers[ProviderDigitalOcean] = ProviderConfig{Region: "fra1", AsgNames: []Asg{invalid}}
+	assert.ErrorContains(t, cfg.Validate(), "must be a droplet tag")
+
+	invalid = pool
+	invalid.Droplet.Image = ""
+	cfg.Providers[ProviderDigitalOcean] = ProviderConfig{Region: "fra1", AsgNames: []Asg{invalid}}
+	assert.ErrorContains(t, cfg.Validate(), "droplet.size and droplet.image are required")
+
+	cfg = validConfig()
+	aws := cfg.Providers["aws"]
+	aws.AsgNames[0].Droplet.Size = "s-2vcpu-4gb"
+	cfg.Providers["aws"] = aws
+	assert.ErrorContains(t, cfg.Validate(), "droplet is only supported by the digitalocean provider")
+}
+
+// TestConfigValidate_Exec verifies the exec provider needs a command or a webhook for each operation
+func TestConfigValidate_Exec(t *testing.T) {
+	cfg := validConfig()
+	scaler := ProviderConfig{AsgNames: []Asg{{Name: "proxmox-pool", Tags: []string{"proxmox"}, MaxAsgCapacity: 2}}}
+	cfg.Providers[ProviderExec] = scaler
+	assert.ErrorContains(t, cfg.Validate(), "exactly one of get-capacity-cmd and get-capacity-url is required")
+
+	scaler.GetCapacityCmd, scaler.GetCapacityURL = "/usr/local/bin/pool capacity", "https://scaler.example.com/capacity"
+	cfg.Providers[ProviderExec] = scaler
+	assert.ErrorContains(t, cfg.Validate(), "exactly one of get-capacity-cmd and get-capacity-url is required")
+
+	scaler.GetCapacityURL = ""
+	cfg.Providers[ProviderExec] = scaler
+	assert.ErrorContains(t, cfg.Validate(), "exactly one of set-capacity-cmd and set-capacity-url is required")
+
+	scaler.SetCapacityURL = "scaler.example.com/scale"
+	cfg.Providers[ProviderExec] = scaler
+	assert.ErrorContains(t, cfg.Validate(), "set-capacity-url must be an http or https URL")
+
+	scaler.SetCapacityURL = "https://scaler.example.com/scale"
+	cfg.Providers[ProviderExec] = scaler
+	assert.NoError(t, cfg.Validate())
+}
+
+// TestConfigValidate_Plugin verifies the plugin of a provider entry
+// Expected behavior:
+//   - unix:///path, tcp://host:port and host:port addresses are accepted
+//   - Relative socket paths, other schemes and addresses without a port are rejected
+//   - A plugin cannot take over the name of a built-in provider
+func TestConfigValidate_Plugin(t *testing.T) {
+	cfg := validConfig()
+	for _, address := range []string{"unix:///run/plugin.sock", "tcp://127.0.0.1:9000", "plugin.internal:9000"} {
+		cfg.Providers["proxmox"] = ProviderConfig{Plugin: PluginConfig{Address: address}, AsgNames: []Asg{{Name: "pool", Tags: []string{"proxmox"}, MaxAsgCapacity: 2}}}
+		assert.NoError(t, cfg.Validate(), address)
+	}
+	for _, address := range []string{"unix://run/plugin.sock", "http://plugin.internal:9000", "plugin.internal"} {
+		cfg.Providers["proxmox"] = ProviderConfig{Plugin: PluginConfig{Address: address}, AsgNames: []Asg{{Name: "pool", Tags: []string{"proxmox"}, MaxAsgCapacity: 2}}}
+		assert.ErrorContains(t, cfg.Validate(), "provider proxmox: plugin.address", address)
+	}
+
+	cfg = validConfig()
+	aws := cfg.Providers["aws"]
+	aws.Plugin.Address = "unix:///run/plugin.sock"
+	cfg.Providers["aws"] = aws
+	assert.ErrorContains(t, cfg.Validate(), "plugin needs a provider name of its own, aws is a built-in provider")
+}
+
+// TestConfigValidate_ProviderType verifies entries named freely are served by the provider of their type
+// Expected behavior:
+//   - An entry with type aws is valid under any name, bare "aws" keeps working
+//   - Checks of a provider apply to entries of its type
+//   - Unknown types, type with plugin, and ASGs listed by several entries are rejected
+func TestConfigValidate_ProviderType(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers["aws-prod"] = ProviderConfig{Type: "aws", AsgNames: []Asg{{Name: "prod-runners", Tags: []string{"prod"}, MaxAsgCapacity: 2}}}
+	assert.NoError(t, cfg.Validate())
+	assert.Equal(t, ProviderAWS, cfg.Providers["aws-prod"].EffectiveType("aws-prod"))
+	assert.Equal(t, ProviderAWS, cfg.Providers["aws"].EffectiveType("aws"))
+
+	cfg.Providers["gcp-ci"] = ProviderConfig{Type: "gcp", AsgNames: []Asg{{Name: "mig", Tags: []string{"gcp"}, MaxAsgCapacity: 2}}}
+	assert.ErrorContains(t, cfg.Validate(), "provider gcp-ci: project is required")
+	delete(cfg.Providers, "gcp-ci")
+
+	cfg.Providers["aws-dev"] = ProviderConfig{AsgNames: []Asg{{Name: "dev-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2}}}
+	assert.ErrorContains(t, cfg.Validate(), `provider aws-dev: unknown type "aws-dev"`)
+	cfg.Providers["aws-dev"] = ProviderConfig{Type: "ec2", AsgNames: []Asg{{Name: "dev-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2}}}
+	assert.ErrorContains(t, cfg.Validate(), `provider aws-dev: unknown type "ec2"`)
+	cfg.Providers["aws-dev"] = ProviderConfig{Type: "aws", Plugin: PluginConfig{Address: "unix:///run/plugin.sock"}, AsgNames: []Asg{{Name: "dev-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2}}}
+	assert.ErrorContains(t, cfg.Validate(), "provider aws-dev: type and plugin cannot be used together")
+
+	cfg.Providers["aws-dev"] = ProviderConfig{Type: "aws", AsgNames: []Asg{{Name: "prod-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2}}}
+	assert.ErrorContains(t, cfg.Validate(), "asg prod-runners is claimed by providers aws-dev and aws-prod")
+	cfg.Providers["aws-dev"] = ProviderConfig{Type: "aws", AsgNames: []Asg{
+		{Name: "dev-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2},
+		{Name: "dev-runners", Tags: []string{"dev"}, MaxAsgCapacity: 2},
+	}}
+	assert.ErrorContains(t, cfg.Validate(), "provider aws-dev: asg dev-runners is listed twice")
+}
+
+// TestConfigValidate_DuplicateASGs verifies an ASG name listed twice is rejected naming both entries
+// Expected behavior:
+//   - Identical entries of one provider name both indexes
+//   - Entries of one provider with different tags or limits also name the settings that differ
+//   - Identical entries of two providers name both providers and indexes
+//   - Entries of two providers with different settings also name the settings that differ
+func TestConfigValidate_DuplicateASGs(t *testing.T) {
+	asg := Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 3}
+	other := Asg{Name: "other-asg", Tags: []string{"arm64"}, MaxAsgCapacity: 3}
+	changed := Asg{Name: "test-asg", Tags: []string{"arm64"}, MaxAsgCapacity: 5}
+
+	cfg := validConfig()
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{asg, other, asg}}
+	assert.EqualError(t, cfg.Validate(), "provider aws: asg test-asg is listed twice, as asg-names[0] and asg-names[2]")
+
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{asg, changed}}
+	assert.EqualError(t, cfg.Validate(),
+		"provider aws: asg test-asg is listed twice, as asg-names[0] and asg-names[1], with different tags, max-asg-capacity")
+
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{asg}}
+	cfg.Providers["aws-eu"] = ProviderConfig{Type: "aws", AsgNames: []Asg{other, asg}}
+	assert.EqualError(t, cfg.Validate(),
+		"asg test-asg is claimed by providers aws and aws-eu, as aws.asg-names[0] and aws-eu.asg-names[1]")
+
+	cfg.Providers["aws-eu"] = ProviderConfig{Type: "aws", AsgNames: []Asg{changed}}
+	assert.EqualError(t, cfg.Validate(),
+		"asg test-asg is claimed by providers aws and aws-eu, as aws.asg-names[0] and aws-eu.asg-names[0], with different tags, max-asg-capacity")
+
+	cfg.Providers["aws-eu"] = ProviderConfig{Type: "aws", AsgNames: []Asg{other}}
+	assert.NoError(t, cfg.Validate())
+}
+
+// TestConfig_TagOverlaps verifies the tags served by several ASGs are listed with the ASGs serving them
+// Expected behavior:
+//   - Each shared tag is listed once, sorted, with its ASGs sorted, also across providers
+//   - A synonym of tag-aliases counts as its canonical tag
+//   - A tag listed twice by one ASG and tags of a single ASG are no overlap
+func TestConfig_TagOverlaps(t *testing.T) {
+	cfg := validConfig()
+	cfg.Autoscaler.TagAliases = map[string][]string{"amd64": {"x86_64"}}
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "runners-b", Tags: []string{"amd64", "build", "build"}, MaxAsgCapacity: 3},
+		{Name: "runners-a", Tags: []string{"build", "docker"}, MaxAsgCapacity: 3},
+	}}
+	cfg.Providers["aws-eu"] = ProviderConfig{Type: "aws", AsgNames: []Asg{
+		{Name: "runners-eu", Tags: []string{"x86_64", "gpu"}, MaxAsgCapacity: 3},
+	}}
+
+	overlaps := cfg.TagOverlaps()
+
+	assert.Equal(t, []TagOverlap{
+		{Tag: "amd64", ASGs: []string{"runners-b", "runners-eu"}},
+		{Tag: "build", ASGs: []string{"runners-a", "runners-b"}},
+	}, overlaps)
+	assert.Equal(t, `tag "build" is served by ASGs runners-a, runners-b`, overlaps[1].String())
+}
+
+// TestConfigValidate_OverlappingTags verifies overlapping-tags decides whether shared tags reject the configuration
+// Expected behavior:
+//   - warn (the default) and allow accept them
+//   - error rejects them, listing each shared tag with its ASGs
+//   - error accepts ASGs without a shared tag
+//   - Other values are rejected
+func TestConfigValidate_OverlappingTags(t *testing.T) {
+	cfg := validConfig()
+	cfg.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "runners-a", Tags: []string{"amd64", "build"}, MaxAsgCapacity: 3},
+		{Name: "runners-b", Tags: []string{"amd64", "build"}, MaxAsgCapacity: 3},
+	}}
+	assert.Equal(t, OverlappingTagsWarn, cfg.Autoscaler.EffectiveOverlappingTags())
+	assert.NoError(t, cfg.Validate())
+	cfg.Autoscaler.OverlappingTags = OverlappingTagsAllow
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Autoscaler.OverlappingTags = OverlappingTagsError
+	assert.EqualError(t, cfg.Validate(),
+		`overlapping-tags is "error": tag "amd64" is served by ASGs runners-a, runners-b; tag "build" is served by ASGs runners-a, runners-b`)
+	cfg.Providers["aws"].AsgNames[1].Tags = []string{"arm64"}
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Autoscaler.OverlappingTags = "fail"
+	assert.ErrorContains(t, cfg.Validate(), "overlapping-tags must be one of")
+}
+
+// TestConfigValidate_MetricsListen verifies metrics.listen is a host:port address of its own
+func TestConfigValidate_MetricsListen(t *testing.T) {
+	cfg := validConfig()
+	cfg.Metrics.Listen = ":9464"
+	assert.NoError(t, cfg.Validate())
+
+	cfg.Metrics.Listen = "9464"
+	assert.ErrorContains(t, cfg.Validate(), "metrics.listen is not a valid host:port address")
+
+	cfg.Metrics.Listen, cfg.Admin.Listen = "127.0.0.1:8048", "127.0.0.1:8048"
+	assert.ErrorContains(t, cfg.Validate(), "metrics.listen must differ from admin.listen")
+}
+
+// TestDiscoveredAsg verifies the configuration of a discovered ASG is read from its well-known tags
+// Expected behavior:
+//   - Job tags are split on commas and trimmed, max capacity and scale-to-zero are parsed
+//   - Missing or malformed tags and settings rejected by Asg.Validate are errors
+func TestDiscoveredAsg(t *testing.T) {
+	asg, err := DiscoveredAsg("team-a-runners", map[string]string{
+		DiscoveryTagTags:        "amd64, team-a,",
+		DiscoveryTagMaxCapacity: "8",
+		DiscoveryTagScaleToZero: "true",
+		"Name":                  "team-a-runners",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, Asg{Name: "team-a-runners", Tags: []string{"amd64", "team-a"}, MaxAsgCapacity: 8, ScaleToZero: true}, asg)
+
+	for name, tags := range map[string]map[string]string{
+		"tag gitlab-autoscaler:tags is required":              {DiscoveryTagMaxCapacity: "8"},
+		"tag gitlab-autoscaler:max-capacity is required":      {DiscoveryTagTags: "amd64"},
+		"tag gitlab-autoscaler:max-capacity must be a number": {DiscoveryTagTags: "amd64", DiscoveryTagMaxCapacity: "many"},
+		"tag gitlab-autoscaler:scale-to-zero must be true or": {DiscoveryTagTags: "amd64", DiscoveryTagMaxCapacity: "8", DiscoveryTagScaleToZero: "yes please"},
+		"max-asg-capacity 20000 exceeds the ceiling":          {DiscoveryTagTags: "amd64", DiscoveryTagMaxCapacity: "20000"},
+	} {
+		_, err := DiscoveredAsg("team-a-runners", tags)
+		assert.ErrorContains(t, err, name)
+	}
+}
+
+// TestScheduleValidate verifies malformed schedule windows are rejected
+// Expected behavior:
+//   - A well-formed window, including one spanning midnight and one ending at 24:00, is accepted
+//   - Bad HH:MM times, equal start and end, unknown days and time zones are rejected
+//   - min-capacity may not exceed the max-capacity of the window, or max-asg-capacity without one
+func TestScheduleValidate(t *testing.T) {
+	asg := Asg{Name: "test-asg", MaxAsgCapacity: 5}
+	valid := Schedule{Days: []string{"mon-fri"}, Start: "08:00", End: "19:00", Timezone: "Europe/Berlin", MinCapacity: 2}
+	assert.NoError(t, valid.Validate(asg))
+	assert.NoError(t, Schedule{Days: []string{"fri-mon"}, Start: "22:00", End: "06:00"}.Validate(asg))
+	assert.NoError(t, Schedule{Start: "18:00", End: "24:00"}.Validate(asg))
+
+	invalid := map[string]Schedule{
+		"bad start":     {Start: "8:00", End: "19:00"},
+		"bad end":       {Start: "08:00", End: "19:60"},
+		"24:00 start":   {Start: "24:00", End: "06:00"},
+		"equal":         {Start: "08:00", End: "08:00"},
+		"unknown day":   {Days: []string{"mon-fry"}, Start: "08:00", End: "19:00"},
+		"bad timezone":  {Start: "08:00", End: "19:00", Timezone: "Mars/Olympus"},
+		"min above max": {Start: "08:00", End: "19:00", MinCapacity: 4, MaxCapacity: 3},
+		"min above asg": {Start: "08:00", End: "19:00", MinCapacity: 6},
+	}
+	for name, schedule := range invalid {
+		assert.Error(t, schedule.Validate(asg), name)
+	}
+
+	asg.Schedules = []Schedule{valid, invalid["equal"]}
+	err := asg.Validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "schedules[1]")
+}
+
+// TestScheduleActive verifies windows are evaluated in their time zone and may span midnight
+// Expected behavior:
+//   - A weekday window is active inside its hours on its days only, with an exclusive end
+//   - The time zone shifts the window: 08:00 Berlin in summer is 06:00 UTC
+//   - An overnight window belongs to the day it starts on, so Friday 22:00-06:00 covers Saturday 03:00
+func TestScheduleActive(t *testing.T) {
+	weekdays := Schedule{Days: []string{"mon-fri"}, Start: "08:00", End: "19:00", Timezone: "Europe/Berlin"}
+	monday := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC) // Berlin is UTC+2 in May
+
+	assert.False(t, weekdays.Active(monday.Add(5*time.Hour+59*time.Minute)))
+	assert.True(t, weekdays.Active(monday.Add(6*time.Hour)))
+	assert.True(t, weekdays.Active(monday.Add(16*time.Hour+59*time.Minute)))
+	assert.False(t, weekdays.Active(monday.Add(17*time.Hour)))
+	assert.False(t, weekdays.Active(monday.Add(-2*24*time.Hour+10*time.Hour)), "saturday")
+
+	overnight := Schedule{Days: []string{"fri"}, Start: "22:00", End: "06:00"}
+	friday := monday.Add(4 * 24 * time.Hour)
+	assert.True(t, overnight.Active(friday.Add(23*time.Hour)))
+	assert.True(t, overnight.Active(friday.Add(27*time.Hour)))
+	assert.False(t, overnight.Active(friday.Add(3*time.Hour)), "friday early morning belongs to thursday")
+	assert.False(t, overnight.Active(friday.Add(30*time.Hour)))
+}
+
+// TestAsgCapacityBounds verifies active schedules replace min-asg-capacity and max-asg-capacity
+// Expected behavior:
+//   - Outside any window the effective min-asg-capacity and max-asg-capacity apply
+//   - Overlapping windows take the largest min-capacity and the largest max-capacity set
+//   - A window without max-capacity keeps max-asg-capacity
+func TestAsgCapacityBounds(t *testing.T) {
+	asg := Asg{Name: "test-asg", MaxAsgCapacity: 10, Schedules: []Schedule{
+		{Start: "08:00", End: "19:00", MinCapacity: 2},
+		{Start: "12:00", End: "14:00", MinCapacity: 4, MaxCapacity: 6},
+	}}
+	day := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+
+	floor, ceiling, active := asg.CapacityBounds(day.Add(7 * time.Hour))
+	assert.Equal(t, []int64{1, 10}, []int64{floor, ceiling})
+	assert.Empty(t, active)
+
+	floor, ceiling, active = asg.CapacityBounds(day.Add(9 * time.Hour))
+	assert.Equal(t, []int64{2, 10}, []int64{floor, ceiling})
+	assert.Len(t, active, 1)
+
+	floor, ceiling, active = asg.CapacityBounds(day.Add(13 * time.Hour))
+	assert.Equal(t, []int64{4, 6}, []int64{floor, ceiling})
+	assert.Len(t, active, 2)
+}
+
+// TestBlackoutWindow verifies blackout windows are validated and evaluated in their time zone
+// Expected behavior:
+//   - A window with a start before its end is accepted; bad times, end not after start and unknown time zones are rejected
+//   - The window is active from its start up to, not including, its end
+//   - An ASG uses its own windows when set, so an empty list in YAML opts it out of the global ones
+func TestBlackoutWindow(t *testing.T) {
+	freeze := BlackoutWindow{Start: "2024-12-20 18:00", End: "2025-01-06 08:00", Timezone: "Europe/Berlin", Reason: "release freeze"}
+	assert.NoError(t, freeze.Validate())
+	assert.Error(t, BlackoutWindow{Start: "2024-12-20", End: "2025-01-06 08:00"}.Validate())
+	assert.Error(t, BlackoutWindow{Start: "2024-12-20 18:00", End: "2024-12-20 18:00"}.Validate())
+	assert.Error(t, BlackoutWindow{Start: "2024-12-20 18:00", End: "2025-01-06 08:00", Timezone: "Mars/Olympus"}.Validate())
+
+	start := time.Date(2024, 12, 20, 17, 0, 0, 0, time.UTC) // 18:00 in Berlin
+	assert.False(t, freeze.Active(start.Add(-time.Minute)))
+	assert.True(t, freeze.Active(start))
+	assert.True(t, freeze.Active(time.Date(2025, 1, 6, 6, 59, 0, 0, time.UTC)))
+	assert.False(t, freeze.Active(time.Date(2025, 1, 6, 7, 0, 0, 0, time.UTC)))
+
+	var asgs []Asg
+	require.NoError(t, yaml.Unmarshal([]byte("- name: a\n- name: b\n  blackout-windows: []\n"), &asgs))
+	global := []BlackoutWindow{freeze}
+	window, ok := asgs[0].ActiveBlackout(global, start)
+	assert.True(t, ok)
+	assert.Equal(t, "2024-12-20 18:00-2025-01-06 08:00 Europe/Berlin (release freeze)", window.String())
+	_, ok = asgs[1].ActiveBlackout(global, start)
+	assert.False(t, ok)
+}
@@ -0,0 +1,189 @@
+package config
+
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+const unsetValue = "<unset>"
+
+var asgsType = reflect.TypeOf([]Asg(nil))
+
+// Change is a setting that differs between two configurations, with both values formatted as by Render
+type Change struct {
+	Path string // Dotted yaml path of the setting, e.g. "autoscaler.check-interval" or "aws.region"
+	Old  string
+	New  string
+}
+
+// String formats the change as "path: old -> new"
+func (c Change) String() string {
+	return fmt.Sprintf("%s: %s -> %s", c.Path, c.Old, c.New)
+}
+
+// ASGChange lists the settings that differ of an ASG present in both configurations
+type ASGChange struct {
+	Name    string
+	Changes []Change // Paths relative to the ASG, e.g. "max-asg-capacity"
+}
+
+// Diff is what changed between two configurations. Secrets are redacted like in Render: a changed token shows as a
+// change without revealing either value.
+type Diff struct {
+	Changes      []Change    // Settings outside the ASGs
+	AddedASGs    []string    // ASGs only in the new configuration
+	RemovedASGs  []string    // ASGs only in the old configuration
+	ModifiedASGs []ASGChange // ASGs in both whose settings differ
+}
+
+// IsEmpty reports whether nothing changed
+func (d Diff) IsEmpty() bool {
+	return len(d.Changes) == 0 && len(d.AddedASGs) == 0 && len(d.RemovedASGs) == 0 && len(d.ModifiedASGs) == 0
+}
+
+// Compare returns what changed from previous to next. Like Render it walks the struct metadata, so new settings are
+// compared under their yaml path without touching this function. ASGs are matched by name, also when they moved to
+// another provider entry, which shows as a change of their "provider".
+func Compare(previous, next *Config) Diff {
+	var diff Diff
+	diffValues(&diff.Changes, "", reflect.ValueOf(*previous), reflect.ValueOf(*next))
+
+	previousASGs, nextASGs := asgsByName(previous), asgsByName(next)
+	for _, name := range slices.Sorted(maps.Keys(previousASGs)) {
+		if _, ok := nextASGs[name]; !ok {
+			diff.RemovedASGs = append(diff.RemovedASGs, name)
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(nextASGs)) {
+		after := nextASGs[name]
+		before, ok := previousASGs[name]
+		if !ok {
+			diff.AddedASGs = append(diff.AddedASGs, name)
+			continue
+		}
+		var changes []Change
+		if before.provider != after.provider {
+			changes = append(changes, Change{Path: "provider", Old: before.provider, New: after.provider})
+		}
+		diffValues(&changes, "", reflect.ValueOf(before.asg), reflect.ValueOf(after.asg))
+		if len(changes) > 0 {
+			diff.ModifiedASGs = append(diff.ModifiedASGs, ASGChange{Name: name, Changes: changes})
+		}
+	}
+	return diff
+}
+
+// providerASG is an ASG with the provider entry configuring it
+type providerASG struct {
+	provider string
+	asg      Asg
+}
+
+// asgsByName returns the ASGs of all provider entries by name
+func asgsByName(cfg *Config) map[string]providerASG {
+	asgs := make(map[string]providerASG)
+	for provider, providerCfg := range cfg.Providers {
+		for _, asg := range providerCfg.AsgNames {
+			asgs[asg.Name] = providerASG{provider: provider, asg: asg}
+		}
+	}
+	return asgs
+}
+
+// diffValues appends the settings that differ between a and b under path. A value missing on one side, e.g. a
+// provider entry that was added, is invalid and compared as unset.
+func diffValues(changes *[]Change, path string, a, b reflect.Value) {
+	if a.IsValid() && b.IsValid() && reflect.DeepEqual(a.Interface(), b.Interface()) {
+		return
+	}
+	t := b.Type()
+	if !b.IsValid() {
+		t = a.Type()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		a, b = orZero(a, t), orZero(b, t)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name, inline := yamlName(field)
+			if name == "-" || !field.IsExported() || field.Type == asgsType {
+				continue
+			}
+			fieldPath := joinPath(path, name)
+			if inline {
+				fieldPath = path
+			}
+			fa, fb := a.Field(i), b.Field(i)
+			if field.Tag.Get("secret") == "true" {
+				if fa.String() != fb.String() {
+					*changes = append(*changes, Change{Path: fieldPath, Old: redact(fa), New: redact(fb)})
+				}
+				continue
+			}
+			diffValues(changes, fieldPath, fa, fb)
+		}
+	case reflect.Map:
+		keys := make(map[string]reflect.Value)
+		for _, v := range []reflect.Value{a, b} {
+			if v.IsValid() {
+				for _, key := range v.MapKeys() {
+					keys[fmt.Sprint(key)] = key
+				}
+			}
+		}
+		for _, name := range slices.Sorted(maps.Keys(keys)) {
+			diffValues(changes, joinPath(path, name), mapIndex(a, keys[name]), mapIndex(b, keys[name]))
+		}
+	default:
+		// A nil and an empty list are not a change
+		if old, new := summary(a), summary(b); old != new {
+			*changes = append(*changes, Change{Path: path, Old: old, New: new})
+		}
+	}
+}
+
+// orZero returns v, or the zero value of t when v is missing
+func orZero(v reflect.Value, t reflect.Type) reflect.Value {
+	if !v.IsValid() {
+		return reflect.Zero(t)
+	}
+	return v
+}
+
+// mapIndex returns the value of key in the map m; invalid when m is missing or has no such key
+func mapIndex(m, key reflect.Value) reflect.Value {
+	if !m.IsValid() {
+		return reflect.Value{}
+	}
+	return m.MapIndex(key)
+}
+
+// summary formats a value on a single line; lists of structs show each item as its String method does
+func summary(v reflect.Value) string {
+	switch {
+	case !v.IsValid():
+		return unsetValue
+	case isScalar(v):
+		return scalar(v)
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = fmt.Sprint(v.Index(i).Interface())
+		}
+		return "[" + strings.Join(items, "; ") + "]"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// joinPath appends the yaml key name to path
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
@@ -0,0 +1,84 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCompare_Unchanged verifies equal configurations have an empty diff
+// Expected behavior:
+//   - A copy of the configuration is no change, nor is an empty list where none was set
+func TestCompare_Unchanged(t *testing.T) {
+	previous, next := validConfig(), validConfig()
+	next.Autoscaler.BlackoutWindows = []BlackoutWindow{}
+
+	diff := Compare(&previous, &next)
+
+	assert.True(t, diff.IsEmpty(), "%+v", diff)
+}
+
+// TestCompare_Settings verifies changed settings are listed under their yaml path
+// Expected behavior:
+//   - Changes of the GitLab group and proxy and of the check-interval show both values
+//   - A changed token is listed, redacted on both sides
+//   - A provider entry that was added lists its settings as previously unset
+func TestCompare_Settings(t *testing.T) {
+	previous, next := validConfig(), validConfig()
+	next.GitLab.Group = "othergroup"
+	next.GitLab.Proxy = "http://proxy.example.com:3128"
+	next.GitLab.Token = "new-secret-token"
+	next.Autoscaler.CheckInterval = 30
+	next.Autoscaler.ScaleDownCooldown = 5 * time.Minute
+	next.Providers["gcp"] = ProviderConfig{Project: "runners"}
+
+	diff := Compare(&previous, &next)
+
+	assert.Equal(t, []Change{
+		{Path: "gitlab.token", Old: redactedValue, New: redactedValue},
+		{Path: "gitlab.group", Old: "mygroup", New: "othergroup"},
+		{Path: "gitlab.proxy", Old: `""`, New: "http://proxy.example.com:3128"},
+		{Path: "autoscaler.check-interval", Old: "10", New: "30"},
+		{Path: "autoscaler.scale-down-cooldown", Old: "0s", New: "5m0s"},
+		{Path: "gcp.project", Old: `""`, New: "runners"},
+	}, diff.Changes)
+	assert.Empty(t, diff.AddedASGs)
+	assert.Empty(t, diff.ModifiedASGs)
+	for _, change := range diff.Changes {
+		assert.False(t, strings.Contains(change.String(), "secret"), change.String())
+	}
+}
+
+// TestCompare_ASGs verifies ASGs are matched by name across provider entries
+// Expected behavior:
+//   - ASGs only in the new configuration are added, those only in the old one removed
+//   - A modified ASG lists the fields that changed, relative to it, including a move to another provider entry
+func TestCompare_ASGs(t *testing.T) {
+	previous := validConfig()
+	previous.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 3},
+		{Name: "old-asg", MaxAsgCapacity: 1},
+		{Name: "moved-asg", MaxAsgCapacity: 1},
+	}}
+	next := validConfig()
+	next.Providers["aws"] = ProviderConfig{AsgNames: []Asg{
+		{Name: "test-asg", Tags: []string{"amd64", "prod"}, MaxAsgCapacity: 5},
+		{Name: "new-asg", MaxAsgCapacity: 1},
+	}}
+	next.Providers["aws-eu"] = ProviderConfig{Type: "aws", AsgNames: []Asg{{Name: "moved-asg", MaxAsgCapacity: 1}}}
+
+	diff := Compare(&previous, &next)
+
+	assert.Equal(t, []string{"new-asg"}, diff.AddedASGs)
+	assert.Equal(t, []string{"old-asg"}, diff.RemovedASGs)
+	assert.Equal(t, []ASGChange{
+		{Name: "moved-asg", Changes: []Change{{Path: "provider", Old: "aws", New: "aws-eu"}}},
+		{Name: "test-asg", Changes: []Change{
+			{Path: "tags", Old: "[amd64]", New: "[amd64, prod]"},
+			{Path: "max-asg-capacity", Old: "3", New: "5"},
+		}},
+	}, diff.ModifiedASGs)
+	assert.Equal(t, []Change{{Path: "aws-eu.type", Old: `""`, New: "aws"}}, diff.Changes)
+}
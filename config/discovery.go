@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Well-known tags of a discovered ASG, read instead of the fields of an asg-names entry
+const (
+	DiscoveryTagTags        = "gitlab-autoscaler:tags"          // Comma-separated job tags served by the ASG (required)
+	DiscoveryTagMaxCapacity = "gitlab-autoscaler:max-capacity"  // max-asg-capacity of the ASG (required)
+	DiscoveryTagScaleToZero = "gitlab-autoscaler:scale-to-zero" // "true" allows scaling the ASG down to zero. Default is false
+)
+
+// AsgDiscovery selects ASGs served in addition to asg-names by a tag set on the group, e.g. by Terraform
+type AsgDiscovery struct {
+	TagKey   string `yaml:"tag-key"`   // Key of the tag marking the ASGs to serve (e.g. "gitlab-autoscaler:enabled"); disabled when empty
+	TagValue string `yaml:"tag-value"` // Value the tag must have (e.g. "true"). Default is any value
+}
+
+// IsSet reports whether ASGs are discovered
+func (d AsgDiscovery) IsSet() bool {
+	return d.TagKey != ""
+}
+
+// DiscoveredAsg returns the configuration of a discovered ASG from the well-known tags set on it
+func DiscoveredAsg(name string, tags map[string]string) (Asg, error) {
+	asg := Asg{Name: name}
+	for _, tag := range strings.Split(tags[DiscoveryTagTags], ",") {
+		if tag = strings.TrimSpace(tag); tag != "" {
+			asg.Tags = append(asg.Tags, tag)
+		}
+	}
+	if len(asg.Tags) == 0 {
+		return Asg{}, fmt.Errorf("tag %s is required", DiscoveryTagTags)
+	}
+
+	value, ok := tags[DiscoveryTagMaxCapacity]
+	if !ok {
+		return Asg{}, fmt.Errorf("tag %s is required", DiscoveryTagMaxCapacity)
+	}
+	maxCapacity, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return Asg{}, fmt.Errorf("tag %s must be a number, got %q", DiscoveryTagMaxCapacity, value)
+	}
+	asg.MaxAsgCapacity = maxCapacity
+
+	if value, ok := tags[DiscoveryTagScaleToZero]; ok {
+		scaleToZero, err := strconv.ParseBool(strings.TrimSpace(value))
+		if err != nil {
+			return Asg{}, fmt.Errorf("tag %s must be true or false, got %q", DiscoveryTagScaleToZero, value)
+		}
+		asg.ScaleToZero = scaleToZero
+	}
+
+	if err := asg.Validate(); err != nil {
+		return Asg{}, err
+	}
+	return asg, nil
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// envReference matches, in this order, the escape $${, a reference ${VAR} or ${VAR:-default}, and any other ${
+var envReference = regexp.MustCompile(`\$\$\{|\$\{([A-Za-z_][A-Za-z0-9_]*)(?::-([^}\n]*))?\}|\$\{`)
+
+// expandEnv replaces the references to environment variables in a configuration file before it is decoded:
+// ${VAR} by the value of VAR, which must be set, and ${VAR:-default} by the value of VAR, or default when VAR is unset
+// or empty. $${ is a literal ${, e.g. for a command of the exec provider. Comments are left as they are.
+// A problem is returned per variable that is unset without a default and per malformed reference, with its line;
+// those are replaced by an empty string, so that the rest of the file can still be checked.
+func expandEnv(data []byte) ([]byte, []error) {
+	var problems []error
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	for i, line := range lines {
+		start := commentStart(line)
+		content, comment := line[:start], line[start:]
+		content = envReference.ReplaceAllFunc(content, func(match []byte) []byte {
+			if string(match) == "$${" {
+				return []byte("${")
+			}
+			groups := envReference.FindSubmatch(match)
+			if len(groups[1]) == 0 {
+				problems = append(problems, fmt.Errorf("line %d: invalid variable reference: expected ${VAR} or ${VAR:-default}, or $${ for a literal ${", i+1))
+				return nil
+			}
+			name := string(groups[1])
+			if value, ok := os.LookupEnv(name); ok && (value != "" || groups[2] == nil) {
+				return []byte(value)
+			}
+			if groups[2] != nil {
+				return groups[2]
+			}
+			problems = append(problems, fmt.Errorf("line %d: environment variable %s is not set and has no default", i+1, name))
+			return nil
+		})
+		lines[i] = append(content, comment...)
+	}
+	return bytes.Join(lines, nil), problems
+}
+
+// commentStart returns the offset of the comment of a line, its length without one. Like in YAML a comment starts
+// with a # at the beginning of the line or after a blank, outside of quoted scalars, which start after a blank, an
+// indicator of a flow collection or nothing; an apostrophe within a word, as in "don't", quotes nothing.
+func commentStart(line []byte) int {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		switch c := line[i]; {
+		case quote == '"' && c == '\\':
+			i++
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case (c == '\'' || c == '"') && (i == 0 || bytes.IndexByte([]byte(" \t[{,"), line[i-1]) >= 0):
+			quote = c
+		case c == '#' && (i == 0 || line[i-1] == ' ' || line[i-1] == '\t'):
+			return i
+		}
+	}
+	return len(line)
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestLoad_EnvironmentVariables verifies references to environment variables are expanded before decoding
+// Expected behavior:
+//   - ${VAR} is replaced by the value of VAR, also for settings that are no strings
+//   - ${VAR:-default} is replaced by default when VAR is unset or empty, by its value otherwise
+//   - $${ is a literal ${, as needed by shell commands of the exec provider
+//   - References in comments are left as they are, even of unset variables
+func TestLoad_EnvironmentVariables(t *testing.T) {
+	t.Setenv("TEST_GITLAB_TOKEN", "glpat-from-env")
+	t.Setenv("TEST_CHECK_INTERVAL", "15")
+	t.Setenv("TEST_EMPTY", "")
+	t.Setenv("TEST_GROUP", "env-group")
+
+	cfg, err := Load(writeConfig(t, `# token: ${TEST_UNSET_IN_COMMENT}
+gitlab:
+  token: '${TEST_GITLAB_TOKEN}'  # ${TEST_UNSET_IN_COMMENT}
+  group: ${TEST_GROUP:-default-group}
+autoscaler:
+  check-interval: ${TEST_CHECK_INTERVAL}
+aws:
+  region: ${TEST_UNSET_REGION:-eu-west-1}
+  asg-names:
+    - name: asg-${TEST_EMPTY:-default}
+      max-asg-capacity: 3
+exec:
+  get-capacity-cmd: 'pool capacity "$${POOL:-$GITLAB_AUTOSCALER_ASG}"'
+`))
+
+	require.NoError(t, err)
+	assert.Equal(t, "glpat-from-env", cfg.GitLab.Token)
+	assert.Equal(t, "env-group", cfg.GitLab.Group)
+	assert.Equal(t, 15, cfg.Autoscaler.CheckInterval)
+	assert.Equal(t, "eu-west-1", cfg.Providers["aws"].Region)
+	assert.Equal(t, "asg-default", cfg.Providers["aws"].AsgNames[0].Name)
+	assert.Equal(t, `pool capacity "${POOL:-$GITLAB_AUTOSCALER_ASG}"`, cfg.Providers["exec"].GetCapacityCmd)
+}
+
+// TestLoad_UnsetEnvironmentVariable verifies Load fails on a variable that is unset and has no default, naming it
+// and its line, and on a malformed reference
+func TestLoad_UnsetEnvironmentVariable(t *testing.T) {
+	t.Setenv("TEST_SET", "")
+
+	_, err := Load(writeConfig(t, `gitlab:
+  token: '${TEST_UNSET_GITLAB_TOKEN}'
+  group: '${TEST_SET}'
+`))
+	assert.ErrorContains(t, err, "line 2: environment variable TEST_UNSET_GITLAB_TOKEN is not set and has no default")
+	assert.NotContains(t, err.Error(), "TEST_SET")
+
+	_, err = Load(writeConfig(t, `gitlab:
+  token: '${TEST-TOKEN}'
+`))
+	assert.ErrorContains(t, err, "line 2: invalid variable reference")
+}
+
+// TestCheck_UnsetEnvironmentVariables verifies Check reports every unset variable and still checks the rest
+func TestCheck_UnsetEnvironmentVariables(t *testing.T) {
+	cfg, problems := Check(writeConfig(t, `gitlab:
+  token: '${TEST_UNSET_GITLAB_TOKEN}'
+  group: ${TEST_UNSET_GROUP}
+autoscaler:
+  check-interval: 10
+aws:
+  asg-names:
+    - name: test-asg
+      max-asg-capacity: 3
+`))
+
+	require.NotNil(t, cfg)
+	messages := make([]string, len(problems))
+	for i, problem := range problems {
+		messages[i] = problem.Error()
+	}
+	require.Len(t, messages, 3, messages)
+	assert.Equal(t, "line 2: environment variable TEST_UNSET_GITLAB_TOKEN is not set and has no default", messages[0])
+	assert.Equal(t, "line 3: environment variable TEST_UNSET_GROUP is not set and has no default", messages[1])
+	assert.Contains(t, messages[2], "token")
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	redactedValue = "<redacted>"
+	indentUnit    = "  "
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// Render returns a human readable view of the configuration. It walks the struct metadata, so every
+// field with a yaml tag is rendered under its yaml name without having to touch this function when
+// new settings are added. Fields tagged `secret:"true"` are redacted.
+func Render(cfg *Config) string {
+	var b strings.Builder
+	renderStruct(&b, reflect.ValueOf(*cfg), 1)
+	return b.String()
+}
+
+// renderStruct writes every yaml-tagged field of a struct value at the given indentation depth
+func renderStruct(b *strings.Builder, v reflect.Value, depth int) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline := yamlName(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		value := v.Field(i)
+
+		if inline {
+			renderValue(b, value, depth)
+			continue
+		}
+
+		if field.Tag.Get("secret") == "true" {
+			fmt.Fprintf(b, "%s%s: %s\n", indent(depth), name, redact(value))
+			continue
+		}
+
+		if isScalar(value) {
+			fmt.Fprintf(b, "%s%s: %s\n", indent(depth), name, scalar(value))
+			continue
+		}
+		fmt.Fprintf(b, "%s%s:\n", indent(depth), name)
+		renderValue(b, value, depth+1)
+	}
+}
+
+// renderValue writes a composite value (struct, map or slice of structs) at the given indentation depth
+func renderValue(b *strings.Builder, v reflect.Value, depth int) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			renderValue(b, v.Elem(), depth)
+		}
+	case reflect.Struct:
+		renderStruct(b, v, depth)
+	case reflect.Map:
+		keys := v.MapKeys()
+		sort.Slice(keys, func(i, j int) bool { return fmt.Sprint(keys[i]) < fmt.Sprint(keys[j]) })
+		for _, key := range keys {
+			item := v.MapIndex(key)
+			if isScalar(item) {
+				fmt.Fprintf(b, "%s%v: %s\n", indent(depth), key, scalar(item))
+				continue
+			}
+			fmt.Fprintf(b, "%s%v:\n", indent(depth), key)
+			renderValue(b, item, depth+1)
+		}
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			item := v.Index(i)
+			if isScalar(item) {
+				fmt.Fprintf(b, "%s- %s\n", indent(depth), scalar(item))
+				continue
+			}
+			// Render the item one level deeper and turn the first indentation into a list marker
+			var itemBuilder strings.Builder
+			renderValue(&itemBuilder, item, depth+1)
+			rendered := itemBuilder.String()
+			if rendered == "" {
+				fmt.Fprintf(b, "%s- {}\n", indent(depth))
+				continue
+			}
+			b.WriteString(indent(depth) + "- " + strings.TrimPrefix(rendered, indent(depth+1)))
+		}
+	}
+}
+
+// isScalar reports whether a value is rendered on a single line
+func isScalar(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Struct, reflect.Map:
+		return false
+	case reflect.Ptr:
+		return v.IsNil() || isScalar(v.Elem())
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if !isScalar(v.Index(i)) {
+				return false
+			}
+		}
+		return true
+	default:
+		return true
+	}
+}
+
+// scalar formats a single line value
+func scalar(v reflect.Value) string {
+	switch {
+	case v.Kind() == reflect.Ptr:
+		if v.IsNil() {
+			return "<unset>"
+		}
+		return scalar(v.Elem())
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice || v.Kind() == reflect.Array:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = scalar(v.Index(i))
+		}
+		return "[" + strings.Join(items, ", ") + "]"
+	case v.Kind() == reflect.String && v.Len() == 0:
+		return `""`
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+// redact hides the value of a secret field while still showing whether it is set
+func redact(v reflect.Value) string {
+	if v.IsZero() {
+		return `""`
+	}
+	return redactedValue
+}
+
+// yamlName returns the yaml key of a field and whether the field is inlined
+func yamlName(field reflect.StructField) (string, bool) {
+	tag := field.Tag.Get("yaml")
+	parts := strings.Split(tag, ",")
+	inline := false
+	for _, option := range parts[1:] {
+		if option == "inline" {
+			inline = true
+		}
+	}
+	if parts[0] == "" {
+		return strings.ToLower(field.Name), inline
+	}
+	return parts[0], inline
+}
+
+// indent returns the indentation prefix for the given depth
+func indent(depth int) string {
+	return strings.Repeat(indentUnit, depth)
+}
@@ -0,0 +1,42 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var updateGolden = flag.Bool("update", false, "update golden files in testdata")
+
+// TestRender_Golden verifies the rendered configuration of a config exercising every field
+// Expected behavior:
+//   - Output matches testdata/full.golden (regenerate with `go test ./config -update`)
+//   - The GitLab token is redacted
+func TestRender_Golden(t *testing.T) {
+	cfg, err := Load(filepath.Join("testdata", "full.yml"))
+	require.NoError(t, err)
+
+	rendered := Render(cfg)
+	golden := filepath.Join("testdata", "full.golden")
+	if *updateGolden {
+		require.NoError(t, os.WriteFile(golden, []byte(rendered), 0644))
+	}
+
+	expected, err := os.ReadFile(golden)
+	require.NoError(t, err)
+	assert.Equal(t, string(expected), rendered)
+	assert.False(t, strings.Contains(rendered, "private-gitlab-token"), "token must be redacted")
+}
+
+// TestRender_EmptySecret verifies an unset secret is shown as empty rather than redacted
+func TestRender_EmptySecret(t *testing.T) {
+	cfg := validConfig()
+	cfg.GitLab.Token = ""
+
+	assert.Contains(t, Render(&cfg), `token: ""`)
+}
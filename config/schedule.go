@@ -0,0 +1,187 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// weekdays maps the day names accepted in schedule days to time.Weekday
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "mon": time.Monday, "tue": time.Tuesday, "wed": time.Wednesday,
+	"thu": time.Thursday, "fri": time.Friday, "sat": time.Saturday,
+}
+
+// locations caches loaded time zones, since schedules are evaluated every cycle
+var locations sync.Map
+
+// Validate checks the window, days, time zone and capacities of the schedule against its ASG
+func (s Schedule) Validate(asg Asg) error {
+	start, err := parseClock(s.Start, false)
+	if err != nil {
+		return fmt.Errorf("start: %w", err)
+	}
+	end, err := parseClock(s.End, true)
+	if err != nil {
+		return fmt.Errorf("end: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("start and end are both %s", s.Start)
+	}
+	if _, err := parseDays(s.Days); err != nil {
+		return err
+	}
+	if _, err := loadLocation(s.Timezone); err != nil {
+		return err
+	}
+
+	if s.MinCapacity < 0 {
+		return fmt.Errorf("min-capacity must be non-negative")
+	}
+	if s.MaxCapacity < 0 {
+		return fmt.Errorf("max-capacity must be non-negative")
+	}
+	if s.MaxCapacity > MaxAsgCapacityCeiling {
+		return fmt.Errorf("max-capacity %d exceeds the ceiling of %d", s.MaxCapacity, MaxAsgCapacityCeiling)
+	}
+	ceiling := asg.MaxAsgCapacity
+	if s.MaxCapacity > 0 {
+		ceiling = s.MaxCapacity
+	}
+	if s.MinCapacity > ceiling {
+		return fmt.Errorf("min-capacity %d exceeds the maximum capacity %d of the window", s.MinCapacity, ceiling)
+	}
+	return nil
+}
+
+// Active reports whether now falls into the window. A window ending before its start spans midnight
+// and belongs to the day it starts on. Schedules are validated on load, so parse errors make it inactive.
+func (s Schedule) Active(now time.Time) bool {
+	start, err := parseClock(s.Start, false)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(s.End, true)
+	if err != nil {
+		return false
+	}
+	days, err := parseDays(s.Days)
+	if err != nil {
+		return false
+	}
+	loc, err := loadLocation(s.Timezone)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(loc)
+	minute := local.Hour()*60 + local.Minute()
+	if start < end {
+		return days[local.Weekday()] && minute >= start && minute < end
+	}
+	previousDay := (local.Weekday() + 6) % 7
+	return (days[local.Weekday()] && minute >= start) || (days[previousDay] && minute < end)
+}
+
+// String describes the window for logs and the status API, e.g. "mon-fri 08:00-19:00 Europe/Berlin"
+func (s Schedule) String() string {
+	days := "daily"
+	if len(s.Days) > 0 {
+		days = strings.Join(s.Days, ",")
+	}
+	timezone := s.Timezone
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	return fmt.Sprintf("%s %s-%s %s", days, s.Start, s.End, timezone)
+}
+
+// loadLocation returns the named time zone of a schedule or blackout window; UTC when none is set
+func loadLocation(timezone string) (*time.Location, error) {
+	if timezone == "" {
+		return time.UTC, nil
+	}
+	if loc, ok := locations.Load(timezone); ok {
+		return loc.(*time.Location), nil
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("timezone %q: %w", timezone, err)
+	}
+	locations.Store(timezone, loc)
+	return loc, nil
+}
+
+// CapacityBounds returns the floor and ceiling of the ASG at now together with the schedules active then.
+// Without an active schedule these are the effective min-asg-capacity and max-asg-capacity. Active schedules
+// override them: the floor is the largest min-capacity among them (overlapping windows take the max of the mins),
+// the ceiling the largest max-capacity among those setting one, raised to the floor when windows disagree.
+func (a Asg) CapacityBounds(now time.Time) (int64, int64, []Schedule) {
+	var active []Schedule
+	for _, schedule := range a.Schedules {
+		if schedule.Active(now) {
+			active = append(active, schedule)
+		}
+	}
+	if len(active) == 0 {
+		return a.EffectiveMinCapacity(), a.MaxAsgCapacity, nil
+	}
+
+	var floor, ceiling int64
+	for _, schedule := range active {
+		floor = max(floor, schedule.MinCapacity)
+		ceiling = max(ceiling, schedule.MaxCapacity)
+	}
+	if ceiling == 0 {
+		ceiling = a.MaxAsgCapacity
+	}
+	return floor, max(ceiling, floor), active
+}
+
+// parseClock parses an HH:MM time of day into minutes since midnight; "24:00" is accepted as an end
+func parseClock(value string, isEnd bool) (int, error) {
+	hours, minutes, ok := strings.Cut(value, ":")
+	if !ok || len(hours) != 2 || len(minutes) != 2 {
+		return 0, fmt.Errorf("%q is not an HH:MM time", value)
+	}
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if errH != nil || errM != nil || m < 0 || m > 59 || h < 0 || (h > 23 && !(isEnd && h == 24 && m == 0)) {
+		return 0, fmt.Errorf("%q is not an HH:MM time", value)
+	}
+	return h*60 + m, nil
+}
+
+// parseDays parses day names and ranges (e.g. "mon-fri", "sat", "fri-mon") into the weekdays they cover;
+// no days means every day
+func parseDays(entries []string) ([7]bool, error) {
+	var days [7]bool
+	if len(entries) == 0 {
+		for i := range days {
+			days[i] = true
+		}
+		return days, nil
+	}
+	for _, entry := range entries {
+		first, last, isRange := strings.Cut(strings.ToLower(strings.TrimSpace(entry)), "-")
+		from, ok := weekdays[first]
+		if !ok {
+			return days, fmt.Errorf("days entry %q: unknown day %q", entry, first)
+		}
+		to := from
+		if isRange {
+			if to, ok = weekdays[last]; !ok {
+				return days, fmt.Errorf("days entry %q: unknown day %q", entry, last)
+			}
+		}
+		for day := from; ; day = (day + 1) % 7 {
+			days[day] = true
+			if day == to {
+				break
+			}
+		}
+	}
+	return days, nil
+}
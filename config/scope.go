@@ -0,0 +1,50 @@
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// IsSet reports whether the scope restricts anything
+func (s GitLabScope) IsSet() bool {
+	return s.Group != "" || len(s.Projects) > 0
+}
+
+// Key returns a stable identifier of the scope, used to share scoped demand between ASGs
+func (s GitLabScope) Key() string {
+	projects := append([]string(nil), s.Projects...)
+	sort.Strings(projects)
+	return s.Group + "|" + strings.Join(projects, ",")
+}
+
+// Contains reports whether a project (by its full path) belongs to the scope
+func (s GitLabScope) Contains(projectPath string) bool {
+	if s.Group != "" && isWithinGroup(projectPath, s.Group) {
+		return true
+	}
+	for _, project := range s.Projects {
+		if project == projectPath {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks that the scope is a subset of the configured GitLab group
+func (s GitLabScope) Validate(group string) error {
+	if s.Group != "" && s.Group != group && !isWithinGroup(s.Group, group) {
+		return fmt.Errorf("gitlab-scope group %q is not inside gitlab group %q", s.Group, group)
+	}
+	for _, project := range s.Projects {
+		if !isWithinGroup(project, group) {
+			return fmt.Errorf("gitlab-scope project %q is not inside gitlab group %q", project, group)
+		}
+	}
+	return nil
+}
+
+// isWithinGroup reports whether a path is nested below the given group path
+func isWithinGroup(path, group string) bool {
+	return strings.HasPrefix(path, strings.TrimSuffix(group, "/")+"/")
+}
@@ -0,0 +1,88 @@
+package config
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+const (
+	tagGlobPrefix  = "glob:" // Tag entry matching tags with a shell pattern, e.g. "glob:team-*-runner"
+	tagRegexPrefix = "re:"   // Tag entry matching tags with a regular expression, e.g. "re:^gpu-.*$"
+)
+
+// IsTagPattern reports whether an ASG tag entry is a glob or regex pattern rather than a literal tag
+func IsTagPattern(entry string) bool {
+	return strings.HasPrefix(entry, tagGlobPrefix) || strings.HasPrefix(entry, tagRegexPrefix)
+}
+
+// ExpandTags resolves the pattern entries of an ASG tag list against the live tags.
+// Literal entries are kept as they are; every tag appears once even if several entries match it.
+func ExpandTags(entries []string, live []string) []string {
+	seen := make(map[string]bool)
+	var tags []string
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+
+	for _, entry := range entries {
+		if !IsTagPattern(entry) {
+			add(entry)
+			continue
+		}
+		for _, tag := range live {
+			if matched, _ := matchTag(entry, tag); matched {
+				add(tag)
+			}
+		}
+	}
+	return tags
+}
+
+// TagWeight returns the demand multiplier of a tag served by the ASG; 1 unless tag-weights sets one
+func (a Asg) TagWeight(tag string) float64 {
+	if weight, ok := a.TagWeights[tag]; ok {
+		return weight
+	}
+	return 1
+}
+
+// servesTag reports whether a literal tag is one of the ASG tags or matched by one of its patterns
+func (a Asg) servesTag(tag string) bool {
+	for _, entry := range a.Tags {
+		if matched, _ := matchTag(entry, tag); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// matchTag reports whether a tag matches a glob: or re: entry
+func matchTag(entry, tag string) (bool, error) {
+	if pattern, ok := strings.CutPrefix(entry, tagGlobPrefix); ok {
+		return path.Match(pattern, tag)
+	}
+	if pattern, ok := strings.CutPrefix(entry, tagRegexPrefix); ok {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return false, err
+		}
+		return re.MatchString(tag), nil
+	}
+	return entry == tag, nil
+}
+
+// validateTagEntry checks that a glob: or re: entry compiles
+func validateTagEntry(entry string) error {
+	if !IsTagPattern(entry) {
+		return nil
+	}
+	if _, err := matchTag(entry, ""); err != nil {
+		return fmt.Errorf("invalid tag pattern %q: %w", entry, err)
+	}
+	return nil
+}
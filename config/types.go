@@ -1,36 +1,311 @@
 package config
 
+import "time"
+
 // Config represents the application configuration structure
 type Config struct {
 	GitLab     GitLabConfig              `yaml:"gitlab"`     // GitLab settings for API access
 	Autoscaler AutoscalerConfig          `yaml:"autoscaler"` // Autoscaling algorithm parameters
+	Admin      AdminConfig               `yaml:"admin"`      // Local admin HTTP endpoints
+	Metrics    MetricsConfig             `yaml:"metrics"`    // Prometheus metrics served on the admin listener
+	Fleeting   FleetingConfig            `yaml:"fleeting"`   // Where targets of export-only: fleeting ASGs are published
+	Testing    TestingConfig             `yaml:"testing"`    // Resilience testing aids; never enable in production
 	Providers  map[string]ProviderConfig `yaml:",inline"`    // Map of providers (AWS, Azure etc.) with their specific configurations
 }
 
 // ProviderConfig contains settings specific to a cloud provider (e.g., AWS, Azure)
 type ProviderConfig struct {
+	Type string `yaml:"type"` // Built-in provider serving this entry, e.g. "aws" for an entry named aws-prod. Default is the entry name
+
 	Region      string `yaml:"region"`       // Cloud region where the ASGs are located
 	AsgNames    []Asg  `yaml:"asg-names"`    // List of Auto Scaling Groups configured for this provider
 	DefaultZone string `yaml:"default-zone"` // Default zone (used in some cloud providers)
+
+	AsgDiscovery AsgDiscovery `yaml:"asg-discovery"` // Also serve the ASGs carrying a tag, configured by their well-known tags; asg-names entries take precedence
+
+	Profile     string `yaml:"profile"`      // Named profile of the shared AWS config and credentials files. Default is AWS_PROFILE, then "default"
+	EndpointURL string `yaml:"endpoint-url"` // Endpoint of the Auto Scaling and EC2 APIs (aws), Compute API (gcp) or DigitalOcean API instead of the default, e.g. LocalStack at http://localhost:4566
+
+	Project         string `yaml:"project"`          // Project of the managed instance groups (gcp); default-zone makes them zonal, they are regional in region otherwise
+	CredentialsFile string `yaml:"credentials-file"` // Service account key file (gcp). Default is the application default credentials
+
+	SubscriptionID string `yaml:"subscription-id"`             // Subscription of the scale sets (azure)
+	ResourceGroup  string `yaml:"resource-group"`              // Resource group of the scale sets (azure)
+	TenantID       string `yaml:"tenant-id"`                   // Tenant of the service principal authenticating with client-secret (azure)
+	ClientID       string `yaml:"client-id"`                   // Application (client) ID of the service principal authenticating with client-secret (azure)
+	ClientSecret   string `yaml:"client-secret" secret:"true"` // Service principal secret (azure). Default is environment variables, then managed identity
+
+	Kubeconfig string `yaml:"kubeconfig"` // Kubeconfig file of the cluster of the runner Deployments (k8s). Default is the in-cluster config
+
+	Token string `yaml:"token" secret:"true"` // API token (digitalocean), default DIGITALOCEAN_TOKEN, or bearer token sent to the webhooks (exec)
+
+	Plugin PluginConfig `yaml:"plugin"` // Serve the ASGs through an out-of-tree provider plugin instead of a built-in provider
+
+	GetCapacityCmd string `yaml:"get-capacity-cmd"` // Shell command printing {"allocated": N, "desired": N} for the ASG in $GITLAB_AUTOSCALER_ASG (exec)
+	SetCapacityCmd string `yaml:"set-capacity-cmd"` // Shell command setting the ASG in $GITLAB_AUTOSCALER_ASG to $GITLAB_AUTOSCALER_CAPACITY, exiting with 0 (exec)
+	GetCapacityURL string `yaml:"get-capacity-url"` // Webhook answering {"allocated": N, "desired": N} to a POST of {"asg": name} (exec)
+	SetCapacityURL string `yaml:"set-capacity-url"` // Webhook setting the capacity on a POST of {"asg": name, "capacity": N}, answering 2xx (exec)
+
+	ReadRoleARN     string `yaml:"read-role-arn"`     // IAM role assumed for describe calls. Default is role-arn, then the ambient credentials
+	WriteRoleARN    string `yaml:"write-role-arn"`    // IAM role assumed for capacity updates and terminations. Default is role-arn, then the ambient credentials
+	RoleARN         string `yaml:"role-arn"`          // IAM role assumed for all calls, e.g. in the account of the runners. Default is the ambient credentials
+	ExternalID      string `yaml:"external-id"`       // External ID passed when assuming the roles, as required by their trust policy
+	RoleSessionName string `yaml:"role-session-name"` // Session name of the assumed roles, shown in CloudTrail. Default is "gitlab-autoscaler"
+
+	ManageMinMax              bool `yaml:"manage-min-max"`               // Pin MinSize and MaxSize of the ASGs to the desired capacity instead of keeping their own limits
+	CountUnhealthyAsAllocated bool `yaml:"count-unhealthy-as-allocated"` // Count instances failing health checks as allocated although they are being replaced
+	TagScalingEvents          bool `yaml:"tag-scaling-events"`           // Tag the ASG with the reason and time of every scale-up, propagated to new instances
+
+	MaxRetries     int           `yaml:"max-retries"`     // Retries of a throttled or transiently failing API call. Default is 3, -1 disables retries
+	RequestTimeout time.Duration `yaml:"request-timeout"` // Bound of a single API call, so that a hung endpoint cannot block a cycle. Default is 15s
 }
 
 // GitLabConfig contains the configuration for connecting to GitLab API
 type GitLabConfig struct {
-	Token           string   `yaml:"token"`            // Private access token with necessary permissions to read projects and jobs
-	Group           string   `yaml:"group"`            // Name of the GitLab group containing all CI/CD enabled projects
-	ExcludeProjects []string `yaml:"exclude-projects"` // List of project names to exclude from processing (e.g., "node-deployment")
+	Token           string   `yaml:"token" secret:"true"` // Private access token with necessary permissions to read projects and jobs
+	Group           string   `yaml:"group"`               // Name of the GitLab group containing all CI/CD enabled projects
+	Projects        []string `yaml:"projects"`            // Explicit project IDs or paths (e.g. "org/repo") served instead of a group
+	ExcludeProjects []string `yaml:"exclude-projects"`    // List of project names to exclude from processing (e.g., "node-deployment")
+
+	CleanupOfflineRunners bool          `yaml:"cleanup-offline-runners"` // Delete group runners with managed tags that stay offline longer than offline-runner-max-age
+	OfflineRunnerMaxAge   time.Duration `yaml:"offline-runner-max-age"`  // How long a runner may stay offline before it is deleted (required with cleanup-offline-runners)
+	CleanupDryRun         bool          `yaml:"cleanup-dry-run"`         // Only log the runners that would be deleted
+
+	Proxy                 string        `yaml:"proxy"`                    // HTTP(S) proxy URL for GitLab API requests
+	CACertFile            string        `yaml:"ca-cert-file"`             // PEM file with additional CA certificates trusted for the GitLab API
+	TLSInsecureSkipVerify bool          `yaml:"tls-insecure-skip-verify"` // Disable TLS certificate verification (testing only)
+	RequestTimeout        time.Duration `yaml:"request-timeout"`          // Timeout of a single GitLab API request. Default is 25s
+	MaxIdleConns          int           `yaml:"max-idle-conns"`           // Maximum idle keep-alive connections in the pool. Default is 100
+	MaxIdleConnsPerHost   int           `yaml:"max-idle-conns-per-host"`  // Maximum idle keep-alive connections to the GitLab host. Default is 32
+
+	CountBridgeJobs       bool `yaml:"count-bridge-jobs"`       // Count bridge (trigger) jobs as demand. Default is false since they never need a runner
+	RespectResourceGroups bool `yaml:"respect-resource-groups"` // Count at most one pending job per resource_group, since the others cannot run concurrently
+}
+
+// AdminConfig contains settings of the local admin HTTP endpoints
+type AdminConfig struct {
+	Listen   string `yaml:"listen"`   // Listen address (e.g. "127.0.0.1:8048"); disabled when empty
+	Required bool   `yaml:"required"` // Exit when the listen address cannot be bound instead of retrying in the background
+}
+
+// FleetingConfig contains where the desired capacity of export-only: fleeting ASGs is published for fleeting plugins
+type FleetingConfig struct {
+	Listen string `yaml:"listen"` // Listen address of GET /targets (e.g. "127.0.0.1:8049"); disabled when empty
+	File   string `yaml:"file"`   // JSON file rewritten with every target change; disabled when empty
+}
+
+// IsSet reports whether targets are published anywhere
+func (f FleetingConfig) IsSet() bool {
+	return f.Listen != "" || f.File != ""
+}
+
+// MetricsConfig contains settings of the Prometheus metrics
+type MetricsConfig struct {
+	Listen     string          `yaml:"listen"`      // Listen address of a dedicated GET /metrics endpoint (e.g. ":9464"); only on the admin listener when empty
+	AgeBuckets []time.Duration `yaml:"age-buckets"` // Upper boundaries of the pending job age buckets. Default is [1m, 5m]
+	MaxTags    int             `yaml:"max-tags"`    // Cardinality cap: tags beyond the busiest max-tags are exported as "other". Default is 50
+}
+
+// TestingConfig contains settings used only for resilience testing
+type TestingConfig struct {
+	FaultInjection FaultInjectionConfig `yaml:"fault-injection"` // Inject failures into GitLab and provider calls
+}
+
+// FaultInjectionConfig contains the probabilities of injected failures; requires the --allow-fault-injection flag
+type FaultInjectionConfig struct {
+	Enabled               bool          `yaml:"enabled"`                 // Wrap the GitLab client and providers with fault injection
+	Seed                  int64         `yaml:"seed"`                    // Seed of the random source; the same seed injects the same failures
+	GitLabFetchError      float64       `yaml:"gitlab-fetch-error"`      // Probability (0..1) that a GitLab API request fails
+	ProviderDescribeError float64       `yaml:"provider-describe-error"` // Probability (0..1) that reading ASG capacity fails
+	ProviderUpdateError   float64       `yaml:"provider-update-error"`   // Probability (0..1) that updating ASG capacity fails
+	Latency               time.Duration `yaml:"latency"`                 // Latency added to a GitLab request or provider call
+	LatencyProbability    float64       `yaml:"latency-probability"`     // Probability (0..1) that latency is added to a call
 }
 
 // AutoscalerConfig contains settings for how often and how the autoscaler should operate
 type AutoscalerConfig struct {
-	CheckInterval int `yaml:"check-interval"` // Interval in seconds between scaling checks (must be positive)
+	CheckInterval         int                 `yaml:"check-interval"`          // Interval in seconds between scaling checks (must be positive)
+	RunnerReconciliation  string              `yaml:"runner-reconciliation"`   // Compare online runners with allocated instances: "" (disabled), "warn" or "block" (also blocks scale-down)
+	IncludeCreatedJobs    bool                `yaml:"include-created-jobs"`    // Count jobs in the "created" scope (waiting on needs/DAG dependencies) as pending demand
+	CreatedJobsFactor     float64             `yaml:"created-jobs-factor"`     // Share (0..1] of created jobs counted as pending. Default is 1
+	PipelineHold          time.Duration       `yaml:"pipeline-hold"`           // Hold scale-down while a pipeline that ran matching jobs within this duration is still active (0 disables)
+	TagAliases            map[string][]string `yaml:"tag-aliases"`             // Canonical tag -> synonyms; jobs tagged with a synonym count as the canonical tag
+	SizeTagPrefix         string              `yaml:"size-tag-prefix"`         // Prefix of job tags stating how many job slots a job needs, e.g. "size-" makes "size-3x" count 3; over tag-weights
+	TagLimits             map[string]int64    `yaml:"tag-limits"`              // Tag -> most instances serving that tag fleet-wide, in job slots by jobs-per-instance; pending demand beyond it is not scaled for
+	ScaleUpStabilization  int                 `yaml:"scale-up-stabilization"`  // Consecutive cycles a shortfall must persist before scaling up. Default is 1 (scale up immediately)
+	ScaleDownIdleCycles   int                 `yaml:"scale-down-idle-cycles"`  // Consecutive cycles without matching jobs before scaling down. Default is 1
+	ScaleDownCooldown     time.Duration       `yaml:"scale-down-cooldown"`     // No scale-down of an ASG within this duration after its last scale-up (0 disables)
+	ScaleUpCooldown       time.Duration       `yaml:"scale-up-cooldown"`       // No further scale-up of an ASG within this duration after its last scale-up while instances are still booting (0 disables)
+	DescribeStaleAfter    time.Duration       `yaml:"describe-stale-after"`    // Warn when an ASG had no successful capacity read for this duration (0 disables)
+	ErrorBackoffAfter     int                 `yaml:"error-backoff-after"`     // Consecutive failed evaluations of an ASG before its interval is widened (0 disables)
+	ErrorBackoffMax       time.Duration       `yaml:"error-backoff-max"`       // Cap of the widened interval of a failing ASG. Default is 5m
+	PendingTimeout        time.Duration       `yaml:"pending-timeout"`         // Instances pending longer than this are stuck and not counted as allocated. Default is 15m
+	ReplaceStuckInstances bool                `yaml:"replace-stuck-instances"` // Terminate stuck instances so the provider launches replacements
+	DrainTimeout          time.Duration       `yaml:"drain-timeout"`           // Pause the runners of the instance a scale-down removes and wait up to this long for their jobs (0 disables)
+	BlackoutWindows       []BlackoutWindow    `yaml:"blackout-windows"`        // Time ranges in which decisions are logged but capacity is never changed
+	DryRun                bool                `yaml:"dry-run"`                 // Log every scaling decision with its reasoning but never change capacity; also set by --dry-run
+	MaxTotalCapacity      int64               `yaml:"max-total-capacity"`      // Most instances desired across all ASGs (e.g. the EC2 quota); 0 disables
+	StuckQueueCycles      int                 `yaml:"stuck-queue-cycles"`      // Consecutive cycles a tag may have pending jobs without a scale-up before it is diagnosed (0 disables)
+	TagSharing            string              `yaml:"tag-sharing"`             // How ASGs serving the same tag split its pending jobs: "even" (default), "headroom", "priority" or "duplicate"
+	SharedTagScaleDown    string              `yaml:"shared-tag-scale-down"`   // Idle ASGs whose tags have pending jobs assigned elsewhere this cycle: "hold" (default) or "allow" scale-down
+	OverlappingTags       string              `yaml:"overlapping-tags"`        // Tags served by several ASGs: "warn" (default) logs them, "error" rejects the configuration, "allow" accepts them silently
+
+	DemandHistoryFile     string        `yaml:"demand-history-file"`      // JSON file keeping the demand learned for predictive-prescale across restarts; in memory only when empty
+	DemandHistoryHalfLife time.Duration `yaml:"demand-history-half-life"` // Age at which learned demand counts half as much as demand seen now. Default is 672h (4 weeks)
+
+	AuditLog        string `yaml:"audit-log"`          // JSONL file every capacity change is appended to; disabled when empty
+	AuditLogMaxSize int64  `yaml:"audit-log-max-size"` // Megabytes after which the audit log is rotated to <audit-log>.1. Default is 100
 }
 
+// DefaultErrorBackoffMax caps the widened evaluation interval of a failing ASG when error-backoff-max is not set
+const DefaultErrorBackoffMax = 5 * time.Minute
+
+// DefaultAuditLogMaxSize is the size in megabytes at which the audit log is rotated when audit-log-max-size is not set
+const DefaultAuditLogMaxSize = 100
+
+// DefaultMaxRetries is how often a throttled provider API call is retried when max-retries is not set
+const DefaultMaxRetries = 3
+
+// DefaultRequestTimeout bounds a single provider API call when request-timeout is not set
+const DefaultRequestTimeout = 15 * time.Second
+
+// DefaultPendingTimeout is how long an instance may stay pending before it is stuck when pending-timeout is not set
+const DefaultPendingTimeout = 15 * time.Minute
+
+// DefaultDemandHistoryHalfLife is the age at which learned demand counts half when demand-history-half-life is not set
+const DefaultDemandHistoryHalfLife = 4 * 7 * 24 * time.Hour
+
+// DefaultCreatedJobsFactor is the share of created jobs counted as pending when created-jobs-factor is not set
+const DefaultCreatedJobsFactor = 1.0
+
+// MaxAsgCapacityCeiling is the largest max-asg-capacity accepted by Validate; anything above is treated as a typo
+const MaxAsgCapacityCeiling = 10000
+
+const (
+	RunnerReconciliationWarn  = "warn"  // Log when online runners lag behind allocated instances
+	RunnerReconciliationBlock = "block" // Log and block scale-down when online runners lag behind allocated instances
+)
+
+const (
+	TagSharingEven      = "even"      // Split the pending jobs of a shared tag evenly among the ASGs serving it
+	TagSharingHeadroom  = "headroom"  // Split them in proportion to the capacity each ASG has left below its max
+	TagSharingPriority  = "priority"  // Fill the ASGs by descending priority up to the capacity they have left
+	TagSharingDuplicate = "duplicate" // Every ASG counts all of them, so ASGs sharing a tag scale up for the same jobs
+)
+
+const (
+	SharedTagScaleDownHold  = "hold"  // An idle ASG does not scale down while jobs of its tags are pending, even if assigned to other ASGs
+	SharedTagScaleDownAllow = "allow" // An idle ASG scales down when the pending jobs of its tags were assigned to other ASGs
+)
+
+const (
+	OverlappingTagsWarn  = "warn"  // Log every tag served by several ASGs on start and reload
+	OverlappingTagsError = "error" // Reject a configuration with a tag served by several ASGs
+	OverlappingTagsAllow = "allow" // Accept tags served by several ASGs without a word, e.g. when tag-sharing splits them on purpose
+)
+
 // Asg represents a single Auto Scaling Group configuration
 type Asg struct {
-	Name           string   `yaml:"name"`             // Unique name of the ASG in cloud provider
-	Tags           []string `yaml:"tags"`             // List of tags that this ASG should handle (e.g., ["amd64", "prod"])
-	MaxAsgCapacity int64    `yaml:"max-asg-capacity"` // Maximum number of instances allowed in this ASG (prevents over-provisioning)
-	ScaleToZero    bool     `yaml:"scale-to-zero"`    // Whether the ASG can be scaled down to zero instances
-	Region         string   `yaml:"region"`           // Region where this specific ASG is located (overrides provider default if set)
+	Name                string             `yaml:"name"`                   // Unique name of the ASG in cloud provider
+	Tags                []string           `yaml:"tags"`                   // List of tags that this ASG should handle (e.g., ["amd64", "prod"])
+	ExcludeTags         []string           `yaml:"exclude-tags"`           // Jobs carrying any of these tags are not served by this ASG (e.g., ["privileged"])
+	JobsPerInstance     int64              `yaml:"jobs-per-instance"`      // Jobs one instance runs at once (the runner "concurrent" setting). Default is 1
+	MinAsgCapacity      int64              `yaml:"min-asg-capacity"`       // Minimum number of instances kept in this ASG. Default is 0 with scale-to-zero, 1 otherwise
+	MaxAsgCapacity      int64              `yaml:"max-asg-capacity"`       // Maximum number of instances allowed in this ASG (prevents over-provisioning)
+	ScaleToZero         bool               `yaml:"scale-to-zero"`          // Whether the ASG can be scaled down to zero instances
+	Region              string             `yaml:"region"`                 // Region where this specific ASG is located (overrides provider default if set)
+	TargetMaxWait       time.Duration      `yaml:"target-max-wait"`        // Longest a matching job should wait in the queue; once exceeded, damping is bypassed, below half of it scale-up waits one more cycle (0 disables)
+	GitLabScope         GitLabScope        `yaml:"gitlab-scope"`           // Restricts the projects whose jobs count as demand for this ASG (default: the whole group)
+	ScaleDownIdleCycles int                `yaml:"scale-down-idle-cycles"` // Overrides autoscaler.scale-down-idle-cycles for this ASG
+	ScaleDownCooldown   time.Duration      `yaml:"scale-down-cooldown"`    // Overrides autoscaler.scale-down-cooldown for this ASG
+	ScaleUpCooldown     time.Duration      `yaml:"scale-up-cooldown"`      // Overrides autoscaler.scale-up-cooldown for this ASG
+	PreviousNames       []string           `yaml:"previous-names"`         // Former names of this ASG; their orchestrator state is migrated on startup/reload
+	Schedules           []Schedule         `yaml:"schedules"`              // Time-of-day windows overriding the capacity bounds while active
+	TagWeights          map[string]float64 `yaml:"tag-weights"`            // Demand multiplier per served tag (e.g. {"integration": 2}). Default is 1
+	BlackoutWindows     []BlackoutWindow   `yaml:"blackout-windows"`       // Overrides autoscaler.blackout-windows for this ASG; an empty list opts out
+	ExportOnly          string             `yaml:"export-only"`            // "fleeting": publish the desired capacity instead of updating the ASG through the provider
+	Priority            int                `yaml:"priority"`               // ASGs with a higher priority get contested max-total-capacity headroom first. Default is 0
+
+	WarmSlots               int64 `yaml:"warm-slots"`                  // Free job slots kept above the running matching jobs at all times. Default is 0
+	WarmSlotsOnlyWhenActive bool  `yaml:"warm-slots-only-when-active"` // With scale-to-zero, drop the warm slots once the ASG had no matching jobs for the idle cycles
+
+	HandlesUntaggedJobs bool `yaml:"handles-untagged-jobs"` // Serve jobs without tags: one such ASG scales up for them, all of them keep capacity while they run
+
+	PredictivePrescale bool  `yaml:"predictive-prescale"` // Raise the minimum to the median demand learned for this weekday and hour and the next one
+	PredictiveMax      int64 `yaml:"predictive-max"`      // Most instances the learned demand raises the minimum to. Default is max-asg-capacity
+
+	RoleARN         string `yaml:"role-arn"`          // IAM role assumed for all calls of this ASG instead of the roles of the provider, e.g. in another account
+	ExternalID      string `yaml:"external-id"`       // Overrides the external-id of the provider for this ASG
+	RoleSessionName string `yaml:"role-session-name"` // Overrides the role-session-name of the provider for this ASG
+
+	ProtectBusyInstances bool   `yaml:"protect-busy-instances"` // Protect instances running a job from scale-in; runner descriptions must contain the instance ID
+	LifecycleHook        string `yaml:"lifecycle-hook"`         // Terminating lifecycle hook of the ASG, completed once the runners of a terminating instance run no jobs
+
+	MaxInstanceAge time.Duration `yaml:"max-instance-age"` // While idle, replace the oldest instance older than this, one per cycle (0 disables)
+
+	Droplet DropletSettings `yaml:"droplet"` // Droplets created for this pool (digitalocean); the name is the tag shared by its droplets
+}
+
+// DropletSettings contains how the droplets of a DigitalOcean pool are created
+type DropletSettings struct {
+	Size     string   `yaml:"size"`      // Size slug (e.g. "s-2vcpu-4gb")
+	Image    string   `yaml:"image"`     // Image slug (e.g. "ubuntu-24-04-x64") or ID of a snapshot with the runner installed
+	UserData string   `yaml:"user-data"` // Cloud-init user data, e.g. registering the runner
+	SSHKeys  []string `yaml:"ssh-keys"`  // IDs or fingerprints of the SSH keys installed
+	VPCUUID  string   `yaml:"vpc-uuid"`  // VPC of the droplets. Default is the default VPC of the region
+}
+
+// IsSet reports whether any droplet setting is configured
+func (d DropletSettings) IsSet() bool {
+	return d.Size != "" || d.Image != "" || d.UserData != "" || len(d.SSHKeys) > 0 || d.VPCUUID != ""
+}
+
+// Types of the built-in providers
+const (
+	ProviderAWS          = "aws"          // AWS Auto Scaling groups
+	ProviderGCP          = "gcp"          // Google Cloud managed instance groups
+	ProviderAzure        = "azure"        // Azure Virtual Machine Scale Sets
+	ProviderK8s          = "k8s"          // Kubernetes runner Deployments, named namespace/deployment
+	ProviderDigitalOcean = "digitalocean" // DigitalOcean droplet pools, named by the tag of their droplets
+	ProviderExec         = "exec"         // Commands or webhooks of an external scaler, e.g. for Proxmox, vSphere or bare metal
+)
+
+// builtinProviders are the provider names served by a built-in provider; a plugin needs a name of its own
+var builtinProviders = []string{ProviderAWS, ProviderGCP, ProviderAzure, ProviderK8s, ProviderDigitalOcean, ProviderExec}
+
+// PluginConfig contains where the provider plugin of a provider entry listens, see providers/plugin
+type PluginConfig struct {
+	Address string `yaml:"address"` // unix:///path/to.sock, tcp://host:port or host:port of the plugin
+}
+
+// IsSet reports whether the provider entry is served by a plugin
+func (p PluginConfig) IsSet() bool {
+	return p.Address != ""
+}
+
+// ExportFleeting publishes the desired capacity of an ASG for a fleeting plugin, see FleetingConfig
+const ExportFleeting = "fleeting"
+
+// BlackoutWindow is a time range, e.g. a release freeze, in which the autoscaler does not change capacity
+type BlackoutWindow struct {
+	Start    string `yaml:"start"`    // First minute of the range, "YYYY-MM-DD HH:MM"
+	End      string `yaml:"end"`      // End of the range (exclusive), "YYYY-MM-DD HH:MM"
+	Timezone string `yaml:"timezone"` // IANA time zone of start and end (e.g. "Europe/Berlin"). Default is UTC
+	Reason   string `yaml:"reason"`   // Shown in logs and the status API (e.g. "release freeze")
+}
+
+// Schedule overrides the capacity bounds of an ASG while a recurring time-of-day window is active
+type Schedule struct {
+	Days        []string `yaml:"days"`         // Weekdays of the window: names (mon..sun) or ranges (mon-fri). Default is every day
+	Start       string   `yaml:"start"`        // Window start, HH:MM
+	End         string   `yaml:"end"`          // Window end, HH:MM (exclusive, 24:00 allowed); before start, the window spans midnight
+	Timezone    string   `yaml:"timezone"`     // IANA time zone of start and end (e.g. "Europe/Berlin"). Default is UTC
+	MinCapacity int64    `yaml:"min-capacity"` // Instances kept while the window is active, even without jobs
+	MaxCapacity int64    `yaml:"max-capacity"` // Most instances scaled up to while the window is active. Default is max-asg-capacity
+}
+
+// GitLabScope restricts demand attribution to a subgroup and/or a list of projects
+type GitLabScope struct {
+	Group    string   `yaml:"group"`    // Group or subgroup path (e.g. "mygroup/team-a"), nested subgroups included
+	Projects []string `yaml:"projects"` // Project paths (e.g. "mygroup/tools/app")
 }
@@ -0,0 +1,52 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+)
+
+// ActivityReporter is implemented by providers that can tell whether a scaling activity of the ASG, such as
+// launching the instances of the last capacity change, is still in progress
+type ActivityReporter interface {
+	// ScalingActivityInProgress returns the status of the scaling activity of the ASG in progress; ok is false without one
+	ScalingActivityInProgress(ctx context.Context, asgName string) (activity string, ok bool, err error)
+}
+
+// activityCheck asks the provider about scaling activities of an ASG in progress at most once per cycle, and only
+// once a capacity change is about to be applied
+type activityCheck struct {
+	ctx      context.Context
+	provider Provider
+	asgName  string
+
+	checked  bool
+	busy     bool
+	activity string
+}
+
+// inProgress tells whether a scaling activity of the ASG is in progress; a failed check is logged and allows the change
+func (a *activityCheck) inProgress() bool {
+	if a.checked {
+		return a.busy
+	}
+	a.checked = true
+	reporter, ok := a.provider.(ActivityReporter)
+	if !ok {
+		return false
+	}
+	activity, busy, err := reporter.ScalingActivityInProgress(a.ctx, a.asgName)
+	if err != nil {
+		slog.Error("Checking scaling activities failed", "asg", a.asgName, "error", err)
+		return false
+	}
+	a.busy, a.activity = busy, activity
+	return busy
+}
+
+// holdForActivity records a capacity change that a scaling activity in progress keeps from being applied this cycle
+func holdForActivity(asgName, activity string, desired, proposed int64, status *ASGStatus) {
+	status.Decision, status.Proposed = DecisionActivity, proposed
+	status.Reason += "; held by scaling activity in progress: " + activity
+	slog.Info("Scaling activity in progress: capacity change not applied this cycle", "asg", asgName,
+		"desired", desired, "proposed", proposed, "activity", activity)
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// activityProvider is a mocked provider that also reports scaling activities in progress
+type activityProvider struct {
+	*mocks.MockProvider
+}
+
+func (p activityProvider) ScalingActivityInProgress(ctx context.Context, asgName string) (string, bool, error) {
+	args := p.Called(ctx, asgName)
+	return args.String(0), args.Bool(1), args.Error(2)
+}
+
+// TestScaleASGs_ActivityInProgress verifies capacity changes wait for the scaling activity of the last one.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, 3 pending jobs every cycle
+// - Cycle 1: an instance launch is in progress
+// - Cycle 2: no activity in progress
+// - Cycle 3: checking the activities fails
+//
+// Expected result:
+// - Cycle 1: the scale-up to 3 is held with decision activity-in-progress
+// - Cycles 2 and 3: the scale-up to 3 is applied, a failed check does not hold it
+func TestScaleASGs_ActivityInProgress(t *testing.T) {
+	provider := activityProvider{&mocks.MockProvider{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("ScalingActivityInProgress", mock.Anything, "test-asg").
+		Return("Launching a new EC2 instance: i-1 (PreInService, 30%)", true, nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionActivity, snapshot.ASGs[0].Decision)
+	assert.Equal(t, int64(3), snapshot.ASGs[0].Proposed)
+	assert.Contains(t, snapshot.ASGs[0].Reason, "held by scaling activity in progress: Launching a new EC2 instance: i-1")
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+
+	provider.On("ScalingActivityInProgress", mock.Anything, "test-asg").Return("", false, nil).Once()
+	provider.On("ScalingActivityInProgress", mock.Anything, "test-asg").Return("", false, errors.New("throttled")).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Twice()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision)
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ActivityNotCheckedWithoutChange verifies no activity check is made when capacity stays as it is
+func TestScaleASGs_ActivityNotCheckedWithoutChange(t *testing.T) {
+	provider := activityProvider{&mocks.MockProvider{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(3), int64(3), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertNotCalled(t, "ScalingActivityInProgress", mock.Anything, mock.Anything)
+}
+
+// TestScaleASGs_ActivityKeepsBudget verifies a floor held by a scaling activity claims no max-total-capacity.
+//
+// Conditions:
+// - ASG "warm" (tag ["riscv"], 0 of 0 allocated, 2 warm slots, priority 10) with a scaling activity in progress
+// - ASG "busy" (tag ["amd64"], 1 of 1 allocated) with 3 pending jobs and no activity in progress
+// - max-total-capacity 3
+//
+// Expected result: the warm slots of "warm" are held; "busy" gets the whole headroom and scales up to 3
+func TestScaleASGs_ActivityKeepsBudget(t *testing.T) {
+	provider := activityProvider{&mocks.MockProvider{}}
+	asgs := []config.Asg{
+		{Name: "warm", Tags: []string{"riscv"}, MaxAsgCapacity: 5, ScaleToZero: true, WarmSlots: 2, Priority: 10},
+		{Name: "busy", Tags: []string{"amd64"}, MaxAsgCapacity: 5},
+	}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10, MaxTotalCapacity: 3},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: asgs}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"warm": "aws", "busy": "aws"}, nil, nil)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "warm").Return(int64(0), int64(0), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "busy").Return(int64(1), int64(1), nil)
+	provider.On("ScalingActivityInProgress", mock.Anything, "warm").Return("Terminating EC2 instance: i-1", true, nil)
+	provider.On("ScalingActivityInProgress", mock.Anything, "busy").Return("", false, nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "busy", int64(3)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionActivity, snapshot.ASGs[1].Decision)
+	assert.Equal(t, int64(2), snapshot.ASGs[1].Proposed)
+}
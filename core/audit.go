@@ -0,0 +1,181 @@
+package core
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// Results of the capacity changes recorded in the audit log
+const (
+	AuditResultOK    = "ok"
+	AuditResultError = "error"
+)
+
+// auditQueueSize is how many entries may wait for the writer of the audit log before further ones are dropped
+const auditQueueSize = 1024
+
+// AuditEntry is a line of the audit log: one attempt to change the desired capacity of an ASG
+type AuditEntry struct {
+	Timestamp  time.Time    `json:"ts"`
+	ASG        string       `json:"asg"`
+	Provider   string       `json:"provider"`
+	OldDesired int64        `json:"old_desired"`     // Desired capacity before the change
+	NewDesired int64        `json:"new_desired"`     // Desired capacity asked for
+	Allocated  int64        `json:"allocated"`       // Instances counted as allocated when deciding
+	Trigger    AuditTrigger `json:"trigger"`         // Demand behind the change
+	Result     string       `json:"result"`          // AuditResultOK or AuditResultError
+	Error      string       `json:"error,omitempty"` // Why the provider rejected the change
+}
+
+// AuditTrigger is the demand behind a capacity change
+type AuditTrigger struct {
+	Decision Decision `json:"decision"` // DecisionScaleUp or DecisionScaleDown
+	Tags     []string `json:"tags"`     // Tags of the ASG
+	Pending  int64    `json:"pending"`  // Slots of the matching pending jobs
+	Running  int64    `json:"running"`  // Slots of the matching running jobs
+	Reason   string   `json:"reason"`
+}
+
+// auditLog appends entries as JSON lines to a file from a goroutine of its own, so that a slow or full disk never
+// stalls scaling: entries that do not fit into the queue are dropped and counted. Before the file would grow beyond
+// maxSize bytes it is renamed to <path>.1, replacing the previous one.
+type auditLog struct {
+	path    string
+	maxSize int64 // 0 never rotates
+	entries chan AuditEntry
+	done    chan struct{} // Closed once the queue is written and the file closed
+	dropped atomic.Int64
+}
+
+// openAuditLog starts the writer of the audit log at path; the file is opened with the first entry
+func openAuditLog(path string, maxSize int64) *auditLog {
+	a := &auditLog{path: path, maxSize: maxSize, entries: make(chan AuditEntry, auditQueueSize), done: make(chan struct{})}
+	go a.run()
+	return a
+}
+
+// append queues an entry without waiting; it is dropped when the queue is full
+func (a *auditLog) append(entry AuditEntry) {
+	select {
+	case a.entries <- entry:
+	default:
+		dropped := a.dropped.Add(1)
+		slog.Warn("Audit log queue full, entry dropped", "asg", entry.ASG, "path", a.path, "dropped", dropped)
+	}
+}
+
+// close writes the queued entries and closes the file; append must not be called afterwards
+func (a *auditLog) close() {
+	close(a.entries)
+	<-a.done
+}
+
+func (a *auditLog) run() {
+	defer close(a.done)
+	var file *os.File
+	var size int64
+	for entry := range a.entries {
+		line, err := json.Marshal(entry)
+		if err != nil {
+			slog.Error("Error encoding audit entry", "asg", entry.ASG, "error", err)
+			continue
+		}
+		line = append(line, '\n')
+		if file != nil && a.maxSize > 0 && size > 0 && size+int64(len(line)) > a.maxSize {
+			file.Close()
+			file = nil
+			if err := os.Rename(a.path, a.path+".1"); err != nil {
+				slog.Error("Error rotating audit log", "path", a.path, "error", err)
+			}
+		}
+		if file == nil {
+			if file, size, err = openAppend(a.path); err != nil {
+				slog.Error("Error opening audit log", "path", a.path, "error", err)
+				continue
+			}
+		}
+		n, err := file.Write(line)
+		size += int64(n)
+		if err != nil {
+			slog.Error("Error writing audit log", "path", a.path, "error", err)
+		}
+	}
+	if file != nil {
+		file.Close()
+	}
+}
+
+// openAppend opens path for appending, creating it, and returns its current size
+func openAppend(path string) (*os.File, int64, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0o640)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, 0, fmt.Errorf("failed to stat %s: %w", path, err)
+	}
+	return file, info.Size(), nil
+}
+
+// SetAuditLog starts writing the capacity changes to audit-log, or stops without one. A changed path or size is
+// applied to the following entries; those queued before are still written to the previous file.
+func (o *Orchestrator) SetAuditLog(settings config.AutoscalerConfig) {
+	maxSize := settings.EffectiveAuditLogMaxSize()
+	o.mu.Lock()
+	previous := o.audit
+	if previous != nil && previous.path == settings.AuditLog && previous.maxSize == maxSize {
+		o.mu.Unlock()
+		return
+	}
+	o.audit = nil
+	if settings.AuditLog != "" {
+		o.audit = openAuditLog(settings.AuditLog, maxSize)
+	}
+	o.mu.Unlock()
+
+	if previous != nil {
+		previous.close()
+	}
+}
+
+// CloseAuditLog writes the capacity changes still queued and closes the audit log, e.g. on shutdown
+func (o *Orchestrator) CloseAuditLog() {
+	o.SetAuditLog(config.AutoscalerConfig{})
+}
+
+// auditCapacityChange records an attempt to change the desired capacity of an ASG from desired to proposed in the
+// audit log, if one is set; err is the outcome of the update
+func (o *Orchestrator) auditCapacityChange(asg config.Asg, provider string, demand Demand, allocated, desired, proposed int64,
+	reason string, err error) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.audit == nil {
+		return
+	}
+	entry := AuditEntry{
+		Timestamp:  o.now().UTC(),
+		ASG:        asg.Name,
+		Provider:   provider,
+		OldDesired: desired,
+		NewDesired: proposed,
+		Allocated:  allocated,
+		Trigger:    AuditTrigger{Decision: DecisionScaleUp, Tags: asg.Tags, Pending: demand.Pending, Running: demand.Running, Reason: reason},
+		Result:     AuditResultOK,
+	}
+	if proposed < desired {
+		entry.Trigger.Decision = DecisionScaleDown
+	}
+	if err != nil {
+		entry.Result, entry.Error = AuditResultError, err.Error()
+	}
+	// Appending under the read lock keeps SetAuditLog from closing the queue meanwhile
+	o.audit.append(entry)
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// readAuditLog returns the entries of an audit log file
+func readAuditLog(t *testing.T, path string) []AuditEntry {
+	t.Helper()
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+	var entries []AuditEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry AuditEntry
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &entry), scanner.Text())
+		entries = append(entries, entry)
+	}
+	require.NoError(t, scanner.Err())
+	return entries
+}
+
+// TestScaleASGs_AuditLog verifies every capacity update is appended to the audit log with its trigger and result.
+//
+// Conditions:
+// - ASG "busy" with tag ["amd64"], 1 allocated instance, 3 pending "amd64" jobs
+// - Idle ASG "broken" with tag ["arm64"] and 1 allocated instance, whose update fails
+// - ASG "steady" with tag ["x86"] and no change of capacity
+// - audit-log set
+//
+// Expected result:
+// - A scale-up entry for "busy" from 1 to 3 with the pending jobs and result "ok"
+// - A scale-down entry for "broken" from 1 to 0 with result "error" and the error
+// - No entry for "steady"
+func TestScaleASGs_AuditLog(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	busy := config.Asg{Name: "busy", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	broken := config.Asg{Name: "broken", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	steady := config.Asg{Name: "steady", Tags: []string{"x86"}, MaxAsgCapacity: 5, MinAsgCapacity: 1}
+	orchestrator, cfg := newTestOrchestrator(provider, busy, broken, steady)
+	provider.On("GetCurrentCapacity", mock.Anything, "busy").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "busy", int64(3)).Return(nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "broken").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "broken", int64(0)).Return(assert.AnError)
+	provider.On("GetCurrentCapacity", mock.Anything, "steady").Return(int64(1), int64(1), nil)
+	cfg.Autoscaler.AuditLog = filepath.Join(t.TempDir(), "audit.jsonl")
+	orchestrator.SetAuditLog(cfg.Autoscaler)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+	orchestrator.CloseAuditLog()
+
+	provider.AssertExpectations(t)
+	entries := readAuditLog(t, cfg.Autoscaler.AuditLog)
+	require.Len(t, entries, 2)
+	byASG := map[string]AuditEntry{entries[0].ASG: entries[0], entries[1].ASG: entries[1]}
+
+	up := byASG["busy"]
+	assert.False(t, up.Timestamp.IsZero())
+	assert.Equal(t, "aws", up.Provider)
+	assert.EqualValues(t, 1, up.OldDesired)
+	assert.EqualValues(t, 3, up.NewDesired)
+	assert.EqualValues(t, 1, up.Allocated)
+	assert.Equal(t, AuditTrigger{Decision: DecisionScaleUp, Tags: []string{"amd64"}, Pending: 3,
+		Reason: "3 matching pending jobs, 1 free slots"}, up.Trigger)
+	assert.Equal(t, AuditResultOK, up.Result)
+	assert.Empty(t, up.Error)
+
+	down := byASG["broken"]
+	assert.EqualValues(t, 1, down.OldDesired)
+	assert.EqualValues(t, 0, down.NewDesired)
+	assert.Equal(t, DecisionScaleDown, down.Trigger.Decision)
+	assert.Equal(t, AuditResultError, down.Result)
+	assert.Contains(t, down.Error, assert.AnError.Error())
+}
+
+// TestAuditLog_Rotation verifies the audit log is renamed to <path>.1 before it would exceed its size.
+//
+// Conditions:
+// - Audit log limited to the size of 2 entries
+// - 5 entries appended
+//
+// Expected result: the file holds the last entry, <path>.1 the 2 before it; no file is larger than the limit
+func TestAuditLog_Rotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit.jsonl")
+	line, err := json.Marshal(AuditEntry{ASG: "asg-0", Result: AuditResultOK})
+	require.NoError(t, err)
+	maxSize := 2 * int64(len(line)+1)
+
+	audit := openAuditLog(path, maxSize)
+	for _, name := range []string{"asg-0", "asg-1", "asg-2", "asg-3", "asg-4"} {
+		audit.append(AuditEntry{ASG: name, Result: AuditResultOK})
+	}
+	audit.close()
+
+	current := readAuditLog(t, path)
+	require.Len(t, current, 1)
+	assert.Equal(t, "asg-4", current[0].ASG)
+	rotated := readAuditLog(t, path+".1")
+	require.Len(t, rotated, 2)
+	assert.Equal(t, "asg-2", rotated[0].ASG)
+	assert.Equal(t, "asg-3", rotated[1].ASG)
+	for _, name := range []string{path, path + ".1"} {
+		info, err := os.Stat(name)
+		require.NoError(t, err)
+		assert.LessOrEqual(t, info.Size(), maxSize, name)
+	}
+}
+
+// TestAuditLog_FullQueue verifies appending never waits for a stalled writer.
+//
+// Conditions: audit log whose writer takes no entries and whose queue holds 1
+//
+// Expected result: the second entry is dropped and counted instead of blocking
+func TestAuditLog_FullQueue(t *testing.T) {
+	audit := &auditLog{path: "stalled.jsonl", entries: make(chan AuditEntry, 1), done: make(chan struct{})}
+
+	audit.append(AuditEntry{ASG: "asg-0"})
+	audit.append(AuditEntry{ASG: "asg-1"})
+
+	assert.EqualValues(t, 1, audit.dropped.Load())
+	assert.Equal(t, "asg-0", (<-audit.entries).ASG)
+}
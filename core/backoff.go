@@ -0,0 +1,77 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// backoffState is the error streak of an ASG and when it is evaluated next
+type backoffState struct {
+	errors   int           // Consecutive failed evaluations
+	interval time.Duration // Current evaluation interval
+	next     time.Time     // Earliest time of the next evaluation
+}
+
+// errorBackoff widens the evaluation interval of ASGs whose evaluations keep failing, so a flaky
+// provider or region is not hammered every cycle while healthy ASGs keep the normal cadence
+type errorBackoff struct {
+	mu     sync.Mutex
+	states map[string]backoffState
+}
+
+// due reports whether the ASG is evaluated in the cycle starting at now. Half a base interval of
+// slack absorbs the jitter between cycles, which start after GitLab has been queried.
+func (b *errorBackoff) due(asgName string, now time.Time, base time.Duration) (backoffState, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.states[asgName]
+	if !ok || state.next.IsZero() {
+		return state, true
+	}
+	return state, !now.Add(base / 2).Before(state.next)
+}
+
+// record updates the error streak of the ASG after an evaluation at now and returns its new state.
+// Once the streak reaches after, the interval doubles with every further failure up to maxInterval;
+// a successful evaluation restores the base interval.
+func (b *errorBackoff) record(asgName string, failed bool, now time.Time, base time.Duration, after int, maxInterval time.Duration) backoffState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.states == nil {
+		b.states = make(map[string]backoffState)
+	}
+
+	state := backoffState{interval: base}
+	if failed {
+		state.errors = b.states[asgName].errors + 1
+	}
+	if after > 0 && state.errors >= after {
+		for i := after; i <= state.errors && state.interval < maxInterval; i++ {
+			state.interval *= 2
+		}
+		if state.interval > maxInterval {
+			state.interval = max(maxInterval, base)
+		}
+		state.next = now.Add(state.interval)
+	}
+
+	if state.errors == 0 {
+		delete(b.states, asgName)
+	} else {
+		b.states[asgName] = state
+	}
+	return state
+}
+
+// rename moves the error streak of an ASG to its new name
+func (b *errorBackoff) rename(oldName, newName string) bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	state, ok := b.states[oldName]
+	if !ok {
+		return false
+	}
+	b.states[newName] = state
+	delete(b.states, oldName)
+	return true
+}
@@ -0,0 +1,87 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_ErrorBackoff verifies a failing ASG is evaluated less often while a healthy one keeps the normal cadence.
+//
+// Conditions:
+// - check-interval 10s, error-backoff-after 2, error-backoff-max 40s; the clock advances 10s per cycle
+// - "flaky" fails to describe its capacity 4 times, then recovers; "healthy" never fails
+//
+// Expected result:
+// - "flaky" is evaluated at 0s, 10s, 30s, 70s (interval 20s, then 40s capped) and again at 110s and 120s after recovery
+// - "healthy" is evaluated in all 13 cycles
+func TestScaleASGs_ErrorBackoff(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	flaky := config.Asg{Name: "flaky", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	healthy := config.Asg{Name: "healthy", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	orchestrator, cfg := newTestOrchestrator(provider, flaky, healthy)
+	cfg.Autoscaler.ErrorBackoffAfter = 2
+	cfg.Autoscaler.ErrorBackoffMax = 40 * time.Second
+
+	provider.On("GetCurrentCapacity", mock.Anything, "flaky").Return(int64(0), int64(0), errors.New("throttled")).Times(4)
+	provider.On("GetCurrentCapacity", mock.Anything, "flaky").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "healthy").Return(int64(1), int64(1), nil)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	var evaluated []time.Duration
+	for cycle := 0; cycle <= 12; cycle++ {
+		clock = start.Add(time.Duration(cycle) * 10 * time.Second)
+		before := len(provider.Calls)
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+		for _, call := range provider.Calls[before:] {
+			if call.Arguments.String(1) == "flaky" {
+				evaluated = append(evaluated, clock.Sub(start))
+			}
+		}
+
+		snapshot, _ := orchestrator.Snapshot()
+		if clock.Sub(start) == 20*time.Second {
+			assert.Equal(t, DecisionBackedOff, snapshot.ASGs[0].Decision)
+			assert.Equal(t, 20.0, snapshot.ASGs[0].CadenceSeconds)
+			assert.Equal(t, 2, snapshot.ASGs[0].ErrorStreak)
+		}
+		if clock.Sub(start) == 70*time.Second {
+			assert.Equal(t, 40.0, snapshot.ASGs[0].CadenceSeconds)
+		}
+		assert.Equal(t, 10.0, snapshot.ASGs[1].CadenceSeconds, "healthy cadence at %s", clock.Sub(start))
+	}
+
+	assert.Equal(t, []time.Duration{0, 10 * time.Second, 30 * time.Second, 70 * time.Second, 110 * time.Second, 120 * time.Second}, evaluated)
+	provider.AssertNumberOfCalls(t, "GetCurrentCapacity", 13+len(evaluated))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	assert.Equal(t, 10.0, snapshot.ASGs[0].CadenceSeconds)
+	assert.Zero(t, snapshot.ASGs[0].ErrorStreak)
+}
+
+// TestScaleASGs_ErrorBackoffDisabled verifies every ASG is evaluated each cycle without error-backoff-after
+func TestScaleASGs_ErrorBackoffDisabled(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "flaky", Tags: []string{"amd64"}, MaxAsgCapacity: 5})
+	provider.On("GetCurrentCapacity", mock.Anything, "flaky").Return(int64(0), int64(0), errors.New("throttled"))
+
+	for i := 0; i < 5; i++ {
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	}
+
+	provider.AssertNumberOfCalls(t, "GetCurrentCapacity", 5)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, 5, snapshot.ASGs[0].ErrorStreak)
+	assert.Equal(t, 10.0, snapshot.ASGs[0].CadenceSeconds)
+}
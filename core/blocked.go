@@ -0,0 +1,72 @@
+package core
+
+// Reasons demand could not be turned into capacity, in precedence order: a job blocked by
+// several constraints is attributed to the first one that binds
+const (
+	BlockedMaxCapacity     = "max-capacity"
+	BlockedTotalCapacity   = "max-total-capacity"
+	BlockedProviderError   = "provider-error"
+	BlockedRunnerUnhealthy = "runner-unhealthy"
+	// BlockedTagLimit counts pending jobs left out of demand by tag-limits; they never compete with the reasons above
+	BlockedTagLimit = "tag-limit"
+)
+
+// blockedDemand holds what is needed to attribute the unserved demand of an ASG in a cycle
+type blockedDemand struct {
+	pending         int64 // Matching pending jobs
+	free            int64 // Free job slots before scaling
+	jobsPerInstance int64 // Job slots per instance; 0 counts as 1
+	desired         int64 // Desired capacity before scaling
+	allocated       int64 // Allocated instances; desired - allocated are still launching
+	max             int64 // max-asg-capacity
+	totalCapped     int64 // Instances of the scale-up not granted under max-total-capacity
+	describeFailed  bool  // Capacity could not be read, so nothing was scaled
+	updateFailed    bool  // Scale-up was attempted and failed
+	missingRunners  int64 // Allocated instances without an online runner
+	tagLimited      int64 // Matching pending jobs left out of pending by tag-limits
+}
+
+// attribute returns the number of instances needed but blocked per reason; each instance is counted once
+func (b blockedDemand) attribute() map[string]int64 {
+	blocked := make(map[string]int64)
+	add := func(reason string, count int64) {
+		if count > 0 {
+			blocked[reason] += count
+		}
+	}
+
+	add(BlockedTagLimit, b.tagLimited)
+	if b.describeFailed {
+		add(BlockedProviderError, b.pending)
+		return nilIfEmpty(blocked)
+	}
+
+	// Instances still launching take on part of the shortfall, so the target is what allocated plus the shortfall reaches
+	perInstance := max(b.jobsPerInstance, 1)
+	needed := ceilDiv(b.pending-b.free, perInstance)
+	target := max(b.desired, b.allocated+needed)
+	if over := target - b.max; over > 0 {
+		add(BlockedMaxCapacity, min(over, needed))
+		target = b.max
+	}
+	if b.totalCapped > 0 {
+		add(BlockedTotalCapacity, b.totalCapped)
+		target -= b.totalCapped
+	}
+	if b.updateFailed {
+		add(BlockedProviderError, target-b.desired)
+	}
+
+	// Jobs counted against free slots still wait when those instances have no online runner
+	add(BlockedRunnerUnhealthy, min(b.missingRunners, ceilDiv(min(b.pending, b.free), perInstance)))
+
+	return nilIfEmpty(blocked)
+}
+
+// nilIfEmpty keeps the JSON status free of empty maps
+func nilIfEmpty(m map[string]int64) map[string]int64 {
+	if len(m) == 0 {
+		return nil
+	}
+	return m
+}
@@ -0,0 +1,135 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestBlockedDemand_SingleAttribution verifies every blocked instance is attributed to exactly one reason.
+//
+// Conditions: combinations of max capacity, failed scale-up, failed describe and missing runners
+//
+// Expected result: max-capacity takes precedence over provider-error; runner-unhealthy only covers
+// jobs counted against free slots, so totals never exceed the matching pending jobs
+func TestBlockedDemand_SingleAttribution(t *testing.T) {
+	cases := []struct {
+		name     string
+		demand   blockedDemand
+		expected map[string]int64
+	}{
+		{
+			name:     "fully served",
+			demand:   blockedDemand{pending: 3, free: 1, desired: 1, allocated: 1, max: 10},
+			expected: nil,
+		},
+		{
+			name:     "capped only",
+			demand:   blockedDemand{pending: 8, free: 0, desired: 2, allocated: 2, max: 5},
+			expected: map[string]int64{BlockedMaxCapacity: 5},
+		},
+		{
+			name:     "capped and scale-up failed",
+			demand:   blockedDemand{pending: 8, free: 0, desired: 2, allocated: 2, max: 5, updateFailed: true},
+			expected: map[string]int64{BlockedMaxCapacity: 5, BlockedProviderError: 3},
+		},
+		{
+			name:     "already above max and scale-up failed",
+			demand:   blockedDemand{pending: 4, free: 0, desired: 6, allocated: 6, max: 5, updateFailed: true},
+			expected: map[string]int64{BlockedMaxCapacity: 4},
+		},
+		{
+			name:     "launching instances cover the shortfall",
+			demand:   blockedDemand{pending: 3, free: 0, desired: 3, allocated: 0, max: 10},
+			expected: nil,
+		},
+		{
+			name:     "launching instances and capped",
+			demand:   blockedDemand{pending: 6, free: 0, desired: 3, allocated: 1, max: 5, updateFailed: true},
+			expected: map[string]int64{BlockedMaxCapacity: 2, BlockedProviderError: 2},
+		},
+		{
+			name:     "jobs per instance, capped and scale-up failed",
+			demand:   blockedDemand{pending: 9, free: 0, desired: 1, allocated: 1, max: 2, jobsPerInstance: 4, updateFailed: true},
+			expected: map[string]int64{BlockedMaxCapacity: 2, BlockedProviderError: 1},
+		},
+		{
+			name:     "describe failed",
+			demand:   blockedDemand{pending: 4, describeFailed: true, updateFailed: true, missingRunners: 2},
+			expected: map[string]int64{BlockedProviderError: 4},
+		},
+		{
+			name:     "runners missing and capped",
+			demand:   blockedDemand{pending: 6, free: 2, desired: 3, allocated: 3, max: 5, missingRunners: 3},
+			expected: map[string]int64{BlockedMaxCapacity: 2, BlockedRunnerUnhealthy: 2},
+		},
+		{
+			name:     "runners missing, fewer pending than free slots",
+			demand:   blockedDemand{pending: 1, free: 3, desired: 3, allocated: 3, max: 5, missingRunners: 3},
+			expected: map[string]int64{BlockedRunnerUnhealthy: 1},
+		},
+	}
+
+	for _, c := range cases {
+		blocked := c.demand.attribute()
+		if !reflect.DeepEqual(blocked, c.expected) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.expected, blocked)
+		}
+
+		var total int64
+		for _, count := range blocked {
+			total += count
+		}
+		if total > c.demand.pending {
+			t.Errorf("%s: %d blocked instances exceed %d pending jobs", c.name, total, c.demand.pending)
+		}
+	}
+}
+
+// TestScaleASGs_BlockedCapacity verifies blocked capacity is recorded per ASG and summed fleet-wide.
+//
+// Conditions:
+// - ASG "capped" with max capacity 2, 1 allocated instance and 4 pending "amd64" jobs
+// - ASG "broken" whose capacity cannot be read, 2 pending "arm64" jobs
+//
+// Expected result: capped blocked by max-capacity 2 (1 free slot + scale to 2 serves 2),
+// broken blocked by provider-error 2; fleet totals contain both
+func TestScaleASGs_BlockedCapacity(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "capped", Tags: []string{"amd64"}, MaxAsgCapacity: 2},
+		config.Asg{Name: "broken", Tags: []string{"arm64"}, MaxAsgCapacity: 5},
+	)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "capped").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "capped", int64(2)).Return(nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "broken").Return(int64(0), int64(0), errors.New("describe failed"))
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    6,
+		PendingJobsWithTags: map[string]int{"amd64": 4, "arm64": 2},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	snapshot, _ := orchestrator.Snapshot()
+	expected := map[string]map[string]int64{
+		"broken": {BlockedProviderError: 2},
+		"capped": {BlockedMaxCapacity: 2},
+	}
+	for _, status := range snapshot.ASGs {
+		if !reflect.DeepEqual(status.Blocked, expected[status.Name]) {
+			t.Errorf("%s: expected blocked %v, got %v", status.Name, expected[status.Name], status.Blocked)
+		}
+	}
+	fleet := map[string]int64{BlockedProviderError: 2, BlockedMaxCapacity: 2}
+	if !reflect.DeepEqual(snapshot.BlockedCapacity, fleet) {
+		t.Errorf("expected fleet blocked capacity %v, got %v", fleet, snapshot.BlockedCapacity)
+	}
+}
@@ -0,0 +1,165 @@
+package core
+
+import (
+	"sort"
+	"sync"
+)
+
+// capacityBudget shares the headroom below max-total-capacity among the ASGs scaling up in a cycle.
+// ASGs are evaluated concurrently, so every evaluation settles exactly once: with claim when it scales up,
+// with settle otherwise. Claims are granted once all evaluations settled, when the desired capacity of every
+// ASG is known: by descending priority, and in proportion to the instances asked for among equal priorities.
+// A nil budget grants every claim.
+type capacityBudget struct {
+	mu       sync.Mutex
+	resolved sync.Cond
+	limit    int64
+	waiting  int              // Evaluations that have not settled yet
+	inUse    int64            // Desired capacity of all ASGs, launching instances included
+	settled  map[string]bool  // ASGs that claimed or settled in this cycle
+	wants    map[string]int64 // Instances asked for per ASG
+	priority map[string]int   // Priority of the claiming ASGs
+	grants   map[string]int64 // Instances granted per ASG; nil until all evaluations settled
+}
+
+// newCapacityBudget returns the budget of a cycle with the given number of evaluations, starting from the desired
+// capacity of the ASGs not evaluated in the cycle; nil when limit is 0 (no max-total-capacity)
+func newCapacityBudget(limit int64, evaluations int, inUse int64) *capacityBudget {
+	if limit <= 0 {
+		return nil
+	}
+	b := &capacityBudget{
+		limit:    limit,
+		waiting:  evaluations,
+		inUse:    inUse,
+		settled:  make(map[string]bool),
+		wants:    make(map[string]int64),
+		priority: make(map[string]int),
+	}
+	b.resolved.L = &b.mu
+	if evaluations == 0 {
+		b.resolve()
+	}
+	return b
+}
+
+// claim asks for want more instances on top of the desired capacity of the ASG and waits until all evaluations
+// settled; it returns the instances granted. An ASG claims at most once per cycle, later claims get nothing.
+func (b *capacityBudget) claim(asgName string, priority int, desired, want int64) int64 {
+	if b == nil {
+		return want
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.settled[asgName] {
+		return 0
+	}
+	b.wants[asgName], b.priority[asgName] = want, priority
+	b.settleLocked(asgName, desired)
+
+	for b.grants == nil {
+		b.resolved.Wait()
+	}
+	return b.grants[asgName]
+}
+
+// settle reports the desired capacity of an ASG that did not claim; it is a no-op after a claim
+func (b *capacityBudget) settle(asgName string, desired int64) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if !b.settled[asgName] {
+		b.settleLocked(asgName, desired)
+	}
+}
+
+// settleLocked counts the ASG in and resolves the claims after the last evaluation
+func (b *capacityBudget) settleLocked(asgName string, desired int64) {
+	b.settled[asgName] = true
+	b.inUse += desired
+	b.waiting--
+	if b.waiting <= 0 {
+		b.resolve()
+		b.resolved.Broadcast()
+	}
+}
+
+// resolve grants the claims by descending priority until the headroom is used up
+func (b *capacityBudget) resolve() {
+	byPriority := make(map[int][]string)
+	for name := range b.wants {
+		byPriority[b.priority[name]] = append(byPriority[b.priority[name]], name)
+	}
+	priorities := make([]int, 0, len(byPriority))
+	for priority := range byPriority {
+		priorities = append(priorities, priority)
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(priorities)))
+
+	b.grants = make(map[string]int64, len(b.wants))
+	headroom := max(b.limit-b.inUse, 0)
+	for _, priority := range priorities {
+		headroom -= b.share(byPriority[priority], headroom)
+	}
+}
+
+// share grants the claims of names, which have equal priority, out of headroom and returns the instances granted.
+// When they exceed the headroom, every ASG gets its share of the headroom rounded down, and the instances left
+// go to the largest remainders, ties by name.
+func (b *capacityBudget) share(names []string, headroom int64) int64 {
+	var total int64
+	for _, name := range names {
+		total += b.wants[name]
+	}
+	if total <= headroom {
+		for _, name := range names {
+			b.grants[name] = b.wants[name]
+		}
+		return total
+	}
+
+	left := headroom
+	for _, name := range names {
+		b.grants[name] = headroom * b.wants[name] / total
+		left -= b.grants[name]
+	}
+	sort.Slice(names, func(i, j int) bool {
+		ri, rj := headroom*b.wants[names[i]]%total, headroom*b.wants[names[j]]%total
+		if ri != rj {
+			return ri > rj
+		}
+		return names[i] < names[j]
+	})
+	for _, name := range names[:left] {
+		b.grants[name]++
+	}
+	return headroom
+}
+
+// throttled returns the instances asked for but not granted per ASG; nil when no claim was cut
+func (b *capacityBudget) throttled() map[string]int64 {
+	if b == nil {
+		return nil
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	var throttled map[string]int64
+	for name, want := range b.wants {
+		if cut := want - b.grants[name]; cut > 0 {
+			if throttled == nil {
+				throttled = make(map[string]int64)
+			}
+			throttled[name] = cut
+		}
+	}
+	return throttled
+}
+
+// headroom returns the instances left below max-total-capacity before the claims of the cycle
+func (b *capacityBudget) headroom() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return max(b.limit-b.inUse, 0)
+}
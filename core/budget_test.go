@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestCapacityBudget_Claims verifies the headroom below max-total-capacity is shared once all evaluations settled
+// Expected behavior:
+//   - Claims wait for every evaluation, including those that only settle
+//   - Claims within the headroom are granted in full
+//   - Claims beyond it share the headroom by the instances asked for, the largest remainder first
+//   - A second claim of the same ASG gets nothing; a nil budget grants everything
+func TestCapacityBudget_Claims(t *testing.T) {
+	budget := newCapacityBudget(10, 3, 1) // 1 desired by a backed off ASG
+	grants := make(map[string]int64)
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, want := range map[string]int64{"a": 3, "b": 2} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			granted := budget.claim(name, 0, 2, want)
+			mu.Lock()
+			grants[name] = granted
+			mu.Unlock()
+		}()
+	}
+	budget.settle("c", 2)
+	wg.Wait()
+
+	// 10 - (1 + 2 + 2 + 2) leaves 3 of the 5 asked for: a 9/5 -> 1 + remainder, b 6/5 -> 1
+	assert.Equal(t, map[string]int64{"a": 2, "b": 1}, grants)
+	assert.Equal(t, map[string]int64{"a": 1, "b": 1}, budget.throttled())
+	assert.Equal(t, int64(0), budget.claim("a", 0, 2, 1))
+
+	budget = newCapacityBudget(10, 1, 0)
+	assert.Equal(t, int64(4), budget.claim("a", 0, 2, 4))
+	assert.Nil(t, budget.throttled())
+
+	var unlimited *capacityBudget
+	assert.Equal(t, int64(50), unlimited.claim("a", 0, 2, 50))
+	assert.Nil(t, newCapacityBudget(0, 2, 0))
+}
+
+// TestScaleASGs_MaxTotalCapacity verifies scale-ups of several ASGs share the headroom below max-total-capacity.
+//
+// Conditions:
+// - ASGs "amd64" and "arm64" at 1 of 1 with 4 and 3 pending jobs, idle "gpu" at 2 of 2; max 10 each
+// - max-total-capacity 7, so 3 instances are left for the 3 + 2 asked for
+//
+// Expected result:
+// - amd64 scales up to 3 (+2), arm64 to 2 (+1); gpu scales down to 1
+// - 2 instances are reported blocked by max-total-capacity
+func TestScaleASGs_MaxTotalCapacity(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "amd64", Tags: []string{"amd64"}, MaxAsgCapacity: 10},
+		config.Asg{Name: "arm64", Tags: []string{"arm64"}, MaxAsgCapacity: 10},
+		config.Asg{Name: "gpu", Tags: []string{"gpu"}, MaxAsgCapacity: 10})
+	cfg.Autoscaler.MaxTotalCapacity = 7
+
+	provider.On("GetCurrentCapacity", mock.Anything, "amd64").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "arm64").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "amd64", int64(3)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "arm64", int64(2)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    7,
+		PendingJobsWithTags: map[string]int{"amd64": 4, "arm64": 3},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, int64(2), snapshot.BlockedCapacity[BlockedTotalCapacity])
+	assert.Contains(t, snapshot.ASGs[0].Reason, "1 instances throttled by max-total-capacity 7")
+}
+
+// TestScaleASGs_Priority verifies contested headroom goes to the ASG with the higher priority first.
+//
+// Conditions:
+// - ASGs "amd64" (priority 0) and "gpu" (priority 10), both at 1 of 1 with 4 pending jobs; max 10 each
+// - max-total-capacity 6, so 4 instances are left for the 3 + 3 asked for
+//
+// Expected result: gpu gets all 3 instances and scales up to 4; amd64 gets the 1 left and scales up to 2
+func TestScaleASGs_Priority(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "amd64", Tags: []string{"amd64"}, MaxAsgCapacity: 10},
+		config.Asg{Name: "gpu", Tags: []string{"gpu"}, MaxAsgCapacity: 10, Priority: 10})
+	cfg.Autoscaler.MaxTotalCapacity = 6
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu", int64(4)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "amd64", int64(2)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    8,
+		PendingJobsWithTags: map[string]int{"amd64": 4, "gpu": 4},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTotalCapacity: 2}, snapshot.ASGs[0].Blocked)
+	assert.Nil(t, snapshot.ASGs[1].Blocked)
+}
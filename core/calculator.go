@@ -1,13 +1,28 @@
 package core
 
 import (
+	"math"
+	"slices"
+
 	"github.com/shuliakovsky/gitlab-autoscaler/config"
 	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
 )
 
-// CapacityCalculator defines the interface for capacity calculation strategies
+// CapacityCalculator defines the interface for capacity calculation strategies. The orchestrator asks it how much
+// work an ASG serves and how many instances that work needs; clamping to the capacity bounds, damping and provider
+// calls stay in the orchestrator.
 type CapacityCalculator interface {
-	Calculate(asg config.Asg, state gitlab.ClusterState) int64
+	// Demand returns the job slots of the pending and running jobs the ASG serves
+	Demand(asg config.Asg, state gitlab.ClusterState) Demand
+	// Shortfall returns the free job slots of the allocated instances and the instances to add to them so that
+	// the pending job slots of demand plus extra free slots fit
+	Shortfall(asg config.Asg, state gitlab.ClusterState, demand Demand, allocated, extra int64) (free, additional int64)
+}
+
+// Demand is the work an ASG serves in a cycle, in job slots
+type Demand struct {
+	Pending int64 // Slots of the matching pending jobs
+	Running int64 // Slots of the matching running jobs
 }
 
 // TagBasedCalculator calculates capacity based on job tags
@@ -18,12 +33,77 @@ func NewTagBasedCalculator() *TagBasedCalculator {
 	return &TagBasedCalculator{}
 }
 
-// Calculate computes the required capacity for an ASG based on pending jobs and tags
+// Demand counts the pending and running jobs matching the ASG tags. Tag patterns (glob:, re:) are expanded against
+// the live tags first so that no tag is counted twice. Jobs are weighted by tag-weights, or by their size tag
+// when they carry one; jobs without tags only count with handles-untagged-jobs.
+func (c *TagBasedCalculator) Demand(asg config.Asg, state gitlab.ClusterState) Demand {
+	tags := config.ExpandTags(asg.Tags, liveTags(state))
+	return Demand{
+		Pending: matchingJobs(tags, asg.ExcludeTags, asg.TagWeight, state.PendingJobsWithTags, state.PendingTagShares, state.PendingJobList) +
+			untaggedJobs(asg, state.PendingWithoutTags),
+		Running: matchingJobs(tags, asg.ExcludeTags, asg.TagWeight, state.RunningJobsWithTags, nil, state.RunningJobList) +
+			untaggedJobs(asg, state.RunningWithoutTags),
+	}
+}
+
+// Shortfall counts jobs-per-instance slots per allocated instance, minus the slots of the running jobs the ASG
+// serves, as free; running jobs of other fleets do not occupy its instances. The pending slots plus extra beyond
+// the free ones are rounded up to whole instances.
+func (c *TagBasedCalculator) Shortfall(asg config.Asg, state gitlab.ClusterState, demand Demand, allocated, extra int64) (int64, int64) {
+	jobsPerInstance := asg.EffectiveJobsPerInstance()
+	free := max(allocated*jobsPerInstance-demand.Running, 0)
+	return free, ceilDiv(demand.Pending+extra-free, jobsPerInstance)
+}
+
+// Calculate computes the instances needed for the pending jobs of an ASG from scratch, i.e. without allocated
+// instances, in instances of jobs-per-instance slots each
 func (c *TagBasedCalculator) Calculate(asg config.Asg, state gitlab.ClusterState) int64 {
-	var pendingCount int64 = 0
-	for _, tag := range asg.Tags {
-		pendingCount += int64(state.PendingJobsWithTags[tag])
+	return ceilDiv(c.Demand(asg, state).Pending, asg.EffectiveJobsPerInstance())
+}
+
+// ceilDiv divides job slots by the slots per instance, rounding up to whole instances
+func ceilDiv(slots, perInstance int64) int64 {
+	if slots <= 0 {
+		return 0
+	}
+	return (slots + perInstance - 1) / perInstance
+}
+
+// pendingShare returns the fraction of the jobs of tag counted for an ASG, see ClusterState.PendingTagShares
+func pendingShare(shares map[string]float64, tag string) float64 {
+	if share, ok := shares[tag]; ok {
+		return share
+	}
+	return 1
+}
+
+// matchingJobs sums the per-tag counts of the ASG tags multiplied by their weight, then corrects the
+// contribution of single jobs using the per-job list: jobs carrying any excluded tag are subtracted, and
+// jobs with a size tag count their size instead of the tag weight. The counts of a tag split among several
+// ASGs are the share of this one, so the corrections of its jobs are scaled by shares, the counted fraction
+// of the tag: an ASG subtracts its share of the excluded jobs, not those counted by other ASGs. A fractional sum is rounded up to whole job slots.
+func matchingJobs(tags, excludeTags []string, weight func(tag string) float64, countsWithTags map[string]int, shares map[string]float64, jobs []gitlab.JobSummary) int64 {
+	var count float64 = 0
+	for _, tag := range tags {
+		count += float64(countsWithTags[tag]) * weight(tag)
 	}
 
-	return pendingCount
+	for _, job := range jobs {
+		excluded := len(excludeTags) > 0 && matchesAnyTag(excludeTags, job.Tags)
+		if !excluded && job.Size == 0 {
+			continue
+		}
+		for _, tag := range job.Tags {
+			if !slices.Contains(tags, tag) {
+				continue
+			}
+			if excluded {
+				count -= weight(tag) * pendingShare(shares, tag)
+			} else {
+				count += (float64(job.Slots()) - weight(tag)) * pendingShare(shares, tag)
+			}
+		}
+	}
+	// Summing weights such as 1.1 accumulates float error; do not let it round up a whole extra slot
+	return int64(math.Ceil(max(count, 0) - 1e-9))
 }
@@ -1,10 +1,14 @@
 package core
 
 import (
+	"context"
 	"testing"
 
+	"github.com/stretchr/testify/mock"
+
 	"github.com/shuliakovsky/gitlab-autoscaler/config"
 	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
 )
 
 // TestTagBasedCalculator_TagsOnly verifies the basic tag-based capacity calculation
@@ -296,36 +300,287 @@ func TestScaleDown_FullCycle(t *testing.T) {
 	}
 }
 
-// calculateDesiredCapacity calculates the desired capacity correctly
+// calculateDesiredCapacity adds the shortfall reported by the calculator to the current capacity, capped at
+// max-asg-capacity, the way scaleASG proposes a scale-up
 func calculateDesiredCapacity(asg config.Asg, state gitlab.ClusterState, currentCapacity int64) int64 {
-	pendingForASG := int64(0)
-	for _, tag := range asg.Tags {
-		if count, exists := state.PendingJobsWithTags[tag]; exists {
-			pendingForASG += int64(count)
-		}
+	calculator := NewTagBasedCalculator()
+	_, additional := calculator.Shortfall(asg, state, calculator.Demand(asg, state), currentCapacity, 0)
+	return min(currentCapacity+additional, asg.MaxAsgCapacity)
+}
+
+// TestTagBasedCalculator_OverlappingPatterns verifies a tag matched by several entries is counted once.
+//
+// Conditions:
+// - ASG with tags ["glob:team-*-runner", "re:^team-pay", "team-payments-runner"]
+// - Pending jobs: 2 for "team-payments-runner", 3 for "team-search-runner", 4 for "gpu-large"
+//
+// Expected result: 5 (2 + 3) - "team-payments-runner" matches all three entries but counts once
+func TestTagBasedCalculator_OverlappingPatterns(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+
+	asg := config.Asg{
+		Name: "test-asg",
+		Tags: []string{"glob:team-*-runner", "re:^team-pay", "team-payments-runner"},
+	}
+
+	state := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{
+			"team-payments-runner": 2,
+			"team-search-runner":   3,
+			"gpu-large":            4,
+		},
 	}
 
-	runningCount := int64(0)
-	for _, tag := range asg.Tags {
-		if count, exists := state.RunningJobsWithTags[tag]; exists {
-			runningCount += int64(count)
+	desired := calculator.Calculate(asg, state)
+
+	if desired != 5 {
+		t.Errorf("Expected 5, got %d", desired)
+	}
+}
+
+// TestTagBasedCalculator_ExcludeTags verifies jobs carrying an excluded tag are not counted.
+//
+// Conditions:
+// - ASG with tags ["amd64"] and exclude-tags ["privileged"]
+// - Pending jobs: ["amd64"], ["amd64", "privileged"], ["amd64", "docker"], ["arm64", "privileged"]
+//
+// Expected result: 2 - the privileged "amd64" job is left to another fleet
+func TestTagBasedCalculator_ExcludeTags(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+
+	asg := config.Asg{
+		Name:        "test-asg",
+		Tags:        []string{"amd64"},
+		ExcludeTags: []string{"privileged"},
+	}
+
+	state := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{"amd64": 3, "privileged": 2, "docker": 1, "arm64": 1},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"amd64"}},
+			{Tags: []string{"amd64", "privileged"}},
+			{Tags: []string{"amd64", "docker"}},
+			{Tags: []string{"arm64", "privileged"}},
+		},
+	}
+
+	desired := calculator.Calculate(asg, state)
+
+	if desired != 2 {
+		t.Errorf("Expected 2, got %d", desired)
+	}
+}
+
+// TestTagBasedCalculator_JobsPerInstance verifies job slots are rounded up to whole instances.
+//
+// Conditions:
+// - ASG with tag ["amd64"] and jobs-per-instance 4
+// - 0, 1, 4, 5 and 9 pending "amd64" jobs
+//
+// Expected result: 0, 1, 1, 2 and 3 instances
+func TestTagBasedCalculator_JobsPerInstance(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, JobsPerInstance: 4}
+
+	for pending, expected := range map[int]int64{0: 0, 1: 1, 4: 1, 5: 2, 9: 3} {
+		state := gitlab.ClusterState{PendingJobsWithTags: map[string]int{"amd64": pending}}
+		if desired := calculator.Calculate(asg, state); desired != expected {
+			t.Errorf("%d pending jobs: expected %d instances, got %d", pending, expected, desired)
 		}
 	}
+}
+
+// TestTagBasedCalculator_TagWeights verifies tag-weights scale job slots before the jobs-per-instance division.
+//
+// Conditions:
+// - ASG with tags ["amd64", "integration"], integration weighted 2 and amd64 weighted 0.5
+// - Pending jobs: 3 "amd64", 2 "integration"; jobs-per-instance 1, then 4
+// - A weight of 1.1 on 10 "amd64" jobs
+//
+// Expected result:
+// - 1.5 + 4 = 5.5 job slots round up to 6 instances; with jobs-per-instance 4 to 2 instances
+// - 11 job slots, not 12 from float error
+func TestTagBasedCalculator_TagWeights(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{
+		Name:       "test-asg",
+		Tags:       []string{"amd64", "integration"},
+		TagWeights: map[string]float64{"integration": 2, "amd64": 0.5},
+	}
+	state := gitlab.ClusterState{PendingJobsWithTags: map[string]int{"amd64": 3, "integration": 2}}
+
+	if desired := calculator.Calculate(asg, state); desired != 6 {
+		t.Errorf("Expected 6, got %d", desired)
+	}
+
+	asg.JobsPerInstance = 4
+	if desired := calculator.Calculate(asg, state); desired != 2 {
+		t.Errorf("Expected 2 with jobs-per-instance 4, got %d", desired)
+	}
+
+	asg.JobsPerInstance = 1
+	asg.TagWeights = map[string]float64{"amd64": 1.1}
+	state = gitlab.ClusterState{PendingJobsWithTags: map[string]int{"amd64": 10}}
+	if desired := calculator.Calculate(asg, state); desired != 11 {
+		t.Errorf("Expected 11, got %d", desired)
+	}
+}
 
-	freeCapacity := currentCapacity - runningCount
-	if freeCapacity < 0 {
-		freeCapacity = 0
+// TestTagBasedCalculator_TagWeightsExcludeTags verifies an excluded job takes its weighted slots along.
+//
+// Conditions:
+// - ASG with tag ["integration"] weighted 2 and exclude-tags ["privileged"]
+// - Pending jobs: ["integration"], ["integration", "privileged"]
+//
+// Expected result: 2 - only the unprivileged job counts, at weight 2
+func TestTagBasedCalculator_TagWeightsExcludeTags(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{
+		Name:        "test-asg",
+		Tags:        []string{"integration"},
+		ExcludeTags: []string{"privileged"},
+		TagWeights:  map[string]float64{"integration": 2},
+	}
+	state := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{"integration": 2, "privileged": 1},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"integration"}},
+			{Tags: []string{"integration", "privileged"}},
+		},
 	}
 
-	additionalNeeded := pendingForASG - freeCapacity
-	if additionalNeeded < 0 {
-		additionalNeeded = 0
+	if desired := calculator.Calculate(asg, state); desired != 2 {
+		t.Errorf("Expected 2, got %d", desired)
+	}
+}
+
+// TestTagBasedCalculator_SizeTags verifies sized jobs count their size instead of the tag weight.
+//
+// Conditions:
+// - ASG with tags ["amd64", "integration"] weighted {"integration": 2} and exclude-tags ["privileged"]
+// - Pending jobs: ["amd64"], ["amd64"] of size 3, ["integration"], ["integration"] of size 3, ["amd64", "privileged"] of size 4
+// - Running jobs: ["amd64"] of size 2
+//
+// Expected result: 9 pending slots (1 + 3 + 2 + 3, the privileged job excluded) and 2 running slots
+func TestTagBasedCalculator_SizeTags(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{
+		Name:        "test-asg",
+		Tags:        []string{"amd64", "integration"},
+		ExcludeTags: []string{"privileged"},
+		TagWeights:  map[string]float64{"integration": 2},
+	}
+	state := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{"amd64": 3, "integration": 2, "privileged": 1, "size-3x": 2, "size-4x": 1},
+		RunningJobsWithTags: map[string]int{"amd64": 1, "size-2x": 1},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"amd64"}},
+			{Tags: []string{"amd64", "size-3x"}, Size: 3},
+			{Tags: []string{"integration"}},
+			{Tags: []string{"integration", "size-3x"}, Size: 3},
+			{Tags: []string{"amd64", "privileged", "size-4x"}, Size: 4},
+		},
+		RunningJobList: []gitlab.JobSummary{{Tags: []string{"amd64", "size-2x"}, Size: 2}},
 	}
 
-	desired := currentCapacity + additionalNeeded
-	if desired > asg.MaxAsgCapacity {
-		desired = asg.MaxAsgCapacity
+	demand := calculator.Demand(asg, state)
+	if demand.Pending != 9 || demand.Running != 2 {
+		t.Errorf("Expected 9 pending and 2 running slots, got %d and %d", demand.Pending, demand.Running)
 	}
+}
 
-	return desired
+// TestTagBasedCalculator_Demand verifies pending and running jobs are counted alike.
+//
+// Conditions:
+// - ASG with tags ["amd64", "glob:team-*"] weighted {"amd64": 2}, handles-untagged-jobs
+// - Pending jobs: 2 "amd64", 1 "team-a", 3 "arm64", 1 untagged; running jobs: 1 "amd64", 2 "team-b", 2 untagged
+//
+// Expected result: 6 pending slots (4 + 1 + 1) and 6 running slots (2 + 2 + 2)
+func TestTagBasedCalculator_Demand(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{
+		Name:                "test-asg",
+		Tags:                []string{"amd64", "glob:team-*"},
+		TagWeights:          map[string]float64{"amd64": 2},
+		HandlesUntaggedJobs: true,
+	}
+	state := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{"amd64": 2, "team-a": 1, "arm64": 3},
+		RunningJobsWithTags: map[string]int{"amd64": 1, "team-b": 2},
+		PendingWithoutTags:  1,
+		RunningWithoutTags:  2,
+	}
+
+	demand := calculator.Demand(asg, state)
+
+	if demand != (Demand{Pending: 6, Running: 6}) {
+		t.Errorf("Expected 6 pending and 6 running slots, got %+v", demand)
+	}
+}
+
+// TestTagBasedCalculator_Shortfall verifies free slots and the instances missing for pending jobs and extra slots.
+//
+// Conditions:
+// - ASG with jobs-per-instance 4 and 3 allocated instances; 5 matching jobs running, 40 in the whole cluster
+// - 6 pending slots, without and with 4 extra (warm) slots; then 20 matching running jobs
+//
+// Expected result: 7 free slots and no instance missing; with extra slots 1 missing; with 20 matching running
+// jobs 0 free slots and 2 missing. Running jobs of other fleets never count.
+func TestTagBasedCalculator_Shortfall(t *testing.T) {
+	calculator := NewTagBasedCalculator()
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, JobsPerInstance: 4}
+	state := gitlab.ClusterState{TotalRunningJobs: 40}
+	demand := Demand{Pending: 6, Running: 5}
+
+	if free, additional := calculator.Shortfall(asg, state, demand, 3, 0); free != 7 || additional != 0 {
+		t.Errorf("Expected 7 free slots and 0 instances, got %d and %d", free, additional)
+	}
+	if free, additional := calculator.Shortfall(asg, state, demand, 3, 4); free != 7 || additional != 1 {
+		t.Errorf("Expected 7 free slots and 1 instance with extra slots, got %d and %d", free, additional)
+	}
+
+	demand.Running = 20
+	if free, additional := calculator.Shortfall(asg, state, demand, 3, 0); free != 0 || additional != 2 {
+		t.Errorf("Expected 0 free slots and 2 instances, got %d and %d", free, additional)
+	}
+}
+
+// fixedCalculator reports a fixed demand and shortfall, whatever the cluster state
+type fixedCalculator struct {
+	demand     Demand
+	additional int64
+}
+
+func (c fixedCalculator) Demand(config.Asg, gitlab.ClusterState) Demand { return c.demand }
+
+func (c fixedCalculator) Shortfall(config.Asg, gitlab.ClusterState, Demand, int64, int64) (int64, int64) {
+	return 0, c.additional
+}
+
+// TestScaleASGs_InjectedCalculator verifies the orchestrator scales by the calculator it was created with.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, 2 allocated; the cluster state has no jobs
+// - The injected calculator reports 1 pending slot and 5 missing instances, then 20 missing instances
+//
+// Expected result: scale-up to 7, then capped at max-asg-capacity 10
+func TestScaleASGs_InjectedCalculator(t *testing.T) {
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	state := gitlab.ClusterState{TotalPendingJobs: 1}
+
+	for additional, expected := range map[int64]int64{5: 7, 20: 10} {
+		provider := &mocks.MockProvider{}
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+		provider.On("UpdateASGCapacity", mock.Anything, "test-asg", expected).Return(nil).Once()
+
+		calculator := fixedCalculator{demand: Demand{Pending: 1}, additional: additional}
+		orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"test-asg": "aws"}, calculator, nil)
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+
+		provider.AssertExpectations(t)
+	}
 }
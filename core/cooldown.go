@@ -0,0 +1,64 @@
+package core
+
+import (
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// scaleUpMemory remembers when each ASG was last scaled up, for scale-up-cooldown and scale-down-cooldown
+type scaleUpMemory struct {
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+// record remembers a scale-up of the ASG at now
+func (m *scaleUpMemory) record(asgName string, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.last == nil {
+		m.last = make(map[string]time.Time)
+	}
+	m.last[asgName] = now
+}
+
+// cooldownRemaining returns how much of cooldown is left since the last scale-up of the ASG
+func (m *scaleUpMemory) cooldownRemaining(asgName string, cooldown time.Duration, now time.Time) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last, ok := m.last[asgName]
+	if !ok || cooldown <= 0 {
+		return 0
+	}
+	return max(last.Add(cooldown).Sub(now), 0)
+}
+
+// rename moves the last scale-up of an ASG to its new name
+func (m *scaleUpMemory) rename(oldName, newName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	last, ok := m.last[oldName]
+	if !ok {
+		return false
+	}
+	m.last[newName] = last
+	delete(m.last, oldName)
+	return true
+}
+
+// scaleDownCooldown returns how long scale-down of the ASG is suppressed after a scale-up; the ASG setting overrides the global one
+func scaleDownCooldown(asg config.Asg, settings config.AutoscalerConfig) time.Duration {
+	if asg.ScaleDownCooldown > 0 {
+		return asg.ScaleDownCooldown
+	}
+	return settings.ScaleDownCooldown
+}
+
+// scaleUpCooldown returns how long further scale-ups of the ASG are suppressed while instances boot; the ASG setting overrides the global one
+func scaleUpCooldown(asg config.Asg, settings config.AutoscalerConfig) time.Duration {
+	if asg.ScaleUpCooldown > 0 {
+		return asg.ScaleUpCooldown
+	}
+	return settings.ScaleUpCooldown
+}
@@ -0,0 +1,118 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_ScaleDownCooldown verifies scale-down is suppressed for scale-down-cooldown after a scale-up.
+//
+// Conditions:
+// - ASG with tag ["amd64"], scale-down-cooldown 10m overriding the global 1m
+// - 0m: 3 pending jobs on 1 instance; 5m and 10m: idle on 3 instances
+//
+// Expected result: scale-up to 3 at 0m, no scale-down at 5m (5m remaining), scale-down to 2 at 10m
+func TestScaleASGs_ScaleDownCooldown(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleDownCooldown: 10 * time.Minute}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.ScaleDownCooldown = time.Minute
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(3), int64(3), nil)
+	clock = start.Add(5 * time.Minute)
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	assert.Equal(t, "scale-down cooldown: 5m0s remaining after last scale-up", snapshot.ASGs[0].Reason)
+
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(2)).Return(nil).Once()
+	clock = start.Add(10 * time.Minute)
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleDown, snapshot.ASGs[0].Decision)
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ScaleDownCooldownWithoutScaleUp verifies the cooldown only applies after a scale-up
+func TestScaleASGs_ScaleDownCooldownWithoutScaleUp(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5})
+	cfg.Autoscaler.ScaleDownCooldown = 10 * time.Minute
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ScaleUpCooldown verifies successive scale-ups wait for booting instances or the cooldown.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, scale-up-cooldown 2m
+// - 0s: 1 of 1 allocated, 3 pending; 30s: 1 of 3 allocated, 5 pending; 60s: 3 of 3 allocated, 5 pending;
+// - 90s: 3 of 5 allocated, 6 pending; 4m: still 3 of 5 allocated, 6 pending
+//
+// Expected result:
+// - Scale-up to 3 at 0s; suppressed at 30s while 2 instances boot
+// - Scale-up to 5 at 60s since allocated caught up with desired
+// - Suppressed at 90s; scale-up to 6 at 4m once the cooldown elapsed
+func TestScaleASGs_ScaleUpCooldown(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleUpCooldown: 2 * time.Minute}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	cycles := []struct {
+		at                 time.Duration
+		allocated, desired int64
+		pending            int
+		scaleTo            int64
+	}{
+		{0, 1, 1, 3, 3},
+		{30 * time.Second, 1, 3, 5, 0},
+		{time.Minute, 3, 3, 5, 5},
+		{90 * time.Second, 3, 5, 6, 0},
+		{4 * time.Minute, 3, 5, 6, 6},
+	}
+	for _, cycle := range cycles {
+		clock = start.Add(cycle.at)
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(cycle.allocated, cycle.desired, nil).Once()
+		if cycle.scaleTo > 0 {
+			provider.On("UpdateASGCapacity", mock.Anything, "test-asg", cycle.scaleTo).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(cycle.pending))
+
+		snapshot, _ := orchestrator.Snapshot()
+		if cycle.scaleTo > 0 {
+			assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision, "at %s", cycle.at)
+		} else {
+			assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision, "at %s", cycle.at)
+			assert.Contains(t, snapshot.ASGs[0].Reason, "scale-up cooldown", "at %s", cycle.at)
+		}
+	}
+
+	provider.AssertExpectations(t)
+}
@@ -0,0 +1,44 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// BatchDescriber is implemented by providers that can describe many ASGs in one request. DescribeAll is called
+// once per cycle before the ASGs are evaluated; GetCurrentCapacity then answers from its result instead of
+// describing every ASG on its own.
+type BatchDescriber interface {
+	// DescribeAll describes the ASGs for the GetCurrentCapacity calls of this cycle, replacing the result of the
+	// previous call; ASGs missing from the result are described on their own
+	DescribeAll(ctx context.Context, asgNames []string) error
+}
+
+// describeAll describes the ASGs due this cycle in one batch per provider that supports it. A failed batch
+// is only logged: GetCurrentCapacity falls back to describing each ASG.
+func (o *Orchestrator) describeAll(ctx context.Context, asgs []config.Asg) {
+	byProvider := make(map[string][]string)
+	describers := make(map[string]BatchDescriber)
+	for _, asg := range asgs {
+		providerName, provider, ok := o.providerFor(asg.Name)
+		if !ok {
+			continue
+		}
+		describer, ok := provider.(BatchDescriber)
+		if !ok {
+			continue
+		}
+		describers[providerName] = describer
+		byProvider[providerName] = append(byProvider[providerName], asg.Name)
+	}
+
+	for _, providerName := range slices.Sorted(maps.Keys(byProvider)) {
+		if err := describers[providerName].DescribeAll(ctx, byProvider[providerName]); err != nil {
+			slog.Warn("Error describing ASGs in one batch, describing them one by one", "provider", providerName, "error", err)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// batchProvider is a mock provider recording its DescribeAll calls
+type batchProvider struct {
+	*mocks.MockProvider
+	batches [][]string
+	err     error
+}
+
+func (p *batchProvider) DescribeAll(_ context.Context, asgNames []string) error {
+	p.batches = append(p.batches, asgNames)
+	return p.err
+}
+
+// TestScaleASGs_DescribeAll verifies the ASGs of a provider are described in one batch per cycle.
+//
+// Conditions:
+// - ASGs "asg-a" and "asg-b" of a provider supporting DescribeAll; no jobs
+// - A second cycle in which DescribeAll fails
+//
+// Expected result: one DescribeAll with both ASGs per cycle before their capacity is read; after the failure the
+// ASGs are still evaluated through GetCurrentCapacity
+func TestScaleASGs_DescribeAll(t *testing.T) {
+	mockProvider := &mocks.MockProvider{}
+	asgs := []config.Asg{
+		{Name: "asg-a", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true},
+		{Name: "asg-b", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true},
+	}
+	orchestrator, cfg := newTestOrchestrator(mockProvider, asgs...)
+	provider := &batchProvider{MockProvider: mockProvider}
+	orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{"asg-a": "aws", "asg-b": "aws"})
+	mockProvider.On("GetCurrentCapacity", mock.Anything, "asg-a").Return(int64(0), int64(0), nil)
+	mockProvider.On("GetCurrentCapacity", mock.Anything, "asg-b").Return(int64(0), int64(0), nil)
+	idle := gitlab.ClusterState{PendingJobsWithTags: map[string]int{}, RunningJobsWithTags: map[string]int{}}
+
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+	assert.Equal(t, [][]string{{"asg-a", "asg-b"}}, provider.batches)
+
+	provider.err = errors.New("Throttling")
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+	assert.Len(t, provider.batches, 2)
+	mockProvider.AssertNumberOfCalls(t, "GetCurrentCapacity", 4)
+	snapshot, _ := orchestrator.Snapshot()
+	for _, status := range snapshot.ASGs {
+		assert.NotEqual(t, DecisionError, status.Decision, status.Name)
+	}
+}
@@ -0,0 +1,125 @@
+package core
+
+import (
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// StuckQueue is a tag whose pending jobs got no scale-up for stuck-queue-cycles cycles; it is part of the public status API
+type StuckQueue = api.StuckQueue
+
+// blockedRemedies holds the diagnosis line of every blocked reason, in the precedence order of attribute.
+// The line is formatted with the ASG name and the instances blocked.
+var blockedRemedies = []struct {
+	reason string
+	format string
+}{
+	{BlockedMaxCapacity, "%s is at its capacity ceiling with %d instances blocked: raise max-asg-capacity, or max-capacity of its active schedule"},
+	{BlockedTotalCapacity, "%s has %d instances throttled by autoscaler.max-total-capacity: raise it, or give the ASG a higher priority"},
+	{BlockedProviderError, "%s has %d instances blocked by provider errors: check the provider credentials and API limits, and that the ASG exists in its region"},
+	{BlockedRunnerUnhealthy, "%s has %d instances without an online runner: check the gitlab-runner service and its registration on those instances"},
+	{BlockedTagLimit, "%s has %d pending jobs held back by autoscaler.tag-limits: raise the limit of the tag if the fleet can take them"},
+}
+
+// diagnoseStuckQueues counts the consecutive cycles every pending tag got no scale-up from the ASGs serving it and
+// diagnoses the tags stuck for limit cycles or more. The diagnosis only repeats the decisions and blocked reasons of
+// the cycle, so it never guesses; limit 0 disables it.
+func (o *Orchestrator) diagnoseStuckQueues(limit int, asgs []config.Asg, state gitlab.ClusterState, statuses []ASGStatus) []StuckQueue {
+	if limit <= 0 {
+		o.stuckQueues.retain(func(string) bool { return false })
+		return nil
+	}
+
+	byName := make(map[string]ASGStatus, len(statuses))
+	for _, status := range statuses {
+		byName[status.Name] = status
+	}
+
+	var stuck []StuckQueue
+	for _, tag := range slices.Sorted(maps.Keys(state.PendingJobsWithTags)) {
+		pending := state.PendingJobsWithTags[tag]
+		if pending <= 0 {
+			continue
+		}
+
+		var serving []ASGStatus
+		scaledUp := false
+		for _, asg := range asgs {
+			status, ok := byName[asg.Name]
+			if !ok || !slices.Contains(asg.Tags, tag) {
+				continue
+			}
+			serving = append(serving, status)
+			scaledUp = scaledUp || status.Decision == DecisionScaleUp
+		}
+		if scaledUp {
+			o.stuckQueues.reset(tag)
+			continue
+		}
+
+		if cycles := o.stuckQueues.observe(tag); cycles >= limit {
+			stuck = append(stuck, StuckQueue{Tag: tag, PendingJobs: pending, Cycles: cycles, Diagnosis: diagnose(serving)})
+		}
+	}
+	o.stuckQueues.retain(func(tag string) bool { return state.PendingJobsWithTags[tag] > 0 })
+
+	for _, queue := range stuck {
+		if queue.Cycles%limit != 0 {
+			continue
+		}
+		slog.Error("Stuck queue: pending jobs without a scale-up", "tag", queue.Tag, "pending", queue.PendingJobs,
+			"cycles", queue.Cycles, "diagnosis", queue.Diagnosis)
+	}
+	return stuck
+}
+
+// diagnose explains why the ASGs serving a stuck tag did not scale up, one line per constraint with its remediation
+func diagnose(serving []ASGStatus) []string {
+	if len(serving) == 0 {
+		return []string{"no ASG serves the tag: add it to the tags of an ASG, or map it to a served tag with autoscaler.tag-aliases"}
+	}
+
+	var lines []string
+	for _, status := range serving {
+		found := false
+		switch status.Decision {
+		case DecisionBlackout:
+			lines = append(lines, fmt.Sprintf("%s is frozen (%s): capacity changes resume when the window ends, or opt the ASG out with blackout-windows: []",
+				status.Name, status.Reason))
+			found = true
+		case DecisionDryRun:
+			lines = append(lines, fmt.Sprintf("%s runs in dry-run (%s): capacity changes are only logged; unset autoscaler.dry-run and start without --dry-run to apply them",
+				status.Name, status.Reason))
+			found = true
+		case DecisionActivity:
+			lines = append(lines, fmt.Sprintf("%s waits for a scaling activity in progress (%s): capacity changes resume once it completes",
+				status.Name, status.Reason))
+			found = true
+		case DecisionBackedOff:
+			lines = append(lines, fmt.Sprintf("%s is backed off after %d consecutive errors: fix the error, evaluations return to check-interval after a success",
+				status.Name, status.ErrorStreak))
+			found = true
+		}
+		for _, remedy := range blockedRemedies {
+			count := status.Blocked[remedy.reason]
+			if count <= 0 {
+				continue
+			}
+			line := fmt.Sprintf(remedy.format, status.Name, count)
+			if remedy.reason == BlockedProviderError && status.Decision == DecisionError {
+				line += "; last error: " + status.Reason
+			}
+			lines, found = append(lines, line), true
+		}
+		if !found {
+			lines = append(lines, fmt.Sprintf("%s recorded no blocking constraint; decision %s: %s", status.Name, status.Decision, status.Reason))
+		}
+	}
+	return lines
+}
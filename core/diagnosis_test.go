@@ -0,0 +1,142 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestDiagnose_Constraints verifies every constraint maps to its diagnosis line.
+//
+// Conditions: the ASG serving a stuck tag is frozen, backed off, blocked by each reason, or recorded no constraint
+//
+// Expected result: one line per constraint with its remediation, in the precedence order of the blocked reasons;
+// without a constraint the decision is repeated instead of guessing a cause
+func TestDiagnose_Constraints(t *testing.T) {
+	cases := []struct {
+		name     string
+		serving  []ASGStatus
+		expected []string
+	}{
+		{
+			name:     "no matching ASG",
+			serving:  nil,
+			expected: []string{"no ASG serves the tag: add it to the tags of an ASG, or map it to a served tag with autoscaler.tag-aliases"},
+		},
+		{
+			name:     "at max",
+			serving:  []ASGStatus{{Name: "amd64", Decision: DecisionNone, Blocked: map[string]int64{BlockedMaxCapacity: 2}}},
+			expected: []string{"amd64 is at its capacity ceiling with 2 instances blocked: raise max-asg-capacity, or max-capacity of its active schedule"},
+		},
+		{
+			name:     "budget",
+			serving:  []ASGStatus{{Name: "amd64", Decision: DecisionNone, Blocked: map[string]int64{BlockedTotalCapacity: 3}}},
+			expected: []string{"amd64 has 3 instances throttled by autoscaler.max-total-capacity: raise it, or give the ASG a higher priority"},
+		},
+		{
+			name: "provider error",
+			serving: []ASGStatus{{Name: "amd64", Decision: DecisionError, Reason: "ASG amd64 not found",
+				Blocked: map[string]int64{BlockedProviderError: 4}}},
+			expected: []string{"amd64 has 4 instances blocked by provider errors: check the provider credentials and API limits, " +
+				"and that the ASG exists in its region; last error: ASG amd64 not found"},
+		},
+		{
+			name:     "runner unhealthy",
+			serving:  []ASGStatus{{Name: "amd64", Decision: DecisionNone, Blocked: map[string]int64{BlockedRunnerUnhealthy: 1}}},
+			expected: []string{"amd64 has 1 instances without an online runner: check the gitlab-runner service and its registration on those instances"},
+		},
+		{
+			name:     "tag limit",
+			serving:  []ASGStatus{{Name: "gpu", Decision: DecisionNone, Blocked: map[string]int64{BlockedTagLimit: 5}}},
+			expected: []string{"gpu has 5 pending jobs held back by autoscaler.tag-limits: raise the limit of the tag if the fleet can take them"},
+		},
+		{
+			name:    "frozen",
+			serving: []ASGStatus{{Name: "amd64", Decision: DecisionBlackout, Reason: "scale-up; held by blackout release freeze"}},
+			expected: []string{"amd64 is frozen (scale-up; held by blackout release freeze): capacity changes resume when the window ends, " +
+				"or opt the ASG out with blackout-windows: []"},
+		},
+		{
+			name:    "dry-run",
+			serving: []ASGStatus{{Name: "amd64", Decision: DecisionDryRun, Reason: "3 matching pending jobs, 1 free slots; not applied in dry-run"}},
+			expected: []string{"amd64 runs in dry-run (3 matching pending jobs, 1 free slots; not applied in dry-run): capacity changes are only logged; " +
+				"unset autoscaler.dry-run and start without --dry-run to apply them"},
+		},
+		{
+			name: "backed off",
+			serving: []ASGStatus{{Name: "amd64", Decision: DecisionBackedOff, ErrorStreak: 3,
+				Blocked: map[string]int64{BlockedProviderError: 2}}},
+			expected: []string{
+				"amd64 is backed off after 3 consecutive errors: fix the error, evaluations return to check-interval after a success",
+				"amd64 has 2 instances blocked by provider errors: check the provider credentials and API limits, and that the ASG exists in its region",
+			},
+		},
+		{
+			name: "several constraints and ASGs",
+			serving: []ASGStatus{
+				{Name: "amd64", Decision: DecisionNone, Blocked: map[string]int64{BlockedRunnerUnhealthy: 1, BlockedMaxCapacity: 2}},
+				{Name: "amd64-spot", Decision: DecisionNone, Reason: "scale-up stabilizing (1/3 cycles)"},
+			},
+			expected: []string{
+				"amd64 is at its capacity ceiling with 2 instances blocked: raise max-asg-capacity, or max-capacity of its active schedule",
+				"amd64 has 1 instances without an online runner: check the gitlab-runner service and its registration on those instances",
+				"amd64-spot recorded no blocking constraint; decision none: scale-up stabilizing (1/3 cycles)",
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if lines := diagnose(c.serving); !reflect.DeepEqual(lines, c.expected) {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, lines)
+		}
+	}
+}
+
+// TestScaleASGs_StuckQueues verifies a tag is diagnosed once its pending jobs got no scale-up for stuck-queue-cycles.
+//
+// Conditions:
+// - stuck-queue-cycles 3; ASG "capped" serving "amd64" at max-asg-capacity 1 with 1 allocated instance
+// - 4 pending "amd64" jobs and 2 pending "arm64" jobs no ASG serves, for 3 cycles; then the jobs are gone
+//
+// Expected result: nothing in cycles 1 and 2; in cycle 3 both tags are stuck, amd64 blocked by max-capacity,
+// arm64 without a matching ASG; once the jobs are gone the streaks end
+func TestScaleASGs_StuckQueues(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "capped", Tags: []string{"amd64"}, MaxAsgCapacity: 1})
+	cfg.Autoscaler.StuckQueueCycles = 3
+
+	provider.On("GetCurrentCapacity", mock.Anything, "capped").Return(int64(1), int64(1), nil)
+
+	state := pendingState(4)
+	state.PendingJobsWithTags["arm64"] = 2
+	for cycle := 1; cycle <= 3; cycle++ {
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+		snapshot, _ := orchestrator.Snapshot()
+		if cycle < 3 {
+			assert.Nil(t, snapshot.StuckQueues, "cycle %d", cycle)
+		}
+	}
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, []StuckQueue{
+		{Tag: "amd64", PendingJobs: 4, Cycles: 3, Diagnosis: []string{
+			"capped is at its capacity ceiling with 3 instances blocked: raise max-asg-capacity, or max-capacity of its active schedule",
+		}},
+		{Tag: "arm64", PendingJobs: 2, Cycles: 3, Diagnosis: []string{
+			"no ASG serves the tag: add it to the tags of an ASG, or map it to a served tag with autoscaler.tag-aliases",
+		}},
+	}, snapshot.StuckQueues)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	orchestrator.ScaleASGs(context.Background(), cfg, state)
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Nil(t, snapshot.StuckQueues)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "capped", int64(2))
+}
@@ -0,0 +1,237 @@
+package core
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/utils"
+)
+
+// drainPollInterval is how often the running jobs of paused runners are checked while draining
+const drainPollInterval = 10 * time.Second
+
+// RunnerController pauses the runners of instances, so that they can be drained before a scale-down removes them
+type RunnerController interface {
+	// InstanceRunners returns the IDs of the runners registered from each of the instances
+	InstanceRunners(instanceIDs []string) (map[string][]int, error)
+	// SetRunnerPaused pauses a runner, so that it picks up no new jobs, or resumes it
+	SetRunnerPaused(runnerID int, paused bool) error
+	// RunningJobs returns how many jobs the runner is running
+	RunningJobs(runnerID int) (int, error)
+}
+
+// drainer remembers the runners paused for a scale-down per ASG, so that the runners of instances the provider
+// kept are resumed on the next cycle
+type drainer struct {
+	mu      sync.Mutex
+	control RunnerController
+	paused  map[string]map[string][]int // ASG name -> instance ID -> paused runner IDs
+	wait    func(ctx context.Context, d time.Duration) error
+}
+
+// controller returns the runner controller, nil when none is set
+func (d *drainer) controller() RunnerController {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.control
+}
+
+// remember records the runners paused on an instance
+func (d *drainer) remember(asgName, instanceID string, runnerIDs []int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.paused == nil {
+		d.paused = make(map[string]map[string][]int)
+	}
+	if d.paused[asgName] == nil {
+		d.paused[asgName] = make(map[string][]int)
+	}
+	d.paused[asgName][instanceID] = runnerIDs
+}
+
+// rename moves the paused runners of an ASG to its new name
+func (d *drainer) rename(oldName, newName string) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	paused, ok := d.paused[oldName]
+	if !ok {
+		return false
+	}
+	d.paused[newName] = paused
+	delete(d.paused, oldName)
+	return true
+}
+
+// take removes and returns the paused runners of the ASG
+func (d *drainer) take(asgName string) map[string][]int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	paused := d.paused[asgName]
+	delete(d.paused, asgName)
+	return paused
+}
+
+// pause waits for the poll interval; it returns early with the error of ctx once ctx is done
+func (d *drainer) pause(ctx context.Context, delay time.Duration) error {
+	if d.wait != nil {
+		return d.wait(ctx, delay)
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// SetRunnerController registers the controller used to drain instances with drain-timeout
+func (o *Orchestrator) SetRunnerController(control RunnerController) {
+	o.drain.mu.Lock()
+	defer o.drain.mu.Unlock()
+	o.drain.control = control
+}
+
+// drainVictims pauses the runners of the count instances a scale-down of the ASG is expected to remove and waits
+// up to timeout for their jobs to finish. Idle instances are picked first, pending and protected ones never.
+// Any failure or the timeout falls back to the plain scale-down with a warning.
+func (o *Orchestrator) drainVictims(ctx context.Context, asgName string, provider Provider, state gitlab.ClusterState, count int64, timeout time.Duration) {
+	control := o.drain.controller()
+	instanceProvider, ok := provider.(InstanceProvider)
+	if control == nil || !ok || count <= 0 {
+		return
+	}
+
+	victims := pickVictims(instanceProvider.Instances(asgName), state.BusyRunners, count)
+	if len(victims) == 0 {
+		return
+	}
+	runners, err := control.InstanceRunners(victims)
+	if err != nil {
+		warnDrain(asgName, fmt.Sprintf("finding the runners of %v failed: %s", utils.SafeList(victims), utils.SafeError(err)))
+		return
+	}
+
+	var paused []int
+	for _, instanceID := range victims {
+		var pausedHere []int
+		for _, runnerID := range runners[instanceID] {
+			if err := control.SetRunnerPaused(runnerID, true); err != nil {
+				warnDrain(asgName, fmt.Sprintf("pausing runner #%d failed: %s", runnerID, utils.SafeError(err)))
+				continue
+			}
+			pausedHere = append(pausedHere, runnerID)
+		}
+		if len(pausedHere) > 0 {
+			o.drain.remember(asgName, instanceID, pausedHere)
+			paused = append(paused, pausedHere...)
+		}
+	}
+	if len(paused) == 0 {
+		return
+	}
+	slog.Info("Draining: runners paused, waiting for their jobs", "asg", asgName, "paused_runners", paused,
+		"instances", victims, "timeout", timeout)
+
+	deadline := o.now().Add(timeout)
+	for {
+		busy, err := runningJobs(control, paused)
+		if err != nil {
+			warnDrain(asgName, utils.SafeError(err))
+			return
+		}
+		if busy == 0 {
+			return
+		}
+		if !o.now().Before(deadline) {
+			warnDrain(asgName, fmt.Sprintf("%d jobs still running after drain-timeout %s", busy, timeout))
+			return
+		}
+		if err := o.drain.pause(ctx, min(drainPollInterval, deadline.Sub(o.now()))); err != nil {
+			warnDrain(asgName, utils.SafeError(err))
+			return
+		}
+	}
+}
+
+// resumeDrained resumes the runners paused by the last drain of the ASG whose instance is still allocated: the
+// capacity change failed or the provider removed another instance. Runners of removed instances are forgotten.
+func (o *Orchestrator) resumeDrained(asgName string, provider Provider) {
+	paused := o.drain.take(asgName)
+	control := o.drain.controller()
+	if len(paused) == 0 || control == nil {
+		return
+	}
+
+	allocated := make(map[string]bool)
+	if instanceProvider, ok := provider.(InstanceProvider); ok {
+		for _, instance := range instanceProvider.Instances(asgName) {
+			allocated[instance.ID] = true
+		}
+	}
+	for _, instanceID := range slices.Sorted(maps.Keys(paused)) {
+		if !allocated[instanceID] {
+			continue
+		}
+		for _, runnerID := range paused[instanceID] {
+			if err := control.SetRunnerPaused(runnerID, false); err != nil {
+				slog.Error("Resuming runner failed", "asg", asgName, "runner", runnerID, "instance", instanceID, "error", err)
+				continue
+			}
+			slog.Info("Resumed runner of an instance kept by the scale-down", "asg", asgName, "runner", runnerID,
+				"instance", instanceID)
+		}
+	}
+}
+
+// pickVictims returns up to count instances to drain: in-service instances without protection, idle ones first
+func pickVictims(instances []Instance, busyRunners []string, count int64) []string {
+	busy := busyInstances(instances, busyRunners)
+	var candidates []Instance
+	for _, instance := range instances {
+		if instance.ID != "" && !instance.Pending && !instance.Protected {
+			candidates = append(candidates, instance)
+		}
+	}
+	slices.SortStableFunc(candidates, func(a, b Instance) int {
+		if busy[a.ID] != busy[b.ID] {
+			if busy[a.ID] {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.ID, b.ID)
+	})
+
+	var victims []string
+	for _, instance := range candidates[:min(int64(len(candidates)), count)] {
+		victims = append(victims, instance.ID)
+	}
+	return victims
+}
+
+// runningJobs sums the running jobs of the runners
+func runningJobs(control RunnerController, runnerIDs []int) (int, error) {
+	total := 0
+	for _, runnerID := range runnerIDs {
+		jobs, err := control.RunningJobs(runnerID)
+		if err != nil {
+			return 0, err
+		}
+		total += jobs
+	}
+	return total, nil
+}
+
+// warnDrain logs why a drain ended early; the scale-down goes ahead regardless
+func warnDrain(asgName, reason string) {
+	slog.Warn("Drain incomplete, scaling down without waiting", "asg", asgName, "reason", reason)
+}
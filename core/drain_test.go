@@ -0,0 +1,237 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// mockRunners mocks RunnerController
+type mockRunners struct {
+	mock.Mock
+}
+
+func (m *mockRunners) InstanceRunners(instanceIDs []string) (map[string][]int, error) {
+	args := m.Called(instanceIDs)
+	runners, _ := args.Get(0).(map[string][]int)
+	return runners, args.Error(1)
+}
+
+func (m *mockRunners) SetRunnerPaused(runnerID int, paused bool) error {
+	return m.Called(runnerID, paused).Error(0)
+}
+
+func (m *mockRunners) RunningJobs(runnerID int) (int, error) {
+	args := m.Called(runnerID)
+	return args.Int(0), args.Error(1)
+}
+
+// idleState is a cluster state without matching jobs; busyRunners run jobs of other ASGs
+func idleState(busyRunners ...string) gitlab.ClusterState {
+	return gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+		BusyRunners:         busyRunners,
+	}
+}
+
+// newDrainTestOrchestrator builds an orchestrator with drain-timeout whose waits advance its clock
+func newDrainTestOrchestrator(provider instanceProvider, runners *mockRunners, now *time.Time) (*Orchestrator, config.Config) {
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	orchestrator, cfg := newStuckTestOrchestrator(provider, asg)
+	cfg.Autoscaler.DrainTimeout = time.Minute
+	orchestrator.SetRunnerController(runners)
+	orchestrator.now = func() time.Time { return *now }
+	orchestrator.drain.wait = func(_ context.Context, d time.Duration) error {
+		*now = now.Add(d)
+		return nil
+	}
+	return orchestrator, cfg
+}
+
+// TestScaleASGs_Drain verifies the runner of the instance a scale-down removes is paused until its job finished.
+//
+// Conditions:
+// - Idle ASG with tag ["amd64"], minimum 1, drain-timeout 1m, 2 of 2 allocated
+// - i-1 runs a job of another tag ("runner i-1" is busy), i-2 is idle with runner #12
+// - Cycle 1: runner #12 runs a job on the first poll and none on the second
+// - Cycle 2: i-2 is still allocated at the minimum capacity of 1, the provider removed i-1
+//
+// Expected result:
+// - Cycle 1: the idle i-2 is picked, runner #12 paused; the scale-down to 1 follows 10s later, once its job finished
+// - Cycle 2: runner #12 is resumed
+func TestScaleASGs_Drain(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	runners := &mockRunners{}
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	orchestrator, cfg := newDrainTestOrchestrator(provider, runners, &now)
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-1"}, {ID: "i-2"}}).Twice()
+	runners.On("InstanceRunners", []string{"i-2"}).Return(map[string][]int{"i-2": {12}}, nil).Once()
+	runners.On("SetRunnerPaused", 12, true).Return(nil).Once()
+	runners.On("RunningJobs", 12).Return(1, nil).Once()
+	runners.On("RunningJobs", 12).Return(0, nil).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).
+		Run(func(mock.Arguments) {
+			assert.Equal(t, time.Date(2024, 5, 6, 9, 0, 10, 0, time.UTC), now)
+		}).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState("runner i-1"))
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-2"}})
+	runners.On("SetRunnerPaused", 12, false).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	provider.MockProvider.AssertExpectations(t)
+	runners.AssertExpectations(t)
+}
+
+// TestScaleASGs_DrainRenamed verifies the runners paused by a drain are resumed after the ASG was renamed.
+//
+// Conditions:
+// - Cycle 1: as in TestScaleASGs_Drain, runner #12 of i-2 is paused and the ASG scales down to 1
+// - Reload: the ASG is renamed to "test-asg-green" with previous-names ["test-asg"]
+// - Cycle 2: i-2 is still allocated in "test-asg-green", the provider removed i-1
+//
+// Expected result: runner #12 is resumed in cycle 2
+func TestScaleASGs_DrainRenamed(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	runners := &mockRunners{}
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	orchestrator, cfg := newDrainTestOrchestrator(provider, runners, &now)
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-1"}, {ID: "i-2"}})
+	runners.On("InstanceRunners", []string{"i-2"}).Return(map[string][]int{"i-2": {12}}, nil).Once()
+	runners.On("SetRunnerPaused", 12, true).Return(nil).Once()
+	runners.On("RunningJobs", 12).Return(0, nil).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState("runner i-1"))
+
+	green := cfg.Providers["aws"].AsgNames[0]
+	green.Name, green.PreviousNames = "test-asg-green", []string{"test-asg"}
+	cfg.Providers = map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{green}}}
+	orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{"test-asg-green": "aws"})
+	orchestrator.MigrateRenamedASGs(cfg)
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg-green").Return(int64(1), int64(1), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg-green").Return([]Instance{{ID: "i-2"}})
+	runners.On("SetRunnerPaused", 12, false).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	provider.MockProvider.AssertExpectations(t)
+	runners.AssertExpectations(t)
+}
+
+// TestScaleASGs_DrainFallback verifies a drain that cannot complete falls back to the plain scale-down.
+//
+// Conditions:
+// - Idle ASG with tag ["amd64"], minimum 1, drain-timeout 1m, 2 of 2 allocated, i-2 protected
+// - Run 1: runner #11 of i-1 keeps running a job
+// - Run 2: the runners cannot be listed
+//
+// Expected result:
+// - Run 1: the scale-down to 1 follows once the drain-timeout of 1m elapsed
+// - Run 2: the scale-down to 1 follows without pausing any runner
+func TestScaleASGs_DrainFallback(t *testing.T) {
+	for _, listErr := range []error{nil, errors.New("status=403 Forbidden")} {
+		provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+		runners := &mockRunners{}
+		start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+		now := start
+		orchestrator, cfg := newDrainTestOrchestrator(provider, runners, &now)
+
+		provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+		provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-1"}, {ID: "i-2", Protected: true}})
+		if listErr != nil {
+			runners.On("InstanceRunners", []string{"i-1"}).Return(nil, listErr).Once()
+		} else {
+			runners.On("InstanceRunners", []string{"i-1"}).Return(map[string][]int{"i-1": {11}}, nil).Once()
+			runners.On("SetRunnerPaused", 11, true).Return(nil).Once()
+			runners.On("RunningJobs", 11).Return(1, nil)
+		}
+		provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil).Once()
+
+		orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+		if listErr == nil {
+			assert.Equal(t, start.Add(time.Minute), now)
+		} else {
+			assert.Equal(t, start, now)
+		}
+		provider.MockProvider.AssertExpectations(t)
+		runners.AssertExpectations(t)
+	}
+}
+
+// TestScaleASGs_DrainKeepsBudget verifies a drain does not hold the scale-ups of other ASGs waiting for the
+// max-total-capacity budget.
+//
+// Conditions:
+// - Idle ASG "test-asg" with tag ["amd64"], minimum 1, drain-timeout 1m, 2 of 2 allocated, i-2 protected
+// - ASG "busy-asg" with tag ["arm64"], empty, 2 pending "arm64" jobs; max-total-capacity 10
+// - The job of runner #11 of i-1 finishes only once "busy-asg" scaled up
+//
+// Expected result: "busy-asg" scales up to 2 while "test-asg" drains, then "test-asg" scales down to 1
+func TestScaleASGs_DrainKeepsBudget(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	runners := &mockRunners{}
+	idle := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, MinAsgCapacity: 1}
+	busy := config.Asg{Name: "busy-asg", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10, MaxTotalCapacity: 10, DrainTimeout: time.Minute},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{idle, busy}}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{idle.Name: "aws", busy.Name: "aws"}, nil, nil)
+	orchestrator.SetRunnerController(runners)
+	scaledUp := make(chan struct{})
+	orchestrator.drain.wait = func(ctx context.Context, _ time.Duration) error {
+		select {
+		case <-scaledUp:
+		case <-time.After(5 * time.Second):
+			t.Error("the drain of test-asg held the scale-up of busy-asg")
+		}
+		return nil
+	}
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "busy-asg").Return(int64(0), int64(0), nil)
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-1"}, {ID: "i-2", Protected: true}})
+	provider.mockInstances.On("Instances", "busy-asg").Return([]Instance{})
+	runners.On("InstanceRunners", []string{"i-1"}).Return(map[string][]int{"i-1": {11}}, nil).Once()
+	runners.On("SetRunnerPaused", 11, true).Return(nil).Once()
+	runners.On("RunningJobs", 11).Return(1, nil).Once()
+	runners.On("RunningJobs", 11).Return(0, nil).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "busy-asg", int64(2)).
+		Run(func(mock.Arguments) { close(scaledUp) }).Return(nil).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    2,
+		PendingJobsWithTags: map[string]int{"arm64": 2},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.MockProvider.AssertExpectations(t)
+	runners.AssertExpectations(t)
+}
+
+// TestPickVictims verifies idle in-service instances are drained first and pending or protected ones never
+func TestPickVictims(t *testing.T) {
+	instances := []Instance{{ID: "i-3"}, {ID: "i-1"}, {ID: "i-2", Pending: true}, {ID: "i-4", Protected: true}, {ID: "i-5"}}
+
+	assert.Equal(t, []string{"i-3", "i-5"}, pickVictims(instances, []string{"runner-i-1"}, 2))
+	assert.Equal(t, []string{"i-3", "i-5", "i-1"}, pickVictims(instances, []string{"runner-i-1"}, 5))
+	assert.Empty(t, pickVictims(instances, nil, 0))
+}
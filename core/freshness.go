@@ -0,0 +1,92 @@
+package core
+
+import (
+	"sync"
+	"time"
+)
+
+// freshness remembers the last successful describe and update per ASG, for describe-stale-after
+type freshness struct {
+	mu        sync.Mutex
+	describes map[string]time.Time
+	updates   map[string]time.Time
+	firstSeen map[string]time.Time // Baseline of ASGs never described successfully
+	stale     map[string]bool      // ASGs whose staleness was already reported
+}
+
+// described records a successful describe of the ASG at now
+func (f *freshness) described(asgName string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.describes == nil {
+		f.describes = make(map[string]time.Time)
+	}
+	f.describes[asgName] = now
+}
+
+// updated records a successful capacity update of the ASG at now
+func (f *freshness) updated(asgName string, now time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.updates == nil {
+		f.updates = make(map[string]time.Time)
+	}
+	f.updates[asgName] = now
+}
+
+// last returns the last successful describe and update of the ASG; zero when there was none
+func (f *freshness) last(asgName string) (time.Time, time.Time) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.describes[asgName], f.updates[asgName]
+}
+
+// checkStale returns how long the ASG has gone without a successful describe, counted from its first
+// check if it never had one, and whether it just crossed threshold (became stale) or recovered from it.
+// Each transition is reported once.
+func (f *freshness) checkStale(asgName string, threshold time.Duration, now time.Time) (age time.Duration, becameStale, recovered bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.firstSeen == nil {
+		f.firstSeen = make(map[string]time.Time)
+		f.stale = make(map[string]bool)
+	}
+
+	since, ok := f.describes[asgName]
+	if !ok {
+		if _, seen := f.firstSeen[asgName]; !seen {
+			f.firstSeen[asgName] = now
+		}
+		since = f.firstSeen[asgName]
+	}
+	age = now.Sub(since)
+
+	isStale := age > threshold
+	becameStale = isStale && !f.stale[asgName]
+	recovered = !isStale && f.stale[asgName]
+	if isStale {
+		f.stale[asgName] = true
+	} else {
+		delete(f.stale, asgName)
+	}
+	return age, becameStale, recovered
+}
+
+// rename moves the timestamps of an ASG to its new name
+func (f *freshness) rename(oldName, newName string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	migrated := false
+	for _, m := range []map[string]time.Time{f.describes, f.updates, f.firstSeen} {
+		if last, ok := m[oldName]; ok {
+			m[newName] = last
+			delete(m, oldName)
+			migrated = true
+		}
+	}
+	if f.stale[oldName] {
+		f.stale[newName] = true
+		delete(f.stale, oldName)
+	}
+	return migrated
+}
@@ -0,0 +1,90 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_LastSuccessfulDescribeAndUpdate verifies the status keeps the last successful calls across failures.
+//
+// Conditions:
+// - ASG with tag ["amd64"] scaled up at 0s; its capacity cannot be read at 30s
+//
+// Expected result: at 30s the status still reports the describe and update of 0s
+func TestScaleASGs_LastSuccessfulDescribeAndUpdate(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5})
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	clock = start.Add(30 * time.Second)
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(0), int64(0), errors.New("throttled"))
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionError, snapshot.ASGs[0].Decision)
+	assert.Equal(t, start, snapshot.ASGs[0].LastDescribeAt)
+	assert.Equal(t, start, snapshot.ASGs[0].LastUpdateAt)
+}
+
+// TestFreshness_CheckStale verifies staleness is reported once when crossing the threshold and once on recovery.
+//
+// Conditions: threshold 1m; describes succeed at 0s and 150s only
+//
+// Expected result: stale reported at 90s (not at 60s, exactly on the threshold, nor again at 120s); recovery at 150s
+func TestFreshness_CheckStale(t *testing.T) {
+	var f freshness
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	f.described("test-asg", start)
+
+	cases := []struct {
+		at                     time.Duration
+		describe               bool
+		age                    time.Duration
+		becameStale, recovered bool
+	}{
+		{at: 30 * time.Second, age: 30 * time.Second},
+		{at: 60 * time.Second, age: 60 * time.Second},
+		{at: 90 * time.Second, age: 90 * time.Second, becameStale: true},
+		{at: 120 * time.Second, age: 120 * time.Second},
+		{at: 150 * time.Second, describe: true, recovered: true},
+		{at: 180 * time.Second, age: 30 * time.Second},
+	}
+	for _, c := range cases {
+		now := start.Add(c.at)
+		if c.describe {
+			f.described("test-asg", now)
+		}
+		age, becameStale, recovered := f.checkStale("test-asg", time.Minute, now)
+		assert.Equal(t, c.age, age, "age at %s", c.at)
+		assert.Equal(t, c.becameStale, becameStale, "became stale at %s", c.at)
+		assert.Equal(t, c.recovered, recovered, "recovered at %s", c.at)
+	}
+}
+
+// TestFreshness_NeverDescribed verifies an ASG that never had a successful describe is stale a threshold after its first check
+func TestFreshness_NeverDescribed(t *testing.T) {
+	var f freshness
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	_, becameStale, _ := f.checkStale("test-asg", time.Minute, start)
+	assert.False(t, becameStale)
+
+	age, becameStale, _ := f.checkStale("test-asg", time.Minute, start.Add(2*time.Minute))
+	assert.True(t, becameStale)
+	assert.Equal(t, 2*time.Minute, age)
+}
@@ -0,0 +1,100 @@
+package core
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// livenessCycles is how many check-intervals may pass without a completed cycle before the loop is not alive
+const livenessCycles = 3
+
+// cycleHealth is what Run records about the cycles for the health endpoints
+type cycleHealth struct {
+	started             time.Time     // Creation of the orchestrator, the liveness baseline until a cycle completes
+	checkInterval       time.Duration // check-interval of the last cycle started
+	lastCycle           time.Time     // Last completed cycle, whatever its outcome
+	lastSuccessfulCycle time.Time     // Last cycle without errors
+	lastErrorAt         time.Time     // Last cycle that failed
+	lastError           string        // Why it failed
+	fetchFailed         bool          // Whether the last GitLab fetch failed
+	fetched             bool          // Whether GitLab was fetched at all
+}
+
+// cycleStarted records the check-interval of a cycle of Run starting, so that a first cycle that hangs is noticed
+func (o *Orchestrator) cycleStarted(checkInterval time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.health.checkInterval = checkInterval
+}
+
+// cycleCompleted records the outcome of a cycle of Run; fetchErr is the error of fetching GitLab, err that of the cycle
+func (o *Orchestrator) cycleCompleted(at time.Time, fetchErr, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.health.lastCycle = at
+	o.health.fetched, o.health.fetchFailed = true, fetchErr != nil
+	if err != nil {
+		o.health.lastErrorAt, o.health.lastError = at, err.Error()
+		return
+	}
+	o.health.lastSuccessfulCycle = at
+}
+
+// Liveness tells whether the scaling loop is alive at now: a cycle completed within 3 check-intervals, or the
+// orchestrator was created less than that ago
+func (o *Orchestrator) Liveness(now time.Time) api.HealthResponse {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var failed []string
+	if o.health.checkInterval > 0 {
+		since, last := o.health.lastCycle, "last completed cycle"
+		if since.IsZero() {
+			since, last = o.health.started, "start"
+		}
+		if limit := livenessCycles * o.health.checkInterval; now.Sub(since) > limit {
+			failed = append(failed, fmt.Sprintf("no cycle completed for %s since %s, more than %d check-intervals of %s",
+				now.Sub(since).Round(time.Second), last, livenessCycles, o.health.checkInterval))
+		}
+	}
+	return o.healthResponse(failed)
+}
+
+// Readiness tells whether the autoscaler serves its purpose: the configuration is loaded, the providers are
+// initialized and the last GitLab fetch succeeded
+func (o *Orchestrator) Readiness() api.HealthResponse {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
+	var failed []string
+	if o.reload.LoadedAt.IsZero() {
+		failed = append(failed, "configuration not loaded")
+	}
+	if len(o.providers) == 0 {
+		failed = append(failed, "no provider initialized")
+	}
+	switch {
+	case !o.health.fetched:
+		failed = append(failed, "GitLab not fetched yet")
+	case o.health.fetchFailed:
+		failed = append(failed, "last GitLab fetch failed")
+	}
+	return o.healthResponse(failed)
+}
+
+// healthResponse returns the answer of a health endpoint with the preconditions that failed; callers hold o.mu
+func (o *Orchestrator) healthResponse(failed []string) api.HealthResponse {
+	response := api.HealthResponse{
+		Status:              api.HealthOK,
+		Failed:              failed,
+		LastSuccessfulCycle: o.health.lastSuccessfulCycle,
+		LastErrorAt:         o.health.lastErrorAt,
+		LastError:           o.health.lastError,
+	}
+	if len(failed) > 0 {
+		response.Status = api.HealthUnavailable
+	}
+	return response
+}
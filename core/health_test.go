@@ -0,0 +1,64 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/internal/replay"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// TestHealth follows liveness and readiness through the cycles of Run.
+//
+// Conditions:
+// - An orchestrator with one provider and a check-interval of 10s
+// - A cycle with GitLab unreachable, then the recorded cycle of TestRun_Replay
+//
+// Expected result:
+// - Before the first cycle the orchestrator is alive but not ready: no configuration loaded, GitLab not fetched yet
+// - After the failed fetch it is alive until 3 check-intervals pass without a cycle, and not ready with the error
+// - After the recorded cycle it is ready, with the last successful cycle and the earlier error
+func TestHealth(t *testing.T) {
+	if replay.ModeFromEnv() == replay.Record {
+		t.Skip("nothing to record")
+	}
+	provider := &stubProvider{capacities: map[string][2]int64{"amd64-runners": {4, 4}}}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"amd64-runners": "aws"}, nil, nil)
+	cfg := &config.Config{
+		GitLab:     config.GitLabConfig{Group: "group"},
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{{Name: "amd64-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 10}}}},
+	}
+
+	assert.Equal(t, api.HealthOK, orchestrator.Liveness(time.Now()).Status)
+	readiness := orchestrator.Readiness()
+	assert.Equal(t, api.HealthUnavailable, readiness.Status)
+	assert.Equal(t, []string{"configuration not loaded", "GitLab not fetched yet"}, readiness.Failed)
+
+	orchestrator.ConfigLoaded(time.Now(), "hash")
+	assert.Error(t, Run(context.Background(), cfg, unreachableClient(t), orchestrator))
+
+	assert.Equal(t, api.HealthOK, orchestrator.Liveness(time.Now()).Status)
+	liveness := orchestrator.Liveness(time.Now().Add(31 * time.Second))
+	assert.Equal(t, api.HealthUnavailable, liveness.Status)
+	assert.Len(t, liveness.Failed, 1)
+	assert.Contains(t, liveness.Failed[0], "more than 3 check-intervals of 10s")
+	readiness = orchestrator.Readiness()
+	assert.Equal(t, []string{"last GitLab fetch failed"}, readiness.Failed)
+	assert.Contains(t, readiness.LastError, "connection refused")
+	assert.True(t, readiness.LastSuccessfulCycle.IsZero())
+
+	client, group := replayClient(t, "testdata/replay/three_projects.json")
+	cfg.GitLab.Group = group
+	assert.NoError(t, Run(context.Background(), cfg, client, orchestrator))
+
+	readiness = orchestrator.Readiness()
+	assert.Equal(t, api.HealthOK, readiness.Status)
+	assert.Empty(t, readiness.Failed)
+	assert.False(t, readiness.LastSuccessfulCycle.IsZero())
+	assert.True(t, readiness.LastSuccessfulCycle.After(readiness.LastErrorAt))
+}
@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// completeLifecycleHooks completes the lifecycle hook of the ASG for terminating instances whose runners run no
+// jobs, confirmed through the runner controller. Runners still running a job are paused, so that they pick up
+// no new one, and the hook is completed in a later cycle. Failures are logged and retried in the next cycle;
+// the heartbeat timeout of the hook still applies.
+func (o *Orchestrator) completeLifecycleHooks(ctx context.Context, asg config.Asg, provider Provider) {
+	completer, ok := provider.(LifecycleHookCompleter)
+	control := o.drain.controller()
+	if !ok || control == nil {
+		return
+	}
+	terminating := completer.TerminatingInstances(asg.Name)
+	if len(terminating) == 0 {
+		return
+	}
+
+	runners, err := control.InstanceRunners(terminating)
+	if err != nil {
+		slog.Error("Finding the runners of terminating instances failed", "asg", asg.Name, "error", err)
+		return
+	}
+	for _, instanceID := range terminating {
+		jobs, err := runningJobs(control, runners[instanceID])
+		if err != nil {
+			slog.Error("Checking the runners of a terminating instance failed", "asg", asg.Name, "instance", instanceID, "error", err)
+			continue
+		}
+		if jobs > 0 {
+			for _, runnerID := range runners[instanceID] {
+				if err := control.SetRunnerPaused(runnerID, true); err != nil {
+					slog.Error("Pausing runner failed", "asg", asg.Name, "runner", runnerID, "error", err)
+				}
+			}
+			slog.Info("Terminating instance busy: lifecycle hook held", "asg", asg.Name, "instance", instanceID,
+				"running", jobs, "lifecycle_hook", asg.LifecycleHook)
+			continue
+		}
+		if err := completer.CompleteLifecycleAction(ctx, asg.Name, asg.LifecycleHook, instanceID); err != nil {
+			slog.Error("Completing lifecycle hook failed", "asg", asg.Name, "instance", instanceID, "error", err)
+			continue
+		}
+		slog.Info("Lifecycle hook completed: its runners run no jobs", "asg", asg.Name,
+			"lifecycle_hook", asg.LifecycleHook, "instance", instanceID)
+	}
+}
@@ -0,0 +1,70 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// mockHooks mocks LifecycleHookCompleter
+type mockHooks struct {
+	mock.Mock
+}
+
+func (m *mockHooks) TerminatingInstances(asgName string) []string {
+	return m.Called(asgName).Get(0).([]string)
+}
+
+func (m *mockHooks) CompleteLifecycleAction(ctx context.Context, asgName, hookName, instanceID string) error {
+	return m.Called(ctx, asgName, hookName, instanceID).Error(0)
+}
+
+// hookProvider is a mocked provider whose ASGs hold terminating instances on a lifecycle hook
+type hookProvider struct {
+	*mocks.MockProvider
+	*mockHooks
+}
+
+// TestScaleASGs_CompleteLifecycleHooks verifies the hook is completed for terminating instances whose runners are idle.
+//
+// Conditions:
+// - ASG with tag ["amd64"] at its minimum of 1, lifecycle-hook "drain-runner"
+// - i-1, i-2 and i-3 wait in Terminating:Wait; runner #11 of i-1 is idle, runner #12 of i-2 runs a job, i-3 has no runner
+//
+// Expected result:
+// - The hook is completed for i-1 and i-3
+// - Runner #12 is paused and the hook of i-2 held; capacity is left alone
+func TestScaleASGs_CompleteLifecycleHooks(t *testing.T) {
+	provider := hookProvider{&mocks.MockProvider{}, &mockHooks{}}
+	runners := &mockRunners{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, LifecycleHook: "drain-runner"}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil)
+	orchestrator.SetRunnerController(runners)
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.mockHooks.On("TerminatingInstances", "test-asg").Return([]string{"i-1", "i-2", "i-3"})
+	runners.On("InstanceRunners", []string{"i-1", "i-2", "i-3"}).Return(map[string][]int{"i-1": {11}, "i-2": {12}}, nil)
+	runners.On("RunningJobs", 11).Return(0, nil)
+	runners.On("RunningJobs", 12).Return(1, nil)
+	runners.On("SetRunnerPaused", 12, true).Return(nil).Once()
+	provider.mockHooks.On("CompleteLifecycleAction", mock.Anything, "test-asg", "drain-runner", "i-1").Return(nil).Once()
+	provider.mockHooks.On("CompleteLifecycleAction", mock.Anything, "test-asg", "drain-runner", "i-3").Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	provider.MockProvider.AssertExpectations(t)
+	provider.mockHooks.AssertExpectations(t)
+	provider.mockHooks.AssertNotCalled(t, "CompleteLifecycleAction", mock.Anything, "test-asg", "drain-runner", "i-2")
+	runners.AssertExpectations(t)
+}
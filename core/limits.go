@@ -0,0 +1,86 @@
+package core
+
+import (
+	"log/slog"
+	"sync"
+)
+
+// LimitsProvider is implemented by providers that report the size limits configured on the ASG itself, so that
+// the desired capacity is never set above what the cloud accepts
+type LimitsProvider interface {
+	// GetASGLimits returns the MinSize and MaxSize of the ASG seen by the last GetCurrentCapacity call;
+	// ok is false when they are unknown or managed by the autoscaler
+	GetASGLimits(asgName string) (minSize, maxSize int64, ok bool)
+}
+
+// Limits of the ASG in the cloud, as named in warnings and reasons
+const (
+	limitMinSize = "ASG MinSize"
+	limitMaxSize = "ASG MaxSize"
+)
+
+// limitWarnings remembers the ASGs already warned about a configured min or max beyond their cloud MinSize or MaxSize
+type limitWarnings struct {
+	mu     sync.Mutex
+	warned map[string][2]int64 // ASG name and limit -> configured and cloud value of the last warning
+}
+
+// cloudLimits returns minAllowed raised to the MinSize and maxAllowed lowered to the MaxSize of the ASG when its
+// provider reports them beyond, and whether either was changed. The cloud rejects or overrides a desired capacity
+// outside of them, so a scale-down below MinSize would only be logged, audited and drained without effect. The
+// first time a pair of limits disagrees a warning is logged, so operators can fix either side.
+func (o *Orchestrator) cloudLimits(provider Provider, asgName string, minAllowed, maxAllowed int64) (newMin, newMax int64, raised, lowered bool) {
+	limits, ok := provider.(LimitsProvider)
+	if !ok {
+		return minAllowed, maxAllowed, false, false
+	}
+	cloudMin, cloudMax, ok := limits.GetASGLimits(asgName)
+	if !ok {
+		return minAllowed, maxAllowed, false, false
+	}
+	if cloudMax < maxAllowed {
+		if o.limitWarnings.first(asgName, limitMaxSize, maxAllowed, cloudMax) {
+			slog.Warn("Max capacity above ASG MaxSize: capacity is capped at MaxSize", "asg", asgName,
+				"max_asg_capacity", maxAllowed, "max_size", cloudMax)
+		}
+		minAllowed, maxAllowed, lowered = min(minAllowed, cloudMax), cloudMax, true
+	}
+	if cloudMin > minAllowed {
+		if o.limitWarnings.first(asgName, limitMinSize, minAllowed, cloudMin) {
+			slog.Warn("Min capacity below ASG MinSize: capacity is kept at MinSize", "asg", asgName,
+				"min_asg_capacity", minAllowed, "min_size", cloudMin)
+		}
+		minAllowed, maxAllowed, raised = cloudMin, max(maxAllowed, cloudMin), true
+	}
+	return minAllowed, maxAllowed, raised, lowered
+}
+
+// rename moves the warnings given about the limits of an ASG to its new name
+func (w *limitWarnings) rename(oldName, newName string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	migrated := false
+	for _, limit := range []string{limitMinSize, limitMaxSize} {
+		if pair, ok := w.warned[oldName+" "+limit]; ok {
+			w.warned[newName+" "+limit] = pair
+			delete(w.warned, oldName+" "+limit)
+			migrated = true
+		}
+	}
+	return migrated
+}
+
+// first records a warning about a limit of the ASG and reports whether it was not given for these values before
+func (w *limitWarnings) first(asgName, limit string, configured, cloud int64) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.warned == nil {
+		w.warned = make(map[string][2]int64)
+	}
+	key, pair := asgName+" "+limit, [2]int64{configured, cloud}
+	if w.warned[key] == pair {
+		return false
+	}
+	w.warned[key] = pair
+	return true
+}
@@ -0,0 +1,80 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// limitedProvider is a mock provider reporting the MinSize and MaxSize of every ASG
+type limitedProvider struct {
+	*mocks.MockProvider
+	minSize, maxSize int64
+}
+
+func (p limitedProvider) GetASGLimits(string) (int64, int64, bool) { return p.minSize, p.maxSize, true }
+
+// TestScaleASGs_CloudMaxSize verifies the MaxSize configured on the ASG caps a higher max-asg-capacity.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max-asg-capacity 10, scale-to-zero; the provider reports MaxSize 4
+// - 8 matching pending jobs, 0 instances
+//
+// Expected result: scale-up to 4 with a reason naming the ASG MaxSize; the mismatch is warned about once per pair
+// of limits
+func TestScaleASGs_CloudMaxSize(t *testing.T) {
+	mockProvider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(mockProvider, asg)
+	orchestrator.SetProviders(map[string]Provider{"aws": limitedProvider{MockProvider: mockProvider, maxSize: 4}},
+		map[string]string{asg.Name: "aws"})
+	mockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(0), int64(0), nil)
+	mockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(4)).Return(nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    8,
+		PendingJobsWithTags: map[string]int{"amd64": 8},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	mockProvider.AssertCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", int64(4))
+	mockProvider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", mock.MatchedBy(func(c int64) bool { return c != 4 }))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Contains(t, snapshot.ASGs[0].Reason, "capped at ASG MaxSize 4")
+
+	assert.False(t, orchestrator.limitWarnings.first("test-asg", limitMaxSize, 10, 4))
+	assert.True(t, orchestrator.limitWarnings.first("test-asg", limitMaxSize, 10, 6))
+}
+
+// TestScaleASGs_CloudMinSize verifies the MinSize configured on the ASG is the floor of scale-down.
+//
+// Conditions:
+// - Idle ASG with tag ["amd64"], max-asg-capacity 10, scale-to-zero; the provider reports MinSize 2 and MaxSize 10
+// - 2 of 2 instances allocated, no matching jobs
+//
+// Expected result: no scale-down to 1, which the cloud would raise back to 2; the reason names the ASG MinSize
+func TestScaleASGs_CloudMinSize(t *testing.T) {
+	mockProvider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(mockProvider, asg)
+	orchestrator.SetProviders(map[string]Provider{"aws": limitedProvider{MockProvider: mockProvider, minSize: 2, maxSize: 10}},
+		map[string]string{asg.Name: "aws"})
+	mockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	mockProvider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	assert.Equal(t, "no matching jobs, already at ASG MinSize 2", snapshot.ASGs[0].Reason)
+	assert.False(t, orchestrator.limitWarnings.first("test-asg", limitMinSize, 0, 2))
+}
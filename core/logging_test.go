@@ -0,0 +1,126 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/utils"
+)
+
+// captureJSONLogs makes the default logger write JSON records to the returned buffer for the rest of the test
+func captureJSONLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	handler, err := utils.NewLogHandler(&buf, utils.LogOptions{Level: slog.LevelInfo, Format: utils.LogFormatJSON})
+	require.NoError(t, err)
+	previous, output, flags := slog.Default(), log.Writer(), log.Flags()
+	slog.SetDefault(slog.New(handler))
+	t.Cleanup(func() {
+		// Setting the default logger also redirects the log package, which is restored on its own
+		slog.SetDefault(previous)
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	})
+	return &buf
+}
+
+// logRecord returns the first JSON record of logs with the message, failing the test without one
+func logRecord(t *testing.T, logs *bytes.Buffer, msg string) map[string]any {
+	t.Helper()
+	for _, line := range bytes.Split(bytes.TrimSpace(logs.Bytes()), []byte("\n")) {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal(line, &record), string(line))
+		if record["msg"] == msg {
+			return record
+		}
+	}
+	require.Failf(t, "no log record", "message %q not logged in:\n%s", msg, logs.String())
+	return nil
+}
+
+// TestScaleASGs_ScaleUpJSONLog verifies a scale-up is logged as one JSON record with its fields.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, 3 pending "amd64" jobs
+// - JSON logs
+//
+// Expected result: an INFO "Scaling up" record with asg, provider, tags, pending and running jobs, allocated, desired
+// and proposed capacity and reason
+func TestScaleASGs_ScaleUpJSONLog(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil)
+	logs := captureJSONLogs(t)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+	record := logRecord(t, logs, "Scaling up")
+	assert.Equal(t, "INFO", record["level"])
+	assert.NotEmpty(t, record["ts"])
+	assert.Equal(t, "test-asg", record["asg"])
+	assert.Equal(t, "aws", record["provider"])
+	assert.Equal(t, []any{"amd64"}, record["tags"])
+	assert.EqualValues(t, 3, record["pending"])
+	assert.EqualValues(t, 0, record["running"])
+	assert.EqualValues(t, 1, record["allocated"])
+	assert.EqualValues(t, 1, record["desired"])
+	assert.EqualValues(t, 3, record["proposed"])
+	assert.Equal(t, "3 matching pending jobs, 1 free slots", record["reason"])
+}
+
+// TestScaleASGs_ScaleDownJSONLog verifies a scale-down and a failed update are logged as JSON records.
+//
+// Conditions:
+// - Idle ASG "idle" with tag ["amd64"] and 2 allocated instances
+// - Idle ASG "broken" with tag ["arm64"] and 1 allocated instance, whose update fails
+// - JSON logs
+//
+// Expected result:
+// - An INFO "Scaling down" record for "idle" with desired and proposed capacity, allocated and reason
+// - An ERROR "Scale-down failed" record for "broken" with the provider and the error
+func TestScaleASGs_ScaleDownJSONLog(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	idle := config.Asg{Name: "idle", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	broken := config.Asg{Name: "broken", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, idle, broken)
+	provider.On("GetCurrentCapacity", mock.Anything, "idle").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "idle", int64(1)).Return(nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "broken").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "broken", int64(0)).Return(assert.AnError)
+	logs := captureJSONLogs(t)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.AssertExpectations(t)
+	record := logRecord(t, logs, "Scaling down")
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "idle", record["asg"])
+	assert.EqualValues(t, 2, record["desired"])
+	assert.EqualValues(t, 1, record["proposed"])
+	assert.EqualValues(t, 2, record["allocated"])
+	assert.Equal(t, "no matching pending or running jobs", record["reason"])
+
+	record = logRecord(t, logs, "Scale-down failed")
+	assert.Equal(t, "ERROR", record["level"])
+	assert.Equal(t, "broken", record["asg"])
+	assert.Equal(t, "aws", record["provider"])
+	assert.EqualValues(t, 0, record["proposed"])
+	assert.Contains(t, record["error"], assert.AnError.Error())
+}
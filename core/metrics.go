@@ -0,0 +1,18 @@
+package core
+
+import "time"
+
+// Metrics receives the events of the orchestrator that a metrics registry counts; capacities, jobs and timings are
+// read from the published snapshots instead
+type Metrics interface {
+	ScaleOperation(asgName string, decision Decision) // A scale-up or scale-down was applied to the ASG
+	ProviderError(providerName, operation string)     // A provider call failed; operation is "describe" or "update"
+	CycleCompleted(duration time.Duration)            // Run finished a cycle, from fetching GitLab to cleaning up runners
+}
+
+// noMetrics discards the events of an orchestrator created without metrics
+type noMetrics struct{}
+
+func (noMetrics) ScaleOperation(string, Decision) {}
+func (noMetrics) ProviderError(string, string)    {}
+func (noMetrics) CycleCompleted(time.Duration)    {}
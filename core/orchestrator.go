@@ -1,10 +1,16 @@
 package core
 
 import (
+	"context"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"slices"
+	"sort"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/shuliakovsky/gitlab-autoscaler/config"
 	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
@@ -15,164 +21,843 @@ import (
 type Orchestrator struct {
 	mu            sync.RWMutex
 	providers     map[string]Provider
-	asgToProvider map[string]string // Maps ASG name to provider name (aws, azure, etc.)
+	asgToProvider map[string]string          // Maps ASG name to provider name (aws, azure, etc.)
+	calculator    CapacityCalculator         // Derives demand and the instances it needs per ASG
+	publishers    map[string]TargetPublisher // Publishers of export-only ASGs by export-only value, see SetPublisher
+	now           func() time.Time           // Clock used for time based decisions; replaceable in tests
+	snapshot      *Snapshot                  // View of the last completed cycle for status endpoints
+	pipelines     pipelineMemory             // Pipelines that recently ran jobs per ASG, for pipeline-hold
+	shortfalls    streakCounter              // Consecutive cycles with a capacity shortfall per ASG, for scale-up-stabilization
+	idleCycles    streakCounter              // Consecutive cycles without matching jobs per ASG, for scale-down-idle-cycles
+	backoff       errorBackoff               // Widened evaluation intervals of failing ASGs, for error-backoff-after
+	scaleUps      scaleUpMemory              // Last scale-up per ASG, for scale-down-cooldown
+	freshness     freshness                  // Last successful describe and update per ASG, for describe-stale-after
+	pending       pendingInstances           // Since when instances are pending per ASG, for pending-timeout
+	ages          instanceAges               // Since when instances exist per ASG, for max-instance-age
+	drain         drainer                    // Runners paused to drain instances before a scale-down, for drain-timeout
+	stuckQueues   streakCounter              // Consecutive cycles with pending jobs of a tag but no scale-up serving it, for stuck-queue-cycles
+	demandHistory demandHistory              // Demand per ASG and hour of the week, for predictive-prescale
+	limitWarnings limitWarnings              // ASGs warned about a configured min or max beyond their cloud MinSize or MaxSize
+	subscribers   []func(Snapshot)           // Notified after every cycle, e.g. to refresh metrics
+	degraded      map[string]string          // Components running degraded with the reason, see SetDegraded
+	reload        ReloadStatus               // Outcome of the configuration reloads, see ReloadFailed
+	tick          time.Time                  // Tick of the next cycle, see markTick
+	metrics       Metrics                    // Counts scale operations, provider errors and cycle durations
+	health        cycleHealth                // Outcome of the cycles, for Liveness and Readiness
+	audit         *auditLog                  // Writer of the capacity changes for audit-log; nil without one
 }
 
-// NewOrchestrator creates a new orchestrator with providers and ASG-to-provider mapping
-func NewOrchestrator(providers map[string]Provider, asgToProvider map[string]string) *Orchestrator {
+// NewOrchestrator creates a new orchestrator with providers, ASG-to-provider mapping, the capacity calculator and
+// the metrics registry it publishes into; a nil calculator defaults to the TagBasedCalculator, nil metrics are discarded
+func NewOrchestrator(providers map[string]Provider, asgToProvider map[string]string, calculator CapacityCalculator, metrics Metrics) *Orchestrator {
+	if calculator == nil {
+		calculator = NewTagBasedCalculator()
+	}
+	if metrics == nil {
+		metrics = noMetrics{}
+	}
 	return &Orchestrator{
 		providers:     providers,
 		asgToProvider: asgToProvider,
+		calculator:    calculator,
+		now:           time.Now,
+		metrics:       metrics,
+		health:        cycleHealth{started: time.Now()},
 	}
 }
 
-// ScaleASGs scales all auto-scaling groups according to current job demand
-func (o *Orchestrator) ScaleASGs(cfg config.Config, state gitlab.ClusterState) {
+// ScaleASGs scales all auto-scaling groups according to current job demand; ctx bounds the provider calls
+func (o *Orchestrator) ScaleASGs(ctx context.Context, cfg config.Config, state gitlab.ClusterState) {
 	var wg sync.WaitGroup
 	mu := &sync.Mutex{}
 	totalCapacity := int64(0)
+	var statuses []ASGStatus
 
 	allAsgs := []config.Asg{}
 	for _, providerConfig := range cfg.Providers {
 		allAsgs = append(allAsgs, providerConfig.AsgNames...)
 	}
 
+	// Demand of scoped ASGs only considers their projects; each distinct scope is aggregated once
+	scopedStates := make(map[string]gitlab.ClusterState)
+	for _, asg := range allAsgs {
+		if !asg.GitLabScope.IsSet() {
+			continue
+		}
+		if _, ok := scopedStates[asg.GitLabScope.Key()]; !ok {
+			scopedStates[asg.GitLabScope.Key()] = state.ForScope(asg.GitLabScope)
+		}
+	}
+
+	baseInterval := time.Duration(cfg.Autoscaler.CheckInterval) * time.Second
+	cycleStart := o.now()
+	clock := o.startCycle(cycleStart)
+	live := liveTags(state)
+	for i := range allAsgs {
+		allAsgs[i].Tags = config.ExpandTags(allAsgs[i].Tags, live)
+	}
+	shares := tagLimitShares(allAsgs, cfg.Autoscaler.TagLimits, state)
+	stateFor := func(asg config.Asg) gitlab.ClusterState {
+		if asg.GitLabScope.IsSet() {
+			return scopedStates[asg.GitLabScope.Key()]
+		}
+		return state
+	}
+	sharing := o.tagSharingShares(cfg.Autoscaler, allAsgs, stateFor, cycleStart)
+	untagged := untaggedDesignees(allAsgs)
+
+	// Backed off ASGs keep the desired capacity of their last evaluation in the max-total-capacity budget
+	var dueAsgs []config.Asg
+	var backedOffDesired int64
 	for _, asg := range allAsgs {
+		if backoff, due := o.backoff.due(asg.Name, cycleStart, baseInterval); !due {
+			status := o.backedOffStatus(asg.Name, backoff)
+			backedOffDesired += status.Desired
+			statuses = append(statuses, status)
+			continue
+		}
+		dueAsgs = append(dueAsgs, asg)
+	}
+	budget := newCapacityBudget(cfg.Autoscaler.MaxTotalCapacity, len(dueAsgs), backedOffDesired)
+	o.describeAll(ctx, dueAsgs)
+	clock.batch = o.now().Sub(cycleStart)
+
+	for _, asg := range dueAsgs {
+
+		asgState := stateFor(asg)
+		// Before the pending jobs are split among the ASGs: an ASG left without a share may get them next cycle
+		demandElsewhere := cfg.Autoscaler.SharedTagScaleDown != config.SharedTagScaleDownAllow &&
+			o.calculator.Demand(asg, asgState).Pending > 0
+		tagLimited := applyTagLimits(&asgState, shares[asg.Name])
+		applyTagSharing(&asgState, sharing[asg.Name])
+		applyUntaggedDesignee(&asgState, asg, untagged)
+
 		wg.Add(1)
-		go func(asg config.Asg) {
+		go func(asg config.Asg, state gitlab.ClusterState) {
 			defer wg.Done()
-			o.scaleASG(asg, state, mu, &totalCapacity)
-		}(asg)
+			status := ASGStatus{Name: asg.Name, Decision: DecisionNone}
+			var timing asgTiming
+			o.scaleASG(ctx, asg, state, cfg.Autoscaler, tagLimited, demandElsewhere, budget, &status, &timing, mu, &totalCapacity)
+			budget.settle(asg.Name, o.settledDesired(status))
+			if status.Decision == DecisionScaleUp || status.Decision == DecisionScaleDown {
+				o.metrics.ScaleOperation(asg.Name, status.Decision)
+			}
+			status.EvaluatedAt = o.now()
+			clock.evaluated(timing, status.EvaluatedAt)
+
+			backoff := o.backoff.record(asg.Name, status.Decision == DecisionError, status.EvaluatedAt,
+				baseInterval, cfg.Autoscaler.ErrorBackoffAfter, cfg.Autoscaler.EffectiveErrorBackoffMax())
+			status.CadenceSeconds, status.ErrorStreak = backoff.interval.Seconds(), backoff.errors
+			if backoff.interval > baseInterval {
+				slog.Warn("Backing off", "asg", asg.Name, "consecutive_errors", backoff.errors, "next_evaluation_in", backoff.interval)
+			}
+
+			mu.Lock()
+			statuses = append(statuses, status)
+			mu.Unlock()
+		}(asg, asgState)
 	}
 	wg.Wait()
+	timings := clock.timings(o.now())
+	warnCycleOverlap(timings, baseInterval)
+
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+
+	for i := range statuses {
+		statuses[i].LastDescribeAt, statuses[i].LastUpdateAt = o.freshness.last(statuses[i].Name)
+		if cfg.Autoscaler.DescribeStaleAfter > 0 {
+			o.reportStaleness(statuses[i].Name, cfg.Autoscaler.DescribeStaleAfter)
+		}
+	}
+
+	if throttled := budget.throttled(); throttled != nil {
+		slog.Warn("Total capacity cap reached", "max_total_capacity", cfg.Autoscaler.MaxTotalCapacity,
+			"headroom", budget.headroom(), "throttled", throttled)
+	}
+
+	var blockedCapacity map[string]int64
+	for _, status := range statuses {
+		for reason, count := range status.Blocked {
+			if blockedCapacity == nil {
+				blockedCapacity = make(map[string]int64)
+			}
+			blockedCapacity[reason] += count
+		}
+	}
+	if blockedCapacity != nil {
+		slog.Warn("Blocked capacity: instances needed but not provided", "blocked", blockedCapacity)
+	}
+
+	stuck := o.diagnoseStuckQueues(cfg.Autoscaler.StuckQueueCycles, allAsgs, state, statuses)
+
+	o.demandHistory.retain(func(asgName string) bool {
+		return slices.ContainsFunc(allAsgs, func(asg config.Asg) bool { return asg.Name == asgName && asg.PredictivePrescale })
+	})
+	if cfg.Autoscaler.DemandHistoryFile != "" {
+		err := o.demandHistory.save(cfg.Autoscaler.DemandHistoryFile, o.now(), false)
+		if err != nil {
+			slog.Error("Error saving demand history", "error", err)
+		}
+		o.SetDegraded(demandHistoryComponent, err)
+	}
+
+	o.checkWriteAccess()
+	degraded := o.degradedComponents()
+	for _, component := range slices.Sorted(maps.Keys(degraded)) {
+		slog.Error("Degraded", "component", component, "reason", degraded[component])
+	}
+
+	o.publishSnapshot(Snapshot{
+		Timestamp:       o.now(),
+		State:           state,
+		ASGs:            statuses,
+		BlockedCapacity: blockedCapacity,
+		Degraded:        degraded,
+		StuckQueues:     stuck,
+		Reload:          o.Reload(),
+		Timings:         timings,
+	})
 }
 
-// scaleASG scales a single auto-scaling group based on job demand
-func (o *Orchestrator) scaleASG(asg config.Asg, state gitlab.ClusterState, mu *sync.Mutex, totalCapacity *int64) {
+// backedOffStatus returns the status of an ASG skipped in this cycle, keeping the capacity of its last evaluation
+func (o *Orchestrator) backedOffStatus(asgName string, backoff backoffState) ASGStatus {
+	status := ASGStatus{Name: asgName}
+	if last, ok := o.Snapshot(); ok {
+		for _, previous := range last.ASGs {
+			if previous.Name == asgName {
+				status = previous
+			}
+		}
+	}
+
+	status.Decision = DecisionBackedOff
+	status.Reason = fmt.Sprintf("backed off after %d consecutive errors, next evaluation at %s",
+		backoff.errors, backoff.next.Format(time.RFC3339))
+	status.CadenceSeconds, status.ErrorStreak = backoff.interval.Seconds(), backoff.errors
+	slog.Info("Backed off", "asg", asgName, "consecutive_errors", backoff.errors, "next_evaluation_at", backoff.next)
+	return status
+}
+
+// settledDesired returns the desired capacity an evaluation leaves in the max-total-capacity budget; when the
+// capacity could not be read, the desired capacity of the last evaluation is assumed
+func (o *Orchestrator) settledDesired(status ASGStatus) int64 {
+	if status.Decision != DecisionError || status.Desired > 0 {
+		return status.Desired
+	}
+	if last, ok := o.Snapshot(); ok {
+		for _, previous := range last.ASGs {
+			if previous.Name == status.Name {
+				return previous.Desired
+			}
+		}
+	}
+	return 0
+}
+
+// reportStaleness warns once when an ASG crosses the describe-stale-after threshold and once when it recovers
+func (o *Orchestrator) reportStaleness(asgName string, threshold time.Duration) {
+	age, becameStale, recovered := o.freshness.checkStale(asgName, threshold, o.now())
+	if becameStale {
+		slog.Error("Stale ASG: no successful capacity read", "asg", asgName, "age", age.Round(time.Second), "threshold", threshold)
+	}
+	if recovered {
+		slog.Info("ASG recovered: capacity read succeeded again", "asg", asgName)
+	}
+}
+
+// providerFor returns the provider serving an ASG
+func (o *Orchestrator) providerFor(asgName string) (string, Provider, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+
 	// Determine provider by ASG name - not region!
-	providerName := o.asgToProvider[asg.Name]
+	providerName := o.asgToProvider[asgName]
 	if providerName == "" {
 		providerName = "aws" // Default to AWS if not specified
 	}
 
 	provider, ok := o.providers[providerName]
+	return providerName, provider, ok
+}
+
+// scaleASG scales a single auto-scaling group based on job demand and records the decision in status
+// tagLimited is the number of matching pending jobs left out of state by tag-limits; demandElsewhere holds scale-down
+// while jobs of the ASG tags are pending but were assigned to other ASGs; scale-ups are claimed from budget.
+// How long the describe took and when the decision was computed are recorded in timing.
+func (o *Orchestrator) scaleASG(ctx context.Context, asg config.Asg, state gitlab.ClusterState, settings config.AutoscalerConfig, tagLimited int64, demandElsewhere bool, budget *capacityBudget, status *ASGStatus, timing *asgTiming, mu *sync.Mutex, totalCapacity *int64) {
+	providerName, provider, ok := o.providerFor(asg.Name)
+	status.Provider = providerName
 	if !ok {
-		log.Println(utils.Red, "Error: No provider found for ASG", asg.Name, utils.Reset)
+		slog.Error("No provider found for ASG", "asg", asg.Name)
+		status.Decision, status.Reason = DecisionError, "no provider found"
 		return
 	}
+	if asg.ExportOnly != "" {
+		publisher, ok := o.publisherFor(asg.ExportOnly)
+		if !ok {
+			slog.Error("No publisher found for export-only", "asg", asg.Name, "export_only", asg.ExportOnly)
+			status.Decision, status.Reason = DecisionError, "no publisher found for export-only "+asg.ExportOnly
+			return
+		}
+		// Capacity is still read from the provider, but the scaler behind the publisher applies it
+		provider = exportOnlyProvider{Provider: provider, publisher: publisher}
+		status.Provider = providerName + "+" + asg.ExportOnly
+	}
 
-	allocatedCount, desiredCapacity, err := provider.GetCurrentCapacity(asg.Name)
+	describeStart := o.now()
+	allocatedCount, desiredCapacity, err := provider.GetCurrentCapacity(ctx, asg.Name)
+	timing.describe = o.now().Sub(describeStart)
 	if err != nil {
-		log.Println(utils.Red, "Error:", err, utils.Reset)
+		slog.Error("Error reading capacity", "asg", asg.Name, "provider", providerName, "error", err)
+		o.metrics.ProviderError(providerName, "describe")
+		status.Decision, status.Reason = DecisionError, err.Error()
+		_, needed := o.calculator.Shortfall(asg, state, o.calculator.Demand(asg, state), 0, 0)
+		status.Blocked = blockedDemand{pending: needed, tagLimited: tagLimited, describeFailed: true}.attribute()
 		return
 	}
+	o.freshness.described(asg.Name, o.now())
+
+	// Capacity changes wait for scaling activities still in progress, checked once a change is about to be applied
+	activities := &activityCheck{ctx: ctx, provider: provider, asgName: asg.Name}
+
+	// In a blackout window decisions are still made and logged, but capacity is left alone
+	blackout, inBlackout := asg.ActiveBlackout(settings.BlackoutWindows, o.now())
+	if inBlackout {
+		settings.ReplaceStuckInstances = false
+	}
+	// In dry-run the provider is only read: nothing is terminated, protected or drained either
+	dryRun := settings.DryRun
+	if dryRun {
+		settings.ReplaceStuckInstances, settings.DrainTimeout = false, 0
+	}
+
+	// Stuck instances do not run jobs; those not replaced still hold a slot of the desired capacity
+	stuckCount, stuckHeld := o.checkStuckInstances(ctx, asg, provider, settings)
+	allocatedCount = max(allocatedCount-stuckCount, 0)
+	launching := max(desiredCapacity-allocatedCount-stuckHeld, 0)
+	status.Desired, status.Allocated, status.Proposed = desiredCapacity, allocatedCount, desiredCapacity
+	status.StuckInstances = stuckCount
+	if asg.ProtectBusyInstances && !dryRun {
+		// Before any capacity change, so that a scale-down below terminates idle instances only
+		status.ProtectedInstances = o.protectBusyInstances(ctx, asg, provider, state)
+	}
+	warmPool := warmInstances(provider, asg.Name)
+	status.WarmInstances = warmPool
+	o.resumeDrained(asg.Name, provider)
+	if asg.LifecycleHook != "" && !dryRun {
+		o.completeLifecycleHooks(ctx, asg, provider)
+	}
+	if asg.MaxInstanceAge > 0 {
+		if instanceProvider, ok := provider.(InstanceProvider); ok {
+			o.ages.observe(asg.Name, instanceProvider.Instances(asg.Name), o.now())
+		}
+	}
 
 	mu.Lock()
 	*totalCapacity += allocatedCount
 	mu.Unlock()
 
-	log.Printf("Processing ASG: %s%-30s%s Desired: %s%-3d%s Allocated: %s%-3d%s Tags:  %s%v%s",
-		utils.LightGray, asg.Name, utils.Reset,
-		utils.Green, desiredCapacity, utils.Reset,
-		utils.Cyan, allocatedCount, utils.Reset,
-		utils.Green, asg.Tags, utils.Reset)
+	slog.Info("Processing ASG", "asg", asg.Name, "provider", providerName, "desired", desiredCapacity, "allocated", allocatedCount, "tags", asg.Tags)
 
 	totalJobs := state.TotalPendingJobs + state.TotalRunningJobs
 
-	pendingJobMatchingTags := false
-	for _, tag := range asg.Tags {
-		if count, exists := state.PendingJobsWithTags[tag]; exists && count > 0 {
-			pendingJobMatchingTags = true
-			break
-		}
+	demand := o.calculator.Demand(asg, state)
+	pendingForASG, runningForASG := demand.Pending, demand.Running
+	pendingJobMatchingTags := pendingForASG > 0
+	runningJobMatchingTags := runningForASG > 0
+
+	policy, oldestWait := waitTargetPolicy(asg, state, o.now())
+	status.Policy = policy.String()
+	if policy == PolicyAggressive {
+		slog.Warn("Wait target exceeded, scaling straight to demand", "asg", asg.Name,
+			"oldest_wait", oldestWait.Round(time.Second), "target_max_wait", asg.TargetMaxWait)
 	}
 
-	runningJobMatchingTags := false
-	for _, tag := range asg.Tags {
-		if count, exists := state.RunningJobsWithTags[tag]; exists && count > 0 {
-			runningJobMatchingTags = true
-			break
+	// Active schedules override min-asg-capacity and max-asg-capacity for this cycle
+	minAllowed, maxAllowed, schedules := asg.CapacityBounds(o.now())
+	maxReason := "max-asg-capacity"
+	if len(schedules) > 0 {
+		status.Schedule = describeSchedules(schedules)
+		maxReason = "scheduled max-capacity"
+	}
+	minReason := floorMinimum
+	minAllowed, maxAllowed, raised, lowered := o.cloudLimits(provider, asg.Name, minAllowed, maxAllowed)
+	if lowered {
+		maxReason = limitMaxSize
+	}
+	if raised {
+		minReason = limitMinSize
+	}
+
+	blocked := blockedDemand{desired: desiredCapacity, allocated: allocatedCount + stuckHeld, max: maxAllowed,
+		jobsPerInstance: asg.EffectiveJobsPerInstance(), tagLimited: tagLimited}
+	if tagLimited > 0 {
+		slog.Warn("Tag limit reached, matching pending jobs not counted", "asg", asg.Name, "not_counted", tagLimited)
+	}
+	defer func() { status.Blocked = blocked.attribute() }()
+
+	blockScaleDown := false
+	if settings.RunnerReconciliation != "" {
+		if online, lagging := onlineRunnersLagging(asg, state, allocatedCount); lagging {
+			blocked.missingRunners = allocatedCount - online
+			slog.Warn("Runners missing: instances booted but runners did not register?", "asg", asg.Name,
+				"allocated", allocatedCount, "online_runners", online)
+			blockScaleDown = settings.RunnerReconciliation == config.RunnerReconciliationBlock
 		}
 	}
 
-	if totalJobs > 0 && pendingJobMatchingTags {
-		var pendingForASG int64
-		for _, tag := range asg.Tags {
-			pendingForASG += int64(state.PendingJobsWithTags[tag])
+	if settings.PipelineHold > 0 {
+		now := o.now()
+		o.pipelines.remember(asg, state, settings.PipelineHold, now)
+		if !pendingJobMatchingTags && !runningJobMatchingTags && !blockScaleDown {
+			if key, held := o.pipelines.activeMatch(asg.Name, state, settings.PipelineHold, now); held {
+				slog.Info("Scale-down held: pipeline is between stages", "asg", asg.Name,
+					"pipeline", key.PipelineID, "project", key.ProjectRef)
+				blockScaleDown = true
+				status.Reason = fmt.Sprintf("scale-down held: pipeline %d of project %s is still active", key.PipelineID, utils.Safe(key.ProjectRef))
+			}
 		}
+	}
+
+	idleStreak := 0
+	if !pendingJobMatchingTags && !runningJobMatchingTags {
+		idleStreak = o.idleCycles.observe(asg.Name)
+	} else {
+		o.idleCycles.reset(asg.Name)
+	}
+	idleRequired := scaleDownIdleCycles(asg, settings)
 
-		freeCapacity := allocatedCount - state.TotalRunningJobs
-		if freeCapacity < 0 {
-			freeCapacity = 0
+	// Warm slots raise the floor above min-asg-capacity: capacity for the running jobs plus the free slots kept
+	floor, floorReason := minAllowed, minReason
+	warm := warmCapacity(asg, runningForASG, idleStreak > 0 && idleStreak >= idleRequired)
+	if warmFloor := min(warm, maxAllowed); warmFloor > minAllowed {
+		floor, floorReason = warmFloor, floorWarmSlots
+	}
+	// Predictive pre-scale raises it to the demand learned for this hour and the next
+	if asg.PredictivePrescale {
+		o.demandHistory.record(asg.Name, ceilDiv(pendingForASG+runningForASG, asg.EffectiveJobsPerInstance()),
+			o.now(), settings.EffectiveDemandHistoryHalfLife())
+		if learned := min(o.demandHistory.prescale(asg.Name, o.now()), asg.EffectivePredictiveMax(), maxAllowed); learned > floor {
+			floor, floorReason = learned, floorPrescale
 		}
+	}
+	warmSlots := int64(0)
+	if warm > 0 {
+		warmSlots = asg.WarmSlots
+	}
+
+	if demandElsewhere && !pendingJobMatchingTags && !runningJobMatchingTags && !blockScaleDown {
+		slog.Info("Scale-down held: pending jobs of its tags are assigned to other ASGs this cycle", "asg", asg.Name)
+		blockScaleDown = true
+		status.Reason = "scale-down held: pending jobs of its tags are assigned to other ASGs this cycle"
+	}
 
-		additionalNeeded := pendingForASG - freeCapacity
+	shortfallStreak := 0
+	if totalJobs > 0 && pendingJobMatchingTags {
+		// Capacity is counted in job slots; the calculator turns the slots missing into instances
+		freeCapacity, additionalNeeded := o.calculator.Shortfall(asg, state, demand, allocatedCount, warmSlots)
+		blocked.pending, blocked.free = pendingForASG, freeCapacity
+		status.Reason = fmt.Sprintf("%d matching pending jobs fit into %d free slots", pendingForASG, freeCapacity)
 		if additionalNeeded > 0 {
-			proposed := desiredCapacity + additionalNeeded
+			shortfallStreak = o.shortfalls.observe(asg.Name)
+			// Instances still launching (desired above allocated) already cover part of the shortfall
+			proposed := max(desiredCapacity, allocatedCount+stuckHeld+additionalNeeded, floor)
 
-			if proposed > asg.MaxAsgCapacity {
-				proposed = asg.MaxAsgCapacity
+			status.Reason = fmt.Sprintf("%d matching pending jobs, %d free slots", pendingForASG, freeCapacity)
+			if warmSlots > 0 {
+				status.Reason += fmt.Sprintf(", %d warm slots", warmSlots)
+			}
+			if proposed > maxAllowed {
+				proposed = max(maxAllowed, desiredCapacity)
+				status.Reason += fmt.Sprintf(", capped at %s %d", maxReason, maxAllowed)
 			}
 
-			if allocatedCount < proposed {
-				err := provider.UpdateASGCapacity(asg.Name, proposed)
+			requiredCycles := policy.shortfallCycles(settings.ScaleUpStabilization)
+			stabilizing := shortfallStreak < requiredCycles
+			if stabilizing && proposed > desiredCapacity && warmPool >= proposed-desiredCapacity {
+				// Warm pool instances come in service within seconds, so a shortfall they cover is not waited out
+				stabilizing = false
+				status.Reason += fmt.Sprintf("; %d warm pool instances cover the scale-up, stabilization skipped", warmPool)
+			}
+			if proposed <= desiredCapacity {
+				if launching > 0 {
+					status.Reason += fmt.Sprintf("; %d instances already launching", launching)
+				}
+			} else if stabilizing {
+				status.Reason += fmt.Sprintf("; scale-up deferred: shortfall seen for %d of %d cycles", shortfallStreak, requiredCycles)
+				slog.Info("Scale-up deferred", "asg", asg.Name, "shortfall_cycles", shortfallStreak,
+					"required_cycles", requiredCycles, "policy", policy)
+			} else if remaining := o.scaleUps.cooldownRemaining(asg.Name, scaleUpCooldown(asg, settings), o.now()); remaining > 0 &&
+				launching > 0 && policy != PolicyAggressive {
+				// Instances of the last scale-up are still booting and will take on the pending jobs
+				status.Reason += fmt.Sprintf("; scale-up cooldown: %s remaining, %d instances still booting",
+					remaining.Round(time.Second), launching)
+				slog.Info("Scale-up cooldown", "asg", asg.Name, "remaining", remaining.Round(time.Second), "launching", launching)
+			} else if inBlackout {
+				holdForBlackout(asg.Name, blackout, desiredCapacity, proposed, status)
+			} else if dryRun {
+				holdForDryRun(asg, demand, allocatedCount, desiredCapacity, proposed, status)
+			} else if activities.inProgress() {
+				holdForActivity(asg.Name, activities.activity, desiredCapacity, proposed, status)
+			} else if granted := budget.claim(asg.Name, asg.Priority, desiredCapacity, proposed-desiredCapacity); granted == 0 {
+				blocked.totalCapped = proposed - desiredCapacity
+				status.Reason += fmt.Sprintf("; no headroom left under max-total-capacity %d", settings.MaxTotalCapacity)
+			} else {
+				if cut := proposed - desiredCapacity - granted; cut > 0 {
+					blocked.totalCapped = cut
+					status.Reason += fmt.Sprintf("; %d instances throttled by max-total-capacity %d", cut, settings.MaxTotalCapacity)
+					proposed = desiredCapacity + granted
+				}
+				status.Proposed = proposed
+				err := o.applyCapacity(ctx, provider, asg.Name, proposed, timing)
+				o.auditCapacityChange(asg, providerName, demand, allocatedCount, desiredCapacity, proposed, status.Reason, err)
 				if err != nil {
-					log.Println(utils.Red, "Scale-up failed:", err, utils.Reset)
+					slog.Error("Scale-up failed", append(decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, proposed, status.Reason), "error", err)...)
+					status.Decision, status.Reason = DecisionError, "scale-up failed: "+err.Error()
+					blocked.updateFailed = true
 				} else {
-					log.Printf("  → %sScaling up%s ASG: %s%s%s, Old desired: %d, New desired: %d",
-						utils.Green, utils.Reset,
-						utils.LightGray, asg.Name, utils.Reset,
-						desiredCapacity, proposed)
+					status.Decision = DecisionScaleUp
+					o.scaleUps.record(asg.Name, o.now())
+					o.freshness.updated(asg.Name, o.now())
+					slog.Info("Scaling up", decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, proposed, status.Reason)...)
+					o.recordScaleReason(ctx, provider, asg.Name, fmt.Sprintf("pending-jobs=%d", pendingForASG))
 				}
 			}
 		}
 	}
 
-	if !pendingJobMatchingTags && !runningJobMatchingTags {
+	if shortfallStreak == 0 {
+		o.shortfalls.reset(asg.Name)
+	}
+
+	if !pendingJobMatchingTags && !runningJobMatchingTags && blockScaleDown && status.Reason == "" {
+		slog.Info("Scale-down blocked: waiting for runners to come online", "asg", asg.Name)
+		status.Reason = "scale-down blocked: waiting for runners to come online"
+	}
+
+	if !pendingJobMatchingTags && runningJobMatchingTags {
+		status.Reason = "matching jobs are running"
+	}
+
+	if !pendingJobMatchingTags && !runningJobMatchingTags && !blockScaleDown {
 		newCapacity := allocatedCount - 1
-		minAllowed := int64(0)
-		if !asg.ScaleToZero {
-			minAllowed = 1
-		}
 
-		if newCapacity >= minAllowed {
-			err := provider.UpdateASGCapacity(asg.Name, newCapacity)
+		status.Reason = fmt.Sprintf("no matching jobs, already at %s %d", floorReason, floor)
+		if newCapacity >= floor && idleStreak < idleRequired {
+			status.Reason = fmt.Sprintf("no matching jobs for %d of %d idle cycles required for scale-down", idleStreak, idleRequired)
+		} else if remaining := o.scaleUps.cooldownRemaining(asg.Name, scaleDownCooldown(asg, settings), o.now()); newCapacity >= floor && remaining > 0 {
+			status.Reason = fmt.Sprintf("scale-down cooldown: %s remaining after last scale-up", remaining.Round(time.Second))
+			slog.Info("Scale-down cooldown after last scale-up", "asg", asg.Name, "remaining", remaining.Round(time.Second))
+		} else if newCapacity >= floor && inBlackout {
+			status.Reason = "no matching pending or running jobs"
+			holdForBlackout(asg.Name, blackout, desiredCapacity, newCapacity, status)
+		} else if newCapacity >= floor && dryRun {
+			status.Reason = "no matching pending or running jobs"
+			holdForDryRun(asg, demand, allocatedCount, desiredCapacity, newCapacity, status)
+		} else if newCapacity >= floor && activities.inProgress() {
+			status.Reason = "no matching pending or running jobs"
+			holdForActivity(asg.Name, activities.activity, desiredCapacity, newCapacity, status)
+		} else if newCapacity >= floor {
+			status.Proposed = newCapacity
+			if settings.DrainTimeout > 0 {
+				// The scale-ups of other ASGs wait for this evaluation to settle, not for the drain
+				budget.settle(asg.Name, newCapacity)
+				o.drainVictims(ctx, asg.Name, provider, state, allocatedCount-newCapacity, settings.DrainTimeout)
+			}
+			err := o.applyCapacity(ctx, provider, asg.Name, newCapacity, timing)
+			o.auditCapacityChange(asg, providerName, demand, allocatedCount, desiredCapacity, newCapacity, "no matching pending or running jobs", err)
 			if err != nil {
-				log.Println(utils.Red, "Scale-down failed:", err, utils.Reset)
+				slog.Error("Scale-down failed", append(decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, newCapacity, "no matching pending or running jobs"), "error", err)...)
+				status.Decision, status.Reason = DecisionError, "scale-down failed: "+err.Error()
 			} else {
-				log.Printf("  → %sScaling down%s ASG: %s%s%s, New capacity: %d",
-					utils.Magenta, utils.Reset,
-					utils.LightGray, asg.Name, utils.Reset,
-					newCapacity)
+				status.Decision, status.Reason = DecisionScaleDown, "no matching pending or running jobs"
+				o.freshness.updated(asg.Name, o.now())
+				slog.Info("Scaling down", decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, newCapacity, status.Reason)...)
+			}
+		}
+	}
+
+	// Schedules, warm slots and predictive pre-scale keep instances even without jobs, so their floor is applied when
+	// nothing else changed
+	if (len(schedules) > 0 || floor > minAllowed) && status.Decision == DecisionNone && desiredCapacity < floor {
+		target, floorLog, floorDetail := floor, "scheduled minimum", status.Schedule
+		status.Reason = fmt.Sprintf("scheduled min-capacity %d (%s)", floor, status.Schedule)
+		switch floorReason {
+		case floorWarmSlots:
+			floorLog, floorDetail = "keep warm slots", fmt.Sprintf("%d warm slots", asg.WarmSlots)
+			status.Reason = fmt.Sprintf("warm-slots %d above %d running matching jobs need %d instances", asg.WarmSlots, runningForASG, floor)
+		case floorPrescale:
+			floorLog, floorDetail = "predicted demand", "median demand learned for this hour and the next"
+			status.Reason = fmt.Sprintf("predictive pre-scale to %d instances, the median demand learned for this hour and the next", floor)
+		}
+		status.Proposed = target
+
+		if inBlackout {
+			holdForBlackout(asg.Name, blackout, desiredCapacity, target, status)
+		} else if dryRun {
+			holdForDryRun(asg, demand, allocatedCount, desiredCapacity, target, status)
+		} else if activities.inProgress() {
+			holdForActivity(asg.Name, activities.activity, desiredCapacity, target, status)
+		} else {
+			// The budget is claimed only for a change that is applied, so a held one does not starve other ASGs
+			if granted := budget.claim(asg.Name, asg.Priority, desiredCapacity, floor-desiredCapacity); granted < floor-desiredCapacity {
+				target = desiredCapacity + granted
+				blocked.totalCapped = floor - target
+				status.Reason += fmt.Sprintf("; %d instances throttled by max-total-capacity %d", floor-target, settings.MaxTotalCapacity)
+				status.Proposed = target
+			}
+			if target > desiredCapacity {
+				err := o.applyCapacity(ctx, provider, asg.Name, target, timing)
+				o.auditCapacityChange(asg, providerName, demand, allocatedCount, desiredCapacity, target, status.Reason, err)
+				if err != nil {
+					slog.Error("Scale-up failed", append(decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, target, status.Reason), "error", err)...)
+					status.Decision, status.Reason = DecisionError, "scale-up failed: "+err.Error()
+					blocked.updateFailed = true
+				} else {
+					status.Decision = DecisionScaleUp
+					o.scaleUps.record(asg.Name, o.now())
+					o.freshness.updated(asg.Name, o.now())
+					slog.Info("Scaling up to "+floorLog, decisionAttrs(asg, providerName, demand, allocatedCount, desiredCapacity, target, floorDetail)...)
+					o.recordScaleReason(ctx, provider, asg.Name, floorScaleReason(floorReason, target))
+				}
 			}
 		}
 	}
+
+	// Old instances are recycled only while the ASG is idle and its capacity is left as it is
+	if asg.MaxInstanceAge > 0 && status.Decision == DecisionNone && !pendingJobMatchingTags && !runningJobMatchingTags &&
+		!inBlackout && !dryRun && !activities.inProgress() {
+		status.RecycledInstance = o.recycleOldInstance(ctx, asg, provider, launching)
+	}
 }
 
-// Run starts the autoscaling process
-func Run(cfg *config.Config, orchestrator *Orchestrator) {
-	PrintSeparator()
+// Reasons of the capacity floor of an ASG, shown when an idle ASG stays at it
+const (
+	floorMinimum   = "minimum capacity"
+	floorWarmSlots = "warm-slots capacity"
+	floorPrescale  = "predicted capacity"
+)
 
-	projects, err := gitlab.FetchProjects(cfg.GitLab.Token, cfg.GitLab.Group, cfg.GitLab.ExcludeProjects)
-	if err != nil {
-		log.Printf("%sError fetching projects: %s%s", utils.Red, err, utils.Reset)
-		return
+// holdForBlackout records a capacity change that an active blackout window keeps from being applied
+func holdForBlackout(asgName string, window config.BlackoutWindow, desired, proposed int64, status *ASGStatus) {
+	status.Decision, status.Proposed = DecisionBlackout, proposed
+	status.Reason += fmt.Sprintf("; held by blackout %s", window)
+	slog.Info("Blackout: capacity change not applied", "asg", asgName, "desired", desired, "proposed", proposed,
+		"window", window.String())
+}
+
+// holdForDryRun records and logs a capacity change that dry-run keeps from being applied, with the demand behind it
+func holdForDryRun(asg config.Asg, demand Demand, allocated, desired, proposed int64, status *ASGStatus) {
+	status.Decision, status.Proposed = DecisionDryRun, proposed
+	status.Reason += "; not applied in dry-run"
+	direction := "up"
+	if proposed < desired {
+		direction = "down"
 	}
+	slog.Info("[dry-run] Would scale "+direction, decisionAttrs(asg, status.Provider, demand, allocated, desired, proposed, status.Reason)...)
+}
+
+// decisionAttrs returns the fields logged with a scale decision of an ASG: desired is the capacity before it,
+// proposed the one it asks for
+func decisionAttrs(asg config.Asg, provider string, demand Demand, allocated, desired, proposed int64, reason string) []any {
+	return []any{"asg", asg.Name, "provider", provider, "tags", asg.Tags, "pending", demand.Pending, "running", demand.Running,
+		"allocated", allocated, "desired", desired, "proposed", proposed, "reason", reason}
+}
 
-	state := gitlab.CalculateClusterState(cfg.GitLab.Token, projects)
-	orchestrator.ScaleASGs(*cfg, state)
+// describeSchedules joins the windows of the active schedules for logs and the status API
+func describeSchedules(schedules []config.Schedule) string {
+	windows := make([]string, len(schedules))
+	for i, schedule := range schedules {
+		windows[i] = schedule.String()
+	}
+	return strings.Join(windows, "; ")
+}
 
-	log.Printf("Total active capacity: %s%-4d%s", utils.Green, state.TotalCapacity, utils.Reset)
+// warmCapacity returns the instances running the matching jobs with warm-slots free job slots on top; 0 without
+// warm slots, or once the ASG is idle when they are only kept while it is active
+func warmCapacity(asg config.Asg, running int64, idle bool) int64 {
+	if asg.WarmSlots == 0 || (idle && asg.WarmSlotsOnlyWhenActive && asg.ScaleToZero) {
+		return 0
+	}
+	return ceilDiv(running+asg.WarmSlots, asg.EffectiveJobsPerInstance())
+}
+
+// scaleDownIdleCycles returns the consecutive idle cycles required before scaling the ASG down; the ASG setting overrides the global one
+func scaleDownIdleCycles(asg config.Asg, settings config.AutoscalerConfig) int {
+	if asg.ScaleDownIdleCycles > 0 {
+		return asg.ScaleDownIdleCycles
+	}
+	return settings.ScaleDownIdleCycles
+}
+
+// onlineRunnersLagging reports whether fewer runners are online for the ASG tags than instances are allocated.
+// An ASG is credited with the smallest online count among its tags, since every instance registers all of them.
+func onlineRunnersLagging(asg config.Asg, state gitlab.ClusterState, allocatedCount int64) (int64, bool) {
+	if state.OnlineRunnersWithTags == nil || len(asg.Tags) == 0 {
+		return 0, false
+	}
+
+	online := int64(-1)
+	for _, tag := range asg.Tags {
+		count := int64(state.OnlineRunnersWithTags[tag])
+		if online < 0 || count < online {
+			online = count
+		}
+	}
 
-	PrintSeparator()
+	return online, online < allocatedCount
+}
+
+// Run runs one cycle of the autoscaling process; canceling ctx aborts the provider calls of the cycle.
+// Returns an error when the projects could not be fetched from GitLab or the evaluation of an ASG failed.
+func Run(ctx context.Context, cfg *config.Config, client *gitlab.Client, orchestrator *Orchestrator) (err error) {
+	start := orchestrator.now()
+	slog.Debug("Cycle started")
+	orchestrator.markTick(start)
+	orchestrator.cycleStarted(time.Duration(cfg.Autoscaler.CheckInterval) * time.Second)
+	var fetchErr error
+	defer func() {
+		end := orchestrator.now()
+		orchestrator.cycleCompleted(end, fetchErr, err)
+		orchestrator.metrics.CycleCompleted(end.Sub(start))
+	}()
+
+	var projects []gitlab.Project
+	if len(cfg.GitLab.Projects) > 0 {
+		projects = gitlab.ProjectsFromConfig(cfg.GitLab.Projects)
+	} else {
+		projects, fetchErr = client.FetchProjects(cfg.GitLab.Group, cfg.GitLab.ExcludeProjects)
+		if fetchErr != nil {
+			slog.Error("Error fetching projects", "error", fetchErr)
+			fetchErr = fmt.Errorf("failed to fetch projects: %w", fetchErr)
+			return fetchErr
+		}
+	}
+
+	state := client.CalculateClusterState(projects, gitlab.StateOptions{
+		CreatedJobsFactor: cfg.Autoscaler.EffectiveCreatedJobsFactor(),
+		TagAliases:        cfg.Autoscaler.TagAliasLookup(),
+		SizeTagPrefix:     cfg.Autoscaler.SizeTagPrefix,
+	})
+	if cfg.Autoscaler.PipelineHold > 0 {
+		state.ActivePipelines = fetchActivePipelines(client, orchestrator, cfg.Autoscaler.PipelineHold)
+	}
+	if cfg.Autoscaler.RunnerReconciliation != "" {
+		online, err := client.CountOnlineRunnersWithTags(cfg.GitLab.Group, managedTags(*cfg, state))
+		if err != nil {
+			slog.Error("Error fetching runners", "error", err)
+		} else {
+			state.OnlineRunnersWithTags = online
+		}
+	}
+	orchestrator.ScaleASGs(ctx, *cfg, state)
+
+	if cfg.GitLab.CleanupOfflineRunners {
+		err := client.CleanupOfflineRunners(cfg.GitLab.Group, managedTags(*cfg, state),
+			cfg.GitLab.OfflineRunnerMaxAge, cfg.GitLab.CleanupDryRun || cfg.Autoscaler.DryRun, orchestrator.now())
+		if err != nil {
+			slog.Error("Error cleaning up offline runners", "error", err)
+		}
+	}
+
+	slog.Info("Cycle completed", "total_capacity", state.TotalCapacity)
+	return failedASGs(orchestrator)
+}
+
+// failedASGs returns the errors of the ASGs whose evaluation failed in the last cycle, nil when none did
+func failedASGs(orchestrator *Orchestrator) error {
+	snapshot, ok := orchestrator.Snapshot()
+	if !ok {
+		return nil
+	}
+	var errs []error
+	for _, status := range snapshot.ASGs {
+		if status.Decision == DecisionError {
+			errs = append(errs, fmt.Errorf("ASG %s: %s", status.Name, status.Reason))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// managedTags returns the distinct tags served by all configured ASGs; patterns are expanded against the job tags in state
+func managedTags(cfg config.Config, state gitlab.ClusterState) []string {
+	live := liveTags(state)
+	seen := make(map[string]bool)
+	var tags []string
+	for _, providerConfig := range cfg.Providers {
+		for _, asg := range providerConfig.AsgNames {
+			for _, tag := range config.ExpandTags(asg.Tags, live) {
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+		}
+	}
+	return tags
+}
+
+// liveTags returns the distinct tags seen in the cluster state, sorted, for expanding tag patterns
+func liveTags(state gitlab.ClusterState) []string {
+	seen := make(map[string]bool)
+	for _, counts := range []map[string]int{state.PendingJobsWithTags, state.RunningJobsWithTags, state.OnlineRunnersWithTags} {
+		for tag := range counts {
+			seen[tag] = true
+		}
+	}
+	for tag := range state.OldestPendingJobWithTags {
+		seen[tag] = true
+	}
+
+	tags := make([]string, 0, len(seen))
+	for tag := range seen {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// MigrateRenamedASGs moves per-ASG state kept under a previous name to the current name of the ASG.
+// It is called on startup and reload; state that was already migrated is not touched again.
+func (o *Orchestrator) MigrateRenamedASGs(cfg config.Config) {
+	for _, providerConfig := range cfg.Providers {
+		for _, asg := range providerConfig.AsgNames {
+			for _, previous := range asg.PreviousNames {
+				migrated := o.pipelines.rename(previous, asg.Name)
+				migrated = o.shortfalls.rename(previous, asg.Name) || migrated
+				migrated = o.idleCycles.rename(previous, asg.Name) || migrated
+				migrated = o.backoff.rename(previous, asg.Name) || migrated
+				migrated = o.scaleUps.rename(previous, asg.Name) || migrated
+				migrated = o.freshness.rename(previous, asg.Name) || migrated
+				migrated = o.pending.rename(previous, asg.Name) || migrated
+				migrated = o.ages.rename(previous, asg.Name) || migrated
+				migrated = o.demandHistory.rename(previous, asg.Name) || migrated
+				migrated = o.drain.rename(previous, asg.Name) || migrated
+				migrated = o.limitWarnings.rename(previous, asg.Name) || migrated
+				if migrated {
+					slog.Info("Migrated state of renamed ASG", "previous", previous, "asg", asg.Name)
+				}
+			}
+		}
+	}
+}
+
+// SetPublisher registers the publisher of the ASGs configured with the given export-only value
+func (o *Orchestrator) SetPublisher(exportOnly string, publisher TargetPublisher) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if o.publishers == nil {
+		o.publishers = make(map[string]TargetPublisher)
+	}
+	o.publishers[exportOnly] = publisher
 }
 
-// PrintSeparator prints a visual separator in logs
-func PrintSeparator() {
-	border := "═"
-	lineLength := 160
-	separator := fmt.Sprintf("%s\n", strings.Repeat(string(border), lineLength))
-	log.Print(separator)
+// publisherFor returns the publisher registered for an export-only value
+func (o *Orchestrator) publisherFor(exportOnly string) (TargetPublisher, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	publisher, ok := o.publishers[exportOnly]
+	return publisher, ok
 }
 
 // SetProviders atomically replaces orchestrator providers and asg->provider mapping.
@@ -0,0 +1,617 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// newTestOrchestrator builds an orchestrator with a single mocked "aws" provider serving all given ASGs
+func newTestOrchestrator(provider *mocks.MockProvider, asgs ...config.Asg) (*Orchestrator, config.Config) {
+	asgToProvider := make(map[string]string)
+	for _, asg := range asgs {
+		asgToProvider[asg.Name] = "aws"
+	}
+
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers: map[string]config.ProviderConfig{
+			"aws": {AsgNames: asgs},
+		},
+	}
+
+	return NewOrchestrator(map[string]Provider{"aws": provider}, asgToProvider, nil, nil), cfg
+}
+
+// TestScaleASGs_RunnerReconciliationBlock verifies scale-down is blocked while fewer
+// runners are online than instances are allocated.
+//
+// Conditions:
+// - Idle ASG with tag ["amd64"] and 2 allocated instances
+// - Only 1 online runner with tag "amd64"
+// - runner-reconciliation: block
+//
+// Expected result: no capacity update
+func TestScaleASGs_RunnerReconciliationBlock(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.RunnerReconciliation = config.RunnerReconciliationBlock
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags:   map[string]int{},
+		RunningJobsWithTags:   map[string]int{},
+		OnlineRunnersWithTags: map[string]int{"amd64": 1},
+	})
+
+	provider.AssertExpectations(t)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", int64(1))
+}
+
+// TestScaleASGs_RunnerReconciliationWarn verifies warn mode only logs and keeps scaling down.
+//
+// Conditions:
+// - Idle ASG with tag ["amd64"] and 2 allocated instances
+// - Only 1 online runner with tag "amd64"
+// - runner-reconciliation: warn
+//
+// Expected result: scale-down to 1
+func TestScaleASGs_RunnerReconciliationWarn(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.RunnerReconciliation = config.RunnerReconciliationWarn
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags:   map[string]int{},
+		RunningJobsWithTags:   map[string]int{},
+		OnlineRunnersWithTags: map[string]int{"amd64": 1},
+	})
+
+	provider.AssertExpectations(t)
+}
+
+// TestOnlineRunnersLagging verifies the ASG is credited with the smallest online count among its tags.
+func TestOnlineRunnersLagging(t *testing.T) {
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64", "prod"}}
+	state := gitlab.ClusterState{OnlineRunnersWithTags: map[string]int{"amd64": 3, "prod": 2}}
+
+	online, lagging := onlineRunnersLagging(asg, state, 3)
+	assert.Equal(t, int64(2), online)
+	assert.True(t, lagging)
+
+	_, lagging = onlineRunnersLagging(asg, state, 2)
+	assert.False(t, lagging)
+
+	_, lagging = onlineRunnersLagging(asg, gitlab.ClusterState{}, 3)
+	assert.False(t, lagging, "no runner data must never report lagging")
+}
+
+// TestScaleASGs_GitLabScopeIsolation verifies that ASGs with different GitLab scopes only react to
+// jobs from their own projects even when the tag is shared.
+//
+// Conditions:
+// - ASG "team-a" scoped to mygroup/team-a, ASG "team-b" scoped to mygroup/team-b, both serving "amd64"
+// - 3 pending "amd64" jobs in mygroup/team-a/app, none in team-b
+// - team-a has 0 instances, team-b has 1 idle instance
+//
+// Expected result: team-a scales up to 3, team-b scales down to 0
+func TestScaleASGs_GitLabScopeIsolation(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	teamA := config.Asg{Name: "team-a", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true,
+		GitLabScope: config.GitLabScope{Group: "mygroup/team-a"}}
+	teamB := config.Asg{Name: "team-b", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true,
+		GitLabScope: config.GitLabScope{Group: "mygroup/team-b"}}
+	orchestrator, cfg := newTestOrchestrator(provider, teamA, teamB)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "team-a").Return(int64(0), int64(0), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "team-b").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "team-a", int64(3)).Return(nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "team-b", int64(0)).Return(nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		PendingJobsWithTags: map[string]int{"amd64": 3},
+		RunningJobsWithTags: map[string]int{},
+		Projects: []gitlab.Project{
+			{ID: 1, PathWithNamespace: "mygroup/team-a/app", PendingJobs: 3, PendingTagList: []string{"amd64", "amd64", "amd64"}},
+			{ID: 2, PathWithNamespace: "mygroup/team-b/app"},
+		},
+	})
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_PublishesSnapshot verifies a snapshot with the decision per ASG is published after a cycle.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance and max capacity 5
+// - 3 pending "amd64" jobs
+//
+// Expected result: no snapshot before the cycle; afterwards a scale-up decision to 3 with its reason
+func TestScaleASGs_PublishesSnapshot(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil)
+
+	_, ok := orchestrator.Snapshot()
+	assert.False(t, ok)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		PendingJobsWithTags: map[string]int{"amd64": 3},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	snapshot, ok := orchestrator.Snapshot()
+	assert.True(t, ok)
+	assert.Equal(t, int64(3), snapshot.State.TotalPendingJobs)
+	if assert.Len(t, snapshot.ASGs, 1) {
+		status := snapshot.ASGs[0]
+		assert.Equal(t, DecisionScaleUp, status.Decision)
+		assert.Equal(t, int64(1), status.Allocated)
+		assert.Equal(t, int64(3), status.Proposed)
+		assert.Equal(t, "aws", status.Provider)
+		assert.Contains(t, status.Reason, "3 matching pending jobs")
+	}
+}
+
+// TestScaleASGs_LaunchingInstancesCoverShortfall verifies instances still launching are not requested twice.
+//
+// Conditions:
+// - ASG with tag ["amd64"], desired 3, 0 allocated (3 instances launching)
+// - 3 pending "amd64" jobs
+//
+// Expected result: no capacity update; the reason mentions the launching instances
+func TestScaleASGs_LaunchingInstancesCoverShortfall(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10})
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(0), int64(3), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	assert.Equal(t, int64(3), snapshot.ASGs[0].Proposed)
+	assert.Contains(t, snapshot.ASGs[0].Reason, "3 instances already launching")
+	assert.Nil(t, snapshot.ASGs[0].Blocked)
+}
+
+// TestScaleASGs_LaunchingInstancesPartialShortfall verifies only the shortfall beyond launching instances is requested.
+//
+// Conditions:
+// - ASG with tag ["amd64"], desired 3, 1 allocated (2 instances launching)
+// - 5 pending "amd64" jobs
+//
+// Expected result: desired raised to 5 (1 allocated + 4 needed), not 7
+func TestScaleASGs_LaunchingInstancesPartialShortfall(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10})
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(3), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(5)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(5))
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_JobsPerInstance verifies scale-up counts job slots of jobs-per-instance per instance.
+//
+// Conditions:
+// - ASG with tag ["amd64"] and jobs-per-instance 4
+// - Empty ASG with 5 pending jobs; then 1 instance running 3 jobs with 5 pending jobs
+//
+// Expected result: 2 instances for 5 jobs (remainder rounded up); then 1 free slot leaves 4 jobs, so 1 more instance
+func TestScaleASGs_JobsPerInstance(t *testing.T) {
+	cases := []struct {
+		allocated, running int64
+		expected           int64
+	}{
+		{allocated: 0, running: 0, expected: 2},
+		{allocated: 1, running: 3, expected: 2},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider,
+			config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, JobsPerInstance: 4, ScaleToZero: true})
+
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(c.allocated, c.allocated, nil)
+		provider.On("UpdateASGCapacity", mock.Anything, "test-asg", c.expected).Return(nil).Once()
+
+		state := pendingState(5)
+		state.TotalRunningJobs = c.running
+		state.RunningJobsWithTags = map[string]int{"amd64": int(c.running)}
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+
+		provider.AssertExpectations(t)
+	}
+}
+
+// TestScaleASGs_FreeCapacityPerTagFamily verifies free slots only discount the running jobs an ASG serves.
+//
+// Conditions:
+// - ASG "amd64" with 2 idle instances, ASG "arm64" with 5 instances all busy; max 10 each
+// - 5 running "arm64" jobs; 2 pending "amd64" jobs and 1 pending "arm64" job
+//
+// Expected result: "amd64" fits its 2 jobs into its 2 free slots and does not scale up despite the busy arm64
+// fleet; "arm64" has no free slot and scales up to 6
+func TestScaleASGs_FreeCapacityPerTagFamily(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "amd64", Tags: []string{"amd64"}, MaxAsgCapacity: 10},
+		config.Asg{Name: "arm64", Tags: []string{"arm64"}, MaxAsgCapacity: 10})
+
+	provider.On("GetCurrentCapacity", mock.Anything, "amd64").Return(int64(2), int64(2), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "arm64").Return(int64(5), int64(5), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "arm64", int64(6)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		TotalRunningJobs:    5,
+		PendingJobsWithTags: map[string]int{"amd64": 2, "arm64": 1},
+		RunningJobsWithTags: map[string]int{"arm64": 5},
+	})
+
+	provider.AssertExpectations(t)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "amd64", mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, "2 matching pending jobs fit into 2 free slots", snapshot.ASGs[0].Reason)
+}
+
+// TestScaleASGs_PublishesDegradedComponents verifies degraded components appear in the snapshot until cleared
+func TestScaleASGs_PublishesDegradedComponents(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider)
+
+	orchestrator.SetDegraded("admin-listener", errors.New("address already in use"))
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]string{"admin-listener": "address already in use"}, snapshot.Degraded)
+
+	orchestrator.SetDegraded("admin-listener", nil)
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Nil(t, snapshot.Degraded)
+}
+
+// writeCheckingProvider is a provider whose capacity changes are unavailable while writeErr is set
+type writeCheckingProvider struct {
+	*mocks.MockProvider
+	writeErr error
+}
+
+func (p *writeCheckingProvider) WriteError() error { return p.writeErr }
+
+// TestScaleASGs_WriteCredentialsDegraded verifies a provider without write access is reported degraded
+// until it recovers or is removed.
+//
+// Conditions:
+// - The aws provider reports a write error, then none; then the provider is removed while degraded
+//
+// Expected result: "aws-write-credentials" is published with the error, then cleared both times
+func TestScaleASGs_WriteCredentialsDegraded(t *testing.T) {
+	provider := &writeCheckingProvider{MockProvider: &mocks.MockProvider{}, writeErr: errors.New("failed to assume write role")}
+	orchestrator, cfg := newTestOrchestrator(provider.MockProvider)
+	orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{})
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]string{"aws-write-credentials": "failed to assume write role"}, snapshot.Degraded)
+
+	provider.writeErr = nil
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Nil(t, snapshot.Degraded)
+
+	provider.writeErr = errors.New("failed to assume write role")
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	orchestrator.SetProviders(map[string]Provider{}, map[string]string{})
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Nil(t, snapshot.Degraded)
+}
+
+// TestScaleASGs_MinAsgCapacity verifies min-asg-capacity is the floor of both scale-down and scale-up.
+//
+// Conditions:
+// - ASG with tag ["amd64"], min-asg-capacity 2, max 5
+// - Idle with 3 and with 2 allocated instances; 1 pending job on an empty ASG
+//
+// Expected result: scale-down from 3 to 2, none from 2; the empty ASG scales up to 2, not 1
+func TestScaleASGs_MinAsgCapacity(t *testing.T) {
+	cases := []struct {
+		allocated int64
+		pending   int
+		expected  int64 // 0: no update
+	}{
+		{allocated: 3, pending: 0, expected: 2},
+		{allocated: 2, pending: 0, expected: 0},
+		{allocated: 0, pending: 1, expected: 2},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider,
+			config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MinAsgCapacity: 2, MaxAsgCapacity: 5})
+
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(c.allocated, c.allocated, nil)
+		if c.expected > 0 {
+			provider.On("UpdateASGCapacity", mock.Anything, "test-asg", c.expected).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(c.pending))
+
+		provider.AssertExpectations(t)
+		if c.expected == 0 {
+			provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+}
+
+// TestScaleASGs_Schedules verifies an active schedule replaces the capacity bounds of its ASG.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, schedule 08:00-19:00 UTC with min-capacity 3 and max-capacity 4
+// - Monday 10:00: idle at 1, 8 pending jobs at 2, idle at 4 and at 3; 20:00: idle at 1
+//
+// Expected result:
+// - Idle at 1 inside the window scales up to the scheduled floor of 3 without jobs
+// - 8 pending jobs are capped at the scheduled max of 4, not max-asg-capacity
+// - Idle at 4 scales down to 3, idle at 3 stays
+// - Outside the window nothing changes
+func TestScaleASGs_Schedules(t *testing.T) {
+	monday := time.Date(2024, 5, 6, 0, 0, 0, 0, time.UTC)
+	cases := []struct {
+		clock     time.Time
+		allocated int64
+		pending   int
+		expected  int64 // 0: no update
+		decision  Decision
+	}{
+		{clock: monday.Add(10 * time.Hour), allocated: 1, pending: 0, expected: 3, decision: DecisionScaleUp},
+		{clock: monday.Add(10 * time.Hour), allocated: 2, pending: 8, expected: 4, decision: DecisionScaleUp},
+		{clock: monday.Add(10 * time.Hour), allocated: 4, pending: 0, expected: 3, decision: DecisionScaleDown},
+		{clock: monday.Add(10 * time.Hour), allocated: 3, pending: 0, expected: 0, decision: DecisionNone},
+		{clock: monday.Add(20 * time.Hour), allocated: 1, pending: 0, expected: 0, decision: DecisionNone},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider, config.Asg{
+			Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10,
+			Schedules: []config.Schedule{{Start: "08:00", End: "19:00", MinCapacity: 3, MaxCapacity: 4}},
+		})
+		orchestrator.now = func() time.Time { return c.clock }
+
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(c.allocated, c.allocated, nil)
+		if c.expected > 0 {
+			provider.On("UpdateASGCapacity", mock.Anything, "test-asg", c.expected).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(c.pending))
+
+		provider.AssertExpectations(t)
+		if c.expected == 0 {
+			provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+		}
+		snapshot, _ := orchestrator.Snapshot()
+		assert.Equal(t, c.decision, snapshot.ASGs[0].Decision, "allocated %d, pending %d at %s", c.allocated, c.pending, c.clock)
+		if c.clock.Hour() == 10 {
+			assert.Equal(t, "daily 08:00-19:00 UTC", snapshot.ASGs[0].Schedule)
+		}
+	}
+}
+
+// TestScaleASGs_WarmSlots verifies warm-slots keep free job slots above the running matching jobs.
+//
+// Conditions:
+// - ASG with tag ["amd64"], scale-to-zero, jobs-per-instance 2, warm-slots 3, max 10
+// - Idle, running and pending cycles at various capacities; warm-slots-only-when-active in the last two
+//
+// Expected result:
+// - Without jobs the ASG is raised to 2 instances (3 warm slots) and scale-down stops there
+// - 4 running jobs need 4 instances; 3 pending jobs on 2 idle instances scale to 3, keeping 3 slots free
+// - With warm-slots-only-when-active an idle ASG drops below the warm capacity, an active one keeps it
+func TestScaleASGs_WarmSlots(t *testing.T) {
+	cases := []struct {
+		allocated      int64
+		pending        int
+		running        int
+		onlyWhenActive bool
+		expected       int64 // 0: no update
+		reason         string
+	}{
+		{allocated: 0, expected: 2, reason: "warm-slots 3 above 0 running matching jobs need 2 instances"},
+		{allocated: 2, running: 4, expected: 4, reason: "warm-slots 3 above 4 running matching jobs need 4 instances"},
+		{allocated: 2, pending: 3, expected: 3, reason: "3 matching pending jobs, 4 free slots, 3 warm slots"},
+		{allocated: 3, expected: 2, reason: "no matching pending or running jobs"},
+		{allocated: 2, expected: 0, reason: "no matching jobs, already at warm-slots capacity 2"},
+		{allocated: 2, onlyWhenActive: true, expected: 1, reason: "no matching pending or running jobs"},
+		{allocated: 1, running: 1, onlyWhenActive: true, expected: 2, reason: "warm-slots 3 above 1 running matching jobs need 2 instances"},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider, config.Asg{
+			Name: "test-asg", Tags: []string{"amd64"}, ScaleToZero: true, JobsPerInstance: 2, MaxAsgCapacity: 10,
+			WarmSlots: 3, WarmSlotsOnlyWhenActive: c.onlyWhenActive,
+		})
+
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(c.allocated, c.allocated, nil)
+		if c.expected > 0 {
+			provider.On("UpdateASGCapacity", mock.Anything, "test-asg", c.expected).Return(nil).Once()
+		}
+
+		state := pendingState(c.pending)
+		state.TotalRunningJobs = int64(c.running)
+		state.RunningJobsWithTags["amd64"] = c.running
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+
+		provider.AssertExpectations(t)
+		if c.expected == 0 {
+			provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+		}
+		snapshot, _ := orchestrator.Snapshot()
+		assert.Equal(t, c.reason, snapshot.ASGs[0].Reason, "allocated %d, pending %d, running %d", c.allocated, c.pending, c.running)
+	}
+}
+
+// TestScaleASGs_BlackoutWindow verifies capacity is never changed inside a blackout window, re-evaluated after a reload.
+//
+// Conditions:
+// - ASGs "busy" (tag ["amd64"], 1 of 1 allocated) and "idle" (tag ["arm64"], 2 of 2 allocated), max 5
+// - 3 pending "amd64" jobs; a global blackout window around the cycle
+// - A reload with the same windows, but "idle" opting out with an empty blackout-windows list
+// - A reload without windows
+//
+// Expected result:
+// - In the window both decisions are "blackout" with the proposed capacity, UpdateASGCapacity is not called
+// - After the first reload only "idle" scales down; after the second "busy" scales up to 3
+func TestScaleASGs_BlackoutWindow(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "busy", Tags: []string{"amd64"}, MaxAsgCapacity: 5},
+		config.Asg{Name: "idle", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true})
+	now := time.Date(2024, 12, 23, 10, 0, 0, 0, time.UTC)
+	orchestrator.now = func() time.Time { return now }
+	cfg.Autoscaler.BlackoutWindows = []config.BlackoutWindow{{Start: "2024-12-20 18:00", End: "2025-01-06 08:00", Reason: "release freeze"}}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "busy").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "idle").Return(int64(2), int64(2), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	for _, status := range snapshot.ASGs {
+		assert.Equal(t, DecisionBlackout, status.Decision, status.Name)
+		assert.Contains(t, status.Reason, "held by blackout 2024-12-20 18:00-2025-01-06 08:00 UTC (release freeze)")
+	}
+	assert.Equal(t, []int64{3, 1}, []int64{snapshot.ASGs[0].Proposed, snapshot.ASGs[1].Proposed})
+
+	reloaded := cfg
+	reloaded.Providers = map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{
+		cfg.Providers["aws"].AsgNames[0],
+		{Name: "idle", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true, BlackoutWindows: []config.BlackoutWindow{}},
+	}}}
+	provider.On("UpdateASGCapacity", mock.Anything, "idle", int64(1)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), reloaded, pendingState(3))
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "busy", mock.Anything)
+
+	cfg.Autoscaler.BlackoutWindows = nil
+	provider.On("UpdateASGCapacity", mock.Anything, "busy", int64(3)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "idle", int64(1)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_DryRun verifies dry-run decides and logs every capacity change but never applies one, until a
+// reload turns it off.
+//
+// Conditions:
+// - ASGs "busy" (tag ["amd64"], 1 of 1 allocated), "idle" (tag ["arm64"], 2 of 2 allocated) and "warm" (tag
+// ["riscv"], 0 of 0 allocated, 2 warm slots), max 5
+// - 3 pending "amd64" jobs; autoscaler.dry-run set
+// - A reload without dry-run
+//
+// Expected result:
+// - In dry-run the decisions are "dry-run" with the proposed capacity, UpdateASGCapacity is not called
+// - After the reload "busy" scales up to 3, "idle" down to 1 and "warm" up to 2
+func TestScaleASGs_DryRun(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "busy", Tags: []string{"amd64"}, MaxAsgCapacity: 5},
+		config.Asg{Name: "idle", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true},
+		config.Asg{Name: "warm", Tags: []string{"riscv"}, MaxAsgCapacity: 5, ScaleToZero: true, WarmSlots: 2})
+	cfg.Autoscaler.DryRun = true
+
+	provider.On("GetCurrentCapacity", mock.Anything, "busy").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "idle").Return(int64(2), int64(2), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "warm").Return(int64(0), int64(0), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	for _, status := range snapshot.ASGs {
+		assert.Equal(t, DecisionDryRun, status.Decision, status.Name)
+		assert.Contains(t, status.Reason, "not applied in dry-run", status.Name)
+	}
+	assert.Equal(t, []int64{3, 1, 2}, []int64{snapshot.ASGs[0].Proposed, snapshot.ASGs[1].Proposed, snapshot.ASGs[2].Proposed})
+
+	cfg.Autoscaler.DryRun = false
+	provider.On("UpdateASGCapacity", mock.Anything, "busy", int64(3)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "idle", int64(1)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "warm", int64(2)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+}
+
+// fakePublisher records published targets
+type fakePublisher struct {
+	targets map[string]int64
+}
+
+func (p *fakePublisher) PublishTarget(asgName string, desired int64) error {
+	p.targets[asgName] = desired
+	return nil
+}
+
+// TestScaleASGs_ExportOnly verifies export-only ASGs publish their target instead of updating the provider.
+//
+// Conditions:
+// - ASG "exported" (export-only: fleeting) and ASG "managed", both tag ["amd64"] at 1 of 1, max 5
+// - 3 pending jobs counted by both ASGs (tag-sharing duplicate)
+// - A fleeting publisher is registered; later one ASG uses an export-only value without a publisher
+//
+// Expected result:
+// - "exported" publishes 3 and is never updated through the provider; "managed" is updated to 3
+// - Without a publisher the ASG is reported as an error
+func TestScaleASGs_ExportOnly(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "exported", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ExportOnly: config.ExportFleeting},
+		config.Asg{Name: "managed", Tags: []string{"amd64"}, MaxAsgCapacity: 5})
+	cfg.Autoscaler.TagSharing = config.TagSharingDuplicate
+	publisher := &fakePublisher{targets: make(map[string]int64)}
+	orchestrator.SetPublisher(config.ExportFleeting, publisher)
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "managed", int64(3)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "exported", mock.Anything)
+	assert.Equal(t, map[string]int64{"exported": 3}, publisher.targets)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision)
+	assert.Equal(t, "aws+fleeting", snapshot.ASGs[0].Provider)
+
+	cfg.Providers["aws"].AsgNames[1].ExportOnly = "nomad"
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, DecisionError, snapshot.ASGs[1].Decision)
+}
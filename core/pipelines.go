@@ -0,0 +1,141 @@
+package core
+
+import (
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// pipelineKey identifies a pipeline across projects
+type pipelineKey struct {
+	ProjectRef string
+	PipelineID int
+}
+
+// pipelineMemory remembers, per ASG, when pipelines last had pending or running jobs matching the ASG tags
+type pipelineMemory struct {
+	mu       sync.Mutex
+	lastSeen map[string]map[pipelineKey]time.Time
+}
+
+// remember records the pipelines with matching jobs for the ASG and forgets those not seen within hold
+func (m *pipelineMemory) remember(asg config.Asg, state gitlab.ClusterState, hold time.Duration, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.lastSeen == nil {
+		m.lastSeen = make(map[string]map[pipelineKey]time.Time)
+	}
+	seen := m.lastSeen[asg.Name]
+	if seen == nil {
+		seen = make(map[pipelineKey]time.Time)
+		m.lastSeen[asg.Name] = seen
+	}
+
+	for _, project := range state.Projects {
+		for pipelineID, tags := range project.JobPipelines {
+			if matchesAnyTag(asg.Tags, tags) {
+				seen[pipelineKey{ProjectRef: project.Ref(), PipelineID: pipelineID}] = now
+			}
+		}
+	}
+
+	for key, last := range seen {
+		if now.Sub(last) > hold {
+			delete(seen, key)
+		}
+	}
+}
+
+// rename moves the remembered pipelines of an ASG to its new name
+func (m *pipelineMemory) rename(oldName, newName string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	seen, ok := m.lastSeen[oldName]
+	if !ok {
+		return false
+	}
+	if m.lastSeen[newName] == nil {
+		m.lastSeen[newName] = make(map[pipelineKey]time.Time)
+	}
+	for key, last := range seen {
+		if current, exists := m.lastSeen[newName][key]; !exists || last.After(current) {
+			m.lastSeen[newName][key] = last
+		}
+	}
+	delete(m.lastSeen, oldName)
+	return true
+}
+
+// activeMatch returns a remembered pipeline of the ASG that is still active within the hold duration
+func (m *pipelineMemory) activeMatch(asgName string, state gitlab.ClusterState, hold time.Duration, now time.Time) (pipelineKey, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for key, last := range m.lastSeen[asgName] {
+		if now.Sub(last) > hold {
+			continue
+		}
+		for _, id := range state.ActivePipelines[key.ProjectRef] {
+			if id == key.PipelineID {
+				return key, true
+			}
+		}
+	}
+	return pipelineKey{}, false
+}
+
+// projects returns the refs of projects with pipelines remembered within the hold duration
+func (m *pipelineMemory) projects(hold time.Duration, now time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	unique := make(map[string]bool)
+	for _, seen := range m.lastSeen {
+		for key, last := range seen {
+			if now.Sub(last) <= hold {
+				unique[key.ProjectRef] = true
+			}
+		}
+	}
+
+	refs := make([]string, 0, len(unique))
+	for ref := range unique {
+		refs = append(refs, ref)
+	}
+	sort.Strings(refs)
+	return refs
+}
+
+// fetchActivePipelines fetches the active pipeline IDs of projects that recently ran jobs for any ASG
+func fetchActivePipelines(client *gitlab.Client, orchestrator *Orchestrator, hold time.Duration) map[string][]int {
+	active := make(map[string][]int)
+	for _, ref := range orchestrator.pipelines.projects(hold, orchestrator.now()) {
+		pipelines, err := client.FetchActivePipelines(ref)
+		if err != nil {
+			slog.Error("Error fetching pipelines", "project", ref, "error", err)
+			continue
+		}
+		for _, pipeline := range pipelines {
+			active[ref] = append(active[ref], pipeline.ID)
+		}
+	}
+	return active
+}
+
+// matchesAnyTag reports whether any of the job tags is served by the ASG
+func matchesAnyTag(asgTags, jobTags []string) bool {
+	for _, jobTag := range jobTags {
+		for _, asgTag := range asgTags {
+			if jobTag == asgTag {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,138 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_PipelineHold verifies scale-down waits for the next stage of a pipeline that ran matching jobs.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 2 allocated instances, pipeline-hold 5m
+// - Cycle 1: pipeline 7 of project 1 runs an "amd64" job
+// - Cycle 2 (+1m): no jobs, pipeline 7 still active (gap between stages)
+// - Cycle 3 (+7m): no jobs, pipeline 7 still active but the hold expired
+//
+// Expected result: no capacity update in cycles 1 and 2; scale-down to 1 in cycle 3
+func TestScaleASGs_PipelineHold(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.PipelineHold = 5 * time.Minute
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	now := start
+	orchestrator.now = func() time.Time { return now }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 1},
+		Projects:            []gitlab.Project{{ID: 1, JobPipelines: map[int][]string{7: {"amd64"}}}},
+	})
+	assert.Equal(t, []string{"1"}, orchestrator.pipelines.projects(cfg.Autoscaler.PipelineHold, now))
+
+	idle := gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+		Projects:            []gitlab.Project{{ID: 1}},
+		ActivePipelines:     map[string][]int{"1": {7}},
+	}
+
+	now = start.Add(time.Minute)
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", int64(1))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Contains(t, snapshot.ASGs[0].Reason, "pipeline 7 of project 1")
+
+	now = start.Add(7 * time.Minute)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil)
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+	provider.AssertExpectations(t)
+	assert.Empty(t, orchestrator.pipelines.projects(cfg.Autoscaler.PipelineHold, now))
+}
+
+// TestScaleASGs_PipelineHoldOtherTags verifies active pipelines that only ran jobs for other ASGs do not hold scale-down.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 2 allocated instances, pipeline-hold 5m
+// - Pipeline 7 ran only "arm64" jobs and is still active
+//
+// Expected result: scale-down to 1
+func TestScaleASGs_PipelineHoldOtherTags(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.PipelineHold = 5 * time.Minute
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"arm64": 1},
+		Projects:            []gitlab.Project{{ID: 1, JobPipelines: map[int][]string{7: {"arm64"}}}},
+		ActivePipelines:     map[string][]int{"1": {7}},
+	})
+
+	provider.AssertExpectations(t)
+}
+
+// TestMigrateRenamedASGs verifies pipeline-hold state follows an ASG through a rename on reload.
+//
+// Conditions:
+// - Cycle 1: ASG "runners-blue" serves a running "amd64" job of pipeline 7, pipeline-hold 5m
+// - Reload: the ASG is replaced by "runners-green" with previous-names ["runners-blue"]
+// - Cycle 2 (+1m): no jobs, pipeline 7 still active
+//
+// Expected result: "runners-green" holds scale-down; a second migration is a no-op
+func TestMigrateRenamedASGs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	blue := config.Asg{Name: "runners-blue", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, blue)
+	cfg.Autoscaler.PipelineHold = 5 * time.Minute
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	now := start
+	orchestrator.now = func() time.Time { return now }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "runners-blue").Return(int64(2), int64(2), nil)
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 1},
+		Projects:            []gitlab.Project{{ID: 1, JobPipelines: map[int][]string{7: {"amd64"}}}},
+	})
+
+	green := blue
+	green.Name, green.PreviousNames = "runners-green", []string{"runners-blue"}
+	cfg.Providers = map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{green}}}
+	orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{"runners-green": "aws"})
+	orchestrator.MigrateRenamedASGs(cfg)
+	orchestrator.MigrateRenamedASGs(cfg)
+
+	now = start.Add(time.Minute)
+	provider.On("GetCurrentCapacity", mock.Anything, "runners-green").Return(int64(2), int64(2), nil)
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+		Projects:            []gitlab.Project{{ID: 1}},
+		ActivePipelines:     map[string][]int{"1": {7}},
+	})
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "runners-green", int64(1))
+	if _, ok := orchestrator.pipelines.lastSeen["runners-blue"]; ok {
+		t.Errorf("expected state under the previous name to be removed")
+	}
+}
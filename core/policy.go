@@ -0,0 +1,76 @@
+package core
+
+import (
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// ScalingPolicy describes how eagerly an ASG reacts to pending demand in the current cycle
+type ScalingPolicy int
+
+const (
+	// PolicyDefault applies every configured damping knob as usual
+	PolicyDefault ScalingPolicy = iota
+	// PolicyConservative means matching jobs are well within their wait target: a scale-up waits one more shortfall
+	// cycle than scale-up-stabilization asks for
+	PolicyConservative
+	// PolicyAggressive means the wait target was exceeded: damping is bypassed and the ASG scales straight to demand
+	PolicyAggressive
+)
+
+// String returns a human readable name of the policy for logs
+func (p ScalingPolicy) String() string {
+	switch p {
+	case PolicyConservative:
+		return "conservative"
+	case PolicyAggressive:
+		return "aggressive"
+	default:
+		return "default"
+	}
+}
+
+// shortfallCycles returns the consecutive shortfall cycles a scale-up waits for under the policy, given the
+// configured scale-up-stabilization: none when aggressive, one more when conservative
+func (p ScalingPolicy) shortfallCycles(stabilization int) int {
+	switch p {
+	case PolicyAggressive:
+		return 0
+	case PolicyConservative:
+		return stabilization + 1
+	default:
+		return stabilization
+	}
+}
+
+// waitTargetPolicy derives the scaling policy of an ASG from the age of its oldest matching pending job.
+//
+// Precedence: an exceeded target-max-wait overrides every other damping knob (smoothing, cooldowns).
+// A job waiting exactly the target is still within it. Below half the target the ASG is considered
+// comfortably served and a scale-up is stabilized one cycle longer. Without a target the default policy applies.
+// The age of the oldest matching pending job is returned alongside for logging.
+func waitTargetPolicy(asg config.Asg, state gitlab.ClusterState, now time.Time) (ScalingPolicy, time.Duration) {
+	var oldestWait time.Duration
+	for _, tag := range asg.Tags {
+		created, ok := state.OldestPendingJobWithTags[tag]
+		if !ok {
+			continue
+		}
+		if wait := now.Sub(created); wait > oldestWait {
+			oldestWait = wait
+		}
+	}
+
+	if asg.TargetMaxWait <= 0 {
+		return PolicyDefault, oldestWait
+	}
+	if oldestWait > asg.TargetMaxWait {
+		return PolicyAggressive, oldestWait
+	}
+	if oldestWait < asg.TargetMaxWait/2 {
+		return PolicyConservative, oldestWait
+	}
+	return PolicyDefault, oldestWait
+}
@@ -0,0 +1,109 @@
+package core
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// TestWaitTargetPolicy_Threshold verifies the switchover to the aggressive policy
+// happens exactly when the oldest matching job waits longer than the target.
+//
+// Conditions:
+// - ASG with tag ["amd64"] and target-max-wait of 120s
+// - Oldest pending "amd64" job aged 59s, 60s, 119s, 120s and 121s
+//
+// Expected result: conservative below half the target, default up to and including
+// the target, aggressive only once the target is exceeded
+func TestWaitTargetPolicy_Threshold(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	asg := config.Asg{
+		Name:          "test-asg",
+		Tags:          []string{"amd64"},
+		TargetMaxWait: 120 * time.Second,
+	}
+
+	cases := []struct {
+		age      time.Duration
+		expected ScalingPolicy
+	}{
+		{59 * time.Second, PolicyConservative},
+		{60 * time.Second, PolicyDefault},
+		{119 * time.Second, PolicyDefault},
+		{120 * time.Second, PolicyDefault},
+		{120*time.Second + time.Nanosecond, PolicyAggressive},
+		{121 * time.Second, PolicyAggressive},
+	}
+
+	for _, c := range cases {
+		state := gitlab.ClusterState{
+			OldestPendingJobWithTags: map[string]time.Time{"amd64": now.Add(-c.age)},
+		}
+
+		policy, wait := waitTargetPolicy(asg, state, now)
+		if policy != c.expected {
+			t.Errorf("age %s: expected %s, got %s", c.age, c.expected, policy)
+		}
+		if wait != c.age {
+			t.Errorf("age %s: expected reported wait %s, got %s", c.age, c.age, wait)
+		}
+	}
+}
+
+// TestWaitTargetPolicy_MatchingTagsOnly verifies only jobs with tags served by the ASG
+// influence the policy.
+//
+// Conditions:
+// - ASG with tags ["amd64", "prod"] and target-max-wait of 60s
+// - "arm64" job waiting 10m, "prod" job waiting 90s, "amd64" job waiting 5s
+//
+// Expected result: aggressive, driven by the 90s "prod" job
+func TestWaitTargetPolicy_MatchingTagsOnly(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	asg := config.Asg{
+		Name:          "test-asg",
+		Tags:          []string{"amd64", "prod"},
+		TargetMaxWait: time.Minute,
+	}
+
+	state := gitlab.ClusterState{
+		OldestPendingJobWithTags: map[string]time.Time{
+			"arm64": now.Add(-10 * time.Minute),
+			"prod":  now.Add(-90 * time.Second),
+			"amd64": now.Add(-5 * time.Second),
+		},
+	}
+
+	policy, wait := waitTargetPolicy(asg, state, now)
+	if policy != PolicyAggressive {
+		t.Errorf("Expected aggressive, got %s", policy)
+	}
+	if wait != 90*time.Second {
+		t.Errorf("Expected 90s wait, got %s", wait)
+	}
+}
+
+// TestWaitTargetPolicy_Disabled verifies the default policy applies without a target.
+//
+// Conditions:
+// - ASG without target-max-wait
+// - Matching job waiting one hour
+//
+// Expected result: default policy
+func TestWaitTargetPolicy_Disabled(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	asg := config.Asg{
+		Name: "test-asg",
+		Tags: []string{"amd64"},
+	}
+
+	state := gitlab.ClusterState{
+		OldestPendingJobWithTags: map[string]time.Time{"amd64": now.Add(-time.Hour)},
+	}
+
+	if policy, _ := waitTargetPolicy(asg, state, now); policy != PolicyDefault {
+		t.Errorf("Expected default, got %s", policy)
+	}
+}
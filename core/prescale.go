@@ -0,0 +1,224 @@
+package core
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+const (
+	// demandWeightFloor drops a demand from a histogram once its samples decayed below this weight
+	demandWeightFloor = 0.01
+	// demandHistorySaveInterval is how often the learned demand is written to demand-history-file at most
+	demandHistorySaveInterval = 5 * time.Minute
+	// demandHistoryVersion is the format of demand-history-file
+	demandHistoryVersion = 1
+	// demandHistoryComponent is the degraded component while demand-history-file cannot be written
+	demandHistoryComponent = "demand-history"
+)
+
+// demandHistory learns the demand of ASGs with predictive-prescale per hour of the week, so capacity can be raised
+// before a recurring busy hour such as the first pipelines of the workday. Every hour keeps a histogram of the demand
+// its cycles saw, in instances; older samples lose weight with the half-life, so the histogram follows a changing
+// workload. Hours are taken in the time zone of the autoscaler clock.
+type demandHistory struct {
+	mu      sync.Mutex
+	asgs    map[string]map[int]*demandSlot // ASG name -> hour of the week (0 is Sunday 00:00) -> histogram
+	dirty   bool                           // Samples were recorded since the last save
+	savedAt time.Time
+}
+
+// demandSlot is the histogram of one hour of the week
+type demandSlot struct {
+	Weights map[int64]float64 `json:"weights"` // Demand in instances -> decayed number of cycles that saw it
+	Updated time.Time         `json:"updated"` // When the weights were last decayed
+}
+
+// demandHistoryFile is the content of demand-history-file
+type demandHistoryFile struct {
+	Version int                            `json:"version"`
+	ASGs    map[string]map[int]*demandSlot `json:"asgs"`
+}
+
+// hourOfWeek returns the index of the hour of the week of t, 0 being Sunday 00:00
+func hourOfWeek(t time.Time) int {
+	return int(t.Weekday())*24 + t.Hour()
+}
+
+// record adds a cycle of the ASG that saw demand instances to the hour of now, after decaying the older samples
+func (h *demandHistory) record(asgName string, demand int64, now time.Time, halfLife time.Duration) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.asgs == nil {
+		h.asgs = make(map[string]map[int]*demandSlot)
+	}
+	if h.asgs[asgName] == nil {
+		h.asgs[asgName] = make(map[int]*demandSlot)
+	}
+	hour := hourOfWeek(now)
+	slot := h.asgs[asgName][hour]
+	if slot == nil {
+		slot = &demandSlot{Weights: make(map[int64]float64)}
+		h.asgs[asgName][hour] = slot
+	}
+
+	slot.decay(now, halfLife)
+	slot.Weights[demand]++
+	slot.Updated = now
+	h.dirty = true
+}
+
+// decay halves the weights of the slot per half-life elapsed since its last update and drops negligible ones
+func (s *demandSlot) decay(now time.Time, halfLife time.Duration) {
+	elapsed := now.Sub(s.Updated)
+	if s.Updated.IsZero() || elapsed <= 0 || halfLife <= 0 {
+		return
+	}
+	factor := math.Exp2(-float64(elapsed) / float64(halfLife))
+	for demand, weight := range s.Weights {
+		if weight *= factor; weight < demandWeightFloor {
+			delete(s.Weights, demand)
+		} else {
+			s.Weights[demand] = weight
+		}
+	}
+}
+
+// median returns the weighted median demand of the slot; 0 without samples
+func (s *demandSlot) median() int64 {
+	var total float64
+	demands := make([]int64, 0, len(s.Weights))
+	for demand, weight := range s.Weights {
+		demands = append(demands, demand)
+		total += weight
+	}
+	slices.Sort(demands)
+
+	var cumulative float64
+	for _, demand := range demands {
+		if cumulative += s.Weights[demand]; cumulative >= total/2 {
+			return demand
+		}
+	}
+	return 0
+}
+
+// prescale returns the median demand learned for the hour of now or the next hour, whichever is larger, so the
+// capacity of a busy hour is in place when it starts and kept while it lasts; 0 without history
+func (h *demandHistory) prescale(asgName string, now time.Time) int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	var demand int64
+	for _, t := range []time.Time{now, now.Add(time.Hour)} {
+		if slot := h.asgs[asgName][hourOfWeek(t)]; slot != nil {
+			demand = max(demand, slot.median())
+		}
+	}
+	return demand
+}
+
+// retain forgets the history of every ASG keep reports false for
+func (h *demandHistory) retain(keep func(asgName string) bool) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for asgName := range h.asgs {
+		if !keep(asgName) {
+			delete(h.asgs, asgName)
+			h.dirty = true
+		}
+	}
+}
+
+// rename moves the history of an ASG to its new name
+func (h *demandHistory) rename(oldName, newName string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	slots, ok := h.asgs[oldName]
+	if !ok {
+		return false
+	}
+	h.asgs[newName] = slots
+	delete(h.asgs, oldName)
+	h.dirty = true
+	return true
+}
+
+// load replaces the history with the content of path; a missing file leaves it empty
+func (h *demandHistory) load(path string) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read demand history: %w", err)
+	}
+	var file demandHistoryFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return fmt.Errorf("failed to decode demand history: %w", err)
+	}
+	if file.Version != demandHistoryVersion {
+		return fmt.Errorf("unsupported demand history version %d", file.Version)
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.asgs, h.dirty = file.ASGs, false
+	return nil
+}
+
+// save writes the history to path when samples were recorded since the last save, at most once per
+// demandHistorySaveInterval unless force is set. The file is replaced atomically.
+func (h *demandHistory) save(path string, now time.Time, force bool) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if !h.dirty || (!force && now.Sub(h.savedAt) < demandHistorySaveInterval) {
+		return nil
+	}
+	data, err := json.Marshal(demandHistoryFile{Version: demandHistoryVersion, ASGs: h.asgs})
+	if err != nil {
+		return fmt.Errorf("failed to encode demand history: %w", err)
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to write demand history: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write demand history: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write demand history: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write demand history: %w", err)
+	}
+	h.dirty, h.savedAt = false, now
+	return nil
+}
+
+// LoadDemandHistory restores the demand learned for predictive-prescale from demand-history-file; a no-op without one
+func (o *Orchestrator) LoadDemandHistory(settings config.AutoscalerConfig) error {
+	if settings.DemandHistoryFile == "" {
+		return nil
+	}
+	return o.demandHistory.load(settings.DemandHistoryFile)
+}
+
+// SaveDemandHistory writes the demand learned for predictive-prescale to demand-history-file, e.g. on shutdown;
+// a no-op without one
+func (o *Orchestrator) SaveDemandHistory(settings config.AutoscalerConfig) error {
+	if settings.DemandHistoryFile == "" {
+		return nil
+	}
+	return o.demandHistory.save(settings.DemandHistoryFile, o.now(), true)
+}
@@ -0,0 +1,164 @@
+package core
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// monday9 is a Monday 09:00 UTC
+var monday9 = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+// TestDemandHistory_Median verifies the prediction is the weighted median of the hour and the next one.
+//
+// Conditions:
+// - Hour 09: 3 cycles with demand 4, 2 with demand 1; hour 10: 5 cycles with demand 6
+// - Predictions at 08:30, 09:30, 10:30 and 11:30
+//
+// Expected result: 4 (next hour 09), 6 (next hour 10 outweighs 09), 6 (hour 10), 0 (no history)
+func TestDemandHistory_Median(t *testing.T) {
+	var history demandHistory
+	for i, demand := range []int64{4, 1, 4, 1, 4} {
+		history.record("test-asg", demand, monday9.Add(time.Duration(i)*time.Minute), time.Hour)
+	}
+	for i := 0; i < 5; i++ {
+		history.record("test-asg", 6, monday9.Add(time.Hour+time.Duration(i)*time.Minute), time.Hour)
+	}
+
+	assert.Equal(t, int64(4), history.prescale("test-asg", monday9.Add(-30*time.Minute)))
+	assert.Equal(t, int64(6), history.prescale("test-asg", monday9.Add(30*time.Minute)))
+	assert.Equal(t, int64(6), history.prescale("test-asg", monday9.Add(90*time.Minute)))
+	assert.Equal(t, int64(0), history.prescale("test-asg", monday9.Add(150*time.Minute)))
+	assert.Equal(t, int64(0), history.prescale("other-asg", monday9))
+}
+
+// TestDemandHistory_Decay verifies old samples lose weight with the half-life.
+//
+// Conditions:
+// - Half-life 1 week; 10 cycles with demand 8 on a Monday at 09:00, then 3 cycles with demand 2 four weeks later
+// - Then 20 weeks later one cycle with demand 5
+//
+// Expected result: demand 2 outweighs the decayed demand 8 (10 / 16 < 3); after 20 weeks the old samples are
+// dropped and 5 is the only demand left
+func TestDemandHistory_Decay(t *testing.T) {
+	const week = 7 * 24 * time.Hour
+	var history demandHistory
+	for i := 0; i < 10; i++ {
+		history.record("test-asg", 8, monday9, week)
+	}
+	assert.Equal(t, int64(8), history.prescale("test-asg", monday9))
+
+	for i := 0; i < 3; i++ {
+		history.record("test-asg", 2, monday9.Add(4*week), week)
+	}
+	assert.Equal(t, int64(2), history.prescale("test-asg", monday9.Add(4*week)))
+
+	history.record("test-asg", 5, monday9.Add(24*week), week)
+	assert.Equal(t, map[int64]float64{5: 1}, history.asgs["test-asg"][hourOfWeek(monday9)].Weights)
+}
+
+// TestScaleASGs_PredictivePrescale verifies the morning ramp learned from last week is in place before it starts.
+//
+// Conditions:
+// - ASG with tag ["amd64"], scale-to-zero, max 10, predictive-prescale; a fake clock
+// - Last Monday 09:00-09:50: 4 matching jobs running on 4 instances, one cycle every 10 minutes
+// - This Monday at 07:30 and at 08:30: no jobs, 0 instances; then again at 08:30 with predictive-max 3
+//
+// Expected result: nothing at 07:30 (no history for 07 and 08); at 08:30 a scale-up to the learned demand 4 with a
+// predictive pre-scale reason; bounded by predictive-max to 3
+func TestScaleASGs_PredictivePrescale(t *testing.T) {
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true, PredictivePrescale: true}
+	clock := monday9
+	learn := func() *Orchestrator {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider, asg)
+		orchestrator.now = func() time.Time { return clock }
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(4), int64(4), nil)
+		busy := gitlab.ClusterState{
+			TotalRunningJobs:    4,
+			PendingJobsWithTags: map[string]int{},
+			RunningJobsWithTags: map[string]int{"amd64": 4},
+		}
+		for clock = monday9; clock.Before(monday9.Add(time.Hour)); clock = clock.Add(10 * time.Minute) {
+			orchestrator.ScaleASGs(context.Background(), cfg, busy)
+		}
+		provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+		return orchestrator
+	}
+	idle := func(orchestrator *Orchestrator, asg config.Asg, at time.Time) *mocks.MockProvider {
+		provider := &mocks.MockProvider{}
+		orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"})
+		clock = at
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(0), int64(0), nil)
+		provider.On("UpdateASGCapacity", mock.Anything, "test-asg", mock.Anything).Return(nil)
+		cfg := config.Config{
+			Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+			Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+		}
+		orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{PendingJobsWithTags: map[string]int{}, RunningJobsWithTags: map[string]int{}})
+		return provider
+	}
+	nextMonday := monday9.Add(7 * 24 * time.Hour)
+
+	orchestrator := learn()
+	provider := idle(orchestrator, asg, nextMonday.Add(-90*time.Minute))
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+
+	provider = idle(orchestrator, asg, nextMonday.Add(-30*time.Minute))
+	provider.AssertCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", int64(4))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision)
+	assert.Equal(t, "predictive pre-scale to 4 instances, the median demand learned for this hour and the next", snapshot.ASGs[0].Reason)
+
+	bounded := asg
+	bounded.PredictiveMax = 3
+	orchestrator = learn()
+	provider = idle(orchestrator, bounded, nextMonday.Add(-30*time.Minute))
+	provider.AssertCalled(t, "UpdateASGCapacity", mock.Anything, "test-asg", int64(3))
+}
+
+// TestDemandHistory_Persistence verifies the learned demand survives a restart through demand-history-file.
+//
+// Conditions:
+// - demand-history-file in a temporary directory; a cycle of an ASG with predictive-prescale learns demand 3
+// - A new orchestrator loads the file; a missing file and a file of another version are loaded too
+//
+// Expected result: the cycle writes the file; the new orchestrator predicts 3; a missing file loads empty without
+// error; an unknown version is an error
+func TestDemandHistory_Persistence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "demand-history.json")
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, PredictivePrescale: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.DemandHistoryFile = path
+	orchestrator.now = func() time.Time { return monday9 }
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(3), int64(3), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    3,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 3},
+	})
+	require.FileExists(t, path)
+
+	restarted := NewOrchestrator(nil, nil, nil, nil)
+	require.NoError(t, restarted.LoadDemandHistory(cfg.Autoscaler))
+	assert.Equal(t, int64(3), restarted.demandHistory.prescale("test-asg", monday9))
+
+	missing := cfg.Autoscaler
+	missing.DemandHistoryFile = filepath.Join(t.TempDir(), "missing.json")
+	assert.NoError(t, NewOrchestrator(nil, nil, nil, nil).LoadDemandHistory(missing))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"version": 99}`), 0644))
+	assert.Error(t, NewOrchestrator(nil, nil, nil, nil).LoadDemandHistory(cfg.Autoscaler))
+}
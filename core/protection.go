@@ -0,0 +1,75 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// busyInstances returns the IDs of the instances whose ID is part of the description of a runner running a job
+func busyInstances(instances []Instance, busyRunners []string) map[string]bool {
+	busy := make(map[string]bool)
+	for _, instance := range instances {
+		if instance.ID == "" {
+			continue
+		}
+		for _, runner := range busyRunners {
+			if strings.Contains(runner, instance.ID) {
+				busy[instance.ID] = true
+				break
+			}
+		}
+	}
+	return busy
+}
+
+// protectBusyInstances protects the instances of the ASG running a job from scale-in and clears the protection
+// of idle ones, so that lowering the desired capacity terminates idle instances only. Runners are matched to
+// instances by the instance ID in their description. Failures are logged and leave the protection as it was.
+// Returns how many instances are protected after the changes.
+func (o *Orchestrator) protectBusyInstances(ctx context.Context, asg config.Asg, provider Provider, state gitlab.ClusterState) int64 {
+	instanceProvider, ok := provider.(InstanceProvider)
+	if !ok {
+		return 0
+	}
+	protector, ok := provider.(InstanceProtector)
+	if !ok {
+		return 0
+	}
+
+	instances := instanceProvider.Instances(asg.Name)
+	busy := busyInstances(instances, state.BusyRunners)
+	var protect, release []string
+	var protected int64
+	for _, instance := range instances {
+		switch {
+		case busy[instance.ID] && !instance.Protected:
+			protect = append(protect, instance.ID)
+		case !busy[instance.ID] && instance.Protected:
+			release = append(release, instance.ID)
+		case instance.Protected:
+			protected++
+		}
+	}
+
+	if len(protect) > 0 {
+		if err := protector.SetInstanceProtection(ctx, asg.Name, protect, true); err != nil {
+			slog.Error("Protecting busy instances failed", "asg", asg.Name, "error", err)
+		} else {
+			protected += int64(len(protect))
+			slog.Info("Protecting busy instances running a job", "asg", asg.Name, "instances", protect)
+		}
+	}
+	if len(release) > 0 {
+		if err := protector.SetInstanceProtection(ctx, asg.Name, release, false); err != nil {
+			protected += int64(len(release))
+			slog.Error("Releasing idle instances failed", "asg", asg.Name, "error", err)
+		} else {
+			slog.Info("Releasing idle instances without a job", "asg", asg.Name, "instances", release)
+		}
+	}
+	return protected
+}
@@ -0,0 +1,59 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_ProtectBusyInstances verifies instances running a job are protected from scale-in and idle ones released.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, protect-busy-instances, 4 of 4 allocated, 4 running jobs
+// - Busy runners "runner-i-1" and "runner-i-3"; i-1 unprotected, i-2 and i-4 protected, i-3 protected
+// - Releasing fails
+//
+// Expected result:
+// - i-1 is protected; the release of i-2 and i-4 is attempted in one call
+// - i-1, i-3 and, as the release failed, i-2 and i-4 are reported protected; capacity is left alone
+func TestScaleASGs_ProtectBusyInstances(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ProtectBusyInstances: true}
+	orchestrator, cfg := newStuckTestOrchestrator(provider, asg)
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(4), int64(4), nil)
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{
+		{ID: "i-1"},
+		{ID: "i-2", Protected: true},
+		{ID: "i-3", Protected: true},
+		{ID: "i-4", Protected: true},
+	})
+	provider.mockInstances.On("SetInstanceProtection", mock.Anything, "test-asg", []string{"i-1"}, true).Return(nil).Once()
+	provider.mockInstances.On("SetInstanceProtection", mock.Anything, "test-asg", []string{"i-2", "i-4"}, false).
+		Return(errors.New("throttled")).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    4,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 4},
+		BusyRunners:         []string{"runner-i-1", "runner-i-3"},
+	})
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, int64(4), snapshot.ASGs[0].ProtectedInstances)
+	provider.MockProvider.AssertExpectations(t)
+	provider.mockInstances.AssertExpectations(t)
+}
+
+// TestBusyInstances verifies runners are matched to instances by the instance ID in their description
+func TestBusyInstances(t *testing.T) {
+	busy := busyInstances([]Instance{{ID: "i-0a"}, {ID: "i-0b"}, {ID: ""}}, []string{"gitlab-runner i-0a (amd64)", "other-host"})
+	assert.Equal(t, map[string]bool{"i-0a": true}, busy)
+}
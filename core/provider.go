@@ -1,7 +1,79 @@
 package core
 
-// Provider defines the interface for cloud provider implementations
+import (
+	"context"
+	"time"
+)
+
+// Provider defines the interface for cloud provider implementations. The context is canceled on shutdown,
+// calls in flight are expected to return early then.
 type Provider interface {
-	GetCurrentCapacity(asgName string) (int64, int64, error)
-	UpdateASGCapacity(asgName string, capacity int64) error
+	GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error)
+	UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error
+}
+
+// WithRequestTimeout bounds a provider call with the request-timeout of its provider; only canceled with ctx when
+// timeout is 0. A deadline of ctx that is earlier is kept.
+func WithRequestTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
+// WriteChecker is implemented by providers whose capacity changes can be unavailable while describes still
+// work, e.g. when separate write credentials cannot be resolved
+type WriteChecker interface {
+	// WriteError returns why capacity changes are unavailable, nil when they are available
+	WriteError() error
+}
+
+// TargetPublisher publishes the desired capacity of export-only ASGs for an external scaler to apply
+type TargetPublisher interface {
+	PublishTarget(asgName string, desired int64) error
+}
+
+// exportOnlyProvider reads capacity from the provider of an export-only ASG but publishes capacity changes
+// instead of applying them. It hides InstanceProvider, since the external scaler owns the instances.
+type exportOnlyProvider struct {
+	Provider
+	publisher TargetPublisher
+}
+
+// UpdateASGCapacity publishes the capacity as the target of the ASG
+func (p exportOnlyProvider) UpdateASGCapacity(_ context.Context, asgName string, capacity int64) error {
+	return p.publisher.PublishTarget(asgName, capacity)
+}
+
+// InstanceProvider is implemented by providers that report the instances behind the allocated count,
+// so that instances stuck in a pending state can be excluded from it and replaced
+type InstanceProvider interface {
+	// Instances returns the instances of the ASG seen by the last GetCurrentCapacity call
+	Instances(asgName string) []Instance
+	// TerminateInstance terminates a single instance without lowering the desired capacity, so that it is replaced
+	TerminateInstance(ctx context.Context, asgName, instanceID string) error
+}
+
+// Instance is a single instance counted as allocated by GetCurrentCapacity
+type Instance struct {
+	ID         string
+	Pending    bool      // Launched but not in service yet
+	LaunchTime time.Time // Zero when the provider does not report it; the first cycle seeing the instance pending is used instead
+	Protected  bool      // Protected from scale-in: lowering the desired capacity does not terminate it
+}
+
+// InstanceProtector is implemented by providers of InstanceProvider that can protect instances from scale-in,
+// so that lowering the desired capacity terminates idle instances instead of ones running a job
+type InstanceProtector interface {
+	SetInstanceProtection(ctx context.Context, asgName string, instanceIDs []string, protected bool) error
+}
+
+// LifecycleHookCompleter is implemented by providers whose ASGs hold terminating instances on a lifecycle hook
+// until it is completed, e.g. so that their runners can unregister
+type LifecycleHookCompleter interface {
+	// TerminatingInstances returns the instances of the ASG waiting on a terminating lifecycle hook, seen by the
+	// last GetCurrentCapacity call
+	TerminatingInstances(asgName string) []string
+	// CompleteLifecycleAction completes the lifecycle hook of the instance, so that it continues terminating
+	CompleteLifecycleAction(ctx context.Context, asgName, hookName, instanceID string) error
 }
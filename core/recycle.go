@@ -0,0 +1,107 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// instanceAges remembers since when the instances of each ASG exist, for max-instance-age
+type instanceAges struct {
+	mu    sync.Mutex
+	since map[string]map[string]time.Time // ASG name -> instance ID -> launched or first seen
+}
+
+// observe records the instances of the ASG. Instances without a launch time are counted from the first call
+// that saw them, so ages start over after a restart; instances no longer allocated are forgotten.
+func (a *instanceAges) observe(asgName string, instances []Instance, now time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.since == nil {
+		a.since = make(map[string]map[string]time.Time)
+	}
+
+	previous := a.since[asgName]
+	current := make(map[string]time.Time, len(instances))
+	for _, instance := range instances {
+		since := instance.LaunchTime
+		if since.IsZero() {
+			since = now
+			if first, ok := previous[instance.ID]; ok {
+				since = first
+			}
+		}
+		current[instance.ID] = since
+	}
+
+	if len(current) == 0 {
+		delete(a.since, asgName)
+	} else {
+		a.since[asgName] = current
+	}
+}
+
+// oldest returns the oldest instance in service and not protected from scale-in that is older than maxAge
+func (a *instanceAges) oldest(asgName string, instances []Instance, maxAge time.Duration, now time.Time) (string, time.Duration, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var oldestID string
+	var oldestAge time.Duration
+	for _, instance := range instances {
+		since, ok := a.since[asgName][instance.ID]
+		if !ok || instance.Pending || instance.Protected {
+			continue
+		}
+		if age := now.Sub(since); age > maxAge && age > oldestAge {
+			oldestID, oldestAge = instance.ID, age
+		}
+	}
+	return oldestID, oldestAge, oldestID != ""
+}
+
+// forget drops an instance, e.g. after it was terminated
+func (a *instanceAges) forget(asgName, instanceID string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.since[asgName], instanceID)
+}
+
+// rename moves the instance ages of an ASG to its new name
+func (a *instanceAges) rename(oldName, newName string) bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	since, ok := a.since[oldName]
+	if !ok {
+		return false
+	}
+	a.since[newName] = since
+	delete(a.since, oldName)
+	return true
+}
+
+// recycleOldInstance terminates the oldest instance of an idle ASG older than max-instance-age without lowering
+// the desired capacity, so that the ASG launches a fresh one. At most one instance is recycled per cycle, and none
+// while instances are still launching, e.g. the replacement of the last one. Returns the terminated instance.
+func (o *Orchestrator) recycleOldInstance(ctx context.Context, asg config.Asg, provider Provider, launching int64) string {
+	instanceProvider, ok := provider.(InstanceProvider)
+	if !ok || launching > 0 {
+		return ""
+	}
+
+	instances := instanceProvider.Instances(asg.Name)
+	instanceID, age, ok := o.ages.oldest(asg.Name, instances, asg.MaxInstanceAge, o.now())
+	if !ok {
+		return ""
+	}
+	if err := instanceProvider.TerminateInstance(ctx, asg.Name, instanceID); err != nil {
+		slog.Error("Recycling instance failed", "asg", asg.Name, "instance", instanceID, "error", err)
+		return ""
+	}
+	o.ages.forget(asg.Name, instanceID)
+	slog.Info("Recycling instance: terminated, the ASG replaces it", "asg", asg.Name, "instance", instanceID,
+		"age", age.Round(time.Second), "max_instance_age", asg.MaxInstanceAge)
+	return instanceID
+}
@@ -0,0 +1,102 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_MaxInstanceAge verifies idle ASGs recycle instances older than max-instance-age one at a time.
+//
+// Conditions:
+// - ASG with tag ["amd64"], min 2, max 5, max-instance-age 168h, 2 of 2 allocated
+// - 0h, idle: i-1 launched 200h ago, i-2 without a launch time
+// - 0h+1m, idle: i-1 terminated, its replacement is launching
+// - 169h, 1 matching job running: i-2 and its replacement i-3 in service
+// - 169h+1m, idle
+//
+// Expected result:
+// - 0h: i-1 is terminated without lowering the desired capacity
+// - 0h+1m: nothing is recycled while the replacement launches
+// - 169h: i-2 is old enough, but the ASG is busy
+// - 169h+1m: i-2, counted from the first cycle that saw it, is terminated; i-3 is too young
+func TestScaleASGs_MaxInstanceAge(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MinAsgCapacity: 2, MaxAsgCapacity: 5, MaxInstanceAge: 168 * time.Hour}
+	orchestrator, cfg := newStuckTestOrchestrator(provider, asg)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{
+		{ID: "i-1", LaunchTime: start.Add(-200 * time.Hour)},
+		{ID: "i-2"},
+	}).Times(3)
+	provider.mockInstances.On("TerminateInstance", mock.Anything, "test-asg", "i-1").Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, "i-1", snapshot.ASGs[0].RecycledInstance)
+
+	clock = start.Add(time.Minute)
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(2), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-2"}}).Twice()
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Empty(t, snapshot.ASGs[0].RecycledInstance)
+
+	clock = start.Add(169 * time.Hour)
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil).Twice()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{{ID: "i-2"}, {ID: "i-3"}}).Times(5)
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 1},
+	})
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Empty(t, snapshot.ASGs[0].RecycledInstance)
+
+	clock = start.Add(169*time.Hour + time.Minute)
+	provider.mockInstances.On("TerminateInstance", mock.Anything, "test-asg", "i-2").Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, idleState())
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, "i-2", snapshot.ASGs[0].RecycledInstance)
+	assert.Equal(t, DecisionNone, snapshot.ASGs[0].Decision)
+	provider.MockProvider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	provider.MockProvider.AssertExpectations(t)
+	provider.mockInstances.AssertExpectations(t)
+}
+
+// TestInstanceAges_Oldest verifies pending and protected instances are never recycled
+func TestInstanceAges_Oldest(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	instances := []Instance{
+		{ID: "i-1", LaunchTime: now.Add(-10 * time.Hour), Protected: true},
+		{ID: "i-2", LaunchTime: now.Add(-9 * time.Hour), Pending: true},
+		{ID: "i-3", LaunchTime: now.Add(-3 * time.Hour)},
+		{ID: "i-4", LaunchTime: now.Add(-5 * time.Hour)},
+		{ID: "i-5", LaunchTime: now.Add(-time.Hour)},
+	}
+	var ages instanceAges
+	ages.observe("test-asg", instances, now)
+
+	instanceID, age, ok := ages.oldest("test-asg", instances, 2*time.Hour, now)
+	assert.True(t, ok)
+	assert.Equal(t, "i-4", instanceID)
+	assert.Equal(t, 5*time.Hour, age)
+
+	_, _, ok = ages.oldest("test-asg", instances, 6*time.Hour, now)
+	assert.False(t, ok)
+}
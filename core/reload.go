@@ -0,0 +1,47 @@
+package core
+
+import (
+	"fmt"
+	"time"
+)
+
+// configReloadComponent is the degraded component while the configuration on disk failed to reload
+const configReloadComponent = "config-reload"
+
+// ReloadStatus tells whether the configuration in use is the one on disk; a failed reload keeps the previous
+// configuration running, so it stays reported until a reload succeeds
+type ReloadStatus struct {
+	LoadedAt      time.Time `json:"loaded_at"`             // When the configuration in use was loaded
+	LoadedHash    string    `json:"loaded_hash,omitempty"` // Hash of the configuration file in use
+	LastAttemptAt time.Time `json:"last_attempt_at"`       // When the configuration file was last loaded or reloaded, successfully or not
+	Failed        bool      `json:"failed"`                // The last reload failed and the configuration on disk is not in use
+	LastError     string    `json:"last_error,omitempty"`  // Why the last reload failed
+	FailedHash    string    `json:"failed_hash,omitempty"` // Hash of the configuration file that failed to reload
+}
+
+// ConfigLoaded records that the configuration with the file hash is in use, clearing a failed reload
+func (o *Orchestrator) ConfigLoaded(at time.Time, hash string) {
+	o.mu.Lock()
+	o.reload = ReloadStatus{LoadedAt: at, LoadedHash: hash, LastAttemptAt: at}
+	o.mu.Unlock()
+	o.SetDegraded(configReloadComponent, nil)
+}
+
+// ReloadFailed records a failed reload of the configuration file with the hash and marks config-reload degraded
+// until the next ConfigLoaded
+func (o *Orchestrator) ReloadFailed(at time.Time, hash string, err error) {
+	o.mu.Lock()
+	o.reload.Failed = true
+	o.reload.LastAttemptAt = at
+	o.reload.LastError = err.Error()
+	o.reload.FailedHash = hash
+	o.mu.Unlock()
+	o.SetDegraded(configReloadComponent, fmt.Errorf("configuration on disk not applied, the previous one keeps running: %w", err))
+}
+
+// Reload returns the outcome of the configuration reloads
+func (o *Orchestrator) Reload() ReloadStatus {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	return o.reload
+}
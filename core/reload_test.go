@@ -0,0 +1,53 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestReloadStatus_FailedThenSuccessful verifies a failed reload stays reported until a reload succeeds.
+//
+// Conditions:
+// - The configuration with hash "a" is loaded at 09:00
+// - A reload of the file with hash "b" fails at 09:05, a cycle runs
+// - A reload of the file with hash "c" succeeds at 09:10, a cycle runs
+//
+// Expected result: after the failure the snapshot reports the failed reload with its error and hash, the
+// configuration in use is still "a" and config-reload is degraded; after the success the configuration in use is
+// "c", the failure is cleared and nothing is degraded
+func TestReloadStatus_FailedThenSuccessful(t *testing.T) {
+	orchestrator, cfg := newTestOrchestrator(&mocks.MockProvider{})
+	idle := gitlab.ClusterState{PendingJobsWithTags: map[string]int{}, RunningJobsWithTags: map[string]int{}}
+	loaded := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	orchestrator.ConfigLoaded(loaded, "a")
+
+	failedAt := loaded.Add(5 * time.Minute)
+	orchestrator.ReloadFailed(failedAt, "b", errors.New("config validation failed: check-interval must be positive"))
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, ReloadStatus{
+		LoadedAt:      loaded,
+		LoadedHash:    "a",
+		LastAttemptAt: failedAt,
+		Failed:        true,
+		LastError:     "config validation failed: check-interval must be positive",
+		FailedHash:    "b",
+	}, snapshot.Reload)
+	assert.Contains(t, snapshot.Degraded[configReloadComponent], "check-interval must be positive")
+
+	reloaded := loaded.Add(10 * time.Minute)
+	orchestrator.ConfigLoaded(reloaded, "c")
+	orchestrator.ScaleASGs(context.Background(), cfg, idle)
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, ReloadStatus{LoadedAt: reloaded, LoadedHash: "c", LastAttemptAt: reloaded}, snapshot.Reload)
+	assert.NotContains(t, snapshot.Degraded, configReloadComponent)
+}
@@ -0,0 +1,226 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/internal/replay"
+)
+
+// fixtureGroup is the hashed name of the group recorded in testdata/replay
+const fixtureGroup = "name-db74bfb2"
+
+// stubProvider serves fixed capacities and records capacity updates
+type stubProvider struct {
+	mu         sync.Mutex
+	capacities map[string][2]int64 // ASG name -> allocated, desired
+	updates    map[string]int64
+	updateErr  error // Returned by every capacity update when set
+}
+
+func (p *stubProvider) GetCurrentCapacity(_ context.Context, asgName string) (int64, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	capacity := p.capacities[asgName]
+	return capacity[0], capacity[1], nil
+}
+
+func (p *stubProvider) UpdateASGCapacity(_ context.Context, asgName string, capacity int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.updateErr != nil {
+		return p.updateErr
+	}
+	if p.updates == nil {
+		p.updates = make(map[string]int64)
+	}
+	p.updates[asgName] = capacity
+	return nil
+}
+
+// replayClient builds a GitLab client answering from the cassette at path. With
+// GITLAB_AUTOSCALER_RECORD=1 it records the group in GITLAB_GROUP with GITLAB_TOKEN instead,
+// hashing the group and any comma-separated GITLAB_RECORD_NAMES; returns the group to configure.
+func replayClient(t *testing.T, path string) (*gitlab.Client, string) {
+	t.Helper()
+	mode := replay.ModeFromEnv()
+	group, token := fixtureGroup, "replay"
+	var sanitizer replay.Sanitizer
+	if mode == replay.Record {
+		group, token = os.Getenv("GITLAB_GROUP"), os.Getenv("GITLAB_TOKEN")
+		require.NotEmpty(t, group, "recording needs GITLAB_GROUP")
+		require.NotEmpty(t, token, "recording needs GITLAB_TOKEN")
+		sanitizer.Names = append([]string{group}, strings.Split(os.Getenv("GITLAB_RECORD_NAMES"), ",")...)
+	}
+
+	transport, err := replay.New(path, mode, sanitizer)
+	require.NoError(t, err)
+	client, err := gitlab.NewClient(config.GitLabConfig{Group: group, Token: token}, nil)
+	require.NoError(t, err)
+	client.WrapTransport(transport.Wrap)
+	t.Cleanup(func() { require.NoError(t, transport.Save()) })
+	return client, group
+}
+
+// TestRun_Replay runs a whole cycle against recorded GitLab responses.
+//
+// Conditions:
+// - Group with 3 projects: api (3 pending amd64 jobs, one also tagged docker; 1 running amd64),
+// web (2 pending arm64) and infra (a pending amd64 bridge job; 1 running arm64)
+// - amd64 ASG at 1/1, arm64 ASG at 0/0, idle gpu ASG at 2/2
+//
+// Expected result:
+// - The bridge job is not counted: amd64 pending demand is 3, arm64 pending demand is 2
+// - amd64 scales up by its pending demand to 4 and arm64 to 2; idle gpu scales down one step to 1
+func TestRun_Replay(t *testing.T) {
+	client, group := replayClient(t, "testdata/replay/three_projects.json")
+	if replay.ModeFromEnv() == replay.Record {
+		cfg := &config.Config{GitLab: config.GitLabConfig{Group: group}}
+		Run(context.Background(), cfg, client, NewOrchestrator(nil, nil, nil, nil))
+		t.Skip("recorded testdata/replay/three_projects.json; review it, then run the test again without " + replay.RecordEnv)
+	}
+
+	provider := &stubProvider{capacities: map[string][2]int64{
+		"amd64-runners": {1, 1},
+		"arm64-runners": {0, 0},
+		"gpu-runners":   {2, 2},
+	}}
+	asgs := []config.Asg{
+		{Name: "amd64-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 10},
+		{Name: "arm64-runners", Tags: []string{"arm64"}, MaxAsgCapacity: 5},
+		{Name: "gpu-runners", Tags: []string{"gpu"}, MaxAsgCapacity: 3},
+	}
+	asgToProvider := make(map[string]string)
+	for _, asg := range asgs {
+		asgToProvider[asg.Name] = "aws"
+	}
+	cfg := &config.Config{
+		GitLab:     config.GitLabConfig{Group: group},
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: asgs}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, asgToProvider, nil, nil)
+
+	Run(context.Background(), cfg, client, orchestrator)
+
+	assert.Equal(t, map[string]int64{"amd64-runners": 4, "arm64-runners": 2, "gpu-runners": 1}, provider.updates)
+
+	snapshot, ok := orchestrator.Snapshot()
+	require.True(t, ok)
+	decisions := make(map[string]Decision)
+	for _, status := range snapshot.ASGs {
+		decisions[status.Name] = status.Decision
+	}
+	assert.Equal(t, map[string]Decision{
+		"amd64-runners": DecisionScaleUp,
+		"arm64-runners": DecisionScaleUp,
+		"gpu-runners":   DecisionScaleDown,
+	}, decisions)
+	assert.Equal(t, map[string]int{"amd64": 3, "arm64": 2, "docker": 1}, snapshot.State.PendingJobsWithTags)
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// unreachableClient returns a GitLab client whose requests all fail with connection refused
+func unreachableClient(t *testing.T) *gitlab.Client {
+	t.Helper()
+	client, err := gitlab.NewClient(config.GitLabConfig{Group: "group", Token: "token"}, nil)
+	require.NoError(t, err)
+	client.WrapTransport(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") })
+	})
+	return client
+}
+
+// TestRun_Errors verifies a cycle reports what failed, as --once exits with it
+//
+// Conditions:
+// - GitLab unreachable
+// - The recorded group of TestRun_Replay with a provider failing every capacity update
+//
+// Expected result:
+// - Without GitLab the cycle fails fetching the projects and no capacity is read
+// - With the failing provider the cycle fails with each ASG that could not be scaled
+func TestRun_Errors(t *testing.T) {
+	client := unreachableClient(t)
+	provider := &stubProvider{}
+	cfg := &config.Config{
+		GitLab:     config.GitLabConfig{Group: "group"},
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{{Name: "amd64-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 10}}}},
+	}
+	err := Run(context.Background(), cfg, client, NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"amd64-runners": "aws"}, nil, nil))
+	assert.ErrorContains(t, err, "failed to fetch projects")
+	assert.ErrorContains(t, err, "connection refused")
+
+	if replay.ModeFromEnv() == replay.Record {
+		t.Skip("nothing to record")
+	}
+	replayed, group := replayClient(t, "testdata/replay/three_projects.json")
+	cfg.GitLab.Group = group
+	provider = &stubProvider{
+		capacities: map[string][2]int64{"amd64-runners": {1, 1}},
+		updateErr:  errors.New("access denied"),
+	}
+	err = Run(context.Background(), cfg, replayed, NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"amd64-runners": "aws"}, nil, nil))
+	assert.ErrorContains(t, err, "ASG amd64-runners: scale-up failed: ")
+	assert.ErrorContains(t, err, "access denied")
+}
+
+// TestRun_CleanupOfflineRunnersClock verifies the age of offline runners is taken from the clock of the orchestrator
+//
+// Conditions:
+// - Group without projects; cleanup-offline-runners with offline-runner-max-age 1h
+// - Runner #5 with tag "amd64", offline and last contacted 2h before the clock of the orchestrator, which is ahead
+// of the wall clock
+//
+// Expected result: runner #5 is deleted
+func TestRun_CleanupOfflineRunnersClock(t *testing.T) {
+	now := time.Date(2100, 1, 1, 9, 0, 0, 0, time.UTC)
+	var deleted []string
+	client, err := gitlab.NewClient(config.GitLabConfig{Group: "group", Token: "token"}, nil)
+	require.NoError(t, err)
+	client.WrapTransport(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			body := "[]"
+			switch {
+			case req.Method == http.MethodDelete:
+				deleted = append(deleted, req.URL.Path)
+				body = ""
+			case req.URL.Path == "/api/v4/groups/group/runners":
+				body = `[{"id":5,"status":"offline"}]`
+			case req.URL.Path == "/api/v4/runners/5":
+				body = fmt.Sprintf(`{"id":5,"status":"offline","contacted_at":%q}`, now.Add(-2*time.Hour).Format(time.RFC3339))
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body)), Header: http.Header{}, Request: req}, nil
+		})
+	})
+
+	cfg := &config.Config{
+		GitLab:     config.GitLabConfig{Group: "group", CleanupOfflineRunners: true, OfflineRunnerMaxAge: time.Hour},
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{{Name: "amd64-runners", Tags: []string{"amd64"}, MaxAsgCapacity: 10}}}},
+	}
+	provider := &stubProvider{capacities: map[string][2]int64{"amd64-runners": {1, 1}}}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{"amd64-runners": "aws"}, nil, nil)
+	orchestrator.now = func() time.Time { return now }
+
+	require.NoError(t, Run(context.Background(), cfg, client, orchestrator))
+	assert.Equal(t, []string{"/api/v4/runners/5"}, deleted)
+}
@@ -0,0 +1,38 @@
+package core
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// ScaleReasonRecorder is implemented by providers that can record on the ASG itself why the autoscaler last scaled
+// it up, e.g. as tags for cost attribution
+type ScaleReasonRecorder interface {
+	// RecordScaleReason records the reason of a scale-up applied at the given time, e.g. "pending-jobs=7"
+	RecordScaleReason(ctx context.Context, asgName, reason string, at time.Time) error
+}
+
+// recordScaleReason records the reason of an applied scale-up with providers that support it; it is best-effort,
+// failures are only logged
+func (o *Orchestrator) recordScaleReason(ctx context.Context, provider Provider, asgName, reason string) {
+	recorder, ok := provider.(ScaleReasonRecorder)
+	if !ok {
+		return
+	}
+	if err := recorder.RecordScaleReason(ctx, asgName, reason, o.now()); err != nil {
+		slog.Warn("Recording the scale-up reason failed", "asg", asgName, "error", err)
+	}
+}
+
+// floorScaleReason is the scale-up reason recorded for a scale-up to the capacity floor
+func floorScaleReason(floorReason string, floor int64) string {
+	switch floorReason {
+	case floorWarmSlots:
+		return fmt.Sprintf("warm-slots=%d", floor)
+	case floorPrescale:
+		return fmt.Sprintf("predictive-prescale=%d", floor)
+	}
+	return fmt.Sprintf("min-capacity=%d", floor)
+}
@@ -0,0 +1,62 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// reasonProvider is a mocked provider that also records scale-up reasons
+type reasonProvider struct {
+	*mocks.MockProvider
+}
+
+func (p reasonProvider) RecordScaleReason(ctx context.Context, asgName, reason string, at time.Time) error {
+	return p.Called(ctx, asgName, reason, at).Error(0)
+}
+
+// TestScaleASGs_RecordScaleReason verifies the reason of an applied scale-up is recorded with the provider.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, 3 pending jobs every cycle
+// - Cycle 1: recording the reason succeeds
+// - Cycle 2: recording the reason fails
+//
+// Expected result:
+// - Both cycles record "pending-jobs=3" and apply the scale-up to 3, a failed recording does not fail it
+func TestScaleASGs_RecordScaleReason(t *testing.T) {
+	provider := reasonProvider{&mocks.MockProvider{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil)
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Twice()
+	provider.On("RecordScaleReason", mock.Anything, "test-asg", "pending-jobs=3", mock.Anything).Return(nil).Once()
+	provider.On("RecordScaleReason", mock.Anything, "test-asg", "pending-jobs=3", mock.Anything).
+		Return(errors.New("access denied")).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision)
+	provider.AssertExpectations(t)
+}
+
+// TestFloorScaleReason verifies scale-ups to the capacity floor are recorded with what set the floor
+func TestFloorScaleReason(t *testing.T) {
+	assert.Equal(t, "warm-slots=2", floorScaleReason(floorWarmSlots, 2))
+	assert.Equal(t, "predictive-prescale=4", floorScaleReason(floorPrescale, 4))
+	assert.Equal(t, "min-capacity=3", floorScaleReason(floorMinimum, 3))
+}
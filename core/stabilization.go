@@ -0,0 +1,51 @@
+package core
+
+import "sync"
+
+// streakCounter counts, per ASG, the consecutive cycles in which a condition was observed
+type streakCounter struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// observe records one more consecutive observation for the ASG and returns the streak length
+func (s *streakCounter) observe(asgName string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.counts == nil {
+		s.counts = make(map[string]int)
+	}
+	s.counts[asgName]++
+	return s.counts[asgName]
+}
+
+// reset ends the streak of the ASG
+func (s *streakCounter) reset(asgName string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.counts, asgName)
+}
+
+// rename moves the streak of an ASG to its new name
+func (s *streakCounter) rename(oldName, newName string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	count, ok := s.counts[oldName]
+	if !ok {
+		return false
+	}
+	s.counts[newName] = count
+	delete(s.counts, oldName)
+	return true
+}
+
+// retain ends the streaks of every key keep reports false for
+func (s *streakCounter) retain(keep func(key string) bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for key := range s.counts {
+		if !keep(key) {
+			delete(s.counts, key)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package core
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// pendingState returns a cluster state with the given number of pending "amd64" jobs
+func pendingState(pending int) gitlab.ClusterState {
+	return gitlab.ClusterState{
+		TotalPendingJobs:    int64(pending),
+		PendingJobsWithTags: map[string]int{"amd64": pending},
+		RunningJobsWithTags: map[string]int{},
+	}
+}
+
+// TestScaleASGs_ScaleUpStabilization verifies scale-up waits for the shortfall to persist.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, scale-up-stabilization 3
+// - Cycles: 3 pending, 3 pending, 0 pending (streak reset), 3 pending x3
+//
+// Expected result: a single scale-up to 3, in the last cycle
+func TestScaleASGs_ScaleUpStabilization(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.ScaleUpStabilization = 3
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Once()
+
+	for i, pending := range []int{3, 3, 0, 3, 3} {
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(pending))
+		if len(provider.Calls) > i+1 {
+			t.Fatalf("cycle %d: unexpected scale-up", i+1)
+		}
+	}
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ScaleUpStabilizationAggressive verifies an exceeded wait target bypasses stabilization.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, scale-up-stabilization 3, target-max-wait 1m
+// - 3 pending "amd64" jobs, the oldest waiting 2m
+//
+// Expected result: scale-up to 3 in the first cycle
+func TestScaleASGs_ScaleUpStabilizationAggressive(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, TargetMaxWait: time.Minute}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.ScaleUpStabilization = 3
+
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	orchestrator.now = func() time.Time { return now }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil)
+
+	state := pendingState(3)
+	state.OldestPendingJobWithTags = map[string]time.Time{"amd64": now.Add(-2 * time.Minute)}
+	orchestrator.ScaleASGs(context.Background(), cfg, state)
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ScaleUpStabilizationConservative verifies jobs well within their wait target stabilize one more cycle.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, scale-up-stabilization 2, target-max-wait 2m
+// - 3 pending "amd64" jobs in every cycle, the oldest waiting 10s
+//
+// Expected result: no scale-up in the first two cycles, scale-up to 3 in the third
+func TestScaleASGs_ScaleUpStabilizationConservative(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, TargetMaxWait: 2 * time.Minute}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.ScaleUpStabilization = 2
+
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	orchestrator.now = func() time.Time { return now }
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Once()
+
+	state := pendingState(3)
+	state.OldestPendingJobWithTags = map[string]time.Time{"amd64": now.Add(-10 * time.Second)}
+	for i := range 2 {
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+		if len(provider.Calls) > i+1 {
+			t.Fatalf("cycle %d: unexpected scale-up", i+1)
+		}
+	}
+	orchestrator.ScaleASGs(context.Background(), cfg, state)
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_ScaleDownIdleCycles verifies scale-down waits for consecutive idle cycles.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 2 allocated instances, scale-down-idle-cycles 3
+// - Cycles: idle, idle, 1 running job (streak reset), idle, reload of an unrelated field, idle, idle
+//
+// Expected result: a single scale-down to 1, in the last cycle
+func TestScaleASGs_ScaleDownIdleCycles(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	cfg.Autoscaler.ScaleDownIdleCycles = 3
+
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(2), int64(2), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(1)).Return(nil).Once()
+
+	running := gitlab.ClusterState{
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{"amd64": 1},
+	}
+	cycles := []gitlab.ClusterState{pendingState(0), pendingState(0), running, pendingState(0)}
+	for i, state := range cycles {
+		orchestrator.ScaleASGs(context.Background(), cfg, state)
+		if len(provider.Calls) > i+1 {
+			t.Fatalf("cycle %d: unexpected scale-down", i+1)
+		}
+	}
+
+	cfg.Autoscaler.CheckInterval = 30
+	orchestrator.SetProviders(map[string]Provider{"aws": provider}, map[string]string{"test-asg": "aws"})
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+	if len(provider.Calls) > len(cycles)+1 {
+		t.Fatalf("unexpected scale-down right after reload")
+	}
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(0))
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleDownIdleCycles verifies the ASG setting overrides the global one
+func TestScaleDownIdleCycles(t *testing.T) {
+	settings := config.AutoscalerConfig{ScaleDownIdleCycles: 3}
+
+	if got := scaleDownIdleCycles(config.Asg{}, settings); got != 3 {
+		t.Errorf("expected global value 3, got %d", got)
+	}
+	if got := scaleDownIdleCycles(config.Asg{ScaleDownIdleCycles: 5}, settings); got != 5 {
+		t.Errorf("expected ASG override 5, got %d", got)
+	}
+}
@@ -0,0 +1,131 @@
+package core
+
+import (
+	"maps"
+	"strings"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// Decision is the outcome of evaluating a single ASG in a cycle; it is part of the public status API
+type Decision = api.Decision
+
+const (
+	DecisionNone      = api.DecisionNone
+	DecisionScaleUp   = api.DecisionScaleUp
+	DecisionScaleDown = api.DecisionScaleDown
+	DecisionError     = api.DecisionError
+	DecisionBackedOff = api.DecisionBackedOff
+	DecisionBlackout  = api.DecisionBlackout
+	DecisionActivity  = api.DecisionActivity
+	DecisionDryRun    = api.DecisionDryRun
+)
+
+// ASGStatus is the last observed capacity of an ASG together with the scaling decision and its reason;
+// it is part of the public status API
+type ASGStatus = api.ASGStatus
+
+// Snapshot is the view of the last completed cycle, published by the orchestrator for status endpoints
+type Snapshot struct {
+	Timestamp time.Time           `json:"timestamp"`
+	State     gitlab.ClusterState `json:"state"`
+	ASGs      []ASGStatus         `json:"asgs"`
+	// BlockedCapacity holds the fleet-wide instances needed but blocked, per reason
+	BlockedCapacity map[string]int64 `json:"blocked_capacity,omitempty"`
+	// Degraded holds the components running in a degraded mode with the reason, e.g. an unavailable admin listener
+	Degraded map[string]string `json:"degraded,omitempty"`
+	// StuckQueues holds the tags pending without a scale-up for stuck-queue-cycles, with their diagnosis
+	StuckQueues []StuckQueue `json:"stuck_queues,omitempty"`
+	// Reload tells whether the configuration on disk is the one in use
+	Reload ReloadStatus `json:"reload"`
+	// Timings breaks down how long the cycle took from its tick
+	Timings CycleTimings `json:"timings"`
+}
+
+// Snapshot returns the view of the last completed cycle; false until the first cycle completes.
+// It is safe to call while a cycle is running.
+func (o *Orchestrator) Snapshot() (Snapshot, bool) {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if o.snapshot == nil {
+		return Snapshot{}, false
+	}
+	return *o.snapshot, true
+}
+
+// Subscribe registers a function called with the snapshot of every completed cycle
+func (o *Orchestrator) Subscribe(subscriber func(Snapshot)) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.subscribers = append(o.subscribers, subscriber)
+}
+
+// SetDegraded marks a component as degraded with the error that caused it, or clears it when err is nil.
+// Degraded components are logged every cycle and published in the snapshot.
+func (o *Orchestrator) SetDegraded(component string, err error) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	if err == nil {
+		delete(o.degraded, component)
+		return
+	}
+	if o.degraded == nil {
+		o.degraded = make(map[string]string)
+	}
+	o.degraded[component] = err.Error()
+}
+
+// writeCredentialsComponent is the degraded component of a provider whose capacity changes are unavailable
+const writeCredentialsComponent = "-write-credentials"
+
+// checkWriteAccess marks every provider whose capacity changes are unavailable as degraded and clears the
+// providers that recovered or were removed, so ASGs keep being monitored while updates fail
+func (o *Orchestrator) checkWriteAccess() {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	for component := range o.degraded {
+		if name, ok := strings.CutSuffix(component, writeCredentialsComponent); ok {
+			if _, exists := o.providers[name]; !exists {
+				delete(o.degraded, component)
+			}
+		}
+	}
+	for name, provider := range o.providers {
+		checker, ok := provider.(WriteChecker)
+		if !ok {
+			continue
+		}
+		if err := checker.WriteError(); err != nil {
+			if o.degraded == nil {
+				o.degraded = make(map[string]string)
+			}
+			o.degraded[name+writeCredentialsComponent] = err.Error()
+		} else {
+			delete(o.degraded, name+writeCredentialsComponent)
+		}
+	}
+}
+
+// degradedComponents returns a copy of the degraded components, or nil when there are none
+func (o *Orchestrator) degradedComponents() map[string]string {
+	o.mu.RLock()
+	defer o.mu.RUnlock()
+	if len(o.degraded) == 0 {
+		return nil
+	}
+	return maps.Clone(o.degraded)
+}
+
+// publishSnapshot atomically replaces the published view of the last cycle and notifies subscribers
+func (o *Orchestrator) publishSnapshot(snapshot Snapshot) {
+	o.mu.Lock()
+	o.snapshot = &snapshot
+	subscribers := o.subscribers
+	o.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber(snapshot)
+	}
+}
@@ -0,0 +1,106 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// pendingInstances remembers since when the instances of each ASG are pending, for pending-timeout
+type pendingInstances struct {
+	mu    sync.Mutex
+	since map[string]map[string]time.Time // ASG name -> instance ID -> pending since
+}
+
+// stuck returns the IDs of the instances pending longer than timeout. Instances without a launch time
+// are counted from the first call that saw them pending; instances no longer pending are forgotten.
+func (p *pendingInstances) stuck(asgName string, instances []Instance, timeout time.Duration, now time.Time) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.since == nil {
+		p.since = make(map[string]map[string]time.Time)
+	}
+
+	previous := p.since[asgName]
+	current := make(map[string]time.Time)
+	var stuck []string
+	for _, instance := range instances {
+		if !instance.Pending {
+			continue
+		}
+		since := instance.LaunchTime
+		if since.IsZero() {
+			since = now
+			if first, ok := previous[instance.ID]; ok {
+				since = first
+			}
+		}
+		current[instance.ID] = since
+		if now.Sub(since) > timeout {
+			stuck = append(stuck, instance.ID)
+		}
+	}
+
+	if len(current) == 0 {
+		delete(p.since, asgName)
+	} else {
+		p.since[asgName] = current
+	}
+	return stuck
+}
+
+// forget drops an instance, e.g. after it was terminated
+func (p *pendingInstances) forget(asgName, instanceID string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.since[asgName], instanceID)
+}
+
+// rename moves the pending instances of an ASG to its new name
+func (p *pendingInstances) rename(oldName, newName string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	since, ok := p.since[oldName]
+	if !ok {
+		return false
+	}
+	p.since[newName] = since
+	delete(p.since, oldName)
+	return true
+}
+
+// checkStuckInstances finds the instances of the ASG stuck in a pending state longer than pending-timeout
+// and, with replace-stuck-instances, terminates them. Returns how many are stuck and how many of them
+// still hold a slot of the desired capacity (not terminated), both to be left out of the allocated count.
+func (o *Orchestrator) checkStuckInstances(ctx context.Context, asg config.Asg, provider Provider, settings config.AutoscalerConfig) (int64, int64) {
+	instanceProvider, ok := provider.(InstanceProvider)
+	if !ok {
+		return 0, 0
+	}
+
+	timeout := settings.EffectivePendingTimeout()
+	stuck := o.pending.stuck(asg.Name, instanceProvider.Instances(asg.Name), timeout, o.now())
+	if len(stuck) == 0 {
+		return 0, 0
+	}
+	slog.Error("Stuck instances: not counted as allocated", "asg", asg.Name, "pending_longer_than", timeout,
+		"instances", stuck)
+
+	held := int64(len(stuck))
+	if !settings.ReplaceStuckInstances {
+		return held, held
+	}
+	for _, instanceID := range stuck {
+		if err := instanceProvider.TerminateInstance(ctx, asg.Name, instanceID); err != nil {
+			slog.Error("Replacing stuck instance failed", "asg", asg.Name, "instance", instanceID, "error", err)
+			continue
+		}
+		o.pending.forget(asg.Name, instanceID)
+		held--
+		slog.Info("Replacing stuck instance: terminated", "asg", asg.Name, "instance", instanceID)
+	}
+	return int64(len(stuck)), held
+}
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// mockInstances mocks InstanceProvider and InstanceProtector; it is written by hand because a generated mock would import core
+type mockInstances struct {
+	mock.Mock
+}
+
+func (m *mockInstances) Instances(asgName string) []Instance {
+	return m.Called(asgName).Get(0).([]Instance)
+}
+
+func (m *mockInstances) TerminateInstance(ctx context.Context, asgName, instanceID string) error {
+	return m.Called(ctx, asgName, instanceID).Error(0)
+}
+
+func (m *mockInstances) SetInstanceProtection(ctx context.Context, asgName string, instanceIDs []string, protected bool) error {
+	return m.Called(ctx, asgName, instanceIDs, protected).Error(0)
+}
+
+// instanceProvider is a mocked provider that also reports and protects the instances of its ASGs
+type instanceProvider struct {
+	*mocks.MockProvider
+	*mockInstances
+}
+
+// newStuckTestOrchestrator builds an orchestrator whose single "aws" provider reports instances
+func newStuckTestOrchestrator(provider instanceProvider, asg config.Asg) (*Orchestrator, config.Config) {
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers: map[string]config.ProviderConfig{
+			"aws": {AsgNames: []config.Asg{asg}},
+		},
+	}
+	return NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil), cfg
+}
+
+// TestScaleASGs_StuckInstancesExcluded verifies instances pending longer than pending-timeout are not counted as allocated.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, default pending-timeout (15m), 3 pending jobs every cycle
+// - 0m: 3 of 3 allocated; i-2 pending since its launch 20m ago, i-3 pending without a launch time
+// - 16m: 4 of 4 allocated; i-2 and i-3 still pending
+//
+// Expected result:
+// - 0m: only i-2 is stuck; 2 allocated serve 2 jobs and the stuck instance keeps its slot, so desired rises to 4
+// - 16m: i-3 is stuck too, counted from the first cycle that saw it; desired rises to 5
+func TestScaleASGs_StuckInstancesExcluded(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10}
+	orchestrator, cfg := newStuckTestOrchestrator(provider, asg)
+
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(3), int64(3), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{
+		{ID: "i-1"},
+		{ID: "i-2", Pending: true, LaunchTime: start.Add(-20 * time.Minute)},
+		{ID: "i-3", Pending: true},
+	}).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(4)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, int64(1), snapshot.ASGs[0].StuckInstances)
+	assert.Equal(t, int64(2), snapshot.ASGs[0].Allocated)
+
+	clock = start.Add(16 * time.Minute)
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(4), int64(4), nil).Once()
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{
+		{ID: "i-1"},
+		{ID: "i-2", Pending: true, LaunchTime: start.Add(-20 * time.Minute)},
+		{ID: "i-3", Pending: true},
+		{ID: "i-4"},
+	}).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(5)).Return(nil).Once()
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ = orchestrator.Snapshot()
+	assert.Equal(t, DecisionScaleUp, snapshot.ASGs[0].Decision)
+	assert.Equal(t, int64(2), snapshot.ASGs[0].StuckInstances)
+	provider.MockProvider.AssertExpectations(t)
+	provider.mockInstances.AssertExpectations(t)
+}
+
+// TestScaleASGs_ReplaceStuckInstances verifies stuck instances are terminated with replace-stuck-instances.
+//
+// Conditions:
+// - ASG with tag ["amd64"], max 10, pending-timeout 5m, replace-stuck-instances, 3 pending jobs
+// - 3 of 3 allocated; i-2 and i-3 pending for 10m
+// - Terminating i-2 succeeds, terminating i-3 fails
+//
+// Expected result:
+// - Both are left out of allocated; the replacement of i-2 takes its slot, i-3 keeps its own
+// - 1 allocated serves 1 job, so desired rises to 1 + 1 (i-3) + 2 = 4
+func TestScaleASGs_ReplaceStuckInstances(t *testing.T) {
+	provider := instanceProvider{&mocks.MockProvider{}, &mockInstances{}}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 10}
+	orchestrator, cfg := newStuckTestOrchestrator(provider, asg)
+	cfg.Autoscaler.PendingTimeout = 5 * time.Minute
+	cfg.Autoscaler.ReplaceStuckInstances = true
+
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	orchestrator.now = func() time.Time { return now }
+
+	provider.MockProvider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(3), int64(3), nil)
+	provider.mockInstances.On("Instances", "test-asg").Return([]Instance{
+		{ID: "i-1"},
+		{ID: "i-2", Pending: true, LaunchTime: now.Add(-10 * time.Minute)},
+		{ID: "i-3", Pending: true, LaunchTime: now.Add(-10 * time.Minute)},
+	})
+	provider.mockInstances.On("TerminateInstance", mock.Anything, "test-asg", "i-2").Return(nil).Once()
+	provider.mockInstances.On("TerminateInstance", mock.Anything, "test-asg", "i-3").Return(errors.New("throttled")).Once()
+	provider.MockProvider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(4)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, int64(2), snapshot.ASGs[0].StuckInstances)
+	assert.Equal(t, int64(1), snapshot.ASGs[0].Allocated)
+	provider.MockProvider.AssertExpectations(t)
+	provider.mockInstances.AssertExpectations(t)
+}
+
+// TestPendingInstances_Stuck verifies pending time is tracked per instance
+// Expected behavior:
+//   - An instance without launch time is counted from the first call seeing it pending
+//   - An instance that went in service is forgotten, so pending again starts a new count
+//   - rename keeps the tracked instances
+func TestPendingInstances_Stuck(t *testing.T) {
+	var pending pendingInstances
+	start := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	timeout := 15 * time.Minute
+
+	assert.Empty(t, pending.stuck("asg", []Instance{{ID: "i-1", Pending: true}}, timeout, start))
+	assert.Equal(t, []string{"i-1"}, pending.stuck("asg", []Instance{{ID: "i-1", Pending: true}}, timeout, start.Add(16*time.Minute)))
+
+	assert.Empty(t, pending.stuck("asg", []Instance{{ID: "i-1"}}, timeout, start.Add(17*time.Minute)))
+	assert.Empty(t, pending.stuck("asg", []Instance{{ID: "i-1", Pending: true}}, timeout, start.Add(18*time.Minute)))
+
+	assert.True(t, pending.rename("asg", "asg-v2"))
+	assert.Equal(t, []string{"i-1"}, pending.stuck("asg-v2", []Instance{{ID: "i-1", Pending: true}}, timeout, start.Add(34*time.Minute)))
+}
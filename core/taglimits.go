@@ -0,0 +1,126 @@
+package core
+
+import (
+	"maps"
+	"slices"
+	"sort"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// tagShare is the part of the pending jobs of a limited tag attributed to one ASG
+type tagShare struct {
+	counted int64 // Pending jobs counted as demand of the ASG
+	limited int64 // Pending jobs left out because the tag limit is reached
+}
+
+// tagLimitShares splits the pending jobs of every limited tag among the ASGs serving it.
+//
+// A limit is in instances. It is split among the serving ASGs in proportion to their max-asg-capacity and
+// each part converted to job slots with the jobs-per-instance of its ASG; at most those slots minus the
+// running jobs of the tag are counted fleet-wide, the rest is limited. Since an ASG serving several tags
+// shares its capacity between them, a tag is not attributed whole ASGs: both parts are split among the
+// serving ASGs in proportion to their max-asg-capacity, largest remainder first and ties broken by name.
+// Returns ASG name -> tag -> share.
+func tagLimitShares(asgs []config.Asg, limits map[string]int64, state gitlab.ClusterState) map[string]map[string]tagShare {
+	shares := make(map[string]map[string]tagShare)
+	for tag, limit := range limits {
+		var serving []config.Asg
+		for _, asg := range asgs {
+			if slices.Contains(asg.Tags, tag) {
+				serving = append(serving, asg)
+			}
+		}
+		if len(serving) == 0 {
+			continue
+		}
+		sort.Slice(serving, func(i, j int) bool { return serving[i].Name < serving[j].Name })
+
+		weights := make([]int64, len(serving))
+		for i, asg := range serving {
+			weights[i] = asg.MaxAsgCapacity
+		}
+		var slots int64
+		for i, instances := range splitProportionally(limit, weights) {
+			slots += instances * serving[i].EffectiveJobsPerInstance()
+		}
+
+		pending := int64(state.PendingJobsWithTags[tag])
+		counted := min(pending, max(slots-int64(state.RunningJobsWithTags[tag]), 0))
+		countedParts := splitProportionally(counted, weights)
+		limitedParts := splitProportionally(pending-counted, weights)
+
+		for i, asg := range serving {
+			if shares[asg.Name] == nil {
+				shares[asg.Name] = make(map[string]tagShare)
+			}
+			shares[asg.Name][tag] = tagShare{counted: countedParts[i], limited: limitedParts[i]}
+		}
+	}
+	return shares
+}
+
+// splitProportionally splits total into parts proportional to weights using the largest remainder method;
+// equal weights are used when all weights are zero
+func splitProportionally(total int64, weights []int64) []int64 {
+	var sum int64
+	for _, weight := range weights {
+		sum += weight
+	}
+	if sum == 0 {
+		weights = slices.Repeat([]int64{1}, len(weights))
+		sum = int64(len(weights))
+	}
+
+	parts := make([]int64, len(weights))
+	remainders := make([]int, len(weights))
+	assigned := int64(0)
+	for i, weight := range weights {
+		parts[i] = total * weight / sum
+		assigned += parts[i]
+		remainders[i] = i
+	}
+	sort.SliceStable(remainders, func(a, b int) bool {
+		return total*weights[remainders[a]]%sum > total*weights[remainders[b]]%sum
+	})
+	for i := int64(0); i < total-assigned; i++ {
+		parts[remainders[i]]++
+	}
+	return parts
+}
+
+// applyTagLimits replaces the pending counts of limited tags in the state of an ASG with its share
+// and returns the pending jobs left out
+func applyTagLimits(state *gitlab.ClusterState, shares map[string]tagShare) int64 {
+	if len(shares) == 0 {
+		return 0
+	}
+	counts := maps.Clone(state.PendingJobsWithTags)
+	if counts == nil {
+		counts = make(map[string]int)
+	}
+	var limited int64
+	for tag, share := range shares {
+		counted := min(int64(counts[tag]), share.counted)
+		setPendingShare(state, tag, int64(counts[tag]), counted)
+		counts[tag] = int(counted)
+		limited += share.limited
+	}
+	state.PendingJobsWithTags = counts
+	return limited
+}
+
+// setPendingShare records in the state of an ASG that it counts part of the total pending jobs of tag, so that
+// the corrections of single jobs of the tag, e.g. of their size, are scaled alike
+func setPendingShare(state *gitlab.ClusterState, tag string, total, part int64) {
+	if total <= 0 || part >= total {
+		return
+	}
+	shares := maps.Clone(state.PendingTagShares)
+	if shares == nil {
+		shares = make(map[string]float64)
+	}
+	shares[tag] = float64(part) / float64(total)
+	state.PendingTagShares = shares
+}
@@ -0,0 +1,163 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_TagLimitAcrossASGs verifies a tag limit caps the demand of all ASGs serving the tag together.
+//
+// Conditions:
+// - ASGs "gpu-a" and "gpu-b" with tag ["gpu"], max 10 each, both empty
+// - tag-limits gpu: 4; 1 running and 6 pending "gpu" jobs
+//
+// Expected result: 3 pending jobs counted, split 2/1 (tie broken by name); "gpu-a" scales to 2, "gpu-b" to 1;
+// the 3 jobs left out are reported as blocked "tag-limit" fleet-wide
+func TestScaleASGs_TagLimitAcrossASGs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "gpu-a", Tags: []string{"gpu"}, MaxAsgCapacity: 10, ScaleToZero: true},
+		config.Asg{Name: "gpu-b", Tags: []string{"gpu"}, MaxAsgCapacity: 10, ScaleToZero: true})
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 4}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-a").Return(int64(0), int64(0), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-b").Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu-a", int64(2)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu-b", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    6,
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{"gpu": 6},
+		RunningJobsWithTags: map[string]int{"gpu": 1},
+	})
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTagLimit: 3}, snapshot.BlockedCapacity)
+}
+
+// TestScaleASGs_TagLimitSharedASG verifies the proportional attribution for an ASG whose capacity is shared by several tags.
+//
+// Conditions:
+// - ASG "shared" with tags ["amd64", "gpu"], max 6; ASG "gpu-only" with tag ["gpu"], max 2; both empty
+// - tag-limits gpu: 4; 8 pending "gpu" and 2 pending "amd64" jobs
+//
+// Expected result: the 4 counted "gpu" jobs are split 3/1 by max capacity, so "shared" scales to 2 + 3 = 5
+// and "gpu-only" to 1; "amd64" demand is not limited
+func TestScaleASGs_TagLimitSharedASG(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "shared", Tags: []string{"amd64", "gpu"}, MaxAsgCapacity: 6, ScaleToZero: true},
+		config.Asg{Name: "gpu-only", Tags: []string{"gpu"}, MaxAsgCapacity: 2, ScaleToZero: true})
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 4}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "shared").Return(int64(0), int64(0), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-only").Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "shared", int64(5)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu-only", int64(1)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    10,
+		PendingJobsWithTags: map[string]int{"gpu": 8, "amd64": 2},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTagLimit: 4}, snapshot.BlockedCapacity)
+}
+
+// TestScaleASGs_TagLimitReachedByRunningJobs verifies no capacity is added once running jobs reach the limit
+func TestScaleASGs_TagLimitReachedByRunningJobs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "gpu-a", Tags: []string{"gpu"}, MaxAsgCapacity: 10})
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 4}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-a").Return(int64(4), int64(4), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		TotalRunningJobs:    4,
+		PendingJobsWithTags: map[string]int{"gpu": 3},
+		RunningJobsWithTags: map[string]int{"gpu": 4},
+	})
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "gpu-a", int64(7))
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTagLimit: 3}, snapshot.ASGs[0].Blocked)
+}
+
+// TestScaleASGs_TagLimitJobsPerInstance verifies a tag limit counts instances, not jobs.
+//
+// Conditions:
+// - ASG "gpu-a" with tag ["gpu"], jobs-per-instance 4, max 10, empty; tag-limits gpu: 4
+// - 20 pending "gpu" jobs
+//
+// Expected result: 16 jobs counted, the slots of 4 instances; "gpu-a" scales to 4 and 4 jobs are blocked by the limit
+func TestScaleASGs_TagLimitJobsPerInstance(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "gpu-a", Tags: []string{"gpu"}, JobsPerInstance: 4, MaxAsgCapacity: 10, ScaleToZero: true})
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 4}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-a").Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "gpu-a", int64(4)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    20,
+		PendingJobsWithTags: map[string]int{"gpu": 20},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	provider.AssertExpectations(t)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTagLimit: 4}, snapshot.ASGs[0].Blocked)
+}
+
+// TestScaleASGs_TagLimitSizedJobs verifies jobs left out by a tag limit add no demand through their size.
+//
+// Conditions:
+// - ASG "gpu-a" with tag ["gpu"], max 10, empty; tag-limits gpu: 1
+// - 1 running "gpu" job; 2 pending "gpu" jobs with a size of 3 job slots each
+//
+// Expected result: no pending job is counted, so the ASG does not scale up; 2 jobs are blocked by the limit
+func TestScaleASGs_TagLimitSizedJobs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider, config.Asg{Name: "gpu-a", Tags: []string{"gpu"}, MaxAsgCapacity: 10})
+	cfg.Autoscaler.TagLimits = map[string]int64{"gpu": 1}
+
+	provider.On("GetCurrentCapacity", mock.Anything, "gpu-a").Return(int64(1), int64(1), nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    2,
+		TotalRunningJobs:    1,
+		PendingJobsWithTags: map[string]int{"gpu": 2},
+		RunningJobsWithTags: map[string]int{"gpu": 1},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"gpu", "size-3x"}, Size: 3},
+			{Tags: []string{"gpu", "size-3x"}, Size: 3},
+		},
+		RunningJobList: []gitlab.JobSummary{{Tags: []string{"gpu"}}},
+	})
+
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, map[string]int64{BlockedTagLimit: 2}, snapshot.ASGs[0].Blocked)
+}
+
+// TestSplitProportionally verifies the largest remainder split keeps the total
+func TestSplitProportionally(t *testing.T) {
+	assert.Equal(t, []int64{2, 2, 1}, splitProportionally(5, []int64{1, 1, 1}))
+	assert.Equal(t, []int64{3, 1}, splitProportionally(4, []int64{6, 2}))
+	assert.Equal(t, []int64{1, 2}, splitProportionally(3, []int64{1, 3}))
+	assert.Equal(t, []int64{1, 1}, splitProportionally(2, []int64{0, 0}))
+	assert.Equal(t, []int64{0, 0}, splitProportionally(0, []int64{5, 5}))
+}
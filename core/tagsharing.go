@@ -0,0 +1,148 @@
+package core
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// shareCandidate is an ASG serving a shared tag, as seen by a tag sharing strategy
+type shareCandidate struct {
+	name     string
+	priority int
+	headroom int64 // Job slots left below the max capacity, as of the last cycle
+}
+
+// tagSharer splits the pending jobs of a shared tag among the candidates, which are sorted by name;
+// the parts must add up to pending
+type tagSharer func(pending int64, candidates []shareCandidate) []int64
+
+// tagSharers are the strategies of autoscaler.tag-sharing; "duplicate" has none, every ASG counts all jobs
+var tagSharers = map[string]tagSharer{
+	config.TagSharingEven:     shareEvenly,
+	config.TagSharingHeadroom: shareByHeadroom,
+	config.TagSharingPriority: shareByPriority,
+}
+
+// shareEvenly splits the jobs evenly, largest remainder first
+func shareEvenly(pending int64, candidates []shareCandidate) []int64 {
+	return splitProportionally(pending, make([]int64, len(candidates)))
+}
+
+// shareByHeadroom splits the jobs in proportion to the headroom of the candidates; evenly when none has any
+func shareByHeadroom(pending int64, candidates []shareCandidate) []int64 {
+	weights := make([]int64, len(candidates))
+	for i, candidate := range candidates {
+		weights[i] = candidate.headroom
+	}
+	return splitProportionally(pending, weights)
+}
+
+// shareByPriority hands the jobs to the candidates by descending priority, each up to its headroom, equal
+// priorities sharing by headroom. Jobs beyond the headroom of all candidates go to the highest priority.
+func shareByPriority(pending int64, candidates []shareCandidate) []int64 {
+	byPriority := make(map[int][]int)
+	for i, candidate := range candidates {
+		byPriority[candidate.priority] = append(byPriority[candidate.priority], i)
+	}
+	priorities := slices.Sorted(maps.Keys(byPriority))
+	slices.Reverse(priorities)
+
+	parts := make([]int64, len(candidates))
+	left := pending
+	for _, priority := range priorities {
+		group := byPriority[priority]
+		var headroom int64
+		weights := make([]int64, len(group))
+		for j, i := range group {
+			weights[j] = candidates[i].headroom
+			headroom += weights[j]
+		}
+		for j, part := range splitProportionally(min(left, headroom), weights) {
+			parts[group[j]] = part
+		}
+		left -= min(left, headroom)
+	}
+
+	if left > 0 {
+		top := byPriority[priorities[0]]
+		for j, part := range splitProportionally(left, make([]int64, len(top))) {
+			parts[top[j]] += part
+		}
+	}
+	return parts
+}
+
+// tagSharingShares splits the pending jobs of every tag served by several ASGs among them with the strategy of
+// settings.tag-sharing. Only ASGs seeing the same jobs share, i.e. ASGs with the same gitlab-scope; tags in
+// tag-limits are left out, tagLimitShares splits them already. Returns ASG name -> tag -> pending jobs.
+func (o *Orchestrator) tagSharingShares(settings config.AutoscalerConfig, asgs []config.Asg, stateFor func(config.Asg) gitlab.ClusterState, now time.Time) map[string]map[string]int64 {
+	sharer, ok := tagSharers[settings.EffectiveTagSharing()]
+	if !ok {
+		return nil
+	}
+
+	lastDesired := make(map[string]int64)
+	if last, ok := o.Snapshot(); ok {
+		for _, status := range last.ASGs {
+			lastDesired[status.Name] = status.Desired
+		}
+	}
+
+	// Scope key -> tag -> ASGs serving it
+	serving := make(map[string]map[string][]config.Asg)
+	for _, asg := range asgs {
+		key := asg.GitLabScope.Key()
+		if serving[key] == nil {
+			serving[key] = make(map[string][]config.Asg)
+		}
+		for _, tag := range asg.Tags {
+			if _, limited := settings.TagLimits[tag]; !limited {
+				serving[key][tag] = append(serving[key][tag], asg)
+			}
+		}
+	}
+
+	shares := make(map[string]map[string]int64)
+	for _, tags := range serving {
+		for tag, group := range tags {
+			if len(group) < 2 {
+				continue
+			}
+			sort.Slice(group, func(i, j int) bool { return group[i].Name < group[j].Name })
+
+			candidates := make([]shareCandidate, len(group))
+			for i, asg := range group {
+				_, maxAllowed, _ := asg.CapacityBounds(now)
+				headroom := max(maxAllowed-lastDesired[asg.Name], 0) * asg.EffectiveJobsPerInstance()
+				candidates[i] = shareCandidate{name: asg.Name, priority: asg.Priority, headroom: headroom}
+			}
+
+			pending := int64(stateFor(group[0]).PendingJobsWithTags[tag])
+			for i, part := range sharer(pending, candidates) {
+				if shares[group[i].Name] == nil {
+					shares[group[i].Name] = make(map[string]int64)
+				}
+				shares[group[i].Name][tag] = part
+			}
+		}
+	}
+	return shares
+}
+
+// applyTagSharing replaces the pending counts of shared tags in the state of an ASG with its share
+func applyTagSharing(state *gitlab.ClusterState, shares map[string]int64) {
+	if len(shares) == 0 {
+		return
+	}
+	counts := maps.Clone(state.PendingJobsWithTags)
+	for tag, share := range shares {
+		setPendingShare(state, tag, int64(counts[tag]), share)
+		counts[tag] = int(share)
+	}
+	state.PendingJobsWithTags = counts
+}
@@ -0,0 +1,205 @@
+package core
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestTagSharers verifies every strategy splits all pending jobs of a shared tag.
+//
+// Conditions: candidates "a" (priority 0, headroom 6), "b" (priority 5, headroom 2) and "c" (priority 0, headroom 0)
+//
+// Expected result:
+// - even splits 7 jobs 3/2/2, largest remainder by name
+// - headroom splits 8 jobs 6/2/0
+// - priority fills "b" up to its headroom first, then "a"; jobs beyond all headroom go to "b"
+func TestTagSharers(t *testing.T) {
+	candidates := []shareCandidate{
+		{name: "a", priority: 0, headroom: 6},
+		{name: "b", priority: 5, headroom: 2},
+		{name: "c", priority: 0, headroom: 0},
+	}
+	cases := []struct {
+		strategy string
+		pending  int64
+		expected []int64
+	}{
+		{config.TagSharingEven, 7, []int64{3, 2, 2}},
+		{config.TagSharingHeadroom, 8, []int64{6, 2, 0}},
+		{config.TagSharingPriority, 1, []int64{0, 1, 0}},
+		{config.TagSharingPriority, 5, []int64{3, 2, 0}},
+		{config.TagSharingPriority, 10, []int64{6, 4, 0}},
+	}
+
+	for _, c := range cases {
+		if parts := tagSharers[c.strategy](c.pending, candidates); !reflect.DeepEqual(parts, c.expected) {
+			t.Errorf("%s with %d pending: expected %v, got %v", c.strategy, c.pending, c.expected, parts)
+		}
+	}
+}
+
+// TestScaleASGs_TagSharing verifies ASGs serving the same tag scale up for its jobs once, not once each.
+//
+// Conditions:
+// - ASGs "common-a" and "common-b" with tag ["common"], max 10, both empty; 5 pending "common" jobs
+// - ASG "scoped" with tag ["common"] restricted to a gitlab-scope, whose scoped state has 2 pending jobs
+// - tag-sharing even, then duplicate
+//
+// Expected result:
+// - even: "common-a" scales to 3 and "common-b" to 2, 5 instances for 5 jobs; "scoped" does not share and scales to 2
+// - duplicate: both unscoped ASGs scale to 5
+func TestScaleASGs_TagSharing(t *testing.T) {
+	cases := []struct {
+		strategy string
+		expected map[string]int64
+	}{
+		{config.TagSharingEven, map[string]int64{"common-a": 3, "common-b": 2, "scoped": 2}},
+		{config.TagSharingDuplicate, map[string]int64{"common-a": 5, "common-b": 5, "scoped": 2}},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider,
+			config.Asg{Name: "common-a", Tags: []string{"common"}, MaxAsgCapacity: 10, ScaleToZero: true},
+			config.Asg{Name: "common-b", Tags: []string{"common"}, MaxAsgCapacity: 10, ScaleToZero: true},
+			config.Asg{Name: "scoped", Tags: []string{"common"}, MaxAsgCapacity: 10, ScaleToZero: true,
+				GitLabScope: config.GitLabScope{Projects: []string{"group/team"}}})
+		cfg.Autoscaler.TagSharing = c.strategy
+
+		provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(0), int64(0), nil)
+		for name, expected := range c.expected {
+			provider.On("UpdateASGCapacity", mock.Anything, name, expected).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+			TotalPendingJobs:    5,
+			PendingJobsWithTags: map[string]int{"common": 5},
+			RunningJobsWithTags: map[string]int{},
+			Projects: []gitlab.Project{
+				{PathWithNamespace: "group/team", PendingJobs: 2, PendingTagList: []string{"common", "common"}},
+				{PathWithNamespace: "group/other", PendingJobs: 3, PendingTagList: []string{"common", "common", "common"}},
+			},
+		})
+
+		provider.AssertExpectations(t)
+	}
+}
+
+// TestScaleASGs_TagSharingSizedJobs verifies the size of jobs of a shared tag is counted once across its ASGs.
+//
+// Conditions:
+// - ASGs "build-a" and "build-b" with tag ["build"], max 10, both empty; tag-sharing even
+// - 2 pending "build" jobs with a size of 3 job slots each
+//
+// Expected result: each ASG counts its share, 1 job of 3 slots, and scales to 3; 6 instances for 6 slots
+func TestScaleASGs_TagSharingSizedJobs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "build-a", Tags: []string{"build"}, MaxAsgCapacity: 10, ScaleToZero: true},
+		config.Asg{Name: "build-b", Tags: []string{"build"}, MaxAsgCapacity: 10, ScaleToZero: true})
+	cfg.Autoscaler.TagSharing = config.TagSharingEven
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "build-a", int64(3)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "build-b", int64(3)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    2,
+		PendingJobsWithTags: map[string]int{"build": 2, "size-3x": 2},
+		RunningJobsWithTags: map[string]int{},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"build", "size-3x"}, Size: 3},
+			{Tags: []string{"build", "size-3x"}, Size: 3},
+		},
+	})
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_TagSharingExcludedJobs verifies an ASG excluding some jobs of a shared tag only subtracts its share.
+//
+// Conditions:
+// - ASGs "build-a" with tag ["build"] excluding ["privileged"] and "build-b" with tag ["build"], max 10, both empty
+// - 4 pending "build" jobs, 2 of them "privileged"; tag-sharing even
+//
+// Expected result: both count 2 jobs; "build-a" subtracts half of the 2 excluded jobs and scales to 1, "build-b" to 2
+func TestScaleASGs_TagSharingExcludedJobs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "build-a", Tags: []string{"build"}, ExcludeTags: []string{"privileged"}, MaxAsgCapacity: 10, ScaleToZero: true},
+		config.Asg{Name: "build-b", Tags: []string{"build"}, MaxAsgCapacity: 10, ScaleToZero: true})
+	cfg.Autoscaler.TagSharing = config.TagSharingEven
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "build-a", int64(1)).Return(nil).Once()
+	provider.On("UpdateASGCapacity", mock.Anything, "build-b", int64(2)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    4,
+		PendingJobsWithTags: map[string]int{"build": 4, "privileged": 2},
+		RunningJobsWithTags: map[string]int{},
+		PendingJobList: []gitlab.JobSummary{
+			{Tags: []string{"build", "privileged"}},
+			{Tags: []string{"build", "privileged"}},
+			{Tags: []string{"build"}},
+			{Tags: []string{"build"}},
+		},
+	})
+
+	provider.AssertExpectations(t)
+}
+
+// TestScaleASGs_SharedTagScaleDown verifies an ASG left without a share of a tag does not scale down in the same cycle.
+//
+// Conditions:
+// - ASGs "common-a" (empty) and "common-b" (1 instance) with tag ["common"], scale-to-zero, max 10
+// - 1 pending "common" job, assigned to "common-a" by the even split; shared-tag-scale-down hold, then allow
+//
+// Expected result:
+// - hold: "common-a" scales up to 1, "common-b" keeps its instance with a "scale-down held" reason
+// - allow: "common-a" scales up to 1 and "common-b" scales down to 0 (the churn hold prevents)
+func TestScaleASGs_SharedTagScaleDown(t *testing.T) {
+	cases := []struct {
+		mode      string
+		scaleDown bool
+	}{
+		{config.SharedTagScaleDownHold, false},
+		{config.SharedTagScaleDownAllow, true},
+	}
+
+	for _, c := range cases {
+		provider := &mocks.MockProvider{}
+		orchestrator, cfg := newTestOrchestrator(provider,
+			config.Asg{Name: "common-a", Tags: []string{"common"}, MaxAsgCapacity: 10, ScaleToZero: true},
+			config.Asg{Name: "common-b", Tags: []string{"common"}, MaxAsgCapacity: 10, ScaleToZero: true})
+		cfg.Autoscaler.SharedTagScaleDown = c.mode
+
+		provider.On("GetCurrentCapacity", mock.Anything, "common-a").Return(int64(0), int64(0), nil)
+		provider.On("GetCurrentCapacity", mock.Anything, "common-b").Return(int64(1), int64(1), nil)
+		provider.On("UpdateASGCapacity", mock.Anything, "common-a", int64(1)).Return(nil).Once()
+		if c.scaleDown {
+			provider.On("UpdateASGCapacity", mock.Anything, "common-b", int64(0)).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+			TotalPendingJobs:    1,
+			PendingJobsWithTags: map[string]int{"common": 1},
+			RunningJobsWithTags: map[string]int{},
+		})
+
+		provider.AssertExpectations(t)
+		snapshot, _ := orchestrator.Snapshot()
+		if !c.scaleDown {
+			provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "common-b", mock.Anything)
+			assert.Equal(t, "scale-down held: pending jobs of its tags are assigned to other ASGs this cycle", snapshot.ASGs[1].Reason)
+		}
+	}
+}
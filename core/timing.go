@@ -0,0 +1,110 @@
+package core
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// cycleOverlapShare is the share of check-interval a cycle may take from its tick to the last applied update
+// before a warning is logged, since the next tick would start a cycle while this one is still running
+const cycleOverlapShare = 0.8
+
+// CycleTimings breaks down how long a cycle took from its tick. The stages follow each other, so they add up to
+// UpdatesApplied; ASGs are evaluated concurrently, so the describe stage is the slowest describe of an ASG.
+type CycleTimings struct {
+	Fetch             time.Duration `json:"fetch"`              // Querying GitLab, from the tick until the ASGs are evaluated
+	Describe          time.Duration `json:"describe"`           // Batch describes plus the slowest describe of an ASG
+	Decide            time.Duration `json:"decide"`             // Computing the decisions after the describes
+	Apply             time.Duration `json:"apply"`              // Applying capacity updates after the last decision
+	DecisionsComputed time.Duration `json:"decisions_computed"` // From the tick until the decisions of all ASGs were computed
+	UpdatesApplied    time.Duration `json:"updates_applied"`    // From the tick until all capacity updates were applied
+}
+
+// asgTiming is how long the evaluation of a single ASG took, filled in by scaleASG
+type asgTiming struct {
+	describe  time.Duration // GetCurrentCapacity
+	decidedAt time.Time     // When the decision was computed; the end of the evaluation without an update
+}
+
+// cycleClock collects the timings of a cycle from its tick
+type cycleClock struct {
+	mu        sync.Mutex
+	tick      time.Time
+	start     time.Time     // When ScaleASGs started, after GitLab was queried
+	batch     time.Duration // Batch describes before the ASGs are evaluated
+	describe  time.Duration // Slowest describe of an ASG
+	decidedAt time.Time     // Latest decision of an ASG
+}
+
+// markTick records the tick of the next cycle, before GitLab is queried; without it a cycle is timed from the
+// start of ScaleASGs
+func (o *Orchestrator) markTick(tick time.Time) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.tick = tick
+}
+
+// startCycle returns the clock of a cycle starting at start, timed from the tick marked before it
+func (o *Orchestrator) startCycle(start time.Time) *cycleClock {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	tick := o.tick
+	if tick.IsZero() || tick.After(start) {
+		tick = start
+	}
+	o.tick = time.Time{}
+	return &cycleClock{tick: tick, start: start, decidedAt: start}
+}
+
+// evaluated adds the timing of an ASG evaluated at end to the cycle
+func (c *cycleClock) evaluated(timing asgTiming, end time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	decidedAt := timing.decidedAt
+	if decidedAt.IsZero() {
+		decidedAt = end
+	}
+	c.describe = max(c.describe, timing.describe)
+	if decidedAt.After(c.decidedAt) {
+		c.decidedAt = decidedAt
+	}
+}
+
+// timings returns the timings of the cycle whose updates were all applied at end
+func (c *cycleClock) timings(end time.Time) CycleTimings {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	t := CycleTimings{
+		Fetch:             c.start.Sub(c.tick),
+		Describe:          c.batch + c.describe,
+		DecisionsComputed: c.decidedAt.Sub(c.tick),
+		UpdatesApplied:    max(end.Sub(c.tick), c.decidedAt.Sub(c.tick)),
+	}
+	t.Decide = max(t.DecisionsComputed-t.Fetch-t.Describe, 0)
+	t.Apply = t.UpdatesApplied - t.DecisionsComputed
+	return t
+}
+
+// applyCapacity sets the capacity of the ASG, recording in timing that its decision was computed before
+func (o *Orchestrator) applyCapacity(ctx context.Context, provider Provider, asgName string, capacity int64, timing *asgTiming) error {
+	timing.decidedAt = o.now()
+	err := provider.UpdateASGCapacity(ctx, asgName, capacity)
+	if err != nil {
+		providerName, _, _ := o.providerFor(asgName)
+		o.metrics.ProviderError(providerName, "update")
+	}
+	return err
+}
+
+// warnCycleOverlap logs a warning when a cycle took most of the check interval from its tick to its last update
+func warnCycleOverlap(timings CycleTimings, interval time.Duration) {
+	if interval <= 0 || float64(timings.UpdatesApplied) < cycleOverlapShare*float64(interval) {
+		return
+	}
+	slog.Warn("Slow cycle: cycles risk overlapping", "updates_applied", timings.UpdatesApplied.Round(time.Millisecond),
+		"check_interval", interval, "gitlab", timings.Fetch.Round(time.Millisecond),
+		"describe", timings.Describe.Round(time.Millisecond), "decide", timings.Decide.Round(time.Millisecond),
+		"apply", timings.Apply.Round(time.Millisecond))
+}
@@ -0,0 +1,99 @@
+package core
+
+import (
+	"bytes"
+	"context"
+	"log"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_CycleTimings verifies the stages of a cycle are timed from its tick.
+//
+// Conditions:
+// - A fake clock; the tick is marked 4s before ScaleASGs starts, as if GitLab took 4s
+// - ASG with 3 matching pending jobs: its describe takes 2s and its update 3s
+// - check-interval 10s, then 20s
+//
+// Expected result: fetch 4s, describe 2s, decide 0s, apply 3s; decisions computed 6s and updates applied 9s after
+// the tick; a slow-cycle warning since 9s exceeds 80% of 10s, none with 20s
+func TestScaleASGs_CycleTimings(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5, ScaleToZero: true}
+	orchestrator, cfg := newTestOrchestrator(provider, asg)
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	clock := start
+	orchestrator.now = func() time.Time { return clock }
+	provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Run(func(mock.Arguments) { clock = clock.Add(2 * time.Second) }).
+		Return(int64(0), int64(0), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Run(func(mock.Arguments) { clock = clock.Add(3 * time.Second) }).
+		Return(nil)
+	pending := gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		PendingJobsWithTags: map[string]int{"amd64": 3},
+		RunningJobsWithTags: map[string]int{},
+	}
+
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	orchestrator.markTick(start.Add(-4 * time.Second))
+	orchestrator.ScaleASGs(context.Background(), cfg, pending)
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, CycleTimings{
+		Fetch:             4 * time.Second,
+		Describe:          2 * time.Second,
+		Decide:            0,
+		Apply:             3 * time.Second,
+		DecisionsComputed: 6 * time.Second,
+		UpdatesApplied:    9 * time.Second,
+	}, snapshot.Timings)
+	assert.Contains(t, logs.String(), "Slow cycle")
+
+	logs.Reset()
+	clock = clock.Add(time.Minute)
+	cfg.Autoscaler.CheckInterval = 20
+	orchestrator.markTick(clock.Add(-4 * time.Second))
+	orchestrator.ScaleASGs(context.Background(), cfg, pending)
+	assert.NotContains(t, logs.String(), "Slow cycle")
+}
+
+// TestCycleClock_SlowestASG verifies concurrently evaluated ASGs count with their slowest describe and last decision.
+//
+// Conditions:
+// - Tick at 0s, ScaleASGs starts at 1s and batch describes take 1s
+// - ASG "a": describe 3s, decided at 6s; ASG "b": describe 4s, no update, evaluation ends at 7s
+// - All updates applied at 9s
+//
+// Expected result: fetch 1s, describe 5s (1s batch + 4s), decide 1s, apply 2s; decisions computed 7s, updates
+// applied 9s
+func TestCycleClock_SlowestASG(t *testing.T) {
+	tick := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	var orchestrator Orchestrator
+	orchestrator.markTick(tick)
+	clock := orchestrator.startCycle(tick.Add(time.Second))
+	clock.batch = time.Second
+
+	clock.evaluated(asgTiming{describe: 3 * time.Second, decidedAt: tick.Add(6 * time.Second)}, tick.Add(8*time.Second))
+	clock.evaluated(asgTiming{describe: 4 * time.Second}, tick.Add(7*time.Second))
+
+	assert.Equal(t, CycleTimings{
+		Fetch:             time.Second,
+		Describe:          5 * time.Second,
+		Decide:            time.Second,
+		Apply:             2 * time.Second,
+		DecisionsComputed: 7 * time.Second,
+		UpdatesApplied:    9 * time.Second,
+	}, clock.timings(tick.Add(9*time.Second)))
+	assert.Zero(t, orchestrator.tick, "the tick is used by one cycle only")
+}
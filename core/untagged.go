@@ -0,0 +1,44 @@
+package core
+
+import (
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// untaggedJobs returns the jobs without tags counted for the ASG: all of them with handles-untagged-jobs, none otherwise
+func untaggedJobs(asg config.Asg, count int64) int64 {
+	if asg.HandlesUntaggedJobs {
+		return count
+	}
+	return 0
+}
+
+// untaggedDesignees returns the ASG scaling up for pending untagged jobs per gitlab-scope key: of the ASGs with
+// handles-untagged-jobs, the one with the highest priority, ties by name. The others only keep their capacity
+// while untagged jobs run, so the same jobs are not scaled for twice.
+func untaggedDesignees(asgs []config.Asg) map[string]string {
+	designees := make(map[string]config.Asg)
+	for _, asg := range asgs {
+		if !asg.HandlesUntaggedJobs {
+			continue
+		}
+		key := asg.GitLabScope.Key()
+		current, ok := designees[key]
+		if !ok || asg.Priority > current.Priority || (asg.Priority == current.Priority && asg.Name < current.Name) {
+			designees[key] = asg
+		}
+	}
+
+	names := make(map[string]string, len(designees))
+	for key, asg := range designees {
+		names[key] = asg.Name
+	}
+	return names
+}
+
+// applyUntaggedDesignee leaves the pending untagged jobs in the state of an ASG only when it is the designee of its scope
+func applyUntaggedDesignee(state *gitlab.ClusterState, asg config.Asg, designees map[string]string) {
+	if designees[asg.GitLabScope.Key()] != asg.Name {
+		state.PendingWithoutTags = 0
+	}
+}
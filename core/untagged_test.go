@@ -0,0 +1,55 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// TestScaleASGs_UntaggedJobs verifies untagged jobs are served by the handles-untagged-jobs ASGs only.
+//
+// Conditions:
+// - ASGs "generic-a" and "generic-b" with handles-untagged-jobs, "generic-b" with priority 5; ASG "tagged" without it
+// - All ASGs have tag ["amd64"], scale-to-zero, max 10
+// - Cycle 1: all empty, 3 pending untagged jobs
+// - Cycle 2: 1 instance each, no pending and 2 running untagged jobs
+//
+// Expected result:
+// - Cycle 1: only "generic-b", the designee by priority, scales up to 3
+// - Cycle 2: only "tagged" scales down; both untagged ASGs keep their instance while untagged jobs run
+func TestScaleASGs_UntaggedJobs(t *testing.T) {
+	provider := &mocks.MockProvider{}
+	orchestrator, cfg := newTestOrchestrator(provider,
+		config.Asg{Name: "generic-a", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true, HandlesUntaggedJobs: true},
+		config.Asg{Name: "generic-b", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true, HandlesUntaggedJobs: true, Priority: 5},
+		config.Asg{Name: "tagged", Tags: []string{"amd64"}, MaxAsgCapacity: 10, ScaleToZero: true})
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(0), int64(0), nil).Times(3)
+	provider.On("UpdateASGCapacity", mock.Anything, "generic-b", int64(3)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    3,
+		PendingWithoutTags:  3,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+	provider.AssertExpectations(t)
+
+	provider.On("GetCurrentCapacity", mock.Anything, mock.Anything).Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "tagged", int64(0)).Return(nil).Once()
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalRunningJobs:    2,
+		RunningWithoutTags:  2,
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+	provider.AssertExpectations(t)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "generic-a", mock.Anything)
+	provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, "generic-b", int64(0))
+}
@@ -0,0 +1,18 @@
+package core
+
+// WarmPoolProvider is implemented by providers whose ASGs can keep pre-initialized instances in a warm pool.
+// Warm instances are neither allocated nor launching, but a scale-up takes them in service much faster than
+// a cold launch.
+type WarmPoolProvider interface {
+	// WarmInstances returns the instances in the warm pool of the ASG seen by the last GetCurrentCapacity call
+	WarmInstances(asgName string) int64
+}
+
+// warmInstances returns the warm pool instances of the ASG, 0 when the provider does not report a warm pool
+func warmInstances(provider Provider, asgName string) int64 {
+	warmPool, ok := provider.(WarmPoolProvider)
+	if !ok {
+		return 0
+	}
+	return warmPool.WarmInstances(asgName)
+}
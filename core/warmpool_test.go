@@ -0,0 +1,58 @@
+package core
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// warmPoolProvider is a mocked provider whose ASG keeps a fixed number of instances in a warm pool
+type warmPoolProvider struct {
+	*mocks.MockProvider
+	warm int64
+}
+
+func (p warmPoolProvider) WarmInstances(string) int64 {
+	return p.warm
+}
+
+// TestScaleASGs_WarmPoolSkipsStabilization verifies a scale-up covered by the warm pool is not stabilized.
+//
+// Conditions:
+// - ASG with tag ["amd64"], 1 allocated instance, scale-up-stabilization 3, 3 pending jobs (scale-up by 2)
+// - Run 1: 2 instances in the warm pool
+// - Run 2: 1 instance in the warm pool, fewer than the scale-up needs
+//
+// Expected result:
+// - Run 1: scale-up to 3 in the first cycle, the warm instances are reported in the status
+// - Run 2: the scale-up is deferred
+func TestScaleASGs_WarmPoolSkipsStabilization(t *testing.T) {
+	for _, warm := range []int64{2, 1} {
+		provider := warmPoolProvider{&mocks.MockProvider{}, warm}
+		asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MaxAsgCapacity: 5}
+		cfg := config.Config{
+			Autoscaler: config.AutoscalerConfig{CheckInterval: 10, ScaleUpStabilization: 3},
+			Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+		}
+		orchestrator := NewOrchestrator(map[string]Provider{"aws": provider}, map[string]string{asg.Name: "aws"}, nil, nil)
+
+		provider.On("GetCurrentCapacity", mock.Anything, "test-asg").Return(int64(1), int64(1), nil)
+		if warm == 2 {
+			provider.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(3)).Return(nil).Once()
+		}
+
+		orchestrator.ScaleASGs(context.Background(), cfg, pendingState(3))
+
+		snapshot, _ := orchestrator.Snapshot()
+		assert.Equal(t, warm, snapshot.ASGs[0].WarmInstances)
+		provider.AssertExpectations(t)
+		if warm == 1 {
+			provider.AssertNotCalled(t, "UpdateASGCapacity", mock.Anything, mock.Anything, mock.Anything)
+		}
+	}
+}
@@ -0,0 +1,241 @@
+// Package examples keeps the example configurations shipped in this directory loadable and complete:
+// every example must load and validate, and every configuration field must appear in at least one example.
+package examples
+
+import (
+	"bytes"
+	"embed"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+//go:embed *.yml
+var files embed.FS
+
+// examples returns the embedded example files by name
+func examples(t *testing.T) map[string][]byte {
+	t.Helper()
+	names, err := files.ReadDir(".")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+
+	contents := make(map[string][]byte, len(names))
+	for _, entry := range names {
+		data, err := files.ReadFile(entry.Name())
+		require.NoError(t, err)
+		contents[entry.Name()] = data
+	}
+	return contents
+}
+
+// TestExamples_Load verifies every example is accepted the way the binary accepts a configuration
+// Expected behavior:
+//   - config.Load decodes it and Validate passes
+//   - Strict decoding finds no unknown keys, so a renamed field fails here instead of being silently ignored
+func TestExamples_Load(t *testing.T) {
+	for name, data := range examples(t) {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), name)
+			require.NoError(t, os.WriteFile(path, data, 0644))
+
+			cfg, err := config.Load(path)
+			require.NoError(t, err)
+			assert.NoError(t, cfg.Validate())
+
+			decoder := yaml.NewDecoder(bytes.NewReader(data))
+			decoder.KnownFields(true)
+			assert.NoError(t, decoder.Decode(&config.Config{}))
+		})
+	}
+}
+
+// TestExamples_CoverEveryField verifies every field of config.Config is set in at least one example
+// Expected behavior:
+//   - Fields are compared by yaml path, e.g. "autoscaler.check-interval" or "*.asg-names[].schedules[].days"
+//   - Nested structs, slices of structs and maps of structs are walked; other fields are leaves
+//   - A new field without an example fails with its path
+func TestExamples_CoverEveryField(t *testing.T) {
+	configType := reflect.TypeOf(config.Config{})
+	covered := make(map[string]bool)
+	for name, data := range examples(t) {
+		var root yaml.Node
+		require.NoError(t, yaml.Unmarshal(data, &root), name)
+		require.NotEmpty(t, root.Content, name)
+		coveredFields(root.Content[0], configType, "", covered)
+	}
+
+	var missing []string
+	for _, path := range schemaFields(configType, "", nil) {
+		if !covered[path] {
+			missing = append(missing, path)
+		}
+	}
+	assert.Empty(t, missing, "fields without an example")
+}
+
+// TestSchemaFields verifies the walker descends into every kind of composite field
+func TestSchemaFields(t *testing.T) {
+	type leaf struct {
+		Value string `yaml:"value"`
+	}
+	type root struct {
+		Plain    int             `yaml:"plain"`
+		Nested   leaf            `yaml:"nested"`
+		Pointer  *leaf           `yaml:"pointer"`
+		List     []leaf          `yaml:"list"`
+		Scalars  map[string]int  `yaml:"scalars"`
+		Keyed    map[string]leaf `yaml:"keyed"`
+		Inline   map[string]leaf `yaml:",inline"`
+		Untagged string          // yaml default key: lower-cased name
+		Skipped  string          `yaml:"-"`
+	}
+
+	fields := schemaFields(reflect.TypeOf(root{}), "", nil)
+	assert.Equal(t, []string{
+		"*.value", "keyed.*.value", "list[].value", "nested.value",
+		"plain", "pointer.value", "scalars", "untagged",
+	}, fields)
+
+	var node yaml.Node
+	require.NoError(t, yaml.Unmarshal([]byte("plain: 1\nlist: [{value: a}]\nkeyed: {x: {value: b}}\nother: {value: c}\n"), &node))
+	covered := make(map[string]bool)
+	coveredFields(node.Content[0], reflect.TypeOf(root{}), "", covered)
+	assert.Equal(t, map[string]bool{"plain": true, "list[].value": true, "keyed.*.value": true, "*.value": true}, covered)
+}
+
+// schemaFields returns the sorted yaml paths of the leaf fields of struct type t. Struct fields are walked,
+// slices of structs add "[]" and maps of structs "*", the key of the map; an inline map stands for its keys
+// at the level of the struct. Anything else, scalar maps and slices included, is a leaf.
+func schemaFields(t reflect.Type, prefix string, fields []string) []string {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline := yamlKey(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if inline {
+			name = "*"
+		}
+		path := prefix + name
+
+		elem, elemPrefix, composite := compositeElem(field.Type, path, inline)
+		if composite {
+			fields = schemaFields(elem, elemPrefix, fields)
+			continue
+		}
+		fields = append(fields, path)
+	}
+	slices.Sort(fields)
+	return fields
+}
+
+// coveredFields marks the yaml paths of the leaf fields set in node, a mapping decoded into struct type t.
+// Keys unknown to t belong to its inline map, if any, and are ignored otherwise; TestExamples_Load reports them.
+func coveredFields(node *yaml.Node, t reflect.Type, prefix string, covered map[string]bool) {
+	if node.Kind != yaml.MappingNode {
+		return
+	}
+	byKey := make(map[string]reflect.StructField)
+	var inlineMap *reflect.StructField
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		name, inline := yamlKey(field)
+		if name == "-" || !field.IsExported() {
+			continue
+		}
+		if inline {
+			inlineMap = &field
+			continue
+		}
+		byKey[name] = field
+	}
+
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		key, value := node.Content[i].Value, node.Content[i+1]
+		field, ok := byKey[key]
+		if !ok {
+			if inlineMap == nil {
+				continue
+			}
+			// A key of the inline map, e.g. "aws" for a provider, is one of its elements
+			if elem := deref(inlineMap.Type.Elem()); elem.Kind() == reflect.Struct {
+				coveredFields(value, elem, prefix+"*.", covered)
+			} else {
+				covered[prefix+"*"] = true
+			}
+			continue
+		}
+
+		path := prefix + key
+		elem, elemPrefix, composite := compositeElem(field.Type, path, false)
+		switch {
+		case !composite:
+			covered[path] = true
+		case deref(field.Type).Kind() == reflect.Struct:
+			coveredFields(value, elem, elemPrefix, covered)
+		case value.Kind == yaml.SequenceNode:
+			for _, item := range value.Content {
+				coveredFields(item, elem, elemPrefix, covered)
+			}
+		case value.Kind == yaml.MappingNode:
+			for j := 1; j < len(value.Content); j += 2 {
+				coveredFields(value.Content[j], elem, elemPrefix, covered)
+			}
+		}
+	}
+}
+
+// compositeElem returns the struct type walked for the field at path of type t and the prefix of its fields:
+// "path." for a struct, "path[]." for a slice of structs and "path.*." for a map of structs. An inline struct
+// keeps the prefix of its parent. ok is false for a leaf.
+func compositeElem(t reflect.Type, path string, inline bool) (elem reflect.Type, prefix string, ok bool) {
+	t = deref(t)
+	parent := strings.TrimSuffix(path, "*")
+	switch t.Kind() {
+	case reflect.Struct:
+		if inline {
+			return t, parent, true
+		}
+		return t, path + ".", true
+	case reflect.Slice:
+		if elem := deref(t.Elem()); elem.Kind() == reflect.Struct {
+			return elem, path + "[].", true
+		}
+	case reflect.Map:
+		if elem := deref(t.Elem()); elem.Kind() == reflect.Struct {
+			if inline {
+				return elem, parent + "*.", true
+			}
+			return elem, path + ".*.", true
+		}
+	}
+	return nil, "", false
+}
+
+// deref returns the type a pointer type points to, t otherwise
+func deref(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// yamlKey returns the yaml key of a field and whether it is inlined, following the rules of yaml.v3
+func yamlKey(field reflect.StructField) (string, bool) {
+	parts := strings.Split(field.Tag.Get("yaml"), ",")
+	inline := slices.Contains(parts[1:], "inline")
+	if parts[0] == "" {
+		return strings.ToLower(field.Name), inline
+	}
+	return parts[0], inline
+}
@@ -0,0 +1,64 @@
+// Command plugin is a minimal provider plugin: it keeps the desired capacity of each ASG in memory and reports it
+// as allocated at once. A real plugin replaces memoryProvider with calls to its cloud, e.g. Proxmox or vSphere.
+//
+//	go run ./examples/plugin -socket /run/gitlab-autoscaler/memory.sock
+//
+// serves the provider entry of examples/plugin.yml.
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin"
+)
+
+// memoryProvider implements core.Provider in memory
+type memoryProvider struct {
+	mu      sync.Mutex
+	desired map[string]int64
+}
+
+func (p *memoryProvider) GetCurrentCapacity(_ context.Context, asgName string) (int64, int64, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.desired[asgName], p.desired[asgName], nil
+}
+
+func (p *memoryProvider) UpdateASGCapacity(_ context.Context, asgName string, capacity int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	log.Printf("ASG %s: capacity %d -> %d", asgName, p.desired[asgName], capacity)
+	p.desired[asgName] = capacity
+	return nil
+}
+
+func main() {
+	socket := flag.String("socket", "/run/gitlab-autoscaler/memory.sock", "Unix socket to serve the plugin on")
+	flag.Parse()
+
+	_ = os.Remove(*socket)
+	listener, err := net.Listen("unix", *socket)
+	if err != nil {
+		log.Fatalf("Failed to listen on %s: %v", *socket, err)
+	}
+
+	server := plugin.NewServer("memory", "v1", &memoryProvider{desired: make(map[string]int64)})
+	go func() {
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+		<-signals
+		server.GracefulStop()
+	}()
+
+	log.Printf("Serving plugin on %s", *socket)
+	if err := server.Serve(listener); err != nil {
+		log.Fatalf("Plugin server failed: %v", err)
+	}
+}
@@ -0,0 +1,238 @@
+// Package faults injects failures into GitLab requests and provider calls for resilience testing
+package faults
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// ErrInjected is returned (wrapped) by every injected failure
+var ErrInjected = errors.New("injected fault")
+
+// Guard refuses an enabled fault injection configuration unless it was explicitly allowed on the command line
+func Guard(cfg config.FaultInjectionConfig, allowed bool) error {
+	if cfg.Enabled && !allowed {
+		return fmt.Errorf("testing.fault-injection is enabled but --allow-fault-injection was not passed")
+	}
+	return nil
+}
+
+// Injector decides which calls fail; the same seed yields the same sequence of decisions
+type Injector struct {
+	mu    sync.Mutex
+	rnd   *rand.Rand
+	cfg   config.FaultInjectionConfig
+	sleep func(time.Duration) // Replaceable in tests
+}
+
+// NewInjector creates an injector seeded from the configuration
+func NewInjector(cfg config.FaultInjectionConfig) *Injector {
+	return &Injector{
+		rnd:   rand.New(rand.NewSource(cfg.Seed)),
+		cfg:   cfg,
+		sleep: time.Sleep,
+	}
+}
+
+// roll reports whether an event with probability p happens
+func (i *Injector) roll(p float64) bool {
+	if p <= 0 {
+		return false
+	}
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	return i.rnd.Float64() < p
+}
+
+// delay sleeps for the configured latency with the configured probability
+func (i *Injector) delay(call string) {
+	if i.cfg.Latency <= 0 || !i.roll(i.cfg.LatencyProbability) {
+		return
+	}
+	slog.Warn("Fault injection: adding latency", "latency", i.cfg.Latency, "call", call)
+	i.sleep(i.cfg.Latency)
+}
+
+// fail returns an injected error for the call with probability p
+func (i *Injector) fail(p float64, call string) error {
+	if !i.roll(p) {
+		return nil
+	}
+	slog.Warn("Fault injection: failing", "call", call)
+	return fmt.Errorf("%s: %w", call, ErrInjected)
+}
+
+// Transport wraps an HTTP transport so that GitLab requests fail or slow down
+func (i *Injector) Transport(next http.RoundTripper) http.RoundTripper {
+	return &transport{injector: i, next: next}
+}
+
+type transport struct {
+	injector *Injector
+	next     http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	call := fmt.Sprintf("GitLab request %s %s", req.Method, req.URL.Path)
+	t.injector.delay(call)
+	if err := t.injector.fail(t.injector.cfg.GitLabFetchError, call); err != nil {
+		return nil, err
+	}
+	return t.next.RoundTrip(req)
+}
+
+// Providers wraps every provider so that describe and update calls fail or slow down
+func (i *Injector) Providers(providers map[string]core.Provider) map[string]core.Provider {
+	wrapped := make(map[string]core.Provider, len(providers))
+	for name, p := range providers {
+		wrapped[name] = &provider{injector: i, next: p}
+	}
+	return wrapped
+}
+
+type provider struct {
+	injector *Injector
+	next     core.Provider
+}
+
+func (p *provider) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	call := "describe ASG " + asgName
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderDescribeError, call); err != nil {
+		return 0, 0, err
+	}
+	return p.next.GetCurrentCapacity(ctx, asgName)
+}
+
+func (p *provider) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	call := "update ASG " + asgName
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderUpdateError, call); err != nil {
+		return err
+	}
+	return p.next.UpdateASGCapacity(ctx, asgName, capacity)
+}
+
+// Instances passes through to providers that report instances, so wrapping keeps stuck-instance detection working
+func (p *provider) Instances(asgName string) []core.Instance {
+	if instances, ok := p.next.(core.InstanceProvider); ok {
+		return instances.Instances(asgName)
+	}
+	return nil
+}
+
+// DescribeAll passes through to providers that describe in batches; injected describe errors still hit
+// every GetCurrentCapacity call
+func (p *provider) DescribeAll(ctx context.Context, asgNames []string) error {
+	if describer, ok := p.next.(core.BatchDescriber); ok {
+		return describer.DescribeAll(ctx, asgNames)
+	}
+	return nil
+}
+
+// Close passes through to providers holding connections, so a reload closes them also when wrapped
+func (p *provider) Close() error {
+	if closer, ok := p.next.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
+
+// GetASGLimits passes through to providers that report ASG limits, so wrapping keeps capacity within them
+func (p *provider) GetASGLimits(asgName string) (int64, int64, bool) {
+	if limits, ok := p.next.(core.LimitsProvider); ok {
+		return limits.GetASGLimits(asgName)
+	}
+	return 0, 0, false
+}
+
+func (p *provider) TerminateInstance(ctx context.Context, asgName, instanceID string) error {
+	instances, ok := p.next.(core.InstanceProvider)
+	if !ok {
+		return fmt.Errorf("provider of ASG %s cannot terminate instances", asgName)
+	}
+	call := "terminate instance " + instanceID
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderUpdateError, call); err != nil {
+		return err
+	}
+	return instances.TerminateInstance(ctx, asgName, instanceID)
+}
+
+func (p *provider) SetInstanceProtection(ctx context.Context, asgName string, instanceIDs []string, protected bool) error {
+	protector, ok := p.next.(core.InstanceProtector)
+	if !ok {
+		return fmt.Errorf("provider of ASG %s cannot protect instances", asgName)
+	}
+	call := "scale-in protection of ASG " + asgName
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderUpdateError, call); err != nil {
+		return err
+	}
+	return protector.SetInstanceProtection(ctx, asgName, instanceIDs, protected)
+}
+
+// TerminatingInstances passes through to providers that complete lifecycle hooks
+func (p *provider) TerminatingInstances(asgName string) []string {
+	if completer, ok := p.next.(core.LifecycleHookCompleter); ok {
+		return completer.TerminatingInstances(asgName)
+	}
+	return nil
+}
+
+func (p *provider) CompleteLifecycleAction(ctx context.Context, asgName, hookName, instanceID string) error {
+	completer, ok := p.next.(core.LifecycleHookCompleter)
+	if !ok {
+		return fmt.Errorf("provider of ASG %s cannot complete lifecycle hooks", asgName)
+	}
+	call := "lifecycle action of instance " + instanceID
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderUpdateError, call); err != nil {
+		return err
+	}
+	return completer.CompleteLifecycleAction(ctx, asgName, hookName, instanceID)
+}
+
+// WarmInstances passes through to providers that report warm pools
+func (p *provider) WarmInstances(asgName string) int64 {
+	if warmPool, ok := p.next.(core.WarmPoolProvider); ok {
+		return warmPool.WarmInstances(asgName)
+	}
+	return 0
+}
+
+func (p *provider) ScalingActivityInProgress(ctx context.Context, asgName string) (string, bool, error) {
+	reporter, ok := p.next.(core.ActivityReporter)
+	if !ok {
+		return "", false, nil
+	}
+	call := "scaling activities of ASG " + asgName
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderDescribeError, call); err != nil {
+		return "", false, err
+	}
+	return reporter.ScalingActivityInProgress(ctx, asgName)
+}
+
+func (p *provider) RecordScaleReason(ctx context.Context, asgName, reason string, at time.Time) error {
+	recorder, ok := p.next.(core.ScaleReasonRecorder)
+	if !ok {
+		return nil
+	}
+	call := "tagging of ASG " + asgName
+	p.injector.delay(call)
+	if err := p.injector.fail(p.injector.cfg.ProviderUpdateError, call); err != nil {
+		return err
+	}
+	return recorder.RecordScaleReason(ctx, asgName, reason, at)
+}
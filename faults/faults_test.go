@@ -0,0 +1,104 @@
+package faults
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestGuard verifies enabled fault injection is refused without --allow-fault-injection
+func TestGuard(t *testing.T) {
+	enabled := config.FaultInjectionConfig{Enabled: true}
+
+	assert.Error(t, Guard(enabled, false))
+	assert.NoError(t, Guard(enabled, true))
+	assert.NoError(t, Guard(config.FaultInjectionConfig{}, false))
+}
+
+// TestInjector_Rate verifies the share of injected failures follows the configured probability
+func TestInjector_Rate(t *testing.T) {
+	injector := NewInjector(config.FaultInjectionConfig{Seed: 42})
+
+	const calls = 10000
+	failures := 0
+	for range calls {
+		if injector.roll(0.3) {
+			failures++
+		}
+	}
+
+	assert.InDelta(t, 0.3, float64(failures)/calls, 0.02)
+}
+
+// TestInjector_Deterministic verifies the same seed produces the same sequence of failures
+func TestInjector_Deterministic(t *testing.T) {
+	first := NewInjector(config.FaultInjectionConfig{Seed: 7})
+	second := NewInjector(config.FaultInjectionConfig{Seed: 7})
+
+	for range 1000 {
+		require.Equal(t, first.roll(0.5), second.roll(0.5))
+	}
+}
+
+// TestTransport verifies GitLab requests fail before reaching the network
+// Expected behavior:
+//   - Probability 1 returns ErrInjected without calling the wrapped transport
+//   - Probability 0 passes the request through
+func TestTransport(t *testing.T) {
+	calls := 0
+	next := roundTripFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		return httptest.NewRecorder().Result(), nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "https://gitlab.com/api/v4/projects", nil)
+
+	_, err := NewInjector(config.FaultInjectionConfig{GitLabFetchError: 1}).Transport(next).RoundTrip(req)
+	assert.True(t, errors.Is(err, ErrInjected))
+	assert.Equal(t, 0, calls)
+
+	_, err = NewInjector(config.FaultInjectionConfig{}).Transport(next).RoundTrip(req)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, calls)
+}
+
+// TestProviders verifies describe and update errors are injected independently
+// Expected behavior:
+//   - GetCurrentCapacity fails with ErrInjected at provider-describe-error 1
+//   - UpdateASGCapacity is passed through at provider-update-error 0
+//   - Configured latency is added to every call at latency-probability 1
+func TestProviders(t *testing.T) {
+	next := &mocks.MockProvider{}
+	next.On("UpdateASGCapacity", mock.Anything, "test-asg", int64(2)).Return(nil)
+
+	injector := NewInjector(config.FaultInjectionConfig{
+		ProviderDescribeError: 1,
+		Latency:               time.Second,
+		LatencyProbability:    1,
+	})
+	var slept time.Duration
+	injector.sleep = func(d time.Duration) { slept += d }
+
+	provider := injector.Providers(map[string]core.Provider{"aws": next})["aws"]
+
+	_, _, err := provider.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.True(t, errors.Is(err, ErrInjected))
+	assert.NoError(t, provider.UpdateASGCapacity(context.Background(), "test-asg", 2))
+	assert.Equal(t, 2*time.Second, slept)
+	next.AssertExpectations(t)
+}
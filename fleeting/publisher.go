@@ -0,0 +1,152 @@
+// Package fleeting publishes the desired capacity of export-only: fleeting ASGs for GitLab fleeting plugins,
+// which apply it themselves instead of the autoscaler updating the ASG through its provider.
+package fleeting
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// Publisher keeps the last target of every export-only ASG, serves them on GET /targets and
+// rewrites fleeting.file whenever one changes
+type Publisher struct {
+	mu      sync.Mutex
+	targets map[string]api.FleetingTarget
+	file    string
+	now     func() time.Time
+	server  *http.Server
+}
+
+// NewPublisher creates a publisher for the given settings; nothing is served until Start
+func NewPublisher(cfg config.FleetingConfig) *Publisher {
+	p := &Publisher{targets: make(map[string]api.FleetingTarget), file: cfg.File, now: time.Now}
+	if cfg.Listen != "" {
+		p.server = &http.Server{
+			Addr:              cfg.Listen,
+			Handler:           p.Handler(),
+			ReadHeaderTimeout: 5 * time.Second,
+		}
+	}
+	return p
+}
+
+// PublishTarget records the desired capacity of an ASG and rewrites the file; the target is served even
+// when writing the file fails
+func (p *Publisher) PublishTarget(asgName string, desired int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.targets[asgName] = api.FleetingTarget{Name: asgName, Desired: desired, UpdatedAt: p.now().UTC()}
+	if p.file == "" {
+		return nil
+	}
+	if err := writeFileAtomic(p.file, p.payloadLocked()); err != nil {
+		return fmt.Errorf("failed to write fleeting targets: %w", err)
+	}
+	return nil
+}
+
+// Targets returns the published targets sorted by ASG name
+func (p *Publisher) Targets() api.FleetingTargets {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.payloadLocked()
+}
+
+// payloadLocked builds the published targets; p.mu must be held
+func (p *Publisher) payloadLocked() api.FleetingTargets {
+	targets := make([]api.FleetingTarget, 0, len(p.targets))
+	for _, target := range p.targets {
+		targets = append(targets, target)
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].Name < targets[j].Name })
+	return api.FleetingTargets{Targets: targets}
+}
+
+// Handler returns the HTTP handler serving GET /targets and GET /targets/{name}
+func (p *Publisher) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /targets", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, p.Targets())
+	})
+	mux.HandleFunc("GET /targets/{name}", func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		p.mu.Lock()
+		target, ok := p.targets[name]
+		p.mu.Unlock()
+		if !ok {
+			writeJSON(w, http.StatusNotFound, api.ErrorResponse{Error: fmt.Sprintf("%s: %s", api.ErrUnknownASG, name)})
+			return
+		}
+		writeJSON(w, http.StatusOK, target)
+	})
+	return mux
+}
+
+// Start binds fleeting.listen and serves requests in the background; a no-op without a listen address
+func (p *Publisher) Start() error {
+	if p.server == nil {
+		return nil
+	}
+	listener, err := net.Listen("tcp", p.server.Addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", p.server.Addr, err)
+	}
+
+	slog.Info("Fleeting targets listening", "address", listener.Addr().String())
+	go func() {
+		if err := p.server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("Fleeting targets server stopped", "error", err)
+		}
+	}()
+	return nil
+}
+
+// Shutdown stops serving the targets
+func (p *Publisher) Shutdown() {
+	if p.server != nil {
+		_ = p.server.Close()
+	}
+}
+
+// writeFileAtomic writes targets to a temporary file next to path and renames it, so a plugin never reads a partial file
+func writeFileAtomic(path string, targets api.FleetingTargets) error {
+	data, err := json.MarshalIndent(targets, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// writeJSON writes body as an indented JSON response
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(body); err != nil {
+		slog.Error("Error encoding fleeting response", "error", err)
+	}
+}
@@ -0,0 +1,62 @@
+package fleeting
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// TestPublisher_Targets verifies the published payload format on the listener and in the file
+// Expected behavior:
+//   - GET /targets lists the last target of every ASG sorted by name, with its update time
+//   - GET /targets/{name} returns a single target; an unknown ASG answers 404
+//   - fleeting.file holds the same JSON after every change and no temporary file is left behind
+func TestPublisher_Targets(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "fleeting.json")
+	publisher := NewPublisher(config.FleetingConfig{File: file})
+	publisher.now = func() time.Time { return time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC) }
+
+	require.NoError(t, publisher.PublishTarget("runner-b", 2))
+	require.NoError(t, publisher.PublishTarget("runner-a", 1))
+	require.NoError(t, publisher.PublishTarget("runner-b", 3))
+
+	expected := `{"targets": [
+		{"name": "runner-a", "desired": 1, "updated_at": "2024-05-06T09:00:00Z"},
+		{"name": "runner-b", "desired": 3, "updated_at": "2024-05-06T09:00:00Z"}
+	]}`
+	rec := httptest.NewRecorder()
+	publisher.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/targets", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, expected, rec.Body.String())
+
+	written, err := os.ReadFile(file)
+	require.NoError(t, err)
+	assert.JSONEq(t, expected, string(written))
+	entries, err := os.ReadDir(filepath.Dir(file))
+	require.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	rec = httptest.NewRecorder()
+	publisher.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/targets/runner-b", nil))
+	assert.JSONEq(t, `{"name": "runner-b", "desired": 3, "updated_at": "2024-05-06T09:00:00Z"}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	publisher.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/targets/runner-c", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+// TestPublisher_FileError verifies a target is still served when the file cannot be written
+func TestPublisher_FileError(t *testing.T) {
+	publisher := NewPublisher(config.FleetingConfig{File: filepath.Join(t.TempDir(), "missing", "fleeting.json")})
+
+	assert.Error(t, publisher.PublishTarget("runner-a", 1))
+	assert.Equal(t, int64(1), publisher.Targets().Targets[0].Desired)
+}
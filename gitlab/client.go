@@ -4,63 +4,165 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
+	"maps"
+	"math"
 	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
-	"github.com/shuliakovsky/gitlab-autoscaler/utils"
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
 )
 
 const (
 	gitlabAPIBaseTemplate = "https://gitlab.com/api/v4/groups/%s/projects"
-	jobsAPIBaseTemplate   = "https://gitlab.com/api/v4/projects/%d/jobs?scope=%s"
+	jobsAPIBaseTemplate   = "https://gitlab.com/api/v4/projects/%s/jobs?scope=%s"
 	maxRetries            = 5
 )
 
-var gitlabClient = &http.Client{
-	Timeout: 25 * time.Second,
-}
-
 // ClusterState represents the current state of jobs across all projects
 type ClusterState struct {
-	TotalPendingJobs    int64
-	TotalRunningJobs    int64
-	PendingJobsWithTags map[string]int
-	RunningJobsWithTags map[string]int
-	Projects            []Project
-	TotalCapacity       int64
+	TotalPendingJobs int64 `json:"total_pending_jobs"`
+	TotalRunningJobs int64 `json:"total_running_jobs"`
+	// PendingWithoutTags and RunningWithoutTags count the jobs without any tag, served by handles-untagged-jobs ASGs
+	PendingWithoutTags  int64          `json:"pending_without_tags"`
+	RunningWithoutTags  int64          `json:"running_without_tags"`
+	PendingJobsWithTags map[string]int `json:"pending_jobs_with_tags"`
+	RunningJobsWithTags map[string]int `json:"running_jobs_with_tags"`
+	// OnlineRunnersWithTags holds the number of online group runners per tag (only when runner reconciliation is enabled)
+	OnlineRunnersWithTags map[string]int `json:"online_runners_with_tags,omitempty"`
+	// OldestPendingJobWithTags holds the creation time of the oldest pending job per tag
+	OldestPendingJobWithTags map[string]time.Time `json:"oldest_pending_job_with_tags"`
+	// CreatedJobsWithTags holds the number of created (waiting on needs) jobs per tag, before discounting
+	CreatedJobsWithTags map[string]int `json:"created_jobs_with_tags,omitempty"`
+	// CreatedJobsFactor is the share of created jobs folded into the pending counts (0 when created jobs are not counted)
+	CreatedJobsFactor float64 `json:"created_jobs_factor,omitempty"`
+	// ActivePipelines holds the running/pending pipeline IDs per project ref (only for projects queried for pipeline-hold)
+	ActivePipelines map[string][]int `json:"active_pipelines,omitempty"`
+	// DeferredJobs is the number of pending jobs not counted because they wait on a resource group
+	DeferredJobs int64 `json:"deferred_jobs,omitempty"`
+	// BusyRunners holds the sorted descriptions of the runners running a job; group-wide, shared by scoped states
+	BusyRunners []string `json:"busy_runners,omitempty"`
+	// PendingJobList and RunningJobList hold every job, for decisions and metrics that need per-job data
+	PendingJobList []JobSummary `json:"pending_job_list,omitempty"`
+	RunningJobList []JobSummary `json:"running_job_list,omitempty"`
+	// PendingTagShares holds, per tag split among several ASGs by tag-limits or tag-sharing, the fraction of its
+	// pending jobs counted for one ASG; set on the copy of the state for that ASG, 1 for tags missing
+	PendingTagShares map[string]float64 `json:"-"`
+	Projects         []Project          `json:"projects"`
+	TotalCapacity    int64              `json:"total_capacity"`
 }
 
 // Project represents a GitLab project with job information
 type Project struct {
-	ID             int      `json:"id"`
-	Name           string   `json:"name"`
-	PendingTagList []string `json:"pending_tag_list"`
-	RunningTagList []string `json:"running_tag_list"`
+	ID                int      `json:"id"`
+	Name              string   `json:"name"`
+	PathWithNamespace string   `json:"path_with_namespace"`
+	PendingTagList    []string `json:"pending_tag_list"`
+	RunningTagList    []string `json:"running_tag_list"`
+	// Job information filled in by CalculateClusterState
+	PendingJobs              int                  `json:"pending_jobs"`
+	RunningJobs              int                  `json:"running_jobs"`
+	OldestPendingJobWithTags map[string]time.Time `json:"oldest_pending_job_with_tags,omitempty"`
+	CreatedJobs              int                  `json:"created_jobs,omitempty"`
+	CreatedTagList           []string             `json:"created_tag_list,omitempty"`
+	DeferredJobs             int                  `json:"deferred_jobs,omitempty"`
+	// PendingWithoutTags, RunningWithoutTags and CreatedWithoutTags count the jobs without any tag
+	PendingWithoutTags int `json:"pending_without_tags,omitempty"`
+	RunningWithoutTags int `json:"running_without_tags,omitempty"`
+	CreatedWithoutTags int `json:"created_without_tags,omitempty"`
+	// JobPipelines maps pipeline IDs to the tags of their pending and running jobs
+	JobPipelines map[int][]string `json:"job_pipelines,omitempty"`
+	// BusyRunners holds the descriptions of the runners running its jobs
+	BusyRunners []string `json:"busy_runners,omitempty"`
+	// PendingJobList and RunningJobList hold every job
+	PendingJobList []JobSummary `json:"pending_job_list,omitempty"`
+	RunningJobList []JobSummary `json:"running_job_list,omitempty"`
+}
+
+// JobSummary holds the per-job data kept in the cluster state
+type JobSummary struct {
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+	Size      int64     `json:"size,omitempty"` // Job slots stated by a size tag, see StateOptions.SizeTagPrefix; 0 counts as 1
+}
+
+// Slots returns the job slots the job counts for in demand: its size, 1 without one
+func (j JobSummary) Slots() int64 {
+	return max(j.Size, 1)
+}
+
+// Ref returns the identifier of the project for API URLs: the numeric ID, or the URL-encoded path when the ID is unknown
+func (p Project) Ref() string {
+	if p.ID != 0 {
+		return strconv.Itoa(p.ID)
+	}
+	return url.PathEscape(p.PathWithNamespace)
+}
+
+// ProjectsFromConfig builds the project list from explicitly configured numeric IDs or paths (e.g. "org/repo")
+func ProjectsFromConfig(identifiers []string) []Project {
+	projects := make([]Project, 0, len(identifiers))
+	for _, identifier := range identifiers {
+		if id, err := strconv.Atoi(identifier); err == nil {
+			projects = append(projects, Project{ID: id, Name: identifier})
+			continue
+		}
+		projects = append(projects, Project{
+			Name:              identifier[strings.LastIndex(identifier, "/")+1:],
+			PathWithNamespace: identifier,
+		})
+	}
+	return projects
+}
+
+// Job represents a single CI job as returned by the GitLab jobs API
+type Job struct {
+	ID        int       `json:"id"`
+	Tags      []string  `json:"tag_list"`
+	CreatedAt time.Time `json:"created_at"`
+	Pipeline  struct {
+		ID int `json:"id"`
+	} `json:"pipeline"`
+	// DownstreamPipeline is only present on bridge (trigger) jobs; it may be null before the downstream pipeline exists
+	DownstreamPipeline json.RawMessage `json:"downstream_pipeline"`
+	// Runner is the runner that picked up the job; null while the job is pending
+	Runner *struct {
+		ID          int    `json:"id"`
+		Description string `json:"description"`
+	} `json:"runner"`
+}
+
+// IsBridge reports whether the job is a bridge (trigger) job, which never needs a runner
+func (j Job) IsBridge() bool {
+	return len(j.DownstreamPipeline) > 0
 }
 
 // FetchProjects fetches all projects in a GitLab group with proper error handling and retries
-func FetchProjects(token, groupName string, excludeProjects []string) ([]Project, error) {
+func (c *Client) FetchProjects(groupName string, excludeProjects []string) ([]Project, error) {
 	req, err := http.NewRequest("GET", fmt.Sprintf(gitlabAPIBaseTemplate, groupName)+"?include_subgroups=true&per_page=100", nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("PRIVATE-TOKEN", token)
+	req.Header.Set("PRIVATE-TOKEN", c.token)
 
 	var allProjects []Project
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, err := gitlabClient.Do(req)
+		resp, err := c.httpClient.Do(req)
 		if err != nil {
-			utils.LogRed(fmt.Sprintf("Error making request: %v", err))
+			slog.Error("Error making request", "error", err)
 			return nil, err
 		}
 		defer closeBody(resp.Body)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
 			waitDuration := time.Duration(2<<attempt) * time.Second
-			log.Printf("%sReceived 429 Too Many Requests. Retrying in %s...%s", utils.Yellow, waitDuration, utils.Reset)
+			slog.Warn("Received 429 Too Many Requests, retrying", "retry_in", waitDuration)
 			time.Sleep(waitDuration)
 			continue
 		}
@@ -78,12 +180,9 @@ func FetchProjects(token, groupName string, excludeProjects []string) ([]Project
 			if !isExcluded(project.Name, excludeProjects) {
 				allProjects = append(allProjects, project)
 
-				log.Printf("Project: %-35s (ID: %-9d)  Pending jobs: %s%-3d%s tags: %s%v%s. Running jobs: %s%-3d%s tags: %s%v%s",
-					project.Name, project.ID,
-					utils.Cyan, len(project.PendingTagList), utils.Reset,
-					utils.Cyan, project.PendingTagList, utils.Reset,
-					utils.Green, len(project.RunningTagList), utils.Reset,
-					utils.Green, project.RunningTagList, utils.Reset)
+				slog.Info("Project", "project", project.Name, "id", project.ID,
+					"pending", len(project.PendingTagList), "pending_tags", project.PendingTagList,
+					"running", len(project.RunningTagList), "running_tags", project.RunningTagList)
 			}
 		}
 		return allProjects, nil
@@ -92,163 +191,284 @@ func FetchProjects(token, groupName string, excludeProjects []string) ([]Project
 }
 
 // FetchJobsCount fetches job counts for a specific scope (pending/running)
-func FetchJobsCount(token string, projectID int, scope string) (int, []string, error) {
-	req, err := http.NewRequest("GET", fmt.Sprintf(jobsAPIBaseTemplate, projectID, scope), nil)
+func (c *Client) FetchJobsCount(projectRef string, scope string) (int, []string, error) {
+	jobs, err := c.FetchJobs(projectRef, scope)
 	if err != nil {
 		return 0, nil, err
 	}
-	req.Header.Set("PRIVATE-TOKEN", token)
+	return len(jobs), extractTags(jobs), nil
+}
+
+// FetchJobs fetches the jobs of a project (see Project.Ref) for a specific scope (pending/running)
+func (c *Client) FetchJobs(projectRef string, scope string) ([]Job, error) {
+	req, err := http.NewRequest("GET", fmt.Sprintf(jobsAPIBaseTemplate, projectRef, scope), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
 
 	for attempt := 0; attempt < maxRetries; attempt++ {
-		resp, err := gitlabClient.Do(req)
+		resp, err := c.httpClient.Do(req)
 		if err != nil {
-			return 0, nil, err
+			return nil, err
 		}
 		defer closeBody(resp.Body)
 
 		if resp.StatusCode == http.StatusTooManyRequests {
 			waitDuration := time.Duration(2<<attempt) * time.Second
-			log.Printf("%sReceived 429 Too Many Requests. Retrying in %s...%s", utils.Yellow, waitDuration, utils.Reset)
+			slog.Warn("Received 429 Too Many Requests, retrying", "retry_in", waitDuration)
 			time.Sleep(waitDuration)
 			continue
 		}
 
 		if resp.StatusCode != http.StatusOK {
-			return 0, nil, fmt.Errorf("error fetching %s jobs for project ID %d: status=%s", scope, projectID, resp.Status)
+			return nil, fmt.Errorf("error fetching %s jobs for project %s: status=%s", scope, projectRef, resp.Status)
 		}
 
-		var jobs []struct {
-			ID   int      `json:"id"`
-			Tags []string `json:"tag_list"`
-		}
+		var jobs []Job
 		if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
-			return 0, nil, err
+			return nil, err
 		}
 
-		tags := extractTags(jobs)
-		return len(jobs), tags, nil
+		return jobs, nil
 	}
-	return 0, nil, fmt.Errorf("failed to fetch job counts after %d attempts", maxRetries)
+	return nil, fmt.Errorf("failed to fetch job counts after %d attempts", maxRetries)
 }
 
-// CalculateClusterState aggregates job information across all projects (exactly like in the old working version)
-func CalculateClusterState(token string, projects []Project) ClusterState {
-	pendingJobsWithTags := make(map[string]int)
-	runningJobsWithTags := make(map[string]int)
-	var totalPending, totalRunning int64 = 0, 0
-	var totalPendingWithoutTags, totalRunningWithoutTags int
+// projectJobs holds the result of fetching the jobs of a single project
+type projectJobs struct {
+	project Project
+	err     error
+}
 
+// StateOptions controls how CalculateClusterState counts jobs
+type StateOptions struct {
+	CreatedJobsFactor float64           // Share of "created" jobs counted as pending; 0 does not fetch them
+	TagAliases        map[string]string // Synonym -> canonical tag, applied to every job before counting
+	SizeTagPrefix     string            // Prefix of job tags stating the job slots a job needs, e.g. "size-" for "size-3x"; empty ignores them
+}
+
+// CalculateClusterState fetches pending and running jobs of all projects and aggregates them (exactly like in the old working version).
+// With a positive CreatedJobsFactor, jobs in the "created" scope are fetched too and that share of them is counted as pending.
+func (c *Client) CalculateClusterState(projects []Project, opts StateOptions) ClusterState {
+	createdJobsFactor := opts.CreatedJobsFactor
 	var wg sync.WaitGroup
-	results := make(chan struct {
-		name        string
-		id          int
-		pending     int
-		running     int
-		pendingTags []string
-		runningTags []string
-		err         error
-	}, len(projects))
+	results := make(chan projectJobs, len(projects))
 
 	for _, project := range projects {
 		wg.Add(1)
 		go func(p Project) {
 			defer wg.Done()
-			pendingJobs, pendingTags, err := FetchJobsCount(token, p.ID, "pending")
+			pendingJobs, err := c.FetchJobs(p.Ref(), "pending")
 			if err != nil {
-				results <- struct {
-					name        string
-					id          int
-					pending     int
-					running     int
-					pendingTags []string
-					runningTags []string
-					err         error
-				}{name: p.Name, id: p.ID, pending: 0, running: 0, err: err}
+				results <- projectJobs{project: p, err: err}
 				return
 			}
 
-			runningJobs, runningTags, err := FetchJobsCount(token, p.ID, "running")
+			runningJobs, err := c.FetchJobs(p.Ref(), "running")
 			if err != nil {
-				results <- struct {
-					name        string
-					id          int
-					pending     int
-					running     int
-					pendingTags []string
-					runningTags []string
-					err         error
-				}{name: p.Name, id: p.ID, pending: pendingJobs, running: 0, err: err}
+				results <- projectJobs{project: p, err: err}
 				return
 			}
 
-			results <- struct {
-				name        string
-				id          int
-				pending     int
-				running     int
-				pendingTags []string
-				runningTags []string
-				err         error
-			}{
-				name:        p.Name,
-				id:          p.ID,
-				pending:     pendingJobs,
-				running:     runningJobs,
-				pendingTags: pendingTags,
-				runningTags: runningTags,
-				err:         nil,
+			var createdJobs []Job
+			if createdJobsFactor > 0 {
+				createdJobs, err = c.FetchJobs(p.Ref(), "created")
+				if err != nil {
+					results <- projectJobs{project: p, err: err}
+					return
+				}
 			}
+
+			if !c.countBridgeJobs {
+				pendingJobs = withoutBridgeJobs(pendingJobs)
+				runningJobs = withoutBridgeJobs(runningJobs)
+				createdJobs = withoutBridgeJobs(createdJobs)
+			}
+
+			if c.respectResourceGroups && len(pendingJobs) > 0 {
+				jobGroups, err := c.FetchResourceGroupJobs(p.Ref())
+				if err != nil {
+					slog.Warn("Error fetching resource groups, counting all pending jobs", "project", p.Name, "error", err)
+				} else {
+					pendingJobs, p.DeferredJobs = serializeResourceGroups(pendingJobs, jobGroups)
+				}
+			}
+
+			canonicalizeTags(opts.TagAliases, pendingJobs, runningJobs, createdJobs)
+
+			p.PendingJobs = len(pendingJobs)
+			p.RunningJobs = len(runningJobs)
+			p.PendingTagList = extractTags(pendingJobs)
+			p.RunningTagList = extractTags(runningJobs)
+			p.OldestPendingJobWithTags = oldestJobPerTag(pendingJobs)
+			p.JobPipelines = tagsPerPipeline(pendingJobs, runningJobs)
+			p.BusyRunners = runnerDescriptions(runningJobs)
+			p.PendingJobList = summarize(pendingJobs)
+			p.RunningJobList = summarize(runningJobs)
+			sizeJobs(opts.SizeTagPrefix, p.PendingJobList, p.RunningJobList)
+			p.CreatedJobs = len(createdJobs)
+			p.CreatedTagList = extractTags(createdJobs)
+			p.PendingWithoutTags = countUntagged(pendingJobs)
+			p.RunningWithoutTags = countUntagged(runningJobs)
+			p.CreatedWithoutTags = countUntagged(createdJobs)
+			results <- projectJobs{project: p}
 		}(project)
 	}
 
 	wg.Wait()
 	close(results)
 
+	var fetched []Project
+	failed := 0
 	for r := range results {
 		if r.err != nil {
-			log.Printf("Error processing project: %s", r.err)
+			slog.Error("Error processing project", "error", r.err)
+			failed++
 			continue
 		}
-		totalPending += int64(r.pending)
-		totalRunning += int64(r.running)
+		p := r.project
+		fetched = append(fetched, p)
 
-		if len(r.pendingTags) == 0 {
-			totalPendingWithoutTags += r.pending
-		}
-		if len(r.runningTags) == 0 {
-			totalRunningWithoutTags += r.running
+		slog.Info("Project", "project", p.Name, "id", p.ID, "pending", p.PendingJobs, "pending_tags", p.PendingTagList,
+			"running", p.RunningJobs, "running_tags", p.RunningTagList)
+	}
+
+	state := aggregateProjects(fetched, createdJobsFactor)
+	logFetchSummary(state, len(fetched), failed)
+	return state
+}
+
+// logFetchSummary logs the jobs fetched from GitLab in a cycle, in total and per tag
+func logFetchSummary(state ClusterState, projects, failed int) {
+	slog.Info("GitLab fetch summary", "projects", projects, "failed_projects", failed,
+		"pending", state.TotalPendingJobs, "running", state.TotalRunningJobs)
+	tags := make(map[string]int)
+	maps.Copy(tags, state.PendingJobsWithTags)
+	maps.Copy(tags, state.RunningJobsWithTags)
+	for _, tag := range slices.Sorted(maps.Keys(tags)) {
+		slog.Debug("GitLab jobs by tag", "tag", tag, "pending", state.PendingJobsWithTags[tag], "running", state.RunningJobsWithTags[tag])
+	}
+}
+
+// ForScope returns the cluster state restricted to the projects inside the scope.
+// Group-wide data that cannot be attributed to projects (online runners) is shared.
+func (s ClusterState) ForScope(scope config.GitLabScope) ClusterState {
+	var projects []Project
+	for _, project := range s.Projects {
+		if scope.Contains(project.PathWithNamespace) {
+			projects = append(projects, project)
 		}
+	}
+
+	scoped := aggregateProjects(projects, s.CreatedJobsFactor)
+	scoped.OnlineRunnersWithTags = s.OnlineRunnersWithTags
+	scoped.BusyRunners = s.BusyRunners
+	scoped.ActivePipelines = s.ActivePipelines
+	return scoped
+}
 
-		for _, tag := range r.pendingTags {
+// aggregateProjects sums the per-project job information into a cluster state.
+// Created jobs are folded into the pending counts scaled by createdJobsFactor, rounded up per tag.
+func aggregateProjects(projects []Project, createdJobsFactor float64) ClusterState {
+	pendingJobsWithTags := make(map[string]int)
+	runningJobsWithTags := make(map[string]int)
+	oldestPendingJobWithTags := make(map[string]time.Time)
+	var createdJobsWithTags map[string]int
+	var pendingJobList, runningJobList []JobSummary
+	var totalPending, totalRunning, totalCreated, totalDeferred int64 = 0, 0, 0, 0
+	var pendingWithoutTags, runningWithoutTags, createdWithoutTags int64 = 0, 0, 0
+	busyRunners := make(map[string]bool)
+
+	for _, p := range projects {
+		totalPending += int64(p.PendingJobs)
+		totalRunning += int64(p.RunningJobs)
+		totalDeferred += int64(p.DeferredJobs)
+		pendingWithoutTags += int64(p.PendingWithoutTags)
+		runningWithoutTags += int64(p.RunningWithoutTags)
+
+		for _, tag := range p.PendingTagList {
 			pendingJobsWithTags[tag]++
 		}
+		pendingJobList = append(pendingJobList, p.PendingJobList...)
+		runningJobList = append(runningJobList, p.RunningJobList...)
+		for _, runner := range p.BusyRunners {
+			busyRunners[runner] = true
+		}
 
-		for _, tag := range r.runningTags {
+		for _, tag := range p.RunningTagList {
 			runningJobsWithTags[tag]++
 		}
 
-		log.Printf("Project: %-35s (ID: %-9d)  Pending jobs: %s%-3d%s tags: %s%v%s. Running jobs: %s%-3d%s tags: %s%v%s",
-			r.name, r.id,
-			utils.Cyan, r.pending, utils.Reset,
-			utils.Cyan, r.pendingTags, utils.Reset,
-			utils.Green, r.running, utils.Reset,
-			utils.Green, r.runningTags, utils.Reset)
+		if createdJobsFactor > 0 {
+			totalCreated += int64(p.CreatedJobs)
+			createdWithoutTags += int64(p.CreatedWithoutTags)
+			for _, tag := range p.CreatedTagList {
+				if createdJobsWithTags == nil {
+					createdJobsWithTags = make(map[string]int)
+				}
+				createdJobsWithTags[tag]++
+			}
+		}
+
+		for tag, created := range p.OldestPendingJobWithTags {
+			if oldest, ok := oldestPendingJobWithTags[tag]; !ok || created.Before(oldest) {
+				oldestPendingJobWithTags[tag] = created
+			}
+		}
+	}
+
+	for tag, count := range createdJobsWithTags {
+		pendingJobsWithTags[tag] += discount(count, createdJobsFactor)
 	}
+	totalPending += int64(discount(int(totalCreated), createdJobsFactor))
+	pendingWithoutTags += int64(discount(int(createdWithoutTags), createdJobsFactor))
 
 	return ClusterState{
-		TotalPendingJobs:    totalPending,
-		TotalRunningJobs:    totalRunning,
-		PendingJobsWithTags: pendingJobsWithTags,
-		RunningJobsWithTags: runningJobsWithTags,
-		TotalCapacity:       totalPending + totalRunning,
+		TotalPendingJobs:         totalPending,
+		TotalRunningJobs:         totalRunning,
+		PendingWithoutTags:       pendingWithoutTags,
+		RunningWithoutTags:       runningWithoutTags,
+		PendingJobsWithTags:      pendingJobsWithTags,
+		RunningJobsWithTags:      runningJobsWithTags,
+		OldestPendingJobWithTags: oldestPendingJobWithTags,
+		CreatedJobsWithTags:      createdJobsWithTags,
+		CreatedJobsFactor:        createdJobsFactor,
+		DeferredJobs:             totalDeferred,
+		BusyRunners:              sortedKeys(busyRunners),
+		PendingJobList:           pendingJobList,
+		RunningJobList:           runningJobList,
+		Projects:                 projects,
+		TotalCapacity:            totalPending + totalRunning,
 	}
 }
 
+// sortedKeys returns the keys of set sorted, nil when it is empty
+func sortedKeys(set map[string]bool) []string {
+	if len(set) == 0 {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(set))
+}
+
+// runnerDescriptions returns the descriptions of the runners running the jobs, each once
+func runnerDescriptions(jobs []Job) []string {
+	seen := make(map[string]bool)
+	for _, job := range jobs {
+		if job.Runner != nil && job.Runner.Description != "" {
+			seen[job.Runner.Description] = true
+		}
+	}
+	return sortedKeys(seen)
+}
+
+// discount scales a job count by factor, rounding up so that any created job counts at least once
+func discount(count int, factor float64) int {
+	return int(math.Ceil(float64(count) * factor))
+}
+
 // extractTags extracts all tags from job list
-func extractTags(jobs []struct {
-	ID   int      `json:"id"`
-	Tags []string `json:"tag_list"`
-}) []string {
+func extractTags(jobs []Job) []string {
 	var allTags []string
 	for _, job := range jobs {
 		allTags = append(allTags, job.Tags...)
@@ -256,10 +476,94 @@ func extractTags(jobs []struct {
 	return allTags
 }
 
+// countUntagged returns the number of jobs without any tag
+func countUntagged(jobs []Job) int {
+	count := 0
+	for _, job := range jobs {
+		if len(job.Tags) == 0 {
+			count++
+		}
+	}
+	return count
+}
+
+// canonicalizeTags replaces tag synonyms with their canonical tag, keeping each tag once per job
+func canonicalizeTags(aliases map[string]string, jobLists ...[]Job) {
+	if len(aliases) == 0 {
+		return
+	}
+	for _, jobs := range jobLists {
+		for i := range jobs {
+			seen := make(map[string]bool, len(jobs[i].Tags))
+			tags := make([]string, 0, len(jobs[i].Tags))
+			for _, tag := range jobs[i].Tags {
+				if canonical, ok := aliases[tag]; ok {
+					tag = canonical
+				}
+				if !seen[tag] {
+					seen[tag] = true
+					tags = append(tags, tag)
+				}
+			}
+			jobs[i].Tags = tags
+		}
+	}
+}
+
+// summarize returns the per-job data kept in the cluster state
+func summarize(jobs []Job) []JobSummary {
+	summaries := make([]JobSummary, 0, len(jobs))
+	for _, job := range jobs {
+		summaries = append(summaries, JobSummary{Tags: job.Tags, CreatedAt: job.CreatedAt})
+	}
+	return summaries
+}
+
+// tagsPerPipeline groups the tags of jobs by the pipeline they belong to
+func tagsPerPipeline(jobLists ...[]Job) map[int][]string {
+	pipelines := make(map[int][]string)
+	for _, jobs := range jobLists {
+		for _, job := range jobs {
+			if job.Pipeline.ID == 0 {
+				continue
+			}
+			pipelines[job.Pipeline.ID] = append(pipelines[job.Pipeline.ID], job.Tags...)
+		}
+	}
+	return pipelines
+}
+
+// withoutBridgeJobs drops bridge (trigger) jobs, which carry tags but never occupy a runner
+func withoutBridgeJobs(jobs []Job) []Job {
+	filtered := jobs[:0]
+	for _, job := range jobs {
+		if !job.IsBridge() {
+			filtered = append(filtered, job)
+		}
+	}
+	return filtered
+}
+
+// oldestJobPerTag returns the creation time of the oldest job carrying each tag
+func oldestJobPerTag(jobs []Job) map[string]time.Time {
+	oldest := make(map[string]time.Time)
+	for _, job := range jobs {
+		if job.CreatedAt.IsZero() {
+			continue
+		}
+		for _, tag := range job.Tags {
+			if current, ok := oldest[tag]; !ok || job.CreatedAt.Before(current) {
+				oldest[tag] = job.CreatedAt
+			}
+		}
+	}
+	return oldest
+}
+
 // closeBody closes HTTP response body safely
 func closeBody(body io.Closer) {
 	if err := body.Close(); err != nil {
-		log.Printf("Error closing response body: %v", err)
+		slog.Warn("Error closing response body", "error", err)
 	}
 }
 
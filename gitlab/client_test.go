@@ -0,0 +1,429 @@
+package gitlab
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/utils"
+)
+
+// redirectTransport sends every request to the test server regardless of the original host
+type redirectTransport struct {
+	target *url.URL
+}
+
+func (t *redirectTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.URL.Scheme = t.target.Scheme
+	req.URL.Host = t.target.Host
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+// newTestClient returns a client whose requests are all served by the given handler
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+	target, _ := url.Parse(server.URL)
+
+	return &Client{
+		token:      "token",
+		httpClient: &http.Client{Transport: &redirectTransport{target: target}},
+	}
+}
+
+// serveFixtures returns a handler answering the pending jobs endpoint with a fixture file
+// and every other jobs request with an empty list
+func serveFixtures(t *testing.T, pendingFixture string) http.Handler {
+	pending, err := os.ReadFile(filepath.Join("testdata", pendingFixture))
+	require.NoError(t, err)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("scope") == "pending" {
+			w.Write(pending)
+			return
+		}
+		w.Write([]byte("[]"))
+	})
+}
+
+// TestCalculateClusterState_ExcludesBridgeJobs verifies bridge (trigger) jobs do not count as demand
+// Expected behavior:
+//   - Jobs 103 (downstream pipeline not created yet) and 104 (downstream created) are bridges and ignored
+//   - 2 pending "amd64" jobs and 1 pending "arm64" job remain, no "docs" demand
+//   - Oldest pending "amd64" job is the build job, not the older bridge
+func TestCalculateClusterState_ExcludesBridgeJobs(t *testing.T) {
+	client := newTestClient(t, serveFixtures(t, "pending_jobs_with_bridges.json"))
+
+	state := client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{})
+
+	assert.Equal(t, int64(3), state.TotalPendingJobs)
+	assert.Equal(t, map[string]int{"amd64": 2, "arm64": 1}, state.PendingJobsWithTags)
+	assert.Equal(t, time.Date(2024, 5, 6, 8, 58, 0, 0, time.UTC), state.OldestPendingJobWithTags["amd64"])
+}
+
+// TestCalculateClusterState_CountBridgeJobs verifies bridges are counted when count-bridge-jobs is enabled
+func TestCalculateClusterState_CountBridgeJobs(t *testing.T) {
+	client := newTestClient(t, serveFixtures(t, "pending_jobs_with_bridges.json"))
+	client.countBridgeJobs = true
+
+	state := client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{})
+
+	assert.Equal(t, int64(5), state.TotalPendingJobs)
+	assert.Equal(t, map[string]int{"amd64": 4, "arm64": 1, "docs": 1}, state.PendingJobsWithTags)
+}
+
+// TestClusterStateForScope verifies scoped states only aggregate projects inside the scope
+// Expected behavior:
+//   - Two projects in different subgroups share the "amd64" tag
+//   - The team-a scope sees only the team-a project's jobs; runner data is shared
+func TestClusterStateForScope(t *testing.T) {
+	created := time.Date(2024, 5, 6, 8, 0, 0, 0, time.UTC)
+	state := aggregateProjects([]Project{
+		{ID: 1, PathWithNamespace: "mygroup/team-a/app", PendingJobs: 2, PendingTagList: []string{"amd64", "amd64"},
+			OldestPendingJobWithTags: map[string]time.Time{"amd64": created.Add(time.Minute)}},
+		{ID: 2, PathWithNamespace: "mygroup/team-b/app", PendingJobs: 3, RunningJobs: 1,
+			PendingTagList: []string{"amd64", "amd64", "amd64"}, RunningTagList: []string{"amd64"},
+			OldestPendingJobWithTags: map[string]time.Time{"amd64": created}},
+	}, 0)
+	state.OnlineRunnersWithTags = map[string]int{"amd64": 4}
+
+	assert.Equal(t, map[string]int{"amd64": 5}, state.PendingJobsWithTags)
+
+	scoped := state.ForScope(config.GitLabScope{Group: "mygroup/team-a"})
+	assert.Equal(t, int64(2), scoped.TotalPendingJobs)
+	assert.Equal(t, int64(0), scoped.TotalRunningJobs)
+	assert.Equal(t, map[string]int{"amd64": 2}, scoped.PendingJobsWithTags)
+	assert.Equal(t, created.Add(time.Minute), scoped.OldestPendingJobWithTags["amd64"])
+	assert.Equal(t, map[string]int{"amd64": 4}, scoped.OnlineRunnersWithTags)
+}
+
+// TestCalculateClusterState_BusyRunners verifies the runners running a job are collected for protect-busy-instances
+// Expected behavior:
+//   - Running jobs report the description of their runner; two jobs on one runner list it once
+//   - Pending jobs have no runner and a running job without a description is skipped
+//   - Scoped states keep the busy runners of the whole group, whose jobs may run on instances of any ASG
+func TestCalculateClusterState_BusyRunners(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "running":
+			w.Write([]byte(`[
+				{"id": 1, "tag_list": ["amd64"], "runner": {"id": 7, "description": "runner-i-0b"}},
+				{"id": 2, "tag_list": ["amd64"], "runner": {"id": 7, "description": "runner-i-0b"}},
+				{"id": 3, "tag_list": ["amd64"], "runner": {"id": 8, "description": "runner-i-0a"}},
+				{"id": 4, "tag_list": ["amd64"], "runner": {"id": 9, "description": ""}}
+			]`))
+		case "pending":
+			w.Write([]byte(`[{"id": 5, "tag_list": ["amd64"], "runner": null}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+
+	state := client.CalculateClusterState([]Project{{ID: 1, PathWithNamespace: "mygroup/team-a/app"}}, StateOptions{})
+
+	assert.Equal(t, []string{"runner-i-0a", "runner-i-0b"}, state.BusyRunners)
+	assert.Equal(t, state.BusyRunners, state.ForScope(config.GitLabScope{Group: "mygroup/team-b"}).BusyRunners)
+}
+
+// TestProjectsFromConfig verifies explicit projects are fetched by numeric ID or URL-encoded path
+// Expected behavior:
+//   - "42" is requested as /projects/42/jobs
+//   - "org/sub/repo" is requested as /projects/org%2Fsub%2Frepo/jobs and keeps its path for gitlab-scope matching
+func TestProjectsFromConfig(t *testing.T) {
+	projects := ProjectsFromConfig([]string{"42", "org/sub/repo"})
+	require.Len(t, projects, 2)
+	assert.Equal(t, Project{ID: 42, Name: "42"}, projects[0])
+	assert.Equal(t, Project{Name: "repo", PathWithNamespace: "org/sub/repo"}, projects[1])
+
+	var requested []string
+	var mu sync.Mutex
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requested = append(requested, r.URL.EscapedPath())
+		mu.Unlock()
+		w.Write([]byte(`[{"id": 1, "tag_list": ["amd64"]}]`))
+	}))
+
+	state := client.CalculateClusterState(projects, StateOptions{})
+
+	assert.Equal(t, int64(2), state.TotalPendingJobs)
+	assert.ElementsMatch(t, []string{
+		"/api/v4/projects/42/jobs", "/api/v4/projects/42/jobs",
+		"/api/v4/projects/org%2Fsub%2Frepo/jobs", "/api/v4/projects/org%2Fsub%2Frepo/jobs",
+	}, requested)
+}
+
+// TestCalculateClusterState_CreatedJobs verifies created (needs/DAG) jobs are folded into the pending demand
+// Expected behavior:
+//   - Without a factor the created scope is not requested
+//   - With factor 0.5, 3 created "amd64" jobs add 2 (rounded up) to the 1 pending "amd64" job
+func TestCalculateClusterState_CreatedJobs(t *testing.T) {
+	var createdRequests atomic.Int32
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "pending":
+			w.Write([]byte(`[{"id": 1, "tag_list": ["amd64"]}]`))
+		case "created":
+			createdRequests.Add(1)
+			w.Write([]byte(`[{"id": 2, "tag_list": ["amd64"]}, {"id": 3, "tag_list": ["amd64"]}, {"id": 4, "tag_list": ["amd64"]}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	projects := []Project{{ID: 1, Name: "app", PathWithNamespace: "mygroup/app"}}
+
+	state := client.CalculateClusterState(projects, StateOptions{})
+	assert.Equal(t, map[string]int{"amd64": 1}, state.PendingJobsWithTags)
+	assert.Equal(t, int32(0), createdRequests.Load())
+
+	state = client.CalculateClusterState(projects, StateOptions{CreatedJobsFactor: 0.5})
+	assert.Equal(t, map[string]int{"amd64": 3}, state.PendingJobsWithTags)
+	assert.Equal(t, map[string]int{"amd64": 3}, state.CreatedJobsWithTags)
+	assert.Equal(t, int64(3), state.TotalPendingJobs)
+	assert.Equal(t, map[string]int{"amd64": 3}, state.ForScope(config.GitLabScope{Group: "mygroup"}).PendingJobsWithTags)
+}
+
+// TestCalculateClusterState_UntaggedJobs verifies jobs without tags are counted separately
+// Expected behavior:
+//   - 2 pending and 1 running untagged job are counted next to the tagged ones
+//   - 2 created untagged jobs with factor 0.5 add 1 pending untagged job
+//   - Untagged jobs appear in no per-tag count
+func TestCalculateClusterState_UntaggedJobs(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "pending":
+			w.Write([]byte(`[{"id": 1, "tag_list": []}, {"id": 2}, {"id": 3, "tag_list": ["amd64"]}]`))
+		case "running":
+			w.Write([]byte(`[{"id": 4, "tag_list": []}]`))
+		case "created":
+			w.Write([]byte(`[{"id": 5}, {"id": 6}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+
+	state := client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{CreatedJobsFactor: 0.5})
+
+	assert.Equal(t, int64(3), state.PendingWithoutTags)
+	assert.Equal(t, int64(1), state.RunningWithoutTags)
+	assert.Equal(t, map[string]int{"amd64": 1}, state.PendingJobsWithTags)
+	assert.Empty(t, state.RunningJobsWithTags)
+}
+
+// TestFetchActivePipelines verifies running and pending pipelines are both fetched and jobs are grouped by pipeline
+func TestFetchActivePipelines(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v4/projects/1/pipelines" && r.URL.Query().Get("scope") == "running":
+			w.Write([]byte(`[{"id": 7, "status": "running"}]`))
+		case r.URL.Path == "/api/v4/projects/1/pipelines" && r.URL.Query().Get("scope") == "pending":
+			w.Write([]byte(`[{"id": 8, "status": "pending"}]`))
+		case r.URL.Query().Get("scope") == "running":
+			w.Write([]byte(`[{"id": 1, "tag_list": ["amd64"], "pipeline": {"id": 7}}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+
+	pipelines, err := client.FetchActivePipelines("1")
+	require.NoError(t, err)
+	assert.Equal(t, []Pipeline{{ID: 7, Status: "running"}, {ID: 8, Status: "pending"}}, pipelines)
+
+	state := client.CalculateClusterState([]Project{{ID: 1}}, StateOptions{})
+	require.Len(t, state.Projects, 1)
+	assert.Equal(t, map[int][]string{7: {"amd64"}}, state.Projects[0].JobPipelines)
+}
+
+// TestCalculateClusterState_FetchSummaryLogged verifies the fetch is summarized in JSON records
+// Expected behavior:
+//   - One INFO record with the projects, pending and running jobs fetched
+//   - At debug level, one record per tag with its pending and running jobs
+func TestCalculateClusterState_FetchSummaryLogged(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "pending":
+			w.Write([]byte(`[{"id": 1, "tag_list": ["amd64"]}, {"id": 2, "tag_list": ["arm64"]}]`))
+		case "running":
+			w.Write([]byte(`[{"id": 3, "tag_list": ["gpu"]}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+	var buf bytes.Buffer
+	handler, err := utils.NewLogHandler(&buf, utils.LogOptions{Level: slog.LevelDebug, Format: utils.LogFormatJSON})
+	require.NoError(t, err)
+	previous, output, flags := slog.Default(), log.Writer(), log.Flags()
+	slog.SetDefault(slog.New(handler))
+	t.Cleanup(func() {
+		// Setting the default logger also redirects the log package, which is restored on its own
+		slog.SetDefault(previous)
+		log.SetOutput(output)
+		log.SetFlags(flags)
+	})
+
+	client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{})
+
+	var summary map[string]any
+	var tags []string
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var record map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &record))
+		switch record["msg"] {
+		case "GitLab fetch summary":
+			summary = record
+		case "GitLab jobs by tag":
+			tags = append(tags, fmt.Sprintf("%s %v/%v", record["tag"], record["pending"], record["running"]))
+		}
+	}
+	require.NotNil(t, summary)
+	assert.Equal(t, "INFO", summary["level"])
+	assert.EqualValues(t, 1, summary["projects"])
+	assert.EqualValues(t, 0, summary["failed_projects"])
+	assert.EqualValues(t, 2, summary["pending"])
+	assert.EqualValues(t, 1, summary["running"])
+	assert.Equal(t, []string{"amd64 1/0", "arm64 1/0", "gpu 0/1"}, tags)
+}
+
+// TestCalculateClusterState_TagAliases verifies synonyms are collapsed into the canonical tag before counting
+// Expected behavior:
+//   - "linux" and "x86_64" jobs count as "amd64"
+//   - A job carrying two synonyms of the same tag counts once
+func TestCalculateClusterState_TagAliases(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("scope") {
+		case "pending":
+			w.Write([]byte(`[{"id": 1, "tag_list": ["linux"]}, {"id": 2, "tag_list": ["linux", "x86_64"]}, {"id": 3, "tag_list": ["arm64"]}]`))
+		case "running":
+			w.Write([]byte(`[{"id": 4, "tag_list": ["x86_64"]}]`))
+		default:
+			w.Write([]byte("[]"))
+		}
+	}))
+
+	state := client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{
+		TagAliases: map[string]string{"linux": "amd64", "x86_64": "amd64"},
+	})
+
+	assert.Equal(t, map[string]int{"amd64": 2, "arm64": 1}, state.PendingJobsWithTags)
+	assert.Equal(t, map[string]int{"amd64": 1}, state.RunningJobsWithTags)
+	assert.Equal(t, []JobSummary{{Tags: []string{"amd64"}}, {Tags: []string{"amd64"}}, {Tags: []string{"arm64"}}}, state.PendingJobList)
+}
+
+// TestCalculateClusterState_SizeTags verifies the size of jobs is read from their size tags
+// Expected behavior:
+//   - "size-3x" is size 3; of "size-2x" and "size-4x" the larger counts
+//   - Malformed size tags ("size-big", "size-0x") and "size-1x" leave the job unsized, and the malformed ones are logged
+//   - Tag counts are unchanged: size tags are ordinary tags for matching
+//   - Without a prefix no job is sized
+func TestCalculateClusterState_SizeTags(t *testing.T) {
+	client := newTestClient(t, serveFixtures(t, "pending_jobs_mixed_sizes.json"))
+	var logs bytes.Buffer
+	log.SetOutput(&logs)
+	defer log.SetOutput(os.Stderr)
+
+	state := client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{SizeTagPrefix: "size-"})
+
+	sizes := make([]int64, 0, len(state.PendingJobList))
+	for _, job := range state.PendingJobList {
+		sizes = append(sizes, job.Size)
+	}
+	assert.Equal(t, []int64{0, 3, 4, 0, 0, 0}, sizes)
+	assert.Equal(t, 4, state.PendingJobsWithTags["amd64"])
+	assert.Contains(t, logs.String(), "tag=size-big")
+	assert.Contains(t, logs.String(), "tag=size-0x")
+	assert.NotContains(t, logs.String(), "tag=size-1x")
+
+	state = client.CalculateClusterState([]Project{{ID: 1, Name: "app"}}, StateOptions{})
+	for _, job := range state.PendingJobList {
+		assert.Zero(t, job.Size)
+	}
+}
+
+// TestParseSize verifies only a positive integer followed by "x" is a size
+func TestParseSize(t *testing.T) {
+	for multiplier, expected := range map[string]int64{"1x": 1, "3x": 3, "12x": 12} {
+		size, err := parseSize(multiplier)
+		assert.NoError(t, err, multiplier)
+		assert.Equal(t, expected, size, multiplier)
+	}
+	for _, multiplier := range []string{"", "x", "3", "0x", "-2x", "2.5x", "big", "3X", "x3"} {
+		_, err := parseSize(multiplier)
+		assert.Error(t, err, multiplier)
+	}
+}
+
+// TestCalculateClusterState_ResourceGroups verifies jobs waiting on the same resource group count once
+// Expected behavior:
+//   - Five pending "amd64" jobs share the "production" resource group: demand 1, 4 deferred
+//   - The oldest job of the group is kept, so its wait time is reported
+//   - Without respect-resource-groups all five count
+func TestCalculateClusterState_ResourceGroups(t *testing.T) {
+	fixtures := serveFixtures(t, "pending_jobs_resource_group.json")
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v4/projects/1/resource_groups":
+			w.Write([]byte(`[{"id": 1, "key": "production"}]`))
+		case "/api/v4/projects/1/resource_groups/production/upcoming_jobs":
+			w.Write([]byte(`[{"id": 201}, {"id": 202}, {"id": 203}, {"id": 204}, {"id": 205}]`))
+		default:
+			fixtures.ServeHTTP(w, r)
+		}
+	}))
+	projects := []Project{{ID: 1, Name: "app"}}
+
+	state := client.CalculateClusterState(projects, StateOptions{})
+	assert.Equal(t, int64(5), state.TotalPendingJobs)
+
+	client.respectResourceGroups = true
+	state = client.CalculateClusterState(projects, StateOptions{})
+	assert.Equal(t, int64(1), state.TotalPendingJobs)
+	assert.Equal(t, map[string]int{"amd64": 1}, state.PendingJobsWithTags)
+	assert.Equal(t, int64(4), state.DeferredJobs)
+	assert.Equal(t, time.Date(2024, 5, 6, 8, 55, 0, 0, time.UTC), state.OldestPendingJobWithTags["amd64"])
+}
+
+// TestCalculateClusterState_HostileNamesLogged verifies project names and tags from GitLab cannot forge log lines
+// Expected behavior:
+//   - The project line is logged as a single line without ESC characters, followed by the fetch summary
+//   - The embedded newline of the name is shown escaped
+func TestCalculateClusterState_HostileNamesLogged(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("scope") == "pending" {
+			w.Write([]byte(`[{"id": 1, "tag_list": ["amd64\n2024/05/06 09:00:00 forged", "\u001b[2J"], "created_at": "2024-05-06T08:58:00Z"}]`))
+			return
+		}
+		w.Write([]byte("[]"))
+	}))
+
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	t.Cleanup(func() { log.SetOutput(os.Stderr) })
+
+	client.CalculateClusterState([]Project{{ID: 1, Name: "app\n2024/05/06 09:00:00 Project: forged\x1b[31m"}}, StateOptions{})
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+	assert.Contains(t, lines[1], "GitLab fetch summary")
+	output := lines[0]
+	assert.NotContains(t, output, "\x1b[2J")
+	assert.NotContains(t, output, "forged\x1b")
+	assert.Contains(t, output, `app\n2024/05/06 09:00:00 Project: forged`)
+	assert.Contains(t, output, `amd64\n2024/05/06 09:00:00 forged`)
+}
@@ -0,0 +1,80 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	groupRunnersAPITemplate = "https://gitlab.com/api/v4/groups/%s/runners?per_page=100&page=%d"
+	runnerJobsAPITemplate   = "https://gitlab.com/api/v4/runners/%d/jobs?status=running&per_page=100"
+)
+
+// RunnerControl pauses and resumes the runners of a group, so that instances can be drained before a scale-down
+// removes them. Runners are matched to instances by the instance ID in their description.
+type RunnerControl struct {
+	client    *Client
+	groupName string
+}
+
+// NewRunnerControl returns the runner control of the group; the token needs at least the maintainer role in it
+func NewRunnerControl(client *Client, groupName string) *RunnerControl {
+	return &RunnerControl{client: client, groupName: groupName}
+}
+
+// InstanceRunners returns the IDs of the group runners registered from each of the instances
+func (r *RunnerControl) InstanceRunners(instanceIDs []string) (map[string][]int, error) {
+	runners, err := r.client.fetchRunnerPages(func(page int) string {
+		return fmt.Sprintf(groupRunnersAPITemplate, url.PathEscape(r.groupName), page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching runners of group %s: %w", r.groupName, err)
+	}
+
+	byInstance := make(map[string][]int)
+	for _, instanceID := range instanceIDs {
+		for _, runner := range runners {
+			if instanceID != "" && strings.Contains(runner.Description, instanceID) {
+				byInstance[instanceID] = append(byInstance[instanceID], runner.ID)
+			}
+		}
+	}
+	return byInstance, nil
+}
+
+// SetRunnerPaused pauses a runner, so that it picks up no new jobs, or resumes it. It sets paused, which replaced
+// the deprecated active=false
+func (r *RunnerControl) SetRunnerPaused(runnerID int, paused bool) error {
+	form := url.Values{"paused": {fmt.Sprint(paused)}}
+	req, err := http.NewRequest(http.MethodPut, fmt.Sprintf(runnerAPITemplate, runnerID), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", r.client.token)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := r.client.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("error updating runner %d: status=%s", runnerID, resp.Status)
+	}
+	return nil
+}
+
+// RunningJobs returns how many jobs the runner is running; up to one page of jobs is counted, enough to tell
+// whether it is idle
+func (r *RunnerControl) RunningJobs(runnerID int) (int, error) {
+	var jobs []struct {
+		ID int `json:"id"`
+	}
+	if _, err := r.client.getJSON(fmt.Sprintf(runnerJobsAPITemplate, runnerID), &jobs); err != nil {
+		return 0, fmt.Errorf("error fetching running jobs of runner %d: %w", runnerID, err)
+	}
+	return len(jobs), nil
+}
@@ -0,0 +1,49 @@
+package gitlab
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRunnerControl verifies runners are matched to instances and paused through the runners API
+// Expected behavior:
+//   - All group runners are listed without a tag filter; runners #1 and #3 run on i-0a, none on i-0c
+//   - Pausing runner #1 sends PUT /runners/1 with paused=true
+//   - The running jobs of runner #3 are counted from its jobs with status=running
+func TestRunnerControl(t *testing.T) {
+	var paused string
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/mygroup/runners", func(w http.ResponseWriter, r *http.Request) {
+		assert.False(t, r.URL.Query().Has("tag_list"))
+		fmt.Fprint(w, `[{"id":1,"description":"runner i-0a"},{"id":2,"description":"runner i-0b"},{"id":3,"description":"docker i-0a"}]`)
+	})
+	mux.HandleFunc("/api/v4/runners/1", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		body, _ := io.ReadAll(r.Body)
+		paused = string(body)
+		fmt.Fprint(w, `{"id":1}`)
+	})
+	mux.HandleFunc("/api/v4/runners/3/jobs", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "running", r.URL.Query().Get("status"))
+		fmt.Fprint(w, `[{"id":100},{"id":101}]`)
+	})
+	control := NewRunnerControl(newTestClient(t, mux), "mygroup")
+
+	runners, err := control.InstanceRunners([]string{"i-0a", "i-0c"})
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]int{"i-0a": {1, 3}}, runners)
+
+	require.NoError(t, control.SetRunnerPaused(1, true))
+	assert.Equal(t, "paused=true", paused)
+
+	jobs, err := control.RunningJobs(3)
+	require.NoError(t, err)
+	assert.Equal(t, 2, jobs)
+
+	assert.ErrorContains(t, control.SetRunnerPaused(4, false), "error updating runner 4: status=404")
+}
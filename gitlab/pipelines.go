@@ -0,0 +1,24 @@
+package gitlab
+
+import "fmt"
+
+const pipelinesAPITemplate = "https://gitlab.com/api/v4/projects/%s/pipelines?scope=%s&per_page=100"
+
+// Pipeline represents a CI pipeline as returned by the GitLab pipelines API
+type Pipeline struct {
+	ID     int    `json:"id"`
+	Status string `json:"status"`
+}
+
+// FetchActivePipelines fetches the running and pending pipelines of a project (see Project.Ref)
+func (c *Client) FetchActivePipelines(projectRef string) ([]Pipeline, error) {
+	var active []Pipeline
+	for _, scope := range []string{"running", "pending"} {
+		var pipelines []Pipeline
+		if _, err := c.getJSON(fmt.Sprintf(pipelinesAPITemplate, projectRef, scope), &pipelines); err != nil {
+			return nil, fmt.Errorf("error fetching %s pipelines for project %s: %w", scope, projectRef, err)
+		}
+		active = append(active, pipelines...)
+	}
+	return active, nil
+}
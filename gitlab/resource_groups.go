@@ -0,0 +1,61 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/url"
+)
+
+const (
+	resourceGroupsAPITemplate = "https://gitlab.com/api/v4/projects/%s/resource_groups"
+	upcomingJobsAPITemplate   = "https://gitlab.com/api/v4/projects/%s/resource_groups/%s/upcoming_jobs"
+)
+
+// ResourceGroup is a GitLab resource group that serializes the jobs using it
+type ResourceGroup struct {
+	ID  int    `json:"id"`
+	Key string `json:"key"`
+}
+
+// FetchResourceGroupJobs returns the resource group key of every upcoming job of a project (see Project.Ref)
+func (c *Client) FetchResourceGroupJobs(projectRef string) (map[int]string, error) {
+	var groups []ResourceGroup
+	if _, err := c.getJSON(fmt.Sprintf(resourceGroupsAPITemplate, projectRef), &groups); err != nil {
+		return nil, fmt.Errorf("error fetching resource groups for project %s: %w", projectRef, err)
+	}
+
+	jobGroups := make(map[int]string)
+	for _, group := range groups {
+		var jobs []Job
+		if _, err := c.getJSON(fmt.Sprintf(upcomingJobsAPITemplate, projectRef, url.PathEscape(group.Key)), &jobs); err != nil {
+			return nil, fmt.Errorf("error fetching upcoming jobs of resource group %s in project %s: %w", group.Key, projectRef, err)
+		}
+		for _, job := range jobs {
+			jobGroups[job.ID] = group.Key
+		}
+	}
+	return jobGroups, nil
+}
+
+// serializeResourceGroups keeps only the oldest pending job per resource group, since the others cannot run
+// concurrently, and returns the number of deferred jobs
+func serializeResourceGroups(pending []Job, jobGroups map[int]string) ([]Job, int) {
+	first := make(map[string]int)
+	for _, job := range pending {
+		if group, ok := jobGroups[job.ID]; ok {
+			if id, seen := first[group]; !seen || job.ID < id {
+				first[group] = job.ID
+			}
+		}
+	}
+
+	kept := make([]Job, 0, len(pending))
+	deferred := 0
+	for _, job := range pending {
+		if group, ok := jobGroups[job.ID]; ok && first[group] != job.ID {
+			deferred++
+			continue
+		}
+		kept = append(kept, job)
+	}
+	return kept, deferred
+}
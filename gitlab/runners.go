@@ -0,0 +1,254 @@
+package gitlab
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+const (
+	runnersAPIBaseTemplate = "https://gitlab.com/api/v4/groups/%s/runners?tag_list=%s&per_page=100&page=%d"
+	runnerAPITemplate      = "https://gitlab.com/api/v4/runners/%d"
+	currentUserAPI         = "https://gitlab.com/api/v4/user"
+	groupMemberAPITemplate = "https://gitlab.com/api/v4/groups/%s/members/all/%d"
+	groupAPITemplate       = "https://gitlab.com/api/v4/groups/%s"
+	projectAPITemplate     = "https://gitlab.com/api/v4/projects/%s"
+	ownerAccessLevel       = 50
+	runnerStatusOffline    = "offline"
+	runnerStatusStale      = "stale"
+	runnerStatusOnline     = "online"
+)
+
+// Runner represents a GitLab runner as returned by the runners API
+type Runner struct {
+	ID          int        `json:"id"`
+	Description string     `json:"description"`
+	Online      bool       `json:"online"`
+	Status      string     `json:"status"`
+	ContactedAt *time.Time `json:"contacted_at"`
+	CreatedAt   *time.Time `json:"created_at"`
+}
+
+// FetchGroupRunners fetches all runners of a group carrying the given tag, following pagination
+func (c *Client) FetchGroupRunners(groupName, tag string) ([]Runner, error) {
+	runners, err := c.fetchRunnerPages(func(page int) string {
+		return fmt.Sprintf(runnersAPIBaseTemplate, url.PathEscape(groupName), url.QueryEscape(tag), page)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error fetching runners with tag %s: %w", tag, err)
+	}
+	return runners, nil
+}
+
+// fetchRunnerPages fetches the runners of every page of a runners list, following pagination
+func (c *Client) fetchRunnerPages(pageURL func(page int) string) ([]Runner, error) {
+	var allRunners []Runner
+	page := 1
+	for page > 0 {
+		var runners []Runner
+		header, err := c.getJSON(pageURL(page), &runners)
+		if err != nil {
+			return nil, err
+		}
+		allRunners = append(allRunners, runners...)
+		page, _ = strconv.Atoi(header.Get("X-Next-Page"))
+	}
+	return allRunners, nil
+}
+
+// CountOnlineRunnersWithTags counts online group runners for each of the given tags
+func (c *Client) CountOnlineRunnersWithTags(groupName string, tags []string) (map[string]int, error) {
+	onlineRunnersWithTags := make(map[string]int)
+	for _, tag := range tags {
+		if _, done := onlineRunnersWithTags[tag]; done {
+			continue
+		}
+		runners, err := c.FetchGroupRunners(groupName, tag)
+		if err != nil {
+			return nil, err
+		}
+		online := 0
+		for _, runner := range runners {
+			if runner.Online || runner.Status == runnerStatusOnline {
+				online++
+			}
+		}
+		onlineRunnersWithTags[tag] = online
+	}
+	return onlineRunnersWithTags, nil
+}
+
+// CheckAccess verifies with read-only calls that the token is valid and can read the configured group or projects
+func (c *Client) CheckAccess(cfg config.GitLabConfig) error {
+	var user struct {
+		ID int `json:"id"`
+	}
+	if _, err := c.getJSON(currentUserAPI, &user); err != nil {
+		return fmt.Errorf("error fetching token owner: %w", err)
+	}
+	if cfg.Group != "" {
+		var group struct {
+			ID int `json:"id"`
+		}
+		if _, err := c.getJSON(fmt.Sprintf(groupAPITemplate, url.PathEscape(cfg.Group)), &group); err != nil {
+			return fmt.Errorf("error fetching group %s: %w", cfg.Group, err)
+		}
+		return nil
+	}
+	for _, project := range ProjectsFromConfig(cfg.Projects) {
+		var found struct {
+			ID int `json:"id"`
+		}
+		if _, err := c.getJSON(fmt.Sprintf(projectAPITemplate, project.Ref()), &found); err != nil {
+			return fmt.Errorf("error fetching project %s: %w", project.Name, err)
+		}
+	}
+	return nil
+}
+
+// CheckRunnerCleanupAccess verifies the token belongs to an instance admin or a group owner,
+// which is required to delete group runners
+func (c *Client) CheckRunnerCleanupAccess(groupName string) error {
+	var user struct {
+		ID      int  `json:"id"`
+		IsAdmin bool `json:"is_admin"`
+	}
+	if _, err := c.getJSON(currentUserAPI, &user); err != nil {
+		return fmt.Errorf("error fetching token owner: %w", err)
+	}
+	if user.IsAdmin {
+		return nil
+	}
+
+	var member struct {
+		AccessLevel int `json:"access_level"`
+	}
+	if _, err := c.getJSON(fmt.Sprintf(groupMemberAPITemplate, url.PathEscape(groupName), user.ID), &member); err != nil {
+		return fmt.Errorf("error fetching group membership of token owner: %w", err)
+	}
+	if member.AccessLevel < ownerAccessLevel {
+		return fmt.Errorf("token owner has access level %d in group %s, owner (%d) or admin is required to delete runners",
+			member.AccessLevel, groupName, ownerAccessLevel)
+	}
+	return nil
+}
+
+// CleanupOfflineRunners deletes group runners carrying any of the given tags that have been offline
+// for longer than maxAge. In dry-run mode the runners are only logged.
+func (c *Client) CleanupOfflineRunners(groupName string, tags []string, maxAge time.Duration, dryRun bool, now time.Time) error {
+	seen := make(map[int]bool)
+	for _, tag := range tags {
+		runners, err := c.FetchGroupRunners(groupName, tag)
+		if err != nil {
+			return err
+		}
+
+		for _, runner := range runners {
+			if seen[runner.ID] || runner.Online || !isOfflineStatus(runner.Status) {
+				continue
+			}
+			seen[runner.ID] = true
+
+			// The list endpoint does not include the last contact time, fetch runner details
+			var details Runner
+			if _, err := c.getJSON(fmt.Sprintf(runnerAPITemplate, runner.ID), &details); err != nil {
+				slog.Error("Error fetching runner", "runner", runner.ID, "error", err)
+				continue
+			}
+			// A runner that never contacted GitLab, e.g. whose instance failed to boot, is offline since its registration
+			lastSeen := details.ContactedAt
+			if lastSeen == nil {
+				lastSeen = details.CreatedAt
+			}
+			if lastSeen == nil {
+				continue
+			}
+			offlineFor := now.Sub(*lastSeen)
+			if offlineFor <= maxAge {
+				continue
+			}
+
+			if dryRun {
+				slog.Info("[dry-run] Would delete offline runner", "runner", runner.ID, "description", runner.Description,
+					"tag", tag, "offline_for", offlineFor.Round(time.Second))
+				continue
+			}
+
+			if err := c.deleteRunner(runner.ID); err != nil {
+				slog.Error("Error deleting runner", "runner", runner.ID, "error", err)
+				continue
+			}
+			slog.Info("Deleted offline runner", "runner", runner.ID, "description", runner.Description,
+				"tag", tag, "offline_for", offlineFor.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// isOfflineStatus reports whether a runner status means the runner is not connected
+func isOfflineStatus(status string) bool {
+	return status == runnerStatusOffline || status == runnerStatusStale
+}
+
+// deleteRunner removes a runner registration
+func (c *Client) deleteRunner(runnerID int) error {
+	req, err := http.NewRequest("DELETE", fmt.Sprintf(runnerAPITemplate, runnerID), nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("PRIVATE-TOKEN", c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent && resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("status=%s", resp.Status)
+	}
+	return nil
+}
+
+// getJSON performs an authenticated GET request, retrying on 429, and decodes the JSON body into out
+func (c *Client) getJSON(requestURL string, out interface{}) (http.Header, error) {
+	for attempt := 0; attempt < maxRetries; attempt++ {
+		req, err := http.NewRequest("GET", requestURL, nil)
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set("PRIVATE-TOKEN", c.token)
+
+		resp, err := c.httpClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			closeBody(resp.Body)
+			waitDuration := time.Duration(2<<attempt) * time.Second
+			slog.Warn("Received 429 Too Many Requests, retrying", "retry_in", waitDuration)
+			time.Sleep(waitDuration)
+			continue
+		}
+
+		if resp.StatusCode != http.StatusOK {
+			closeBody(resp.Body)
+			return nil, fmt.Errorf("status=%s", resp.Status)
+		}
+
+		err = json.NewDecoder(resp.Body).Decode(out)
+		closeBody(resp.Body)
+		if err != nil {
+			return nil, err
+		}
+		return resp.Header, nil
+	}
+	return nil, fmt.Errorf("failed after %d attempts", maxRetries)
+}
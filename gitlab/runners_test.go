@@ -0,0 +1,147 @@
+package gitlab
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// TestCleanupOfflineRunners verifies that only runners offline longer than the max age are deleted,
+// across paginated runner lists.
+//
+// Expected behavior:
+//   - Runner 1 (online) is kept
+//   - Runner 2 (offline for 2h) is deleted
+//   - Runner 3 (offline for 30m) is kept
+//   - Runner 4 on the second page (offline for 3h) is deleted
+//   - Runner 5 (never contacted, registered 2h ago) is deleted, runner 6 (never contacted, registered 10m ago) is kept
+func TestCleanupOfflineRunners(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+	contacted := map[int]time.Time{
+		2: now.Add(-2 * time.Hour),
+		3: now.Add(-30 * time.Minute),
+		4: now.Add(-3 * time.Hour),
+	}
+	created := map[int]time.Time{
+		5: now.Add(-2 * time.Hour),
+		6: now.Add(-10 * time.Minute),
+	}
+
+	var mu sync.Mutex
+	var deleted []int
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/mygroup/runners", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "amd64", r.URL.Query().Get("tag_list"))
+		if r.URL.Query().Get("page") == "1" {
+			w.Header().Set("X-Next-Page", "2")
+			fmt.Fprint(w, `[{"id":1,"online":true,"status":"online"},{"id":2,"status":"offline"},{"id":3,"status":"offline"}]`)
+			return
+		}
+		fmt.Fprint(w, `[{"id":4,"status":"stale"},{"id":5,"status":"offline"},{"id":6,"status":"offline"}]`)
+	})
+	mux.HandleFunc("/api/v4/runners/", func(w http.ResponseWriter, r *http.Request) {
+		var id int
+		fmt.Sscanf(r.URL.Path, "/api/v4/runners/%d", &id)
+		if r.Method == http.MethodDelete {
+			mu.Lock()
+			deleted = append(deleted, id)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		if createdAt, ok := created[id]; ok {
+			fmt.Fprintf(w, `{"id":%d,"status":"offline","contacted_at":null,"created_at":%q}`, id, createdAt.Format(time.RFC3339))
+			return
+		}
+		fmt.Fprintf(w, `{"id":%d,"status":"offline","contacted_at":%q}`, id, contacted[id].Format(time.RFC3339))
+	})
+	client := newTestClient(t, mux)
+
+	err := client.CleanupOfflineRunners("mygroup", []string{"amd64"}, time.Hour, false, now)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 4, 5}, deleted)
+}
+
+// TestCleanupOfflineRunners_DryRun verifies that dry-run mode never calls the delete API
+func TestCleanupOfflineRunners_DryRun(t *testing.T) {
+	now := time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/groups/mygroup/runners", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[{"id":2,"status":"offline"}]`)
+	})
+	mux.HandleFunc("/api/v4/runners/2", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			t.Errorf("runner must not be deleted in dry-run mode")
+		}
+		fmt.Fprintf(w, `{"id":2,"status":"offline","contacted_at":%q}`, now.Add(-48*time.Hour).Format(time.RFC3339))
+	})
+	client := newTestClient(t, mux)
+
+	err := client.CleanupOfflineRunners("mygroup", []string{"amd64"}, time.Hour, true, now)
+	assert.NoError(t, err)
+}
+
+// TestCheckRunnerCleanupAccess verifies group owners pass and maintainers are rejected
+func TestCheckRunnerCleanupAccess(t *testing.T) {
+	accessLevel := 50
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":7}`)
+	})
+	mux.HandleFunc("/api/v4/groups/mygroup/members/all/7", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `{"access_level":%d}`, accessLevel)
+	})
+	client := newTestClient(t, mux)
+
+	assert.NoError(t, client.CheckRunnerCleanupAccess("mygroup"))
+
+	accessLevel = 40
+	err := client.CheckRunnerCleanupAccess("mygroup")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "access level 40")
+}
+
+// TestCheckAccess verifies the token and the configured group or projects are checked with read-only calls
+// Expected behavior:
+//   - A readable group passes
+//   - A project the token cannot read is rejected naming it
+//   - An invalid token is rejected before the group is read
+func TestCheckAccess(t *testing.T) {
+	validToken := true
+	mux := http.NewServeMux()
+	mux.HandleFunc("/api/v4/user", func(w http.ResponseWriter, r *http.Request) {
+		if !validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		fmt.Fprint(w, `{"id":7}`)
+	})
+	mux.HandleFunc("/api/v4/groups/mygroup", func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodGet, r.Method)
+		fmt.Fprint(w, `{"id":1}`)
+	})
+	mux.HandleFunc("/api/v4/projects/42", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"id":42}`)
+	})
+	mux.HandleFunc("/api/v4/projects/43", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	client := newTestClient(t, mux)
+
+	assert.NoError(t, client.CheckAccess(config.GitLabConfig{Group: "mygroup"}))
+
+	err := client.CheckAccess(config.GitLabConfig{Projects: []string{"42", "43"}})
+	assert.ErrorContains(t, err, "error fetching project 43")
+
+	validToken = false
+	err = client.CheckAccess(config.GitLabConfig{Group: "mygroup"})
+	assert.ErrorContains(t, err, "error fetching token owner")
+}
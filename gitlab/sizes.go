@@ -0,0 +1,67 @@
+package gitlab
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+)
+
+// jobSize returns the job slots a job with tags needs according to its size tags, e.g. 3 for "size-3x" with the
+// prefix "size-"; 1 without a size tag or prefix. With several size tags the largest counts. Malformed size tags
+// are returned so that they can be reported, and count as 1.
+func jobSize(prefix string, tags []string) (int64, []string) {
+	size := int64(1)
+	if prefix == "" {
+		return size, nil
+	}
+	var malformed []string
+	for _, tag := range tags {
+		multiplier, ok := strings.CutPrefix(tag, prefix)
+		if !ok {
+			continue
+		}
+		n, err := parseSize(multiplier)
+		if err != nil {
+			malformed = append(malformed, tag)
+			continue
+		}
+		size = max(size, n)
+	}
+	return size, malformed
+}
+
+// parseSize parses the multiplier of a size tag, a positive integer followed by "x" as in "3x"
+func parseSize(multiplier string) (int64, error) {
+	digits, ok := strings.CutSuffix(multiplier, "x")
+	if !ok {
+		return 0, fmt.Errorf("size %q does not end with x", multiplier)
+	}
+	n, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("size %q is not a positive multiplier", multiplier)
+	}
+	return n, nil
+}
+
+// sizeJobs sets the size of every job summary from its size tags, logging each malformed size tag once
+func sizeJobs(prefix string, jobLists ...[]JobSummary) {
+	if prefix == "" {
+		return
+	}
+	reported := make(map[string]bool)
+	for _, jobs := range jobLists {
+		for i := range jobs {
+			size, malformed := jobSize(prefix, jobs[i].Tags)
+			if size > 1 {
+				jobs[i].Size = size
+			}
+			for _, tag := range malformed {
+				if !reported[tag] {
+					reported[tag] = true
+					slog.Warn("Malformed size tag, the job counts as size 1", "tag", tag, "expected", prefix+"<n>x")
+				}
+			}
+		}
+	}
+}
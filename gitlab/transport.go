@@ -0,0 +1,133 @@
+package gitlab
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+const (
+	defaultRequestTimeout      = 25 * time.Second
+	defaultMaxIdleConns        = 100
+	defaultMaxIdleConnsPerHost = 32 // all requests go to the same GitLab host
+)
+
+// Client performs GitLab API requests on behalf of a single token
+type Client struct {
+	token                 string
+	httpClient            *http.Client
+	countBridgeJobs       bool
+	respectResourceGroups bool
+}
+
+// Metrics receives the failed requests of a client, e.g. to count them in a metrics registry
+type Metrics interface {
+	APIError(code string) // HTTP status code of the failed request, or "network" when no response was received
+}
+
+// NewClient builds a GitLab API client with proxy, TLS, timeout and connection pool settings from the configuration;
+// failed requests are published into metrics unless it is nil
+func NewClient(cfg config.GitLabConfig, metrics Metrics) (*Client, error) {
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if metrics != nil {
+		httpClient.Transport = &countingTransport{next: httpClient.Transport, metrics: metrics}
+	}
+	return &Client{
+		token:                 cfg.Token,
+		httpClient:            httpClient,
+		countBridgeJobs:       cfg.CountBridgeJobs,
+		respectResourceGroups: cfg.RespectResourceGroups,
+	}, nil
+}
+
+// WrapTransport replaces the HTTP transport with a wrapper around it (used for fault injection). Failed requests are
+// still counted with the failures of the wrapper, e.g. injected faults.
+func (c *Client) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	if counting, ok := c.httpClient.Transport.(*countingTransport); ok {
+		counting.next = wrap(counting.next)
+		return
+	}
+	c.httpClient.Transport = wrap(c.httpClient.Transport)
+}
+
+// countingTransport publishes requests answered with an error status or without an answer into metrics
+type countingTransport struct {
+	next    http.RoundTripper
+	metrics Metrics
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	switch {
+	case err != nil:
+		t.metrics.APIError("network")
+	case resp.StatusCode >= http.StatusBadRequest:
+		t.metrics.APIError(strconv.Itoa(resp.StatusCode))
+	}
+	return resp, err
+}
+
+// newHTTPClient builds the HTTP client for GitLab API requests from the proxy, TLS, timeout and pool settings
+func newHTTPClient(cfg config.GitLabConfig) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConns = defaultMaxIdleConns
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+	transport.MaxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	if cfg.MaxIdleConnsPerHost > 0 {
+		transport.MaxIdleConnsPerHost = cfg.MaxIdleConnsPerHost
+	}
+
+	if cfg.Proxy != "" {
+		proxyURL, err := url.Parse(cfg.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gitlab.proxy %q: %w", cfg.Proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	if cfg.CACertFile != "" || cfg.TLSInsecureSkipVerify {
+		tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+		if cfg.CACertFile != "" {
+			pem, err := os.ReadFile(cfg.CACertFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read gitlab.ca-cert-file: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in gitlab.ca-cert-file %s", cfg.CACertFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		if cfg.TLSInsecureSkipVerify {
+			slog.Warn("TLS certificate verification is disabled for GitLab requests")
+			tlsConfig.InsecureSkipVerify = true
+		}
+		transport.TLSClientConfig = tlsConfig
+	}
+
+	timeout := cfg.RequestTimeout
+	if timeout == 0 {
+		timeout = defaultRequestTimeout
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package gitlab
+
+import (
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+)
+
+// writeServerCA stores the self-signed certificate of a TLS test server as a PEM file
+func writeServerCA(t *testing.T, server *httptest.Server) string {
+	path := filepath.Join(t.TempDir(), "ca.pem")
+	block := &pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(block), 0600))
+	return path
+}
+
+// TestNewHTTPClient_CACertFile verifies a self-signed GitLab endpoint is trusted only with its CA configured
+// Expected behavior:
+//   - Default client fails TLS verification
+//   - Client with ca-cert-file succeeds
+//   - Client with tls-insecure-skip-verify succeeds
+func TestNewHTTPClient_CACertFile(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := newHTTPClient(config.GitLabConfig{})
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	assert.Error(t, err)
+
+	client, err = newHTTPClient(config.GitLabConfig{CACertFile: writeServerCA(t, server)})
+	require.NoError(t, err)
+	resp, err := client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	client, err = newHTTPClient(config.GitLabConfig{TLSInsecureSkipVerify: true})
+	require.NoError(t, err)
+	resp, err = client.Get(server.URL)
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+// TestNewHTTPClient_Proxy verifies requests are sent through the configured proxy
+func TestNewHTTPClient_Proxy(t *testing.T) {
+	var proxiedHost string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxiedHost = r.Host
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer proxy.Close()
+
+	client, err := newHTTPClient(config.GitLabConfig{Proxy: proxy.URL})
+	require.NoError(t, err)
+
+	resp, err := client.Get("http://gitlab.example.internal/api/v4/user")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "gitlab.example.internal", proxiedHost)
+}
+
+// TestNewHTTPClient_Pool verifies the connection pool defaults and overrides
+func TestNewHTTPClient_Pool(t *testing.T) {
+	client, err := newHTTPClient(config.GitLabConfig{})
+	require.NoError(t, err)
+	transport := client.Transport.(*http.Transport)
+	assert.Equal(t, 100, transport.MaxIdleConns)
+	assert.Equal(t, 32, transport.MaxIdleConnsPerHost)
+
+	client, err = newHTTPClient(config.GitLabConfig{MaxIdleConns: 400, MaxIdleConnsPerHost: 200})
+	require.NoError(t, err)
+	transport = client.Transport.(*http.Transport)
+	assert.Equal(t, 400, transport.MaxIdleConns)
+	assert.Equal(t, 200, transport.MaxIdleConnsPerHost)
+}
+
+// TestNewHTTPClient_Timeout verifies the request timeout default and override
+func TestNewHTTPClient_Timeout(t *testing.T) {
+	client, err := newHTTPClient(config.GitLabConfig{})
+	require.NoError(t, err)
+	assert.Equal(t, 25*time.Second, client.Timeout)
+
+	client, err = newHTTPClient(config.GitLabConfig{RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+	assert.Equal(t, 5*time.Second, client.Timeout)
+}
+
+// TestNewHTTPClient_InvalidCACertFile verifies unreadable or empty CA files are reported
+func TestNewHTTPClient_InvalidCACertFile(t *testing.T) {
+	_, err := newHTTPClient(config.GitLabConfig{CACertFile: filepath.Join(t.TempDir(), "missing.pem")})
+	assert.Error(t, err)
+
+	empty := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(empty, []byte("not a certificate"), 0600))
+	_, err = newHTTPClient(config.GitLabConfig{CACertFile: empty})
+	assert.Error(t, err)
+}
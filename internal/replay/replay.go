@@ -0,0 +1,214 @@
+// Package replay records GitLab API responses into cassette files and replays them offline,
+// so integration tests run deterministically without credentials or network access
+package replay
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// RecordEnv switches tests to record mode when set to "1"; recording talks to the real GitLab API
+const RecordEnv = "GITLAB_AUTOSCALER_RECORD"
+
+// ErrNoRecording is returned (wrapped) when a replayed request has no recorded response
+var ErrNoRecording = errors.New("no recorded response")
+
+// Mode selects whether a Transport replays a cassette or records a new one
+type Mode int
+
+const (
+	Replay Mode = iota // Serve responses from the cassette, never touching the network
+	Record             // Forward requests to the wrapped transport and save the sanitized responses
+)
+
+// ModeFromEnv returns Record when RecordEnv is set to "1" and Replay otherwise
+func ModeFromEnv() Mode {
+	if os.Getenv(RecordEnv) == "1" {
+		return Record
+	}
+	return Replay
+}
+
+// Interaction is a single recorded request and its response
+type Interaction struct {
+	Method string      `json:"method"`
+	URL    string      `json:"url"`
+	Status int         `json:"status"`
+	Header http.Header `json:"header,omitempty"`
+	// Body is the JSON response body, omitted when empty; a non-JSON body is stored (and replayed) as a JSON string
+	Body json.RawMessage `json:"body,omitempty"`
+}
+
+// Cassette is the content of a fixture file
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// Load reads a cassette from path
+func Load(path string) (*Cassette, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cassette: %w", err)
+	}
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("parsing cassette %s: %w", path, err)
+	}
+	return &cassette, nil
+}
+
+// Save writes the cassette to path, creating its directory. Interactions are ordered by request
+// so that re-recording concurrent requests yields a stable diff.
+func (c *Cassette) Save(path string) error {
+	sort.SliceStable(c.Interactions, func(i, j int) bool {
+		if c.Interactions[i].URL != c.Interactions[j].URL {
+			return c.Interactions[i].URL < c.Interactions[j].URL
+		}
+		return c.Interactions[i].Method < c.Interactions[j].Method
+	})
+	data, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("creating cassette directory: %w", err)
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Transport is an http.RoundTripper that records or replays GitLab API responses.
+// Requests are matched by method and normalized URL (see Sanitizer.Key); several responses
+// recorded for the same request are served in order, the last one repeating once exhausted.
+type Transport struct {
+	mode      Mode
+	path      string
+	sanitizer Sanitizer
+	next      http.RoundTripper
+
+	mu       sync.Mutex
+	cassette *Cassette
+	served   map[string]int // Responses served per request key in replay mode
+}
+
+// New creates a transport for the cassette at path. Replay mode loads the cassette right away;
+// record mode starts an empty one that Save writes.
+func New(path string, mode Mode, sanitizer Sanitizer) (*Transport, error) {
+	t := &Transport{
+		mode:      mode,
+		path:      path,
+		sanitizer: sanitizer,
+		cassette:  &Cassette{},
+		served:    make(map[string]int),
+	}
+	if mode == Replay {
+		cassette, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		t.cassette = cassette
+	}
+	return t, nil
+}
+
+// Wrap sets the transport that record mode forwards requests to and returns t; it matches
+// gitlab.Client.WrapTransport
+func (t *Transport) Wrap(next http.RoundTripper) http.RoundTripper {
+	t.next = next
+	return t
+}
+
+// Save writes the recorded cassette; it does nothing in replay mode
+func (t *Transport) Save() error {
+	if t.mode != Record {
+		return nil
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.cassette.Save(t.path)
+}
+
+// RoundTrip serves the request from the cassette or, in record mode, from the wrapped transport
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if t.mode == Record {
+		return t.record(req)
+	}
+	return t.replay(req)
+}
+
+// record forwards the request and appends the sanitized response to the cassette
+func (t *Transport) record(req *http.Request) (*http.Response, error) {
+	if t.next == nil {
+		return nil, fmt.Errorf("replay: record mode needs a wrapped transport")
+	}
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	closeErr := resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("reading response of %s %s: %w", req.Method, t.sanitizer.URL(req.URL), err)
+	}
+	if closeErr != nil {
+		return nil, closeErr
+	}
+
+	interaction := Interaction{
+		Method: req.Method,
+		URL:    t.sanitizer.URL(req.URL),
+		Status: resp.StatusCode,
+		Header: t.sanitizer.Header(resp.Header),
+		Body:   t.sanitizer.Body(body),
+	}
+	t.mu.Lock()
+	t.cassette.Interactions = append(t.cassette.Interactions, interaction)
+	t.mu.Unlock()
+
+	// The caller gets the original response so recording does not change the behavior under test
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+	return resp, nil
+}
+
+// replay serves the next recorded response for the request
+func (t *Transport) replay(req *http.Request) (*http.Response, error) {
+	key := t.sanitizer.Key(req.Method, req.URL)
+
+	t.mu.Lock()
+	var matches []Interaction
+	for _, interaction := range t.cassette.Interactions {
+		if t.sanitizer.KeyString(interaction.Method, interaction.URL) == key {
+			matches = append(matches, interaction)
+		}
+	}
+	if len(matches) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay: %s: %w", key, ErrNoRecording)
+	}
+	interaction := matches[min(t.served[key], len(matches)-1)]
+	t.served[key]++
+	t.mu.Unlock()
+
+	header := interaction.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
@@ -0,0 +1,153 @@
+package replay
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestSanitizer_URL verifies recorded URLs carry no credentials and match regardless of query order
+// Expected behavior:
+//   - Token parameters are dropped
+//   - Remaining parameters are sorted
+//   - Configured names are hashed, including their path-escaped form
+func TestSanitizer_URL(t *testing.T) {
+	sanitizer := Sanitizer{Names: []string{"acme/ci"}}
+
+	u, err := url.Parse("https://gitlab.com/api/v4/groups/acme%2Fci/projects?per_page=100&private_token=glpat-secret&include_subgroups=true")
+	require.NoError(t, err)
+
+	assert.Equal(t,
+		"https://gitlab.com/api/v4/groups/"+HashName("acme/ci")+"/projects?include_subgroups=true&per_page=100",
+		sanitizer.URL(u))
+}
+
+// TestSanitizer_Body verifies secrets are redacted and names hashed at any depth of a JSON body
+// Expected behavior:
+//   - Token and e-mail fields are replaced with REDACTED, null ones are kept
+//   - Names are hashed inside longer strings
+//   - Numbers are kept exactly
+//   - A non-JSON body is stored as a JSON string with names hashed
+func TestSanitizer_Body(t *testing.T) {
+	sanitizer := Sanitizer{Names: []string{"acme"}}
+
+	body := sanitizer.Body([]byte(`[{"id":9007199254740993,"token":"glrt-secret","runners_token":null,` +
+		`"path_with_namespace":"acme/api","user":{"email":"dev@acme.io","name":"dev"}}]`))
+
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(body, &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "REDACTED", decoded[0]["token"])
+	assert.Nil(t, decoded[0]["runners_token"])
+	assert.Equal(t, HashName("acme")+"/api", decoded[0]["path_with_namespace"])
+	assert.Equal(t, "REDACTED", decoded[0]["user"].(map[string]interface{})["email"])
+	assert.Contains(t, string(body), `"id":9007199254740993`)
+	assert.NotContains(t, string(body), "secret")
+
+	assert.JSONEq(t, `"Forbidden for `+HashName("acme")+`"`, string(sanitizer.Body([]byte("Forbidden for acme"))))
+	assert.Nil(t, sanitizer.Body(nil))
+}
+
+// TestTransport_RecordThenReplay verifies a recorded cassette replays offline
+// Expected behavior:
+//   - Record mode passes the real response through and saves it sanitized
+//   - The PRIVATE-TOKEN header and token values never reach the cassette
+//   - Replay mode serves the response without calling the network, matching the hashed name
+//   - Pagination headers survive the round trip
+func TestTransport_RecordThenReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	sanitizer := Sanitizer{Names: []string{"acme"}}
+
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "glpat-secret", r.Header.Get("PRIVATE-TOKEN"))
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Pages", "1")
+		w.Header().Set("Set-Cookie", "session=secret")
+		_, _ = io.WriteString(w, `[{"id":1,"name":"api","path_with_namespace":"acme/api","runners_token":"secret"}]`)
+	}))
+	defer upstream.Close()
+
+	recorder, err := New(path, Record, sanitizer)
+	require.NoError(t, err)
+	client := &http.Client{Transport: recorder.Wrap(http.DefaultTransport)}
+
+	req, err := http.NewRequest(http.MethodGet, upstream.URL+"/api/v4/groups/acme/projects?per_page=100", nil)
+	require.NoError(t, err)
+	req.Header.Set("PRIVATE-TOKEN", "glpat-secret")
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	recorded, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+	assert.Contains(t, string(recorded), `"acme/api"`, "the caller sees the unsanitized response")
+	require.NoError(t, recorder.Save())
+
+	cassette, err := Load(path)
+	require.NoError(t, err)
+	require.Len(t, cassette.Interactions, 1)
+	saved, err := json.Marshal(cassette)
+	require.NoError(t, err)
+	assert.NotContains(t, string(saved), "secret")
+	assert.NotContains(t, string(saved), "acme")
+
+	player, err := New(path, Replay, Sanitizer{})
+	require.NoError(t, err)
+	player.Wrap(roundTripFunc(func(*http.Request) (*http.Response, error) {
+		t.Fatal("replay mode must not call the network")
+		return nil, nil
+	}))
+	replayed, err := player.RoundTrip(httptest.NewRequest(http.MethodGet,
+		upstream.URL+"/api/v4/groups/"+HashName("acme")+"/projects?per_page=100", nil))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, replayed.StatusCode)
+	assert.Equal(t, "1", replayed.Header.Get("X-Total-Pages"))
+	assert.Empty(t, replayed.Header.Get("Set-Cookie"))
+	body, err := io.ReadAll(replayed.Body)
+	require.NoError(t, err)
+	assert.Contains(t, string(body), HashName("acme")+"/api")
+}
+
+// TestTransport_ReplaySequence verifies repeated requests are answered in recorded order
+// Expected behavior:
+//   - Responses recorded for the same request are served one after another
+//   - The last response repeats once they are exhausted
+//   - Query parameter order and tokens do not affect matching
+//   - An unrecorded request fails with ErrNoRecording
+func TestTransport_ReplaySequence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cassette.json")
+	cassette := &Cassette{Interactions: []Interaction{
+		{Method: http.MethodGet, URL: "https://gitlab.com/api/v4/projects/1/jobs?scope=pending", Status: http.StatusTooManyRequests},
+		{Method: http.MethodGet, URL: "https://gitlab.com/api/v4/projects/1/jobs?scope=pending", Status: http.StatusOK, Body: json.RawMessage(`[]`)},
+	}}
+	require.NoError(t, cassette.Save(path))
+
+	player, err := New(path, Replay, Sanitizer{})
+	require.NoError(t, err)
+
+	statuses := make([]int, 0, 3)
+	for range 3 {
+		resp, err := player.RoundTrip(httptest.NewRequest(http.MethodGet,
+			"https://gitlab.com/api/v4/projects/1/jobs?private_token=x&scope=pending", nil))
+		require.NoError(t, err)
+		statuses = append(statuses, resp.StatusCode)
+	}
+	assert.Equal(t, []int{http.StatusTooManyRequests, http.StatusOK, http.StatusOK}, statuses)
+
+	_, err = player.RoundTrip(httptest.NewRequest(http.MethodGet, "https://gitlab.com/api/v4/projects/2/jobs?scope=pending", nil))
+	assert.True(t, errors.Is(err, ErrNoRecording))
+	assert.True(t, strings.Contains(err.Error(), "projects/2/jobs"))
+}
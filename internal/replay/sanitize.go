@@ -0,0 +1,157 @@
+package replay
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// redacted replaces the value of every secret field in a recorded body
+const redacted = "REDACTED"
+
+// secretParams are query parameters dropped from recorded and matched URLs
+var secretParams = []string{"private_token", "access_token", "job_token", "token"}
+
+// secretFields are JSON fields whose values are redacted in recorded bodies, at any depth
+var secretFields = map[string]bool{
+	"token":            true,
+	"runners_token":    true,
+	"private_token":    true,
+	"access_token":     true,
+	"password":         true,
+	"email":            true,
+	"public_email":     true,
+	"commit_email":     true,
+	"ip_address":       true,
+	"token_expires_at": true,
+}
+
+// keptHeaders are the response headers recorded; the rest (cookies, request IDs, rate limit state) is dropped
+var keptHeaders = []string{"Content-Type", "Link", "X-Next-Page", "X-Page", "X-Per-Page", "X-Prev-Page", "X-Total", "X-Total-Pages"}
+
+// Sanitizer strips credentials from recorded interactions and replaces names with stable hashes
+type Sanitizer struct {
+	// Names are group, project or user names replaced by HashName in URLs, headers and bodies
+	Names []string
+}
+
+// HashName returns the stable stand-in of a name in recorded fixtures
+func HashName(name string) string {
+	sum := sha256.Sum256([]byte(name))
+	return "name-" + hex.EncodeToString(sum[:4])
+}
+
+// replaceNames replaces every configured name in text, longest first so that a name containing another
+// one is hashed whole; path-escaped forms (e.g. "group%2Fsubgroup") are replaced too
+func (s Sanitizer) replaceNames(text string) string {
+	names := append([]string(nil), s.Names...)
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	for _, name := range names {
+		if name == "" {
+			continue
+		}
+		hashed := HashName(name)
+		text = strings.ReplaceAll(text, name, hashed)
+		if escaped := url.PathEscape(name); escaped != name {
+			text = strings.ReplaceAll(text, escaped, hashed)
+		}
+	}
+	return text
+}
+
+// URL returns the recorded form of u: secret parameters dropped, the remaining ones sorted and names hashed
+func (s Sanitizer) URL(u *url.URL) string {
+	query := u.Query()
+	for _, param := range secretParams {
+		query.Del(param)
+	}
+	for param, values := range query {
+		for i, value := range values {
+			values[i] = s.replaceNames(value)
+		}
+		query[param] = values
+	}
+
+	sanitized := u.Scheme + "://" + u.Host + s.replaceNames(u.EscapedPath())
+	if encoded := query.Encode(); encoded != "" {
+		sanitized += "?" + encoded
+	}
+	return sanitized
+}
+
+// Key returns the string requests are matched by: the method and the recorded form of the URL
+func (s Sanitizer) Key(method string, u *url.URL) string {
+	return method + " " + s.URL(u)
+}
+
+// KeyString is Key for a URL in string form; an unparsable URL is matched literally
+func (s Sanitizer) KeyString(method, rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return method + " " + rawURL
+	}
+	return s.Key(method, u)
+}
+
+// Header returns the recorded response headers with names hashed
+func (s Sanitizer) Header(header http.Header) http.Header {
+	kept := make(http.Header)
+	for _, name := range keptHeaders {
+		for _, value := range header.Values(name) {
+			kept.Add(name, s.replaceNames(value))
+		}
+	}
+	if len(kept) == 0 {
+		return nil
+	}
+	return kept
+}
+
+// Body returns the recorded form of a response body: secret fields redacted and names hashed in every
+// string of a JSON body; a non-JSON body becomes a JSON string with names hashed
+func (s Sanitizer) Body(body []byte) json.RawMessage {
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil
+	}
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil || decoder.More() {
+		encoded, _ := json.Marshal(s.replaceNames(string(body)))
+		return encoded
+	}
+	encoded, err := json.Marshal(s.sanitizeValue(value))
+	if err != nil {
+		encoded, _ = json.Marshal(s.replaceNames(string(body)))
+	}
+	return encoded
+}
+
+// sanitizeValue walks a decoded JSON value, redacting secret fields and hashing names in strings
+func (s Sanitizer) sanitizeValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for field, nested := range v {
+			if secretFields[field] && nested != nil {
+				v[field] = redacted
+				continue
+			}
+			v[field] = s.sanitizeValue(nested)
+		}
+		return v
+	case []interface{}:
+		for i, nested := range v {
+			v[i] = s.sanitizeValue(nested)
+		}
+		return v
+	case string:
+		return s.replaceNames(v)
+	default:
+		return v
+	}
+}
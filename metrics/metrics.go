@@ -0,0 +1,361 @@
+// Package metrics exports autoscaler metrics in the Prometheus format
+package metrics
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+)
+
+// otherTag is the tag label of the jobs whose tags exceed the max-tags cardinality cap
+const otherTag = "other"
+
+var (
+	defaultAgeBuckets = []time.Duration{time.Minute, 5 * time.Minute}
+	defaultMaxTags    = 50
+	// cycleBuckets cover cycles from well within to beyond the usual check-interval of 10 to 60 seconds
+	cycleBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}
+)
+
+// Registry holds the autoscaler metrics and refreshes them from completed scaling cycles. It is passed to the
+// orchestrator and the GitLab client to count their operations and errors.
+type Registry struct {
+	registry       *prometheus.Registry
+	pendingJobsAge *prometheus.GaugeVec
+	asgCadence     *prometheus.GaugeVec
+	sinceDescribe  *prometheus.GaugeVec
+	sinceUpdate    *prometheus.GaugeVec
+	stuck          *prometheus.GaugeVec
+	reloadFailed   prometheus.Gauge
+	sinceReload    prometheus.Gauge
+	decisions      prometheus.Histogram
+	applied        prometheus.Histogram
+	pendingJobs    *prometheus.GaugeVec
+	runningJobs    *prometheus.GaugeVec
+	desired        *prometheus.GaugeVec
+	allocated      *prometheus.GaugeVec
+	totalDesired   prometheus.Gauge
+	totalAllocated prometheus.Gauge
+	scaleOps       *prometheus.CounterVec
+	providerErrors *prometheus.CounterVec
+	gitlabErrors   *prometheus.CounterVec
+	cycles         prometheus.Histogram
+	ageBuckets     []time.Duration
+	maxTags        int
+}
+
+// The orchestrator and the GitLab client publish into the registry
+var (
+	_ core.Metrics   = (*Registry)(nil)
+	_ gitlab.Metrics = (*Registry)(nil)
+)
+
+// NewRegistry creates the metrics registry with age buckets and tag cap from the configuration
+func NewRegistry(cfg config.MetricsConfig) *Registry {
+	r := &Registry{
+		registry: prometheus.NewRegistry(),
+		pendingJobsAge: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pending_jobs_age_bucket",
+			Help: "Pending jobs per tag by time spent in the queue.",
+		}, []string{"tag", "bucket"}),
+		asgCadence: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "asg_evaluation_interval_seconds",
+			Help: "Current evaluation interval per ASG; wider than check-interval while the ASG is backed off after errors.",
+		}, []string{"asg"}),
+		sinceDescribe: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "asg_seconds_since_successful_describe",
+			Help: "Seconds since the capacity of the ASG was last read successfully.",
+		}, []string{"asg"}),
+		sinceUpdate: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "asg_seconds_since_successful_update",
+			Help: "Seconds since the capacity of the ASG was last changed successfully.",
+		}, []string{"asg"}),
+		stuck: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "stuck_instances",
+			Help: "Instances of the ASG pending longer than pending-timeout and not counted as allocated.",
+		}, []string{"asg"}),
+		reloadFailed: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "config_reload_failed",
+			Help: "1 while the last configuration reload failed and the configuration on disk is not in use, 0 otherwise.",
+		}),
+		sinceReload: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "config_seconds_since_successful_reload",
+			Help: "Seconds since the configuration in use was loaded.",
+		}),
+		decisions: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cycle_decisions_computed_seconds",
+			Help:    "Seconds from the tick of a cycle until the decisions of all ASGs were computed.",
+			Buckets: cycleBuckets,
+		}),
+		applied: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cycle_updates_applied_seconds",
+			Help:    "Seconds from the tick of a cycle until all capacity updates were applied.",
+			Buckets: cycleBuckets,
+		}),
+		pendingJobs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "pending_jobs",
+			Help: "Pending jobs per tag in the last cycle.",
+		}, []string{"tag"}),
+		runningJobs: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "running_jobs",
+			Help: "Running jobs per tag in the last cycle.",
+		}, []string{"tag"}),
+		desired: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "asg_desired_capacity",
+			Help: "Desired capacity of the ASG read in the last cycle.",
+		}, []string{"asg"}),
+		allocated: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "asg_allocated_capacity",
+			Help: "Instances of the ASG allocated in the last cycle, stuck instances excluded.",
+		}, []string{"asg"}),
+		totalDesired: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "total_desired_capacity",
+			Help: "Desired capacity summed over all ASGs.",
+		}),
+		totalAllocated: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "total_allocated_capacity",
+			Help: "Allocated instances summed over all ASGs.",
+		}),
+		scaleOps: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "scale_operations_total",
+			Help: "Capacity changes applied per ASG and direction (up or down).",
+		}, []string{"asg", "direction"}),
+		providerErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "provider_errors_total",
+			Help: "Failed provider calls per provider entry and operation (describe or update).",
+		}, []string{"provider", "operation"}),
+		gitlabErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gitlab_api_errors_total",
+			Help: "Failed GitLab API requests per HTTP status code, or network when no answer was received.",
+		}, []string{"code"}),
+		cycles: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "cycle_duration_seconds",
+			Help:    "Seconds a whole cycle took, from querying GitLab to cleaning up offline runners.",
+			Buckets: cycleBuckets,
+		}),
+		ageBuckets: cfg.AgeBuckets,
+		maxTags:    cfg.MaxTags,
+	}
+	if len(r.ageBuckets) == 0 {
+		r.ageBuckets = defaultAgeBuckets
+	}
+	if r.maxTags == 0 {
+		r.maxTags = defaultMaxTags
+	}
+	r.registry.MustRegister(r.pendingJobsAge, r.asgCadence, r.sinceDescribe, r.sinceUpdate, r.stuck, r.reloadFailed, r.sinceReload,
+		r.decisions, r.applied, r.pendingJobs, r.runningJobs, r.desired, r.allocated, r.totalDesired, r.totalAllocated,
+		r.scaleOps, r.providerErrors, r.gitlabErrors, r.cycles)
+	return r
+}
+
+// Handler serves the metrics in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return promhttp.HandlerFor(r.registry, promhttp.HandlerOpts{})
+}
+
+// Start serves the metrics at GET /metrics on listen in the background, for metrics.listen
+func (r *Registry) Start(listen string) (*http.Server, error) {
+	listener, err := net.Listen("tcp", listen)
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", listen, err)
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", r.Handler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	slog.Info("Metrics listening", "address", listener.Addr().String())
+	go func() {
+		if err := server.Serve(listener); err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server stopped", "error", err)
+		}
+	}()
+	return server, nil
+}
+
+// ScaleOperation counts a capacity change applied to the ASG; the orchestrator publishes it
+func (r *Registry) ScaleOperation(asgName string, decision core.Decision) {
+	direction := "up"
+	if decision == core.DecisionScaleDown {
+		direction = "down"
+	}
+	r.scaleOps.WithLabelValues(asgName, direction).Inc()
+}
+
+// ProviderError counts a failed provider call; the orchestrator publishes it
+func (r *Registry) ProviderError(providerName, operation string) {
+	r.providerErrors.WithLabelValues(providerName, operation).Inc()
+}
+
+// CycleCompleted observes the duration of a whole cycle; the orchestrator publishes it
+func (r *Registry) CycleCompleted(duration time.Duration) {
+	r.cycles.Observe(duration.Seconds())
+}
+
+// APIError counts a failed GitLab API request; the GitLab client publishes it
+func (r *Registry) APIError(code string) {
+	r.gitlabErrors.WithLabelValues(code).Inc()
+}
+
+// ObserveSnapshot refreshes the metrics from a completed cycle; subscribe it to the orchestrator
+func (r *Registry) ObserveSnapshot(snapshot core.Snapshot) {
+	composition := queueComposition(snapshot.State.PendingJobList, r.ageBuckets, snapshot.Timestamp)
+	composition = capTags(composition, r.maxTags)
+	labels := bucketLabels(r.ageBuckets)
+
+	r.pendingJobsAge.Reset()
+	for tag, counts := range composition {
+		for i, count := range counts {
+			r.pendingJobsAge.WithLabelValues(tag, labels[i]).Set(float64(count))
+		}
+	}
+
+	setJobs(r.pendingJobs, capTags(jobCounts(snapshot.State.PendingJobsWithTags), r.maxTags))
+	setJobs(r.runningJobs, capTags(jobCounts(snapshot.State.RunningJobsWithTags), r.maxTags))
+
+	r.asgCadence.Reset()
+	r.sinceDescribe.Reset()
+	r.sinceUpdate.Reset()
+	r.stuck.Reset()
+	r.desired.Reset()
+	r.allocated.Reset()
+	var totalDesired, totalAllocated int64
+	for _, status := range snapshot.ASGs {
+		r.desired.WithLabelValues(status.Name).Set(float64(status.Desired))
+		r.allocated.WithLabelValues(status.Name).Set(float64(status.Allocated))
+		totalDesired += status.Desired
+		totalAllocated += status.Allocated
+		r.asgCadence.WithLabelValues(status.Name).Set(status.CadenceSeconds)
+		r.stuck.WithLabelValues(status.Name).Set(float64(status.StuckInstances))
+		if !status.LastDescribeAt.IsZero() {
+			r.sinceDescribe.WithLabelValues(status.Name).Set(snapshot.Timestamp.Sub(status.LastDescribeAt).Seconds())
+		}
+		if !status.LastUpdateAt.IsZero() {
+			r.sinceUpdate.WithLabelValues(status.Name).Set(snapshot.Timestamp.Sub(status.LastUpdateAt).Seconds())
+		}
+	}
+	r.totalDesired.Set(float64(totalDesired))
+	r.totalAllocated.Set(float64(totalAllocated))
+
+	r.reloadFailed.Set(0)
+	if snapshot.Reload.Failed {
+		r.reloadFailed.Set(1)
+	}
+	if !snapshot.Reload.LoadedAt.IsZero() {
+		r.sinceReload.Set(snapshot.Timestamp.Sub(snapshot.Reload.LoadedAt).Seconds())
+	}
+	r.decisions.Observe(snapshot.Timings.DecisionsComputed.Seconds())
+	r.applied.Observe(snapshot.Timings.UpdatesApplied.Seconds())
+}
+
+// jobCounts turns jobs per tag into the single-bucket composition capTags works on
+func jobCounts(jobs map[string]int) map[string][]int {
+	counts := make(map[string][]int, len(jobs))
+	for tag, count := range jobs {
+		counts[tag] = []int{count}
+	}
+	return counts
+}
+
+// setJobs replaces the series of a per-tag job gauge with the counts of the last cycle
+func setJobs(gauge *prometheus.GaugeVec, counts map[string][]int) {
+	gauge.Reset()
+	for tag, count := range counts {
+		gauge.WithLabelValues(tag).Set(float64(count[0]))
+	}
+}
+
+// queueComposition counts pending jobs per tag and age bucket. Bucket i holds ages in
+// [boundaries[i-1], boundaries[i]); the last bucket holds everything at or above the last boundary.
+func queueComposition(jobs []gitlab.JobSummary, boundaries []time.Duration, now time.Time) map[string][]int {
+	composition := make(map[string][]int)
+	for _, job := range jobs {
+		if job.CreatedAt.IsZero() {
+			continue
+		}
+		bucket := sort.Search(len(boundaries), func(i int) bool { return now.Sub(job.CreatedAt) < boundaries[i] })
+		for _, tag := range job.Tags {
+			if composition[tag] == nil {
+				composition[tag] = make([]int, len(boundaries)+1)
+			}
+			composition[tag][bucket]++
+		}
+	}
+	return composition
+}
+
+// capTags keeps the maxTags tags with the most jobs and merges the rest into the "other" tag
+func capTags(composition map[string][]int, maxTags int) map[string][]int {
+	if len(composition) <= maxTags {
+		return composition
+	}
+
+	type tagTotal struct {
+		tag   string
+		total int
+	}
+	totals := make([]tagTotal, 0, len(composition))
+	for tag, counts := range composition {
+		total := 0
+		for _, count := range counts {
+			total += count
+		}
+		totals = append(totals, tagTotal{tag, total})
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		if totals[i].total != totals[j].total {
+			return totals[i].total > totals[j].total
+		}
+		return totals[i].tag < totals[j].tag
+	})
+
+	capped := make(map[string][]int, maxTags+1)
+	for i, t := range totals {
+		if i < maxTags {
+			capped[t.tag] = composition[t.tag]
+			continue
+		}
+		if capped[otherTag] == nil {
+			capped[otherTag] = make([]int, len(composition[t.tag]))
+		}
+		for bucket, count := range composition[t.tag] {
+			capped[otherTag][bucket] += count
+		}
+	}
+	return capped
+}
+
+// bucketLabels returns the label of every age bucket, e.g. "<1m", "1m-5m", ">=5m"
+func bucketLabels(boundaries []time.Duration) []string {
+	labels := make([]string, 0, len(boundaries)+1)
+	for i, boundary := range boundaries {
+		if i == 0 {
+			labels = append(labels, "<"+shortDuration(boundary))
+			continue
+		}
+		labels = append(labels, shortDuration(boundaries[i-1])+"-"+shortDuration(boundary))
+	}
+	return append(labels, ">="+shortDuration(boundaries[len(boundaries)-1]))
+}
+
+// shortDuration formats a duration without zero components, e.g. "5m" instead of "5m0s"
+func shortDuration(d time.Duration) string {
+	s := d.String()
+	if strings.HasSuffix(s, "m0s") {
+		s = strings.TrimSuffix(s, "0s")
+	}
+	if strings.HasSuffix(s, "h0m") {
+		s = strings.TrimSuffix(s, "0m")
+	}
+	return s
+}
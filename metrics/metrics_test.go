@@ -0,0 +1,352 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+var now = time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC)
+
+// job returns a pending job summary created age ago
+func job(age time.Duration, tags ...string) gitlab.JobSummary {
+	return gitlab.JobSummary{Tags: tags, CreatedAt: now.Add(-age)}
+}
+
+// TestQueueComposition_Boundaries verifies ages on a boundary fall into the upper bucket
+// Expected behavior:
+//   - 59s is <1m, exactly 1m and 4m59s are 1m-5m, exactly 5m and 1h are >=5m
+//   - Jobs without a creation time are ignored
+func TestQueueComposition_Boundaries(t *testing.T) {
+	jobs := []gitlab.JobSummary{
+		job(59*time.Second, "amd64"),
+		job(time.Minute, "amd64"),
+		job(4*time.Minute+59*time.Second, "amd64", "docker"),
+		job(5*time.Minute, "amd64"),
+		job(time.Hour, "arm64"),
+		{Tags: []string{"amd64"}},
+	}
+
+	composition := queueComposition(jobs, defaultAgeBuckets, now)
+
+	assert.Equal(t, map[string][]int{
+		"amd64":  {1, 2, 1},
+		"docker": {0, 1, 0},
+		"arm64":  {0, 0, 1},
+	}, composition)
+}
+
+// TestCapTags verifies tags beyond the cap are merged into "other", keeping the busiest tags
+func TestCapTags(t *testing.T) {
+	composition := map[string][]int{
+		"amd64":  {3, 0},
+		"arm64":  {1, 1},
+		"docker": {0, 1},
+		"gpu":    {1, 0},
+	}
+
+	capped := capTags(composition, 2)
+
+	assert.Equal(t, map[string][]int{
+		"amd64": {3, 0},
+		"arm64": {1, 1},
+		"other": {1, 1},
+	}, capped)
+}
+
+// TestBucketLabels verifies bucket labels for custom boundaries
+func TestBucketLabels(t *testing.T) {
+	labels := bucketLabels([]time.Duration{30 * time.Second, 90 * time.Second, time.Hour})
+
+	assert.Equal(t, []string{"<30s", "30s-1m30s", "1m30s-1h", ">=1h"}, labels)
+}
+
+// TestObserveSnapshot verifies gauges are refreshed from every cycle and stale series are dropped
+func TestObserveSnapshot(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, State: gitlab.ClusterState{
+		PendingJobList: []gitlab.JobSummary{job(10*time.Second, "amd64"), job(10*time.Minute, "arm64")},
+	}})
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, State: gitlab.ClusterState{
+		PendingJobList: []gitlab.JobSummary{job(2*time.Minute, "amd64")},
+	}})
+
+	expected := `
+# HELP pending_jobs_age_bucket Pending jobs per tag by time spent in the queue.
+# TYPE pending_jobs_age_bucket gauge
+pending_jobs_age_bucket{bucket="1m-5m",tag="amd64"} 1
+pending_jobs_age_bucket{bucket="<1m",tag="amd64"} 0
+pending_jobs_age_bucket{bucket=">=5m",tag="amd64"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "pending_jobs_age_bucket"))
+}
+
+// TestObserveSnapshot_ASGCadence verifies the evaluation interval is exported per ASG
+func TestObserveSnapshot_ASGCadence(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, ASGs: []core.ASGStatus{
+		{Name: "flaky", Decision: core.DecisionBackedOff, CadenceSeconds: 40},
+		{Name: "healthy", CadenceSeconds: 10},
+	}})
+
+	expected := `
+# HELP asg_evaluation_interval_seconds Current evaluation interval per ASG; wider than check-interval while the ASG is backed off after errors.
+# TYPE asg_evaluation_interval_seconds gauge
+asg_evaluation_interval_seconds{asg="flaky"} 40
+asg_evaluation_interval_seconds{asg="healthy"} 10
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "asg_evaluation_interval_seconds"))
+}
+
+// TestObserveSnapshot_SecondsSinceSuccess verifies staleness gauges are exported only for ASGs with a successful call
+func TestObserveSnapshot_SecondsSinceSuccess(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, ASGs: []core.ASGStatus{
+		{Name: "flaky", LastDescribeAt: now.Add(-10 * time.Minute)},
+		{Name: "healthy", LastDescribeAt: now, LastUpdateAt: now.Add(-time.Minute)},
+	}})
+
+	expected := `
+# HELP asg_seconds_since_successful_describe Seconds since the capacity of the ASG was last read successfully.
+# TYPE asg_seconds_since_successful_describe gauge
+asg_seconds_since_successful_describe{asg="flaky"} 600
+asg_seconds_since_successful_describe{asg="healthy"} 0
+# HELP asg_seconds_since_successful_update Seconds since the capacity of the ASG was last changed successfully.
+# TYPE asg_seconds_since_successful_update gauge
+asg_seconds_since_successful_update{asg="healthy"} 60
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected),
+		"asg_seconds_since_successful_describe", "asg_seconds_since_successful_update"))
+}
+
+// TestObserveSnapshot_StuckInstances verifies stuck instances are exported per ASG, zero included
+func TestObserveSnapshot_StuckInstances(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, ASGs: []core.ASGStatus{
+		{Name: "broken-subnet", StuckInstances: 2},
+		{Name: "healthy"},
+	}})
+
+	expected := `
+# HELP stuck_instances Instances of the ASG pending longer than pending-timeout and not counted as allocated.
+# TYPE stuck_instances gauge
+stuck_instances{asg="broken-subnet"} 2
+stuck_instances{asg="healthy"} 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "stuck_instances"))
+}
+
+// TestObserveSnapshot_ConfigReload verifies a failed reload is exported until a reload succeeds
+// Expected behavior:
+//   - While the last reload failed config_reload_failed is 1 and the age of the configuration in use keeps growing
+//   - After a successful reload config_reload_failed is 0 and the age restarts from the reload
+func TestObserveSnapshot_ConfigReload(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, Reload: core.ReloadStatus{
+		LoadedAt: now.Add(-time.Hour), Failed: true, LastAttemptAt: now.Add(-time.Minute), LastError: "config validation failed",
+	}})
+	expected := `
+# HELP config_reload_failed 1 while the last configuration reload failed and the configuration on disk is not in use, 0 otherwise.
+# TYPE config_reload_failed gauge
+config_reload_failed 1
+# HELP config_seconds_since_successful_reload Seconds since the configuration in use was loaded.
+# TYPE config_seconds_since_successful_reload gauge
+config_seconds_since_successful_reload 3600
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected),
+		"config_reload_failed", "config_seconds_since_successful_reload"))
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, Reload: core.ReloadStatus{LoadedAt: now.Add(-10 * time.Second)}})
+	expected = `
+# HELP config_reload_failed 1 while the last configuration reload failed and the configuration on disk is not in use, 0 otherwise.
+# TYPE config_reload_failed gauge
+config_reload_failed 0
+# HELP config_seconds_since_successful_reload Seconds since the configuration in use was loaded.
+# TYPE config_seconds_since_successful_reload gauge
+config_seconds_since_successful_reload 10
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected),
+		"config_reload_failed", "config_seconds_since_successful_reload"))
+}
+
+// TestObserveSnapshot_CycleTimings verifies every cycle observes its latency from tick to decisions and to updates
+func TestObserveSnapshot_CycleTimings(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, Timings: core.CycleTimings{
+		DecisionsComputed: 3 * time.Second, UpdatesApplied: 7 * time.Second,
+	}})
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now, Timings: core.CycleTimings{
+		DecisionsComputed: 2 * time.Second, UpdatesApplied: 2 * time.Second,
+	}})
+
+	expected := `
+# HELP cycle_updates_applied_seconds Seconds from the tick of a cycle until all capacity updates were applied.
+# TYPE cycle_updates_applied_seconds histogram
+cycle_updates_applied_seconds_bucket{le="0.5"} 0
+cycle_updates_applied_seconds_bucket{le="1"} 0
+cycle_updates_applied_seconds_bucket{le="2.5"} 1
+cycle_updates_applied_seconds_bucket{le="5"} 1
+cycle_updates_applied_seconds_bucket{le="10"} 2
+cycle_updates_applied_seconds_bucket{le="20"} 2
+cycle_updates_applied_seconds_bucket{le="30"} 2
+cycle_updates_applied_seconds_bucket{le="60"} 2
+cycle_updates_applied_seconds_bucket{le="120"} 2
+cycle_updates_applied_seconds_bucket{le="+Inf"} 2
+cycle_updates_applied_seconds_sum 9
+cycle_updates_applied_seconds_count 2
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "cycle_updates_applied_seconds"))
+}
+
+// TestObserveSnapshot_JobsAndCapacity verifies jobs per tag and capacities per ASG and in total are exported
+func TestObserveSnapshot_JobsAndCapacity(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{MaxTags: 1})
+
+	registry.ObserveSnapshot(core.Snapshot{Timestamp: now,
+		State: gitlab.ClusterState{
+			PendingJobsWithTags: map[string]int{"amd64": 3, "arm64": 1, "gpu": 1},
+			RunningJobsWithTags: map[string]int{"amd64": 2},
+		},
+		ASGs: []core.ASGStatus{
+			{Name: "amd64-runners", Desired: 4, Allocated: 3},
+			{Name: "arm64-runners", Desired: 1, Allocated: 1},
+		},
+	})
+
+	expected := `
+# HELP pending_jobs Pending jobs per tag in the last cycle.
+# TYPE pending_jobs gauge
+pending_jobs{tag="amd64"} 3
+pending_jobs{tag="other"} 2
+# HELP running_jobs Running jobs per tag in the last cycle.
+# TYPE running_jobs gauge
+running_jobs{tag="amd64"} 2
+# HELP asg_desired_capacity Desired capacity of the ASG read in the last cycle.
+# TYPE asg_desired_capacity gauge
+asg_desired_capacity{asg="amd64-runners"} 4
+asg_desired_capacity{asg="arm64-runners"} 1
+# HELP asg_allocated_capacity Instances of the ASG allocated in the last cycle, stuck instances excluded.
+# TYPE asg_allocated_capacity gauge
+asg_allocated_capacity{asg="amd64-runners"} 3
+asg_allocated_capacity{asg="arm64-runners"} 1
+# HELP total_desired_capacity Desired capacity summed over all ASGs.
+# TYPE total_desired_capacity gauge
+total_desired_capacity 5
+# HELP total_allocated_capacity Allocated instances summed over all ASGs.
+# TYPE total_allocated_capacity gauge
+total_allocated_capacity 4
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "pending_jobs", "running_jobs",
+		"asg_desired_capacity", "asg_allocated_capacity", "total_desired_capacity", "total_allocated_capacity"))
+}
+
+// TestRegistry_Cycle verifies the orchestrator publishes its operations into the registry it was created with
+// Expected behavior:
+//   - A scale-up and a scale-down are counted per ASG and direction
+//   - Failed describes and updates are counted per provider entry and operation
+//   - Capacities of the cycle are exported from its snapshot
+func TestRegistry_Cycle(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+	provider := &mocks.MockProvider{}
+	provider.On("GetCurrentCapacity", mock.Anything, "busy").Return(int64(1), int64(1), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "idle").Return(int64(2), int64(2), nil)
+	provider.On("GetCurrentCapacity", mock.Anything, "broken").Return(int64(0), int64(0), errors.New("access denied"))
+	provider.On("GetCurrentCapacity", mock.Anything, "full").Return(int64(1), int64(1), nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "busy", int64(3)).Return(nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "idle", int64(1)).Return(nil)
+	provider.On("UpdateASGCapacity", mock.Anything, "full", int64(2)).Return(errors.New("quota exceeded"))
+
+	asgs := []config.Asg{
+		{Name: "busy", Tags: []string{"amd64"}, MaxAsgCapacity: 5},
+		{Name: "idle", Tags: []string{"arm64"}, MaxAsgCapacity: 5, ScaleToZero: true},
+		{Name: "broken", Tags: []string{"gpu"}, MaxAsgCapacity: 5},
+		{Name: "full", Tags: []string{"riscv"}, MaxAsgCapacity: 5},
+	}
+	asgToProvider := map[string]string{"busy": "aws-prod", "idle": "aws-prod", "broken": "aws-prod", "full": "aws-prod"}
+	orchestrator := core.NewOrchestrator(map[string]core.Provider{"aws-prod": provider}, asgToProvider, nil, registry)
+	orchestrator.Subscribe(registry.ObserveSnapshot)
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws-prod": {Type: config.ProviderAWS, AsgNames: asgs}},
+	}
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		TotalPendingJobs:    4,
+		PendingJobsWithTags: map[string]int{"amd64": 3, "riscv": 1},
+		RunningJobsWithTags: map[string]int{"riscv": 1},
+	})
+
+	expected := `
+# HELP scale_operations_total Capacity changes applied per ASG and direction (up or down).
+# TYPE scale_operations_total counter
+scale_operations_total{asg="busy",direction="up"} 1
+scale_operations_total{asg="idle",direction="down"} 1
+# HELP provider_errors_total Failed provider calls per provider entry and operation (describe or update).
+# TYPE provider_errors_total counter
+provider_errors_total{operation="describe",provider="aws-prod"} 1
+provider_errors_total{operation="update",provider="aws-prod"} 1
+# HELP total_desired_capacity Desired capacity summed over all ASGs.
+# TYPE total_desired_capacity gauge
+total_desired_capacity 4
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected),
+		"scale_operations_total", "provider_errors_total", "total_desired_capacity"))
+}
+
+// roundTripFunc adapts a function to http.RoundTripper
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+// TestRegistry_GitLabErrors verifies the GitLab client publishes failed requests into the registry it was created with
+// Expected behavior:
+//   - Error answers are counted by status code, requests without an answer as network
+//   - Failures of a wrapped transport, like injected faults, are counted too
+func TestRegistry_GitLabErrors(t *testing.T) {
+	registry := NewRegistry(config.MetricsConfig{})
+	client, err := gitlab.NewClient(config.GitLabConfig{Token: "token"}, registry)
+	require.NoError(t, err)
+	answers := []int{http.StatusForbidden, 0}
+	client.WrapTransport(func(http.RoundTripper) http.RoundTripper {
+		return roundTripFunc(func(req *http.Request) (*http.Response, error) {
+			code := answers[0]
+			answers = answers[1:]
+			if code == 0 {
+				return nil, errors.New("connection reset")
+			}
+			return &http.Response{StatusCode: code, Status: http.StatusText(code), Body: http.NoBody, Request: req}, nil
+		})
+	})
+
+	_, err = client.FetchProjects("group", nil)
+	assert.Error(t, err)
+	_, err = client.FetchProjects("group", nil)
+	assert.Error(t, err)
+
+	expected := `
+# HELP gitlab_api_errors_total Failed GitLab API requests per HTTP status code, or network when no answer was received.
+# TYPE gitlab_api_errors_total counter
+gitlab_api_errors_total{code="403"} 1
+gitlab_api_errors_total{code="network"} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry.registry, strings.NewReader(expected), "gitlab_api_errors_total"))
+}
@@ -0,0 +1,148 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package core
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockProvider is an autogenerated mock type for the Provider type
+type MockProvider struct {
+	mock.Mock
+}
+
+type MockProvider_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockProvider) EXPECT() *MockProvider_Expecter {
+	return &MockProvider_Expecter{mock: &_m.Mock}
+}
+
+// GetCurrentCapacity provides a mock function with given fields: ctx, asgName
+func (_m *MockProvider) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	ret := _m.Called(ctx, asgName)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetCurrentCapacity")
+	}
+
+	var r0 int64
+	var r1 int64
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int64, int64, error)); ok {
+		return rf(ctx, asgName)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int64); ok {
+		r0 = rf(ctx, asgName)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) int64); ok {
+		r1 = rf(ctx, asgName)
+	} else {
+		r1 = ret.Get(1).(int64)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, string) error); ok {
+		r2 = rf(ctx, asgName)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// MockProvider_GetCurrentCapacity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetCurrentCapacity'
+type MockProvider_GetCurrentCapacity_Call struct {
+	*mock.Call
+}
+
+// GetCurrentCapacity is a helper method to define mock.On call
+//   - ctx context.Context
+//   - asgName string
+func (_e *MockProvider_Expecter) GetCurrentCapacity(ctx interface{}, asgName interface{}) *MockProvider_GetCurrentCapacity_Call {
+	return &MockProvider_GetCurrentCapacity_Call{Call: _e.mock.On("GetCurrentCapacity", ctx, asgName)}
+}
+
+func (_c *MockProvider_GetCurrentCapacity_Call) Run(run func(ctx context.Context, asgName string)) *MockProvider_GetCurrentCapacity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockProvider_GetCurrentCapacity_Call) Return(_a0 int64, _a1 int64, _a2 error) *MockProvider_GetCurrentCapacity_Call {
+	_c.Call.Return(_a0, _a1, _a2)
+	return _c
+}
+
+func (_c *MockProvider_GetCurrentCapacity_Call) RunAndReturn(run func(context.Context, string) (int64, int64, error)) *MockProvider_GetCurrentCapacity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// UpdateASGCapacity provides a mock function with given fields: ctx, asgName, capacity
+func (_m *MockProvider) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	ret := _m.Called(ctx, asgName, capacity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpdateASGCapacity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, asgName, capacity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockProvider_UpdateASGCapacity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpdateASGCapacity'
+type MockProvider_UpdateASGCapacity_Call struct {
+	*mock.Call
+}
+
+// UpdateASGCapacity is a helper method to define mock.On call
+//   - ctx context.Context
+//   - asgName string
+//   - capacity int64
+func (_e *MockProvider_Expecter) UpdateASGCapacity(ctx interface{}, asgName interface{}, capacity interface{}) *MockProvider_UpdateASGCapacity_Call {
+	return &MockProvider_UpdateASGCapacity_Call{Call: _e.mock.On("UpdateASGCapacity", ctx, asgName, capacity)}
+}
+
+func (_c *MockProvider_UpdateASGCapacity_Call) Run(run func(ctx context.Context, asgName string, capacity int64)) *MockProvider_UpdateASGCapacity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *MockProvider_UpdateASGCapacity_Call) Return(_a0 error) *MockProvider_UpdateASGCapacity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockProvider_UpdateASGCapacity_Call) RunAndReturn(run func(context.Context, string, int64) error) *MockProvider_UpdateASGCapacity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockProvider creates a new instance of MockProvider. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockProvider(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockProvider {
+	mock := &MockProvider{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -23,6 +23,154 @@ func (_m *MockAutoscalingAPI) EXPECT() *MockAutoscalingAPI_Expecter {
 	return &MockAutoscalingAPI_Expecter{mock: &_m.Mock}
 }
 
+// CompleteLifecycleAction provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockAutoscalingAPI) CompleteLifecycleAction(_a0 context.Context, _a1 *autoscaling.CompleteLifecycleActionInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.CompleteLifecycleActionOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CompleteLifecycleAction")
+	}
+
+	var r0 *autoscaling.CompleteLifecycleActionOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.CompleteLifecycleActionInput, ...func(*autoscaling.Options)) (*autoscaling.CompleteLifecycleActionOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.CompleteLifecycleActionInput, ...func(*autoscaling.Options)) *autoscaling.CompleteLifecycleActionOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.CompleteLifecycleActionOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.CompleteLifecycleActionInput, ...func(*autoscaling.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAutoscalingAPI_CompleteLifecycleAction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CompleteLifecycleAction'
+type MockAutoscalingAPI_CompleteLifecycleAction_Call struct {
+	*mock.Call
+}
+
+// CompleteLifecycleAction is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *autoscaling.CompleteLifecycleActionInput
+//   - _a2 ...func(*autoscaling.Options)
+func (_e *MockAutoscalingAPI_Expecter) CompleteLifecycleAction(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockAutoscalingAPI_CompleteLifecycleAction_Call {
+	return &MockAutoscalingAPI_CompleteLifecycleAction_Call{Call: _e.mock.On("CompleteLifecycleAction",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockAutoscalingAPI_CompleteLifecycleAction_Call) Run(run func(_a0 context.Context, _a1 *autoscaling.CompleteLifecycleActionInput, _a2 ...func(*autoscaling.Options))) *MockAutoscalingAPI_CompleteLifecycleAction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*autoscaling.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*autoscaling.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*autoscaling.CompleteLifecycleActionInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_CompleteLifecycleAction_Call) Return(_a0 *autoscaling.CompleteLifecycleActionOutput, _a1 error) *MockAutoscalingAPI_CompleteLifecycleAction_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_CompleteLifecycleAction_Call) RunAndReturn(run func(context.Context, *autoscaling.CompleteLifecycleActionInput, ...func(*autoscaling.Options)) (*autoscaling.CompleteLifecycleActionOutput, error)) *MockAutoscalingAPI_CompleteLifecycleAction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CreateOrUpdateTags provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockAutoscalingAPI) CreateOrUpdateTags(_a0 context.Context, _a1 *autoscaling.CreateOrUpdateTagsInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateOrUpdateTags")
+	}
+
+	var r0 *autoscaling.CreateOrUpdateTagsOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) *autoscaling.CreateOrUpdateTagsOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.CreateOrUpdateTagsOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAutoscalingAPI_CreateOrUpdateTags_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateOrUpdateTags'
+type MockAutoscalingAPI_CreateOrUpdateTags_Call struct {
+	*mock.Call
+}
+
+// CreateOrUpdateTags is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *autoscaling.CreateOrUpdateTagsInput
+//   - _a2 ...func(*autoscaling.Options)
+func (_e *MockAutoscalingAPI_Expecter) CreateOrUpdateTags(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockAutoscalingAPI_CreateOrUpdateTags_Call {
+	return &MockAutoscalingAPI_CreateOrUpdateTags_Call{Call: _e.mock.On("CreateOrUpdateTags",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockAutoscalingAPI_CreateOrUpdateTags_Call) Run(run func(_a0 context.Context, _a1 *autoscaling.CreateOrUpdateTagsInput, _a2 ...func(*autoscaling.Options))) *MockAutoscalingAPI_CreateOrUpdateTags_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*autoscaling.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*autoscaling.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*autoscaling.CreateOrUpdateTagsInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_CreateOrUpdateTags_Call) Return(_a0 *autoscaling.CreateOrUpdateTagsOutput, _a1 error) *MockAutoscalingAPI_CreateOrUpdateTags_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_CreateOrUpdateTags_Call) RunAndReturn(run func(context.Context, *autoscaling.CreateOrUpdateTagsInput, ...func(*autoscaling.Options)) (*autoscaling.CreateOrUpdateTagsOutput, error)) *MockAutoscalingAPI_CreateOrUpdateTags_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // DescribeAutoScalingGroups provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MockAutoscalingAPI) DescribeAutoScalingGroups(_a0 context.Context, _a1 *autoscaling.DescribeAutoScalingGroupsInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.DescribeAutoScalingGroupsOutput, error) {
 	_va := make([]interface{}, len(_a2))
@@ -97,6 +245,228 @@ func (_c *MockAutoscalingAPI_DescribeAutoScalingGroups_Call) RunAndReturn(run fu
 	return _c
 }
 
+// DescribeScalingActivities provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockAutoscalingAPI) DescribeScalingActivities(_a0 context.Context, _a1 *autoscaling.DescribeScalingActivitiesInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.DescribeScalingActivitiesOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DescribeScalingActivities")
+	}
+
+	var r0 *autoscaling.DescribeScalingActivitiesOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribeScalingActivitiesInput, ...func(*autoscaling.Options)) (*autoscaling.DescribeScalingActivitiesOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.DescribeScalingActivitiesInput, ...func(*autoscaling.Options)) *autoscaling.DescribeScalingActivitiesOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.DescribeScalingActivitiesOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.DescribeScalingActivitiesInput, ...func(*autoscaling.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAutoscalingAPI_DescribeScalingActivities_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DescribeScalingActivities'
+type MockAutoscalingAPI_DescribeScalingActivities_Call struct {
+	*mock.Call
+}
+
+// DescribeScalingActivities is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *autoscaling.DescribeScalingActivitiesInput
+//   - _a2 ...func(*autoscaling.Options)
+func (_e *MockAutoscalingAPI_Expecter) DescribeScalingActivities(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockAutoscalingAPI_DescribeScalingActivities_Call {
+	return &MockAutoscalingAPI_DescribeScalingActivities_Call{Call: _e.mock.On("DescribeScalingActivities",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockAutoscalingAPI_DescribeScalingActivities_Call) Run(run func(_a0 context.Context, _a1 *autoscaling.DescribeScalingActivitiesInput, _a2 ...func(*autoscaling.Options))) *MockAutoscalingAPI_DescribeScalingActivities_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*autoscaling.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*autoscaling.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*autoscaling.DescribeScalingActivitiesInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_DescribeScalingActivities_Call) Return(_a0 *autoscaling.DescribeScalingActivitiesOutput, _a1 error) *MockAutoscalingAPI_DescribeScalingActivities_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_DescribeScalingActivities_Call) RunAndReturn(run func(context.Context, *autoscaling.DescribeScalingActivitiesInput, ...func(*autoscaling.Options)) (*autoscaling.DescribeScalingActivitiesOutput, error)) *MockAutoscalingAPI_DescribeScalingActivities_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetInstanceProtection provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockAutoscalingAPI) SetInstanceProtection(_a0 context.Context, _a1 *autoscaling.SetInstanceProtectionInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.SetInstanceProtectionOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetInstanceProtection")
+	}
+
+	var r0 *autoscaling.SetInstanceProtectionOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.SetInstanceProtectionInput, ...func(*autoscaling.Options)) (*autoscaling.SetInstanceProtectionOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.SetInstanceProtectionInput, ...func(*autoscaling.Options)) *autoscaling.SetInstanceProtectionOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.SetInstanceProtectionOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.SetInstanceProtectionInput, ...func(*autoscaling.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAutoscalingAPI_SetInstanceProtection_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetInstanceProtection'
+type MockAutoscalingAPI_SetInstanceProtection_Call struct {
+	*mock.Call
+}
+
+// SetInstanceProtection is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *autoscaling.SetInstanceProtectionInput
+//   - _a2 ...func(*autoscaling.Options)
+func (_e *MockAutoscalingAPI_Expecter) SetInstanceProtection(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockAutoscalingAPI_SetInstanceProtection_Call {
+	return &MockAutoscalingAPI_SetInstanceProtection_Call{Call: _e.mock.On("SetInstanceProtection",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockAutoscalingAPI_SetInstanceProtection_Call) Run(run func(_a0 context.Context, _a1 *autoscaling.SetInstanceProtectionInput, _a2 ...func(*autoscaling.Options))) *MockAutoscalingAPI_SetInstanceProtection_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*autoscaling.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*autoscaling.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*autoscaling.SetInstanceProtectionInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_SetInstanceProtection_Call) Return(_a0 *autoscaling.SetInstanceProtectionOutput, _a1 error) *MockAutoscalingAPI_SetInstanceProtection_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_SetInstanceProtection_Call) RunAndReturn(run func(context.Context, *autoscaling.SetInstanceProtectionInput, ...func(*autoscaling.Options)) (*autoscaling.SetInstanceProtectionOutput, error)) *MockAutoscalingAPI_SetInstanceProtection_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// TerminateInstanceInAutoScalingGroup provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockAutoscalingAPI) TerminateInstanceInAutoScalingGroup(_a0 context.Context, _a1 *autoscaling.TerminateInstanceInAutoScalingGroupInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for TerminateInstanceInAutoScalingGroup")
+	}
+
+	var r0 *autoscaling.TerminateInstanceInAutoScalingGroupOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.TerminateInstanceInAutoScalingGroupInput, ...func(*autoscaling.Options)) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *autoscaling.TerminateInstanceInAutoScalingGroupInput, ...func(*autoscaling.Options)) *autoscaling.TerminateInstanceInAutoScalingGroupOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*autoscaling.TerminateInstanceInAutoScalingGroupOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *autoscaling.TerminateInstanceInAutoScalingGroupInput, ...func(*autoscaling.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'TerminateInstanceInAutoScalingGroup'
+type MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call struct {
+	*mock.Call
+}
+
+// TerminateInstanceInAutoScalingGroup is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *autoscaling.TerminateInstanceInAutoScalingGroupInput
+//   - _a2 ...func(*autoscaling.Options)
+func (_e *MockAutoscalingAPI_Expecter) TerminateInstanceInAutoScalingGroup(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call {
+	return &MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call{Call: _e.mock.On("TerminateInstanceInAutoScalingGroup",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call) Run(run func(_a0 context.Context, _a1 *autoscaling.TerminateInstanceInAutoScalingGroupInput, _a2 ...func(*autoscaling.Options))) *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*autoscaling.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*autoscaling.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*autoscaling.TerminateInstanceInAutoScalingGroupInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call) Return(_a0 *autoscaling.TerminateInstanceInAutoScalingGroupOutput, _a1 error) *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call) RunAndReturn(run func(context.Context, *autoscaling.TerminateInstanceInAutoScalingGroupInput, ...func(*autoscaling.Options)) (*autoscaling.TerminateInstanceInAutoScalingGroupOutput, error)) *MockAutoscalingAPI_TerminateInstanceInAutoScalingGroup_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // UpdateAutoScalingGroup provides a mock function with given fields: _a0, _a1, _a2
 func (_m *MockAutoscalingAPI) UpdateAutoScalingGroup(_a0 context.Context, _a1 *autoscaling.UpdateAutoScalingGroupInput, _a2 ...func(*autoscaling.Options)) (*autoscaling.UpdateAutoScalingGroupOutput, error) {
 	_va := make([]interface{}, len(_a2))
@@ -0,0 +1,111 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package aws
+
+import (
+	context "context"
+
+	ec2 "github.com/aws/aws-sdk-go-v2/service/ec2"
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockEC2API is an autogenerated mock type for the EC2API type
+type MockEC2API struct {
+	mock.Mock
+}
+
+type MockEC2API_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockEC2API) EXPECT() *MockEC2API_Expecter {
+	return &MockEC2API_Expecter{mock: &_m.Mock}
+}
+
+// DescribeInstances provides a mock function with given fields: _a0, _a1, _a2
+func (_m *MockEC2API) DescribeInstances(_a0 context.Context, _a1 *ec2.DescribeInstancesInput, _a2 ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error) {
+	_va := make([]interface{}, len(_a2))
+	for _i := range _a2 {
+		_va[_i] = _a2[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _a0, _a1)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	if len(ret) == 0 {
+		panic("no return value specified for DescribeInstances")
+	}
+
+	var r0 *ec2.DescribeInstancesOutput
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)); ok {
+		return rf(_a0, _a1, _a2...)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) *ec2.DescribeInstancesOutput); ok {
+		r0 = rf(_a0, _a1, _a2...)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*ec2.DescribeInstancesOutput)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) error); ok {
+		r1 = rf(_a0, _a1, _a2...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockEC2API_DescribeInstances_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'DescribeInstances'
+type MockEC2API_DescribeInstances_Call struct {
+	*mock.Call
+}
+
+// DescribeInstances is a helper method to define mock.On call
+//   - _a0 context.Context
+//   - _a1 *ec2.DescribeInstancesInput
+//   - _a2 ...func(*ec2.Options)
+func (_e *MockEC2API_Expecter) DescribeInstances(_a0 interface{}, _a1 interface{}, _a2 ...interface{}) *MockEC2API_DescribeInstances_Call {
+	return &MockEC2API_DescribeInstances_Call{Call: _e.mock.On("DescribeInstances",
+		append([]interface{}{_a0, _a1}, _a2...)...)}
+}
+
+func (_c *MockEC2API_DescribeInstances_Call) Run(run func(_a0 context.Context, _a1 *ec2.DescribeInstancesInput, _a2 ...func(*ec2.Options))) *MockEC2API_DescribeInstances_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		variadicArgs := make([]func(*ec2.Options), len(args)-2)
+		for i, a := range args[2:] {
+			if a != nil {
+				variadicArgs[i] = a.(func(*ec2.Options))
+			}
+		}
+		run(args[0].(context.Context), args[1].(*ec2.DescribeInstancesInput), variadicArgs...)
+	})
+	return _c
+}
+
+func (_c *MockEC2API_DescribeInstances_Call) Return(_a0 *ec2.DescribeInstancesOutput, _a1 error) *MockEC2API_DescribeInstances_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockEC2API_DescribeInstances_Call) RunAndReturn(run func(context.Context, *ec2.DescribeInstancesInput, ...func(*ec2.Options)) (*ec2.DescribeInstancesOutput, error)) *MockEC2API_DescribeInstances_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockEC2API creates a new instance of MockEC2API. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockEC2API(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockEC2API {
+	mock := &MockEC2API{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,204 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package azure
+
+import (
+	armcompute "github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockScaleSetsAPI is an autogenerated mock type for the ScaleSetsAPI type
+type MockScaleSetsAPI struct {
+	mock.Mock
+}
+
+type MockScaleSetsAPI_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockScaleSetsAPI) EXPECT() *MockScaleSetsAPI_Expecter {
+	return &MockScaleSetsAPI_Expecter{mock: &_m.Mock}
+}
+
+// GetScaleSet provides a mock function with given fields: ctx, name
+func (_m *MockScaleSetsAPI) GetScaleSet(ctx context.Context, name string) (*armcompute.VirtualMachineScaleSet, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetScaleSet")
+	}
+
+	var r0 *armcompute.VirtualMachineScaleSet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*armcompute.VirtualMachineScaleSet, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *armcompute.VirtualMachineScaleSet); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*armcompute.VirtualMachineScaleSet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockScaleSetsAPI_GetScaleSet_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetScaleSet'
+type MockScaleSetsAPI_GetScaleSet_Call struct {
+	*mock.Call
+}
+
+// GetScaleSet is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockScaleSetsAPI_Expecter) GetScaleSet(ctx interface{}, name interface{}) *MockScaleSetsAPI_GetScaleSet_Call {
+	return &MockScaleSetsAPI_GetScaleSet_Call{Call: _e.mock.On("GetScaleSet", ctx, name)}
+}
+
+func (_c *MockScaleSetsAPI_GetScaleSet_Call) Run(run func(ctx context.Context, name string)) *MockScaleSetsAPI_GetScaleSet_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_GetScaleSet_Call) Return(_a0 *armcompute.VirtualMachineScaleSet, _a1 error) *MockScaleSetsAPI_GetScaleSet_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_GetScaleSet_Call) RunAndReturn(run func(context.Context, string) (*armcompute.VirtualMachineScaleSet, error)) *MockScaleSetsAPI_GetScaleSet_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListInstances provides a mock function with given fields: ctx, name
+func (_m *MockScaleSetsAPI) ListInstances(ctx context.Context, name string) ([]*armcompute.VirtualMachineScaleSetVM, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListInstances")
+	}
+
+	var r0 []*armcompute.VirtualMachineScaleSetVM
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*armcompute.VirtualMachineScaleSetVM, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*armcompute.VirtualMachineScaleSetVM); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*armcompute.VirtualMachineScaleSetVM)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockScaleSetsAPI_ListInstances_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListInstances'
+type MockScaleSetsAPI_ListInstances_Call struct {
+	*mock.Call
+}
+
+// ListInstances is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockScaleSetsAPI_Expecter) ListInstances(ctx interface{}, name interface{}) *MockScaleSetsAPI_ListInstances_Call {
+	return &MockScaleSetsAPI_ListInstances_Call{Call: _e.mock.On("ListInstances", ctx, name)}
+}
+
+func (_c *MockScaleSetsAPI_ListInstances_Call) Run(run func(ctx context.Context, name string)) *MockScaleSetsAPI_ListInstances_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_ListInstances_Call) Return(_a0 []*armcompute.VirtualMachineScaleSetVM, _a1 error) *MockScaleSetsAPI_ListInstances_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_ListInstances_Call) RunAndReturn(run func(context.Context, string) ([]*armcompute.VirtualMachineScaleSetVM, error)) *MockScaleSetsAPI_ListInstances_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetCapacity provides a mock function with given fields: ctx, name, capacity
+func (_m *MockScaleSetsAPI) SetCapacity(ctx context.Context, name string, capacity int64) error {
+	ret := _m.Called(ctx, name, capacity)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetCapacity")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, name, capacity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockScaleSetsAPI_SetCapacity_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetCapacity'
+type MockScaleSetsAPI_SetCapacity_Call struct {
+	*mock.Call
+}
+
+// SetCapacity is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - capacity int64
+func (_e *MockScaleSetsAPI_Expecter) SetCapacity(ctx interface{}, name interface{}, capacity interface{}) *MockScaleSetsAPI_SetCapacity_Call {
+	return &MockScaleSetsAPI_SetCapacity_Call{Call: _e.mock.On("SetCapacity", ctx, name, capacity)}
+}
+
+func (_c *MockScaleSetsAPI_SetCapacity_Call) Run(run func(ctx context.Context, name string, capacity int64)) *MockScaleSetsAPI_SetCapacity_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_SetCapacity_Call) Return(_a0 error) *MockScaleSetsAPI_SetCapacity_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockScaleSetsAPI_SetCapacity_Call) RunAndReturn(run func(context.Context, string, int64) error) *MockScaleSetsAPI_SetCapacity_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockScaleSetsAPI creates a new instance of MockScaleSetsAPI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockScaleSetsAPI(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockScaleSetsAPI {
+	mock := &MockScaleSetsAPI{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,191 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package digitalocean
+
+import (
+	context "context"
+
+	godo "github.com/digitalocean/godo"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockDropletsAPI is an autogenerated mock type for the DropletsAPI type
+type MockDropletsAPI struct {
+	mock.Mock
+}
+
+type MockDropletsAPI_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockDropletsAPI) EXPECT() *MockDropletsAPI_Expecter {
+	return &MockDropletsAPI_Expecter{mock: &_m.Mock}
+}
+
+// CreateMultiple provides a mock function with given fields: ctx, request
+func (_m *MockDropletsAPI) CreateMultiple(ctx context.Context, request *godo.DropletMultiCreateRequest) error {
+	ret := _m.Called(ctx, request)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CreateMultiple")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *godo.DropletMultiCreateRequest) error); ok {
+		r0 = rf(ctx, request)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDropletsAPI_CreateMultiple_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CreateMultiple'
+type MockDropletsAPI_CreateMultiple_Call struct {
+	*mock.Call
+}
+
+// CreateMultiple is a helper method to define mock.On call
+//   - ctx context.Context
+//   - request *godo.DropletMultiCreateRequest
+func (_e *MockDropletsAPI_Expecter) CreateMultiple(ctx interface{}, request interface{}) *MockDropletsAPI_CreateMultiple_Call {
+	return &MockDropletsAPI_CreateMultiple_Call{Call: _e.mock.On("CreateMultiple", ctx, request)}
+}
+
+func (_c *MockDropletsAPI_CreateMultiple_Call) Run(run func(ctx context.Context, request *godo.DropletMultiCreateRequest)) *MockDropletsAPI_CreateMultiple_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(*godo.DropletMultiCreateRequest))
+	})
+	return _c
+}
+
+func (_c *MockDropletsAPI_CreateMultiple_Call) Return(_a0 error) *MockDropletsAPI_CreateMultiple_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDropletsAPI_CreateMultiple_Call) RunAndReturn(run func(context.Context, *godo.DropletMultiCreateRequest) error) *MockDropletsAPI_CreateMultiple_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Delete provides a mock function with given fields: ctx, id
+func (_m *MockDropletsAPI) Delete(ctx context.Context, id int) error {
+	ret := _m.Called(ctx, id)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Delete")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockDropletsAPI_Delete_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Delete'
+type MockDropletsAPI_Delete_Call struct {
+	*mock.Call
+}
+
+// Delete is a helper method to define mock.On call
+//   - ctx context.Context
+//   - id int
+func (_e *MockDropletsAPI_Expecter) Delete(ctx interface{}, id interface{}) *MockDropletsAPI_Delete_Call {
+	return &MockDropletsAPI_Delete_Call{Call: _e.mock.On("Delete", ctx, id)}
+}
+
+func (_c *MockDropletsAPI_Delete_Call) Run(run func(ctx context.Context, id int)) *MockDropletsAPI_Delete_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(int))
+	})
+	return _c
+}
+
+func (_c *MockDropletsAPI_Delete_Call) Return(_a0 error) *MockDropletsAPI_Delete_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockDropletsAPI_Delete_Call) RunAndReturn(run func(context.Context, int) error) *MockDropletsAPI_Delete_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListByTag provides a mock function with given fields: ctx, tag
+func (_m *MockDropletsAPI) ListByTag(ctx context.Context, tag string) ([]godo.Droplet, error) {
+	ret := _m.Called(ctx, tag)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListByTag")
+	}
+
+	var r0 []godo.Droplet
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]godo.Droplet, error)); ok {
+		return rf(ctx, tag)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []godo.Droplet); ok {
+		r0 = rf(ctx, tag)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]godo.Droplet)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tag)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockDropletsAPI_ListByTag_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListByTag'
+type MockDropletsAPI_ListByTag_Call struct {
+	*mock.Call
+}
+
+// ListByTag is a helper method to define mock.On call
+//   - ctx context.Context
+//   - tag string
+func (_e *MockDropletsAPI_Expecter) ListByTag(ctx interface{}, tag interface{}) *MockDropletsAPI_ListByTag_Call {
+	return &MockDropletsAPI_ListByTag_Call{Call: _e.mock.On("ListByTag", ctx, tag)}
+}
+
+func (_c *MockDropletsAPI_ListByTag_Call) Run(run func(ctx context.Context, tag string)) *MockDropletsAPI_ListByTag_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockDropletsAPI_ListByTag_Call) Return(_a0 []godo.Droplet, _a1 error) *MockDropletsAPI_ListByTag_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockDropletsAPI_ListByTag_Call) RunAndReturn(run func(context.Context, string) ([]godo.Droplet, error)) *MockDropletsAPI_ListByTag_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockDropletsAPI creates a new instance of MockDropletsAPI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockDropletsAPI(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockDropletsAPI {
+	mock := &MockDropletsAPI{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
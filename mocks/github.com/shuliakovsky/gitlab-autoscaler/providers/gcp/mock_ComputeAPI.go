@@ -0,0 +1,204 @@
+// Code generated by mockery. DO NOT EDIT.
+
+package gcp
+
+import (
+	context "context"
+
+	compute "google.golang.org/api/compute/v1"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MockComputeAPI is an autogenerated mock type for the ComputeAPI type
+type MockComputeAPI struct {
+	mock.Mock
+}
+
+type MockComputeAPI_Expecter struct {
+	mock *mock.Mock
+}
+
+func (_m *MockComputeAPI) EXPECT() *MockComputeAPI_Expecter {
+	return &MockComputeAPI_Expecter{mock: &_m.Mock}
+}
+
+// GetInstanceGroupManager provides a mock function with given fields: ctx, name
+func (_m *MockComputeAPI) GetInstanceGroupManager(ctx context.Context, name string) (*compute.InstanceGroupManager, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for GetInstanceGroupManager")
+	}
+
+	var r0 *compute.InstanceGroupManager
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*compute.InstanceGroupManager, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *compute.InstanceGroupManager); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*compute.InstanceGroupManager)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockComputeAPI_GetInstanceGroupManager_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'GetInstanceGroupManager'
+type MockComputeAPI_GetInstanceGroupManager_Call struct {
+	*mock.Call
+}
+
+// GetInstanceGroupManager is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockComputeAPI_Expecter) GetInstanceGroupManager(ctx interface{}, name interface{}) *MockComputeAPI_GetInstanceGroupManager_Call {
+	return &MockComputeAPI_GetInstanceGroupManager_Call{Call: _e.mock.On("GetInstanceGroupManager", ctx, name)}
+}
+
+func (_c *MockComputeAPI_GetInstanceGroupManager_Call) Run(run func(ctx context.Context, name string)) *MockComputeAPI_GetInstanceGroupManager_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockComputeAPI_GetInstanceGroupManager_Call) Return(_a0 *compute.InstanceGroupManager, _a1 error) *MockComputeAPI_GetInstanceGroupManager_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockComputeAPI_GetInstanceGroupManager_Call) RunAndReturn(run func(context.Context, string) (*compute.InstanceGroupManager, error)) *MockComputeAPI_GetInstanceGroupManager_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListManagedInstances provides a mock function with given fields: ctx, name
+func (_m *MockComputeAPI) ListManagedInstances(ctx context.Context, name string) ([]*compute.ManagedInstance, error) {
+	ret := _m.Called(ctx, name)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListManagedInstances")
+	}
+
+	var r0 []*compute.ManagedInstance
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) ([]*compute.ManagedInstance, error)); ok {
+		return rf(ctx, name)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) []*compute.ManagedInstance); ok {
+		r0 = rf(ctx, name)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]*compute.ManagedInstance)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, name)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MockComputeAPI_ListManagedInstances_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListManagedInstances'
+type MockComputeAPI_ListManagedInstances_Call struct {
+	*mock.Call
+}
+
+// ListManagedInstances is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+func (_e *MockComputeAPI_Expecter) ListManagedInstances(ctx interface{}, name interface{}) *MockComputeAPI_ListManagedInstances_Call {
+	return &MockComputeAPI_ListManagedInstances_Call{Call: _e.mock.On("ListManagedInstances", ctx, name)}
+}
+
+func (_c *MockComputeAPI_ListManagedInstances_Call) Run(run func(ctx context.Context, name string)) *MockComputeAPI_ListManagedInstances_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *MockComputeAPI_ListManagedInstances_Call) Return(_a0 []*compute.ManagedInstance, _a1 error) *MockComputeAPI_ListManagedInstances_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *MockComputeAPI_ListManagedInstances_Call) RunAndReturn(run func(context.Context, string) ([]*compute.ManagedInstance, error)) *MockComputeAPI_ListManagedInstances_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// Resize provides a mock function with given fields: ctx, name, size
+func (_m *MockComputeAPI) Resize(ctx context.Context, name string, size int64) error {
+	ret := _m.Called(ctx, name, size)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Resize")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, int64) error); ok {
+		r0 = rf(ctx, name, size)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MockComputeAPI_Resize_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Resize'
+type MockComputeAPI_Resize_Call struct {
+	*mock.Call
+}
+
+// Resize is a helper method to define mock.On call
+//   - ctx context.Context
+//   - name string
+//   - size int64
+func (_e *MockComputeAPI_Expecter) Resize(ctx interface{}, name interface{}, size interface{}) *MockComputeAPI_Resize_Call {
+	return &MockComputeAPI_Resize_Call{Call: _e.mock.On("Resize", ctx, name, size)}
+}
+
+func (_c *MockComputeAPI_Resize_Call) Run(run func(ctx context.Context, name string, size int64)) *MockComputeAPI_Resize_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(int64))
+	})
+	return _c
+}
+
+func (_c *MockComputeAPI_Resize_Call) Return(_a0 error) *MockComputeAPI_Resize_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *MockComputeAPI_Resize_Call) RunAndReturn(run func(context.Context, string, int64) error) *MockComputeAPI_Resize_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// NewMockComputeAPI creates a new instance of MockComputeAPI. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+// The first argument is typically a *testing.T value.
+func NewMockComputeAPI(t interface {
+	mock.TestingT
+	Cleanup(func())
+}) *MockComputeAPI {
+	mock := &MockComputeAPI{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
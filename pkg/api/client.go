@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrNoCycle is returned until the autoscaler completed its first scaling cycle
+	ErrNoCycle = errors.New("no scaling cycle completed yet")
+	// ErrUnknownASG is returned by Explain for an ASG the autoscaler does not manage
+	ErrUnknownASG = errors.New("unknown ASG")
+)
+
+// StatusError is returned for a status code the endpoint does not answer with itself, e.g. from a proxy in between
+type StatusError struct {
+	StatusCode int    // HTTP status code of the response
+	Path       string // Path of the endpoint requested
+	Message    string // Error message of the response body, empty when it carried none
+}
+
+// Error formats the status code with the path and the message of the response
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("status API returned %d for %s: %s", e.StatusCode, e.Path, e.Message)
+}
+
+// noCycle maps the status code of the endpoints answering before the first scaling cycle
+var noCycle = map[int]error{http.StatusServiceUnavailable: ErrNoCycle}
+
+// unixScheme prefixes base URLs that address a unix socket, e.g. "unix:///run/gitlab-autoscaler.sock"
+const unixScheme = "unix://"
+
+// Client reads the status API of a running autoscaler
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient creates a client for the status API at baseURL, e.g. "http://127.0.0.1:8048"
+// or "unix:///run/gitlab-autoscaler.sock"
+func NewClient(baseURL string) (*Client, error) {
+	if socket, ok := strings.CutPrefix(baseURL, unixScheme); ok {
+		if socket == "" {
+			return nil, fmt.Errorf("unix socket path is empty in %q", baseURL)
+		}
+		var dialer net.Dialer
+		transport := &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				return dialer.DialContext(ctx, "unix", socket)
+			},
+		}
+		return &Client{baseURL: "http://unix", httpClient: &http.Client{Transport: transport, Timeout: 10 * time.Second}}, nil
+	}
+
+	parsed, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid base URL %q: %w", baseURL, err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, fmt.Errorf("base URL %q must use http, https or unix", baseURL)
+	}
+	return &Client{baseURL: strings.TrimSuffix(baseURL, "/"), httpClient: &http.Client{Timeout: 10 * time.Second}}, nil
+}
+
+// Status returns the last observed capacity and scaling decision of every ASG
+func (c *Client) Status(ctx context.Context) ([]ASGStatus, error) {
+	var statuses []ASGStatus
+	if err := c.get(ctx, "/asgs", &statuses, noCycle); err != nil {
+		return nil, err
+	}
+	return statuses, nil
+}
+
+// Explain returns the last scaling decision of a single ASG together with its reason
+func (c *Client) Explain(ctx context.Context, asgName string) (ASGStatus, error) {
+	var status ASGStatus
+	known := map[int]error{http.StatusServiceUnavailable: ErrNoCycle, http.StatusNotFound: ErrUnknownASG}
+	if err := c.get(ctx, "/asgs/"+url.PathEscape(asgName), &status, known); err != nil {
+		return ASGStatus{}, err
+	}
+	return status, nil
+}
+
+// State returns the GitLab cluster state of the last cycle
+func (c *Client) State(ctx context.Context) (StateResponse, error) {
+	var state StateResponse
+	if err := c.get(ctx, "/state", &state, noCycle); err != nil {
+		return StateResponse{}, err
+	}
+	return state, nil
+}
+
+// get requests path and decodes the JSON response into out. Status codes in known map to the errors the endpoint
+// answers with, any other one that is not OK to a StatusError.
+func (c *Client) get(ctx context.Context, path string, out any, known map[int]error) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+path, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to request %s: %w", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		if err, ok := known[resp.StatusCode]; ok {
+			return err
+		}
+		var body ErrorResponse
+		_ = json.NewDecoder(resp.Body).Decode(&body)
+		return &StatusError{StatusCode: resp.StatusCode, Path: path, Message: body.Error}
+	}
+
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s: %w", path, err)
+	}
+	return nil
+}
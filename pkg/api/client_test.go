@@ -0,0 +1,139 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go/build"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestASGStatus_RoundTrip verifies every field survives encoding and decoding with the documented keys
+func TestASGStatus_RoundTrip(t *testing.T) {
+	status := ASGStatus{
+		Name: "runner-amd64", Provider: "aws", Desired: 1, Allocated: 1, Proposed: 3,
+		Decision: DecisionScaleUp, Reason: "3 matching pending jobs, 1 free slots", Policy: "damped",
+		EvaluatedAt:    time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+		Blocked:        map[string]int64{"max-capacity": 1},
+		CadenceSeconds: 10, ErrorStreak: 2,
+		LastDescribeAt: time.Date(2024, 5, 6, 8, 59, 50, 0, time.UTC),
+	}
+
+	encoded, err := json.Marshal(status)
+	require.NoError(t, err)
+	for _, key := range []string{`"evaluated_at"`, `"cadence_seconds"`, `"error_streak"`, `"decision":"scale-up"`, `"last_successful_describe"`} {
+		assert.Contains(t, string(encoded), key)
+	}
+	assert.NotContains(t, string(encoded), `"last_successful_update"`)
+
+	var decoded ASGStatus
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, status, decoded)
+}
+
+// TestStateResponse_RoundTrip verifies the cluster state is passed through undecoded
+func TestStateResponse_RoundTrip(t *testing.T) {
+	state := StateResponse{
+		Timestamp: time.Date(2024, 5, 6, 9, 0, 0, 0, time.UTC),
+		State:     json.RawMessage(`{"total_pending_jobs":3}`),
+	}
+
+	encoded, err := json.Marshal(state)
+	require.NoError(t, err)
+
+	var decoded StateResponse
+	require.NoError(t, json.Unmarshal(encoded, &decoded))
+	assert.Equal(t, state, decoded)
+}
+
+// TestClient_Errors verifies status codes map to the exported errors of the endpoints answering with them
+// Expected behavior:
+//   - 503 is ErrNoCycle, 404 of Explain is ErrUnknownASG
+//   - 404 of another endpoint, e.g. from a proxy, is a StatusError, not ErrUnknownASG
+//   - Other codes are a StatusError carrying the error message of the response
+func TestClient_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/asgs":
+			w.WriteHeader(http.StatusServiceUnavailable)
+		case "/asgs/missing":
+			w.WriteHeader(http.StatusNotFound)
+		case "/state":
+			w.WriteHeader(http.StatusInternalServerError)
+			_ = json.NewEncoder(w).Encode(ErrorResponse{Error: "boom"})
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewClient(server.URL + "/")
+	require.NoError(t, err)
+
+	_, err = client.Status(context.Background())
+	assert.True(t, errors.Is(err, ErrNoCycle))
+
+	_, err = client.Explain(context.Background(), "missing")
+	assert.True(t, errors.Is(err, ErrUnknownASG))
+
+	_, err = client.State(context.Background())
+	assert.ErrorContains(t, err, "returned 500 for /state: boom")
+	var statusErr *StatusError
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusInternalServerError, statusErr.StatusCode)
+
+	proxied, err := NewClient(server.URL + "/prefix")
+	require.NoError(t, err)
+	_, err = proxied.Status(context.Background())
+	assert.False(t, errors.Is(err, ErrUnknownASG))
+	require.ErrorAs(t, err, &statusErr)
+	assert.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+}
+
+// TestClient_UnixSocket verifies a unix:// base URL is served over the socket
+func TestClient_UnixSocket(t *testing.T) {
+	socket := filepath.Join(t.TempDir(), "autoscaler.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(ASGStatus{Name: strings.TrimPrefix(r.URL.Path, "/asgs/"), Decision: DecisionNone})
+	})}
+	go func() { _ = server.Serve(listener) }()
+	defer server.Close()
+
+	client, err := NewClient("unix://" + socket)
+	require.NoError(t, err)
+
+	status, err := client.Explain(context.Background(), "runner-amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "runner-amd64", status.Name)
+}
+
+// TestNewClient_InvalidURL verifies unsupported base URLs are rejected
+func TestNewClient_InvalidURL(t *testing.T) {
+	for _, baseURL := range []string{"127.0.0.1:8048", "ftp://host", "unix://"} {
+		_, err := NewClient(baseURL)
+		assert.Error(t, err, baseURL)
+	}
+}
+
+// TestPackage_StandardLibraryOnly verifies the package does not import anything outside the standard library
+func TestPackage_StandardLibraryOnly(t *testing.T) {
+	pkg, err := build.ImportDir(".", 0)
+	require.NoError(t, err)
+
+	for _, path := range pkg.Imports {
+		first, _, _ := strings.Cut(path, "/")
+		assert.NotContains(t, first, ".", "non-standard import %s", path)
+	}
+}
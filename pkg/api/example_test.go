@@ -0,0 +1,39 @@
+package api_test
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/pkg/api"
+)
+
+// Example reads the decisions of the last scaling cycle from the admin listener
+func Example() {
+	client, err := api.NewClient("http://127.0.0.1:8048")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	statuses, err := client.Status(context.Background())
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, status := range statuses {
+		fmt.Printf("%s: %s (%s)\n", status.Name, status.Decision, status.Reason)
+	}
+}
+
+// ExampleClient_Explain shows why a single ASG was or was not scaled in the last cycle
+func ExampleClient_Explain() {
+	client, err := api.NewClient("unix:///run/gitlab-autoscaler.sock")
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	status, err := client.Explain(context.Background(), "my-gitlab-runner-amd64")
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("desired %d, proposed %d: %s\n", status.Desired, status.Proposed, status.Reason)
+}
@@ -0,0 +1,109 @@
+// Package api holds the JSON types of the autoscaler status API and a client to read it.
+// It depends on the standard library only, so other modules can import it without the autoscaler internals.
+package api
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Decision is the outcome of evaluating a single ASG in a cycle
+type Decision string
+
+const (
+	DecisionNone      Decision = "none"
+	DecisionScaleUp   Decision = "scale-up"
+	DecisionScaleDown Decision = "scale-down"
+	DecisionError     Decision = "error"
+	DecisionBackedOff Decision = "backed-off"           // Not evaluated this cycle: the ASG is backed off after consecutive errors
+	DecisionBlackout  Decision = "blackout"             // A capacity change was decided but held back by an active blackout window
+	DecisionActivity  Decision = "activity-in-progress" // A capacity change was decided but held back by a scaling activity still in progress
+	DecisionDryRun    Decision = "dry-run"              // A capacity change was decided but only logged, as autoscaler.dry-run is set
+)
+
+// ASGStatus is the last observed capacity of an ASG together with the scaling decision and its reason.
+// It is served by GET /asgs and GET /asgs/{name}.
+type ASGStatus struct {
+	Name        string    `json:"name"`
+	Provider    string    `json:"provider"`
+	Desired     int64     `json:"desired"`
+	Allocated   int64     `json:"allocated"`
+	Proposed    int64     `json:"proposed"`
+	Decision    Decision  `json:"decision"`
+	Reason      string    `json:"reason"`
+	Policy      string    `json:"policy"`
+	EvaluatedAt time.Time `json:"evaluated_at"`
+	// Blocked holds the instances needed but not provided, per reason (e.g. "max-capacity")
+	Blocked map[string]int64 `json:"blocked,omitempty"`
+	// CadenceSeconds is the current evaluation interval, widened beyond check-interval after consecutive errors
+	CadenceSeconds float64 `json:"cadence_seconds"`
+	ErrorStreak    int     `json:"error_streak,omitempty"`
+	// Schedule lists the schedule windows overriding the capacity bounds this cycle
+	Schedule string `json:"schedule,omitempty"`
+	// StuckInstances is the number of instances pending longer than pending-timeout, left out of Allocated
+	StuckInstances int64 `json:"stuck_instances,omitempty"`
+	// ProtectedInstances is the number of instances protected from scale-in because they run a job, with protect-busy-instances
+	ProtectedInstances int64 `json:"protected_instances,omitempty"`
+	// WarmInstances is the number of instances in the warm pool of the ASG, neither allocated nor launching
+	WarmInstances int64 `json:"warm_instances,omitempty"`
+	// RecycledInstance is the instance terminated this cycle for being older than max-instance-age
+	RecycledInstance string `json:"recycled_instance,omitempty"`
+	// LastDescribeAt and LastUpdateAt are the last successful capacity read and change; zero when there was none
+	LastDescribeAt time.Time `json:"last_successful_describe,omitzero"`
+	LastUpdateAt   time.Time `json:"last_successful_update,omitzero"`
+}
+
+// StuckQueue is a tag whose pending jobs got no scale-up for autoscaler.stuck-queue-cycles consecutive cycles,
+// with the diagnosis of what blocked the ASGs serving it
+type StuckQueue struct {
+	Tag         string `json:"tag"`
+	PendingJobs int    `json:"pending_jobs"`
+	Cycles      int    `json:"cycles"`
+	// Diagnosis holds one line per blocking constraint found in the decisions of the cycle, with its remediation
+	Diagnosis []string `json:"diagnosis"`
+}
+
+// FleetingTargets is the body of GET /targets on the fleeting listener and the content of fleeting.file:
+// the desired capacity the autoscaler computed for every export-only: fleeting ASG
+type FleetingTargets struct {
+	Targets []FleetingTarget `json:"targets"`
+}
+
+// FleetingTarget is the desired capacity of a single ASG, served by GET /targets/{name}
+type FleetingTarget struct {
+	Name      string    `json:"name"`
+	Desired   int64     `json:"desired"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// StateResponse is the body of GET /state. State is the GitLab cluster state of the last cycle;
+// its shape follows the autoscaler version and is left undecoded.
+type StateResponse struct {
+	Timestamp time.Time       `json:"timestamp"`
+	State     json.RawMessage `json:"state"`
+}
+
+// Status of a health endpoint
+const (
+	HealthOK          = "ok"
+	HealthUnavailable = "unavailable"
+)
+
+// HealthResponse is the body of GET /healthz and GET /readyz, answered with 503 when a precondition failed
+type HealthResponse struct {
+	Status              string    `json:"status"`                         // HealthOK or HealthUnavailable
+	Failed              []string  `json:"failed,omitempty"`               // The preconditions that failed
+	LastSuccessfulCycle time.Time `json:"last_successful_cycle,omitzero"` // Last cycle without errors
+	LastErrorAt         time.Time `json:"last_error_at,omitzero"`         // Last cycle that failed
+	LastError           string    `json:"last_error,omitempty"`           // Why it failed
+}
+
+// TriggerResponse is the body of POST /trigger, answered with 202: the cycle runs in the background
+type TriggerResponse struct {
+	Coalesced bool `json:"coalesced"` // An immediate cycle was already pending and runs for this trigger too
+}
+
+// ErrorResponse is the body of every other non-2xx response
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
@@ -1,50 +1,346 @@
 package aws
 
 import (
+	"cmp"
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 
 	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/utils"
 )
 
 const minCapacity = 0
 
-func NewAWSClient(region string) (core.Provider, error) {
-	cfg, err := config.LoadDefaultConfig(context.TODO(),
+// describeBatchSize is the most ASG names DescribeAll passes to a single DescribeAutoScalingGroups request
+const describeBatchSize = 50
+
+// Tags recording the last scale-up with tag-scaling-events
+const (
+	scaleReasonTag = "gitlab-autoscaler:last-scale-reason"
+	scaleTimeTag   = "gitlab-autoscaler:last-scale-time"
+)
+
+// activityRecords is how many of the latest scaling activities are checked for one in progress
+const activityRecords = 10
+
+// protectionBatchSize is the most instance IDs a single SetInstanceProtection request accepts
+const protectionBatchSize = 50
+
+// launchTimeBatchSize is the most instance IDs a single DescribeInstances request is given
+const launchTimeBatchSize = 100
+
+// Roles are the IAM roles assumed by the client; an empty ARN uses the ambient credentials
+type Roles struct {
+	Read  string // Role for DescribeAutoScalingGroups and DescribeInstances
+	Write string // Role for capacity changes, instance terminations, scale-in protection and lifecycle actions
+
+	ExternalID  string // External ID required by the trust policy of the roles, if any
+	SessionName string // Session name of the assumed roles, shown in CloudTrail. Default is "gitlab-autoscaler"
+}
+
+// defaultSessionName is the session name of assumed roles when Roles.SessionName is empty
+const defaultSessionName = "gitlab-autoscaler"
+
+// Options tune the behavior of the client
+type Options struct {
+	ManageMinMax bool // Pin MinSize and MaxSize to the desired capacity on capacity updates
+	MaxRetries   int  // Retries of a throttled or transiently failing API call before it fails
+
+	CountUnhealthyAsAllocated bool // Count instances with HealthStatus Unhealthy as allocated although they are being replaced
+	TagScalingEvents          bool // Tag the ASG with the reason and time of every scale-up, propagated to new instances
+
+	RequestTimeout time.Duration // Bound of a single API call attempt, so that a hung endpoint cannot block a cycle
+
+	Profile     string // Named profile of the shared config and credentials files; the SDK default when empty
+	EndpointURL string // Endpoint of the Auto Scaling and EC2 APIs instead of the ones of the region, e.g. LocalStack
+}
+
+// credentialsTimeout bounds assuming a role
+const credentialsTimeout = 10 * time.Second
+
+// NewAWSClient creates a client for the region. Describe calls use the read role and capacity changes the
+// write role; the SDK refreshes the credentials of assumed roles before they expire. A read role that cannot
+// be assumed fails right away. When the write role cannot be assumed the client is still returned: describes keep working,
+// updates fail and retry assuming the role, and WriteError reports the cause. With ManageMinMax capacity
+// updates pin MinSize and MaxSize to the desired capacity; otherwise the limits of the group are left alone.
+// Throttled and transient failures are retried MaxRetries times with backoff by the client itself, the SDK
+// retryer is disabled so that calls are not retried twice. Every attempt is bounded by RequestTimeout.
+// Credentials come from the Profile when set, and the Auto Scaling and EC2 APIs are reached at EndpointURL when set.
+// Launch times of instances are described through EC2 with the read role.
+func NewAWSClient(region string, roles Roles, options Options) (core.Provider, error) {
+	loadOptions := []func(*config.LoadOptions) error{
 		config.WithRegion(region),
-	)
+		config.WithRetryer(func() aws.Retryer { return aws.NopRetryer{} }),
+	}
+	if options.Profile != "" {
+		loadOptions = append(loadOptions, config.WithSharedConfigProfile(options.Profile))
+	}
+	cfg, err := config.LoadDefaultConfig(context.TODO(), loadOptions...)
 	if err != nil {
 		return nil, errors.New("failed to load AWS configuration: " + err.Error())
 	}
+	newService := func(cfg aws.Config) *autoscaling.Client {
+		return autoscaling.NewFromConfig(cfg, withEndpoint(options.EndpointURL))
+	}
 
-	svc := autoscaling.NewFromConfig(cfg)
+	readCfg := roles.withRole(cfg, roles.Read)
+	if roles.Read != "" {
+		if err := verifyRole(readCfg.Credentials, roles.Read); err != nil {
+			return nil, err
+		}
+	}
 
-	return &AWSClient{
-		svc: svc,
-	}, nil
+	client := &AWSClient{
+		region:         region,
+		svc:            newService(readCfg),
+		ec2Svc:         ec2.NewFromConfig(readCfg, withEC2Endpoint(options.EndpointURL)),
+		manageMinMax:   options.ManageMinMax,
+		countUnhealthy: options.CountUnhealthyAsAllocated,
+		tagScaling:     options.TagScalingEvents,
+		maxRetries:     options.MaxRetries,
+		requestTimeout: options.RequestTimeout,
+	}
+	switch roles.Write {
+	case "":
+		client.writeSvc = newService(cfg)
+		return client, nil
+	case roles.Read:
+		client.writeSvc = client.svc
+		return client, nil
+	}
+
+	writeCfg := roles.withRole(cfg, roles.Write)
+	client.newWriteSvc = func() (AutoscalingAPI, error) {
+		if err := verifyRole(writeCfg.Credentials, roles.Write); err != nil {
+			return nil, err
+		}
+		return newService(writeCfg), nil
+	}
+	_, _ = client.writer()
+	return client, nil
+}
+
+// withEndpoint sets the endpoint of the Auto Scaling client; the endpoint of the region is kept when endpointURL is empty
+func withEndpoint(endpointURL string) func(*autoscaling.Options) {
+	return func(o *autoscaling.Options) {
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	}
+}
+
+// withEC2Endpoint sets the endpoint of the EC2 client; the endpoint of the region is kept when endpointURL is empty
+func withEC2Endpoint(endpointURL string) func(*ec2.Options) {
+	return func(o *ec2.Options) {
+		if endpointURL != "" {
+			o.BaseEndpoint = aws.String(endpointURL)
+		}
+	}
 }
 
-func (c *AWSClient) GetCurrentCapacity(asgName string) (int64, int64, error) {
+// withRole returns cfg with credentials assuming roleARN through STS; cfg itself when roleARN is empty
+func (r Roles) withRole(cfg aws.Config, roleARN string) aws.Config {
+	if roleARN == "" {
+		return cfg
+	}
+	assumed := cfg.Copy()
+	assumed.Credentials = r.credentials(sts.NewFromConfig(cfg), roleARN)
+	return assumed
+}
+
+// credentials returns the cached credentials of roleARN assumed through client with the external ID and
+// session name of the roles; the cache assumes the role again before the credentials expire
+func (r Roles) credentials(client stscreds.AssumeRoleAPIClient, roleARN string) aws.CredentialsProvider {
+	return aws.NewCredentialsCache(stscreds.NewAssumeRoleProvider(client, roleARN, func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = cmp.Or(r.SessionName, defaultSessionName)
+		if r.ExternalID != "" {
+			o.ExternalID = aws.String(r.ExternalID)
+		}
+	}))
+}
+
+// verifyRole assumes the role once through credentials, bounded by credentialsTimeout
+func verifyRole(credentials aws.CredentialsProvider, roleARN string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), credentialsTimeout)
+	defer cancel()
+	if _, err := credentials.Retrieve(ctx); err != nil {
+		return fmt.Errorf("failed to assume role %s: %w", roleARN, err)
+	}
+	return nil
+}
+
+// writer returns the service for capacity changes, assuming the write role again while it is unavailable
+func (c *AWSClient) writer() (AutoscalingAPI, error) {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	if c.writeSvc != nil {
+		return c.writeSvc, nil
+	}
+	if c.newWriteSvc == nil {
+		return c.svc, nil
+	}
+	svc, err := c.newWriteSvc()
+	if err != nil {
+		c.writeErr = err
+		return nil, err
+	}
+	c.writeSvc, c.writeErr = svc, nil
+	return svc, nil
+}
+
+// Region returns the region the client is bound to
+func (c *AWSClient) Region() string {
+	return c.region
+}
+
+// WriteError returns why capacity changes are unavailable; nil when the write credentials resolved
+func (c *AWSClient) WriteError() error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.writeErr
+}
+
+// DescribeAll describes the ASGs in as few requests as possible, describeBatchSize names each and following
+// pagination, and keeps the groups for the next GetCurrentCapacity call of each. The groups of a previous call
+// are dropped, so a group is never read from an older cycle.
+func (c *AWSClient) DescribeAll(ctx context.Context, asgNames []string) error {
+	groups := make(map[string]types.AutoScalingGroup, len(asgNames))
+	for batch := range slices.Chunk(asgNames, describeBatchSize) {
+		input := &autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: batch,
+		}
+		for {
+			var result *autoscaling.DescribeAutoScalingGroupsOutput
+			err := c.withRetries(ctx, fmt.Sprintf("describe of %d ASGs", len(batch)), func(ctx context.Context) (err error) {
+				result, err = c.svc.DescribeAutoScalingGroups(ctx, input)
+				return err
+			})
+			if err != nil {
+				c.keepDescribed(nil)
+				return fmt.Errorf("failed to describe %d ASGs: %w", len(batch), err)
+			}
+			for _, group := range result.AutoScalingGroups {
+				groups[aws.ToString(group.AutoScalingGroupName)] = group
+			}
+			if aws.ToString(result.NextToken) == "" {
+				break
+			}
+			input = &autoscaling.DescribeAutoScalingGroupsInput{
+				AutoScalingGroupNames: batch,
+				NextToken:             result.NextToken,
+			}
+		}
+	}
+	c.keepDescribed(groups)
+	return nil
+}
+
+// DiscoveredASG is a group found by DiscoverASGs, with the tags set on it
+type DiscoveredASG struct {
+	Name string
+	Tags map[string]string
+}
+
+// DiscoverASGs lists the groups carrying the tag key, with the tag value unless it is empty, following pagination
+func (c *AWSClient) DiscoverASGs(ctx context.Context, tagKey, tagValue string) ([]DiscoveredASG, error) {
+	filter := types.Filter{Name: aws.String("tag-key"), Values: []string{tagKey}}
+	if tagValue != "" {
+		filter = types.Filter{Name: aws.String("tag:" + tagKey), Values: []string{tagValue}}
+	}
+	input := &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters: []types.Filter{filter},
+	}
+
+	var discovered []DiscoveredASG
+	for {
+		var result *autoscaling.DescribeAutoScalingGroupsOutput
+		err := c.withRetries(ctx, "discovery of ASGs tagged "+tagKey, func(ctx context.Context) (err error) {
+			result, err = c.svc.DescribeAutoScalingGroups(ctx, input)
+			return err
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to discover ASGs tagged %s: %w", tagKey, err)
+		}
+		for _, group := range result.AutoScalingGroups {
+			tags := make(map[string]string, len(group.Tags))
+			for _, tag := range group.Tags {
+				tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+			}
+			discovered = append(discovered, DiscoveredASG{Name: aws.ToString(group.AutoScalingGroupName), Tags: tags})
+		}
+		if aws.ToString(result.NextToken) == "" {
+			return discovered, nil
+		}
+		input = &autoscaling.DescribeAutoScalingGroupsInput{
+			Filters:   []types.Filter{filter},
+			NextToken: result.NextToken,
+		}
+	}
+}
+
+// keepDescribed replaces the groups of the last DescribeAll
+func (c *AWSClient) keepDescribed(groups map[string]types.AutoScalingGroup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.described = groups
+}
+
+// takeDescribed returns the group kept by DescribeAll and forgets it, so that it is read once
+func (c *AWSClient) takeDescribed(asgName string) (types.AutoScalingGroup, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	group, ok := c.described[asgName]
+	delete(c.described, asgName)
+	return group, ok
+}
+
+// describe returns the group from the last DescribeAll, or describes it on its own
+func (c *AWSClient) describe(ctx context.Context, asgName string) (types.AutoScalingGroup, error) {
+	if group, ok := c.takeDescribed(asgName); ok {
+		return group, nil
+	}
 	input := &autoscaling.DescribeAutoScalingGroupsInput{
 		AutoScalingGroupNames: []string{asgName},
 	}
 
-	result, err := c.svc.DescribeAutoScalingGroups(context.TODO(), input)
+	var result *autoscaling.DescribeAutoScalingGroupsOutput
+	err := c.withRetries(ctx, "describe of ASG "+utils.Safe(asgName), func(ctx context.Context) (err error) {
+		result, err = c.svc.DescribeAutoScalingGroups(ctx, input)
+		return err
+	})
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to describe ASG %s: %w", asgName, err)
+		return types.AutoScalingGroup{}, fmt.Errorf("failed to describe ASG %s: %w", asgName, err)
 	}
 
 	if len(result.AutoScalingGroups) == 0 {
-		return 0, 0, fmt.Errorf("ASG %s not found", asgName)
+		return types.AutoScalingGroup{}, fmt.Errorf("ASG %s not found", asgName)
 	}
+	return result.AutoScalingGroups[0], nil
+}
 
-	asg := result.AutoScalingGroups[0]
+func (c *AWSClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	asg, err := c.describe(ctx, asgName)
+	if err != nil {
+		return 0, 0, err
+	}
 	var allocatedCount int64 = 0
+	var instances []core.Instance
+	var terminating []string
+	var warm int64
 
 	allocatedStates := map[string]bool{
 		"InService":       true,
@@ -58,35 +354,360 @@ func (c *AWSClient) GetCurrentCapacity(asgName string) (int64, int64, error) {
 			continue
 		}
 		state := string(inst.LifecycleState)
-		if allocatedStates[state] {
+		if inst.LifecycleState == types.LifecycleStateTerminatingWait {
+			terminating = append(terminating, aws.ToString(inst.InstanceId))
+		}
+		if strings.HasPrefix(state, "Warmed:") && !strings.HasPrefix(state, "Warmed:Terminat") {
+			warm++
+		}
+		// Unhealthy instances are replaced by the ASG and run no jobs meanwhile
+		unhealthy := aws.ToString(inst.HealthStatus) == "Unhealthy"
+		if allocatedStates[state] && (!unhealthy || c.countUnhealthy) {
 			allocatedCount++
+			instances = append(instances, core.Instance{
+				ID:        aws.ToString(inst.InstanceId),
+				Pending:   strings.HasPrefix(state, "Pending"),
+				Protected: aws.ToBool(inst.ProtectedFromScaleIn),
+			})
 		}
 	}
+	// Warm pool instances are usually only reported by DescribeWarmPool, the group reports the size of the pool
+	if asg.WarmPoolSize != nil {
+		warm = max(warm, int64(*asg.WarmPoolSize))
+	}
+	c.fillLaunchTimes(ctx, asgName, instances)
+	c.rememberInstances(asgName, instances, terminating, warm)
+	c.rememberLimits(asgName, asg.MinSize, asg.MaxSize)
 
 	desiredCapacity := int64(0)
-	if asg.DesiredCapacity != nil && *asg.DesiredCapacity != 0 {
+	if asg.DesiredCapacity != nil {
 		desiredCapacity = int64(*asg.DesiredCapacity)
 	}
 
 	return allocatedCount, desiredCapacity, nil
 }
 
-func (c *AWSClient) UpdateASGCapacity(asgName string, capacity int64) error {
+// fillLaunchTimes sets the launch times of the instances of the ASG. The Auto Scaling API does not report them, so
+// instances not seen before are described through EC2, launchTimeBatchSize per request; a failure is logged and
+// leaves their LaunchTime zero, to be described again in the next cycle.
+func (c *AWSClient) fillLaunchTimes(ctx context.Context, asgName string, instances []core.Instance) {
+	if c.ec2Svc == nil {
+		return
+	}
+	c.mu.Lock()
+	known := c.launched[asgName]
+	c.mu.Unlock()
+
+	var unknown []string
+	for _, instance := range instances {
+		if _, ok := known[instance.ID]; !ok && instance.ID != "" {
+			unknown = append(unknown, instance.ID)
+		}
+	}
+	described, err := c.describeLaunchTimes(ctx, unknown)
+	if err != nil {
+		slog.Warn("Launch times of instances unavailable, their ages count from the first cycle seeing them",
+			"asg", asgName, "error", err)
+	}
+
+	current := make(map[string]time.Time, len(instances))
+	for i, instance := range instances {
+		launched, ok := known[instance.ID]
+		if !ok {
+			launched, ok = described[instance.ID]
+		}
+		if ok {
+			instances[i].LaunchTime = launched
+			current[instance.ID] = launched
+		}
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.launched == nil {
+		c.launched = make(map[string]map[string]time.Time)
+	}
+	c.launched[asgName] = current
+}
+
+// describeLaunchTimes returns the launch time per instance ID through DescribeInstances, following pagination;
+// the times described before a failure are returned with it
+func (c *AWSClient) describeLaunchTimes(ctx context.Context, instanceIDs []string) (map[string]time.Time, error) {
+	launched := make(map[string]time.Time, len(instanceIDs))
+	for batch := range slices.Chunk(instanceIDs, launchTimeBatchSize) {
+		input := &ec2.DescribeInstancesInput{InstanceIds: batch}
+		for {
+			var result *ec2.DescribeInstancesOutput
+			err := c.withRetries(ctx, fmt.Sprintf("describe of %d instances", len(batch)), func(ctx context.Context) (err error) {
+				result, err = c.ec2Svc.DescribeInstances(ctx, input)
+				return err
+			})
+			if err != nil {
+				return launched, fmt.Errorf("failed to describe %d instances: %w", len(batch), err)
+			}
+			for _, reservation := range result.Reservations {
+				for _, instance := range reservation.Instances {
+					if instance.LaunchTime != nil {
+						launched[aws.ToString(instance.InstanceId)] = *instance.LaunchTime
+					}
+				}
+			}
+			if aws.ToString(result.NextToken) == "" {
+				break
+			}
+			input = &ec2.DescribeInstancesInput{InstanceIds: batch, NextToken: result.NextToken}
+		}
+	}
+	return launched, nil
+}
+
+func (c *AWSClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
 	if capacity < minCapacity {
 		return errors.New("cannot set capacity below " + fmt.Sprint(minCapacity))
 	}
+	if capacity > math.MaxInt32 {
+		return fmt.Errorf("cannot set capacity %d for ASG %s: exceeds the maximum of %d supported by the AWS API", capacity, asgName, int64(math.MaxInt32))
+	}
 
 	input := &autoscaling.UpdateAutoScalingGroupInput{
 		AutoScalingGroupName: aws.String(asgName),
-		MinSize:              aws.Int32(int32(capacity)),
-		MaxSize:              aws.Int32(int32(capacity)),
-		DesiredCapacity:      aws.Int32(int32(capacity)),
 	}
+	if c.manageMinMax {
+		input.MinSize = aws.Int32(int32(capacity))
+		input.MaxSize = aws.Int32(int32(capacity))
+	} else {
+		capacity = c.withinLimits(asgName, capacity)
+	}
+	input.DesiredCapacity = aws.Int32(int32(capacity))
 
-	_, err := c.svc.UpdateAutoScalingGroup(context.TODO(), input)
+	svc, err := c.writer()
+	if err != nil {
+		return fmt.Errorf("cannot update ASG %s: %w", asgName, err)
+	}
+	err = c.withRetries(ctx, "update of ASG "+utils.Safe(asgName), func(ctx context.Context) error {
+		_, err := svc.UpdateAutoScalingGroup(ctx, input)
+		return err
+	})
 	if err != nil {
 		return fmt.Errorf("failed to update ASG %s: %w", asgName, err)
 	}
 
 	return nil
 }
+
+// rememberInstances keeps the allocated, terminating and warm pool instances of the last describe for Instances,
+// TerminatingInstances and WarmInstances
+func (c *AWSClient) rememberInstances(asgName string, instances []core.Instance, terminating []string, warm int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.instances == nil {
+		c.instances = make(map[string][]core.Instance)
+		c.terminating = make(map[string][]string)
+		c.warm = make(map[string]int64)
+	}
+	c.instances[asgName] = instances
+	c.terminating[asgName] = terminating
+	c.warm[asgName] = warm
+}
+
+// rememberLimits keeps the MinSize and MaxSize of the last describe for withinLimits
+func (c *AWSClient) rememberLimits(asgName string, minSize, maxSize *int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.limits == nil {
+		c.limits = make(map[string]groupLimits)
+	}
+	c.limits[asgName] = groupLimits{min: minSize, max: maxSize}
+}
+
+// GetASGLimits returns the MinSize and MaxSize of the ASG seen by the last GetCurrentCapacity call; ok is false
+// before the first describe and with manage-min-max, where the limits follow the desired capacity
+func (c *AWSClient) GetASGLimits(asgName string) (int64, int64, bool) {
+	if c.manageMinMax {
+		return 0, 0, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limits, ok := c.limits[asgName]
+	if !ok || limits.min == nil || limits.max == nil {
+		return 0, 0, false
+	}
+	return int64(*limits.min), int64(*limits.max), true
+}
+
+// withinLimits clamps capacity to the MinSize and MaxSize the group reported in the last describe, which AWS
+// rejects a desired capacity outside of; capacity unchanged before the first describe
+func (c *AWSClient) withinLimits(asgName string, capacity int64) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	limits := c.limits[asgName]
+	if limits.max != nil {
+		capacity = min(capacity, int64(*limits.max))
+	}
+	if limits.min != nil {
+		capacity = max(capacity, int64(*limits.min))
+	}
+	return capacity
+}
+
+// Instances returns the allocated instances of the ASG seen by the last GetCurrentCapacity call, with their launch
+// times unless describing them failed
+func (c *AWSClient) Instances(asgName string) []core.Instance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.instances[asgName]
+}
+
+// TerminateInstance terminates an instance of the ASG without decrementing the desired capacity,
+// so that the ASG launches a replacement
+func (c *AWSClient) TerminateInstance(ctx context.Context, asgName, instanceID string) error {
+	input := &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String(instanceID),
+		ShouldDecrementDesiredCapacity: aws.Bool(false),
+	}
+
+	svc, err := c.writer()
+	if err != nil {
+		return fmt.Errorf("cannot terminate instance %s of ASG %s: %w", instanceID, asgName, err)
+	}
+	err = c.withRetries(ctx, "termination of instance "+utils.Safe(instanceID), func(ctx context.Context) error {
+		_, err := svc.TerminateInstanceInAutoScalingGroup(ctx, input)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to terminate instance %s of ASG %s: %w", instanceID, asgName, err)
+	}
+
+	return nil
+}
+
+// SetInstanceProtection sets or clears the scale-in protection of instances of the ASG, so that lowering the
+// desired capacity terminates unprotected instances only
+func (c *AWSClient) SetInstanceProtection(ctx context.Context, asgName string, instanceIDs []string, protected bool) error {
+	svc, err := c.writer()
+	if err != nil {
+		return fmt.Errorf("cannot set scale-in protection of ASG %s: %w", asgName, err)
+	}
+	for batch := range slices.Chunk(instanceIDs, protectionBatchSize) {
+		input := &autoscaling.SetInstanceProtectionInput{
+			AutoScalingGroupName: aws.String(asgName),
+			InstanceIds:          batch,
+			ProtectedFromScaleIn: aws.Bool(protected),
+		}
+		err := c.withRetries(ctx, "scale-in protection of ASG "+utils.Safe(asgName), func(ctx context.Context) error {
+			_, err := svc.SetInstanceProtection(ctx, input)
+			return err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to set scale-in protection of instances %v of ASG %s: %w", batch, asgName, err)
+		}
+	}
+	return nil
+}
+
+// WarmInstances returns the instances in the warm pool of the ASG seen by the last GetCurrentCapacity call,
+// excluding warm instances being terminated
+func (c *AWSClient) WarmInstances(asgName string) int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.warm[asgName]
+}
+
+// TerminatingInstances returns the instances of the ASG in Terminating:Wait seen by the last GetCurrentCapacity
+// call, held by a terminating lifecycle hook
+func (c *AWSClient) TerminatingInstances(asgName string) []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.terminating[asgName]
+}
+
+// CompleteLifecycleAction completes the lifecycle hook of an instance with CONTINUE, so that it terminates
+func (c *AWSClient) CompleteLifecycleAction(ctx context.Context, asgName, hookName, instanceID string) error {
+	input := &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String(asgName),
+		LifecycleHookName:     aws.String(hookName),
+		InstanceId:            aws.String(instanceID),
+		LifecycleActionResult: aws.String("CONTINUE"),
+	}
+
+	svc, err := c.writer()
+	if err != nil {
+		return fmt.Errorf("cannot complete lifecycle hook %s of instance %s: %w", hookName, instanceID, err)
+	}
+	err = c.withRetries(ctx, "lifecycle action of instance "+utils.Safe(instanceID), func(ctx context.Context) error {
+		_, err := svc.CompleteLifecycleAction(ctx, input)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to complete lifecycle hook %s of instance %s of ASG %s: %w", hookName, instanceID, asgName, err)
+	}
+	return nil
+}
+
+// ScalingActivityInProgress returns the latest scaling activity of the ASG that is not finished, e.g. launching the
+// instances of the last capacity change. Terminations are left out: they do not conflict with a capacity change and
+// may wait on a lifecycle hook for long.
+func (c *AWSClient) ScalingActivityInProgress(ctx context.Context, asgName string) (string, bool, error) {
+	input := &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String(asgName),
+		MaxRecords:           aws.Int32(activityRecords),
+	}
+	var result *autoscaling.DescribeScalingActivitiesOutput
+	err := c.withRetries(ctx, "scaling activities of ASG "+utils.Safe(asgName), func(ctx context.Context) error {
+		var err error
+		result, err = c.svc.DescribeScalingActivities(ctx, input)
+		return err
+	})
+	if err != nil {
+		return "", false, fmt.Errorf("failed to describe scaling activities of ASG %s: %w", asgName, err)
+	}
+
+	for _, activity := range result.Activities {
+		switch activity.StatusCode {
+		case types.ScalingActivityStatusCodeSuccessful, types.ScalingActivityStatusCodeFailed, types.ScalingActivityStatusCodeCancelled:
+			continue
+		}
+		description := aws.ToString(activity.Description)
+		if strings.HasPrefix(description, "Terminating") {
+			continue
+		}
+		return fmt.Sprintf("%s (%s, %d%%)", description, activity.StatusCode, aws.ToInt32(activity.Progress)), true, nil
+	}
+	return "", false, nil
+}
+
+// RecordScaleReason tags the ASG with the reason and time of a scale-up when tag-scaling-events is enabled; the
+// tags are propagated to the instances launched afterwards
+func (c *AWSClient) RecordScaleReason(ctx context.Context, asgName, reason string, at time.Time) error {
+	if !c.tagScaling {
+		return nil
+	}
+	tag := func(key, value string) types.Tag {
+		return types.Tag{
+			Key:               aws.String(key),
+			Value:             aws.String(value),
+			PropagateAtLaunch: aws.Bool(true),
+			ResourceId:        aws.String(asgName),
+			ResourceType:      aws.String("auto-scaling-group"),
+		}
+	}
+	input := &autoscaling.CreateOrUpdateTagsInput{
+		Tags: []types.Tag{
+			tag(scaleReasonTag, reason),
+			tag(scaleTimeTag, at.UTC().Format(time.RFC3339)),
+		},
+	}
+
+	svc, err := c.writer()
+	if err != nil {
+		return fmt.Errorf("cannot tag ASG %s: %w", asgName, err)
+	}
+	err = c.withRetries(ctx, "tagging of ASG "+utils.Safe(asgName), func(ctx context.Context) error {
+		_, err := svc.CreateOrUpdateTags(ctx, input)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to tag ASG %s: %w", asgName, err)
+	}
+	return nil
+}
@@ -2,13 +2,32 @@ package aws
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
 	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/aws-sdk-go-v2/service/ec2"
+	ec2types "github.com/aws/aws-sdk-go-v2/service/ec2/types"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	ststypes "github.com/aws/aws-sdk-go-v2/service/sts/types"
+	"github.com/aws/smithy-go"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
 
+	"github.com/shuliakovsky/gitlab-autoscaler/config"
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/gitlab"
 	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/providers/aws"
 )
 
@@ -20,8 +39,7 @@ import (
 func TestGetCurrentCapacity(t *testing.T) {
 	mockSvc := &mocks.MockAutoscalingAPI{}
 
-	mockSvc.On("DescribeAutoScalingGroups",
-		context.TODO(),
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
 		&autoscaling.DescribeAutoScalingGroupsInput{
 			AutoScalingGroupNames: []string{"test-asg"},
 		},
@@ -43,7 +61,7 @@ func TestGetCurrentCapacity(t *testing.T) {
 		svc: mockSvc,
 	}
 
-	allocated, desired, err := client.GetCurrentCapacity("test-asg")
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
 
 	assert.NoError(t, err)
 	assert.Equal(t, int64(2), allocated)
@@ -52,17 +70,164 @@ func TestGetCurrentCapacity(t *testing.T) {
 	mockSvc.AssertExpectations(t)
 }
 
+// TestGetCurrentCapacity_ScaledToZero verifies a desired capacity of 0 is reported as is while an instance terminates
+// Expected behavior:
+//   - Returns allocatedCount = 0, the Terminating instance is not allocated
+//   - Returns desiredCapacity = 0
+func TestGetCurrentCapacity_ScaledToZero(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{"test-asg"},
+		},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: "Terminating"},
+				},
+				DesiredCapacity: aws.Int32(0),
+			},
+		},
+	}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), allocated)
+	assert.Equal(t, int64(0), desired)
+	assert.Empty(t, client.Instances("test-asg"))
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestDescribeAll verifies a batch describe answers the GetCurrentCapacity calls of the cycle
+// Expected behavior:
+//   - One request per page: the second page is fetched with the NextToken of the first
+//   - GetCurrentCapacity of both ASGs makes no further request
+//   - A second GetCurrentCapacity of an ASG describes it on its own, the batch result is read once
+func TestDescribeAll(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	names := []string{"asg-a", "asg-b"}
+
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: names},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{AutoScalingGroupName: aws.String("asg-a"), DesiredCapacity: aws.Int32(1),
+				Instances: []types.Instance{{InstanceId: aws.String("i-1"), LifecycleState: "InService"}}},
+		},
+		NextToken: aws.String("page-2"),
+	}, nil).Once()
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: names, NextToken: aws.String("page-2")},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{AutoScalingGroupName: aws.String("asg-b"), DesiredCapacity: aws.Int32(3)},
+		},
+	}, nil).Once()
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []string{"asg-a"}},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{AutoScalingGroupName: aws.String("asg-a"), DesiredCapacity: aws.Int32(2)},
+		},
+	}, nil).Once()
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	assert.NoError(t, client.DescribeAll(context.Background(), names))
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "asg-a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), allocated)
+	assert.Equal(t, int64(1), desired)
+	_, desired, err = client.GetCurrentCapacity(context.Background(), "asg-b")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), desired)
+
+	_, desired, err = client.GetCurrentCapacity(context.Background(), "asg-a")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestDescribeAll_Batches verifies names are split into requests of describeBatchSize and a failure drops the batch
+// Expected behavior:
+//   - 60 names are described in requests of 50 and 10 names
+//   - When a request fails the error is returned and no group of an earlier DescribeAll is kept
+func TestDescribeAll_Batches(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	names := make([]string, 60)
+	for i := range names {
+		names[i] = fmt.Sprintf("asg-%02d", i)
+	}
+	var sizes []int
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			sizes = append(sizes, len(args.Get(1).(*autoscaling.DescribeAutoScalingGroupsInput).AutoScalingGroupNames))
+		}).
+		Return(&autoscaling.DescribeAutoScalingGroupsOutput{}, nil).Twice()
+
+	client := &AWSClient{
+		svc:       mockSvc,
+		described: map[string]types.AutoScalingGroup{"stale": {}},
+	}
+
+	assert.NoError(t, client.DescribeAll(context.Background(), names))
+	assert.Equal(t, []int{50, 10}, sizes)
+
+	client.described = map[string]types.AutoScalingGroup{"stale": {}}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).
+		Return(nil, errors.New("Throttling")).Once()
+	assert.Error(t, client.DescribeAll(context.Background(), names))
+	_, kept := client.takeDescribed("stale")
+	assert.False(t, kept)
+
+	mockSvc.AssertExpectations(t)
+}
+
 // TestUpdateASGCapacity_Success verifies the UpdateASGCapacity method successfully scales ASG to a valid capacity
 // Expected behavior:
 //   - No error returned when updating to valid capacity (5)
-//   - AWS SDK's UpdateAutoScalingGroup is called with correct parameters:
-//   - MinSize=5, MaxSize=5, DesiredCapacity=5
-//   - AutoScalingGroupName="test-asg"
+//   - AWS SDK's UpdateAutoScalingGroup is called with AutoScalingGroupName="test-asg" and DesiredCapacity=5 only
+//   - MinSize and MaxSize of the group are left alone
 func TestUpdateASGCapacity_Success(t *testing.T) {
 	mockSvc := &mocks.MockAutoscalingAPI{}
 
-	mockSvc.On("UpdateAutoScalingGroup",
-		context.TODO(),
+	mockSvc.On("UpdateAutoScalingGroup", mock.Anything,
+		&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String("test-asg"),
+			DesiredCapacity:      aws.Int32(5),
+		},
+	).Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	err := client.UpdateASGCapacity(context.Background(), "test-asg", 5)
+	assert.NoError(t, err)
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestUpdateASGCapacity_ManageMinMax verifies manage-min-max pins the limits of the group to the desired capacity
+// Expected behavior:
+//   - UpdateAutoScalingGroup is called with MinSize=5, MaxSize=5, DesiredCapacity=5
+func TestUpdateASGCapacity_ManageMinMax(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("UpdateAutoScalingGroup", mock.Anything,
 		&autoscaling.UpdateAutoScalingGroupInput{
 			AutoScalingGroupName: aws.String("test-asg"),
 			MinSize:              aws.Int32(5),
@@ -71,16 +236,104 @@ func TestUpdateASGCapacity_Success(t *testing.T) {
 		},
 	).Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil)
 
+	client := &AWSClient{
+		svc:          mockSvc,
+		manageMinMax: true,
+	}
+
+	err := client.UpdateASGCapacity(context.Background(), "test-asg", 5)
+	assert.NoError(t, err)
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestUpdateASGCapacity_GroupLimits verifies the MinSize and MaxSize configured on the group bound the desired capacity
+// Expected behavior:
+//   - The describe reports MinSize=1 and MaxSize=4
+//   - A capacity of 10 sets DesiredCapacity=4, a capacity of 0 sets DesiredCapacity=1, 3 is passed unchanged
+//   - MinSize and MaxSize are never sent
+func TestUpdateASGCapacity_GroupLimits(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{"test-asg"},
+		},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				DesiredCapacity:      aws.Int32(2),
+				MinSize:              aws.Int32(1),
+				MaxSize:              aws.Int32(4),
+			},
+		},
+	}, nil)
+	for _, desired := range []int32{4, 1, 3} {
+		mockSvc.On("UpdateAutoScalingGroup", mock.Anything,
+			&autoscaling.UpdateAutoScalingGroupInput{
+				AutoScalingGroupName: aws.String("test-asg"),
+				DesiredCapacity:      aws.Int32(desired),
+			},
+		).Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil).Once()
+	}
+
 	client := &AWSClient{
 		svc: mockSvc,
 	}
 
-	err := client.UpdateASGCapacity("test-asg", 5)
+	_, _, err := client.GetCurrentCapacity(context.Background(), "test-asg")
 	assert.NoError(t, err)
 
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 10))
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 0))
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 3))
+
 	mockSvc.AssertExpectations(t)
 }
 
+// TestGetASGLimits verifies the limits of the group are reported from the last describe
+// Expected behavior:
+//   - Unknown before the first describe
+//   - MinSize=1 and MaxSize=4 after it
+//   - Unknown with manage-min-max, where the limits follow the desired capacity
+func TestGetASGLimits(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{"test-asg"},
+		},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				DesiredCapacity:      aws.Int32(2),
+				MinSize:              aws.Int32(1),
+				MaxSize:              aws.Int32(4),
+			},
+		},
+	}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	_, _, ok := client.GetASGLimits("test-asg")
+	assert.False(t, ok)
+
+	_, _, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	minSize, maxSize, ok := client.GetASGLimits("test-asg")
+	assert.True(t, ok)
+	assert.Equal(t, int64(1), minSize)
+	assert.Equal(t, int64(4), maxSize)
+
+	client.manageMinMax = true
+	_, _, ok = client.GetASGLimits("test-asg")
+	assert.False(t, ok)
+}
+
 // TestUpdateASGCapacity_InvalidCapacity verifies error handling when attempting invalid capacity (negative value)
 // Expected behavior:
 //   - Returns an error with message containing "cannot set capacity below 0"
@@ -92,9 +345,678 @@ func TestUpdateASGCapacity_InvalidCapacity(t *testing.T) {
 		svc: mockSvc,
 	}
 
-	err := client.UpdateASGCapacity("test-asg", -1)
+	err := client.UpdateASGCapacity(context.Background(), "test-asg", -1)
 	assert.Error(t, err)
 	assert.Contains(t, err.Error(), "cannot set capacity below 0")
 
 	mockSvc.AssertExpectations(t)
 }
+
+// TestUpdateASGCapacity_Int32Bounds verifies capacities are bounds-checked before the int32 conversion
+// Expected behavior:
+//   - math.MaxInt32 is passed to AWS unchanged
+//   - math.MaxInt32 + 1 returns an error naming the offending value and no AWS API call is made
+func TestUpdateASGCapacity_Int32Bounds(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("UpdateAutoScalingGroup", mock.Anything,
+		&autoscaling.UpdateAutoScalingGroupInput{
+			AutoScalingGroupName: aws.String("test-asg"),
+			DesiredCapacity:      aws.Int32(math.MaxInt32),
+		},
+	).Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil).Once()
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	err := client.UpdateASGCapacity(context.Background(), "test-asg", math.MaxInt32)
+	assert.NoError(t, err)
+
+	err = client.UpdateASGCapacity(context.Background(), "test-asg", math.MaxInt32+1)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "2147483648")
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestInstances verifies the allocated instances of the last describe are reported for stuck-instance detection
+// Expected behavior:
+//   - InService and Pending* instances are reported, Pending* ones marked pending
+//   - Instances protected from scale-in are marked protected
+//   - Instances in other states (Terminating) are left out like in the allocated count
+func TestInstances(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything,
+		&autoscaling.DescribeAutoScalingGroupsInput{
+			AutoScalingGroupNames: []string{"test-asg"},
+		},
+	).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: "InService", ProtectedFromScaleIn: aws.Bool(true)},
+					{InstanceId: aws.String("i-2"), LifecycleState: "Pending:Wait"},
+					{InstanceId: aws.String("i-3"), LifecycleState: "Terminating"},
+				},
+				DesiredCapacity: aws.Int32(2),
+			},
+		},
+	}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	_, _, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+
+	assert.NoError(t, err)
+	assert.Equal(t, []core.Instance{{ID: "i-1", Protected: true}, {ID: "i-2", Pending: true}}, client.Instances("test-asg"))
+	assert.Empty(t, client.Instances("other-asg"))
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestTerminateInstance verifies a stuck instance is terminated so that the ASG replaces it
+// Expected behavior:
+//   - TerminateInstanceInAutoScalingGroup is called with ShouldDecrementDesiredCapacity=false
+func TestTerminateInstance(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+
+	mockSvc.On("TerminateInstanceInAutoScalingGroup", mock.Anything,
+		&autoscaling.TerminateInstanceInAutoScalingGroupInput{
+			InstanceId:                     aws.String("i-2"),
+			ShouldDecrementDesiredCapacity: aws.Bool(false),
+		},
+	).Return(&autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	assert.NoError(t, client.TerminateInstance(context.Background(), "test-asg", "i-2"))
+
+	mockSvc.AssertExpectations(t)
+}
+
+// TestSetInstanceProtection verifies scale-in protection is set in batches the API accepts
+// Expected behavior:
+//   - 60 instances are protected in a request of 50 and one of 10, both for the ASG
+//   - A failing batch returns an error naming the ASG
+func TestSetInstanceProtection(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	var batches [][]string
+	mockSvc.On("SetInstanceProtection", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			input := args.Get(1).(*autoscaling.SetInstanceProtectionInput)
+			assert.Equal(t, "test-asg", aws.ToString(input.AutoScalingGroupName))
+			assert.True(t, aws.ToBool(input.ProtectedFromScaleIn))
+			batches = append(batches, input.InstanceIds)
+		}).
+		Return(&autoscaling.SetInstanceProtectionOutput{}, nil).Twice()
+	mockSvc.On("SetInstanceProtection", mock.Anything, mock.Anything).
+		Return(nil, &smithy.GenericAPIError{Code: "ValidationError"}).Once()
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	instanceIDs := make([]string, 60)
+	for i := range instanceIDs {
+		instanceIDs[i] = fmt.Sprintf("i-%d", i)
+	}
+	assert.NoError(t, client.SetInstanceProtection(context.Background(), "test-asg", instanceIDs, true))
+	assert.Len(t, batches, 2)
+	assert.Equal(t, instanceIDs[:50], batches[0])
+	assert.Equal(t, instanceIDs[50:], batches[1])
+
+	err := client.SetInstanceProtection(context.Background(), "test-asg", []string{"i-1"}, false)
+	assert.ErrorContains(t, err, "failed to set scale-in protection of instances [i-1] of ASG test-asg")
+	mockSvc.AssertExpectations(t)
+}
+
+// TestGetCurrentCapacity_Unhealthy verifies instances failing health checks are not counted as allocated by default
+// Expected behavior:
+//   - Of 4 InService instances, i-2 and i-4 are Unhealthy: 2 allocated, and only i-1 and i-3 reported as instances
+//   - With count-unhealthy-as-allocated all 4 are allocated
+func TestGetCurrentCapacity_Unhealthy(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService, HealthStatus: aws.String("Healthy")},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStateInService, HealthStatus: aws.String("Unhealthy")},
+					{InstanceId: aws.String("i-3"), LifecycleState: types.LifecycleStateInService, HealthStatus: aws.String("Healthy")},
+					{InstanceId: aws.String("i-4"), LifecycleState: types.LifecycleStateInService, HealthStatus: aws.String("Unhealthy")},
+				},
+				DesiredCapacity: aws.Int32(4),
+			},
+		},
+	}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(4), desired)
+	assert.Equal(t, []core.Instance{{ID: "i-1"}, {ID: "i-3"}}, client.Instances("test-asg"))
+
+	client.countUnhealthy = true
+	allocated, _, err = client.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(4), allocated)
+}
+
+// TestGetCurrentCapacity_LaunchTimes verifies the launch times of instances are described through EC2
+// Expected behavior:
+//   - Instances get the launch time EC2 reports, described once per instance across cycles
+//   - A failed describe leaves the launch time zero and is retried in the next cycle
+func TestGetCurrentCapacity_LaunchTimes(t *testing.T) {
+	launched := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStatePending},
+				},
+				DesiredCapacity: aws.Int32(2),
+			},
+		},
+	}, nil)
+	mockEC2 := &mocks.MockEC2API{}
+	mockEC2.On("DescribeInstances", mock.Anything, &ec2.DescribeInstancesInput{InstanceIds: []string{"i-1", "i-2"}}).
+		Return(nil, &smithy.GenericAPIError{Code: "UnauthorizedOperation"}).Once()
+	mockEC2.On("DescribeInstances", mock.Anything, &ec2.DescribeInstancesInput{InstanceIds: []string{"i-1", "i-2"}}).
+		Return(&ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{
+			{Instances: []ec2types.Instance{{InstanceId: aws.String("i-1"), LaunchTime: aws.Time(launched)}}},
+			{Instances: []ec2types.Instance{{InstanceId: aws.String("i-2"), LaunchTime: aws.Time(launched.Add(time.Hour))}}},
+		}}, nil).Once()
+
+	client := &AWSClient{svc: mockSvc, ec2Svc: mockEC2}
+
+	_, _, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	require.NoError(t, err)
+	assert.Equal(t, []core.Instance{{ID: "i-1"}, {ID: "i-2", Pending: true}}, client.Instances("test-asg"))
+
+	for range 2 {
+		_, _, err = client.GetCurrentCapacity(context.Background(), "test-asg")
+		require.NoError(t, err)
+		assert.Equal(t, []core.Instance{
+			{ID: "i-1", LaunchTime: launched},
+			{ID: "i-2", Pending: true, LaunchTime: launched.Add(time.Hour)},
+		}, client.Instances("test-asg"))
+	}
+	mockEC2.AssertExpectations(t)
+}
+
+// TestMaxInstanceAge_FirstCycle verifies max-instance-age counts from the launch time EC2 reports, so an old
+// instance is recycled in the first cycle of a freshly started autoscaler
+// Expected behavior:
+//   - i-1, launched 14 days ago, is terminated without lowering the desired capacity
+//   - i-2, launched an hour ago, is kept
+func TestMaxInstanceAge_FirstCycle(t *testing.T) {
+	now := time.Now()
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStateInService},
+				},
+				DesiredCapacity: aws.Int32(2),
+			},
+		},
+	}, nil)
+	mockSvc.On("TerminateInstanceInAutoScalingGroup", mock.Anything, &autoscaling.TerminateInstanceInAutoScalingGroupInput{
+		InstanceId:                     aws.String("i-1"),
+		ShouldDecrementDesiredCapacity: aws.Bool(false),
+	}).Return(&autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil).Once()
+	mockSvc.On("DescribeScalingActivities", mock.Anything, mock.Anything).Return(&autoscaling.DescribeScalingActivitiesOutput{}, nil)
+	mockEC2 := &mocks.MockEC2API{}
+	mockEC2.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{
+		{Instances: []ec2types.Instance{
+			{InstanceId: aws.String("i-1"), LaunchTime: aws.Time(now.Add(-14 * 24 * time.Hour))},
+			{InstanceId: aws.String("i-2"), LaunchTime: aws.Time(now.Add(-time.Hour))},
+		}},
+	}}, nil)
+
+	client := &AWSClient{svc: mockSvc, ec2Svc: mockEC2}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MinAsgCapacity: 2, MaxAsgCapacity: 5, MaxInstanceAge: 168 * time.Hour}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := core.NewOrchestrator(map[string]core.Provider{"aws": client}, map[string]string{"test-asg": "aws"}, nil, nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, "i-1", snapshot.ASGs[0].RecycledInstance)
+	mockSvc.AssertExpectations(t)
+}
+
+// TestPendingTimeout_FirstCycle verifies pending-timeout counts from the launch time EC2 reports, so an instance
+// stuck in Pending since before a restart is reported stuck in the first cycle
+// Expected behavior:
+//   - i-2, pending since its launch 20 minutes ago, is stuck and left out of the allocated instances
+//   - i-3, pending since its launch a minute ago, is allocated
+func TestPendingTimeout_FirstCycle(t *testing.T) {
+	now := time.Now()
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStatePending},
+					{InstanceId: aws.String("i-3"), LifecycleState: types.LifecycleStatePending},
+				},
+				DesiredCapacity: aws.Int32(3),
+			},
+		},
+	}, nil)
+	mockEC2 := &mocks.MockEC2API{}
+	mockEC2.On("DescribeInstances", mock.Anything, mock.Anything).Return(&ec2.DescribeInstancesOutput{Reservations: []ec2types.Reservation{
+		{Instances: []ec2types.Instance{
+			{InstanceId: aws.String("i-1"), LaunchTime: aws.Time(now.Add(-time.Hour))},
+			{InstanceId: aws.String("i-2"), LaunchTime: aws.Time(now.Add(-20 * time.Minute))},
+			{InstanceId: aws.String("i-3"), LaunchTime: aws.Time(now.Add(-time.Minute))},
+		}},
+	}}, nil)
+
+	client := &AWSClient{svc: mockSvc, ec2Svc: mockEC2}
+	asg := config.Asg{Name: "test-asg", Tags: []string{"amd64"}, MinAsgCapacity: 3, MaxAsgCapacity: 5}
+	cfg := config.Config{
+		Autoscaler: config.AutoscalerConfig{CheckInterval: 10},
+		Providers:  map[string]config.ProviderConfig{"aws": {AsgNames: []config.Asg{asg}}},
+	}
+	orchestrator := core.NewOrchestrator(map[string]core.Provider{"aws": client}, map[string]string{"test-asg": "aws"}, nil, nil)
+
+	orchestrator.ScaleASGs(context.Background(), cfg, gitlab.ClusterState{
+		PendingJobsWithTags: map[string]int{},
+		RunningJobsWithTags: map[string]int{},
+	})
+
+	snapshot, _ := orchestrator.Snapshot()
+	assert.Equal(t, int64(1), snapshot.ASGs[0].StuckInstances)
+	assert.Equal(t, int64(2), snapshot.ASGs[0].Allocated)
+}
+
+// TestWarmInstances verifies warm pool instances are neither allocated nor lost
+// Expected behavior:
+//   - Warmed:Running and Warmed:Stopped instances are not counted as allocated
+//   - The warm pool size reported by the group wins over the warm instances listed with it
+//   - A group without a warm pool reports none
+func TestWarmInstances(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{"warm-asg"},
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("warm-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStateWarmedRunning},
+					{InstanceId: aws.String("i-3"), LifecycleState: types.LifecycleStateWarmedStopped},
+				},
+				DesiredCapacity: aws.Int32(1),
+				WarmPoolSize:    aws.Int32(3),
+			},
+		},
+	}, nil)
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, &autoscaling.DescribeAutoScalingGroupsInput{
+		AutoScalingGroupNames: []string{"cold-asg"},
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{AutoScalingGroupName: aws.String("cold-asg"), DesiredCapacity: aws.Int32(0)},
+		},
+	}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	allocated, _, err := client.GetCurrentCapacity(context.Background(), "warm-asg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), allocated)
+	assert.Equal(t, int64(3), client.WarmInstances("warm-asg"))
+
+	_, _, err = client.GetCurrentCapacity(context.Background(), "cold-asg")
+	assert.NoError(t, err)
+	assert.Zero(t, client.WarmInstances("cold-asg"))
+}
+
+// TestScalingActivityInProgress verifies the latest unfinished activity is reported, terminations left out
+// Expected behavior:
+//   - Finished activities and a termination waiting on a lifecycle hook are skipped
+//   - The launch in PreInService is reported with its status and progress
+//   - Without unfinished activities nothing is in progress
+func TestScalingActivityInProgress(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeScalingActivities", mock.Anything, &autoscaling.DescribeScalingActivitiesInput{
+		AutoScalingGroupName: aws.String("test-asg"),
+		MaxRecords:           aws.Int32(activityRecords),
+	}).Return(&autoscaling.DescribeScalingActivitiesOutput{
+		Activities: []types.Activity{
+			{Description: aws.String("Terminating EC2 instance: i-3"), StatusCode: types.ScalingActivityStatusCodeMidLifecycleAction},
+			{Description: aws.String("Launching a new EC2 instance: i-2"), StatusCode: types.ScalingActivityStatusCodeSuccessful},
+			{Description: aws.String("Launching a new EC2 instance: i-1"), StatusCode: types.ScalingActivityStatusCodePreInService, Progress: aws.Int32(30)},
+		},
+	}, nil).Once()
+	mockSvc.On("DescribeScalingActivities", mock.Anything, mock.Anything).Return(&autoscaling.DescribeScalingActivitiesOutput{
+		Activities: []types.Activity{
+			{Description: aws.String("Launching a new EC2 instance: i-1"), StatusCode: types.ScalingActivityStatusCodeSuccessful},
+		},
+	}, nil).Once()
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	activity, ok, err := client.ScalingActivityInProgress(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "Launching a new EC2 instance: i-1 (PreInService, 30%)", activity)
+
+	_, ok, err = client.ScalingActivityInProgress(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	mockSvc.AssertExpectations(t)
+}
+
+// TestLifecycleHooks verifies instances held by a terminating lifecycle hook are reported and their hook completed
+// Expected behavior:
+//   - The instance in Terminating:Wait is reported by TerminatingInstances, not as allocated
+//   - CompleteLifecycleAction is called with the hook name and CONTINUE
+func TestLifecycleHooks(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("test-asg"),
+				Instances: []types.Instance{
+					{InstanceId: aws.String("i-1"), LifecycleState: types.LifecycleStateInService},
+					{InstanceId: aws.String("i-2"), LifecycleState: types.LifecycleStateTerminatingWait},
+				},
+				DesiredCapacity: aws.Int32(1),
+			},
+		},
+	}, nil)
+	mockSvc.On("CompleteLifecycleAction", mock.Anything, &autoscaling.CompleteLifecycleActionInput{
+		AutoScalingGroupName:  aws.String("test-asg"),
+		LifecycleHookName:     aws.String("drain-runner"),
+		InstanceId:            aws.String("i-2"),
+		LifecycleActionResult: aws.String("CONTINUE"),
+	}).Return(&autoscaling.CompleteLifecycleActionOutput{}, nil)
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	allocated, _, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), allocated)
+	assert.Equal(t, []string{"i-2"}, client.TerminatingInstances("test-asg"))
+	assert.NoError(t, client.CompleteLifecycleAction(context.Background(), "test-asg", "drain-runner", "i-2"))
+	mockSvc.AssertExpectations(t)
+}
+
+// TestRoleRouting verifies describes use the read service while capacity changes use the write service
+// Expected behavior:
+//   - DescribeAutoScalingGroups is only called on the read mock
+//   - UpdateAutoScalingGroup and TerminateInstanceInAutoScalingGroup are only called on the write mock
+//   - No write error is reported
+func TestRoleRouting(t *testing.T) {
+	readSvc := &mocks.MockAutoscalingAPI{}
+	writeSvc := &mocks.MockAutoscalingAPI{}
+
+	readSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).
+		Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+			AutoScalingGroups: []types.AutoScalingGroup{{DesiredCapacity: aws.Int32(2)}},
+		}, nil)
+	writeSvc.On("UpdateAutoScalingGroup", mock.Anything, mock.Anything).
+		Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil)
+	writeSvc.On("TerminateInstanceInAutoScalingGroup", mock.Anything, mock.Anything).
+		Return(&autoscaling.TerminateInstanceInAutoScalingGroupOutput{}, nil)
+
+	client := &AWSClient{
+		svc:         readSvc,
+		newWriteSvc: func() (AutoscalingAPI, error) { return writeSvc, nil },
+	}
+
+	_, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 3))
+	assert.NoError(t, client.TerminateInstance(context.Background(), "test-asg", "i-1"))
+	assert.NoError(t, client.WriteError())
+
+	readSvc.AssertExpectations(t)
+	readSvc.AssertNotCalled(t, "UpdateAutoScalingGroup", mock.Anything, mock.Anything)
+	readSvc.AssertNotCalled(t, "TerminateInstanceInAutoScalingGroup", mock.Anything, mock.Anything)
+	writeSvc.AssertExpectations(t)
+	writeSvc.AssertNotCalled(t, "DescribeAutoScalingGroups", mock.Anything, mock.Anything)
+}
+
+// TestRoleRouting_WriteDegraded verifies an unavailable write role leaves describes working
+// Expected behavior:
+//   - Describes succeed through the read service
+//   - Updates and terminations fail with the credential error, which WriteError reports
+//   - The write role is assumed again on the next update, which then succeeds and clears WriteError
+func TestRoleRouting_WriteDegraded(t *testing.T) {
+	readSvc := &mocks.MockAutoscalingAPI{}
+	writeSvc := &mocks.MockAutoscalingAPI{}
+	credentialsErr := errors.New("AccessDenied: not authorized to perform sts:AssumeRole")
+
+	readSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).
+		Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+			AutoScalingGroups: []types.AutoScalingGroup{{DesiredCapacity: aws.Int32(2)}},
+		}, nil)
+	writeSvc.On("UpdateAutoScalingGroup", mock.Anything, mock.Anything).
+		Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil)
+
+	attempts := 0
+	client := &AWSClient{
+		svc: readSvc,
+		newWriteSvc: func() (AutoscalingAPI, error) {
+			attempts++
+			if attempts <= 2 {
+				return nil, credentialsErr
+			}
+			return writeSvc, nil
+		},
+	}
+
+	_, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+
+	assert.ErrorIs(t, client.UpdateASGCapacity(context.Background(), "test-asg", 3), credentialsErr)
+	assert.ErrorIs(t, client.TerminateInstance(context.Background(), "test-asg", "i-1"), credentialsErr)
+	assert.ErrorIs(t, client.WriteError(), credentialsErr)
+
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 3))
+	assert.NoError(t, client.WriteError())
+
+	readSvc.AssertNotCalled(t, "UpdateAutoScalingGroup", mock.Anything, mock.Anything)
+	writeSvc.AssertExpectations(t)
+}
+
+// fakeSTS answers AssumeRole with the configured error or temporary credentials, recording the requests
+type fakeSTS struct {
+	inputs []*sts.AssumeRoleInput
+	err    error
+}
+
+func (f *fakeSTS) AssumeRole(_ context.Context, input *sts.AssumeRoleInput, _ ...func(*sts.Options)) (*sts.AssumeRoleOutput, error) {
+	f.inputs = append(f.inputs, input)
+	if f.err != nil {
+		return nil, f.err
+	}
+	return &sts.AssumeRoleOutput{Credentials: &ststypes.Credentials{
+		AccessKeyId:     aws.String("AKID"),
+		SecretAccessKey: aws.String("SECRET"),
+		SessionToken:    aws.String("TOKEN"),
+		Expiration:      aws.Time(time.Now().Add(time.Hour)),
+	}}, nil
+}
+
+// TestRolesCredentials verifies roles are assumed with the external ID and session name, and credentials cached
+// Expected behavior:
+//   - AssumeRole is called once for two retrievals, with the role ARN, external ID and session name
+//   - Without a session name "gitlab-autoscaler" is used and no external ID is sent
+//   - A role that cannot be assumed fails verifyRole with the role ARN in the error
+func TestRolesCredentials(t *testing.T) {
+	const roleARN = "arn:aws:iam::222222222222:role/autoscaler"
+	client := &fakeSTS{}
+	credentials := Roles{ExternalID: "external", SessionName: "runners"}.credentials(client, roleARN)
+	assert.NoError(t, verifyRole(credentials, roleARN))
+	assert.NoError(t, verifyRole(credentials, roleARN))
+	assert.Len(t, client.inputs, 1)
+	assert.Equal(t, roleARN, aws.ToString(client.inputs[0].RoleArn))
+	assert.Equal(t, "external", aws.ToString(client.inputs[0].ExternalId))
+	assert.Equal(t, "runners", aws.ToString(client.inputs[0].RoleSessionName))
+
+	client = &fakeSTS{}
+	assert.NoError(t, verifyRole(Roles{}.credentials(client, roleARN), roleARN))
+	assert.Nil(t, client.inputs[0].ExternalId)
+	assert.Equal(t, defaultSessionName, aws.ToString(client.inputs[0].RoleSessionName))
+
+	denied := errors.New("AccessDenied: not authorized to perform sts:AssumeRole")
+	err := verifyRole(Roles{}.credentials(&fakeSTS{err: denied}, roleARN), roleARN)
+	assert.ErrorIs(t, err, denied)
+	assert.ErrorContains(t, err, "failed to assume role "+roleARN)
+}
+
+// TestNewAWSClient_ProfileAndEndpoint verifies a named profile and a custom endpoint, e.g. LocalStack
+// Expected behavior:
+//   - The credentials of the profile sign the requests
+//   - DescribeAutoScalingGroups reaches the endpoint-url instead of the endpoint of the region
+func TestNewAWSClient_ProfileAndEndpoint(t *testing.T) {
+	dir := t.TempDir()
+	configFile := filepath.Join(dir, "config")
+	credentialsFile := filepath.Join(dir, "credentials")
+	require.NoError(t, os.WriteFile(configFile, []byte("[profile localstack]\nregion = eu-west-1\n"), 0o600))
+	require.NoError(t, os.WriteFile(credentialsFile,
+		[]byte("[localstack]\naws_access_key_id = LOCALSTACKKEY\naws_secret_access_key = secret\n"), 0o600))
+	t.Setenv("AWS_CONFIG_FILE", configFile)
+	t.Setenv("AWS_SHARED_CREDENTIALS_FILE", credentialsFile)
+
+	var authorization string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		authorization = r.Header.Get("Authorization")
+		w.Header().Set("Content-Type", "text/xml")
+		_, _ = io.WriteString(w, `<DescribeAutoScalingGroupsResponse xmlns="http://autoscaling.amazonaws.com/doc/2011-01-01/">
+  <DescribeAutoScalingGroupsResult>
+    <AutoScalingGroups>
+      <member><AutoScalingGroupName>test-asg</AutoScalingGroupName><DesiredCapacity>2</DesiredCapacity></member>
+    </AutoScalingGroups>
+  </DescribeAutoScalingGroupsResult>
+</DescribeAutoScalingGroupsResponse>`)
+	}))
+	defer server.Close()
+
+	client, err := NewAWSClient("eu-west-1", Roles{}, Options{Profile: "localstack", EndpointURL: server.URL})
+	require.NoError(t, err)
+
+	_, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+	assert.Contains(t, authorization, "Credential=LOCALSTACKKEY/")
+}
+
+// TestRecordScaleReason verifies scale-up reasons are tagged on the ASG only with tag-scaling-events
+// Expected behavior:
+//   - Disabled, no tags are written
+//   - Enabled, the reason and the UTC time are tagged on the ASG and propagated at launch
+func TestRecordScaleReason(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+	at := time.Date(2024, 3, 1, 12, 30, 0, 0, time.FixedZone("CET", 3600))
+
+	assert.NoError(t, client.RecordScaleReason(context.Background(), "test-asg", "pending-jobs=7", at))
+	mockSvc.AssertNotCalled(t, "CreateOrUpdateTags", mock.Anything, mock.Anything)
+
+	tag := func(key, value string) types.Tag {
+		return types.Tag{
+			Key:               aws.String(key),
+			Value:             aws.String(value),
+			PropagateAtLaunch: aws.Bool(true),
+			ResourceId:        aws.String("test-asg"),
+			ResourceType:      aws.String("auto-scaling-group"),
+		}
+	}
+	mockSvc.On("CreateOrUpdateTags", mock.Anything, &autoscaling.CreateOrUpdateTagsInput{
+		Tags: []types.Tag{
+			tag("gitlab-autoscaler:last-scale-reason", "pending-jobs=7"),
+			tag("gitlab-autoscaler:last-scale-time", "2024-03-01T11:30:00Z"),
+		},
+	}).Return(&autoscaling.CreateOrUpdateTagsOutput{}, nil).Once()
+
+	client.tagScaling = true
+	assert.NoError(t, client.RecordScaleReason(context.Background(), "test-asg", "pending-jobs=7", at))
+	mockSvc.AssertExpectations(t)
+}
+
+// TestDiscoverASGs verifies groups are discovered by a tag filter across pages
+// Expected behavior:
+//   - With a tag value, the groups are filtered on tag:<key> with that value
+//   - The groups of every page are returned with their tags
+func TestDiscoverASGs(t *testing.T) {
+	filter := []types.Filter{{Name: aws.String("tag:gitlab-autoscaler:enabled"), Values: []string{"true"}}}
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters: filter,
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{
+				AutoScalingGroupName: aws.String("team-a-runners"),
+				Tags: []types.TagDescription{
+					{Key: aws.String("gitlab-autoscaler:enabled"), Value: aws.String("true")},
+					{Key: aws.String("gitlab-autoscaler:tags"), Value: aws.String("amd64,team-a")},
+				},
+			},
+		},
+		NextToken: aws.String("page-2"),
+	}, nil).Once()
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, &autoscaling.DescribeAutoScalingGroupsInput{
+		Filters:   filter,
+		NextToken: aws.String("page-2"),
+	}).Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+		AutoScalingGroups: []types.AutoScalingGroup{
+			{AutoScalingGroupName: aws.String("team-b-runners")},
+		},
+	}, nil).Once()
+
+	client := &AWSClient{
+		svc: mockSvc,
+	}
+
+	groups, err := client.DiscoverASGs(context.Background(), "gitlab-autoscaler:enabled", "true")
+	assert.NoError(t, err)
+	assert.Equal(t, []DiscoveredASG{
+		{Name: "team-a-runners", Tags: map[string]string{"gitlab-autoscaler:enabled": "true", "gitlab-autoscaler:tags": "amd64,team-a"}},
+		{Name: "team-b-runners", Tags: map[string]string{}},
+	}, groups)
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,74 @@
+package aws
+
+import (
+	"context"
+	"log/slog"
+	"math/rand/v2"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+const (
+	retryBaseDelay = 200 * time.Millisecond // Delay before the first retry, doubled on every further one
+	retryMaxDelay  = 5 * time.Second        // Cap of the delay between two attempts
+)
+
+// retryables classifies errors like the SDK retryer: throttling, transient service and connection errors are
+// retried, anything else such as ValidationError or AccessDenied fails on the first attempt
+var retryables = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+// retryable tells whether a failed call may succeed when repeated
+func retryable(err error) bool {
+	return retryables.IsErrorRetryable(err) == aws.TrueTernary
+}
+
+// retryDelay is the jittered exponential backoff before the retry following attempt (0 for the first)
+func retryDelay(attempt int) time.Duration {
+	delay := min(retryBaseDelay<<min(attempt, 16), retryMaxDelay)
+	return delay/2 + rand.N(delay/2+1)
+}
+
+// withRetries calls call until it succeeds, fails with an error that is not retryable, or has been retried
+// maxRetries times; the error of the last attempt is returned. Every attempt is bounded by requestTimeout and
+// no retry is made once ctx is done.
+func (c *AWSClient) withRetries(ctx context.Context, operation string, call func(ctx context.Context) error) error {
+	for attempt := 0; ; attempt++ {
+		err := c.attempt(ctx, call)
+		if err == nil || attempt >= c.maxRetries || ctx.Err() != nil || !retryable(err) {
+			return err
+		}
+		delay := retryDelay(attempt)
+		slog.Warn("Retrying", "provider", "aws", "operation", operation, "retry_in", delay.Round(time.Millisecond),
+			"attempt", attempt+2, "attempts", c.maxRetries+1, "error", err)
+		if err := c.pause(ctx, delay); err != nil {
+			return err
+		}
+	}
+}
+
+// attempt makes a single call, bounded by requestTimeout when set
+func (c *AWSClient) attempt(ctx context.Context, call func(ctx context.Context) error) error {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+	return call(ctx)
+}
+
+// pause waits for the delay before a retry; it returns early with the error of ctx once ctx is done
+func (c *AWSClient) pause(ctx context.Context, delay time.Duration) error {
+	if c.sleep != nil {
+		c.sleep(delay)
+		return ctx.Err()
+	}
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
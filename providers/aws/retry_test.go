@@ -0,0 +1,151 @@
+package aws
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling"
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+	"github.com/aws/smithy-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/providers/aws"
+)
+
+// TestWithRetries_Throttled verifies throttled calls are retried with growing delays until they succeed
+// Expected behavior:
+//   - DescribeAutoScalingGroups fails twice with Throttling and RequestLimitExceeded, then succeeds
+//   - GetCurrentCapacity returns the capacity of the third attempt without an error
+//   - Two delays are slept, the second not shorter than half of the doubled base delay
+func TestWithRetries_Throttled(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	input := &autoscaling.DescribeAutoScalingGroupsInput{AutoScalingGroupNames: []string{"test-asg"}}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, input).
+		Return(nil, &smithy.GenericAPIError{Code: "Throttling", Message: "Rate exceeded"}).Once()
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, input).
+		Return(nil, &smithy.GenericAPIError{Code: "RequestLimitExceeded", Message: "Request limit exceeded"}).Once()
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, input).
+		Return(&autoscaling.DescribeAutoScalingGroupsOutput{
+			AutoScalingGroups: []types.AutoScalingGroup{
+				{AutoScalingGroupName: aws.String("test-asg"), DesiredCapacity: aws.Int32(2)},
+			},
+		}, nil).Once()
+
+	var delays []time.Duration
+	client := &AWSClient{
+		svc:        mockSvc,
+		maxRetries: 3,
+		sleep:      func(d time.Duration) { delays = append(delays, d) },
+	}
+
+	_, desired, err := client.GetCurrentCapacity(context.Background(), "test-asg")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+	assert.Len(t, delays, 2)
+	assert.GreaterOrEqual(t, delays[1], retryBaseDelay)
+	mockSvc.AssertNumberOfCalls(t, "DescribeAutoScalingGroups", 3)
+}
+
+// TestWithRetries_FailFast verifies errors that cannot succeed on a retry are returned on the first attempt
+// Expected behavior:
+//   - UpdateAutoScalingGroup failing with ValidationError or AccessDenied is called once
+//   - The error is returned without sleeping
+func TestWithRetries_FailFast(t *testing.T) {
+	for _, code := range []string{"ValidationError", "AccessDenied"} {
+		mockSvc := &mocks.MockAutoscalingAPI{}
+		mockSvc.On("UpdateAutoScalingGroup", mock.Anything, mock.Anything).
+			Return(nil, &smithy.GenericAPIError{Code: code})
+
+		client := &AWSClient{
+			svc:        mockSvc,
+			maxRetries: 3,
+			sleep:      func(time.Duration) { t.Errorf("%s: slept before a retry", code) },
+		}
+
+		err := client.UpdateASGCapacity(context.Background(), "test-asg", 5)
+
+		assert.ErrorContains(t, err, code)
+		mockSvc.AssertNumberOfCalls(t, "UpdateAutoScalingGroup", 1)
+	}
+}
+
+// TestWithRetries_Exhausted verifies a call throttled on every attempt fails after max-retries retries
+// Expected behavior:
+//   - TerminateInstanceInAutoScalingGroup is called 1 + maxRetries times
+//   - The throttling error of the last attempt is returned
+func TestWithRetries_Exhausted(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("TerminateInstanceInAutoScalingGroup", mock.Anything, mock.Anything).
+		Return(nil, &smithy.GenericAPIError{Code: "ThrottlingException"})
+
+	client := &AWSClient{
+		svc:        mockSvc,
+		maxRetries: 2,
+		sleep:      func(time.Duration) {},
+	}
+
+	err := client.TerminateInstance(context.Background(), "test-asg", "i-1")
+
+	assert.ErrorContains(t, err, "ThrottlingException")
+	mockSvc.AssertNumberOfCalls(t, "TerminateInstanceInAutoScalingGroup", 3)
+}
+
+// TestRetryDelay verifies the backoff doubles from the base delay and is capped
+func TestRetryDelay(t *testing.T) {
+	for attempt, base := range []time.Duration{retryBaseDelay, 2 * retryBaseDelay, 4 * retryBaseDelay} {
+		delay := retryDelay(attempt)
+		assert.GreaterOrEqual(t, delay, base/2, attempt)
+		assert.LessOrEqual(t, delay, base, attempt)
+	}
+	assert.LessOrEqual(t, retryDelay(100), retryMaxDelay)
+}
+
+// TestWithRetries_Canceled verifies no retry is made once the context is canceled, e.g. on SIGTERM
+// Expected behavior:
+//   - DescribeAutoScalingGroups is called once although it was throttled
+//   - The error of the canceled context is returned
+func TestWithRetries_Canceled(t *testing.T) {
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("DescribeAutoScalingGroups", mock.Anything, mock.Anything).
+		Return(nil, &smithy.GenericAPIError{Code: "Throttling"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	client := &AWSClient{
+		svc:        mockSvc,
+		maxRetries: 3,
+		sleep:      func(time.Duration) { cancel() },
+	}
+
+	_, _, err := client.GetCurrentCapacity(ctx, "test-asg")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	mockSvc.AssertNumberOfCalls(t, "DescribeAutoScalingGroups", 1)
+}
+
+// TestWithRetries_RequestTimeout verifies every attempt is bounded by request-timeout
+// Expected behavior:
+//   - UpdateAutoScalingGroup receives a context with a deadline within the request timeout
+//   - Without a request timeout the context of the caller is passed as is
+func TestWithRetries_RequestTimeout(t *testing.T) {
+	var deadline time.Time
+	var hasDeadline bool
+	mockSvc := &mocks.MockAutoscalingAPI{}
+	mockSvc.On("UpdateAutoScalingGroup", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			deadline, hasDeadline = args.Get(0).(context.Context).Deadline()
+		}).
+		Return(&autoscaling.UpdateAutoScalingGroupOutput{}, nil)
+
+	client := &AWSClient{svc: mockSvc, requestTimeout: time.Minute}
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 1))
+	assert.True(t, hasDeadline)
+	assert.WithinDuration(t, time.Now().Add(time.Minute), deadline, 5*time.Second)
+
+	client.requestTimeout = 0
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "test-asg", 1))
+	assert.False(t, hasDeadline)
+}
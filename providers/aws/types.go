@@ -1,6 +1,42 @@
 package aws
 
+import (
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/autoscaling/types"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
 // AWSClient implements the AutoscalingAPI interface using AWS SDK.
 type AWSClient struct {
-	svc AutoscalingAPI
+	region string         // Region the client is bound to
+	svc    AutoscalingAPI // Describe calls, with the read role when configured
+	ec2Svc EC2API         // Launch times of instances, with the read role when configured; none are reported when nil
+
+	writeMu     sync.Mutex
+	writeSvc    AutoscalingAPI                 // Capacity changes; nil while the write role is unavailable, svc is used when newWriteSvc is nil too
+	newWriteSvc func() (AutoscalingAPI, error) // Assumes the write role; nil without write-role-arn
+	writeErr    error                          // Why the write role is unavailable
+
+	manageMinMax   bool                // Pin MinSize and MaxSize to the desired capacity on updates
+	countUnhealthy bool                // Count instances failing health checks as allocated
+	tagScaling     bool                // Tag the ASG with the reason and time of every scale-up
+	maxRetries     int                 // Retries of a throttled or transiently failing API call
+	requestTimeout time.Duration       // Bound of a single API call attempt; none when 0
+	sleep          func(time.Duration) // Waits between retries in tests; a timer canceled with the context when nil
+
+	mu          sync.Mutex
+	instances   map[string][]core.Instance        // Allocated instances per ASG seen by the last describe
+	terminating map[string][]string               // Instances in Terminating:Wait per ASG seen by the last describe
+	warm        map[string]int64                  // Warm pool instances per ASG seen by the last describe
+	limits      map[string]groupLimits            // MinSize and MaxSize per ASG seen by the last describe
+	described   map[string]types.AutoScalingGroup // Groups of the last DescribeAll not read by GetCurrentCapacity yet
+	launched    map[string]map[string]time.Time   // Launch time per instance ID per ASG, described once per instance
+}
+
+// groupLimits are the size limits configured on an ASG; nil when AWS did not report one
+type groupLimits struct {
+	min, max *int32
 }
@@ -0,0 +1,134 @@
+package azure
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore"
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// Options tune the behavior of the client
+type Options struct {
+	SubscriptionID string        // Subscription of the scale sets
+	ResourceGroup  string        // Resource group of the scale sets
+	TenantID       string        // Tenant of the service principal authenticating with ClientSecret
+	ClientID       string        // Application (client) ID of the service principal authenticating with ClientSecret
+	ClientSecret   string        // Secret of the service principal; the default credential chain is used when empty
+	RequestTimeout time.Duration // Bound of a single API call, so that a hung endpoint cannot block a cycle
+}
+
+// Provisioning states of a VM that is going away or will not come up; it runs no jobs
+var leavingStates = map[string]bool{
+	"deleting": true,
+	"failed":   true,
+}
+
+// Power states of a VM that does not run, although it still belongs to the scale set
+var stoppedPowerStates = map[string]bool{
+	"PowerState/deallocated":  true,
+	"PowerState/deallocating": true,
+	"PowerState/stopped":      true,
+	"PowerState/stopping":     true,
+}
+
+// NewAzureClient creates a client for the scale sets of the resource group. With a ClientSecret the service
+// principal of TenantID and ClientID authenticates; otherwise the default credential chain does: environment
+// variables, workload identity, managed identity, then the Azure CLI. Every call is bounded by RequestTimeout.
+func NewAzureClient(options Options) (core.Provider, error) {
+	var credential azcore.TokenCredential
+	var err error
+	if options.ClientSecret != "" {
+		credential, err = azidentity.NewClientSecretCredential(options.TenantID, options.ClientID, options.ClientSecret, nil)
+	} else {
+		credential, err = azidentity.NewDefaultAzureCredential(nil)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Azure credentials: %w", err)
+	}
+
+	scaleSets, err := armcompute.NewVirtualMachineScaleSetsClient(options.SubscriptionID, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scale set client: %w", err)
+	}
+	vms, err := armcompute.NewVirtualMachineScaleSetVMsClient(options.SubscriptionID, credential, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create scale set VM client: %w", err)
+	}
+
+	return &AzureClient{
+		resourceGroup:  options.ResourceGroup,
+		svc:            scaleSetService{scaleSets: scaleSets, vms: vms, resourceGroup: options.ResourceGroup},
+		requestTimeout: options.RequestTimeout,
+	}, nil
+}
+
+// GetCurrentCapacity returns the VMs of the scale set that run or are being created, and sku.capacity as the
+// desired capacity. VMs being deleted, failed to provision, or stopped and deallocated are not allocated.
+func (c *AzureClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	scaleSet, err := c.svc.GetScaleSet(ctx, asgName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get scale set %s: %w", asgName, err)
+	}
+	vms, err := c.svc.ListInstances(ctx, asgName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list instances of scale set %s: %w", asgName, err)
+	}
+
+	var allocatedCount int64
+	for _, vm := range vms {
+		if allocated(vm) {
+			allocatedCount++
+		}
+	}
+
+	desiredCapacity := int64(0)
+	if scaleSet.SKU != nil && scaleSet.SKU.Capacity != nil {
+		desiredCapacity = *scaleSet.SKU.Capacity
+	}
+	return allocatedCount, desiredCapacity, nil
+}
+
+// allocated reports whether a VM runs or is being created, from its provisioning state and power state
+func allocated(vm *armcompute.VirtualMachineScaleSetVM) bool {
+	if vm == nil || vm.Properties == nil {
+		return false
+	}
+	if state := vm.Properties.ProvisioningState; state != nil && leavingStates[strings.ToLower(*state)] {
+		return false
+	}
+	if view := vm.Properties.InstanceView; view != nil {
+		for _, status := range view.Statuses {
+			if status != nil && status.Code != nil && stoppedPowerStates[*status.Code] {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// UpdateASGCapacity sets sku.capacity of the scale set; the scale set creates or deletes VMs in the background
+func (c *AzureClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for scale set %s: must not be negative", capacity, asgName)
+	}
+	if capacity > math.MaxInt32 {
+		return fmt.Errorf("cannot set capacity %d for scale set %s: exceeds the maximum of %d", capacity, asgName, int64(math.MaxInt32))
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	if err := c.svc.SetCapacity(ctx, asgName, capacity); err != nil {
+		return fmt.Errorf("failed to set capacity of scale set %s: %w", asgName, err)
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package azure
+
+import (
+	"context"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/providers/azure"
+)
+
+// vm returns a scale set VM with a provisioning state and instance view status codes
+func vm(provisioningState string, codes ...string) *armcompute.VirtualMachineScaleSetVM {
+	view := &armcompute.VirtualMachineScaleSetVMInstanceView{}
+	for _, code := range codes {
+		view.Statuses = append(view.Statuses, &armcompute.InstanceViewStatus{Code: to.Ptr(code)})
+	}
+	return &armcompute.VirtualMachineScaleSetVM{
+		Properties: &armcompute.VirtualMachineScaleSetVMProperties{
+			ProvisioningState: to.Ptr(provisioningState),
+			InstanceView:      view,
+		},
+	}
+}
+
+// TestGetCurrentCapacity verifies the allocated VMs and sku.capacity of a scale set
+// Expected behavior:
+//   - Running VMs and VMs being created are allocated
+//   - VMs being deleted, failed ones and deallocated or stopped ones are not
+//   - sku.capacity is the desired capacity
+func TestGetCurrentCapacity(t *testing.T) {
+	mockSvc := &mocks.MockScaleSetsAPI{}
+	mockSvc.On("GetScaleSet", mock.Anything, "runners").Return(&armcompute.VirtualMachineScaleSet{
+		SKU: &armcompute.SKU{Name: to.Ptr("Standard_D4s_v5"), Capacity: to.Ptr(int64(5))},
+	}, nil)
+	mockSvc.On("ListInstances", mock.Anything, "runners").Return([]*armcompute.VirtualMachineScaleSetVM{
+		vm("Succeeded", "ProvisioningState/succeeded", "PowerState/running"),
+		vm("Creating", "ProvisioningState/creating"),
+		vm("Deleting", "ProvisioningState/deleting", "PowerState/running"),
+		vm("Failed", "ProvisioningState/failed"),
+		vm("Succeeded", "ProvisioningState/succeeded", "PowerState/deallocated"),
+		vm("Succeeded", "ProvisioningState/succeeded", "PowerState/stopped"),
+	}, nil)
+
+	client := &AzureClient{svc: mockSvc}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "runners")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(5), desired)
+	mockSvc.AssertExpectations(t)
+}
+
+// TestGetCurrentCapacity_ScaledToZero verifies an empty scale set reads as zero allocated and desired
+func TestGetCurrentCapacity_ScaledToZero(t *testing.T) {
+	mockSvc := &mocks.MockScaleSetsAPI{}
+	mockSvc.On("GetScaleSet", mock.Anything, "runners").Return(&armcompute.VirtualMachineScaleSet{
+		SKU: &armcompute.SKU{Capacity: to.Ptr(int64(0))},
+	}, nil)
+	mockSvc.On("ListInstances", mock.Anything, "runners").Return([]*armcompute.VirtualMachineScaleSetVM(nil), nil)
+
+	client := &AzureClient{svc: mockSvc}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "runners")
+	require.NoError(t, err)
+	assert.Zero(t, allocated)
+	assert.Zero(t, desired)
+}
+
+// TestGetCurrentCapacity_Error verifies a failed read of the scale set is returned with its name
+func TestGetCurrentCapacity_Error(t *testing.T) {
+	mockSvc := &mocks.MockScaleSetsAPI{}
+	mockSvc.On("GetScaleSet", mock.Anything, "runners").Return(nil, errors.New("ResourceNotFound"))
+
+	client := &AzureClient{svc: mockSvc}
+
+	_, _, err := client.GetCurrentCapacity(context.Background(), "runners")
+	assert.ErrorContains(t, err, "failed to get scale set runners: ResourceNotFound")
+	mockSvc.AssertNotCalled(t, "ListInstances", mock.Anything, mock.Anything)
+}
+
+// TestUpdateASGCapacity verifies capacity changes set sku.capacity of the scale set
+// Expected behavior:
+//   - A valid capacity is set, zero included for scale-to-zero
+//   - Negative capacities and capacities beyond int32 are rejected without a call
+//   - A failed update is returned
+func TestUpdateASGCapacity(t *testing.T) {
+	mockSvc := &mocks.MockScaleSetsAPI{}
+	mockSvc.On("SetCapacity", mock.Anything, "runners", int64(4)).Return(nil).Once()
+	mockSvc.On("SetCapacity", mock.Anything, "runners", int64(0)).Return(nil).Once()
+	mockSvc.On("SetCapacity", mock.Anything, "runners", int64(6)).Return(errors.New("OperationNotAllowed")).Once()
+
+	client := &AzureClient{svc: mockSvc}
+
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "runners", 4))
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "runners", 0))
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", -1), "must not be negative")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", math.MaxInt32+1), "exceeds the maximum")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", 6), "failed to set capacity of scale set runners: OperationNotAllowed")
+	mockSvc.AssertExpectations(t)
+}
@@ -0,0 +1,14 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+)
+
+// ScaleSetsAPI defines the Virtual Machine Scale Set operations on the scale sets of one resource group
+type ScaleSetsAPI interface {
+	GetScaleSet(ctx context.Context, name string) (*armcompute.VirtualMachineScaleSet, error)
+	ListInstances(ctx context.Context, name string) ([]*armcompute.VirtualMachineScaleSetVM, error)
+	SetCapacity(ctx context.Context, name string, capacity int64) error
+}
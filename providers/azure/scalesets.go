@@ -0,0 +1,47 @@
+package azure
+
+import (
+	"context"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azcore/to"
+	"github.com/Azure/azure-sdk-for-go/sdk/resourcemanager/compute/armcompute/v6"
+)
+
+// scaleSetService implements ScaleSetsAPI with the Azure Resource Manager compute clients
+type scaleSetService struct {
+	scaleSets     *armcompute.VirtualMachineScaleSetsClient
+	vms           *armcompute.VirtualMachineScaleSetVMsClient
+	resourceGroup string
+}
+
+func (s scaleSetService) GetScaleSet(ctx context.Context, name string) (*armcompute.VirtualMachineScaleSet, error) {
+	result, err := s.scaleSets.Get(ctx, s.resourceGroup, name, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &result.VirtualMachineScaleSet, nil
+}
+
+// ListInstances lists the VMs of the scale set with their instance view, following pagination
+func (s scaleSetService) ListInstances(ctx context.Context, name string) ([]*armcompute.VirtualMachineScaleSetVM, error) {
+	var vms []*armcompute.VirtualMachineScaleSetVM
+	pager := s.vms.NewListPager(s.resourceGroup, name, &armcompute.VirtualMachineScaleSetVMsClientListOptions{
+		Expand: to.Ptr("instanceView"),
+	})
+	for pager.More() {
+		page, err := pager.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		vms = append(vms, page.Value...)
+	}
+	return vms, nil
+}
+
+// SetCapacity patches sku.capacity of the scale set without waiting for the update to complete
+func (s scaleSetService) SetCapacity(ctx context.Context, name string, capacity int64) error {
+	_, err := s.scaleSets.BeginUpdate(ctx, s.resourceGroup, name, armcompute.VirtualMachineScaleSetUpdate{
+		SKU: &armcompute.SKU{Capacity: to.Ptr(capacity)},
+	}, nil)
+	return err
+}
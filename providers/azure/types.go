@@ -0,0 +1,10 @@
+package azure
+
+import "time"
+
+// AzureClient implements core.Provider for the Virtual Machine Scale Sets of a resource group
+type AzureClient struct {
+	resourceGroup  string        // Resource group of the scale sets
+	svc            ScaleSetsAPI  // Scale set API bound to the subscription and resource group
+	requestTimeout time.Duration // Bound of a single API call; none when 0
+}
@@ -0,0 +1,291 @@
+package digitalocean
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"golang.org/x/oauth2"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// Options tune the behavior of the client
+type Options struct {
+	Token          string          // API token; DIGITALOCEAN_TOKEN, then DIGITALOCEAN_ACCESS_TOKEN when empty
+	EndpointURL    string          // Endpoint of the DigitalOcean API instead of the default one, e.g. a mock server
+	RequestTimeout time.Duration   // Bound of a single API call, so that a hung endpoint cannot block a cycle
+	Pools          map[string]Pool // Droplet settings of each pool by its tag
+}
+
+// Pool contains the settings of the droplets created for a pool
+type Pool struct {
+	Size     string   // Size slug, e.g. "s-2vcpu-4gb"
+	Image    string   // Image slug or ID of a snapshot
+	UserData string   // Cloud-init user data registering the runner
+	SSHKeys  []string // IDs or fingerprints of the SSH keys installed
+	VPCUUID  string   // VPC of the droplets; the default VPC of the region when empty
+}
+
+// Statuses of a droplet that runs or is being created
+var allocatedStatuses = map[string]bool{
+	"new":    true,
+	"active": true,
+}
+
+// maxCreateBatch is the most droplets a single create request accepts
+const maxCreateBatch = 10
+
+// NewDigitalOceanClient creates a client creating the droplets of the pools in the region. Every call is bounded
+// by RequestTimeout.
+func NewDigitalOceanClient(region string, options Options) (core.Provider, error) {
+	token := cmp.Or(options.Token, os.Getenv("DIGITALOCEAN_TOKEN"), os.Getenv("DIGITALOCEAN_ACCESS_TOKEN"))
+	if token == "" {
+		return nil, fmt.Errorf("no API token: set token or DIGITALOCEAN_TOKEN")
+	}
+	httpClient := oauth2.NewClient(context.Background(), oauth2.StaticTokenSource(&oauth2.Token{AccessToken: token}))
+	clientOptions := []godo.ClientOpt{godo.SetUserAgent("gitlab-autoscaler")}
+	if options.EndpointURL != "" {
+		clientOptions = append(clientOptions, godo.SetBaseURL(options.EndpointURL))
+	}
+	client, err := godo.New(httpClient, clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create DigitalOcean API client: %w", err)
+	}
+
+	return newClient(region, options.Pools, dropletService{client: client}, options.RequestTimeout), nil
+}
+
+// newClient creates a client on top of the droplet API
+func newClient(region string, pools map[string]Pool, svc DropletsAPI, requestTimeout time.Duration) *DigitalOceanClient {
+	return &DigitalOceanClient{
+		region:         region,
+		pools:          pools,
+		svc:            svc,
+		requestTimeout: requestTimeout,
+		now:            time.Now,
+		droplets:       make(map[string][]godo.Droplet),
+		protected:      make(map[string]map[string]bool),
+	}
+}
+
+// listPool returns the droplets of the pool that are active or still being created
+func (c *DigitalOceanClient) listPool(ctx context.Context, pool string) ([]godo.Droplet, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	droplets, err := c.svc.ListByTag(ctx, pool)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list droplets of pool %s: %w", pool, err)
+	}
+	return slices.DeleteFunc(droplets, func(droplet godo.Droplet) bool {
+		return !allocatedStatuses[droplet.Status]
+	}), nil
+}
+
+// GetCurrentCapacity returns the droplets of the pool that are active or still being created, like pending
+// instances of an ASG, as both the allocated and the desired capacity. Powered off and archived droplets are not
+// counted; the protection of droplets that are gone is dropped.
+func (c *DigitalOceanClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	droplets, err := c.listPool(ctx, asgName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	c.mu.Lock()
+	c.droplets[asgName] = droplets
+	for id := range c.protected[asgName] {
+		if !slices.ContainsFunc(droplets, func(droplet godo.Droplet) bool { return strconv.Itoa(droplet.ID) == id }) {
+			delete(c.protected[asgName], id)
+		}
+	}
+	c.mu.Unlock()
+	return int64(len(droplets)), int64(len(droplets)), nil
+}
+
+// UpdateASGCapacity creates droplets from the settings of the pool until it reaches the capacity, or deletes the
+// most recently created droplets not protected from scale-down. Protected droplets are kept, so the pool stays
+// above the capacity until they are released.
+func (c *DigitalOceanClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for pool %s: must not be negative", capacity, asgName)
+	}
+	if _, ok := c.pools[asgName]; !ok {
+		return fmt.Errorf("cannot set capacity for pool %s: no droplet settings", asgName)
+	}
+	droplets, err := c.listPool(ctx, asgName)
+	if err != nil {
+		return err
+	}
+
+	current := int64(len(droplets))
+	switch {
+	case capacity > current:
+		return c.createDroplets(ctx, asgName, int(capacity-current))
+	case capacity < current:
+		return c.deleteDroplets(ctx, asgName, c.scaleDownCandidates(asgName, droplets, int(current-capacity)))
+	}
+	return nil
+}
+
+// scaleDownCandidates returns up to count droplets of the pool not protected from scale-down, most recently
+// created first
+func (c *DigitalOceanClient) scaleDownCandidates(pool string, droplets []godo.Droplet, count int) []godo.Droplet {
+	c.mu.Lock()
+	protected := maps.Clone(c.protected[pool])
+	c.mu.Unlock()
+
+	candidates := slices.DeleteFunc(slices.Clone(droplets), func(droplet godo.Droplet) bool {
+		return protected[strconv.Itoa(droplet.ID)]
+	})
+	slices.SortFunc(candidates, func(a, b godo.Droplet) int {
+		return cmp.Or(createdAt(b).Compare(createdAt(a)), cmp.Compare(b.ID, a.ID))
+	})
+	return candidates[:min(count, len(candidates))]
+}
+
+// createDroplets creates count droplets from the settings of the pool, tagged with it
+func (c *DigitalOceanClient) createDroplets(ctx context.Context, pool string, count int) error {
+	settings := c.pools[pool]
+	base := dropletName(pool)
+	stamp := c.now().Unix()
+	for created := 0; created < count; {
+		batch := min(count-created, maxCreateBatch)
+		names := make([]string, batch)
+		for i := range names {
+			names[i] = fmt.Sprintf("%s-%d-%d", base, stamp, created+i)
+		}
+		request := &godo.DropletMultiCreateRequest{
+			Names:    names,
+			Region:   c.region,
+			Size:     settings.Size,
+			Image:    createImage(settings.Image),
+			SSHKeys:  createSSHKeys(settings.SSHKeys),
+			UserData: settings.UserData,
+			Tags:     []string{pool},
+			VPCUUID:  settings.VPCUUID,
+		}
+		callCtx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+		err := c.svc.CreateMultiple(callCtx, request)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to create %d droplets for pool %s: %w", batch, pool, err)
+		}
+		created += batch
+	}
+	return nil
+}
+
+// deleteDroplets deletes the droplets of the pool, stopping at the first failure
+func (c *DigitalOceanClient) deleteDroplets(ctx context.Context, pool string, droplets []godo.Droplet) error {
+	for _, droplet := range droplets {
+		callCtx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+		err := c.svc.Delete(callCtx, droplet.ID)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("failed to delete droplet %d of pool %s: %w", droplet.ID, pool, err)
+		}
+	}
+	return nil
+}
+
+// Instances returns the droplets of the pool seen by the last GetCurrentCapacity call; droplets still being
+// created are pending
+func (c *DigitalOceanClient) Instances(asgName string) []core.Instance {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	instances := make([]core.Instance, 0, len(c.droplets[asgName]))
+	for _, droplet := range c.droplets[asgName] {
+		id := strconv.Itoa(droplet.ID)
+		instances = append(instances, core.Instance{
+			ID:         id,
+			Pending:    droplet.Status == "new",
+			LaunchTime: createdAt(droplet),
+			Protected:  c.protected[asgName][id],
+		})
+	}
+	return instances
+}
+
+// TerminateInstance deletes a droplet of the pool and creates a replacement, so that the capacity is kept
+func (c *DigitalOceanClient) TerminateInstance(ctx context.Context, asgName, instanceID string) error {
+	if _, ok := c.pools[asgName]; !ok {
+		return fmt.Errorf("cannot replace droplet %s of pool %s: no droplet settings", instanceID, asgName)
+	}
+	id, err := strconv.Atoi(instanceID)
+	if err != nil {
+		return fmt.Errorf("invalid droplet ID %q: %w", instanceID, err)
+	}
+	if err := c.deleteDroplets(ctx, asgName, []godo.Droplet{{ID: id}}); err != nil {
+		return err
+	}
+	return c.createDroplets(ctx, asgName, 1)
+}
+
+// SetInstanceProtection protects droplets of the pool from being deleted by a scale-down or releases them. The
+// protection is kept in memory only and is rebuilt from the busy runners after a restart.
+func (c *DigitalOceanClient) SetInstanceProtection(_ context.Context, asgName string, instanceIDs []string, protected bool) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.protected[asgName] == nil {
+		c.protected[asgName] = make(map[string]bool)
+	}
+	for _, id := range instanceIDs {
+		if protected {
+			c.protected[asgName][id] = true
+		} else {
+			delete(c.protected[asgName], id)
+		}
+	}
+	return nil
+}
+
+// createdAt returns when the droplet was created; zero when unknown
+func createdAt(droplet godo.Droplet) time.Time {
+	created, err := time.Parse(time.RFC3339, droplet.Created)
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
+
+// dropletName returns the tag of the pool as a droplet hostname prefix, which allows letters, digits, dots and
+// dashes only
+func dropletName(pool string) string {
+	return strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '.' || r == '-' {
+			return r
+		}
+		return '-'
+	}, pool)
+}
+
+// createImage returns the image of a create request: a snapshot or custom image by numeric ID, a slug otherwise
+func createImage(image string) godo.DropletCreateImage {
+	if id, err := strconv.Atoi(image); err == nil {
+		return godo.DropletCreateImage{ID: id}
+	}
+	return godo.DropletCreateImage{Slug: image}
+}
+
+// createSSHKeys returns the SSH keys of a create request, by numeric ID or fingerprint
+func createSSHKeys(keys []string) []godo.DropletCreateSSHKey {
+	sshKeys := make([]godo.DropletCreateSSHKey, 0, len(keys))
+	for _, key := range keys {
+		if id, err := strconv.Atoi(key); err == nil {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{ID: id})
+		} else {
+			sshKeys = append(sshKeys, godo.DropletCreateSSHKey{Fingerprint: key})
+		}
+	}
+	return sshKeys
+}
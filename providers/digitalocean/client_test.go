@@ -0,0 +1,170 @@
+package digitalocean
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/digitalocean/godo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/providers/digitalocean"
+)
+
+var testPools = map[string]Pool{
+	"ci:docker": {Size: "s-2vcpu-4gb", Image: "ubuntu-24-04-x64", UserData: "#cloud-config", SSHKeys: []string{"1234", "aa:bb"}},
+}
+
+// newTestClient returns a client of the test pools in fra1 on top of the mocked API
+func newTestClient(svc DropletsAPI) *DigitalOceanClient {
+	client := newClient("fra1", testPools, svc, 0)
+	client.now = func() time.Time { return time.Unix(1700000000, 0) }
+	return client
+}
+
+// TestGetCurrentCapacity verifies the droplets counted for a pool
+// Expected behavior:
+//   - Active droplets and droplets still being created are allocated and desired
+//   - Powered off and archived droplets are not counted
+//   - Droplets being created are reported as pending instances with their creation time
+func TestGetCurrentCapacity(t *testing.T) {
+	mockSvc := &mocks.MockDropletsAPI{}
+	mockSvc.On("ListByTag", mock.Anything, "ci:docker").Return([]godo.Droplet{
+		{ID: 1, Status: "active", Created: "2026-01-01T10:00:00Z"},
+		{ID: 2, Status: "new", Created: "2026-01-01T11:00:00Z"},
+		{ID: 3, Status: "off"},
+		{ID: 4, Status: "archive"},
+	}, nil)
+
+	client := newTestClient(mockSvc)
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "ci:docker")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(2), desired)
+	assert.Equal(t, []string{"1", "2"}, []string{client.Instances("ci:docker")[0].ID, client.Instances("ci:docker")[1].ID})
+	assert.False(t, client.Instances("ci:docker")[0].Pending)
+	assert.True(t, client.Instances("ci:docker")[1].Pending)
+	assert.Equal(t, time.Date(2026, 1, 1, 11, 0, 0, 0, time.UTC), client.Instances("ci:docker")[1].LaunchTime)
+}
+
+// TestGetCurrentCapacity_Error verifies a failed list is returned with the pool
+func TestGetCurrentCapacity_Error(t *testing.T) {
+	mockSvc := &mocks.MockDropletsAPI{}
+	mockSvc.On("ListByTag", mock.Anything, "ci:docker").Return(nil, errors.New("unauthorized"))
+
+	_, _, err := newTestClient(mockSvc).GetCurrentCapacity(context.Background(), "ci:docker")
+	assert.ErrorContains(t, err, "failed to list droplets of pool ci:docker: unauthorized")
+}
+
+// TestUpdateASGCapacity_ScaleUp verifies droplets are created from the settings of the pool
+// Expected behavior:
+//   - The missing droplets are created in batches of at most 10, tagged with the pool
+//   - Names are derived from the tag, image and SSH keys are passed by ID or slug/fingerprint
+func TestUpdateASGCapacity_ScaleUp(t *testing.T) {
+	mockSvc := &mocks.MockDropletsAPI{}
+	mockSvc.On("ListByTag", mock.Anything, "ci:docker").Return([]godo.Droplet{{ID: 1, Status: "active"}}, nil)
+	var requests []*godo.DropletMultiCreateRequest
+	mockSvc.On("CreateMultiple", mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		requests = append(requests, args.Get(1).(*godo.DropletMultiCreateRequest))
+	}).Return(nil)
+
+	require.NoError(t, newTestClient(mockSvc).UpdateASGCapacity(context.Background(), "ci:docker", 13))
+
+	require.Len(t, requests, 2)
+	assert.Len(t, requests[0].Names, 10)
+	assert.Equal(t, []string{"ci-docker-1700000000-10", "ci-docker-1700000000-11"}, requests[1].Names)
+	assert.Equal(t, "fra1", requests[0].Region)
+	assert.Equal(t, "s-2vcpu-4gb", requests[0].Size)
+	assert.Equal(t, godo.DropletCreateImage{Slug: "ubuntu-24-04-x64"}, requests[0].Image)
+	assert.Equal(t, []godo.DropletCreateSSHKey{{ID: 1234}, {Fingerprint: "aa:bb"}}, requests[0].SSHKeys)
+	assert.Equal(t, "#cloud-config", requests[0].UserData)
+	assert.Equal(t, []string{"ci:docker"}, requests[0].Tags)
+}
+
+// TestUpdateASGCapacity_ScaleDown verifies the most recently created droplets not protected are deleted
+// Expected behavior:
+//   - Droplets are deleted newest first
+//   - Protected droplets are kept even if that leaves the pool above the capacity
+func TestUpdateASGCapacity_ScaleDown(t *testing.T) {
+	mockSvc := &mocks.MockDropletsAPI{}
+	mockSvc.On("ListByTag", mock.Anything, "ci:docker").Return([]godo.Droplet{
+		{ID: 1, Status: "active", Created: "2026-01-01T09:00:00Z"},
+		{ID: 2, Status: "active", Created: "2026-01-01T11:00:00Z"},
+		{ID: 3, Status: "active", Created: "2026-01-01T10:00:00Z"},
+		{ID: 4, Status: "new", Created: "2026-01-01T12:00:00Z"},
+	}, nil).Once()
+	mockSvc.On("ListByTag", mock.Anything, "ci:docker").Return([]godo.Droplet{
+		{ID: 1, Status: "active", Created: "2026-01-01T09:00:00Z"},
+		{ID: 4, Status: "active", Created: "2026-01-01T12:00:00Z"},
+	}, nil).Once()
+	mockSvc.On("Delete", mock.Anything, 2).Return(nil).Once()
+	mockSvc.On("Delete", mock.Anything, 3).Return(nil).Once()
+	mockSvc.On("Delete", mock.Anything, 1).Return(nil).Once()
+
+	client := newTestClient(mockSvc)
+	require.NoError(t, client.SetInstanceProtection(context.Background(), "ci:docker", []string{"4"}, true))
+
+	require.NoError(t, client.UpdateASGCapacity(context.Background(), "ci:docker", 2))
+	mockSvc.AssertNotCalled(t, "Delete", mock.Anything, 4)
+	mockSvc.AssertNotCalled(t, "Delete", mock.Anything, 1)
+
+	require.NoError(t, client.UpdateASGCapacity(context.Background(), "ci:docker", 0))
+	mockSvc.AssertNotCalled(t, "Delete", mock.Anything, 4)
+	mockSvc.AssertCalled(t, "Delete", mock.Anything, 1)
+}
+
+// TestUpdateASGCapacity_Invalid verifies capacity changes that cannot be applied are rejected without a call
+func TestUpdateASGCapacity_Invalid(t *testing.T) {
+	client := newTestClient(&mocks.MockDropletsAPI{})
+
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "ci:docker", -1), "must not be negative")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "ci:gpu", 1), "cannot set capacity for pool ci:gpu: no droplet settings")
+}
+
+// TestTerminateInstance verifies a replaced droplet is deleted and a new one is created in its place
+func TestTerminateInstance(t *testing.T) {
+	mockSvc := &mocks.MockDropletsAPI{}
+	mockSvc.On("Delete", mock.Anything, 7).Return(nil).Once()
+	mockSvc.On("CreateMultiple", mock.Anything, mock.MatchedBy(func(request *godo.DropletMultiCreateRequest) bool {
+		return len(request.Names) == 1
+	})).Return(nil).Once()
+
+	client := newTestClient(mockSvc)
+
+	require.NoError(t, client.TerminateInstance(context.Background(), "ci:docker", "7"))
+	assert.ErrorContains(t, client.TerminateInstance(context.Background(), "ci:docker", "i-7"), "invalid droplet ID")
+	mockSvc.AssertExpectations(t)
+}
+
+// TestDropletService_ListByTag verifies the droplets of a tag are listed across pages
+func TestDropletService_ListByTag(t *testing.T) {
+	var pages []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "ci:docker", r.URL.Query().Get("tag_name"))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		pages = append(pages, r.URL.Query().Get("page"))
+		body := map[string]any{"droplets": []godo.Droplet{{ID: len(pages), Status: "active"}}}
+		if len(pages) == 1 {
+			body["links"] = map[string]any{"pages": map[string]string{"next": server.URL + "/v2/droplets?page=2", "last": server.URL + "/v2/droplets?page=2"}}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	defer server.Close()
+
+	provider, err := NewDigitalOceanClient("fra1", Options{Token: "token", EndpointURL: server.URL})
+	require.NoError(t, err)
+
+	allocated, _, err := provider.GetCurrentCapacity(context.Background(), "ci:docker")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, []string{"1", "2"}, pages)
+}
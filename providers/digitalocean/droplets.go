@@ -0,0 +1,40 @@
+package digitalocean
+
+import (
+	"context"
+
+	"github.com/digitalocean/godo"
+)
+
+// dropletService implements DropletsAPI with the godo client
+type dropletService struct {
+	client *godo.Client
+}
+
+// ListByTag lists the droplets carrying the tag, following pagination
+func (s dropletService) ListByTag(ctx context.Context, tag string) ([]godo.Droplet, error) {
+	var droplets []godo.Droplet
+	options := &godo.ListOptions{Page: 1, PerPage: 200}
+	for {
+		page, response, err := s.client.Droplets.ListByTag(ctx, tag, options)
+		if err != nil {
+			return nil, err
+		}
+		droplets = append(droplets, page...)
+		if response == nil || response.Links == nil || response.Links.IsLastPage() {
+			return droplets, nil
+		}
+		options.Page++
+	}
+}
+
+// CreateMultiple creates the droplets of the request without waiting for them to become active
+func (s dropletService) CreateMultiple(ctx context.Context, request *godo.DropletMultiCreateRequest) error {
+	_, _, err := s.client.Droplets.CreateMultiple(ctx, request)
+	return err
+}
+
+func (s dropletService) Delete(ctx context.Context, id int) error {
+	_, err := s.client.Droplets.Delete(ctx, id)
+	return err
+}
@@ -0,0 +1,14 @@
+package digitalocean
+
+import (
+	"context"
+
+	"github.com/digitalocean/godo"
+)
+
+// DropletsAPI defines the DigitalOcean API operations on the droplets of the pools
+type DropletsAPI interface {
+	ListByTag(ctx context.Context, tag string) ([]godo.Droplet, error)
+	CreateMultiple(ctx context.Context, request *godo.DropletMultiCreateRequest) error
+	Delete(ctx context.Context, id int) error
+}
@@ -0,0 +1,21 @@
+package digitalocean
+
+import (
+	"sync"
+	"time"
+
+	"github.com/digitalocean/godo"
+)
+
+// DigitalOceanClient implements core.Provider for pools of droplets sharing a tag; the tag is the ASG name
+type DigitalOceanClient struct {
+	region         string          // Region new droplets are created in
+	pools          map[string]Pool // Droplet settings of each pool by its tag
+	svc            DropletsAPI     // Droplet API authenticated with the token
+	requestTimeout time.Duration   // Bound of a single API call; none when 0
+	now            func() time.Time
+
+	mu        sync.Mutex
+	droplets  map[string][]godo.Droplet  // Droplets of each pool counted by the last GetCurrentCapacity call
+	protected map[string]map[string]bool // IDs of the droplets of each pool protected from scale-down
+}
@@ -0,0 +1,217 @@
+package exec
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	osexec "os/exec"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// Options tune the behavior of the client. Of the command and the webhook of each operation, exactly one is set.
+type Options struct {
+	GetCapacityCmd string        // Shell command printing {"allocated": N, "desired": N} for the ASG
+	SetCapacityCmd string        // Shell command setting the capacity of the ASG, successful when it exits with 0
+	GetCapacityURL string        // Webhook answering {"allocated": N, "desired": N} to a POST of {"asg": name}
+	SetCapacityURL string        // Webhook setting the capacity on a POST of {"asg": name, "capacity": N}
+	Token          string        // Bearer token sent to the webhooks; none when empty
+	RequestTimeout time.Duration // Bound of a single command or webhook call, so that a hung scaler cannot block a cycle
+}
+
+// maxOutput is the most bytes read from a command or webhook; a larger answer is not a capacity
+const maxOutput = 64 << 10
+
+// capacityRequest is the JSON sent to the commands on stdin and to the webhooks as the body
+type capacityRequest struct {
+	Asg      string `json:"asg"`
+	Capacity *int64 `json:"capacity,omitempty"`
+}
+
+// capacityResponse is the JSON answered by the get-capacity command or webhook
+type capacityResponse struct {
+	Allocated *int64 `json:"allocated"`
+	Desired   *int64 `json:"desired"`
+}
+
+// NewExecClient creates a client running the commands or calling the webhooks of options
+func NewExecClient(options Options) (core.Provider, error) {
+	if (options.GetCapacityCmd == "") == (options.GetCapacityURL == "") {
+		return nil, errors.New("exactly one of get-capacity-cmd and get-capacity-url is required")
+	}
+	if (options.SetCapacityCmd == "") == (options.SetCapacityURL == "") {
+		return nil, errors.New("exactly one of set-capacity-cmd and set-capacity-url is required")
+	}
+	return &ExecClient{
+		getCapacityCmd: options.GetCapacityCmd,
+		setCapacityCmd: options.SetCapacityCmd,
+		getCapacityURL: options.GetCapacityURL,
+		setCapacityURL: options.SetCapacityURL,
+		token:          options.Token,
+		httpClient:     &http.Client{},
+		requestTimeout: options.RequestTimeout,
+	}, nil
+}
+
+// GetCurrentCapacity asks the get-capacity command or webhook for the allocated and desired capacity of the ASG.
+// Both are required and must not be negative.
+func (c *ExecClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	request := capacityRequest{Asg: asgName}
+	var output []byte
+	var err error
+	if c.getCapacityCmd != "" {
+		output, err = runCommand(ctx, c.getCapacityCmd, request)
+	} else {
+		output, err = c.post(ctx, c.getCapacityURL, request)
+	}
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get capacity of ASG %s: %w", asgName, err)
+	}
+
+	var response capacityResponse
+	if err := json.Unmarshal(output, &response); err != nil {
+		return 0, 0, fmt.Errorf("failed to get capacity of ASG %s: invalid answer %q: %w", asgName, snippet(output), err)
+	}
+	if response.Allocated == nil || response.Desired == nil {
+		return 0, 0, fmt.Errorf("failed to get capacity of ASG %s: answer %q must contain allocated and desired", asgName, snippet(output))
+	}
+	if *response.Allocated < 0 || *response.Desired < 0 {
+		return 0, 0, fmt.Errorf("failed to get capacity of ASG %s: allocated %d and desired %d must not be negative", asgName, *response.Allocated, *response.Desired)
+	}
+	return *response.Allocated, *response.Desired, nil
+}
+
+// UpdateASGCapacity asks the set-capacity command or webhook to set the capacity of the ASG
+func (c *ExecClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for ASG %s: must not be negative", capacity, asgName)
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	request := capacityRequest{Asg: asgName, Capacity: &capacity}
+	var err error
+	if c.setCapacityCmd != "" {
+		_, err = runCommand(ctx, c.setCapacityCmd, request)
+	} else {
+		_, err = c.post(ctx, c.setCapacityURL, request)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set capacity %d for ASG %s: %w", capacity, asgName, err)
+	}
+	return nil
+}
+
+// runCommand runs the command with sh, passing the request as JSON on stdin and as GITLAB_AUTOSCALER_ASG and
+// GITLAB_AUTOSCALER_CAPACITY in the environment. Returns its stdout; a non-zero exit is an error with its stderr.
+func runCommand(ctx context.Context, command string, request capacityRequest) ([]byte, error) {
+	input, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := osexec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), "GITLAB_AUTOSCALER_ASG="+request.Asg)
+	if request.Capacity != nil {
+		cmd.Env = append(cmd.Env, "GITLAB_AUTOSCALER_CAPACITY="+strconv.FormatInt(*request.Capacity, 10))
+	}
+	cmd.Stdin = bytes.NewReader(input)
+	// Children keeping the output open must not hold the call past its timeout
+	cmd.WaitDelay = time.Second
+	var stdout, stderr limitedBuffer
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("command timed out: %w", ctx.Err())
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("command failed: %w: %s", err, snippet([]byte(message)))
+		}
+		return nil, fmt.Errorf("command failed: %w", err)
+	}
+	if stdout.truncated {
+		return nil, fmt.Errorf("command printed more than %d bytes", maxOutput)
+	}
+	return stdout.Bytes(), nil
+}
+
+// post sends the request as JSON to the webhook and returns the body of a 2xx answer
+func (c *ExecClient) post(ctx context.Context, url string, request capacityRequest) ([]byte, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpRequest.Header.Set("Content-Type", "application/json")
+	httpRequest.Header.Set("User-Agent", "gitlab-autoscaler")
+	if c.token != "" {
+		httpRequest.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	response, err := c.httpClient.Do(httpRequest)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	output, err := io.ReadAll(io.LimitReader(response.Body, maxOutput+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read answer: %w", err)
+	}
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, fmt.Errorf("webhook answered %s: %s", response.Status, snippet(output))
+	}
+	if len(output) > maxOutput {
+		return nil, fmt.Errorf("webhook answered more than %d bytes", maxOutput)
+	}
+	return output, nil
+}
+
+// snippet shortens output quoted in an error
+func snippet(output []byte) string {
+	const limit = 200
+	text := strings.TrimSpace(string(output))
+	if len(text) > limit {
+		return text[:limit] + "..."
+	}
+	return text
+}
+
+// limitedBuffer keeps the first maxOutput bytes written to it and drops the rest, so that a runaway command
+// cannot exhaust memory. It does not embed bytes.Buffer, whose ReadFrom would bypass the limit in io.Copy.
+type limitedBuffer struct {
+	buf       bytes.Buffer
+	truncated bool
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if room := maxOutput - b.buf.Len(); len(p) > room {
+		b.truncated = true
+		b.buf.Write(p[:max(room, 0)])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+func (b *limitedBuffer) Bytes() []byte {
+	return b.buf.Bytes()
+}
+
+func (b *limitedBuffer) String() string {
+	return b.buf.String()
+}
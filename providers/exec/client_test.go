@@ -0,0 +1,155 @@
+package exec
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubScript writes an executable shell script and returns its path
+func stubScript(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "stub.sh")
+	require.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0o755))
+	return path
+}
+
+// newCmdClient returns a client running the get and set commands
+func newCmdClient(t *testing.T, getCmd, setCmd string) *ExecClient {
+	t.Helper()
+	provider, err := NewExecClient(Options{GetCapacityCmd: getCmd, SetCapacityCmd: setCmd, RequestTimeout: 2 * time.Second})
+	require.NoError(t, err)
+	return provider.(*ExecClient)
+}
+
+// TestNewExecClient verifies each operation needs exactly one of its command and webhook
+func TestNewExecClient(t *testing.T) {
+	_, err := NewExecClient(Options{SetCapacityCmd: "true"})
+	assert.ErrorContains(t, err, "exactly one of get-capacity-cmd and get-capacity-url is required")
+	_, err = NewExecClient(Options{GetCapacityCmd: "true", GetCapacityURL: "http://scaler", SetCapacityCmd: "true"})
+	assert.ErrorContains(t, err, "exactly one of get-capacity-cmd and get-capacity-url is required")
+	_, err = NewExecClient(Options{GetCapacityCmd: "true"})
+	assert.ErrorContains(t, err, "exactly one of set-capacity-cmd and set-capacity-url is required")
+	_, err = NewExecClient(Options{GetCapacityCmd: "true", SetCapacityURL: "http://scaler"})
+	assert.NoError(t, err)
+}
+
+// TestGetCurrentCapacity_Command verifies the capacity printed by the get-capacity command
+// Expected behavior:
+//   - The ASG is passed on stdin as JSON and in GITLAB_AUTOSCALER_ASG
+//   - allocated and desired are parsed from stdout
+func TestGetCurrentCapacity_Command(t *testing.T) {
+	script := stubScript(t, `input=$(cat)
+[ "$input" = '{"asg":"proxmox-pool"}' ] || exit 3
+[ "$GITLAB_AUTOSCALER_ASG" = proxmox-pool ] || exit 4
+echo '{"allocated": 2, "desired": 3}'`)
+	client := newCmdClient(t, script, "true")
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "proxmox-pool")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(3), desired)
+}
+
+// TestGetCurrentCapacity_InvalidOutput verifies answers that are not a valid capacity are rejected
+// Expected behavior:
+//   - A non-zero exit is an error with the stderr of the command
+//   - Invalid JSON, missing fields and negative values are errors
+//   - Output beyond the limit is an error
+func TestGetCurrentCapacity_InvalidOutput(t *testing.T) {
+	for name, test := range map[string]struct {
+		script string
+		err    string
+	}{
+		"exit":      {`echo "no such pool" >&2; exit 2`, "command failed: exit status 2: no such pool"},
+		"not json":  {`echo "two"`, `invalid answer "two"`},
+		"missing":   {`echo '{"allocated": 2}'`, "must contain allocated and desired"},
+		"negative":  {`echo '{"allocated": -1, "desired": 0}'`, "must not be negative"},
+		"too large": {`head -c 70000 /dev/zero`, "command printed more than 65536 bytes"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			client := newCmdClient(t, stubScript(t, test.script), "true")
+			_, _, err := client.GetCurrentCapacity(context.Background(), "pool")
+			assert.ErrorContains(t, err, "failed to get capacity of ASG pool")
+			assert.ErrorContains(t, err, test.err)
+		})
+	}
+}
+
+// TestGetCurrentCapacity_Timeout verifies a hung command is stopped at the request timeout
+func TestGetCurrentCapacity_Timeout(t *testing.T) {
+	provider, err := NewExecClient(Options{GetCapacityCmd: "sleep 10", SetCapacityCmd: "true", RequestTimeout: 100 * time.Millisecond})
+	require.NoError(t, err)
+
+	start := time.Now()
+	_, _, err = provider.GetCurrentCapacity(context.Background(), "pool")
+	assert.ErrorContains(t, err, "command timed out")
+	assert.Less(t, time.Since(start), 5*time.Second)
+}
+
+// TestUpdateASGCapacity_Command verifies the set-capacity command gets the capacity and its exit status counts
+// Expected behavior:
+//   - The capacity is passed on stdin as JSON and in GITLAB_AUTOSCALER_CAPACITY
+//   - A non-zero exit is an error, negative capacities are rejected without running the command
+func TestUpdateASGCapacity_Command(t *testing.T) {
+	out := filepath.Join(t.TempDir(), "out")
+	script := stubScript(t, `cat > `+out+`
+[ "$GITLAB_AUTOSCALER_CAPACITY" = 0 ] && exit 1
+echo " $GITLAB_AUTOSCALER_ASG=$GITLAB_AUTOSCALER_CAPACITY" >> `+out)
+	client := newCmdClient(t, "true", script)
+
+	require.NoError(t, client.UpdateASGCapacity(context.Background(), "vsphere-pool", 4))
+	written, err := os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, `{"asg":"vsphere-pool","capacity":4} vsphere-pool=4`, strings.TrimSpace(string(written)))
+
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "vsphere-pool", 0), "failed to set capacity 0 for ASG vsphere-pool: command failed: exit status 1")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "vsphere-pool", -1), "must not be negative")
+}
+
+// TestWebhooks verifies the capacity is read from and set through the webhooks
+// Expected behavior:
+//   - Requests are JSON POSTs carrying the bearer token
+//   - The get webhook answers the capacity, a non-2xx answer is an error with its body
+func TestWebhooks(t *testing.T) {
+	var requests []capacityRequest
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPost, r.Method)
+		assert.Equal(t, "application/json", r.Header.Get("Content-Type"))
+		assert.Equal(t, "Bearer secret", r.Header.Get("Authorization"))
+		var request capacityRequest
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+		requests = append(requests, request)
+		switch {
+		case r.URL.Path == "/capacity":
+			_, _ = w.Write([]byte(`{"allocated": 1, "desired": 2}`))
+		case request.Capacity != nil && *request.Capacity > 5:
+			http.Error(w, "quota exceeded", http.StatusConflict)
+		}
+	}))
+	defer server.Close()
+
+	provider, err := NewExecClient(Options{GetCapacityURL: server.URL + "/capacity", SetCapacityURL: server.URL + "/scale", Token: "secret"})
+	require.NoError(t, err)
+
+	allocated, desired, err := provider.GetCurrentCapacity(context.Background(), "metal")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), allocated)
+	assert.Equal(t, int64(2), desired)
+	require.NoError(t, provider.UpdateASGCapacity(context.Background(), "metal", 3))
+	assert.ErrorContains(t, provider.UpdateASGCapacity(context.Background(), "metal", 6), "webhook answered 409 Conflict: quota exceeded")
+
+	require.Len(t, requests, 3)
+	assert.Equal(t, "metal", requests[0].Asg)
+	assert.Nil(t, requests[0].Capacity)
+	assert.Equal(t, int64(3), *requests[1].Capacity)
+}
@@ -0,0 +1,17 @@
+package exec
+
+import (
+	"net/http"
+	"time"
+)
+
+// ExecClient implements core.Provider by running commands or calling webhooks of an external scaler
+type ExecClient struct {
+	getCapacityCmd string        // Shell command printing the capacity of an ASG
+	setCapacityCmd string        // Shell command setting the capacity of an ASG
+	getCapacityURL string        // Webhook returning the capacity of an ASG
+	setCapacityURL string        // Webhook setting the capacity of an ASG
+	token          string        // Bearer token of the webhooks; none when empty
+	httpClient     *http.Client  // Client of the webhooks
+	requestTimeout time.Duration // Bound of a single command or webhook call; none when 0
+}
@@ -0,0 +1,112 @@
+package gcp
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// Options tune the behavior of the client
+type Options struct {
+	Project         string        // Project of the managed instance groups
+	Zone            string        // Zone of zonal managed instance groups; the groups are regional when empty
+	CredentialsFile string        // Service account key file; the application default credentials when empty
+	EndpointURL     string        // Endpoint of the Compute API instead of the default one, e.g. an emulator
+	RequestTimeout  time.Duration // Bound of a single API call, so that a hung endpoint cannot block a cycle
+}
+
+// Actions of a managed instance that is going away; its instance no longer runs jobs
+var leavingActions = map[string]bool{
+	"ABANDONING": true,
+	"DELETING":   true,
+	"STOPPING":   true,
+	"SUSPENDING": true,
+}
+
+// Statuses of an instance that does not run, although the group has no action scheduled for it
+var stoppedStatuses = map[string]bool{
+	"STOPPED":    true,
+	"SUSPENDED":  true,
+	"TERMINATED": true,
+}
+
+// NewGCPClient creates a client for the managed instance groups of the project in options.Zone, or in the region
+// when no zone is set. Credentials come from the CredentialsFile when set and from the application default
+// credentials otherwise. Every call is bounded by RequestTimeout.
+func NewGCPClient(region string, options Options) (core.Provider, error) {
+	clientOptions := []option.ClientOption{option.WithUserAgent("gitlab-autoscaler")}
+	if options.CredentialsFile != "" {
+		clientOptions = append(clientOptions, option.WithCredentialsFile(options.CredentialsFile))
+	}
+	if options.EndpointURL != "" {
+		clientOptions = append(clientOptions, option.WithEndpoint(options.EndpointURL))
+	}
+	svc, err := compute.NewService(context.Background(), clientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Compute API client: %w", err)
+	}
+
+	return &GCPClient{
+		location:       cmp.Or(options.Zone, region),
+		svc:            computeService{svc: svc, project: options.Project, zone: options.Zone, region: region},
+		requestTimeout: options.RequestTimeout,
+	}, nil
+}
+
+// Location returns the zone or region the client is bound to
+func (c *GCPClient) Location() string {
+	return c.location
+}
+
+// GetCurrentCapacity returns the instances of the managed instance group that run or are being created, and its
+// target size as the desired capacity. Instances being deleted, abandoned, stopped or suspended are not allocated.
+func (c *GCPClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	group, err := c.svc.GetInstanceGroupManager(ctx, asgName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get managed instance group %s: %w", asgName, err)
+	}
+	instances, err := c.svc.ListManagedInstances(ctx, asgName)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list instances of managed instance group %s: %w", asgName, err)
+	}
+
+	var allocatedCount int64
+	for _, instance := range instances {
+		if leavingActions[instance.CurrentAction] {
+			continue
+		}
+		if instance.CurrentAction == "NONE" && stoppedStatuses[instance.InstanceStatus] {
+			continue
+		}
+		allocatedCount++
+	}
+	return allocatedCount, group.TargetSize, nil
+}
+
+// UpdateASGCapacity resizes the managed instance group to the capacity; the group creates or deletes instances
+// in the background
+func (c *GCPClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for managed instance group %s: must not be negative", capacity, asgName)
+	}
+	if capacity > math.MaxInt32 {
+		return fmt.Errorf("cannot set capacity %d for managed instance group %s: exceeds the maximum of %d", capacity, asgName, int64(math.MaxInt32))
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	if err := c.svc.Resize(ctx, asgName, capacity); err != nil {
+		return fmt.Errorf("failed to resize managed instance group %s: %w", asgName, err)
+	}
+	return nil
+}
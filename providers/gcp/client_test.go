@@ -0,0 +1,114 @@
+package gcp
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/api/compute/v1"
+	"google.golang.org/api/option"
+
+	mocks "github.com/shuliakovsky/gitlab-autoscaler/mocks/github.com/shuliakovsky/gitlab-autoscaler/providers/gcp"
+)
+
+// TestGetCurrentCapacity verifies the allocated instances and target size of a managed instance group
+// Expected behavior:
+//   - Running instances and instances being created or verified are allocated
+//   - Instances being deleted or abandoned, and stopped instances without an action, are not
+//   - The target size is the desired capacity
+func TestGetCurrentCapacity(t *testing.T) {
+	mockSvc := &mocks.MockComputeAPI{}
+	mockSvc.On("GetInstanceGroupManager", mock.Anything, "runners").Return(&compute.InstanceGroupManager{TargetSize: 5}, nil)
+	mockSvc.On("ListManagedInstances", mock.Anything, "runners").Return([]*compute.ManagedInstance{
+		{CurrentAction: "NONE", InstanceStatus: "RUNNING"},
+		{CurrentAction: "CREATING"},
+		{CurrentAction: "VERIFYING", InstanceStatus: "RUNNING"},
+		{CurrentAction: "DELETING", InstanceStatus: "RUNNING"},
+		{CurrentAction: "ABANDONING", InstanceStatus: "RUNNING"},
+		{CurrentAction: "NONE", InstanceStatus: "STOPPED"},
+	}, nil)
+
+	client := &GCPClient{svc: mockSvc}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "runners")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), allocated)
+	assert.Equal(t, int64(5), desired)
+	mockSvc.AssertExpectations(t)
+}
+
+// TestGetCurrentCapacity_Error verifies a failed get of the group is returned with its name
+func TestGetCurrentCapacity_Error(t *testing.T) {
+	mockSvc := &mocks.MockComputeAPI{}
+	mockSvc.On("GetInstanceGroupManager", mock.Anything, "runners").Return(nil, errors.New("notFound"))
+
+	client := &GCPClient{svc: mockSvc}
+
+	_, _, err := client.GetCurrentCapacity(context.Background(), "runners")
+	assert.ErrorContains(t, err, "failed to get managed instance group runners: notFound")
+	mockSvc.AssertNotCalled(t, "ListManagedInstances", mock.Anything, mock.Anything)
+}
+
+// TestUpdateASGCapacity verifies capacity changes resize the managed instance group
+// Expected behavior:
+//   - A valid capacity resizes the group
+//   - Negative capacities and capacities beyond int32 are rejected without a call
+//   - A failed resize is returned
+func TestUpdateASGCapacity(t *testing.T) {
+	mockSvc := &mocks.MockComputeAPI{}
+	mockSvc.On("Resize", mock.Anything, "runners", int64(4)).Return(nil).Once()
+	mockSvc.On("Resize", mock.Anything, "runners", int64(6)).Return(errors.New("quotaExceeded")).Once()
+
+	client := &GCPClient{svc: mockSvc}
+
+	assert.NoError(t, client.UpdateASGCapacity(context.Background(), "runners", 4))
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", -1), "must not be negative")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", math.MaxInt32+1), "exceeds the maximum")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "runners", 6), "failed to resize managed instance group runners: quotaExceeded")
+	mockSvc.AssertExpectations(t)
+}
+
+// TestComputeService_Locations verifies zonal and regional groups are reached at their Compute API paths
+// Expected behavior:
+//   - With a zone, the zonal instanceGroupManagers of the zone are used
+//   - Without a zone, the regionInstanceGroupManagers of the region are used
+func TestComputeService_Locations(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+		w.Header().Set("Content-Type", "application/json")
+		switch r.Method {
+		case http.MethodGet:
+			_ = json.NewEncoder(w).Encode(compute.InstanceGroupManager{TargetSize: 2})
+		default:
+			_ = json.NewEncoder(w).Encode(compute.InstanceGroupManagersListManagedInstancesResponse{
+				ManagedInstances: []*compute.ManagedInstance{{CurrentAction: "NONE", InstanceStatus: "RUNNING"}},
+			})
+		}
+	}))
+	defer server.Close()
+	svc, err := compute.NewService(context.Background(), option.WithEndpoint(server.URL+"/"), option.WithoutAuthentication())
+	require.NoError(t, err)
+
+	for _, zone := range []string{"europe-west1-b", ""} {
+		client := &GCPClient{svc: computeService{svc: svc, project: "ci", zone: zone, region: "europe-west1"}}
+		allocated, desired, err := client.GetCurrentCapacity(context.Background(), "runners")
+		require.NoError(t, err)
+		assert.Equal(t, int64(1), allocated)
+		assert.Equal(t, int64(2), desired)
+	}
+
+	assert.Equal(t, []string{
+		"GET /projects/ci/zones/europe-west1-b/instanceGroupManagers/runners",
+		"POST /projects/ci/zones/europe-west1-b/instanceGroupManagers/runners/listManagedInstances",
+		"GET /projects/ci/regions/europe-west1/instanceGroupManagers/runners",
+		"POST /projects/ci/regions/europe-west1/instanceGroupManagers/runners/listManagedInstances",
+	}, paths)
+}
@@ -0,0 +1,50 @@
+package gcp
+
+import (
+	"context"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// computeService implements ComputeAPI with the Compute API client, on the zonal instance group managers when
+// zone is set and on the regional ones otherwise
+type computeService struct {
+	svc     *compute.Service
+	project string
+	zone    string
+	region  string
+}
+
+func (s computeService) GetInstanceGroupManager(ctx context.Context, name string) (*compute.InstanceGroupManager, error) {
+	if s.zone != "" {
+		return s.svc.InstanceGroupManagers.Get(s.project, s.zone, name).Context(ctx).Do()
+	}
+	return s.svc.RegionInstanceGroupManagers.Get(s.project, s.region, name).Context(ctx).Do()
+}
+
+func (s computeService) ListManagedInstances(ctx context.Context, name string) ([]*compute.ManagedInstance, error) {
+	var instances []*compute.ManagedInstance
+	if s.zone != "" {
+		err := s.svc.InstanceGroupManagers.ListManagedInstances(s.project, s.zone, name).
+			Pages(ctx, func(page *compute.InstanceGroupManagersListManagedInstancesResponse) error {
+				instances = append(instances, page.ManagedInstances...)
+				return nil
+			})
+		return instances, err
+	}
+	err := s.svc.RegionInstanceGroupManagers.ListManagedInstances(s.project, s.region, name).
+		Pages(ctx, func(page *compute.RegionInstanceGroupManagersListInstancesResponse) error {
+			instances = append(instances, page.ManagedInstances...)
+			return nil
+		})
+	return instances, err
+}
+
+func (s computeService) Resize(ctx context.Context, name string, size int64) error {
+	if s.zone != "" {
+		_, err := s.svc.InstanceGroupManagers.Resize(s.project, s.zone, name, size).Context(ctx).Do()
+		return err
+	}
+	_, err := s.svc.RegionInstanceGroupManagers.Resize(s.project, s.region, name, size).Context(ctx).Do()
+	return err
+}
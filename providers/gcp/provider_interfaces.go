@@ -0,0 +1,14 @@
+package gcp
+
+import (
+	"context"
+
+	"google.golang.org/api/compute/v1"
+)
+
+// ComputeAPI defines the Compute API operations on the managed instance groups of one project and location
+type ComputeAPI interface {
+	GetInstanceGroupManager(ctx context.Context, name string) (*compute.InstanceGroupManager, error)
+	ListManagedInstances(ctx context.Context, name string) ([]*compute.ManagedInstance, error)
+	Resize(ctx context.Context, name string, size int64) error
+}
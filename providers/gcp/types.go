@@ -0,0 +1,10 @@
+package gcp
+
+import "time"
+
+// GCPClient implements core.Provider for the managed instance groups of a project in a zone or region
+type GCPClient struct {
+	location       string        // Zone of zonal or region of regional managed instance groups
+	svc            ComputeAPI    // Compute API bound to the project and location
+	requestTimeout time.Duration // Bound of a single API call; none when 0
+}
@@ -0,0 +1,126 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+)
+
+// Options tune the behavior of the client
+type Options struct {
+	Kubeconfig     string        // Kubeconfig file of the cluster; the in-cluster config of the service account when empty
+	RequestTimeout time.Duration // Bound of a single API call, so that a hung API server cannot block a cycle
+}
+
+// NewK8sClient creates a client for the Deployments of the cluster of the Kubeconfig, or of the cluster the
+// autoscaler runs in when no Kubeconfig is set. Every call is bounded by RequestTimeout.
+func NewK8sClient(options Options) (core.Provider, error) {
+	var config *rest.Config
+	var err error
+	if options.Kubeconfig != "" {
+		config, err = clientcmd.BuildConfigFromFlags("", options.Kubeconfig)
+	} else {
+		config, err = rest.InClusterConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes configuration: %w", err)
+	}
+	config.UserAgent = "gitlab-autoscaler"
+
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return &K8sClient{clientset: clientset, requestTimeout: options.RequestTimeout}, nil
+}
+
+// SplitName returns the namespace and the Deployment of an ASG name of the form "namespace/deployment"
+func SplitName(asgName string) (string, string, error) {
+	namespace, deployment, ok := strings.Cut(asgName, "/")
+	if !ok || namespace == "" || deployment == "" || strings.Contains(deployment, "/") {
+		return "", "", fmt.Errorf("name %q must be namespace/deployment", asgName)
+	}
+	return namespace, deployment, nil
+}
+
+// GetCurrentCapacity returns the Ready pods of the Deployment that are not being deleted, and spec.replicas as
+// the desired capacity
+func (c *K8sClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	namespace, name, err := SplitName(asgName)
+	if err != nil {
+		return 0, 0, err
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	deployment, err := c.clientset.AppsV1().Deployments(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get Deployment %s: %w", asgName, err)
+	}
+	selector, err := metav1.LabelSelectorAsSelector(deployment.Spec.Selector)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid selector of Deployment %s: %w", asgName, err)
+	}
+	pods, err := c.clientset.CoreV1().Pods(namespace).List(ctx, metav1.ListOptions{LabelSelector: selector.String()})
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to list pods of Deployment %s: %w", asgName, err)
+	}
+
+	var allocatedCount int64
+	for _, pod := range pods.Items {
+		if pod.DeletionTimestamp == nil && podReady(pod) {
+			allocatedCount++
+		}
+	}
+
+	// An unset replica count defaults to 1
+	desiredCapacity := int64(1)
+	if deployment.Spec.Replicas != nil {
+		desiredCapacity = int64(*deployment.Spec.Replicas)
+	}
+	return allocatedCount, desiredCapacity, nil
+}
+
+// podReady reports whether the Ready condition of the pod is true
+func podReady(pod corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// UpdateASGCapacity patches the replicas of the scale subresource of the Deployment
+func (c *K8sClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	namespace, name, err := SplitName(asgName)
+	if err != nil {
+		return err
+	}
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for Deployment %s: must not be negative", capacity, asgName)
+	}
+	if capacity > math.MaxInt32 {
+		return fmt.Errorf("cannot set capacity %d for Deployment %s: exceeds the maximum of %d", capacity, asgName, int64(math.MaxInt32))
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	patch := fmt.Appendf(nil, `{"spec":{"replicas":%d}}`, capacity)
+	_, err = c.clientset.AppsV1().Deployments(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "scale")
+	if err != nil {
+		return fmt.Errorf("failed to scale Deployment %s: %w", asgName, err)
+	}
+	return nil
+}
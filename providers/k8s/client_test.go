@@ -0,0 +1,95 @@
+package k8s
+
+import (
+	"context"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// deployment returns a runner Deployment selecting pods labeled app=<name>
+func deployment(namespace, name string, replicas int32) *appsv1.Deployment {
+	return &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			Selector: &metav1.LabelSelector{MatchLabels: map[string]string{"app": name}},
+		},
+	}
+}
+
+// pod returns a pod labeled app=<app> with the given Ready condition
+func pod(namespace, name, app string, ready corev1.ConditionStatus) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: map[string]string{"app": app}},
+		Status: corev1.PodStatus{
+			Conditions: []corev1.PodCondition{{Type: corev1.PodReady, Status: ready}},
+		},
+	}
+}
+
+// TestGetCurrentCapacity verifies the Ready pods and spec.replicas of a Deployment
+// Expected behavior:
+//   - Ready pods selected by the Deployment are allocated
+//   - Pods not ready yet, pods being deleted and pods of other Deployments are not
+//   - spec.replicas is the desired capacity
+func TestGetCurrentCapacity(t *testing.T) {
+	terminating := pod("ci", "runner-amd64-c", "runner-amd64", corev1.ConditionTrue)
+	terminating.DeletionTimestamp = &metav1.Time{}
+	terminating.Finalizers = []string{"test"}
+	clientset := fake.NewClientset(
+		deployment("ci", "runner-amd64", 4),
+		pod("ci", "runner-amd64-a", "runner-amd64", corev1.ConditionTrue),
+		pod("ci", "runner-amd64-b", "runner-amd64", corev1.ConditionFalse),
+		terminating,
+		pod("ci", "runner-arm64-a", "runner-arm64", corev1.ConditionTrue),
+		pod("other", "runner-amd64-a", "runner-amd64", corev1.ConditionTrue),
+	)
+	client := &K8sClient{clientset: clientset}
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "ci/runner-amd64")
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), allocated)
+	assert.Equal(t, int64(4), desired)
+
+	_, _, err = client.GetCurrentCapacity(context.Background(), "ci/missing")
+	assert.ErrorContains(t, err, "failed to get Deployment ci/missing")
+}
+
+// TestUpdateASGCapacity verifies capacity changes scale the Deployment, down to zero as well
+// Expected behavior:
+//   - The replicas of the Deployment follow the capacity, zero included
+//   - Negative capacities and capacities beyond int32 are rejected
+func TestUpdateASGCapacity(t *testing.T) {
+	clientset := fake.NewClientset(deployment("ci", "runner-amd64", 1))
+	client := &K8sClient{clientset: clientset}
+
+	for _, capacity := range []int64{3, 0} {
+		require.NoError(t, client.UpdateASGCapacity(context.Background(), "ci/runner-amd64", capacity))
+		_, desired, err := client.GetCurrentCapacity(context.Background(), "ci/runner-amd64")
+		require.NoError(t, err)
+		assert.Equal(t, capacity, desired)
+	}
+
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "ci/runner-amd64", -1), "must not be negative")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "ci/runner-amd64", math.MaxInt32+1), "exceeds the maximum")
+}
+
+// TestSplitName verifies ASG names of the k8s provider are namespace/deployment
+func TestSplitName(t *testing.T) {
+	namespace, name, err := SplitName("ci/runner-amd64")
+	require.NoError(t, err)
+	assert.Equal(t, "ci", namespace)
+	assert.Equal(t, "runner-amd64", name)
+
+	for _, invalid := range []string{"runner-amd64", "/runner-amd64", "ci/", "ci/runner/amd64"} {
+		_, _, err := SplitName(invalid)
+		assert.ErrorContains(t, err, "must be namespace/deployment", invalid)
+	}
+}
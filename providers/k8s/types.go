@@ -0,0 +1,13 @@
+package k8s
+
+import (
+	"time"
+
+	"k8s.io/client-go/kubernetes"
+)
+
+// K8sClient implements core.Provider for runner Deployments, named "namespace/deployment"
+type K8sClient struct {
+	clientset      kubernetes.Interface // Clientset of the cluster of the Deployments
+	requestTimeout time.Duration        // Bound of a single API call; none when 0
+}
@@ -0,0 +1,140 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/backoff"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin/pluginpb"
+)
+
+// Options tune the behavior of the client
+type Options struct {
+	Address        string        // unix:///path/to.sock, tcp://host:port or host:port
+	RequestTimeout time.Duration // Bound of a single call, including waiting for the plugin to become reachable
+}
+
+// Reconnects to an unreachable plugin back off from one second up to half a minute
+var reconnectBackoff = backoff.Config{
+	BaseDelay:  time.Second,
+	Multiplier: 1.6,
+	Jitter:     0.2,
+	MaxDelay:   30 * time.Second,
+}
+
+// retryPolicy retries calls failing while the plugin restarts; setting a capacity is idempotent, so both calls are
+// safe to retry
+var retryPolicy = fmt.Sprintf(`{"methodConfig": [{
+	"name": [{"service": %q}],
+	"retryPolicy": {
+		"maxAttempts": 4,
+		"initialBackoff": "0.5s",
+		"maxBackoff": "5s",
+		"backoffMultiplier": 2,
+		"retryableStatusCodes": ["UNAVAILABLE"]
+	}
+}]}`, pluginpb.Provider_ServiceDesc.ServiceName)
+
+// NewPluginClient connects to the plugin serving the provider entry name at options.Address and describes it.
+// The connection is re-established in the background whenever the plugin restarts; calls wait for it until their
+// deadline and are retried when the plugin went away while they were sent. Plugins are local processes, so the connection is not encrypted.
+func NewPluginClient(name string, options Options) (core.Provider, error) {
+	grpcTarget, err := target(options.Address)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: %w", name, err)
+	}
+	conn, err := grpc.NewClient(grpcTarget,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithUserAgent("gitlab-autoscaler"),
+		grpc.WithConnectParams(grpc.ConnectParams{Backoff: reconnectBackoff, MinConnectTimeout: 5 * time.Second}),
+		grpc.WithDefaultCallOptions(grpc.WaitForReady(true)),
+		grpc.WithDefaultServiceConfig(retryPolicy),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to create client for %s: %w", name, options.Address, err)
+	}
+
+	client := &PluginClient{
+		name:           name,
+		address:        options.Address,
+		conn:           conn,
+		svc:            pluginpb.NewProviderClient(conn),
+		requestTimeout: options.RequestTimeout,
+	}
+	description, err := client.describe(context.Background())
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	slog.Info("Provider served by plugin", "provider", name, "plugin", description.GetName(),
+		"version", description.GetVersion(), "address", options.Address)
+	return client, nil
+}
+
+// Close closes the connection to the plugin and stops reconnecting, e.g. once a reload replaced the provider; the
+// client must not be used afterwards
+func (c *PluginClient) Close() error {
+	return c.conn.Close()
+}
+
+// target returns the gRPC target of a plugin address: unix:///path/to.sock as is, tcp://host:port and host:port
+// without name resolution by gRPC
+func target(address string) (string, error) {
+	switch {
+	case strings.HasPrefix(address, "unix://"):
+		return address, nil
+	case strings.HasPrefix(address, "tcp://"):
+		return "passthrough:///" + strings.TrimPrefix(address, "tcp://"), nil
+	case strings.Contains(address, "://"):
+		return "", fmt.Errorf("unsupported address %q: must be unix:///path, tcp://host:port or host:port", address)
+	}
+	return "passthrough:///" + address, nil
+}
+
+// describe asks the plugin what it is, which also checks that it serves the provider service
+func (c *PluginClient) describe(ctx context.Context) (*pluginpb.DescribeResponse, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	description, err := c.svc.Describe(ctx, &pluginpb.DescribeRequest{})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: failed to describe plugin at %s: %w", c.name, c.address, err)
+	}
+	return description, nil
+}
+
+// GetCurrentCapacity asks the plugin for the allocated and desired capacity of the ASG
+func (c *PluginClient) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	capacity, err := c.svc.GetCurrentCapacity(ctx, &pluginpb.GetCurrentCapacityRequest{AsgName: asgName})
+	if err != nil {
+		return 0, 0, fmt.Errorf("plugin %s: failed to get capacity of ASG %s: %w", c.name, asgName, err)
+	}
+	if capacity.GetAllocated() < 0 || capacity.GetDesired() < 0 {
+		return 0, 0, fmt.Errorf("plugin %s: ASG %s: allocated %d and desired %d must not be negative", c.name, asgName, capacity.GetAllocated(), capacity.GetDesired())
+	}
+	return capacity.GetAllocated(), capacity.GetDesired(), nil
+}
+
+// UpdateASGCapacity asks the plugin to set the desired capacity of the ASG
+func (c *PluginClient) UpdateASGCapacity(ctx context.Context, asgName string, capacity int64) error {
+	if capacity < 0 {
+		return fmt.Errorf("cannot set capacity %d for ASG %s: must not be negative", capacity, asgName)
+	}
+	ctx, cancel := core.WithRequestTimeout(ctx, c.requestTimeout)
+	defer cancel()
+
+	if _, err := c.svc.UpdateASGCapacity(ctx, &pluginpb.UpdateASGCapacityRequest{AsgName: asgName, Capacity: capacity}); err != nil {
+		return fmt.Errorf("plugin %s: failed to set capacity %d for ASG %s: %w", c.name, capacity, asgName, err)
+	}
+	return nil
+}
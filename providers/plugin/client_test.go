@@ -0,0 +1,156 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+	"net"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeProvider is an in-memory provider served by the plugin server of the tests
+type fakeProvider struct {
+	mu       sync.Mutex
+	desired  map[string]int64
+	deadline time.Time // Deadline of the last call
+	block    bool      // Calls wait for their context instead of answering
+}
+
+func (p *fakeProvider) GetCurrentCapacity(ctx context.Context, asgName string) (int64, int64, error) {
+	p.mu.Lock()
+	p.deadline, _ = ctx.Deadline()
+	block := p.block
+	p.mu.Unlock()
+	if block {
+		<-ctx.Done()
+		return 0, 0, ctx.Err()
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	desired, ok := p.desired[asgName]
+	if !ok {
+		return 0, 0, errors.New("no such pool")
+	}
+	return desired - 1, desired, nil
+}
+
+func (p *fakeProvider) UpdateASGCapacity(_ context.Context, asgName string, capacity int64) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.desired[asgName] = capacity
+	return nil
+}
+
+// servePlugin serves the provider as a plugin on a unix socket and returns its address
+func servePlugin(t *testing.T, provider *fakeProvider) string {
+	t.Helper()
+	socket := filepath.Join(t.TempDir(), "plugin.sock")
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	server := NewServer("fake", "v1.2.3", provider)
+	go func() { _ = server.Serve(listener) }()
+	t.Cleanup(server.Stop)
+	return "unix://" + socket
+}
+
+// TestPluginClient verifies a provider served by a plugin behaves like a built-in one
+// Expected behavior:
+//   - Capacity is read from and set through the plugin
+//   - Plugin errors are returned with the name of the provider entry and the ASG
+func TestPluginClient(t *testing.T) {
+	provider := &fakeProvider{desired: map[string]int64{"proxmox": 3}}
+	client, err := NewPluginClient("proxmox-plugin", Options{Address: servePlugin(t, provider), RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	allocated, desired, err := client.GetCurrentCapacity(context.Background(), "proxmox")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), allocated)
+	assert.Equal(t, int64(3), desired)
+
+	require.NoError(t, client.UpdateASGCapacity(context.Background(), "proxmox", 5))
+	_, desired, err = client.GetCurrentCapacity(context.Background(), "proxmox")
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), desired)
+
+	_, _, err = client.GetCurrentCapacity(context.Background(), "vsphere")
+	assert.ErrorContains(t, err, "plugin proxmox-plugin: failed to get capacity of ASG vsphere")
+	assert.ErrorContains(t, err, "no such pool")
+	assert.ErrorContains(t, client.UpdateASGCapacity(context.Background(), "proxmox", -1), "must not be negative")
+}
+
+// TestPluginClient_Deadline verifies the deadline of a call reaches the plugin and bounds the call
+func TestPluginClient_Deadline(t *testing.T) {
+	provider := &fakeProvider{desired: map[string]int64{"proxmox": 1}}
+	client, err := NewPluginClient("proxmox-plugin", Options{Address: servePlugin(t, provider), RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	provider.mu.Lock()
+	provider.block = true
+	provider.mu.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, _, err = client.GetCurrentCapacity(ctx, "proxmox")
+	assert.ErrorContains(t, err, "DeadlineExceeded")
+	assert.Less(t, time.Since(start), 3*time.Second)
+
+	provider.mu.Lock()
+	defer provider.mu.Unlock()
+	expected, _ := ctx.Deadline()
+	assert.WithinDuration(t, expected, provider.deadline, 100*time.Millisecond)
+}
+
+// TestPluginClient_Reconnect verifies calls wait for a restarted plugin instead of failing
+func TestPluginClient_Reconnect(t *testing.T) {
+	provider := &fakeProvider{desired: map[string]int64{"proxmox": 2}}
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	address := listener.Addr().String()
+	server := NewServer("fake", "v1", provider)
+	go func() { _ = server.Serve(listener) }()
+
+	client, err := NewPluginClient("proxmox-plugin", Options{Address: "tcp://" + address, RequestTimeout: 10 * time.Second})
+	require.NoError(t, err)
+	server.Stop()
+
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		restarted, err := net.Listen("tcp", address)
+		if !assert.NoError(t, err) {
+			return
+		}
+		server := NewServer("fake", "v1", provider)
+		t.Cleanup(server.Stop)
+		_ = server.Serve(restarted)
+	}()
+
+	_, desired, err := client.GetCurrentCapacity(context.Background(), "proxmox")
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), desired)
+}
+
+// TestPluginClient_Close verifies a closed client gives up its connection, so calls fail instead of reconnecting
+func TestPluginClient_Close(t *testing.T) {
+	provider := &fakeProvider{desired: map[string]int64{"proxmox": 2}}
+	client, err := NewPluginClient("proxmox-plugin", Options{Address: servePlugin(t, provider), RequestTimeout: 5 * time.Second})
+	require.NoError(t, err)
+
+	require.NoError(t, client.(*PluginClient).Close())
+	_, _, err = client.GetCurrentCapacity(context.Background(), "proxmox")
+	assert.ErrorContains(t, err, "Canceled")
+}
+
+// TestNewPluginClient_Unreachable verifies a plugin that does not answer fails the provider entry by name
+func TestNewPluginClient_Unreachable(t *testing.T) {
+	_, err := NewPluginClient("proxmox-plugin", Options{Address: "unix://" + filepath.Join(t.TempDir(), "missing.sock"), RequestTimeout: 200 * time.Millisecond})
+	assert.ErrorContains(t, err, "plugin proxmox-plugin: failed to describe plugin at unix://")
+
+	_, err = NewPluginClient("proxmox-plugin", Options{Address: "http://localhost:9000"})
+	assert.ErrorContains(t, err, `plugin proxmox-plugin: unsupported address "http://localhost:9000"`)
+}
@@ -0,0 +1,379 @@
+// Protocol between gitlab-autoscaler and out-of-tree provider plugins. A plugin serves the Provider service on a
+// unix socket or TCP address; the autoscaler calls it like a built-in provider.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: provider.proto
+
+package pluginpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type DescribeRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeRequest) Reset() {
+	*x = DescribeRequest{}
+	mi := &file_provider_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeRequest) ProtoMessage() {}
+
+func (x *DescribeRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeRequest.ProtoReflect.Descriptor instead.
+func (*DescribeRequest) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{0}
+}
+
+type DescribeResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`       // Name of the plugin, e.g. "proxmox"
+	Version       string                 `protobuf:"bytes,2,opt,name=version,proto3" json:"version,omitempty"` // Version of the plugin
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DescribeResponse) Reset() {
+	*x = DescribeResponse{}
+	mi := &file_provider_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DescribeResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DescribeResponse) ProtoMessage() {}
+
+func (x *DescribeResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DescribeResponse.ProtoReflect.Descriptor instead.
+func (*DescribeResponse) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *DescribeResponse) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *DescribeResponse) GetVersion() string {
+	if x != nil {
+		return x.Version
+	}
+	return ""
+}
+
+type GetCurrentCapacityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AsgName       string                 `protobuf:"bytes,1,opt,name=asg_name,json=asgName,proto3" json:"asg_name,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentCapacityRequest) Reset() {
+	*x = GetCurrentCapacityRequest{}
+	mi := &file_provider_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentCapacityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentCapacityRequest) ProtoMessage() {}
+
+func (x *GetCurrentCapacityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentCapacityRequest.ProtoReflect.Descriptor instead.
+func (*GetCurrentCapacityRequest) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetCurrentCapacityRequest) GetAsgName() string {
+	if x != nil {
+		return x.AsgName
+	}
+	return ""
+}
+
+type GetCurrentCapacityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Allocated     int64                  `protobuf:"varint,1,opt,name=allocated,proto3" json:"allocated,omitempty"` // Instances that run or are being created
+	Desired       int64                  `protobuf:"varint,2,opt,name=desired,proto3" json:"desired,omitempty"`     // Desired capacity of the ASG
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCurrentCapacityResponse) Reset() {
+	*x = GetCurrentCapacityResponse{}
+	mi := &file_provider_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCurrentCapacityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCurrentCapacityResponse) ProtoMessage() {}
+
+func (x *GetCurrentCapacityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCurrentCapacityResponse.ProtoReflect.Descriptor instead.
+func (*GetCurrentCapacityResponse) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GetCurrentCapacityResponse) GetAllocated() int64 {
+	if x != nil {
+		return x.Allocated
+	}
+	return 0
+}
+
+func (x *GetCurrentCapacityResponse) GetDesired() int64 {
+	if x != nil {
+		return x.Desired
+	}
+	return 0
+}
+
+type UpdateASGCapacityRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	AsgName       string                 `protobuf:"bytes,1,opt,name=asg_name,json=asgName,proto3" json:"asg_name,omitempty"`
+	Capacity      int64                  `protobuf:"varint,2,opt,name=capacity,proto3" json:"capacity,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateASGCapacityRequest) Reset() {
+	*x = UpdateASGCapacityRequest{}
+	mi := &file_provider_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateASGCapacityRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateASGCapacityRequest) ProtoMessage() {}
+
+func (x *UpdateASGCapacityRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateASGCapacityRequest.ProtoReflect.Descriptor instead.
+func (*UpdateASGCapacityRequest) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *UpdateASGCapacityRequest) GetAsgName() string {
+	if x != nil {
+		return x.AsgName
+	}
+	return ""
+}
+
+func (x *UpdateASGCapacityRequest) GetCapacity() int64 {
+	if x != nil {
+		return x.Capacity
+	}
+	return 0
+}
+
+type UpdateASGCapacityResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateASGCapacityResponse) Reset() {
+	*x = UpdateASGCapacityResponse{}
+	mi := &file_provider_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateASGCapacityResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateASGCapacityResponse) ProtoMessage() {}
+
+func (x *UpdateASGCapacityResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_provider_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateASGCapacityResponse.ProtoReflect.Descriptor instead.
+func (*UpdateASGCapacityResponse) Descriptor() ([]byte, []int) {
+	return file_provider_proto_rawDescGZIP(), []int{5}
+}
+
+var File_provider_proto protoreflect.FileDescriptor
+
+const file_provider_proto_rawDesc = "" +
+	"\n" +
+	"\x0eprovider.proto\x12\x1agitlabautoscaler.plugin.v1\"\x11\n" +
+	"\x0fDescribeRequest\"@\n" +
+	"\x10DescribeResponse\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\tR\aversion\"6\n" +
+	"\x19GetCurrentCapacityRequest\x12\x19\n" +
+	"\basg_name\x18\x01 \x01(\tR\aasgName\"T\n" +
+	"\x1aGetCurrentCapacityResponse\x12\x1c\n" +
+	"\tallocated\x18\x01 \x01(\x03R\tallocated\x12\x18\n" +
+	"\adesired\x18\x02 \x01(\x03R\adesired\"Q\n" +
+	"\x18UpdateASGCapacityRequest\x12\x19\n" +
+	"\basg_name\x18\x01 \x01(\tR\aasgName\x12\x1a\n" +
+	"\bcapacity\x18\x02 \x01(\x03R\bcapacity\"\x1b\n" +
+	"\x19UpdateASGCapacityResponse2\xfa\x02\n" +
+	"\bProvider\x12e\n" +
+	"\bDescribe\x12+.gitlabautoscaler.plugin.v1.DescribeRequest\x1a,.gitlabautoscaler.plugin.v1.DescribeResponse\x12\x83\x01\n" +
+	"\x12GetCurrentCapacity\x125.gitlabautoscaler.plugin.v1.GetCurrentCapacityRequest\x1a6.gitlabautoscaler.plugin.v1.GetCurrentCapacityResponse\x12\x80\x01\n" +
+	"\x11UpdateASGCapacity\x124.gitlabautoscaler.plugin.v1.UpdateASGCapacityRequest\x1a5.gitlabautoscaler.plugin.v1.UpdateASGCapacityResponseBEZCgithub.com/shuliakovsky/gitlab-autoscaler/providers/plugin/pluginpbb\x06proto3"
+
+var (
+	file_provider_proto_rawDescOnce sync.Once
+	file_provider_proto_rawDescData []byte
+)
+
+func file_provider_proto_rawDescGZIP() []byte {
+	file_provider_proto_rawDescOnce.Do(func() {
+		file_provider_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_provider_proto_rawDesc), len(file_provider_proto_rawDesc)))
+	})
+	return file_provider_proto_rawDescData
+}
+
+var file_provider_proto_msgTypes = make([]protoimpl.MessageInfo, 6)
+var file_provider_proto_goTypes = []any{
+	(*DescribeRequest)(nil),            // 0: gitlabautoscaler.plugin.v1.DescribeRequest
+	(*DescribeResponse)(nil),           // 1: gitlabautoscaler.plugin.v1.DescribeResponse
+	(*GetCurrentCapacityRequest)(nil),  // 2: gitlabautoscaler.plugin.v1.GetCurrentCapacityRequest
+	(*GetCurrentCapacityResponse)(nil), // 3: gitlabautoscaler.plugin.v1.GetCurrentCapacityResponse
+	(*UpdateASGCapacityRequest)(nil),   // 4: gitlabautoscaler.plugin.v1.UpdateASGCapacityRequest
+	(*UpdateASGCapacityResponse)(nil),  // 5: gitlabautoscaler.plugin.v1.UpdateASGCapacityResponse
+}
+var file_provider_proto_depIdxs = []int32{
+	0, // 0: gitlabautoscaler.plugin.v1.Provider.Describe:input_type -> gitlabautoscaler.plugin.v1.DescribeRequest
+	2, // 1: gitlabautoscaler.plugin.v1.Provider.GetCurrentCapacity:input_type -> gitlabautoscaler.plugin.v1.GetCurrentCapacityRequest
+	4, // 2: gitlabautoscaler.plugin.v1.Provider.UpdateASGCapacity:input_type -> gitlabautoscaler.plugin.v1.UpdateASGCapacityRequest
+	1, // 3: gitlabautoscaler.plugin.v1.Provider.Describe:output_type -> gitlabautoscaler.plugin.v1.DescribeResponse
+	3, // 4: gitlabautoscaler.plugin.v1.Provider.GetCurrentCapacity:output_type -> gitlabautoscaler.plugin.v1.GetCurrentCapacityResponse
+	5, // 5: gitlabautoscaler.plugin.v1.Provider.UpdateASGCapacity:output_type -> gitlabautoscaler.plugin.v1.UpdateASGCapacityResponse
+	3, // [3:6] is the sub-list for method output_type
+	0, // [0:3] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_provider_proto_init() }
+func file_provider_proto_init() {
+	if File_provider_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_provider_proto_rawDesc), len(file_provider_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   6,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_provider_proto_goTypes,
+		DependencyIndexes: file_provider_proto_depIdxs,
+		MessageInfos:      file_provider_proto_msgTypes,
+	}.Build()
+	File_provider_proto = out.File
+	file_provider_proto_goTypes = nil
+	file_provider_proto_depIdxs = nil
+}
@@ -0,0 +1,212 @@
+// Protocol between gitlab-autoscaler and out-of-tree provider plugins. A plugin serves the Provider service on a
+// unix socket or TCP address; the autoscaler calls it like a built-in provider.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: provider.proto
+
+package pluginpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Provider_Describe_FullMethodName           = "/gitlabautoscaler.plugin.v1.Provider/Describe"
+	Provider_GetCurrentCapacity_FullMethodName = "/gitlabautoscaler.plugin.v1.Provider/GetCurrentCapacity"
+	Provider_UpdateASGCapacity_FullMethodName  = "/gitlabautoscaler.plugin.v1.Provider/UpdateASGCapacity"
+)
+
+// ProviderClient is the client API for Provider service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Provider mirrors core.Provider. Every call carries the deadline of the autoscaler; a plugin should give up once
+// it has passed.
+type ProviderClient interface {
+	// Describe returns what the plugin is; it is called once when the autoscaler connects
+	Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error)
+	// GetCurrentCapacity returns the allocated and desired capacity of an ASG
+	GetCurrentCapacity(ctx context.Context, in *GetCurrentCapacityRequest, opts ...grpc.CallOption) (*GetCurrentCapacityResponse, error)
+	// UpdateASGCapacity sets the desired capacity of an ASG
+	UpdateASGCapacity(ctx context.Context, in *UpdateASGCapacityRequest, opts ...grpc.CallOption) (*UpdateASGCapacityResponse, error)
+}
+
+type providerClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewProviderClient(cc grpc.ClientConnInterface) ProviderClient {
+	return &providerClient{cc}
+}
+
+func (c *providerClient) Describe(ctx context.Context, in *DescribeRequest, opts ...grpc.CallOption) (*DescribeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DescribeResponse)
+	err := c.cc.Invoke(ctx, Provider_Describe_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) GetCurrentCapacity(ctx context.Context, in *GetCurrentCapacityRequest, opts ...grpc.CallOption) (*GetCurrentCapacityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCurrentCapacityResponse)
+	err := c.cc.Invoke(ctx, Provider_GetCurrentCapacity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *providerClient) UpdateASGCapacity(ctx context.Context, in *UpdateASGCapacityRequest, opts ...grpc.CallOption) (*UpdateASGCapacityResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UpdateASGCapacityResponse)
+	err := c.cc.Invoke(ctx, Provider_UpdateASGCapacity_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ProviderServer is the server API for Provider service.
+// All implementations must embed UnimplementedProviderServer
+// for forward compatibility.
+//
+// Provider mirrors core.Provider. Every call carries the deadline of the autoscaler; a plugin should give up once
+// it has passed.
+type ProviderServer interface {
+	// Describe returns what the plugin is; it is called once when the autoscaler connects
+	Describe(context.Context, *DescribeRequest) (*DescribeResponse, error)
+	// GetCurrentCapacity returns the allocated and desired capacity of an ASG
+	GetCurrentCapacity(context.Context, *GetCurrentCapacityRequest) (*GetCurrentCapacityResponse, error)
+	// UpdateASGCapacity sets the desired capacity of an ASG
+	UpdateASGCapacity(context.Context, *UpdateASGCapacityRequest) (*UpdateASGCapacityResponse, error)
+	mustEmbedUnimplementedProviderServer()
+}
+
+// UnimplementedProviderServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedProviderServer struct{}
+
+func (UnimplementedProviderServer) Describe(context.Context, *DescribeRequest) (*DescribeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Describe not implemented")
+}
+func (UnimplementedProviderServer) GetCurrentCapacity(context.Context, *GetCurrentCapacityRequest) (*GetCurrentCapacityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCurrentCapacity not implemented")
+}
+func (UnimplementedProviderServer) UpdateASGCapacity(context.Context, *UpdateASGCapacityRequest) (*UpdateASGCapacityResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateASGCapacity not implemented")
+}
+func (UnimplementedProviderServer) mustEmbedUnimplementedProviderServer() {}
+func (UnimplementedProviderServer) testEmbeddedByValue()                  {}
+
+// UnsafeProviderServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ProviderServer will
+// result in compilation errors.
+type UnsafeProviderServer interface {
+	mustEmbedUnimplementedProviderServer()
+}
+
+func RegisterProviderServer(s grpc.ServiceRegistrar, srv ProviderServer) {
+	// If the following call pancis, it indicates UnimplementedProviderServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Provider_ServiceDesc, srv)
+}
+
+func _Provider_Describe_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DescribeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).Describe(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Provider_Describe_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).Describe(ctx, req.(*DescribeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_GetCurrentCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCurrentCapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).GetCurrentCapacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Provider_GetCurrentCapacity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).GetCurrentCapacity(ctx, req.(*GetCurrentCapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Provider_UpdateASGCapacity_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateASGCapacityRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ProviderServer).UpdateASGCapacity(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Provider_UpdateASGCapacity_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ProviderServer).UpdateASGCapacity(ctx, req.(*UpdateASGCapacityRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Provider_ServiceDesc is the grpc.ServiceDesc for Provider service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Provider_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gitlabautoscaler.plugin.v1.Provider",
+	HandlerType: (*ProviderServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Describe",
+			Handler:    _Provider_Describe_Handler,
+		},
+		{
+			MethodName: "GetCurrentCapacity",
+			Handler:    _Provider_GetCurrentCapacity_Handler,
+		},
+		{
+			MethodName: "UpdateASGCapacity",
+			Handler:    _Provider_UpdateASGCapacity_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "provider.proto",
+}
@@ -0,0 +1,57 @@
+package plugin
+
+import (
+	"context"
+	"errors"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/core"
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin/pluginpb"
+)
+
+// NewServer returns a gRPC server serving the provider as a plugin named name. A plugin written in Go implements
+// core.Provider and serves it on its socket with NewServer(...).Serve(listener).
+func NewServer(name, version string, provider core.Provider) *grpc.Server {
+	server := grpc.NewServer()
+	pluginpb.RegisterProviderServer(server, &providerServer{name: name, version: version, provider: provider})
+	return server
+}
+
+// providerServer implements the provider service on top of a core.Provider
+type providerServer struct {
+	pluginpb.UnimplementedProviderServer
+	name     string
+	version  string
+	provider core.Provider
+}
+
+func (s *providerServer) Describe(context.Context, *pluginpb.DescribeRequest) (*pluginpb.DescribeResponse, error) {
+	return &pluginpb.DescribeResponse{Name: s.name, Version: s.version}, nil
+}
+
+func (s *providerServer) GetCurrentCapacity(ctx context.Context, request *pluginpb.GetCurrentCapacityRequest) (*pluginpb.GetCurrentCapacityResponse, error) {
+	allocated, desired, err := s.provider.GetCurrentCapacity(ctx, request.GetAsgName())
+	if err != nil {
+		return nil, statusError(err)
+	}
+	return &pluginpb.GetCurrentCapacityResponse{Allocated: allocated, Desired: desired}, nil
+}
+
+func (s *providerServer) UpdateASGCapacity(ctx context.Context, request *pluginpb.UpdateASGCapacityRequest) (*pluginpb.UpdateASGCapacityResponse, error) {
+	if err := s.provider.UpdateASGCapacity(ctx, request.GetAsgName(), request.GetCapacity()); err != nil {
+		return nil, statusError(err)
+	}
+	return &pluginpb.UpdateASGCapacityResponse{}, nil
+}
+
+// statusError returns the gRPC status of a provider error: the status of a passed deadline or cancellation, the
+// message as an unknown error otherwise
+func statusError(err error) error {
+	if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+		return status.FromContextError(err).Err()
+	}
+	return status.Error(codes.Unknown, err.Error())
+}
@@ -0,0 +1,18 @@
+package plugin
+
+import (
+	"time"
+
+	"google.golang.org/grpc"
+
+	"github.com/shuliakovsky/gitlab-autoscaler/providers/plugin/pluginpb"
+)
+
+// PluginClient implements core.Provider by calling a provider plugin over gRPC
+type PluginClient struct {
+	name           string                  // Name of the provider entry, used in errors
+	address        string                  // Address of the plugin as configured
+	conn           *grpc.ClientConn        // Connection to the plugin, closed by Close
+	svc            pluginpb.ProviderClient // Provider service of the plugin
+	requestTimeout time.Duration           // Bound of a single call; none when 0
+}
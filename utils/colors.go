@@ -1,11 +0,0 @@
-package utils
-
-const (
-	Reset     = "\033[0m"
-	Red       = "\033[31m"
-	Green     = "\033[32m"
-	Yellow    = "\033[33m"
-	Cyan      = "\033[36m"
-	Magenta   = "\033[35m"
-	LightGray = "\033[37m"
-)
@@ -1,27 +1,137 @@
 package utils
 
 import (
+	"bytes"
+	"context"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
 )
 
-var logger = log.New(log.Writer(), "", log.LstdFlags)
+// Log formats accepted by --log-format
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// JSONTimeKey is the key of the time of JSON records, as log aggregators such as Loki expect it
+const JSONTimeKey = "ts"
+
+// ANSI colors of text log lines by level
+const (
+	red       = "\033[31m"
+	yellow    = "\033[33m"
+	lightGray = "\033[37m"
+	reset     = "\033[0m"
+)
+
+// LogOptions configures the logger installed by SetupLogging
+type LogOptions struct {
+	Level  slog.Level // Records below the level are dropped
+	Format string     // LogFormatText or LogFormatJSON
+	Color  bool       // Color text lines by level; ignored for JSON
+}
+
+// ParseLogLevel parses a --log-level value: debug, info, warn or error
+func ParseLogLevel(value string) (slog.Level, error) {
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(value)); err != nil {
+		return 0, fmt.Errorf("invalid log level %q: expected debug, info, warn or error", value)
+	}
+	return level, nil
+}
+
+// NewLogHandler returns the handler writing records to w as configured by opts
+func NewLogHandler(w io.Writer, opts LogOptions) (slog.Handler, error) {
+	handlerOpts := &slog.HandlerOptions{Level: opts.Level}
+	switch strings.ToLower(opts.Format) {
+	case LogFormatJSON:
+		handlerOpts.ReplaceAttr = renameTime
+		return slog.NewJSONHandler(w, handlerOpts), nil
+	case LogFormatText, "":
+		if !opts.Color {
+			return slog.NewTextHandler(w, handlerOpts), nil
+		}
+		buf := &bytes.Buffer{}
+		return &colorHandler{Handler: slog.NewTextHandler(buf, handlerOpts), out: w, buf: buf, mu: &sync.Mutex{}}, nil
+	default:
+		return nil, fmt.Errorf("invalid log format %q: expected %s or %s", opts.Format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// SetupLogging makes a logger writing to w the default of slog and of the log package, so that libraries logging
+// with log.Printf end up in the same format
+func SetupLogging(w io.Writer, opts LogOptions) error {
+	handler, err := NewLogHandler(w, opts)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+// renameTime names the time of a record JSONTimeKey
+func renameTime(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) == 0 && attr.Key == slog.TimeKey {
+		attr.Key = JSONTimeKey
+	}
+	return attr
+}
 
-func Log(message string) {
-	logger.Println(message)
+// IsTerminal reports whether f is a terminal rather than a pipe, file or journald socket
+func IsTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
 }
-func LogRed(message string) {
-	fmt.Printf("%s%s%s\n", Red, message, Reset)
+
+// colorHandler colors the lines of a text handler by level. The text handler and those derived from it write to
+// the shared buf, which is flushed to out under mu with the color of the record
+type colorHandler struct {
+	slog.Handler
+	out io.Writer
+	buf *bytes.Buffer
+	mu  *sync.Mutex
+}
+
+func (h *colorHandler) Handle(ctx context.Context, record slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.buf.Reset()
+	if err := h.Handler.Handle(ctx, record); err != nil {
+		return err
+	}
+	line := bytes.TrimSuffix(h.buf.Bytes(), []byte("\n"))
+	color := levelColor(record.Level)
+	if color == "" {
+		_, err := fmt.Fprintf(h.out, "%s\n", line)
+		return err
+	}
+	_, err := fmt.Fprintf(h.out, "%s%s%s\n", color, line, reset)
+	return err
 }
 
-func LogGreen(message string) {
-	fmt.Printf("%s%s%s\n", Green, message, Reset)
+func (h *colorHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &colorHandler{Handler: h.Handler.WithAttrs(attrs), out: h.out, buf: h.buf, mu: h.mu}
 }
 
-func LogYellow(message string) {
-	fmt.Printf("%s%s%s\n", Yellow, message, Reset)
+func (h *colorHandler) WithGroup(name string) slog.Handler {
+	return &colorHandler{Handler: h.Handler.WithGroup(name), out: h.out, buf: h.buf, mu: h.mu}
 }
 
-func LogCyan(message string) {
-	fmt.Printf("%s%s%s\n", Cyan, message, Reset)
+// levelColor returns the color of the lines of a level, none for info
+func levelColor(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return red
+	case level >= slog.LevelWarn:
+		return yellow
+	case level < slog.LevelInfo:
+		return lightGray
+	default:
+		return ""
+	}
 }
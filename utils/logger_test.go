@@ -0,0 +1,86 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestParseLogLevel verifies the --log-level values
+// Expected behavior:
+//   - debug, info, warn and error are accepted in any case
+//   - Anything else is rejected with the accepted values
+func TestParseLogLevel(t *testing.T) {
+	for value, want := range map[string]slog.Level{"debug": slog.LevelDebug, "info": slog.LevelInfo, "WARN": slog.LevelWarn, "error": slog.LevelError} {
+		level, err := ParseLogLevel(value)
+		require.NoError(t, err, value)
+		assert.Equal(t, want, level, value)
+	}
+
+	_, err := ParseLogLevel("verbose")
+	assert.ErrorContains(t, err, "expected debug, info, warn or error")
+}
+
+// TestNewLogHandler_JSON verifies JSON records carry the time, level, message and fields, escaped
+// Expected behavior:
+//   - The time is named ts
+//   - Records below the level are dropped
+//   - Fields of With are included; a hostile value stays inside its JSON string
+func TestNewLogHandler_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewLogHandler(&buf, LogOptions{Level: slog.LevelInfo, Format: LogFormatJSON})
+	require.NoError(t, err)
+	logger := slog.New(handler).With("asg", "prod\n2024/05/06 09:00:00 forged")
+
+	logger.Debug("Processing ASG")
+	logger.Info("Scaling up", "desired", 3)
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Len(t, lines, 1)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.NotEmpty(t, record["ts"])
+	assert.NotContains(t, record, "time")
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, "Scaling up", record["msg"])
+	assert.Equal(t, "prod\n2024/05/06 09:00:00 forged", record["asg"])
+	assert.EqualValues(t, 3, record["desired"])
+}
+
+// TestNewLogHandler_Color verifies text lines are colored by level only when asked to
+// Expected behavior:
+//   - Warnings are yellow, errors red, info lines are plain; loggers derived with With share the output
+//   - Without color no escape sequence is written
+//   - Unknown formats are rejected
+func TestNewLogHandler_Color(t *testing.T) {
+	var buf bytes.Buffer
+	handler, err := NewLogHandler(&buf, LogOptions{Level: slog.LevelInfo, Format: LogFormatText, Color: true})
+	require.NoError(t, err)
+	logger := slog.New(handler)
+
+	logger.Info("Processing ASG")
+	logger.With("asg", "prod").Warn("Runners missing")
+	logger.Error("Scale-up failed")
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	require.Len(t, lines, 3)
+	assert.NotContains(t, lines[0], "\033[")
+	assert.True(t, strings.HasPrefix(lines[1], yellow), lines[1])
+	assert.True(t, strings.HasSuffix(lines[1], reset), lines[1])
+	assert.Contains(t, lines[1], "asg=prod")
+	assert.True(t, strings.HasPrefix(lines[2], red), lines[2])
+
+	buf.Reset()
+	handler, err = NewLogHandler(&buf, LogOptions{Level: slog.LevelInfo, Format: LogFormatText})
+	require.NoError(t, err)
+	slog.New(handler).Error("Scale-up failed")
+	assert.NotContains(t, buf.String(), "\033[")
+
+	_, err = NewLogHandler(&buf, LogOptions{Format: "logfmt"})
+	assert.ErrorContains(t, err, `invalid log format "logfmt"`)
+}
@@ -0,0 +1,80 @@
+package utils
+
+import (
+	"strings"
+	"unicode"
+)
+
+// MaxLogFieldLength caps the runes of an externally sourced string in a log line
+const MaxLogFieldLength = 200
+
+// escapedRunes are shown escaped rather than dropped, since they are likely meaningful in a name or message
+var escapedRunes = map[rune]string{
+	'\n':     `\n`,
+	'\r':     `\r`,
+	'\t':     `\t`,
+	'\u2028': `\u2028`, // Line separator
+	'\u2029': `\u2029`, // Paragraph separator
+}
+
+// Safe makes an externally sourced string (project, tag, runner or ASG name, remote error message) safe to
+// interpolate into a log line: line breaks and tabs are escaped, other control characters (including the ESC of
+// ANSI sequences) and bidirectional formatting characters are dropped, invalid UTF-8 is replaced (by ranging over runes) and the result
+// is capped at MaxLogFieldLength runes. Printable text in any script is kept as is.
+func Safe(s string) string {
+	if isPlainASCII(s) {
+		return s
+	}
+
+	var b strings.Builder
+	runes := 0
+	for _, r := range s {
+		if runes == MaxLogFieldLength {
+			b.WriteString("...")
+			break
+		}
+		runes++
+		if escaped, ok := escapedRunes[r]; ok {
+			b.WriteString(escaped)
+			continue
+		}
+		if unicode.IsControl(r) || unicode.Is(unicode.Bidi_Control, r) {
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SafeList applies Safe to every element, e.g. to log a tag list with %v
+func SafeList(values []string) []string {
+	if values == nil {
+		return nil
+	}
+	safe := make([]string, len(values))
+	for i, value := range values {
+		safe[i] = Safe(value)
+	}
+	return safe
+}
+
+// SafeError returns the message of err made safe by Safe; errors often quote remote responses
+func SafeError(err error) string {
+	if err == nil {
+		return "<nil>"
+	}
+	return Safe(err.Error())
+}
+
+// isPlainASCII reports whether s is short printable ASCII, which Safe returns unchanged
+func isPlainASCII(s string) bool {
+	if len(s) > MaxLogFieldLength {
+		return false
+	}
+	for i := 0; i < len(s); i++ {
+		if s[i] < 0x20 || s[i] >= 0x7f {
+			return false
+		}
+	}
+	return true
+}
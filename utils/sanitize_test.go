@@ -0,0 +1,70 @@
+package utils
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestSafe verifies hostile names cannot forge log lines or inject terminal escapes
+// Expected behavior:
+//   - Newlines, carriage returns and tabs are escaped; Unicode line separators too
+//   - ANSI escape sequences lose their ESC and other control characters are dropped
+//   - Bidirectional formatting characters are dropped
+//   - Invalid UTF-8 is replaced, names in any script are kept
+//   - Plain names are returned unchanged
+func TestSafe(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"plain", "runner-amd64", "runner-amd64"},
+		{"forged entry", "app\n2024/05/06 09:00:00 Scaling up ASG: prod", `app\n2024/05/06 09:00:00 Scaling up ASG: prod`},
+		{"carriage return and tab", "app\r\tx", `app\r\tx`},
+		{"ansi", "\x1b[31mred\x1b[0m", "[31mred[0m"},
+		{"control", "a\x00b\x07c\x7fd\u0085e", "abcde"},
+		{"bidi override", "evil\u202egnp.exe", "evilgnp.exe"},
+		{"line separator", "a\u2028b", `a\u2028b`},
+		{"international", "проект-ビルド-😀", "проект-ビルド-😀"},
+		{"invalid utf-8", "a\xffb", "a�b"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Safe(tt.input))
+		})
+	}
+}
+
+// TestSafe_Length verifies long values are capped in runes, not bytes
+func TestSafe_Length(t *testing.T) {
+	safe := Safe(strings.Repeat("ж", MaxLogFieldLength+50))
+
+	assert.Equal(t, MaxLogFieldLength+len("..."), utf8.RuneCountInString(safe))
+	assert.True(t, strings.HasSuffix(safe, "..."))
+	assert.True(t, utf8.ValidString(safe))
+	assert.Equal(t, strings.Repeat("a", MaxLogFieldLength), Safe(strings.Repeat("a", MaxLogFieldLength)))
+}
+
+// TestSafe_JSON verifies sanitized values encode to escape-free single-line JSON strings
+func TestSafe_JSON(t *testing.T) {
+	encoded, err := json.Marshal(map[string]string{"project": Safe("app\n\x1b[2Jforged")})
+
+	assert.NoError(t, err)
+	assert.NotContains(t, string(encoded), "\n")
+	assert.NotContains(t, string(encoded), `\u001b`)
+	assert.JSONEq(t, `{"project":"app\\n[2Jforged"}`, string(encoded))
+}
+
+// TestSafeListAndError verifies the list and error helpers apply Safe
+func TestSafeListAndError(t *testing.T) {
+	assert.Equal(t, []string{`a\nb`, "c"}, SafeList([]string{"a\nb", "c"}))
+	assert.Nil(t, SafeList(nil))
+	assert.Equal(t, `status=404 \n forged`, SafeError(errors.New("status=404 \n forged")))
+	assert.Equal(t, "<nil>", SafeError(nil))
+}